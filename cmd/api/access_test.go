@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Soundveyve/fiber-backend/internal/routes"
+)
+
+// TestAnonymousAccess проверяет, что административные роуты и роуты,
+// которые отдают данные всех пользователей, без access токена отвечают 401
+func TestAnonymousAccess(t *testing.T) {
+	app, _, _ := newContractApp(t)
+
+	tests := []struct {
+		method, path string
+	}{
+		{fiber.MethodPost, "/admin/v1/users/import"},
+		{fiber.MethodPost, "/api/v1/exports"},
+		{fiber.MethodGet, "/api/v1/exports/1"},
+		// Полный профиль с email и телефоном - только владельцу и администратору
+		{fiber.MethodGet, "/api/v1/users/" + uuid.NewString()},
+		// Группа /admin/v1 целиком требует роль администратора
+		{fiber.MethodGet, "/admin/v1/users/" + uuid.NewString() + "/stats"},
+		{fiber.MethodPost, "/admin/v1/users/" + uuid.NewString() + "/merge"},
+		{fiber.MethodPost, "/admin/v1/announcements"},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader("{}"))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("%s %s: %v", tc.method, tc.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusUnauthorized {
+			t.Errorf("%s %s без токена: %d, ожидался 401", tc.method, tc.path, resp.StatusCode)
+		}
+	}
+}
+
+// TestProtectedRoutes проверяет по GET /openapi.json, что каждый роут под
+// префиксами protectedRoutes описан с обязательным для них scope
+func TestProtectedRoutes(t *testing.T) {
+	app, _, _ := newContractApp(t)
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/openapi.json", nil), -1)
+	if err != nil {
+		t.Fatalf("GET /openapi.json: %v", err)
+	}
+	defer resp.Body.Close()
+	var doc routes.Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("GET /openapi.json: %v", err)
+	}
+
+	checked := map[string]int{}
+	for path, operations := range doc.Paths {
+		for prefix, scope := range protectedRoutes {
+			if !strings.HasPrefix(path, prefix+"/") {
+				continue
+			}
+			for method, op := range operations {
+				checked[prefix]++
+				if !slices.Contains(op.Scopes, scope) {
+					t.Errorf("%s %s: scopes %v, нужен %q", strings.ToUpper(method), path, op.Scopes, scope)
+				}
+			}
+		}
+	}
+	for prefix := range protectedRoutes {
+		if checked[prefix] == 0 {
+			t.Errorf("роутов под %s не найдено", prefix)
+		}
+	}
+}
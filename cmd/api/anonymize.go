@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Soundveyve/fiber-backend/internal/anonymize"
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/database"
+	"github.com/Soundveyve/fiber-backend/internal/logging"
+)
+
+// anonymizeUsage - справка по подкоманде anonymize
+const anonymizeUsage = `Использование: fiber-backend anonymize <имя БД>
+
+Обезличивает на месте копию production базы: email, имена, телефоны,
+пароли, привязки OAuth, IP в журнале аудита; удаляет токены, 2FA и
+тела событий. Имя БД повторяется для подтверждения и должно совпадать
+с DB_NAME. Не запускается при APP_ENV=production`
+
+// runAnonymizeCommand выполняет подкоманду anonymize
+// Подключение к БД берется из той же конфигурации, что и у сервера
+func runAnonymizeCommand(sources config.Sources, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("не указано имя БД\n\n%s", anonymizeUsage)
+	}
+
+	cfg, err := config.LoadConfig(sources)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки конфигурации: %w", err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.App.Env, cfg.App.LogLevel, cfg.App.Region); err != nil {
+		return fmt.Errorf("ошибка настройки логов: %w", err)
+	}
+
+	// Защита от запуска на рабочей базе по ошибке в окружении
+	if cfg.App.Env == "production" {
+		return fmt.Errorf("anonymize не запускается при APP_ENV=production: укажите окружение копии")
+	}
+	if args[0] != cfg.Database.Name {
+		return fmt.Errorf("имя БД %q не совпадает с DB_NAME=%q", args[0], cfg.Database.Name)
+	}
+
+	db, err := database.NewDatabase(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	results, err := anonymize.Run(context.Background(), db.Pool)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		fmt.Printf("%-24s %d строк\n", r.Step, r.Rows)
+	}
+	fmt.Printf("База %s обезличена, пароль всех пользователей: %s\n", cfg.Database.Name, anonymize.Password)
+	return nil
+}
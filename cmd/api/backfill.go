@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/backfill"
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/database"
+	"github.com/Soundveyve/fiber-backend/internal/logging"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/sanitize"
+)
+
+// backfillUsage - справка по подкоманде backfill
+const backfillUsage = `Использование: fiber-backend backfill <команда>
+
+Команды:
+  list            перечислить заполнения
+  status          показать прогресс запускавшихся заполнений
+  run <name>      выполнить заполнение с сохраненной позиции
+  restart <name>  выполнить заполнение заново с начала таблицы
+
+Размер пачки, паузу и длительность запуска задают --backfill-batch-size,
+--backfill-pause-ms и --backfill-max-duration (минуты).
+Ctrl+C останавливает заполнение, run продолжает его с места остановки`
+
+// runBackfillCommand выполняет подкоманду backfill
+// Подключение к БД берется из той же конфигурации, что и у сервера
+func runBackfillCommand(sources config.Sources, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("не указана команда\n\n%s", backfillUsage)
+	}
+	command, args := args[0], args[1:]
+
+	cfg, err := config.LoadConfig(sources)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки конфигурации: %w", err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.App.Env, cfg.App.LogLevel, cfg.App.Region); err != nil {
+		return fmt.Errorf("ошибка настройки логов: %w", err)
+	}
+
+	backfills := backfill.All(sanitize.Policy(cfg.App.SanitizePolicy), emailRules(cfg.Auth))
+
+	// list не требует подключения к БД
+	if command == "list" {
+		for _, b := range backfills {
+			fmt.Printf("%-24s %s\n", b.Name, b.Description)
+		}
+		return nil
+	}
+
+	db, err := database.NewDatabase(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	runner := backfill.NewRunner(db.Pool, repository.New(db.Pool), cfg.Backfill)
+
+	switch command {
+	case "status":
+		progress, err := runner.Status(context.Background())
+		if err != nil {
+			return err
+		}
+		printBackfillStatus(progress)
+		return nil
+
+	case "run", "restart":
+		if len(args) != 1 {
+			return fmt.Errorf("укажите заполнение: backfill %s <name> (список - backfill list)", command)
+		}
+		b, ok := backfill.Find(backfills, args[0])
+		if !ok {
+			return fmt.Errorf("неизвестное заполнение %q (список - backfill list)", args[0])
+		}
+
+		// Ctrl+C и SIGTERM откатывают текущую пачку: позиция сохранена
+		// после предыдущей, следующий run продолжит с нее
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		progress, err := runner.Run(ctx, b, command == "restart")
+		if errors.Is(err, context.Canceled) {
+			fmt.Printf("Заполнение %s остановлено на id %d, продолжить: backfill run %s\n", b.Name, progress.LastID, b.Name)
+			return nil
+		}
+		return err
+	}
+
+	return fmt.Errorf("неизвестная команда %q\n\n%s", command, backfillUsage)
+}
+
+// printBackfillStatus выводит прогресс заполнений для человека
+func printBackfillStatus(progress []repository.Backfill) {
+	if len(progress) == 0 {
+		fmt.Println("Заполнения не запускались")
+		return
+	}
+	for _, p := range progress {
+		state := "в процессе"
+		if p.CompletedAt.Valid {
+			state = "завершено " + p.CompletedAt.Time.UTC().Format(time.RFC3339)
+		}
+		fmt.Printf("%-24s %s: last_id %d, просмотрено %d, изменено %d, обновлено %s\n",
+			p.Name, state, p.LastID, p.Scanned, p.Updated, p.UpdatedAt.UTC().Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+)
+
+// startupBanner - сводка о запущенном экземпляре для отладки деплоев:
+// с какой конфигурацией он стартовал и какие роуты обслуживает
+type startupBanner struct {
+	Event      string                 `json:"event"`
+	App        string                 `json:"app"`
+	Env        string                 `json:"env"`
+	Config     map[string]interface{} `json:"config"`     // Секреты скрыты, см. config.Redacted
+	Features   map[string]bool        `json:"features"`   // Включенные возможности
+	Middleware []bannerRoute          `json:"middleware"` // app.Use и Group с обработчиками
+	Routes     []bannerRoute          `json:"routes"`
+}
+
+// bannerRoute - роут или middleware с именами обработчиков по порядку вызова
+type bannerRoute struct {
+	Method   string   `json:"method,omitempty"`
+	Path     string   `json:"path"`
+	Handlers []string `json:"handlers"`
+}
+
+// logStartupBanner выводит конфигурацию, возможности и таблицу роутов
+// В production - одной строкой JSON для сборщика логов, иначе - таблицей
+// Вызывается после регистрации всех роутов
+func logStartupBanner(cfg *config.Config, app *fiber.App) {
+	banner := startupBanner{
+		Event:    "startup",
+		App:      cfg.App.Name,
+		Env:      cfg.App.Env,
+		Config:   cfg.Redacted(),
+		Features: enabledFeatures(cfg),
+	}
+	banner.Middleware, banner.Routes = collectRoutes(app)
+
+	if cfg.App.Env == "production" {
+		// Одна JSON запись лога с полями сводки
+		slog.Info("startup",
+			"app", banner.App,
+			"env", banner.Env,
+			"config", banner.Config,
+			"features", banner.Features,
+			"middleware", banner.Middleware,
+			"routes", banner.Routes,
+		)
+		return
+	}
+
+	configJSON, _ := json.MarshalIndent(banner.Config, "", "  ")
+	slog.Info(fmt.Sprintf("⚙️  Конфигурация:\n%s", configJSON))
+
+	features := make([]string, 0, len(banner.Features))
+	for name, enabled := range banner.Features {
+		mark := "❌"
+		if enabled {
+			mark = "✅"
+		}
+		features = append(features, mark+" "+name)
+	}
+	sort.Strings(features)
+	slog.Info("🧩 Возможности: " + strings.Join(features, ", "))
+
+	var b strings.Builder
+	for _, m := range banner.Middleware {
+		fmt.Fprintf(&b, "\n  %-7s %-45s %s", "USE", m.Path, strings.Join(m.Handlers, " → "))
+	}
+	for _, r := range banner.Routes {
+		fmt.Fprintf(&b, "\n  %-7s %-45s %s", r.Method, r.Path, strings.Join(r.Handlers, " → "))
+	}
+	slog.Info(fmt.Sprintf("🗺️  Роуты (%d):%s", len(banner.Routes), b.String()))
+}
+
+// enabledFeatures возвращает включенные конфигурацией возможности
+func enabledFeatures(cfg *config.Config) map[string]bool {
+	return map[string]bool{
+		"fake_services":         cfg.App.FakeServices,
+		"signed_admin_requests": cfg.App.ServiceSigningKey != "",
+		"internal_api":          cfg.App.InternalServiceKeys != "" || cfg.App.InternalCertHeader != "",
+		"id_obfuscation":        cfg.App.IDEncoding == publicid.EncodingHashids,
+		"import_concurrency":    cfg.App.ImportConcurrency > 0,
+		"export_concurrency":    cfg.App.ExportConcurrency > 0,
+		"avatar_concurrency":    cfg.App.AvatarConcurrency > 0,
+		"s3_storage":            cfg.Storage.Driver == "s3",
+		"smtp_mail":             cfg.Mail.Driver == "smtp",
+	}
+}
+
+// collectRoutes разделяет зарегистрированные роуты на middleware и конечные роуты
+//
+// Fiber регистрирует middleware (app.Use) отдельно для каждого метода и
+// склеивает подряд идущие middleware одного пути, поэтому для каждого пути
+// выводится самая полная цепочка. HEAD и OPTIONS роуты, которые добавляются
+// к каждому роуту автоматически (см. setupOptionsRoutes), не выводятся
+func collectRoutes(app *fiber.App) (middleware, routes []bannerRoute) {
+	routeKey := func(r fiber.Route) string {
+		return r.Method + " " + r.Path + " " + strings.Join(handlerNames(r.Handlers), ",")
+	}
+
+	endpoints := make(map[string]bool)
+	for _, r := range app.GetRoutes(true) {
+		endpoints[routeKey(r)] = true
+		if r.Method == fiber.MethodHead || r.Method == fiber.MethodOptions {
+			continue
+		}
+		routes = append(routes, bannerRoute{
+			Method:   r.Method,
+			Path:     r.Path,
+			Handlers: handlerNames(r.Handlers),
+		})
+	}
+
+	byPath := make(map[string]int) // путь -> индекс в middleware
+	for _, r := range app.GetRoutes() {
+		if endpoints[routeKey(r)] {
+			continue
+		}
+		names := handlerNames(r.Handlers)
+		i, ok := byPath[r.Path]
+		if !ok {
+			byPath[r.Path] = len(middleware)
+			middleware = append(middleware, bannerRoute{Path: r.Path, Handlers: names})
+			continue
+		}
+		if len(names) > len(middleware[i].Handlers) {
+			middleware[i].Handlers = names
+		}
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return middleware, routes
+}
+
+// closureSuffix - суффиксы имен замыканий и method value (.func1, -fm)
+var closureSuffix = regexp.MustCompile(`(\.func\d+)+$|-fm$`)
+
+// handlerNames возвращает короткие имена обработчиков:
+// middleware.RequireAuth, handlers.(*UserHandler).ListUsers
+func handlerNames(handlers []fiber.Handler) []string {
+	names := make([]string, 0, len(handlers))
+	for _, h := range handlers {
+		name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+		name = name[strings.LastIndex(name, "/")+1:]
+		name = closureSuffix.ReplaceAllString(name, "")
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Soundveyve/fiber-backend/internal/archive"
+	"github.com/Soundveyve/fiber-backend/internal/audit"
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/contract"
+	"github.com/Soundveyve/fiber-backend/internal/devfake"
+	"github.com/Soundveyve/fiber-backend/internal/diagnostics"
+	"github.com/Soundveyve/fiber-backend/internal/handlers"
+	"github.com/Soundveyve/fiber-backend/internal/health"
+	"github.com/Soundveyve/fiber-backend/internal/jobs"
+	"github.com/Soundveyve/fiber-backend/internal/middleware"
+	"github.com/Soundveyve/fiber-backend/internal/operations"
+	"github.com/Soundveyve/fiber-backend/internal/origins"
+	"github.com/Soundveyve/fiber-backend/internal/reference"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/routes"
+	"github.com/Soundveyve/fiber-backend/internal/sanitize"
+	"github.com/Soundveyve/fiber-backend/internal/seed"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/settings"
+	"github.com/Soundveyve/fiber-backend/internal/signedurl"
+	"github.com/Soundveyve/fiber-backend/internal/system"
+	"github.com/Soundveyve/fiber-backend/internal/webhooks"
+	"github.com/Soundveyve/fiber-backend/internal/wellknown"
+	"github.com/Soundveyve/fiber-backend/pkg/svcauth"
+)
+
+// errNoDatabase - ответ contractDB на любой запрос
+var errNoDatabase = errors.New("контрактный тест: БД не подключена")
+
+// contractDB - БД, в которой любой запрос завершается ошибкой
+// Реализует repository.DBTX и services.Beginner
+type contractDB struct{}
+
+func (contractDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errNoDatabase
+}
+
+func (contractDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, errNoDatabase
+}
+
+func (contractDB) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return contractRow{}
+}
+
+func (contractDB) Begin(context.Context) (pgx.Tx, error) {
+	return nil, errNoDatabase
+}
+
+// contractRow - результат QueryRow contractDB
+type contractRow struct{}
+
+func (contractRow) Scan(...interface{}) error { return errNoDatabase }
+
+// contractService и contractServiceKey - внутренний сервис для /internal/v1
+const (
+	contractService    = "contract"
+	contractServiceKey = "contract-service-key-0123456789abcdef"
+)
+
+// newContractApp собирает приложение так же, как main, но поверх contractDB
+// и фейковых внешних сервисов. Лимиты запросов выключены: тест вызывает
+// каждую операцию много раз подряд
+func newContractApp(t *testing.T) (*fiber.App, *auth.TokenManager, *signedurl.Signer) {
+	t.Helper()
+	cfg, err := config.LoadConfig(config.Sources{Flags: map[string]string{
+		"APP_SECRET_KEY":                "contract-secret",
+		"JWT_SECRET":                    "contract-jwt",
+		"INTERNAL_SERVICE_KEYS":         contractService + "=" + contractServiceKey,
+		"APP_RATE_LIMIT":                "0",
+		"APP_RATE_LIMIT_SOFT":           "0",
+		"APP_RATE_LIMIT_BURST":          "0",
+		"APP_USER_RATE_LIMIT":           "0",
+		"APP_USER_RATE_LIMIT_SOFT":      "0",
+		"APP_USER_RATE_LIMIT_BURST":     "0",
+		"APP_INTERNAL_RATE_LIMIT":       "0",
+		"APP_INTERNAL_RATE_LIMIT_SOFT":  "0",
+		"APP_INTERNAL_RATE_LIMIT_BURST": "0",
+		"APP_AUTH_RATE_LIMIT":           "0",
+		"APP_CREATE_USER_RATE_LIMIT":    "0",
+		"APP_EXPORT_RATE_LIMIT":         "0",
+		"APP_EXPORT_IP_RATE_LIMIT":      "0",
+	}})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	db := contractDB{}
+	queries := repository.New(db)
+	runtimeSettings := settings.New(queries, settings.Definitions(cfg))
+	originRegistry, err := origins.New(queries, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbox := devfake.NewOutbox(100)
+	blobStore := devfake.NewBlobStore(outbox)
+	mail := devfake.NewMailer(outbox)
+	limiters := newRateLimiters(cfg, newRateLimitStore(cfg, nil))
+	currentConfig := config.NewCurrent(cfg)
+	tokens := auth.NewTokenManager(cfg.Auth)
+	signer := signedurl.NewSigner(cfg.App.SecretKey)
+	textPolicy := sanitize.Policy(cfg.App.SanitizePolicy)
+	auditLog := audit.NewLogger(queries, cfg.Audit.BufferSize, nil)
+	jobQueue := jobs.New(queries, cfg.Jobs)
+
+	webhookPublisher := webhooks.New(queries, jobQueue)
+	userService := services.NewUserService(queries, db, runtimeSettings, textPolicy, auditLog, webhookPublisher, nil)
+	exportService := services.NewExportService(queries, blobStore, signer, runtimeSettings, nil)
+	profileService := services.NewProfileService(queries)
+	twoFactorService := services.NewTwoFactorService(queries, db, nil, cfg.Auth.TwoFactorIssuer, auditLog)
+	accountService := services.NewAccountService(queries, db, tokens, jobQueue, cfg.Mail.LinkBaseURL)
+	recoveryService := services.NewTwoFactorRecoveryService(queries, accountService, cfg.Auth.TwoFactorRecoveryDelay, cfg.Auth.TwoFactorRecoveryApproval, auditLog)
+	authService := services.NewAuthService(queries, db, userService, twoFactorService, recoveryService, tokens)
+	operationManager := operations.New(queries, jobQueue)
+	userService.RegisterOperations(operationManager)
+	exportService.RegisterOperations(operationManager)
+
+	referenceCatalog, err := reference.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inspector := system.NewInspector(time.Now(), nil, prometheus.NewRegistry(), map[string]system.Queue{"audit": auditLog})
+	database := health.Dependency{Name: "database", Critical: true, Check: func(context.Context) error { return errNoDatabase }}
+
+	app := setupFiberApp(cfg, true, originRegistry, nil, userService.PublicID)
+	setupRoutes(app, cfg, tokens, signer, limiters, newBulkReadMonitor(cfg, newRateLimitStore(cfg, nil), auditLog),
+		handlers.NewUserHandler(userService, accountService, operationManager),
+		handlers.NewAdminHandler(userService, services.NewUserSearchService(db), operationManager),
+		handlers.NewHealthHandler(runtimeSettings, cfg.App.HealthProbeTimeout, database),
+		handlers.NewExportHandler(exportService),
+		handlers.NewAnnouncementHandler(services.NewAnnouncementService(queries, textPolicy)),
+		handlers.NewIdentityHandler(services.NewIdentityService(queries, db, devfake.NewIdentityVerifier())),
+		handlers.NewAuthHandler(authService),
+		handlers.NewTwoFactorHandler(twoFactorService),
+		handlers.NewTwoFactorRecoveryHandler(recoveryService, authService),
+		handlers.NewAccountHandler(accountService),
+		handlers.NewDigestHandler(services.NewDigestService(queries, mail, cfg.Digest.Period, cfg.Mail.LinkBaseURL)),
+		handlers.NewBroadcastHandler(services.NewBroadcastService(queries, mail, runtimeSettings)),
+		handlers.NewSettingsHandler(runtimeSettings),
+		handlers.NewAvatarHandler(services.NewAvatarService(queries, blobStore, cfg.Avatar, auditLog), userService, profileService),
+		handlers.NewProfileHandler(profileService),
+		handlers.NewAuditHandler(auditLog, userService),
+		handlers.NewSystemHandler(inspector),
+		handlers.NewDiagnosticsHandler(diagnostics.NewCollector(inspector, currentConfig, jobQueue)),
+		handlers.NewSchemaHandler(nil),
+		handlers.NewJobsHandler(jobQueue),
+		handlers.NewOperationHandler(operationManager),
+		handlers.NewArchiveHandler(archive.New(queries, db, blobStore, cfg.Audit.ArchiveBatchSize)),
+		handlers.NewEmailTemplateHandler(services.NewEmailTemplateService(queries, mail)),
+		handlers.NewWebhookHandler(services.NewWebhookService(queries)),
+		handlers.NewOriginHandler(services.NewOriginService(queries, originRegistry)),
+		handlers.NewConfigHandler(currentConfig),
+		handlers.NewWellKnownHandler(wellknown.New(cfg)),
+		handlers.NewReferenceHandler(referenceCatalog),
+		handlers.NewInternalHandler(userService),
+	)
+	setupFallbackRoutes(app)
+	return app, tokens, signer
+}
+
+// authorizer возвращает Suite.Authorize: учетные данные администратора
+// из набора seed с действующим sudo токеном, подписи ссылок и запросов
+func authorizer(t *testing.T, tokens *auth.TokenManager, signer *signedurl.Signer) func(*http.Request, routes.Operation) error {
+	issue := func(token auth.Token, err error) string {
+		if err != nil {
+			t.Fatalf("токен: %v", err)
+		}
+		return token.Value
+	}
+	access := issue(tokens.IssueAccess(int(seed.AdminID), "admin", auth.RoleAdmin))
+	sudo := issue(tokens.IssueSudo(int(seed.AdminID)))
+	pending := issue(tokens.IssueTwoFactor(int(seed.AdminID)))
+
+	return func(req *http.Request, op routes.Operation) error {
+		has := func(scope routes.Scope) bool { return slices.Contains(op.Scopes, scope) }
+		switch {
+		case has(routes.ScopeTwoFactor):
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+pending)
+		case has(routes.ScopeUser) || has(routes.ScopeAdmin) || has(routes.ScopeSudo):
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+access)
+			req.Header.Set(middleware.HeaderSudoToken, sudo)
+		}
+		if has(routes.ScopeSignedURL) {
+			signed, err := url.Parse(signer.Sign(req.URL.Path, time.Now().Add(time.Hour)))
+			if err != nil {
+				return err
+			}
+			req.URL.RawQuery = signed.RawQuery
+			req.RequestURI = req.URL.RequestURI()
+		}
+		// Подпись - последней: в нее входят URI и тело
+		if has(routes.ScopeService) {
+			return svcauth.SignRequest(req, contractService, []byte(contractServiceKey))
+		}
+		return nil
+	}
+}
+
+// TestOpenAPIContract вызывает каждую операцию GET /openapi.json допустимым
+// и испорченными телами и сверяет ответы со спецификацией (пакет contract)
+func TestOpenAPIContract(t *testing.T) {
+	app, tokens, signer := newContractApp(t)
+
+	// Спецификация - та, которую отдает приложение, а не построенная заново
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/openapi.json", nil), -1)
+	if err != nil {
+		t.Fatalf("GET /openapi.json: %v", err)
+	}
+	defer resp.Body.Close()
+	var doc routes.Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("GET /openapi.json: %v", err)
+	}
+
+	if missing := contract.Undocumented(app, &doc); len(missing) > 0 {
+		t.Errorf("роуты без описания в спецификации: %v", missing)
+	}
+
+	contract.Suite{
+		App:       app,
+		Doc:       &doc,
+		Authorize: authorizer(t, tokens, signer),
+		Param:     contractParam,
+		// Значение настройки проверяется по типу ключа из пути
+		Bodies: map[string]any{
+			"PUT /admin/v1/settings/{key}": map[string]any{"value": "30s"},
+		},
+	}.Run(t)
+}
+
+// contractParam - параметры пути, которые проходят разбор обработчиков:
+// пользователи адресуются публичным UUID, настройки - ключом
+func contractParam(path, name string) string {
+	switch {
+	case name == "key":
+		return settings.HealthCacheTTL
+	case strings.Contains(path, "/users/{"+name+"}"):
+		return seed.User{ID: seed.UserID}.PublicID().String()
+	}
+	return ""
+}
@@ -2,66 +2,493 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/gofiber/fiber/v2/middleware/recover"
-	
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/valyala/fasthttp/reuseport"
+	"google.golang.org/grpc"
+
+	"github.com/Soundveyve/fiber-backend/internal/adminui"
+	"github.com/Soundveyve/fiber-backend/internal/archive"
+	"github.com/Soundveyve/fiber-backend/internal/audit"
+	"github.com/Soundveyve/fiber-backend/internal/auth"
 	"github.com/Soundveyve/fiber-backend/internal/config"
 	"github.com/Soundveyve/fiber-backend/internal/database"
+	"github.com/Soundveyve/fiber-backend/internal/devfake"
+	"github.com/Soundveyve/fiber-backend/internal/diagnostics"
+	"github.com/Soundveyve/fiber-backend/internal/emailaddr"
+	"github.com/Soundveyve/fiber-backend/internal/grpcapi"
 	"github.com/Soundveyve/fiber-backend/internal/handlers"
+	"github.com/Soundveyve/fiber-backend/internal/health"
+	"github.com/Soundveyve/fiber-backend/internal/httpctx"
+	"github.com/Soundveyve/fiber-backend/internal/identity"
+	"github.com/Soundveyve/fiber-backend/internal/jobs"
+	"github.com/Soundveyve/fiber-backend/internal/jsoncodec"
+	"github.com/Soundveyve/fiber-backend/internal/logging"
+	"github.com/Soundveyve/fiber-backend/internal/mailer"
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+	"github.com/Soundveyve/fiber-backend/internal/middleware"
+	"github.com/Soundveyve/fiber-backend/internal/migrations"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/notify"
+	"github.com/Soundveyve/fiber-backend/internal/operations"
+	"github.com/Soundveyve/fiber-backend/internal/origins"
+	"github.com/Soundveyve/fiber-backend/internal/passhash"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/redact"
+	"github.com/Soundveyve/fiber-backend/internal/reference"
 	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/routes"
+	"github.com/Soundveyve/fiber-backend/internal/sanitize"
 	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/settings"
+	"github.com/Soundveyve/fiber-backend/internal/shutdown"
+	"github.com/Soundveyve/fiber-backend/internal/signedurl"
+	"github.com/Soundveyve/fiber-backend/internal/storage"
+	"github.com/Soundveyve/fiber-backend/internal/system"
+	"github.com/Soundveyve/fiber-backend/internal/totp"
+	"github.com/Soundveyve/fiber-backend/internal/tracing"
+	"github.com/Soundveyve/fiber-backend/internal/wal"
+	"github.com/Soundveyve/fiber-backend/internal/webhooks"
+	"github.com/Soundveyve/fiber-backend/internal/wellknown"
 )
 
 func main() {
-	// 1. Загружаем конфигурацию из .env и переменных окружения
-	cfg, err := config.LoadConfig()
+	// Флаги (--config файл, --app-port=3000) переопределяют переменные
+	// окружения, остальные аргументы - подкоманда
+	sources, args, err := config.ParseArgs(os.Args[1:])
+	if err != nil {
+		log.Fatalf("❌ Ошибка разбора аргументов: %v", err)
+	}
+
+	// Подкоманда migrate управляет схемой БД и не запускает сервер
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrateCommand(sources, args[1:]); err != nil {
+			log.Fatalf("❌ Ошибка миграций: %v", err)
+		}
+		return
+	}
+	// Подкоманда backfill заполняет данные пачками и не запускает сервер
+	if len(args) > 0 && args[0] == "backfill" {
+		if err := runBackfillCommand(sources, args[1:]); err != nil {
+			log.Fatalf("❌ Ошибка заполнения: %v", err)
+		}
+		return
+	}
+	// Подкоманда anonymize обезличивает копию production базы для staging
+	if len(args) > 0 && args[0] == "anonymize" {
+		if err := runAnonymizeCommand(sources, args[1:]); err != nil {
+			log.Fatalf("❌ Ошибка обезличивания: %v", err)
+		}
+		return
+	}
+	// Подкоманда seed записывает тестовые данные для разработки
+	if len(args) > 0 && args[0] == "seed" {
+		if err := runSeedCommand(sources, args[1:]); err != nil {
+			log.Fatalf("❌ Ошибка записи тестовых данных: %v", err)
+		}
+		return
+	}
+	if len(args) > 0 {
+		log.Fatalf("❌ Неизвестная команда: %s", args[0])
+	}
+
+	// Время запуска для uptime в GET /admin/v1/system
+	startedAt := time.Now()
+
+	// 1. Загружаем конфигурацию из флагов, переменных окружения (и .env)
+	// и файла --config. SIGHUP перечитывает ее без перезапуска (см. reloader)
+	cfg, err := config.LoadConfig(sources)
 	if err != nil {
 		log.Fatalf("❌ Ошибка загрузки конфигурации: %v", err)
 	}
 
-	log.Printf("🚀 Запуск приложения: %s (окружение: %s)", cfg.App.Name, cfg.App.Env)
+	// Структурированные логи: JSON в production, текст в остальных окружениях
+	if err := logging.Setup(os.Stderr, cfg.App.Env, cfg.App.LogLevel, cfg.App.Region); err != nil {
+		log.Fatalf("❌ Ошибка настройки логов: %v", err)
+	}
+
+	slog.Info("🚀 Запуск приложения", "app", cfg.App.Name, "env", cfg.App.Env, "region", cfg.App.Region)
+
+	// Трассировка OpenTelemetry до подключения к БД: SQL спаны создает
+	// обертка драйвера. Без TRACING_OTLP_ENDPOINT трассировка выключена
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, cfg.App.Name, cfg.App.Env, cfg.App.Region)
+	if err != nil {
+		fatal("❌ Ошибка настройки трассировки", err)
+	}
+	if cfg.Tracing.OTLPEndpoint != "" {
+		slog.Info("🔭 Трассировка включена", "endpoint", cfg.Tracing.OTLPEndpoint, "sample_ratio", cfg.Tracing.SampleRatio)
+	}
 
 	// 2. Подключаемся к базе данных
 	db, err := database.NewDatabase(cfg.Database)
 	if err != nil {
-		log.Fatalf("❌ Ошибка подключения к БД: %v", err)
+		fatal("❌ Ошибка подключения к БД", err)
 	}
 	defer db.Close()
 
 	// Выводим статистику пула соединений
 	db.LogStats()
 
+	// Непримененные миграции при запуске (DB_AUTO_MIGRATE)
+	if cfg.Database.AutoMigrate {
+		if err := autoMigrate(db); err != nil {
+			fatal("❌ Ошибка применения миграций", err)
+		}
+	}
+
+	// Сверка схемы с миграциями: ручная правка БД (удаленная колонка,
+	// индекс) видна сразу, а не ошибками запросов. Расхождение не мешает
+	// запуску - затронуты только запросы к пропавшим объектам
+	checkSchemaDrift(db)
+
+	// Реплика своего региона для чтений, допускающих отставание (DB_REPLICAS)
+	// Без реплики или при ее недоступности чтения идут в основную БД
+	db.ConnectReplica(cfg.App.Region)
+
+	// Статистика пула публикуется в /metrics при каждом опросе
+	if err := metrics.RegisterDBStats(db.Pool, cfg.Database.Name); err != nil {
+		slog.Warn("⚠️  Не удалось зарегистрировать метрики пула БД", "error", err)
+	}
+	if db.Replica != nil {
+		if err := metrics.RegisterDBStats(db.Replica, cfg.Database.Name+"_replica"); err != nil {
+			slog.Warn("⚠️  Не удалось зарегистрировать метрики пула реплики", "error", err)
+		}
+	}
+
 	// 3. Создаем слой репозитория (sqlc сгенерированный код)
-	queries := repository.New(db.DB)
+	// CountingDB считает запросы для метрик и отладочного заголовка X-DB-Queries
+	countingDB := database.NewCountingDB(db.Pool)
+	queries := repository.New(countingDB)
+
+	// Настройки, изменяемые во время работы: значения по умолчанию из
+	// переменных окружения, переопределения из таблицы settings
+	runtimeSettings := settings.New(queries, settings.Definitions(cfg))
+	if err := runtimeSettings.Load(context.Background()); err != nil {
+		fatal("❌ Ошибка загрузки настроек", err)
+	}
+
+	// Источники CORS: из CORS_ALLOW_ORIGINS и из таблиц, которые
+	// редактируются через admin API (кешируются в памяти)
+	allowAllOrigins, staticOrigins := parseCORSOrigins(cfg.App.CORSAllowOrigins)
+	originRegistry, err := origins.New(queries, staticOrigins)
+	if err != nil {
+		fatal("❌ Ошибка настройки CORS", err)
+	}
+	if err := originRegistry.Load(context.Background()); err != nil {
+		fatal("❌ Ошибка загрузки источников CORS", err)
+	}
+
+	// В режиме DEV_FAKE_SERVICES внешние сервисы пишут в outbox в памяти
+	var outbox *devfake.Outbox
+	if cfg.App.FakeServices {
+		outbox = devfake.NewOutbox(1000)
+		slog.Info("🧪 Включен режим фейковых внешних сервисов (DEV_FAKE_SERVICES)")
+	}
+
+	// Хранилище файлов (в режиме фейков - в памяти)
+	blobStore, err := newBlobStore(cfg, outbox)
+	if err != nil {
+		fatal("❌ Ошибка инициализации хранилища", err)
+	}
+
+	// Отправка писем (в режиме фейков - в outbox)
+	mail, err := newMailer(cfg, outbox)
+	if err != nil {
+		fatal("❌ Ошибка инициализации почты", err)
+	}
+
+	// Проверка OAuth токенов при привязке внешних учетных записей
+	// Пока провайдеры не настроены, привязка доступна только с фейками
+	var identityVerifier identity.Verifier = identity.Unconfigured{}
+	if outbox != nil {
+		identityVerifier = devfake.NewIdentityVerifier()
+	}
+
+	// Redis не обязателен: без REDIS_ADDR redisClient равен nil
+	// Недоступный при запуске Redis не останавливает сервер
+	redisClient := newRedisClient(cfg)
+
+	// Счетчики лимитов запросов: в Redis, если он настроен, иначе в памяти
+	// Значения лимитов меняются перезагрузкой конфигурации
+	rateLimitStore := newRateLimitStore(cfg, redisClient)
+	limiters := newRateLimiters(cfg, rateLimitStore)
+
+	// Действующая конфигурация для GET /admin/v1/config
+	currentConfig := config.NewCurrent(cfg)
+
+	// JWT токены: подпись и проверка access/refresh
+	tokens := auth.NewTokenManager(cfg.Auth)
+
+	// Шифрование секретов TOTP в БД; без ключа включить 2FA нельзя
+	var totpCipher *totp.Cipher
+	if cfg.Auth.TwoFactorEncryptionKey != "" {
+		totpCipher, err = totp.NewCipher(cfg.Auth.TwoFactorEncryptionKey)
+		if err != nil {
+			fatal("❌ Ошибка настройки шифрования секретов 2FA", err)
+		}
+	} else {
+		slog.Warn("⚠️  TWO_FACTOR_ENCRYPTION_KEY не задан, включение 2FA недоступно")
+	}
+
+	// Стоимость хешей паролей: фиксированная или подобранная под бюджет
+	// времени на этом железе. До создания сервисов: фиктивный хеш для
+	// выравнивания времени входа должен иметь ту же стоимость
+	if err := setupPasswordHashing(cfg.Auth); err != nil {
+		fatal("❌ Ошибка настройки хеширования паролей", err)
+	}
+
+	// Временные подписанные ссылки на скачивание (экспорты, приватные файлы)
+	signer := signedurl.NewSigner(cfg.App.SecretKey)
+
+	// Вид целочисленных ID в путях и ответах (APP_ID_ENCODING), до регистрации
+	// роутов: от кодека зависит и схема OpenAPI. Параметры проверены в Config.Validate
+	idCodec, _ := publicid.New(cfg.App.IDEncoding, cfg.App.IDSecret, cfg.App.IDMinLength)
+	publicid.Use(idCodec)
+
+	// Правила канонических email (EMAIL_STRIP_*), до создания сервисов
+	emailaddr.Use(emailRules(cfg.Auth))
+
+	// Очистка свободного текста от HTML перед сохранением (SANITIZE_POLICY)
+	textPolicy := sanitize.Policy(cfg.App.SanitizePolicy)
+
+	// Скрытие и маскировка полей ответов по роли и тенанту (RESPONSE_REDACTION)
+	// Validate уже проверил политику
+	redactPolicy, _ := redact.Parse(cfg.App.ResponseRedaction)
+
+	// Журнал аудита: записи пишет в БД фоновый воркер (см. ниже)
+	// Пока БД недоступна, записи откладываются на диск (AUDIT_SPOOL_DIR)
+	var auditSpool *wal.Log
+	if cfg.Audit.SpoolDir != "" {
+		auditSpool, err = wal.Open(cfg.Audit.SpoolDir, cfg.Audit.SpoolMaxSize)
+		if err != nil {
+			fatal("❌ Ошибка открытия журнала отложенных записей аудита", err)
+		}
+	}
+	auditLog := audit.NewLogger(queries, cfg.Audit.BufferSize, auditSpool)
+
+	// Оповещения о подозрительном объеме экспортов и массовых чтений
+	// (APP_BULK_READ_ALERT_*): счетчики общие с лимитами запросов
+	bulkReadMonitor := newBulkReadMonitor(cfg, rateLimitStore, auditLog)
+
+	// Очередь фоновых заданий в БД: письма, очистка, задания по расписанию
+	// Обработчики регистрируются после создания сервисов (registerJobs)
+	jobQueue := jobs.New(queries, cfg.Jobs)
 
 	// 4. Создаем сервисный слой (бизнес-логика)
-	userService := services.NewUserService(queries, db.DB)
+	webhookPublisher := webhooks.New(queries, jobQueue)
+	userService := services.NewUserService(queries, db.Pool, runtimeSettings, textPolicy, auditLog, webhookPublisher, redactPolicy)
+	exportService := services.NewExportService(queries, blobStore, signer, runtimeSettings, redactPolicy)
+	announcementService := services.NewAnnouncementService(queries, textPolicy)
+	identityService := services.NewIdentityService(queries, db.Pool, identityVerifier)
+	twoFactorService := services.NewTwoFactorService(queries, db.Pool, totpCipher, cfg.Auth.TwoFactorIssuer, auditLog)
+	accountService := services.NewAccountService(queries, db.Pool, tokens, jobQueue, cfg.Mail.LinkBaseURL)
+	recoveryService := services.NewTwoFactorRecoveryService(queries, accountService, cfg.Auth.TwoFactorRecoveryDelay, cfg.Auth.TwoFactorRecoveryApproval, auditLog)
+	authService := services.NewAuthService(queries, db.Pool, userService, twoFactorService, recoveryService, tokens)
+	digestService := services.NewDigestService(queries, mail, cfg.Digest.Period, cfg.Mail.LinkBaseURL)
+	broadcastService := services.NewBroadcastService(queries, mail, runtimeSettings)
+	emailTemplateService := services.NewEmailTemplateService(queries, mail)
+	webhookService := services.NewWebhookService(queries)
+	originService := services.NewOriginService(queries, originRegistry)
+	avatarService := services.NewAvatarService(queries, blobStore, cfg.Avatar, auditLog)
+	profileService := services.NewProfileService(queries)
+	// Поиск - только чтение, отставание реплики для панелей поддержки допустимо
+	userSearchService := services.NewUserSearchService(database.NewCountingDB(db.ReadPool()))
+
+	// Уведомления о действиях с аккаунтом: события из журнала аудита
+	// копятся по окнам NOTIFY_BATCH_WINDOWS и уходят одним письмом
+	notifyWindows, _ := cfg.Notify.Windows() // Формат проверен в Config.Validate
+	notifier, err := notify.NewBatcher(queries, jobQueue, notifyWindows)
+	if err != nil {
+		fatal("❌ Ошибка настройки уведомлений", err)
+	}
+	if notifier.Enabled() {
+		auditLog.Observe(notifier.Observe)
+	}
+
+	// Длительные операции (Prefer: respond-async) выполняет очередь заданий
+	operationManager := operations.New(queries, jobQueue)
+	userService.RegisterOperations(operationManager)
+	exportService.RegisterOperations(operationManager)
+
+	// Архив старых записей журналов в хранилище: у каждого журнала свои
+	// сроки (AUDIT_ARCHIVE_*, LOGIN_HISTORY_ARCHIVE_*), 0 - журнал не архивируется
+	archiver := archive.New(queries, db.Pool, blobStore, cfg.Audit.ArchiveBatchSize)
+	if cfg.Audit.ArchiveAfter > 0 {
+		archiver.Register(audit.NewArchiveSource(queries), archive.Tier{
+			HotFor:  cfg.Audit.ArchiveAfter,
+			KeepFor: cfg.Audit.ArchiveRetention,
+		})
+	}
+	if cfg.Audit.LoginHistoryArchiveAfter > 0 {
+		archiver.Register(services.NewLoginHistoryArchiveSource(queries), archive.Tier{
+			HotFor:  cfg.Audit.LoginHistoryArchiveAfter,
+			KeepFor: cfg.Audit.LoginHistoryArchiveRetention,
+		})
+	}
+	registerJobs(cfg, jobQueue, mail, authService, userService, webhookPublisher, notifier, operationManager, archiver)
 
 	// 5. Создаем HTTP обработчики
-	userHandler := handlers.NewUserHandler(userService)
+	userHandler := handlers.NewUserHandler(userService, accountService, operationManager)
+	adminHandler := handlers.NewAdminHandler(userService, userSearchService, operationManager)
+	operationHandler := handlers.NewOperationHandler(operationManager)
+	archiveHandler := handlers.NewArchiveHandler(archiver)
+	healthHandler := handlers.NewHealthHandler(runtimeSettings, cfg.App.HealthProbeTimeout, healthDependencies(cfg, db, redisClient, mail)...)
+	exportHandler := handlers.NewExportHandler(exportService)
+	announcementHandler := handlers.NewAnnouncementHandler(announcementService)
+	identityHandler := handlers.NewIdentityHandler(identityService)
+	authHandler := handlers.NewAuthHandler(authService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	recoveryHandler := handlers.NewTwoFactorRecoveryHandler(recoveryService, authService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	digestHandler := handlers.NewDigestHandler(digestService)
+	broadcastHandler := handlers.NewBroadcastHandler(broadcastService)
+	emailTemplateHandler := handlers.NewEmailTemplateHandler(emailTemplateService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	originHandler := handlers.NewOriginHandler(originService)
+	settingsHandler := handlers.NewSettingsHandler(runtimeSettings)
+	avatarHandler := handlers.NewAvatarHandler(avatarService, userService, profileService)
+	profileHandler := handlers.NewProfileHandler(profileService)
+	auditHandler := handlers.NewAuditHandler(auditLog, userService)
+	jobsHandler := handlers.NewJobsHandler(jobQueue)
+	configHandler := handlers.NewConfigHandler(currentConfig)
+	wellKnownDocuments := wellknown.New(cfg)
+	wellKnownHandler := handlers.NewWellKnownHandler(wellKnownDocuments)
+	referenceCatalog, err := reference.New()
+	if err != nil {
+		fatal("❌ Ошибка встроенных справочников", err)
+	}
+	referenceHandler := handlers.NewReferenceHandler(referenceCatalog)
+	schemaHandler := handlers.NewSchemaHandler(db.Pool)
+	internalHandler := handlers.NewInternalHandler(userService)
+	inspector := system.NewInspector(startedAt, db.Pool, prometheus.DefaultGatherer, map[string]system.Queue{
+		"audit": auditLog,
+	})
+	systemHandler := handlers.NewSystemHandler(inspector)
+	diagnosticsHandler := handlers.NewDiagnosticsHandler(diagnostics.NewCollector(inspector, currentConfig, jobQueue))
 
 	// 6. Настраиваем Fiber приложение
-	app := setupFiberApp(cfg)
+	app := setupFiberApp(cfg, allowAllOrigins, originRegistry, redactPolicy, userService.PublicID)
 
 	// 7. Регистрируем роуты
-	setupRoutes(app, userHandler)
+	setupRoutes(app, cfg, tokens, signer, limiters, bulkReadMonitor, userHandler, adminHandler, healthHandler, exportHandler, announcementHandler, identityHandler, authHandler, twoFactorHandler, recoveryHandler, accountHandler, digestHandler, broadcastHandler, settingsHandler, avatarHandler, profileHandler, auditHandler, systemHandler, diagnosticsHandler, schemaHandler, jobsHandler, operationHandler, archiveHandler, emailTemplateHandler, webhookHandler, originHandler, configHandler, wellKnownHandler, referenceHandler, internalHandler)
+
+	// Документы /.well-known/ ссылаются на эндпоинты API: при переименовании
+	// роута процесс не запустится с устаревшими ссылками
+	if err := checkWellKnownEndpoints(app, wellKnownDocuments); err != nil {
+		fatal("❌ Ошибка документов /.well-known/", err)
+	}
+
+	// Встроенный интерфейс администратора для инсталляций без своей панели
+	if cfg.App.AdminUI {
+		adminui.Register(app)
+	}
+
+	// Dev эндпоинты только в режиме фейковых сервисов
+	if outbox != nil {
+		setupDevRoutes(app, handlers.NewDevHandler(outbox))
+	}
+
+	// OPTIONS и 404 регистрируются последними, после всех роутов
+	setupFallbackRoutes(app)
+
+	// Конфигурация и таблица роутов для отладки деплоев
+	logStartupBanner(cfg, app)
+
+	// Фоновые горутины разделены по подсистемам: при завершении у каждой
+	// свой срок остановки (SHUTDOWN_*_TIMEOUT), см. план остановки ниже
+	workers := shutdown.NewWorkers()
+
+	workers.Go(func(ctx context.Context) {
+		digestService.RunWorker(ctx, cfg.Digest.PollInterval)
+	})
+
+	workers.Go(func(ctx context.Context) {
+		broadcastService.RunWorker(ctx, cfg.Broadcast.PollInterval)
+	})
+
+	workers.Go(func(ctx context.Context) {
+		runtimeSettings.RunRefresher(ctx, cfg.App.SettingsRefreshInterval)
+	})
+
+	// Источники CORS, измененные через admin API на других инстансах
+	workers.Go(func(ctx context.Context) {
+		originRegistry.RunRefresher(ctx, cfg.App.SettingsRefreshInterval)
+	})
+
+	// Перезагрузка конфигурации по SIGHUP: уровень логов, лимиты
+	// запросов и источники CORS меняются без перезапуска
+	workers.Go(func(ctx context.Context) {
+		r := &reloader{
+			sources:  sources,
+			started:  cfg,
+			current:  currentConfig,
+			limiters: limiters,
+			origins:  originRegistry,
+		}
+		r.run(ctx)
+	})
+
+	// Очередь заданий: при остановке не берет новые задания
+	// и дожидается текущих
+	jobWorkers := shutdown.NewWorkers()
+	jobWorkers.Go(jobQueue.Run)
+
+	// Журнал аудита: при остановке дописывает накопленную очередь,
+	// HTTP сервер и воркеры к этому моменту уже остановлены
+	auditWorker := shutdown.NewWorkers()
+	auditWorker.Go(auditLog.Run)
 
 	// 8. Запускаем HTTP сервер в отдельной горутине
+	// Порт открывается заранее: если он занят, процесс завершается сразу,
+	// а не продолжает работать без сервера
+	addr := fmt.Sprintf(":%s", cfg.App.Port)
+	ln, err := newListener(addr, cfg.App.ReusePort)
+	if err != nil {
+		fatal("❌ Ошибка открытия порта", err)
+	}
 	go func() {
-		addr := fmt.Sprintf(":%s", cfg.App.Port)
-		log.Printf("🌐 HTTP сервер запущен на http://localhost%s", addr)
-		if err := app.Listen(addr); err != nil {
-			log.Printf("❌ Ошибка HTTP сервера: %v", err)
+		slog.Info("🌐 HTTP сервер запущен", "addr", "http://localhost"+addr, "reuse_port", cfg.App.ReusePort)
+		if err := app.Listener(ln); err != nil {
+			slog.Error("❌ Ошибка HTTP сервера", "error", err)
+		}
+	}()
+
+	// gRPC API на отдельном порту (APP_GRPC_PORT) с тем же сервисным слоем
+	var grpcServer *grpc.Server
+	if cfg.App.GRPCPort != "" {
+		grpcAddr := fmt.Sprintf(":%s", cfg.App.GRPCPort)
+		grpcListener, err := newListener(grpcAddr, cfg.App.ReusePort)
+		if err != nil {
+			fatal("❌ Ошибка открытия порта gRPC", err)
 		}
+		grpcServer = grpcapi.NewServer(tokens, userService, accountService)
+		go func() {
+			slog.Info("🌐 gRPC сервер запущен", "addr", grpcAddr)
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				slog.Error("❌ Ошибка gRPC сервера", "error", err)
+			}
+		}()
+	}
+
+	// Прогрев идет параллельно с запуском сервера: health-check
+	// отвечает 503, пока он не закончится
+	go func() {
+		warmUp(cfg, db, userService)
+		healthHandler.MarkReady()
 	}()
 
 	// 9. Graceful shutdown - ждем сигнал завершения
@@ -70,98 +497,1382 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("🛑 Получен сигнал завершения, начинаем graceful shutdown...")
+	slog.Info("🛑 Получен сигнал завершения, начинаем graceful shutdown...")
+
+	// Фазы идут по порядку, подсистемы фазы останавливаются параллельно:
+	// сначала перестаем принимать запросы, затем останавливаем воркеры,
+	// затем дописываем то, что они накопили
+	var plan shutdown.Plan
+	plan.Phase("servers",
+		shutdown.Subsystem{Name: "http", Timeout: cfg.Shutdown.HTTP, Stop: app.ShutdownWithContext},
+		shutdown.Subsystem{Name: "grpc", Timeout: cfg.Shutdown.GRPC, Stop: grpcStopper(grpcServer)},
+	)
+	plan.Phase("workers",
+		shutdown.Subsystem{Name: "jobs", Timeout: cfg.Shutdown.Jobs, Stop: jobWorkers.Stop},
+		shutdown.Subsystem{Name: "workers", Timeout: cfg.Shutdown.Workers, Stop: workers.Stop},
+	)
+	plan.Phase("audit",
+		shutdown.Subsystem{Name: "audit", Timeout: cfg.Shutdown.Audit, Stop: auditWorker.Stop},
+	)
+	// Накопленные спаны отправляются последними: в них попадает и сама остановка
+	plan.Phase("tracing",
+		shutdown.Subsystem{Name: "tracing", Timeout: cfg.Shutdown.Tracing, Stop: shutdownTracing},
+	)
+	shutdown.Report(plan.Run(context.Background()))
+}
+
+// grpcStopper возвращает остановку gRPC сервера для плана остановки
+// nil, если gRPC выключен (APP_GRPC_PORT не задан)
+func grpcStopper(server *grpc.Server) func(ctx context.Context) error {
+	if server == nil {
+		return nil
+	}
+	return func(ctx context.Context) error {
+		return stopGRPC(ctx, server)
+	}
+}
+
+// stopGRPC останавливает gRPC сервер: новые вызовы отклоняются, текущие
+// дорабатывают. Если ctx истек раньше, оставшиеся вызовы прерываются
+func stopGRPC(ctx context.Context, server *grpc.Server) error {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		slog.Error("❌ gRPC сервер не остановился вовремя, вызовы прерваны")
+		server.Stop()
+		return ctx.Err()
+	}
+}
+
+// fatal пишет ошибку запуска в лог и завершает процесс
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// warmUpStatements - горячие запросы, которые готовятся на каждом
+// соединении пула при прогреве (совпадают с queries/users.sql)
+var warmUpStatements = []string{
+	"SELECT * FROM users WHERE id = $1 AND deleted_at IS NULL LIMIT 1",
+	"SELECT * FROM users WHERE email = $1 AND deleted_at IS NULL LIMIT 1",
+}
+
+// warmUp прогревает зависимости перед приемом трафика, чтобы первые
+// запросы после деплоя не получали всплеск задержки
+// Ошибки прогрева не фатальны: сервис просто стартует "холодным"
+func warmUp(cfg *config.Config, db *database.Database, userService *services.UserService) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.App.WarmUpTimeout)
+	defer cancel()
+
+	start := time.Now()
+
+	if err := db.WarmUp(ctx, warmUpStatements...); err != nil {
+		slog.Warn("⚠️  Прогрев пула БД не завершен", "error", err)
+	}
+	if err := userService.WarmUp(ctx); err != nil {
+		slog.Warn("⚠️  Прогрев сервиса пользователей не завершен", "error", err)
+	}
+
+	slog.Info("🔥 Прогрев завершен", "duration", time.Since(start).Round(time.Millisecond))
+}
+
+// setupPasswordHashing задает стоимость bcrypt
+// С PASSWORD_HASH_TARGET_MS стоимость калибруется: наибольшая от
+// BCRYPT_COST до BCRYPT_MAX_COST, при которой хеш укладывается в бюджет
+func setupPasswordHashing(cfg config.AuthConfig) error {
+	if cfg.PasswordHashTarget <= 0 {
+		return passhash.SetCost(cfg.BcryptCost)
+	}
+
+	cost, measured, err := passhash.Calibrate(cfg.PasswordHashTarget, cfg.BcryptCost, cfg.BcryptMaxCost)
+	if err != nil {
+		return err
+	}
+	if measured > cfg.PasswordHashTarget {
+		slog.Warn("⚠️  Хеш пароля с минимальной стоимостью не укладывается в бюджет",
+			"cost", cost, "duration", measured.Round(time.Millisecond), "target", cfg.PasswordHashTarget)
+	}
+	slog.Info("🔑 Стоимость хеширования паролей откалибрована",
+		"cost", cost, "duration", measured.Round(time.Millisecond), "target", cfg.PasswordHashTarget)
+	return passhash.SetCost(cost)
+}
+
+// emailRules - правила канонических email из конфигурации
+// Общие для сервера и заполнения canonical-emails
+func emailRules(cfg config.AuthConfig) emailaddr.Rules {
+	return emailaddr.Rules{
+		GmailDots:   cfg.EmailStripGmailDots,
+		PlusAliases: cfg.EmailStripPlusAliases,
+	}
+}
+
+// newBlobStore создает хранилище файлов по конфигурации
+// В режиме DEV_FAKE_SERVICES (outbox != nil) используется хранилище в памяти
+func newBlobStore(cfg *config.Config, outbox *devfake.Outbox) (storage.BlobStore, error) {
+	if outbox != nil {
+		return devfake.NewBlobStore(outbox), nil
+	}
+	return storage.New(cfg.Storage)
+}
+
+// autoMigrate применяет непримененные миграции до приема запросов
+// Инстансы, запущенные одновременно, применяют миграции по очереди
+// (advisory lock), остальные увидят актуальную схему
+func autoMigrate(db *database.Database) error {
+	migrator, err := migrations.New(db.Pool)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
 
-	// Создаем контекст с таймаутом для завершения
+	applied, err := migrator.Up()
+	if err != nil {
+		return err
+	}
+	if !applied {
+		slog.Info("🗄️  Схема БД актуальна")
+	}
+	return nil
+}
+
+// checkSchemaDrift сверяет схему БД с примененными миграциями и пишет
+// расхождения в лог (см. migrations.CheckDrift)
+func checkSchemaDrift(db *database.Database) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Останавливаем HTTP сервер
-	if err := app.ShutdownWithContext(ctx); err != nil {
-		log.Printf("❌ Ошибка при остановке HTTP сервера: %v", err)
+	drift, err := migrations.CheckDrift(ctx, db.Pool)
+	if err != nil {
+		slog.Warn("⚠️  Не удалось сверить схему БД с миграциями", "error", err)
+		return
+	}
+	if drift.Dirty {
+		slog.Error("❌ Миграция схемы БД не завершена (dirty)", "version", drift.Version)
+	}
+	if !drift.Empty() {
+		slog.Error("❌ Схема БД расходится с миграциями: запросы к отсутствующим объектам будут падать",
+			"version", drift.Version,
+			"missing_tables", drift.MissingTables,
+			"missing_columns", drift.MissingColumns,
+			"missing_indexes", drift.MissingIndexes,
+		)
+		return
+	}
+	slog.Info("🗄️  Схема БД совпадает с миграциями", "version", drift.Version)
+}
+
+// newListener открывает TCP порт сервера
+//
+// С reusePort порт открывается с SO_REUSEPORT, и новый процесс при
+// перезапуске занимает его, не дожидаясь старого: ядро распределяет
+// новые соединения между обоими, пока старый не закроет свой сокет
+// в graceful shutdown. Без SO_REUSEPORT второй процесс получил бы
+// "address already in use"
+func newListener(addr string, reusePort bool) (net.Listener, error) {
+	if !reusePort {
+		return net.Listen(fiber.NetworkTCP4, addr)
+	}
+	return reuseport.Listen(fiber.NetworkTCP4, addr)
+}
+
+// newRedisClient создает клиент Redis, если задан REDIS_ADDR
+// Возвращает nil, когда Redis не настроен. Недоступный Redis только
+// логируется: клиент переподключается сам, а до тех пор функции
+// работают по запасному пути (REDIS_RATE_LIMIT_FALLBACK)
+func newRedisClient(cfg *config.Config) *redis.Client {
+	if cfg.Redis.Addr == "" {
+		return nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:         cfg.Redis.Addr,
+		Password:     cfg.Redis.Password,
+		DB:           cfg.Redis.DB,
+		DialTimeout:  cfg.Redis.Timeout,
+		ReadTimeout:  cfg.Redis.Timeout,
+		WriteTimeout: cfg.Redis.Timeout,
+		// Ожидание соединения из пула тоже ограничено: при зависшем Redis
+		// запросы не должны копиться в очереди к пулу
+		PoolTimeout: cfg.Redis.Timeout,
+		MaxRetries:  -1,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		slog.Warn("⚠️  Redis недоступен, работаем по запасному пути",
+			"addr", cfg.Redis.Addr, "rate_limit", cfg.Redis.RateLimitFallback, "error", err)
+	}
+
+	return client
+}
+
+// newRateLimitStore выбирает хранилище счетчиков лимита запросов
+// С Redis лимиты общие для всех инстансов, без него каждый инстанс
+// считает запросы отдельно. Пока Redis недоступен, счетчики ведутся
+// по REDIS_RATE_LIMIT_FALLBACK: memory - в памяти, open и closed -
+// без счетчиков (см. newRateLimiters)
+func newRateLimitStore(cfg *config.Config, client *redis.Client) middleware.RateLimitStore {
+	if client == nil {
+		slog.Info("⏱️  Лимиты запросов считаются в памяти (REDIS_ADDR не задан)")
+		return middleware.NewMemoryRateLimitStore()
+	}
+
+	var fallback middleware.RateLimitStore
+	if cfg.Redis.RateLimitFallback == "memory" {
+		fallback = middleware.NewMemoryRateLimitStore()
 	}
 
-	log.Println("✅ Приложение успешно завершено")
+	slog.Info("⏱️  Лимиты запросов считаются в Redis",
+		"addr", client.Options().Addr, "fallback", cfg.Redis.RateLimitFallback)
+	return middleware.NewFallbackRateLimitStore("redis", middleware.NewRedisRateLimitStore(client), fallback, cfg.Redis.RetryInterval)
+}
+
+// newBulkReadMonitor создает монитор экспортов и массовых чтений
+// Каждое оповещение попадает в журнал аудита: по нему видно, чей токен
+// и с какого IP выгружал данные
+func newBulkReadMonitor(cfg *config.Config, store middleware.RateLimitStore, auditLog *audit.Logger) fiber.Handler {
+	return middleware.BulkReadMonitor(middleware.BulkReadMonitorConfig{
+		Store:     store,
+		Threshold: cfg.App.BulkReadAlertThreshold,
+		Window:    cfg.App.BulkReadAlertWindow,
+		OnAnomaly: func(ctx context.Context, anomaly middleware.BulkReadAnomaly) {
+			event := audit.Event{
+				Action: audit.ActionSecurityBulkReadAnomaly,
+				After: map[string]interface{}{
+					"route":    anomaly.Route,
+					"subject":  anomaly.Subject,
+					"client":   anomaly.Client,
+					"requests": anomaly.Requests,
+					"window":   anomaly.Window.String(),
+				},
+			}
+			if anomaly.Subject == middleware.BulkReadSubjectUser {
+				event.TargetUserID, _ = strconv.Atoi(anomaly.Client)
+			}
+			auditLog.Record(ctx, event)
+		},
+	})
+}
+
+// healthDependencies перечисляет зависимости для readiness пробы
+// Без БД инстанс не обслуживает ни одного запроса, поэтому она критична.
+// Redis и SMTP некритичны: лимиты без Redis работают по запасному пути
+// (Fallback в ответе пробы), а письма можно отправить повторно
+func healthDependencies(cfg *config.Config, db *database.Database, redisClient *redis.Client, mail mailer.Mailer) []health.Dependency {
+	deps := []health.Dependency{{
+		Name:     "database",
+		Critical: true,
+		Check:    db.HealthCheck,
+	}}
+
+	if redisClient != nil {
+		deps = append(deps, health.Dependency{
+			Name:     "redis",
+			Fallback: "rate_limit=" + cfg.Redis.RateLimitFallback,
+			Check: func(ctx context.Context) error {
+				return redisClient.Ping(ctx).Err()
+			},
+		})
+	}
+
+	if pinger, ok := mail.(mailer.Pinger); ok {
+		deps = append(deps, health.Dependency{
+			Name:  "mailer",
+			Check: pinger.Ping,
+		})
+	}
+
+	return deps
+}
+
+// newMailer выбирает способ отправки писем: outbox фейковых сервисов
+// или драйвер из конфигурации
+func newMailer(cfg *config.Config, outbox *devfake.Outbox) (mailer.Mailer, error) {
+	if outbox != nil {
+		return devfake.NewMailer(outbox), nil
+	}
+	return mailer.New(cfg.Mail)
+}
+
+// registerJobs регистрирует обработчики и расписание фоновых заданий
+func registerJobs(cfg *config.Config, queue *jobs.Queue, mail mailer.Mailer, authService *services.AuthService, userService *services.UserService, publisher *webhooks.Publisher, notifier *notify.Batcher, operationManager *operations.Manager, archiver *archive.Archiver) {
+	queue.Register(jobs.KindSendEmail, jobs.SendEmail(mail))
+	queue.Register(jobs.KindDeliverWebhook, publisher.Deliver)
+	queue.Register(jobs.KindRunOperation, operationManager.Run)
+
+	// Завершенные операции хранятся столько же, сколько завершенные задания
+	queue.Register(jobs.KindPurgeOperations, func(ctx context.Context, _ json.RawMessage) error {
+		return operationManager.Purge(ctx, cfg.Jobs.Retention)
+	})
+	queue.Schedule(jobs.KindPurgeOperations, 24*time.Hour)
+
+	queue.Register(jobs.KindPurgeRefreshTokens, func(ctx context.Context, _ json.RawMessage) error {
+		return authService.PurgeExpiredRefreshTokens(ctx)
+	})
+	queue.Schedule(jobs.KindPurgeRefreshTokens, time.Hour)
+
+	queue.Register(jobs.KindPurgeUserTombstones, func(ctx context.Context, _ json.RawMessage) error {
+		return userService.PurgeUserTombstones(ctx)
+	})
+	queue.Schedule(jobs.KindPurgeUserTombstones, 24*time.Hour)
+
+	// Автоматическая деактивация включается явно: JOBS_INACTIVE_USER_DAYS > 0
+	if cfg.Jobs.InactiveUserDays > 0 {
+		inactiveFor := time.Duration(cfg.Jobs.InactiveUserDays) * 24 * time.Hour
+		queue.Register(jobs.KindDeactivateInactiveUsers, func(ctx context.Context, _ json.RawMessage) error {
+			return userService.DeactivateInactiveUsers(ctx, inactiveFor)
+		})
+		queue.Schedule(jobs.KindDeactivateInactiveUsers, 24*time.Hour)
+	}
+
+	// Архив журналов включается явно: AUDIT_ARCHIVE_AFTER_DAYS или
+	// LOGIN_HISTORY_ARCHIVE_AFTER_DAYS > 0
+	if archiver.Enabled() {
+		queue.Register(jobs.KindArchiveRecords, archiver.Run)
+		queue.Schedule(jobs.KindArchiveRecords, 24*time.Hour)
+	}
+
+	// Накопленные уведомления: пачки с истекшим окном (NOTIFY_BATCH_WINDOWS)
+	if notifier.Enabled() {
+		queue.Register(jobs.KindFlushNotifications, notifier.Flush)
+		queue.Schedule(jobs.KindFlushNotifications, notify.FlushInterval)
+	}
 }
 
 // setupFiberApp настраивает Fiber приложение с middleware
-func setupFiberApp(cfg *config.Config) *fiber.App {
+// allowAllOrigins - CORS_ALLOW_ORIGINS=*, иначе источники проверяет originRegistry
+func setupFiberApp(cfg *config.Config, allowAllOrigins bool, originRegistry *origins.Registry, redactPolicy *redact.Policy, publicID middleware.PublicIDResolver) *fiber.App {
+	// Библиотека JSON уже проверена в Config.Validate
+	codec, _ := jsoncodec.Get(cfg.App.JSONCodec)
+
 	// Создаем новое Fiber приложение с настройками
 	app := fiber.New(fiber.Config{
 		// AppName отображается в заголовках ответов
 		AppName: cfg.App.Name,
-		
+
 		// ServerHeader добавляет кастомный Server заголовок
 		ServerHeader: cfg.App.Name,
-		
-		// ErrorHandler - кастомный обработчик ошибок
-		// Все panic и ошибки будут обработаны здесь
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			code := fiber.StatusInternalServerError
-			
-			// Если это Fiber ошибка, используем её код
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
 
-			return c.Status(code).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		},
+		// В production ASCII баннер Fiber заменяется JSON сводкой logStartupBanner
+		DisableStartupMessage: cfg.App.Env == "production",
+
+		// BodyLimit - максимальный размер тела запроса
+		// Большие тела отклоняются до вызова handlers с 413 Request Entity Too Large
+		BodyLimit: cfg.App.BodyLimit,
+
+		// ReadTimeout - время на чтение запроса вместе с телом
+		// Медленные и оборвавшиеся загрузки обрабатывает middleware.ServerErrorHandler
+		ReadTimeout: cfg.App.ReadTimeout,
+
+		// JSONEncoder/JSONDecoder - библиотека JSON из APP_JSON_CODEC
+		// для c.JSON и BodyParser (см. пакет jsoncodec)
+		JSONEncoder: codec.Marshal,
+		JSONDecoder: codec.Unmarshal,
+
+		// ErrorHandler переводит ошибки handlers и middleware в ответ:
+		// ошибки apperrors - статус их категории, валидация - 422,
+		// лимиты - 429/503 с Retry-After, остальное - 500
+		ErrorHandler: handlers.ErrorHandler,
 	})
 
-	// Middleware для восстановления после паник
-	// Если где-то произойдет panic, приложение не упадет
-	app.Use(recover.New())
+	// Ошибки чтения тела до роутинга: обрыв загрузки - 499, таймаут - 408
+	app.Server().ErrorHandler = middleware.ServerErrorHandler(app)
+
+	// Метрики HTTP запросов по роутам для Prometheus (/metrics)
+	// Подключаются первыми, чтобы учитывать и запросы с паникой
+	app.Use(metrics.Middleware())
+
+	// Идентификатор запроса (X-Request-ID) для связи записей лога с запросом
+	app.Use(middleware.RequestID())
+
+	// Медленные запросы (дольше бюджета роута) пишутся в лог с разбивкой
+	// по этапам. До RequestLogger, чтобы учитывать и перевод ошибки в ответ
+	app.Use(middleware.LatencyBudget(cfg.App.LatencyBudget))
+
+	// Регион экземпляра в X-Served-By (REGION), в том числе в ответах с ошибкой
+	if cfg.App.Region != "" {
+		app.Use(middleware.ServedBy(cfg.App.Region))
+	}
+
+	// Спан OpenTelemetry на каждый запрос; продолжает трассу из traceparent
+	app.Use(tracing.Middleware())
+
+	// Формат ошибок по Accept: application/problem+json (RFC 9457)
+	// или прежний {"error": "..."} до APP_LEGACY_ERRORS_SUNSET
+	// Validate уже проверил формат даты
+	legacyErrorsSunset, _ := time.Parse(time.DateOnly, cfg.App.LegacyErrorsSunset)
+	app.Use(middleware.ErrorFormat(legacyErrorsSunset))
+
+	// IP и User-Agent клиента для метаданных журнала аудита
+	app.Use(middleware.ClientInfo())
 
 	// Middleware для логирования запросов
-	// Логирует каждый HTTP запрос с информацией о методе, пути, статусе и времени
-	app.Use(logger.New(logger.Config{
-		Format:     "[${time}] ${status} - ${latency} ${method} ${path}\n",
-		TimeFormat: "02-Jan-2006 15:04:05",
-		TimeZone:   "Local",
-	}))
+	// Логирует каждый HTTP запрос с методом, путем, статусом, временем и request_id
+	app.Use(middleware.RequestLogger())
+
+	// Middleware для восстановления после паник
+	// Паника handler'а (в том числе при сериализации ответа) - 500
+	// INTERNAL_ERROR, стек - в логе, приложение не падает
+	app.Use(middleware.Recover())
 
 	// CORS middleware для разрешения кросс-доменных запросов
-	// Настройте в production для конкретных доменов
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*", // В production укажите конкретные домены
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-	}))
+	// В production задайте CORS_ALLOW_ORIGINS или источники через admin API
+	corsConfig := cors.Config{
+		// Обычные OPTIONS запросы (не preflight) пропускаем дальше,
+		// чтобы на них ответили роуты из setupOptionsRoutes с заголовком Allow
+		Next: func(c *fiber.Ctx) bool {
+			return c.Method() == fiber.MethodOptions &&
+				c.Get(fiber.HeaderAccessControlRequestMethod) == ""
+		},
+		AllowMethods: "GET,HEAD,POST,PUT,DELETE,OPTIONS",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-Sudo-Token, X-Request-ID, Prefer, traceparent, tracestate",
+		// Заголовки лимитера, X-Request-ID, Sunset и ответа 202 операций доступны JavaScript клиентам в браузере
+		ExposeHeaders: "Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, X-RateLimit-Warning, X-Request-ID, X-Served-By, Sunset, Location, Preference-Applied",
+	}
+	if allowAllOrigins {
+		corsConfig.AllowOrigins = "*"
+	} else {
+		// Проверка по кешу в памяти, без запроса к БД
+		corsConfig.AllowOriginsFunc = originRegistry.Allowed
+	}
+	app.Use(cors.New(corsConfig))
+
+	// Контекст запроса с таймаутом для отмены запросов к БД
+	// Handlers должны передавать в сервисы c.UserContext(), а не c.Context()
+	app.Use(middleware.RequestContext(cfg.App.RequestTimeout))
+
+	// Ограничение вложенности и длины массивов JSON тела запроса
+	app.Use(middleware.JSONLimits(cfg.App.JSONMaxDepth, cfg.App.JSONMaxArrayLen))
+
+	// Подсчет SQL запросов на каждый HTTP запрос (ловит N+1)
+	// Заголовок X-DB-Queries отдаем только в development
+	app.Use(middleware.QueryStats(cfg.App.Env == "development"))
+
+	// Скрытие и маскировка полей ответов по роли и тенанту (RESPONSE_REDACTION)
+	if !redactPolicy.Empty() {
+		app.Use(middleware.Redact(redactPolicy, publicID))
+	}
+
+	// Административные эндпоинты вызываются внутренними сервисами
+	// Если задан ключ, запросы должны быть подписаны и не могут повторяться
+	if cfg.App.ServiceSigningKey != "" {
+		app.Use("/admin/v1", middleware.SignedRequest(
+			cfg.App.ServiceSigningKey,
+			cfg.App.ServiceSignatureMaxAge,
+			middleware.NewMemoryReplayCache(),
+		))
+	}
 
 	return app
 }
 
+// protectedRoutes - префиксы, роуты под которыми не бывают публичными,
+// и обязательный для них scope (routes.Policy.Protected)
+var protectedRoutes = map[string]routes.Scope{
+	"/admin/v1":    routes.ScopeAdmin,
+	"/internal/v1": routes.ScopeService,
+}
+
 // setupRoutes регистрирует все HTTP роуты приложения
-func setupRoutes(app *fiber.App, userHandler *handlers.UserHandler) {
-	// Health check эндпоинт
-	// Используется для проверки доступности сервиса (Kubernetes, Docker)
-	app.Get("/health", userHandler.HealthCheck)
+// Для каждого GET роута Fiber автоматически регистрирует HEAD с тем же обработчиком
+// (fasthttp сам отбрасывает тело ответа), а OPTIONS добавляется в setupOptionsRoutes
+func setupRoutes(
+	app *fiber.App,
+	cfg *config.Config,
+	tokens *auth.TokenManager,
+	signer *signedurl.Signer,
+	limiters *rateLimiters,
+	bulkReadMonitor fiber.Handler,
+	userHandler *handlers.UserHandler,
+	adminHandler *handlers.AdminHandler,
+	healthHandler *handlers.HealthHandler,
+	exportHandler *handlers.ExportHandler,
+	announcementHandler *handlers.AnnouncementHandler,
+	identityHandler *handlers.IdentityHandler,
+	authHandler *handlers.AuthHandler,
+	twoFactorHandler *handlers.TwoFactorHandler,
+	recoveryHandler *handlers.TwoFactorRecoveryHandler,
+	accountHandler *handlers.AccountHandler,
+	digestHandler *handlers.DigestHandler,
+	broadcastHandler *handlers.BroadcastHandler,
+	settingsHandler *handlers.SettingsHandler,
+	avatarHandler *handlers.AvatarHandler,
+	profileHandler *handlers.ProfileHandler,
+	auditHandler *handlers.AuditHandler,
+	systemHandler *handlers.SystemHandler,
+	diagnosticsHandler *handlers.DiagnosticsHandler,
+	schemaHandler *handlers.SchemaHandler,
+	jobsHandler *handlers.JobsHandler,
+	operationHandler *handlers.OperationHandler,
+	archiveHandler *handlers.ArchiveHandler,
+	emailTemplateHandler *handlers.EmailTemplateHandler,
+	webhookHandler *handlers.WebhookHandler,
+	originHandler *handlers.OriginHandler,
+	configHandler *handlers.ConfigHandler,
+	wellKnownHandler *handlers.WellKnownHandler,
+	referenceHandler *handlers.ReferenceHandler,
+	internalHandler *handlers.InternalHandler,
+) {
+	// Дорогие операции ограничены по числу одновременных запросов,
+	// у каждой операции свой лимит
+	exportLimit := middleware.ConcurrencyLimit(cfg.App.ExportConcurrency, cfg.App.ConcurrencyQueueTimeout)
+	avatarLimit := middleware.ConcurrencyLimit(cfg.App.AvatarConcurrency, cfg.App.ConcurrencyQueueTimeout)
+	importLimit := middleware.ConcurrencyLimit(cfg.App.ImportConcurrency, cfg.App.ConcurrencyQueueTimeout)
+
+	// Экспорты дополнительно ограничены по пользователю и IP в длинном окне
+	// (APP_EXPORT_*RATE_LIMIT*). Монитор массовых чтений (Spec.BulkRead)
+	// подключается перед лимитами, чтобы учитывать и отклоненные попытки
+	exportIPLimit := limiters.exportIP.Handler()
+
+	// Внутренние сервисы: ключи по имени сервиса и mTLS (Validate уже
+	// проверил INTERNAL_SERVICE_KEYS). Без ключей и заголовка сертификата
+	// /internal/v1 отвечает 401 на любой запрос
+	serviceKeys, _ := cfg.App.ServiceKeys()
+	serviceAuth := middleware.ServiceAuth{
+		Keys:         serviceKeys,
+		MaxAge:       cfg.App.ServiceSignatureMaxAge,
+		Replay:       middleware.NewMemoryReplayCache(),
+		CertHeader:   cfg.App.InternalCertHeader,
+		CertServices: cfg.App.CertServices(),
+	}
+
+	// Требования доступа и лимиты роутов объявляются в routes.Spec:
+	// из одного описания собираются цепочка middleware и спецификация
+	// GET /openapi.json. Изменяющие запросы требуют access токен,
+	// разрушительные - еще и sudo токен (повторный ввод пароля)
+	registry := routes.NewRegistry(routes.Policy{
+		Scopes: map[routes.Scope][]fiber.Handler{
+			routes.ScopeUser:      {middleware.RequireAuth(tokens)},
+			routes.ScopeAdmin:     {middleware.RequireRole(auth.RoleAdmin)},
+			routes.ScopeSudo:      {middleware.RequireSudo(tokens)},
+			routes.ScopeTwoFactor: {middleware.RequirePendingTwoFactor(tokens)},
+			routes.ScopeSignedURL: {middleware.SignedURL(signer)},
+			routes.ScopeService:   {middleware.RequireService(serviceAuth)},
+		},
+		// Строгие лимиты чувствительных эндпоинтов: подбор паролей (до
+		// проверки токена, чтобы считались и неудачные попытки), рассылка
+		// писем на чужие адреса, массовое создание аккаунтов
+		Tiers: map[routes.Tier]routes.TierHandlers{
+			routes.TierAuth:       {Before: []fiber.Handler{limiters.auth.Handler()}},
+			routes.TierCreateUser: {After: []fiber.Handler{limiters.createUser.Handler()}},
+			routes.TierExport:     {After: []fiber.Handler{limiters.exportUser.Handler(), exportIPLimit, exportLimit}},
+			routes.TierDownload:   {After: []fiber.Handler{exportIPLimit}},
+			routes.TierImport:     {After: []fiber.Handler{importLimit}},
+			routes.TierAvatar:     {After: []fiber.Handler{avatarLimit}},
+		},
+		BulkRead: bulkReadMonitor,
+		// Бюджет времени роута для middleware.LatencyBudget
+		Budget:    middleware.RouteBudget,
+		Protected: protectedRoutes,
+	})
+	user := []routes.Scope{routes.ScopeUser}
+	admin := []routes.Scope{routes.ScopeAdmin}
+	adminSudo := []routes.Scope{routes.ScopeAdmin, routes.ScopeSudo}
+	twoFactor := []routes.Scope{routes.ScopeTwoFactor}
+
+	service := registry.Group(app, "", "Служебные")
+
+	// Liveness: процесс жив и отвечает (livenessProbe Kubernetes)
+	service.Get("/health/live", healthHandler.Liveness, routes.Spec{
+		Summary:  "Процесс жив",
+		Budget:   50 * time.Millisecond,
+		Response: models.HealthResponse{},
+	})
+
+	// Readiness: зависимости доступны и прогрев закончен (readinessProbe Kubernetes)
+	// 503, пока недоступна БД, чтобы трафик уходил на другие инстансы
+	service.Get("/health/ready", healthHandler.Readiness, routes.Spec{
+		Summary:   "Инстанс готов принимать трафик",
+		Response:  models.HealthResponse{},
+		Responses: map[int]interface{}{fiber.StatusServiceUnavailable: models.HealthResponse{}},
+	})
+
+	// Прежний адрес health check, отвечает как /health/ready
+	service.Get("/health", healthHandler.Readiness, routes.Spec{
+		Summary:   "Прежний адрес /health/ready",
+		Response:  models.HealthResponse{},
+		Responses: map[int]interface{}{fiber.StatusServiceUnavailable: models.HealthResponse{}},
+	})
+
+	// Облегченный health check для балансировщиков нагрузки
+	// Результат проверки БД кешируется на несколько секунд
+	service.Get("/health/lb", healthHandler.CachedHealthCheck, routes.Spec{
+		Summary:   "Health check для балансировщиков",
+		Budget:    50 * time.Millisecond,
+		Response:  models.HealthResponse{},
+		Responses: map[int]interface{}{fiber.StatusServiceUnavailable: models.HealthResponse{}},
+	})
+
+	// Метрики в формате Prometheus
+	service.Get("/metrics", metrics.Handler(cfg.App.Region), routes.Spec{
+		Summary: "Метрики Prometheus",
+	})
+
+	// GET /openapi.json - спецификация OpenAPI по объявленным роутам
+	service.Get("/openapi.json", registry.Handler(routes.Info{Title: cfg.App.Name, Version: "v1"}), routes.Spec{
+		Summary: "Спецификация OpenAPI",
+	})
+
+	// Документы /.well-known/, собранные из конфигурации (WELLKNOWN_*, SECURITY_*)
+	// GET /.well-known/security.txt - контакты для сообщений об уязвимостях
+	service.Get(wellknown.SecurityTxtPath, wellKnownHandler.SecurityTxt, routes.Spec{
+		Summary:   "Контакты для сообщений об уязвимостях",
+		Responses: map[int]interface{}{fiber.StatusNotFound: nil},
+	})
+
+	// GET /.well-known/change-password - перенаправление на страницу смены пароля
+	service.Get(wellknown.ChangePasswordPath, wellKnownHandler.ChangePassword, routes.Spec{
+		Summary: "Перенаправление на страницу смены пароля",
+		Status:  fiber.StatusFound,
+	})
+
+	// GET /.well-known/jwks.json - публичные ключи проверки токенов
+	service.Get(wellknown.JWKSPath, wellKnownHandler.JWKS, routes.Spec{
+		Summary:  "Публичные ключи проверки токенов",
+		Response: models.JWKSResponse{},
+	})
+
+	// GET /.well-known/openid-configuration - описание аутентификации
+	service.Get(wellknown.OpenIDConfigurationPath, wellKnownHandler.OpenIDConfiguration, routes.Spec{
+		Summary: "Описание аутентификации",
+	})
 
 	// API группа с префиксом /api/v1
 	// Группировка позволяет применять middleware к группе роутов
 	api := app.Group("/api/v1")
 
+	// Уровень лимитов вызывающего (anonymous, free, pro, internal)
+	// определяется один раз: лимит запросов и ограничения списков
+	// берут пороги этого уровня (APP_LIMIT_TIER_ROLES, APP_*_RATE_LIMIT)
+	limitPolicy := cfg.App.LimitPolicy()
+	api.Use(middleware.IdentifyUser(tokens))
+	api.Use(middleware.ResolveTier(limitPolicy))
+
+	// Лимиты запросов: анонимные клиенты - по IP, пользователи с access
+	// токеном - по пользователю. Сверх мягкого лимита - предупреждение
+	// в X-RateLimit-Warning, сверх жесткого или всплеска - 429
+	api.Use(middleware.TierRateLimit(limiters.tiers))
+
+	// Ограничения списков: размер страницы и фильтры применяются
+	// в пакете query, handlers их не проверяют
+	api.Use(middleware.ListQuota(limitPolicy))
+
+	// Роуты аутентификации
+	authRoutes := registry.Group(api.Group("/auth"), "/api/v1/auth", "Аутентификация")
+	{
+		// POST /api/v1/auth/login - вход по email и паролю
+		authRoutes.Post("/login", authHandler.Login, routes.Spec{
+			Summary:  "Вход по email и паролю",
+			Tier:     routes.TierAuth,
+			Request:  models.LoginRequest{},
+			Response: models.TokenResponse{},
+		})
+
+		// POST /api/v1/auth/2fa - второй шаг входа с 2FA: код из приложения
+		// или код восстановления, в Authorization - two_factor_token из ответа login
+		authRoutes.Post("/2fa", authHandler.CompleteTwoFactor, routes.Spec{
+			Summary:  "Второй шаг входа с 2FA",
+			Scopes:   twoFactor,
+			Tier:     routes.TierAuth,
+			Request:  models.TwoFactorLoginRequest{},
+			Response: models.TokenResponse{},
+		})
+
+		// Восстановление доступа при потере второго фактора и кодов восстановления
+		// POST /api/v1/auth/2fa/recovery - запрос, письмо со ссылкой подтверждения
+		authRoutes.Post("/2fa/recovery", recoveryHandler.Start, routes.Spec{
+			Summary:  "Запрос восстановления доступа без 2FA",
+			Scopes:   twoFactor,
+			Tier:     routes.TierAuth,
+			Response: models.TwoFactorRecoveryResponse{},
+			Status:   fiber.StatusAccepted,
+		})
+
+		// GET /api/v1/auth/2fa/recovery - этапы открытого запроса
+		authRoutes.Get("/2fa/recovery", recoveryHandler.Status, routes.Spec{
+			Summary:  "Этапы открытого запроса восстановления",
+			Scopes:   twoFactor,
+			Tier:     routes.TierAuth,
+			Response: models.TwoFactorRecoveryResponse{},
+		})
+
+		// POST /api/v1/auth/2fa/recovery/confirm - подтверждение по токену из письма
+		authRoutes.Post("/2fa/recovery/confirm", recoveryHandler.Confirm, routes.Spec{
+			Summary:  "Подтверждение восстановления по токену из письма",
+			Tier:     routes.TierAuth,
+			Request:  models.ConfirmTwoFactorRecoveryRequest{},
+			Response: models.TwoFactorRecoveryResponse{},
+		})
+
+		// POST /api/v1/auth/2fa/recovery/complete - отключение 2FA и выдача токенов
+		authRoutes.Post("/2fa/recovery/complete", recoveryHandler.Complete, routes.Spec{
+			Summary:  "Отключение 2FA и выдача токенов",
+			Scopes:   twoFactor,
+			Tier:     routes.TierAuth,
+			Response: models.TokenResponse{},
+		})
+
+		// POST /api/v1/auth/refresh - обмен refresh токена на новую пару
+		authRoutes.Post("/refresh", authHandler.Refresh, routes.Spec{
+			Summary:   "Обмен refresh токена на новую пару",
+			Request:   models.RefreshTokenRequest{},
+			Response:  models.TokenResponse{},
+			Responses: map[int]interface{}{fiber.StatusUnauthorized: nil},
+		})
+
+		// POST /api/v1/auth/logout - отзыв refresh токена
+		authRoutes.Post("/logout", authHandler.Logout, routes.Spec{
+			Summary:   "Отзыв refresh токена",
+			Request:   models.RefreshTokenRequest{},
+			Status:    fiber.StatusNoContent,
+			Responses: map[int]interface{}{fiber.StatusUnauthorized: nil},
+		})
+
+		// POST /api/v1/auth/sudo - повторный ввод пароля, выдача sudo токена
+		authRoutes.Post("/sudo", authHandler.Sudo, routes.Spec{
+			Summary:  "Выдача sudo токена по паролю",
+			Scopes:   user,
+			Tier:     routes.TierAuth,
+			Request:  models.SudoRequest{},
+			Response: models.SudoTokenResponse{},
+		})
+
+		// POST /api/v1/auth/verify-email - подтверждение email по токену из письма
+		authRoutes.Post("/verify-email", accountHandler.VerifyEmail, routes.Spec{
+			Summary:  "Подтверждение email по токену из письма",
+			Request:  models.VerifyEmailRequest{},
+			Response: models.UserResponse{},
+		})
+
+		// POST /api/v1/auth/resend-verification - повторное письмо подтверждения
+		authRoutes.Post("/resend-verification", accountHandler.ResendVerification, routes.Spec{
+			Summary: "Повторное письмо подтверждения email",
+			Tier:    routes.TierAuth,
+			Request: models.EmailRequest{},
+			Status:  fiber.StatusAccepted,
+		})
+
+		// POST /api/v1/auth/forgot-password - письмо со ссылкой сброса пароля
+		authRoutes.Post("/forgot-password", accountHandler.ForgotPassword, routes.Spec{
+			Summary: "Письмо со ссылкой сброса пароля",
+			Tier:    routes.TierAuth,
+			Request: models.EmailRequest{},
+			Status:  fiber.StatusAccepted,
+		})
+
+		// POST /api/v1/auth/reset-password - новый пароль по токену из письма
+		authRoutes.Post("/reset-password", accountHandler.ResetPassword, routes.Spec{
+			Summary: "Новый пароль по токену из письма",
+			Tier:    routes.TierAuth,
+			Request: models.ResetPasswordRequest{},
+			Status:  fiber.StatusNoContent,
+		})
+	}
+
 	// Роуты для пользователей
-	users := api.Group("/users")
+	users := registry.Group(api.Group("/users"), "/api/v1/users", "Пользователи")
 	{
 		// POST /api/v1/users - создание пользователя
-		users.Post("/", userHandler.CreateUser)
-		
-		// GET /api/v1/users - список пользователей
-		users.Get("/", userHandler.ListUsers)
-		
+		users.Post("/", userHandler.CreateUser, routes.Spec{
+			Summary:  "Создание пользователя",
+			Scopes:   user,
+			Tier:     routes.TierCreateUser,
+			Request:  models.CreateUserRequest{},
+			Response: models.UserResponse{},
+			Status:   fiber.StatusCreated,
+		})
+
+		// GET /api/v1/users - список пользователей (только администраторы)
+		users.Get("/", userHandler.ListUsers, routes.Spec{
+			Summary:  "Список пользователей",
+			Scopes:   admin,
+			BulkRead: true,
+			Budget:   500 * time.Millisecond,
+			Response: models.ListUsersResponse{},
+		})
+
+		// POST /api/v1/users/import - массовый импорт из CSV или NDJSON (только администраторы)
+		// С Prefer: respond-async - 202 и операция /admin/v1/operations/:id
+		users.Post("/import", userHandler.ImportUsers, routes.Spec{
+			Summary:   "Импорт пользователей из CSV или NDJSON",
+			Scopes:    admin,
+			Tier:      routes.TierImport,
+			Budget:    routes.NoBudget,
+			Response:  models.ImportUsersResponse{},
+			Responses: map[int]interface{}{fiber.StatusBadRequest: nil},
+		})
+
+		// GET /api/v1/users/export - потоковая выгрузка в CSV или NDJSON (только администраторы)
+		// Регистрируется до /:id, иначе "export" разберется как ID
+		users.Get("/export", userHandler.ExportUsers, routes.Spec{
+			Summary:  "Выгрузка пользователей в CSV или NDJSON",
+			Scopes:   admin,
+			Tier:     routes.TierExport,
+			BulkRead: true,
+			Budget:   routes.NoBudget,
+		})
+
+		// GET /api/v1/users/changes - изменения после токена синхронизации (мобильные клиенты)
+		// Администратор получает изменения всех пользователей, остальные - своей записи
+		users.Get("/changes", userHandler.ListUserChanges, routes.Spec{
+			Summary:  "Изменения пользователей для синхронизации",
+			Scopes:   user,
+			BulkRead: true,
+			Budget:   500 * time.Millisecond,
+			Response: models.UserChangesResponse{},
+		})
+
 		// GET /api/v1/users/:id - получение пользователя
-		users.Get("/:id", userHandler.GetUser)
-		
+		users.Get("/:id", userHandler.GetUser, routes.Spec{
+			Summary:  "Пользователь по ID",
+			Scopes:   user,
+			Budget:   200 * time.Millisecond,
+			Response: models.UserResponse{},
+		})
+
 		// PUT /api/v1/users/:id - обновление пользователя
-		users.Put("/:id", userHandler.UpdateUser)
-		
-		// DELETE /api/v1/users/:id - удаление пользователя
-		users.Delete("/:id", userHandler.DeleteUser)
+		users.Put("/:id", userHandler.UpdateUser, routes.Spec{
+			Summary:  "Обновление пользователя",
+			Scopes:   user,
+			Request:  models.UpdateUserRequest{},
+			Response: models.UserResponse{},
+		})
+
+		// DELETE /api/v1/users/:id - удаление пользователя (только администраторы)
+		users.Delete("/:id", userHandler.DeleteUser, routes.Spec{
+			Summary: "Удаление пользователя",
+			Scopes:  admin,
+			Status:  fiber.StatusNoContent,
+		})
+
+		// PUT /api/v1/users/:id/role - назначение роли (администраторы, sudo режим)
+		users.Put("/:id/role", userHandler.UpdateUserRole, routes.Spec{
+			Summary:  "Назначение роли",
+			Scopes:   adminSudo,
+			Request:  models.UpdateUserRoleRequest{},
+			Response: models.UserResponse{},
+		})
+
+		// PUT /api/v1/users/:id/status - смена состояния аккаунта (только администраторы)
+		users.Put("/:id/status", userHandler.UpdateUserStatus, routes.Spec{
+			Summary:  "Смена состояния аккаунта",
+			Scopes:   admin,
+			Request:  models.UpdateUserStatusRequest{},
+			Response: models.UserResponse{},
+		})
+
+		// POST /api/v1/users/:id/avatar - загрузка аватара (сам пользователь или администратор)
+		users.Post("/:id/avatar", avatarHandler.UploadAvatar, routes.Spec{
+			Summary:  "Загрузка аватара (multipart/form-data)",
+			Scopes:   user,
+			Tier:     routes.TierAvatar,
+			Response: models.UserResponse{},
+		})
+
+		// GET /api/v1/users/:id/avatar - аватар пользователя
+		users.Get("/:id/avatar", avatarHandler.GetAvatar, routes.Spec{
+			Summary: "Аватар пользователя",
+		})
+
+		// Способы входа текущего пользователя (пароль и привязанные провайдеры)
+		// GET /api/v1/users/me/identities - список привязок
+		users.Get("/me/identities", identityHandler.ListIdentities, routes.Spec{
+			Summary:  "Способы входа текущего пользователя",
+			Scopes:   user,
+			Response: models.ListIdentitiesResponse{},
+		})
+
+		// POST /api/v1/users/me/identities - привязка по OAuth токену провайдера
+		users.Post("/me/identities", identityHandler.LinkIdentity, routes.Spec{
+			Summary:  "Привязка провайдера по OAuth токену",
+			Scopes:   user,
+			Request:  models.LinkIdentityRequest{},
+			Response: models.IdentityResponse{},
+			Status:   fiber.StatusCreated,
+		})
+
+		// DELETE /api/v1/users/me/identities/:provider - отвязка провайдера
+		users.Delete("/me/identities/:provider", identityHandler.UnlinkIdentity, routes.Spec{
+			Summary: "Отвязка провайдера",
+			Scopes:  user,
+			Status:  fiber.StatusNoContent,
+		})
+
+		// Двухфакторная аутентификация по кодам из приложения (TOTP)
+		// POST /api/v1/users/me/2fa/enable - секрет и ссылка для QR кода
+		users.Post("/me/2fa/enable", twoFactorHandler.Enable, routes.Spec{
+			Summary:   "Включение 2FA: секрет и ссылка для QR кода",
+			Scopes:    user,
+			Response:  models.TwoFactorEnrollmentResponse{},
+			Responses: map[int]interface{}{fiber.StatusForbidden: nil},
+		})
+
+		// POST /api/v1/users/me/2fa/verify - подтверждение кодом, выдача кодов восстановления
+		users.Post("/me/2fa/verify", twoFactorHandler.Verify, routes.Spec{
+			Summary:   "Подтверждение 2FA кодом, коды восстановления",
+			Scopes:    user,
+			Tier:      routes.TierAuth,
+			Request:   models.TwoFactorVerifyRequest{},
+			Response:  models.TwoFactorVerifyResponse{},
+			Responses: map[int]interface{}{fiber.StatusForbidden: nil},
+		})
+
+		// Еженедельная сводка активности аккаунта на email (по подписке)
+		// GET /api/v1/users/me/digest - состояние подписки
+		users.Get("/me/digest", digestHandler.GetDigest, routes.Spec{
+			Summary:  "Подписка на еженедельную сводку",
+			Scopes:   user,
+			Response: models.DigestSubscriptionResponse{},
+		})
+
+		// PUT /api/v1/users/me/digest - подписка
+		users.Put("/me/digest", digestHandler.Subscribe, routes.Spec{
+			Summary:  "Подписка на еженедельную сводку",
+			Scopes:   user,
+			Response: models.DigestSubscriptionResponse{},
+		})
+
+		// DELETE /api/v1/users/me/digest - отписка
+		users.Delete("/me/digest", digestHandler.Unsubscribe, routes.Spec{
+			Summary: "Отписка от еженедельной сводки",
+			Scopes:  user,
+			Status:  fiber.StatusNoContent,
+		})
+
+		// Что видно в публичном профиле /api/v1/profiles/:username
+		// GET /api/v1/users/me/privacy - настройки приватности
+		users.Get("/me/privacy", httpctx.Adapt(profileHandler.GetPrivacy), routes.Spec{
+			Summary:  "Настройки приватности профиля",
+			Scopes:   user,
+			Response: models.PrivacySettingsResponse{},
+		})
+
+		// PUT /api/v1/users/me/privacy - изменение настроек (передаются только меняемые поля)
+		users.Put("/me/privacy", httpctx.Adapt(profileHandler.UpdatePrivacy), routes.Spec{
+			Summary:  "Изменение настроек приватности",
+			Scopes:   user,
+			Request:  models.UpdatePrivacySettingsRequest{},
+			Response: models.PrivacySettingsResponse{},
+		})
+	}
+
+	public := registry.Group(api, "/api/v1")
+
+	// GET /api/v1/profiles/:username - публичный профиль, доступен без аутентификации
+	// Имя и аватар отдаются, только если пользователь разрешил их показывать
+	public.Get("/profiles/:username", httpctx.Adapt(profileHandler.GetProfile), routes.Spec{
+		Summary:  "Публичный профиль",
+		Tags:     []string{"Пользователи"},
+		Response: models.PublicProfileResponse{},
+	})
+
+	// GET /api/v1/profiles/:username/avatar - аватар публичного профиля (avatar_url профиля)
+	// 404, если пользователь скрыл аватар
+	public.Get("/profiles/:username/avatar", avatarHandler.GetProfileAvatar, routes.Spec{
+		Summary: "Аватар публичного профиля",
+		Tags:    []string{"Пользователи"},
+	})
+
+	// Справочники из данных сборки, доступны без аутентификации
+	// GET /api/v1/reference - список справочников со ссылками на текущую версию
+	public.Get("/reference", referenceHandler.Index, routes.Spec{
+		Summary:  "Список справочников",
+		Tags:     []string{"Справочники"},
+		Response: models.ReferenceIndexResponse{},
+	})
+
+	// GET /api/v1/reference/:name - справочник (roles, countries)
+	// С ?v=<version> из списка ответ кешируется бессрочно
+	public.Get("/reference/:name", referenceHandler.GetDataset, routes.Spec{
+		Summary: "Справочник",
+		Tags:    []string{"Справочники"},
+		Budget:  50 * time.Millisecond,
+	})
+
+	// Роуты асинхронного экспорта
+	exports := registry.Group(api.Group("/exports"), "/api/v1/exports", "Экспорты")
+	{
+		// POST /api/v1/exports - создание задания на экспорт (только администраторы)
+		// Файл формирует операция export_users (/admin/v1/operations/:id)
+		exports.Post("/", exportHandler.CreateExport, routes.Spec{
+			Summary:  "Создание задания на экспорт",
+			Scopes:   admin,
+			Tier:     routes.TierExport,
+			BulkRead: true,
+			Request:  models.CreateExportRequest{},
+			Response: models.OperationResponse{},
+			Status:   fiber.StatusAccepted,
+		})
+
+		// GET /api/v1/exports/:id - статус задания и ссылка на скачивание (только администраторы)
+		exports.Get("/:id", exportHandler.GetExport, routes.Spec{
+			Summary:  "Статус задания и ссылка на скачивание",
+			Scopes:   admin,
+			Response: models.ExportJobResponse{},
+		})
+
+		// GET /api/v1/exports/:id/download - скачивание по подписанной ссылке
+		// Ссылку можно передать дальше, поэтому скачивания считаются и по IP
+		exports.Get("/:id/download", exportHandler.DownloadExport, routes.Spec{
+			Summary:  "Скачивание по подписанной ссылке",
+			Scopes:   []routes.Scope{routes.ScopeSignedURL},
+			Tier:     routes.TierDownload,
+			BulkRead: true,
+			Budget:   routes.NoBudget,
+		})
 	}
 
+	// GET /api/v1/announcements/active - объявления, которые клиент показывает сейчас
+	public.Get("/announcements/active", httpctx.Adapt(announcementHandler.ListActiveAnnouncements), routes.Spec{
+		Summary:  "Объявления, которые клиент показывает сейчас",
+		Tags:     []string{"Объявления"},
+		Response: models.ListAnnouncementsResponse{},
+	})
+
+	// GET /api/v1/audit-logs - журнал изменений пользователей (только администраторы)
+	// Фильтры: actor_id, target_id, action, created_after, created_before
+	public.Get("/audit-logs", auditHandler.ListAuditLogs, routes.Spec{
+		Summary:  "Журнал изменений пользователей",
+		Tags:     []string{"Аудит"},
+		Scopes:   admin,
+		Response: models.ListAuditLogsResponse{},
+	})
+
+	// Административная группа с префиксом /admin/v1
+	// Внутренние эндпоинты для панелей поддержки
+	// Все роуты группы требуют роль администратора (Require): роут,
+	// в Spec которого это забыли, не становится публичным
+	adminRoutes := registry.Group(app.Group("/admin/v1"), "/admin/v1", "Администрирование").Require(routes.ScopeAdmin)
+
+	// GET /admin/v1/users/search - поиск с фильтрами filter[поле][оператор] (только администраторы)
+	adminRoutes.Get("/users/search", adminHandler.SearchUsers, routes.Spec{
+		Summary:  "Поиск пользователей с фильтрами filter[поле][оператор]",
+		BulkRead: true,
+		Response: models.ListUsersResponse{},
+	})
+
+	// GET /admin/v1/users/:id/stats - статистика активности пользователя (только администраторы)
+	adminRoutes.Get("/users/:id/stats", adminHandler.GetUserStats, routes.Spec{
+		Summary:  "Статистика активности пользователя",
+		Response: models.UserStatsResponse{},
+	})
+
+	// POST /admin/v1/users/import - импорт пользователей из внешней системы (только администраторы)
+	// С Prefer: respond-async - 202 и операция /admin/v1/operations/:id
+	adminRoutes.Post("/users/import", adminHandler.ImportUsers, routes.Spec{
+		Summary:   "Импорт пользователей из внешней системы",
+		Tier:      routes.TierImport,
+		Budget:    routes.NoBudget,
+		Response:  models.ImportUsersResponse{},
+		Responses: map[int]interface{}{fiber.StatusBadRequest: nil},
+	})
+
+	// DELETE /admin/v1/users/:id - физическое удаление (необратимо, администраторы в sudo режиме)
+	adminRoutes.Delete("/users/:id", adminHandler.HardDeleteUser, routes.Spec{
+		Summary: "Физическое удаление пользователя",
+		Scopes:  adminSudo,
+		Status:  fiber.StatusNoContent,
+	})
+
+	// POST /admin/v1/users/:id/merge - слияние дубликата с основным аккаунтом
+	// (необратимо, администраторы в sudo режиме)
+	// С Prefer: respond-async - 202 и операция /admin/v1/operations/:id
+	adminRoutes.Post("/users/:id/merge", adminHandler.MergeUsers, routes.Spec{
+		Summary:  "Слияние дубликата с основным аккаунтом",
+		Scopes:   adminSudo,
+		Request:  models.MergeUsersRequest{},
+		Response: models.MergeUsersResponse{},
+	})
+
+	// GET /admin/v1/operations/:id - статус, прогресс и результат операции (Prefer: respond-async)
+	adminRoutes.Get("/operations/:id", operationHandler.GetOperation, routes.Spec{
+		Summary:  "Статус, прогресс и результат длительной операции",
+		Response: models.OperationResponse{},
+	})
+
+	// Архив старых записей журналов (AUDIT_ARCHIVE_AFTER_DAYS)
+	// GET /admin/v1/archives - файлы архива за период (только администраторы)
+	adminRoutes.Get("/archives", archiveHandler.ListArchives, routes.Spec{
+		Summary:   "Файлы архива журналов за период",
+		Response:  models.ListArchivesResponse{},
+		Responses: map[int]interface{}{fiber.StatusBadRequest: nil},
+	})
+
+	// GET /admin/v1/archives/:id/entries - записи файла архива с фильтрами
+	adminRoutes.Get("/archives/:id/entries", archiveHandler.ListEntries, routes.Spec{
+		Summary:   "Записи файла архива",
+		Response:  models.ListArchiveEntriesResponse{},
+		Responses: map[int]interface{}{fiber.StatusBadRequest: nil},
+	})
+
+	// POST /admin/v1/announcements - публикация объявления (только администраторы)
+	adminRoutes.Post("/announcements", httpctx.Adapt(announcementHandler.CreateAnnouncement), routes.Spec{
+		Summary:  "Публикация объявления",
+		Request:  models.CreateAnnouncementRequest{},
+		Response: models.AnnouncementResponse{},
+		Status:   fiber.StatusCreated,
+	})
+
+	// Массовые рассылки по сегменту пользователей (только администраторы)
+	// POST /admin/v1/broadcasts - создание рассылки, письма отправляет воркер
+	adminRoutes.Post("/broadcasts", broadcastHandler.CreateBroadcast, routes.Spec{
+		Summary:  "Создание рассылки",
+		Request:  models.CreateBroadcastRequest{},
+		Response: models.BroadcastResponse{},
+		Status:   fiber.StatusAccepted,
+	})
+
+	// GET /admin/v1/broadcasts/:id - статус и прогресс рассылки
+	adminRoutes.Get("/broadcasts/:id", broadcastHandler.GetBroadcast, routes.Spec{
+		Summary:  "Статус и прогресс рассылки",
+		Response: models.BroadcastResponse{},
+	})
+
+	// POST /admin/v1/broadcasts/:id/cancel - остановка рассылки
+	adminRoutes.Post("/broadcasts/:id/cancel", broadcastHandler.CancelBroadcast, routes.Spec{
+		Summary:  "Остановка рассылки",
+		Response: models.BroadcastResponse{},
+	})
+
+	// Проверка шаблонов писем на примере данных (только администраторы)
+	// GET /admin/v1/email-templates/:name/preview - тема и текст письма
+	adminRoutes.Get("/email-templates/:name/preview", emailTemplateHandler.Preview, routes.Spec{
+		Summary:  "Тема и текст письма на примере данных",
+		Response: models.EmailTemplatePreviewResponse{},
+	})
+
+	// POST /admin/v1/email-templates/:name/test-send - отправка письма с пометкой [Тест]
+	adminRoutes.Post("/email-templates/:name/test-send", emailTemplateHandler.TestSend, routes.Spec{
+		Summary:  "Отправка тестового письма",
+		Request:  models.TestSendEmailTemplateRequest{},
+		Response: models.EmailTemplatePreviewResponse{},
+	})
+
+	// Подписки внешних систем на события пользователей (только администраторы)
+	// POST /admin/v1/webhooks - регистрация подписки, ответ содержит ключ подписи
+	adminRoutes.Post("/webhooks", webhookHandler.CreateWebhook, routes.Spec{
+		Summary:  "Регистрация подписки на события",
+		Request:  models.CreateWebhookRequest{},
+		Response: models.WebhookResponse{},
+		Status:   fiber.StatusCreated,
+	})
+
+	// GET /admin/v1/webhooks - список подписок
+	adminRoutes.Get("/webhooks", webhookHandler.ListWebhooks, routes.Spec{
+		Summary:  "Подписки на события",
+		Response: models.ListWebhooksResponse{},
+	})
+
+	// DELETE /admin/v1/webhooks/:id - удаление подписки
+	adminRoutes.Delete("/webhooks/:id", webhookHandler.DeleteWebhook, routes.Spec{
+		Summary: "Удаление подписки",
+		Status:  fiber.StatusNoContent,
+	})
+
+	// GET /admin/v1/webhooks/:id/deliveries - журнал доставки событий подписки
+	adminRoutes.Get("/webhooks/:id/deliveries", webhookHandler.ListDeliveries, routes.Spec{
+		Summary:  "Журнал доставки событий подписки",
+		Response: models.ListWebhookDeliveriesResponse{},
+	})
+
+	// Настройки, изменяемые без перезапуска (только администраторы)
+	// GET /admin/v1/settings - все настройки с действующими значениями
+	adminRoutes.Get("/settings", settingsHandler.ListSettings, routes.Spec{
+		Summary:  "Настройки с действующими значениями",
+		Response: models.ListSettingsResponse{},
+	})
+
+	// PUT /admin/v1/settings/:key - переопределение значения
+	adminRoutes.Put("/settings/:key", settingsHandler.UpdateSetting, routes.Spec{
+		Summary:  "Переопределение настройки",
+		Request:  models.UpdateSettingRequest{},
+		Response: models.SettingResponse{},
+	})
+
+	// DELETE /admin/v1/settings/:key - сброс к значению из переменных окружения
+	adminRoutes.Delete("/settings/:key", settingsHandler.ResetSetting, routes.Spec{
+		Summary:  "Сброс настройки к значению из окружения",
+		Response: models.SettingResponse{},
+	})
+
+	// Источники CORS и собственные клиенты (фронтенды)
+	// Изменения действуют без перезапуска, на других инстансах - через SETTINGS_REFRESH_INTERVAL
+	// POST /admin/v1/cors-origins - разрешение источника
+	adminRoutes.Post("/cors-origins", originHandler.CreateOrigin, routes.Spec{
+		Summary:  "Разрешение источника CORS",
+		Request:  models.CreateCORSOriginRequest{},
+		Response: models.CORSOriginResponse{},
+		Status:   fiber.StatusCreated,
+	})
+
+	// GET /admin/v1/cors-origins - разрешенные источники
+	adminRoutes.Get("/cors-origins", originHandler.ListOrigins, routes.Spec{
+		Summary:  "Разрешенные источники CORS",
+		Response: models.ListCORSOriginsResponse{},
+	})
+
+	// DELETE /admin/v1/cors-origins/:id - запрет источника
+	adminRoutes.Delete("/cors-origins/:id", originHandler.DeleteOrigin, routes.Spec{
+		Summary: "Запрет источника CORS",
+		Status:  fiber.StatusNoContent,
+	})
+
+	// POST /admin/v1/clients - регистрация клиента с его источниками
+	adminRoutes.Post("/clients", originHandler.CreateClient, routes.Spec{
+		Summary:  "Регистрация клиента",
+		Request:  models.CreateTrustedClientRequest{},
+		Response: models.TrustedClientResponse{},
+		Status:   fiber.StatusCreated,
+	})
+
+	// GET /admin/v1/clients - зарегистрированные клиенты
+	adminRoutes.Get("/clients", originHandler.ListClients, routes.Spec{
+		Summary:  "Зарегистрированные клиенты",
+		Response: models.ListTrustedClientsResponse{},
+	})
+
+	// PUT /admin/v1/clients/:id - замена описания и источников клиента
+	adminRoutes.Put("/clients/:id", originHandler.UpdateClient, routes.Spec{
+		Summary:  "Замена описания и источников клиента",
+		Request:  models.UpdateTrustedClientRequest{},
+		Response: models.TrustedClientResponse{},
+	})
+
+	// DELETE /admin/v1/clients/:id - удаление клиента
+	adminRoutes.Delete("/clients/:id", originHandler.DeleteClient, routes.Spec{
+		Summary: "Удаление клиента",
+		Status:  fiber.StatusNoContent,
+	})
+
+	// GET /admin/v1/system - горутины, память, очереди, кеши и ошибки инстанса (только администраторы)
+	adminRoutes.Get("/system", systemHandler.GetSystem, routes.Spec{
+		Summary:  "Сводка инстанса",
+		Response: models.SystemResponse{},
+	})
+
+	// POST /admin/v1/diagnostics - zip архив для обращения в поддержку: стеки
+	// горутин, последние ошибки, конфигурация без секретов, пул БД и очереди
+	// (только администраторы)
+	adminRoutes.Post("/diagnostics", diagnosticsHandler.CreateBundle, routes.Spec{
+		Summary: "Пакет диагностики инстанса",
+		Budget:  5 * time.Second,
+	})
+
+	// GET /admin/v1/schema - сверка схемы БД с примененными миграциями:
+	// отсутствующие таблицы, колонки и индексы (только администраторы)
+	adminRoutes.Get("/schema", schemaHandler.GetSchemaDrift, routes.Spec{
+		Summary:  "Сверка схемы БД с миграциями",
+		Response: models.SchemaDriftResponse{},
+	})
+
+	// GET /admin/v1/jobs - глубина очереди фоновых заданий (только администраторы)
+	adminRoutes.Get("/jobs", jobsHandler.GetQueue, routes.Spec{
+		Summary:  "Глубина очереди фоновых заданий",
+		Response: models.JobQueueResponse{},
+	})
+
+	// GET /admin/v1/config - действующая конфигурация инстанса и источники
+	// параметров, секреты скрыты (только администраторы)
+	adminRoutes.Get("/config", configHandler.GetConfig, routes.Spec{
+		Summary:  "Действующая конфигурация инстанса",
+		Response: models.ConfigResponse{},
+	})
+
+	// GET /admin/v1/2fa-recoveries - открытые запросы восстановления доступа без 2FA
+	adminRoutes.Get("/2fa-recoveries", recoveryHandler.ListRecoveries, routes.Spec{
+		Summary:  "Открытые запросы восстановления доступа без 2FA",
+		Response: []models.TwoFactorRecoveryResponse{},
+	})
+
+	// POST /admin/v1/2fa-recoveries/:id/approve - одобрение (TWO_FACTOR_RECOVERY_APPROVAL)
+	adminRoutes.Post("/2fa-recoveries/:id/approve", recoveryHandler.ApproveRecovery, routes.Spec{
+		Summary:  "Одобрение восстановления доступа",
+		Response: models.TwoFactorRecoveryResponse{},
+	})
+
+	// POST /admin/v1/2fa-recoveries/:id/reject - отклонение, 2FA остается включенной
+	adminRoutes.Post("/2fa-recoveries/:id/reject", recoveryHandler.RejectRecovery, routes.Spec{
+		Summary:  "Отклонение восстановления доступа",
+		Response: models.TwoFactorRecoveryResponse{},
+	})
+
+	// Внутренняя группа с префиксом /internal/v1 для других сервисов
+	// Вызывается без токена пользователя: подпись ключом сервиса
+	// (INTERNAL_SERVICE_KEYS, клиент - pkg/svcauth) или mTLS через ingress
+	internalRoutes := registry.Group(app.Group("/internal/v1"), "/internal/v1", "Внутренние")
+	serviceOnly := []routes.Scope{routes.ScopeService}
+
+	// GET /internal/v1/users/lookup - пользователь по email
+	internalRoutes.Get("/users/lookup", internalHandler.LookupUser, routes.Spec{
+		Summary:   "Поиск пользователя по email",
+		Scopes:    serviceOnly,
+		Response:  models.UserResponse{},
+		Responses: map[int]interface{}{fiber.StatusBadRequest: nil},
+	})
+
+	// GET /internal/v1/users/:id - пользователь по публичному ID
+	internalRoutes.Get("/users/:id", userHandler.GetUser, routes.Spec{
+		Summary:  "Пользователь по ID",
+		Scopes:   serviceOnly,
+		Response: models.UserResponse{},
+	})
+}
+
+// parseCORSOrigins разбирает CORS_ALLOW_ORIGINS: источники через запятую
+// allowAll - в списке есть "*", остальные источники тогда не нужны
+func parseCORSOrigins(value string) (allowAll bool, list []string) {
+	for _, origin := range strings.Split(value, ",") {
+		origin = strings.TrimSpace(origin)
+		switch origin {
+		case "":
+		case "*":
+			allowAll = true
+		default:
+			list = append(list, origin)
+		}
+	}
+	return allowAll, list
+}
+
+// setupDevRoutes регистрирует служебные роуты локальной разработки
+func setupDevRoutes(app *fiber.App, devHandler *handlers.DevHandler) {
+	dev := app.Group("/dev")
+
+	// GET /dev/outbox - сообщения, перехваченные фейковыми сервисами
+	dev.Get("/outbox", devHandler.ListOutbox)
+
+	// DELETE /dev/outbox - очистка перехваченных сообщений
+	dev.Delete("/outbox", devHandler.ClearOutbox)
+}
+
+// checkWellKnownEndpoints проверяет, что эндпоинты из документов
+// /.well-known/ зарегистрированы
+func checkWellKnownEndpoints(app *fiber.App, documents *wellknown.Documents) error {
+	registered := make(map[string]bool)
+	for _, route := range app.GetRoutes(true) {
+		registered[route.Path] = true
+	}
+	for _, path := range documents.Endpoints() {
+		if !registered[path] {
+			return fmt.Errorf("эндпоинт %s не зарегистрирован", path)
+		}
+	}
+	return nil
+}
+
+// setupFallbackRoutes регистрирует OPTIONS для всех известных путей и 404 обработчик
+// Должна вызываться после регистрации всех остальных роутов
+func setupFallbackRoutes(app *fiber.App) {
+	// OPTIONS для всех зарегистрированных путей
+	setupOptionsRoutes(app)
+
 	// 404 обработчик для неизвестных роутов
 	app.Use(func(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -170,3 +1881,27 @@ func setupRoutes(app *fiber.App, userHandler *handlers.UserHandler) {
 		})
 	})
 }
+
+// setupOptionsRoutes регистрирует OPTIONS обработчик для каждого пути приложения
+// Ответ 204 содержит заголовок Allow со списком методов, доступных для этого пути,
+// вместо того чтобы запрос проваливался в 404 обработчик.
+// CORS preflight запросы сюда не доходят - их раньше обрабатывает cors middleware
+func setupOptionsRoutes(app *fiber.App) {
+	// Собираем методы по путям, сохраняя порядок регистрации путей
+	methods := make(map[string][]string)
+	var paths []string
+	for _, route := range app.GetRoutes(true) {
+		if _, ok := methods[route.Path]; !ok {
+			paths = append(paths, route.Path)
+		}
+		methods[route.Path] = append(methods[route.Path], route.Method)
+	}
+
+	for _, path := range paths {
+		allow := strings.Join(append(methods[path], fiber.MethodOptions), ", ")
+		app.Options(path, func(c *fiber.Ctx) error {
+			c.Set(fiber.HeaderAllow, allow)
+			return c.SendStatus(fiber.StatusNoContent)
+		})
+	}
+}
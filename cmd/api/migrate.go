@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/database"
+	"github.com/Soundveyve/fiber-backend/internal/logging"
+	"github.com/Soundveyve/fiber-backend/internal/migrations"
+)
+
+// migrateUsage - справка по подкоманде migrate
+const migrateUsage = `Использование: fiber-backend migrate <команда>
+
+Команды:
+  up              применить все непримененные миграции
+  down [N]        откатить N последних миграций (по умолчанию 1)
+  status          показать версию схемы и непримененные миграции
+  create <name>   создать пустую пару файлов миграции в ` + migrations.Dir + `
+  force <version> записать версию схемы без выполнения миграций
+                  (после ручного исправления упавшей миграции)`
+
+// runMigrateCommand выполняет подкоманду migrate
+// Подключение к БД берется из той же конфигурации, что и у сервера
+func runMigrateCommand(sources config.Sources, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("не указана команда\n\n%s", migrateUsage)
+	}
+	command, args := args[0], args[1:]
+
+	// create работает только с файлами и не требует конфигурации
+	if command == "create" {
+		if len(args) != 1 {
+			return fmt.Errorf("укажите имя миграции: migrate create <name>")
+		}
+		paths, err := migrations.Create(migrations.Dir, args[0])
+		for _, path := range paths {
+			fmt.Println(path)
+		}
+		return err
+	}
+
+	cfg, err := config.LoadConfig(sources)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки конфигурации: %w", err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.App.Env, cfg.App.LogLevel, cfg.App.Region); err != nil {
+		return fmt.Errorf("ошибка настройки логов: %w", err)
+	}
+
+	// Построение индексов на больших таблицах может идти дольше
+	// таймаута запросов приложения
+	cfg.Database.StatementTimeout = 0
+
+	db, err := database.NewDatabase(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := migrations.New(db.Pool)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		if !applied {
+			fmt.Println("Схема актуальна, новых миграций нет")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			if steps, err = strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("невалидное количество шагов: %q", args[0])
+			}
+		}
+		return migrator.Down(steps)
+
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		printMigrateStatus(status)
+		return nil
+
+	case "force":
+		if len(args) != 1 {
+			return fmt.Errorf("укажите версию: migrate force <version>")
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("невалидная версия: %q", args[0])
+		}
+		return migrator.Force(version)
+	}
+
+	return fmt.Errorf("неизвестная команда %q\n\n%s", command, migrateUsage)
+}
+
+// printMigrateStatus выводит состояние схемы для человека
+func printMigrateStatus(status migrations.Status) {
+	if status.Version == 0 {
+		fmt.Println("Версия схемы: миграции не применялись")
+	} else {
+		fmt.Printf("Версия схемы: %d\n", status.Version)
+	}
+	if status.Dirty {
+		fmt.Printf("⚠️  Миграция %d применена не полностью: исправьте схему и выполните migrate force\n", status.Version)
+	}
+
+	if len(status.Pending) == 0 {
+		fmt.Println("Непримененных миграций нет")
+		return
+	}
+	pending := make([]string, len(status.Pending))
+	for i, version := range status.Pending {
+		pending[i] = strconv.FormatUint(uint64(version), 10)
+	}
+	fmt.Printf("Непримененные миграции (%d): %s\n", len(pending), strings.Join(pending, ", "))
+}
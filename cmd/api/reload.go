@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/logging"
+	"github.com/Soundveyve/fiber-backend/internal/middleware"
+	"github.com/Soundveyve/fiber-backend/internal/origins"
+	"github.com/Soundveyve/fiber-backend/internal/tiers"
+)
+
+// hotReloadFields - поля конфигурации, которые перезагрузка по SIGHUP
+// применяет без перезапуска. Остальные изменения вступают в силу
+// после перезапуска процесса
+var hotReloadFields = map[string]bool{
+	"App.LogLevel":         true,
+	"App.CORSAllowOrigins": true,
+
+	"App.RateLimit":              true,
+	"App.RateLimitSoft":          true,
+	"App.RateLimitBurst":         true,
+	"App.RateLimitWindow":        true,
+	"App.UserRateLimit":          true,
+	"App.UserRateLimitSoft":      true,
+	"App.UserRateLimitBurst":     true,
+	"App.ProRateLimit":           true,
+	"App.ProRateLimitSoft":       true,
+	"App.ProRateLimitBurst":      true,
+	"App.InternalRateLimit":      true,
+	"App.InternalRateLimitSoft":  true,
+	"App.InternalRateLimitBurst": true,
+	"App.AuthRateLimit":          true,
+	"App.CreateUserRateLimit":    true,
+
+	"App.ExportRateLimit":       true,
+	"App.ExportIPRateLimit":     true,
+	"App.ExportRateLimitWindow": true,
+}
+
+// rateLimiters - лимиты запросов /api/v1, значения которых меняет перезагрузка
+type rateLimiters struct {
+	tiers      map[tiers.Tier]*middleware.RateLimiter // По уровню вызывающего (tiers)
+	auth       *middleware.RateLimiter                // Вход и письма по IP
+	createUser *middleware.RateLimiter                // Создание пользователей
+	exportUser *middleware.RateLimiter                // Экспорты одним пользователем
+	exportIP   *middleware.RateLimiter                // Экспорты с одного IP
+}
+
+// newRateLimiters создает лимиты запросов по конфигурации
+// REDIS_RATE_LIMIT_FALLBACK=closed отклоняет запросы, пока счетчики
+// недоступны; в остальных режимах такие запросы пропускаются
+func newRateLimiters(cfg *config.Config, store middleware.RateLimitStore) *rateLimiters {
+	failClosed := cfg.Redis.RateLimitFallback == "closed"
+	l := &rateLimiters{
+		tiers:      make(map[tiers.Tier]*middleware.RateLimiter, len(tiers.All)),
+		auth:       middleware.NewRateLimiter(middleware.RateLimitConfig{Name: "auth", Store: store, KeyFunc: middleware.IPKey, FailClosed: failClosed}),
+		createUser: middleware.NewRateLimiter(middleware.RateLimitConfig{Name: "create_user", Store: store, KeyFunc: middleware.UserKey, FailClosed: failClosed}),
+		exportUser: middleware.NewRateLimiter(middleware.RateLimitConfig{Name: "export_user", Store: store, KeyFunc: middleware.UserKey, FailClosed: failClosed}),
+		exportIP:   middleware.NewRateLimiter(middleware.RateLimitConfig{Name: "export_ip", Store: store, KeyFunc: middleware.IPKey, FailClosed: failClosed}),
+	}
+	for _, t := range tiers.All {
+		l.tiers[t] = middleware.NewRateLimiter(middleware.RateLimitConfig{Name: string(t), Store: store, KeyFunc: middleware.CallerKey, FailClosed: failClosed})
+	}
+	l.apply(cfg)
+	return l
+}
+
+// apply устанавливает значения лимитов из конфигурации
+func (l *rateLimiters) apply(cfg *config.Config) {
+	for t, limits := range cfg.App.LimitPolicy().Limits {
+		l.tiers[t].SetLimits(limits.RateLimit, limits.RateLimitSoft, limits.RateLimitBurst, cfg.App.RateLimitWindow)
+	}
+	l.auth.SetLimits(cfg.App.AuthRateLimit, 0, 0, cfg.App.RateLimitWindow)
+	l.createUser.SetLimits(cfg.App.CreateUserRateLimit, 0, 0, cfg.App.RateLimitWindow)
+	l.exportUser.SetLimits(cfg.App.ExportRateLimit, 0, 0, cfg.App.ExportRateLimitWindow)
+	l.exportIP.SetLimits(cfg.App.ExportIPRateLimit, 0, 0, cfg.App.ExportRateLimitWindow)
+}
+
+// reloader перечитывает конфигурацию по SIGHUP
+type reloader struct {
+	sources  config.Sources
+	started  *config.Config // Конфигурация запуска: по ней работает все, кроме hotReloadFields
+	current  *config.Current
+	limiters *rateLimiters
+	origins  *origins.Registry
+}
+
+// run перезагружает конфигурацию на каждый SIGHUP, пока не отменен ctx
+// Ошибка перезагрузки не останавливает сервер: действует прежняя конфигурация
+func (r *reloader) run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("🔄 Получен SIGHUP, перезагружаем конфигурацию")
+			if err := r.reload(); err != nil {
+				slog.Error("❌ Конфигурация не перезагружена, действует прежняя", "error", err)
+			}
+		}
+	}
+}
+
+// reload загружает конфигурацию из тех же источников и применяет
+// изменения hotReloadFields. Новые значения сначала проверяются,
+// поэтому при ошибке не применяется ни одно из них
+func (r *reloader) reload() error {
+	prev := r.current.Get()
+	next, err := config.LoadConfig(r.sources)
+	if err != nil {
+		return err
+	}
+
+	// 1. Проверяем значения, которые не проверяет config.Validate
+	if _, err := logging.ParseLevel(next.App.LogLevel); err != nil {
+		return err
+	}
+
+	// 2. Источники CORS: список меняется на лету, но переключение
+	// между "*" и списком меняет CORS middleware и требует перезапуска
+	restartRequired := restartRequiredFields(r.started, next)
+	startedAllowAll, _ := parseCORSOrigins(r.started.App.CORSAllowOrigins)
+	allowAll, static := parseCORSOrigins(next.App.CORSAllowOrigins)
+	if allowAll != startedAllowAll {
+		restartRequired = append(restartRequired, "App.CORSAllowOrigins")
+	} else if err := r.origins.SetStatic(static); err != nil {
+		return err
+	}
+
+	// 3. Уровень логов и лимиты запросов
+	_ = logging.SetLevel(next.App.LogLevel)
+	r.limiters.apply(next)
+
+	r.current.Set(next, restartRequired)
+
+	slog.Info("✅ Конфигурация перезагружена", "changed", prev.Changed(next))
+	if len(restartRequired) > 0 {
+		slog.Warn("⚠️  Часть изменений вступит в силу после перезапуска", "fields", restartRequired)
+	}
+	return nil
+}
+
+// restartRequiredFields возвращает поля, которые отличаются от конфигурации
+// запуска и не применяются без перезапуска
+func restartRequiredFields(started, next *config.Config) []string {
+	var fields []string
+	for _, field := range started.Changed(next) {
+		if !hotReloadFields[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/database"
+	"github.com/Soundveyve/fiber-backend/internal/emailaddr"
+	"github.com/Soundveyve/fiber-backend/internal/logging"
+	"github.com/Soundveyve/fiber-backend/internal/seed"
+)
+
+// seedUsage - справка по подкоманде seed
+const seedUsage = `Использование: fiber-backend seed
+
+Записывает в базу набор тестовых пользователей с постоянными id и
+паролем: администратор, активные, неподтвержденный, заблокированный,
+деактивированный и удаленный. Повторный запуск возвращает их в исходное
+состояние. Не запускается при APP_ENV=production`
+
+// runSeedCommand выполняет подкоманду seed
+// Подключение к БД берется из той же конфигурации, что и у сервера
+func runSeedCommand(sources config.Sources, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("лишние аргументы: %v\n\n%s", args, seedUsage)
+	}
+
+	cfg, err := config.LoadConfig(sources)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки конфигурации: %w", err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.App.Env, cfg.App.LogLevel, cfg.App.Region); err != nil {
+		return fmt.Errorf("ошибка настройки логов: %w", err)
+	}
+
+	// Набор перезаписывает пользователей с id 1..1000
+	if cfg.App.Env == "production" {
+		return fmt.Errorf("seed не запускается при APP_ENV=production")
+	}
+	// Канонические email - по тем же правилам, что у сервера
+	emailaddr.Use(emailRules(cfg.Auth))
+
+	db, err := database.NewDatabase(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := seed.Apply(context.Background(), db.Pool); err != nil {
+		return err
+	}
+	for _, u := range seed.Users() {
+		fmt.Printf("%4d  %-12s %-26s %-8s %s\n", u.ID, u.Username, u.Email, u.Role, u.Status)
+	}
+	fmt.Printf("Тестовые данные записаны в %s, пароль всех пользователей: %s\n", cfg.Database.Name, seed.Password)
+	return nil
+}
@@ -0,0 +1,59 @@
+// Package adminui - встроенный в бинарник интерфейс администратора (/admin).
+//
+// Небольшим инсталляциям не нужен отдельный фронтенд для поддержки:
+// страница ищет пользователей, приостанавливает и возвращает аккаунты и
+// показывает журнал аудита. Она статическая и не имеет своего доступа к
+// данным: вход - через POST /api/v1/auth/login, дальше те же вызовы
+// admin API с access токеном, что и у внешних панелей (роль admin
+// проверяется на сервере).
+//
+// Файлы лежат в static/ и встраиваются go:embed, сборка фронтенда не
+// нужна. Скрипт и стили отдаются отдельными файлами, без inline кода,
+// поэтому страница работает под строгой Content-Security-Policy.
+package adminui
+
+import (
+	"embed"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+)
+
+// Path - адрес интерфейса
+const Path = "/admin"
+
+// contentSecurityPolicy - страница загружает только свои файлы и
+// обращается только к API того же источника
+const contentSecurityPolicy = "default-src 'none'; script-src 'self'; style-src 'self'; " +
+	"connect-src 'self'; img-src 'self' data:; form-action 'none'; frame-ancestors 'none'; base-uri 'none'"
+
+//go:embed static
+var static embed.FS
+
+// Register регистрирует страницу GET /admin и ее файлы /admin/assets/*
+// Пути не пересекаются с /admin/v1: неизвестный роут admin API
+// по-прежнему отвечает 404, а не страницей
+func Register(app *fiber.App) {
+	index, err := static.ReadFile("static/index.html")
+	if err != nil {
+		// Файл встроен при сборке, ошибка возможна только при его удалении из static/
+		panic(err)
+	}
+
+	app.Get(Path, func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentSecurityPolicy, contentSecurityPolicy)
+		c.Set(fiber.HeaderXFrameOptions, "DENY")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Type("html", "utf-8")
+		return c.Send(index)
+	})
+
+	// Кеш файлов короткий: после обновления бинарника клиенты получат
+	// новую версию без смены имен файлов
+	app.Use(Path+"/assets", filesystem.New(filesystem.Config{
+		Root:       http.FS(static),
+		PathPrefix: "static/assets",
+		MaxAge:     300,
+	}))
+}
@@ -0,0 +1,69 @@
+package adminui
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRegister(t *testing.T) {
+	app := fiber.New()
+	app.Get("/admin/v1/users/search", func(c *fiber.Ctx) error {
+		return c.SendString("api")
+	})
+	Register(app)
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		contains    string
+	}{
+		{"/admin", fiber.StatusOK, fiber.MIMETextHTML, `src="/admin/assets/app.js"`},
+		{"/admin/", fiber.StatusOK, fiber.MIMETextHTML, "<title>"},
+		{"/admin/assets/app.js", fiber.StatusOK, "javascript", "/admin/v1/users/search"},
+		{"/admin/assets/style.css", fiber.StatusOK, "text/css", "body"},
+		{"/admin/assets/missing.js", fiber.StatusNotFound, "", ""},
+		// admin API не перекрывается страницей
+		{"/admin/v1/users/search", fiber.StatusOK, "", "api"},
+		{"/admin/v1/unknown", fiber.StatusNotFound, "", ""},
+	}
+	for _, tc := range tests {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tc.path, nil))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s: статус %d, ожидался %d", tc.path, resp.StatusCode, tc.status)
+			continue
+		}
+		if ct := resp.Header.Get(fiber.HeaderContentType); !strings.Contains(ct, tc.contentType) {
+			t.Errorf("%s: Content-Type %q, ожидался %q", tc.path, ct, tc.contentType)
+		}
+		if !strings.Contains(string(body), tc.contains) {
+			t.Errorf("%s: в ответе нет %q", tc.path, tc.contains)
+		}
+	}
+}
+
+func TestIndexHeaders(t *testing.T) {
+	app := fiber.New()
+	Register(app)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, Path, nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	// Без inline скриптов страница работает под строгой CSP
+	if csp := resp.Header.Get(fiber.HeaderContentSecurityPolicy); !strings.Contains(csp, "script-src 'self'") ||
+		strings.Contains(csp, "unsafe-inline") {
+		t.Errorf("Content-Security-Policy %q", csp)
+	}
+	if got := resp.Header.Get(fiber.HeaderXFrameOptions); got != "DENY" {
+		t.Errorf("X-Frame-Options %q, ожидался DENY", got)
+	}
+}
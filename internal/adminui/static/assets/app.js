@@ -0,0 +1,255 @@
+// Интерфейс администратора: вызовы admin API с access токеном.
+// Токен хранится в sessionStorage и пропадает при закрытии вкладки.
+"use strict";
+
+const PAGE_SIZE = 20;
+const TOKEN_KEY = "admin_access_token";
+
+const $ = (id) => document.getElementById(id);
+
+let twoFactorToken = "";
+
+// api выполняет запрос к API и возвращает JSON ответа
+// token - токен вместо сохраненного access токена, "" - без токена
+// Ошибка API (models.ErrorResponse) выбрасывается с текстом error
+async function api(method, path, body, token) {
+  const headers = { Accept: "application/json" };
+  const auth = token !== undefined ? token : sessionStorage.getItem(TOKEN_KEY);
+  if (auth) {
+    headers.Authorization = "Bearer " + auth;
+  }
+  if (body !== undefined) {
+    headers["Content-Type"] = "application/json";
+  }
+
+  const resp = await fetch(path, {
+    method,
+    headers,
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+  if (resp.status === 401 && token === undefined) {
+    logout();
+    throw new Error("Сессия истекла, войдите снова");
+  }
+  const data = resp.status === 204 ? null : await resp.json().catch(() => null);
+  if (!resp.ok) {
+    throw new Error((data && data.error) || resp.status + " " + resp.statusText);
+  }
+  return data;
+}
+
+function showError(err) {
+  $("error").textContent = err ? err.message || String(err) : "";
+  $("error").hidden = !err;
+}
+
+// run выполняет действие и показывает ошибку вместо исключения
+async function run(action) {
+  showError(null);
+  try {
+    await action();
+  } catch (err) {
+    showError(err);
+  }
+}
+
+// cell создает ячейку таблицы с текстом (textContent, не HTML)
+function cell(row, text) {
+  const td = document.createElement("td");
+  td.textContent = text == null ? "" : String(text);
+  row.appendChild(td);
+  return td;
+}
+
+function formatTime(value) {
+  return value ? new Date(value).toLocaleString() : "";
+}
+
+// pager рисует кнопки страниц; load(page) загружает страницу
+function pager(el, resp, load) {
+  el.replaceChildren();
+  const info = document.createElement("span");
+  info.textContent = "Страница " + resp.page + " из " + Math.max(resp.total_pages, 1) + ", всего " + resp.total_count;
+  const prev = document.createElement("button");
+  prev.type = "button";
+  prev.textContent = "←";
+  prev.disabled = !resp.has_prev;
+  prev.addEventListener("click", () => run(() => load(resp.page - 1)));
+  const next = document.createElement("button");
+  next.type = "button";
+  next.textContent = "→";
+  next.disabled = !resp.has_next;
+  next.addEventListener("click", () => run(() => load(resp.page + 1)));
+  el.append(prev, info, next);
+}
+
+// Вход
+
+function showSection(name) {
+  const loggedIn = Boolean(sessionStorage.getItem(TOKEN_KEY));
+  $("nav").hidden = !loggedIn;
+  $("login").hidden = loggedIn;
+  for (const id of ["users", "audit"]) {
+    $(id).hidden = !loggedIn || id !== name;
+  }
+}
+
+function logout() {
+  sessionStorage.removeItem(TOKEN_KEY);
+  twoFactorToken = "";
+  $("login-form").hidden = false;
+  $("twofa-form").hidden = true;
+  showSection("");
+}
+
+// claims возвращает данные access токена (JWT) без проверки подписи:
+// только для подсказок интерфейса, права проверяет сервер
+function claims(token) {
+  try {
+    const payload = token.split(".")[1].replace(/-/g, "+").replace(/_/g, "/");
+    return JSON.parse(atob(payload));
+  } catch {
+    return {};
+  }
+}
+
+// finishLogin сохраняет токен администратора
+function finishLogin(tokens) {
+  const user = claims(tokens.access_token);
+  if (user.role !== "admin") {
+    throw new Error("Вход доступен только администраторам");
+  }
+  sessionStorage.setItem(TOKEN_KEY, tokens.access_token);
+  $("whoami").textContent = user.username || "";
+  route();
+}
+
+$("login-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  const form = new FormData(event.target);
+  run(async () => {
+    const tokens = await api("POST", "/api/v1/auth/login", {
+      email: form.get("email"),
+      password: form.get("password"),
+    }, "");
+    if (tokens.two_factor_required) {
+      twoFactorToken = tokens.two_factor_token;
+      $("login-form").hidden = true;
+      $("twofa-form").hidden = false;
+      return;
+    }
+    finishLogin(tokens);
+  });
+});
+
+$("twofa-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  const form = new FormData(event.target);
+  run(async () => {
+    const tokens = await api("POST", "/api/v1/auth/2fa", { code: form.get("code") }, twoFactorToken);
+    finishLogin(tokens);
+  });
+});
+
+$("logout").addEventListener("click", logout);
+
+// Пользователи
+
+async function loadUsers(page) {
+  const form = new FormData($("users-form"));
+  const params = new URLSearchParams({ page: String(page), page_size: String(PAGE_SIZE) });
+  if (form.get("email")) params.set("filter[email][contains]", form.get("email"));
+  if (form.get("username")) params.set("filter[username][contains]", form.get("username"));
+  if (form.get("status")) params.set("filter[status]", form.get("status"));
+  if (form.get("role")) params.set("filter[role]", form.get("role"));
+
+  const resp = await api("GET", "/admin/v1/users/search?" + params);
+  const rows = $("users-rows");
+  rows.replaceChildren();
+  for (const user of resp.users) {
+    const row = document.createElement("tr");
+    cell(row, user.id);
+    cell(row, user.email);
+    cell(row, user.username);
+    cell(row, user.role);
+    cell(row, user.status);
+    cell(row, formatTime(user.created_at));
+    const actions = cell(row, "");
+
+    // Приостановка и возврат аккаунта: PUT /api/v1/users/:id/status
+    const target = user.status === "suspended" ? "active" : "suspended";
+    if (user.status === "active" || user.status === "suspended") {
+      const button = document.createElement("button");
+      button.type = "button";
+      button.textContent = target === "suspended" ? "Приостановить" : "Вернуть";
+      button.addEventListener("click", () => run(async () => {
+        if (!confirm(button.textContent + " " + user.email + "?")) return;
+        await api("PUT", "/api/v1/users/" + encodeURIComponent(user.id) + "/status", { status: target });
+        await loadUsers(page);
+      }));
+      actions.appendChild(button);
+    }
+
+    const history = document.createElement("a");
+    history.href = "#audit";
+    history.textContent = "История";
+    history.addEventListener("click", () => {
+      $("audit-form").elements.target_id.value = user.id;
+    });
+    actions.appendChild(history);
+    rows.appendChild(row);
+  }
+  pager($("users-pager"), resp, loadUsers);
+}
+
+$("users-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  run(() => loadUsers(1));
+});
+
+// Журнал аудита
+
+async function loadAudit(page) {
+  const form = new FormData($("audit-form"));
+  const params = new URLSearchParams({ page: String(page), page_size: String(PAGE_SIZE) });
+  for (const name of ["target_id", "actor_id", "action"]) {
+    if (form.get(name)) params.set(name, form.get(name));
+  }
+
+  const resp = await api("GET", "/api/v1/audit-logs?" + params);
+  const rows = $("audit-rows");
+  rows.replaceChildren();
+  for (const entry of resp.logs) {
+    const row = document.createElement("tr");
+    cell(row, formatTime(entry.created_at));
+    cell(row, entry.action);
+    cell(row, entry.actor_id || "система");
+    cell(row, entry.target_user_id);
+    const changes = cell(row, "");
+    const pre = document.createElement("pre");
+    pre.textContent = entry.changes ? JSON.stringify(entry.changes, null, 2) : "";
+    changes.appendChild(pre);
+    cell(row, entry.ip);
+    rows.appendChild(row);
+  }
+  pager($("audit-pager"), resp, loadAudit);
+}
+
+$("audit-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  run(() => loadAudit(1));
+});
+
+// Разделы по адресу: #users, #audit
+
+function route() {
+  const token = sessionStorage.getItem(TOKEN_KEY);
+  $("whoami").textContent = token ? claims(token).username || "" : "";
+  const name = location.hash === "#audit" ? "audit" : "users";
+  showSection(name);
+  if (!sessionStorage.getItem(TOKEN_KEY)) return;
+  run(() => (name === "audit" ? loadAudit(1) : loadUsers(1)));
+}
+
+window.addEventListener("hashchange", route);
+route();
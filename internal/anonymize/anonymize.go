@@ -0,0 +1,166 @@
+// Package anonymize обезличивает копию production базы для staging и
+// отладки (fiber-backend anonymize).
+//
+// Персональные данные заменяются на месте, а связи, объемы и распределения
+// сохраняются: пользователь 42 остается пользователем 42 со своей ролью,
+// статусом, датами и записями аудита, но становится user42@example.com.
+// Значения выводятся из первичного ключа, поэтому уникальные индексы не
+// нарушаются и повторный запуск дает тот же результат.
+//
+// Секреты, которые нельзя обезличить (токены, TOTP, тела событий и
+// заданий), удаляются. Все шаги выполняются одной транзакцией: ошибка
+// любого шага (например, схема старее миграций приложения) оставляет
+// базу нетронутой.
+//
+// Новая колонка с персональными данными должна попасть в один из шагов
+// Steps - иначе TestStepsCoverSensitiveColumns укажет на нее.
+package anonymize
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Soundveyve/fiber-backend/internal/passhash"
+)
+
+// Password - пароль всех пользователей после обезличивания
+const Password = "anonymized"
+
+// EmailDomain - домен обезличенных адресов; зарезервирован (RFC 2606),
+// письма со staging на него никому не придут
+const EmailDomain = "example.com"
+
+// Step - один шаг обезличивания
+type Step struct {
+	Name  string
+	Table string
+
+	// Columns - колонки, которые шаг заменяет; nil - шаг удаляет строки
+	// таблицы целиком
+	Columns []string
+
+	SQL  string
+	Args []any
+}
+
+// Result - число строк, измененных шагом
+type Result struct {
+	Step string
+	Rows int64
+}
+
+// Steps возвращает шаги обезличивания
+// passwordHash - хеш Password, одинаковый у всех пользователей
+func Steps(passwordHash string) []Step {
+	return []Step{
+		{
+			Name:    "users",
+			Table:   "users",
+			Columns: []string{"email", "canonical_email", "username", "password_hash", "first_name", "last_name", "phone", "avatar_key"},
+			SQL: `UPDATE users SET
+				email = 'user' || id || '@' || $2,
+				canonical_email = CASE WHEN canonical_email IS NULL THEN NULL ELSE 'user' || id || '@' || $2 END,
+				username = 'user' || id,
+				password_hash = $1,
+				first_name = CASE WHEN first_name IS NULL THEN NULL ELSE 'User' END,
+				last_name = CASE WHEN last_name IS NULL THEN NULL ELSE id::text END,
+				phone = CASE WHEN phone IS NULL THEN NULL ELSE '+1555' || lpad((id % 10000000)::text, 7, '0') END,
+				avatar_key = NULL`,
+			Args: []any{passwordHash, EmailDomain},
+		},
+		{
+			Name:    "user_identities",
+			Table:   "user_identities",
+			Columns: []string{"provider_user_id", "email"},
+			SQL: `UPDATE user_identities SET
+				provider_user_id = 'anonymized-' || id,
+				email = CASE WHEN email IS NULL THEN NULL ELSE 'user' || user_id || '@' || $1 END`,
+			Args: []any{EmailDomain},
+		},
+		{
+			Name:    "username_history",
+			Table:   "username_history",
+			Columns: []string{"username"},
+			SQL:     `UPDATE username_history SET username = 'user' || user_id || '-' || id`,
+		},
+		{
+			// Действие, автор и время остаются, значения изменений скрываются
+			Name:    "audit_logs",
+			Table:   "audit_logs",
+			Columns: []string{"changes", "ip", "user_agent"},
+			SQL: `UPDATE audit_logs SET
+				changes = CASE WHEN jsonb_typeof(changes) = 'object' THEN COALESCE(
+					(SELECT jsonb_object_agg(field, '{"old": "***", "new": "***"}'::jsonb) FROM jsonb_object_keys(changes) AS field),
+					'{}'::jsonb) ELSE '{}'::jsonb END,
+				ip = NULL,
+				user_agent = NULL`,
+		},
+		{
+			// События ушли бы на адреса production подписчиков
+			Name:    "webhooks",
+			Table:   "webhooks",
+			Columns: []string{"url", "secret"},
+			SQL: `UPDATE webhooks SET
+				url = 'https://webhooks.' || $1 || '/' || id,
+				secret = md5(random()::text || id)`,
+			Args: []any{EmailDomain},
+		},
+		{
+			// Входы остаются с пользователем и временем, без клиента
+			Name:    "login_history",
+			Table:   "login_history",
+			Columns: []string{"ip", "user_agent"},
+			SQL:     `UPDATE login_history SET ip = NULL, user_agent = NULL`,
+		},
+		{
+			// Файлы выгрузок лежат в production хранилище
+			Name:    "export_jobs",
+			Table:   "export_jobs",
+			Columns: []string{"storage_key"},
+			SQL:     `UPDATE export_jobs SET storage_key = NULL`,
+		},
+		deleteStep("webhook_deliveries"),
+		deleteStep("jobs"),
+		deleteStep("operations"), // Параметры и результаты импорта
+		deleteStep("archives"),   // Файлы архива журналов лежат в production хранилище
+		deleteStep("refresh_tokens"),
+		deleteStep("user_tokens"),
+		deleteStep("user_totp"),
+		deleteStep("user_recovery_codes"),
+	}
+}
+
+// deleteStep удаляет все строки таблицы
+func deleteStep(table string) Step {
+	return Step{Name: table, Table: table, SQL: "DELETE FROM " + table}
+}
+
+// Run обезличивает базу одной транзакцией
+func Run(ctx context.Context, pool *pgxpool.Pool) ([]Result, error) {
+	hash, err := passhash.Hash(Password)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка хеширования пароля: %w", err)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var results []Result
+	for _, step := range Steps(string(hash)) {
+		tag, err := tx.Exec(ctx, step.SQL, step.Args...)
+		if err != nil {
+			return nil, fmt.Errorf("шаг %s: %w", step.Name, err)
+		}
+		results = append(results, Result{Step: step.Name, Rows: tag.RowsAffected()})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,52 @@
+package anonymize
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Soundveyve/fiber-backend/internal/migrations"
+)
+
+// sensitiveColumns - имена колонок с персональными данными и секретами
+var sensitiveColumns = []string{
+	"email", "canonical_email", "username", "password_hash", "first_name", "last_name", "phone", "avatar_key",
+	"provider_user_id", "ip", "user_agent", "changes", "payload", "url", "secret",
+	"secret_encrypted", "token_hash", "code_hash", "storage_key", "input", "result",
+}
+
+func TestStepsCoverSensitiveColumns(t *testing.T) {
+	schema, err := migrations.Expected(^uint(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := Steps("hash")
+
+	// Шаги ссылаются только на существующие таблицы и колонки
+	for _, step := range steps {
+		columns, ok := schema.Tables[step.Table]
+		if !ok {
+			t.Errorf("шаг %s: таблицы %s нет в миграциях", step.Name, step.Table)
+			continue
+		}
+		for _, column := range step.Columns {
+			if !columns[column] {
+				t.Errorf("шаг %s: колонки %s.%s нет в миграциях", step.Name, step.Table, column)
+			}
+		}
+	}
+
+	// Каждая колонка с персональными данными заменяется или удаляется
+	for table, columns := range schema.Tables {
+		for column := range columns {
+			if !slices.Contains(sensitiveColumns, column) {
+				continue
+			}
+			covered := slices.ContainsFunc(steps, func(s Step) bool {
+				return s.Table == table && (s.Columns == nil || slices.Contains(s.Columns, column))
+			})
+			if !covered {
+				t.Errorf("%s.%s не обезличивается: добавьте колонку в Steps", table, column)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package apperrors - типизированные ошибки предметной области.
+//
+// Сервисы возвращают *Error с категорией (ErrNotFound, ErrConflict, ...)
+// и кодом для клиента, а ErrorHandler приложения переводит их в HTTP
+// статус и ErrorResponse. Handlers не разбирают ошибки сервисов сами:
+// достаточно вернуть ошибку из обработчика.
+//
+// Ошибки пакета можно оборачивать (fmt.Errorf("%w: ...", err)): категория
+// и код находятся через errors.As, а текст ответа берется из внешней
+// ошибки вместе с уточнением.
+package apperrors
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Категории ошибок. По категории ErrorHandler выбирает HTTP статус,
+// а сервисы и handlers проверяют ее через errors.Is
+var (
+	ErrInvalid      = errors.New("невалидный запрос")             // 400
+	ErrUnauthorized = errors.New("требуется аутентификация")      // 401
+	ErrForbidden    = errors.New("доступ запрещен")               // 403
+	ErrNotFound     = errors.New("не найдено")                    // 404
+	ErrConflict     = errors.New("конфликт с текущим состоянием") // 409
+	ErrGone         = errors.New("данные больше недоступны")      // 410
+	ErrTooLarge     = errors.New("слишком большой объем данных")  // 413
+	ErrUnsupported  = errors.New("неподдерживаемый тип данных")   // 415
+	ErrValidation   = errors.New("ошибка валидации данных")       // 422
+)
+
+// Error - ошибка предметной области с кодом для API
+type Error struct {
+	Kind    error                  // Категория: ErrNotFound, ErrConflict, ...
+	Code    string                 // Код для ErrorResponse.Code: USER_NOT_FOUND
+	Message string                 // Текст для клиента
+	Details map[string]interface{} // Дополнительные детали, nil - нет
+}
+
+// New создает ошибку категории kind
+// Обычно сервис объявляет ее один раз как сентинельную переменную
+func New(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// NotFound создает ошибку "не найдено" (404)
+func NotFound(code, message string) *Error { return New(ErrNotFound, code, message) }
+
+// Conflict создает ошибку конфликта с текущим состоянием (409)
+func Conflict(code, message string) *Error { return New(ErrConflict, code, message) }
+
+// Invalid создает ошибку невалидного запроса (400)
+func Invalid(code, message string) *Error { return New(ErrInvalid, code, message) }
+
+// Unauthorized создает ошибку аутентификации (401)
+func Unauthorized(code, message string) *Error { return New(ErrUnauthorized, code, message) }
+
+// Forbidden создает ошибку доступа (403)
+func Forbidden(code, message string) *Error { return New(ErrForbidden, code, message) }
+
+// Error реализует error
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is сопоставляет ошибку с категорией и с ошибками того же кода:
+// errors.Is(err, apperrors.ErrNotFound) и errors.Is(err, services.ErrUserNotFound)
+// верны и для копии с деталями (WithDetails)
+func (e *Error) Is(target error) bool {
+	if target == e.Kind {
+		return true
+	}
+	other, ok := target.(*Error)
+	return ok && other.Code == e.Code && other.Kind == e.Kind
+}
+
+// WithDetails возвращает копию ошибки с деталями для ответа
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// pgUniqueViolation - код ошибки PostgreSQL при нарушении уникальности
+const pgUniqueViolation = "23505"
+
+// userConstraints - ограничения уникальности email и username пользователя
+// Индексы *_not_deleted заменили UNIQUE колонок после мягкого удаления
+var userConstraints = map[string]bool{
+	"idx_users_email_not_deleted":           true,
+	"idx_users_canonical_email_not_deleted": true,
+	"idx_users_username_not_deleted":        true,
+	"users_email_key":                       true,
+	"users_username_key":                    true,
+}
+
+// Unique переводит нарушение уникальности PostgreSQL в ErrConflict,
+// для остальных ошибок возвращает nil
+//
+// Для email и username - USER_ALREADY_EXISTS с тем же ответом, что и у
+// services.ErrUserAlreadyExists: занятое поле не уточняется, чтобы по
+// ответу нельзя было проверить, зарегистрирован ли email
+func Unique(err error) *Error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgUniqueViolation {
+		return nil
+	}
+	if userConstraints[pgErr.ConstraintName] {
+		return Conflict("USER_ALREADY_EXISTS", "пользователь с такими данными уже существует")
+	}
+	return Conflict("ALREADY_EXISTS", "запись с такими данными уже существует")
+}
@@ -0,0 +1,465 @@
+// Package archive переносит старые записи журналов из таблиц БД в сжатые
+// файлы хранилища (storage.BlobStore).
+//
+// Журналы (audit_logs, ...) только растут, а читают в основном последние
+// записи. Каждый журнал регистрируется как Source с уровнями хранения
+// (Tier): HotFor записи лежат в таблице и доступны обычному API журнала,
+// затем задание archive_records переносит их пачками в файлы NDJSON,
+// сжатые gzip, а KeepFor спустя удаляет и файлы. Перенос пачки
+// транзакционен со стороны БД: строка archives и удаление записей
+// фиксируются вместе, а файл удаляется, если транзакция не удалась,
+// поэтому запись не теряется и не дублируется между таблицей и архивом.
+//
+// Записи архива хранятся в JSON представлении API источника: файлы
+// читаются без схемы таблицы на момент переноса (GET /admin/v1/archives).
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/query"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/storage"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// keyPrefix - префикс ключей файлов архива в хранилище
+const keyPrefix = "archives"
+
+// contentType - MIME тип файлов архива
+const contentType = "application/gzip"
+
+// TimeField - поле записи со временем создания: по нему строится период
+// файла и фильтры created_after/created_before при чтении
+const TimeField = "created_at"
+
+// ErrArchiveNotFound возвращается, когда архива нет
+var ErrArchiveNotFound = apperrors.NotFound("ARCHIVE_NOT_FOUND", "архив не найден")
+
+// ErrUnknownSource возвращается для фильтра по незарегистрированному источнику
+var ErrUnknownSource = apperrors.Invalid("UNKNOWN_ARCHIVE_SOURCE", "неизвестный источник архива")
+
+// Source - журнал, старые записи которого переносятся в архив
+type Source interface {
+	// Name - имя таблицы источника (audit_logs): поле source архивов
+	Name() string
+
+	// Batch возвращает до limit записей, созданных раньше before, по
+	// возрастанию ID. Пустая пачка - переносить нечего
+	Batch(ctx context.Context, before time.Time, limit int) (Batch, error)
+
+	// Delete удаляет записи пачки запросами q (транзакция переноса)
+	// и возвращает число удаленных записей
+	Delete(ctx context.Context, q *repository.Queries, batch Batch) (int64, error)
+}
+
+// Batch - пачка записей источника для одного файла архива
+type Batch struct {
+	Entries []any // Записи в JSON представлении API, с полем TimeField
+
+	FirstID, LastID int64     // ID первой и последней записи в таблице
+	From, To        time.Time // Самое раннее и самое позднее время записей
+	Before          time.Time // Граница выборки, с которой получена пачка
+}
+
+// Tier - уровни хранения записей источника
+type Tier struct {
+	HotFor  time.Duration // Сколько записи хранятся в таблице
+	KeepFor time.Duration // Сколько хранится файл архива после HotFor; 0 - бессрочно
+}
+
+// Beginner начинает транзакцию переноса; его реализует *pgxpool.Pool
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// source - зарегистрированный источник с уровнями хранения
+type source struct {
+	Source
+	tier Tier
+}
+
+// Archiver переносит записи зарегистрированных источников в хранилище
+// и читает файлы архива
+type Archiver struct {
+	queries   *repository.Queries
+	db        Beginner
+	store     storage.BlobStore
+	batchSize int
+	sources   []source
+	now       func() time.Time
+}
+
+// New создает архиватор с пачками по batchSize записей
+// Источники добавляются Register, обработчик задания archive_records - Run
+func New(queries *repository.Queries, db Beginner, store storage.BlobStore, batchSize int) *Archiver {
+	return &Archiver{
+		queries:   queries,
+		db:        db,
+		store:     store,
+		batchSize: batchSize,
+		now:       time.Now,
+	}
+}
+
+// Register добавляет источник с уровнями хранения
+// Вызывается до запуска очереди заданий
+func (a *Archiver) Register(src Source, tier Tier) {
+	a.sources = append(a.sources, source{Source: src, tier: tier})
+}
+
+// Enabled сообщает, что зарегистрирован хотя бы один источник
+func (a *Archiver) Enabled() bool {
+	return a != nil && len(a.sources) > 0
+}
+
+// Sources возвращает имена зарегистрированных источников
+func (a *Archiver) Sources() []string {
+	names := make([]string, 0, len(a.sources))
+	for _, src := range a.sources {
+		names = append(names, src.Name())
+	}
+	return names
+}
+
+// Run переносит в архив записи старше Tier.HotFor и удаляет файлы
+// старше Tier.KeepFor (задание archive_records)
+// Прерванный перенос продолжается следующим запуском с той же границы
+func (a *Archiver) Run(ctx context.Context, _ json.RawMessage) error {
+	for _, src := range a.sources {
+		if err := a.archive(ctx, src); err != nil {
+			return err
+		}
+		if err := a.expire(ctx, src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archive переносит пачки источника, пока не останется записей старше границы
+func (a *Archiver) archive(ctx context.Context, src source) error {
+	before := a.now().UTC().Add(-src.tier.HotFor)
+	archived := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch, err := src.Batch(ctx, before, a.batchSize)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения записей %s для архива: %w", src.Name(), err)
+		}
+		if len(batch.Entries) == 0 {
+			break
+		}
+		batch.Before = before
+		if err := a.save(ctx, src, batch); err != nil {
+			return err
+		}
+		archived += len(batch.Entries)
+		if len(batch.Entries) < a.batchSize {
+			break
+		}
+	}
+	if archived > 0 {
+		slog.InfoContext(ctx, "📦 Старые записи перенесены в архив", "source", src.Name(), "count", archived)
+	}
+	return nil
+}
+
+// save сохраняет пачку файлом и в одной транзакции записывает архив
+// и удаляет перенесенные записи
+func (a *Archiver) save(ctx context.Context, src source, batch Batch) error {
+	// 1. Файл: NDJSON, сжатый gzip
+	data, err := encode(batch.Entries)
+	if err != nil {
+		return fmt.Errorf("ошибка подготовки архива %s: %w", src.Name(), err)
+	}
+	key := path.Join(keyPrefix, src.Name(), batch.From.UTC().Format("2006/01/02"),
+		fmt.Sprintf("%d-%d.ndjson.gz", batch.FirstID, batch.LastID))
+	if err := a.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return fmt.Errorf("ошибка сохранения архива %s: %w", src.Name(), err)
+	}
+
+	// 2. Строка архива и удаление записей - одной транзакцией
+	err = a.withTx(ctx, func(q *repository.Queries) error {
+		if _, err := q.CreateArchive(ctx, repository.CreateArchiveParams{
+			Source:     src.Name(),
+			RangeStart: batch.From,
+			RangeEnd:   batch.To,
+			FirstID:    batch.FirstID,
+			LastID:     batch.LastID,
+			EntryCount: int32(len(batch.Entries)),
+			SizeBytes:  int64(len(data)),
+			StorageKey: key,
+		}); err != nil {
+			return fmt.Errorf("ошибка записи архива: %w", err)
+		}
+		deleted, err := src.Delete(ctx, q, batch)
+		if err != nil {
+			return fmt.Errorf("ошибка удаления перенесенных записей: %w", err)
+		}
+		if deleted != int64(len(batch.Entries)) {
+			slog.WarnContext(ctx, "⚠️  Удалено записей не столько, сколько перенесено в архив",
+				"source", src.Name(), "archived", len(batch.Entries), "deleted", deleted)
+		}
+		return nil
+	})
+	if err != nil {
+		// Записи остались в таблице: файл без строки archives не нужен
+		if delErr := a.store.Delete(context.WithoutCancel(ctx), key); delErr != nil {
+			slog.ErrorContext(ctx, "❌ Ошибка удаления файла неудавшегося архива", "key", key, "error", delErr)
+		}
+		return fmt.Errorf("ошибка переноса записей %s в архив: %w", src.Name(), err)
+	}
+	return nil
+}
+
+// expire удаляет файлы архива, все записи которых старше HotFor + KeepFor
+func (a *Archiver) expire(ctx context.Context, src source) error {
+	if src.tier.KeepFor == 0 {
+		return nil
+	}
+	before := a.now().UTC().Add(-src.tier.HotFor - src.tier.KeepFor)
+	expired, err := a.queries.ListExpiredArchives(ctx, repository.ListExpiredArchivesParams{
+		Source:   src.Name(),
+		RangeEnd: before,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка получения устаревших архивов %s: %w", src.Name(), err)
+	}
+	for _, archive := range expired {
+		// Сначала файл: строка без файла осталась бы в списке архивов
+		if err := a.store.Delete(ctx, archive.StorageKey); err != nil {
+			return fmt.Errorf("ошибка удаления файла архива %d: %w", archive.ID, err)
+		}
+		if err := a.queries.DeleteArchive(ctx, archive.ID); err != nil {
+			return fmt.Errorf("ошибка удаления архива %d: %w", archive.ID, err)
+		}
+	}
+	if len(expired) > 0 {
+		slog.InfoContext(ctx, "🧹 Устаревшие архивы удалены", "source", src.Name(), "count", len(expired))
+	}
+	return nil
+}
+
+// withTx выполняет fn в транзакции; все запросы fn делает через q
+func (a *Archiver) withTx(ctx context.Context, fn func(q *repository.Queries) error) error {
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	if err := fn(a.queries.WithTx(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+// List возвращает страницу архивов, период которых пересекается с фильтром
+func (a *Archiver) List(ctx context.Context, req models.ListArchivesRequest) (*models.ListArchivesResponse, error) {
+	filter := repository.CountArchivesParams{}
+	if req.Source != "" {
+		known := false
+		for _, src := range a.sources {
+			known = known || src.Name() == req.Source
+		}
+		if !known {
+			return nil, ErrUnknownSource.WithDetails(map[string]interface{}{"sources": a.Sources()})
+		}
+		filter.Source = pgtype.Text{String: req.Source, Valid: true}
+	}
+	if req.CreatedAfter != nil {
+		filter.CreatedAfter = pgtype.Timestamp{Time: *req.CreatedAfter, Valid: true}
+	}
+	if req.CreatedBefore != nil {
+		filter.CreatedBefore = pgtype.Timestamp{Time: *req.CreatedBefore, Valid: true}
+	}
+
+	page := query.Page{Number: req.Page, Size: req.PageSize}
+	rows, err := a.queries.ListArchives(ctx, repository.ListArchivesParams{
+		Source:        filter.Source,
+		CreatedAfter:  filter.CreatedAfter,
+		CreatedBefore: filter.CreatedBefore,
+		Limit:         int32(page.Size),
+		Offset:        int32(page.Offset()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения архивов: %w", err)
+	}
+	total, err := a.queries.CountArchives(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчета архивов: %w", err)
+	}
+
+	meta := query.NewPageMeta(page, int(total))
+	resp := &models.ListArchivesResponse{
+		Archives:   make([]models.ArchiveResponse, 0, len(rows)),
+		TotalCount: meta.TotalCount,
+		Page:       page.Number,
+		PageSize:   page.Size,
+		TotalPages: meta.TotalPages,
+		HasNext:    meta.HasNext,
+		HasPrev:    meta.HasPrev,
+	}
+	for _, row := range rows {
+		resp.Archives = append(resp.Archives, toArchiveResponse(row))
+	}
+	return resp, nil
+}
+
+// Entries возвращает страницу записей архива, подходящих под фильтры
+// Файл читается потоком: в памяти только записи страницы
+func (a *Archiver) Entries(ctx context.Context, req models.ListArchiveEntriesRequest) (*models.ListArchiveEntriesResponse, error) {
+	archive, err := a.queries.GetArchive(ctx, req.ArchiveID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrArchiveNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения архива: %w", err)
+	}
+
+	r, _, err := a.store.Get(ctx, archive.StorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла архива: %w", err)
+	}
+	defer r.Close()
+
+	page := query.Page{Number: req.Page, Size: req.PageSize}
+	entries := make([]json.RawMessage, 0, page.Size)
+	matched := 0
+	err = decode(r, func(entry json.RawMessage) error {
+		ok, err := match(entry, req)
+		if err != nil || !ok {
+			return err
+		}
+		if matched >= page.Offset() && len(entries) < page.Size {
+			entries = append(entries, entry)
+		}
+		matched++
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла архива %d: %w", archive.ID, err)
+	}
+
+	meta := query.NewPageMeta(page, matched)
+	return &models.ListArchiveEntriesResponse{
+		Archive:    toArchiveResponse(archive),
+		Entries:    entries,
+		TotalCount: meta.TotalCount,
+		Page:       page.Number,
+		PageSize:   page.Size,
+		TotalPages: meta.TotalPages,
+		HasNext:    meta.HasNext,
+		HasPrev:    meta.HasPrev,
+	}, nil
+}
+
+// encode записывает записи строками JSON и сжимает gzip
+func encode(entries []any) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decode вызывает fn для каждой записи файла архива
+func decode(r io.Reader, fn func(entry json.RawMessage) error) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	// Строка - одна запись; длина записи не ограничена (в отличие от Scanner)
+	br := bufio.NewReader(zr)
+	for {
+		line, err := br.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if !json.Valid(line) {
+				return fmt.Errorf("невалидная запись: %.100s", line)
+			}
+			if fnErr := fn(json.RawMessage(line)); fnErr != nil {
+				return fnErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// match проверяет запись по периоду и равенству строковых полей
+func match(entry json.RawMessage, req models.ListArchiveEntriesRequest) (bool, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(entry, &fields); err != nil {
+		return false, err
+	}
+
+	for name, want := range req.Fields {
+		var got string
+		if err := json.Unmarshal(fields[name], &got); err != nil || got != want {
+			return false, nil
+		}
+	}
+
+	if req.CreatedAfter == nil && req.CreatedBefore == nil {
+		return true, nil
+	}
+	var created utc.Time
+	if err := json.Unmarshal(fields[TimeField], &created); err != nil {
+		return false, fmt.Errorf("поле %s: %w", TimeField, err)
+	}
+	if req.CreatedAfter != nil && created.Time.Before(*req.CreatedAfter) {
+		return false, nil
+	}
+	if req.CreatedBefore != nil && !created.Time.Before(*req.CreatedBefore) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// toArchiveResponse преобразует архив для API
+func toArchiveResponse(row repository.Archive) models.ArchiveResponse {
+	return models.ArchiveResponse{
+		ID:         publicid.ID(row.ID),
+		Source:     row.Source,
+		RangeStart: utc.From(row.RangeStart),
+		RangeEnd:   utc.From(row.RangeEnd),
+		EntryCount: int(row.EntryCount),
+		SizeBytes:  row.SizeBytes,
+		CreatedAt:  utc.From(row.CreatedAt),
+	}
+}
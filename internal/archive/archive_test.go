@@ -0,0 +1,227 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// testDB - БД архиватора: транзакции считаются, строка архива
+// записывается или завершается ошибкой insertErr
+type testDB struct {
+	repository.DBTX
+	insertErr error
+	commits   int
+	rollbacks int
+}
+
+func (d *testDB) Begin(context.Context) (pgx.Tx, error) { return &testTx{d: d}, nil }
+
+// testTx - транзакция testDB; нужны только QueryRow, Commit и Rollback
+type testTx struct {
+	pgx.Tx
+	d      *testDB
+	closed bool
+}
+
+func (t *testTx) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return testRow{err: t.d.insertErr}
+}
+
+func (t *testTx) Commit(context.Context) error {
+	t.closed = true
+	t.d.commits++
+	return nil
+}
+
+func (t *testTx) Rollback(context.Context) error {
+	if t.closed {
+		return pgx.ErrTxClosed
+	}
+	t.closed = true
+	t.d.rollbacks++
+	return nil
+}
+
+type testRow struct{ err error }
+
+func (r testRow) Scan(...interface{}) error { return r.err }
+
+// testSource отдает записи пачками по порядку и запоминает удаленные
+type testSource struct {
+	entries []map[string]any
+	deleted int
+	before  time.Time
+}
+
+func (s *testSource) Name() string { return "test_logs" }
+
+func (s *testSource) Batch(_ context.Context, before time.Time, limit int) (Batch, error) {
+	s.before = before
+	rest := s.entries[s.deleted:]
+	if len(rest) > limit {
+		rest = rest[:limit]
+	}
+	if len(rest) == 0 {
+		return Batch{}, nil
+	}
+	batch := Batch{FirstID: int64(s.deleted + 1), LastID: int64(s.deleted + len(rest))}
+	for _, entry := range rest {
+		created, _ := time.Parse(time.RFC3339, entry["created_at"].(string))
+		if batch.From.IsZero() || created.Before(batch.From) {
+			batch.From = created
+		}
+		if created.After(batch.To) {
+			batch.To = created
+		}
+		batch.Entries = append(batch.Entries, entry)
+	}
+	return batch, nil
+}
+
+func (s *testSource) Delete(_ context.Context, _ *repository.Queries, batch Batch) (int64, error) {
+	s.deleted += len(batch.Entries)
+	return int64(len(batch.Entries)), nil
+}
+
+func testEntries() []map[string]any {
+	return []map[string]any{
+		{"id": "1", "action": "user.create", "created_at": "2024-01-02T10:00:00Z"},
+		{"id": "2", "action": "user.update", "created_at": "2024-01-02T11:00:00Z"},
+		{"id": "3", "action": "user.update", "created_at": "2024-01-03T09:00:00Z"},
+	}
+}
+
+func newTestArchiver(t *testing.T, db *testDB) (*Archiver, storage.BlobStore) {
+	t.Helper()
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New(repository.New(db), db, store, 2)
+	a.now = func() time.Time { return time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC) }
+	return a, store
+}
+
+// readArchive читает записи файла архива
+func readArchive(t *testing.T, store storage.BlobStore, key string) []string {
+	t.Helper()
+	r, _, err := store.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", key, err)
+	}
+	defer r.Close()
+	var ids []string
+	err = decode(r, func(entry json.RawMessage) error {
+		var fields struct{ ID string }
+		if err := json.Unmarshal(entry, &fields); err != nil {
+			return err
+		}
+		ids = append(ids, fields.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("decode(%s): %v", key, err)
+	}
+	return ids
+}
+
+func TestRunArchivesBatches(t *testing.T) {
+	db := &testDB{}
+	a, store := newTestArchiver(t, db)
+	src := &testSource{entries: testEntries()}
+	a.Register(src, Tier{HotFor: 30 * 24 * time.Hour})
+
+	if err := a.Run(context.Background(), nil); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// Граница - now минус HotFor, пачки по batchSize записей
+	if want := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC); !src.before.Equal(want) {
+		t.Errorf("граница %v, ожидалась %v", src.before, want)
+	}
+	if src.deleted != 3 || db.commits != 2 || db.rollbacks != 0 {
+		t.Errorf("удалено %d, commits %d, rollbacks %d; ожидалось 3, 2, 0", src.deleted, db.commits, db.rollbacks)
+	}
+	files := map[string][]string{
+		"archives/test_logs/2024/01/02/1-2.ndjson.gz": {"1", "2"},
+		"archives/test_logs/2024/01/03/3-3.ndjson.gz": {"3"},
+	}
+	for key, want := range files {
+		if got := readArchive(t, store, key); len(got) != len(want) || got[0] != want[0] {
+			t.Errorf("%s: записи %v, ожидались %v", key, got, want)
+		}
+	}
+}
+
+func TestRunKeepsRecordsWhenTransactionFails(t *testing.T) {
+	db := &testDB{insertErr: errors.New("БД недоступна")}
+	a, store := newTestArchiver(t, db)
+	src := &testSource{entries: testEntries()}
+	a.Register(src, Tier{HotFor: time.Hour})
+
+	if err := a.Run(context.Background(), nil); err == nil {
+		t.Fatal("Run: ожидалась ошибка")
+	}
+
+	// Записи остались в таблице, а файл без строки архива удален
+	if src.deleted != 0 || db.commits != 0 || db.rollbacks != 1 {
+		t.Errorf("удалено %d, commits %d, rollbacks %d; ожидалось 0, 0, 1", src.deleted, db.commits, db.rollbacks)
+	}
+	if _, err := store.Stat(context.Background(), "archives/test_logs/2024/01/02/1-2.ndjson.gz"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("файл неудавшегося архива: %v, ожидалось ErrNotFound", err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	entry := json.RawMessage(`{"action": "user.update", "actor_id": null, "created_at": "2024-01-02T11:00:00Z"}`)
+	day := func(d int) *time.Time {
+		t := time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	tests := []struct {
+		name string
+		req  models.ListArchiveEntriesRequest
+		want bool
+	}{
+		{"без фильтров", models.ListArchiveEntriesRequest{}, true},
+		{"поле совпадает", models.ListArchiveEntriesRequest{Fields: map[string]string{"action": "user.update"}}, true},
+		{"поле отличается", models.ListArchiveEntriesRequest{Fields: map[string]string{"action": "user.create"}}, false},
+		{"поле null", models.ListArchiveEntriesRequest{Fields: map[string]string{"actor_id": "x"}}, false},
+		{"в периоде", models.ListArchiveEntriesRequest{CreatedAfter: day(2), CreatedBefore: day(3)}, true},
+		{"раньше периода", models.ListArchiveEntriesRequest{CreatedAfter: day(3)}, false},
+		{"граница created_before не входит", models.ListArchiveEntriesRequest{CreatedBefore: day(2)}, false},
+	}
+	for _, tc := range tests {
+		got, err := match(entry, tc.req)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: match = %v, ожидалось %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDecodeRejectsCorruptEntry(t *testing.T) {
+	data, err := encode([]any{map[string]any{"id": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decode(bytes.NewReader(data), func(json.RawMessage) error { return nil }); err != nil {
+		t.Errorf("decode: %v", err)
+	}
+	if err := decode(bytes.NewReader([]byte("not gzip")), func(json.RawMessage) error { return nil }); err == nil {
+		t.Error("decode не gzip: ожидалась ошибка")
+	}
+}
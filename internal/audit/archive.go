@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/archive"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+)
+
+// ArchiveSource - журнал аудита как источник архива (archive.Source)
+// Записи архивируются в виде AuditLogResponse: с публичными ID
+// пользователей на момент переноса
+type ArchiveSource struct {
+	queries *repository.Queries
+}
+
+// NewArchiveSource создает источник архива для audit_logs
+func NewArchiveSource(queries *repository.Queries) *ArchiveSource {
+	return &ArchiveSource{queries: queries}
+}
+
+// Name возвращает имя таблицы журнала
+func (s *ArchiveSource) Name() string {
+	return "audit_logs"
+}
+
+// Batch возвращает пачку записей старше before по возрастанию ID
+func (s *ArchiveSource) Batch(ctx context.Context, before time.Time, limit int) (archive.Batch, error) {
+	rows, err := s.queries.ListAuditLogsForArchive(ctx, repository.ListAuditLogsForArchiveParams{
+		CreatedBefore: before,
+		Limit:         int32(limit),
+	})
+	if err != nil || len(rows) == 0 {
+		return archive.Batch{}, err
+	}
+
+	batch := archive.Batch{
+		Entries: make([]any, 0, len(rows)),
+		FirstID: rows[0].ID,
+		LastID:  rows[len(rows)-1].ID,
+		From:    rows[0].CreatedAt,
+		To:      rows[0].CreatedAt,
+	}
+	for _, row := range rows {
+		// Записи из spool сохраняются позже своего времени: по ID
+		// время не упорядочено, период считается по всем записям
+		if row.CreatedAt.Before(batch.From) {
+			batch.From = row.CreatedAt
+		}
+		if row.CreatedAt.After(batch.To) {
+			batch.To = row.CreatedAt
+		}
+		batch.Entries = append(batch.Entries, toAuditLogResponse(repository.ListAuditLogsRow(row)))
+	}
+	return batch, nil
+}
+
+// Delete удаляет записи пачки из audit_logs
+func (s *ArchiveSource) Delete(ctx context.Context, q *repository.Queries, batch archive.Batch) (int64, error) {
+	return q.DeleteArchivedAuditLogs(ctx, repository.DeleteArchivedAuditLogsParams{
+		FirstID:       batch.FirstID,
+		LastID:        batch.LastID,
+		CreatedBefore: batch.Before,
+	})
+}
@@ -0,0 +1,427 @@
+// Package audit ведет журнал аудита: кто, когда и что изменил.
+//
+// Сервисы вызывают Record после успешной изменяющей операции. Запись
+// дополняется автором и метаданными запроса из контекста (reqctx) и
+// ставится в очередь в памяти, а в БД ее пишет фоновый писатель (Run),
+// поэтому аудит не добавляет запрос к БД во время обработки запроса.
+// Если очередь переполнена или БД недоступна, запись откладывается
+// в журнал на диске (см. spool.go) и сохраняется в БД, когда та
+// восстановится. Без журнала или при его переполнении запись
+// отбрасывается с ошибкой в логе и метрикой audit_dropped_total:
+// запрос пользователя важнее записи о нем.
+//
+// Подписчики (Observe) получают запись после сохранения в БД: так
+// пакет notify копит уведомления пользователям, тоже вне обработки запроса.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/query"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/safe"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+	"github.com/Soundveyve/fiber-backend/internal/wal"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Действия над пользователями
+const (
+	ActionUserCreate       = "user.create"
+	ActionUserImport       = "user.import"
+	ActionUserUpdate       = "user.update"
+	ActionUserDeactivate   = "user.deactivate"
+	ActionUserDelete       = "user.delete"
+	ActionUserHardDelete   = "user.hard_delete"
+	ActionUserRoleUpdate   = "user.role_update"
+	ActionUserStatusUpdate = "user.status_update"
+	ActionUserMerge        = "user.merge"
+	ActionUserAvatarUpdate = "user.avatar_update"
+
+	ActionUserTwoFactorEnable = "user.two_factor_enable"
+
+	// Этапы восстановления доступа без второго фактора
+	ActionUserTwoFactorRecoveryStart    = "user.two_factor_recovery_start"
+	ActionUserTwoFactorRecoveryConfirm  = "user.two_factor_recovery_confirm"
+	ActionUserTwoFactorRecoveryApprove  = "user.two_factor_recovery_approve"
+	ActionUserTwoFactorRecoveryReject   = "user.two_factor_recovery_reject"
+	ActionUserTwoFactorRecoveryCancel   = "user.two_factor_recovery_cancel"
+	ActionUserTwoFactorRecoveryComplete = "user.two_factor_recovery_complete"
+)
+
+// События безопасности: операция не изменяла данные, но требует проверки
+const (
+	// Подозрительный объем экспортов и массовых чтений (middleware.BulkReadMonitor)
+	ActionSecurityBulkReadAnomaly = "security.bulk_read_anomaly"
+)
+
+// Actions - все действия, по которым можно фильтровать журнал
+var Actions = []string{
+	ActionUserCreate,
+	ActionUserImport,
+	ActionUserUpdate,
+	ActionUserDeactivate,
+	ActionUserDelete,
+	ActionUserHardDelete,
+	ActionUserRoleUpdate,
+	ActionUserStatusUpdate,
+	ActionUserMerge,
+	ActionUserAvatarUpdate,
+	ActionUserTwoFactorEnable,
+	ActionUserTwoFactorRecoveryStart,
+	ActionUserTwoFactorRecoveryConfirm,
+	ActionUserTwoFactorRecoveryApprove,
+	ActionUserTwoFactorRecoveryReject,
+	ActionUserTwoFactorRecoveryCancel,
+	ActionUserTwoFactorRecoveryComplete,
+	ActionSecurityBulkReadAnomaly,
+}
+
+// drainTimeout ограничивает запись оставшейся очереди при остановке
+const drainTimeout = 5 * time.Second
+
+// ignoredFields не попадают в изменения: меняются при любом обновлении
+var ignoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+// Event - изменяющая операция для журнала
+type Event struct {
+	Action       string
+	TargetUserID int // 0 - операция не касается конкретного пользователя
+
+	// Снимки объекта до и после операции (nil - объекта не было или не стало)
+	// Изменения строятся по их JSON представлению, см. Diff
+	Before any
+	After  any
+}
+
+// Change - изменение одного поля в JSON представлении
+// Отсутствующее значение (поля не было или не стало) - null
+type Change struct {
+	Old json.RawMessage `json:"old"`
+	New json.RawMessage `json:"new"`
+}
+
+// Logger записывает события в журнал аудита
+// nil Logger ничего не записывает (удобно в тестах сервисов)
+type Logger struct {
+	queries *repository.Queries
+	queue   chan repository.CreateAuditLogParams
+
+	// spool - журнал на диске для записей, которые не удалось сохранить
+	// сразу; nil - такие записи отбрасываются
+	spool *wal.Log
+	// sinkDown - БД недоступна: записи идут в spool без попытки записи,
+	// пока replay не сохранит отложенные
+	sinkDown atomic.Bool
+
+	observers []Observer
+}
+
+// Observer получает запись после сохранения в БД
+// Вызывается в горутине Run: медленный Observer задерживает запись журнала
+type Observer func(ctx context.Context, entry repository.CreateAuditLogParams)
+
+// NewLogger создает журнал с очередью на bufferSize записей
+// spool (может быть nil) принимает записи на время недоступности БД
+func NewLogger(queries *repository.Queries, bufferSize int, spool *wal.Log) *Logger {
+	return &Logger{
+		queries: queries,
+		queue:   make(chan repository.CreateAuditLogParams, bufferSize),
+		spool:   spool,
+	}
+}
+
+// Record ставит событие в очередь на запись и не блокируется
+// Автор и метаданные запроса берутся из ctx
+func (l *Logger) Record(ctx context.Context, event Event) {
+	if l == nil {
+		return
+	}
+	// Запись вызывается после сохраненного изменения: паника при снятии
+	// снимка (MarshalJSON объекта) не должна превращать его в 500
+	defer safe.Recover(ctx, "audit", nil)
+
+	changes, err := json.Marshal(Diff(event.Before, event.After))
+	if err != nil {
+		slog.ErrorContext(ctx, "❌ Ошибка подготовки записи аудита", "action", event.Action, "error", err)
+		metrics.AuditDropped.WithLabelValues("encode").Inc()
+		return
+	}
+
+	params := repository.CreateAuditLogParams{
+		Action:       event.Action,
+		TargetUserID: nullInt(event.TargetUserID),
+		Changes:      changes,
+		RequestID:    nullString(reqctx.RequestIDFromContext(ctx)),
+		CreatedAt:    time.Now().UTC(),
+	}
+	if actor, ok := reqctx.UserFromContext(ctx); ok {
+		params.ActorID = nullInt(actor.ID)
+		params.ActorRole = nullString(actor.Role)
+	}
+	if client, ok := reqctx.ClientFromContext(ctx); ok {
+		params.Ip = nullString(client.IP)
+		params.UserAgent = nullString(client.UserAgent)
+	}
+
+	select {
+	case l.queue <- params:
+	default:
+		// Очередь переполнена: запись откладывается на диск
+		if l.spool != nil {
+			l.spill(params)
+			return
+		}
+		slog.ErrorContext(ctx, "❌ Очередь аудита переполнена, запись отброшена",
+			"action", event.Action, "target_user_id", event.TargetUserID)
+		metrics.AuditDropped.WithLabelValues("queue_full").Inc()
+	}
+}
+
+// Observe подписывает fn на сохраненные записи
+// Вызывается до запуска Run
+func (l *Logger) Observe(fn Observer) {
+	if l == nil {
+		return
+	}
+	l.observers = append(l.observers, fn)
+}
+
+// notify передает сохраненную запись подписчикам
+func (l *Logger) notify(ctx context.Context, params repository.CreateAuditLogParams) {
+	// Паника одного подписчика не останавливает запись журнала и не
+	// мешает остальным подписчикам
+	for _, fn := range l.observers {
+		_ = safe.Call(ctx, "audit_observer", func() error {
+			fn(ctx, params)
+			return nil
+		})
+	}
+}
+
+// Len возвращает число записей, ожидающих записи в БД
+func (l *Logger) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.queue)
+}
+
+// Cap возвращает размер очереди (AUDIT_BUFFER_SIZE)
+func (l *Logger) Cap() int {
+	if l == nil {
+		return 0
+	}
+	return cap(l.queue)
+}
+
+// Run пишет записи из очереди в БД, пока не отменен ctx
+// Раз в replayInterval сохраняет отложенные на диск записи
+// После отмены дописывает оставшуюся очередь не дольше drainTimeout
+func (l *Logger) Run(ctx context.Context) {
+	ticker := time.NewTicker(replayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case params := <-l.queue:
+			l.write(ctx, params)
+		case <-ticker.C:
+			l.replay(ctx)
+		case <-ctx.Done():
+			l.drain()
+			l.closeSpool()
+			return
+		}
+	}
+}
+
+// drain записывает то, что осталось в очереди при остановке
+func (l *Logger) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case params := <-l.queue:
+			l.write(ctx, params)
+		default:
+			return
+		}
+	}
+}
+
+// write сохраняет одну запись; ошибка только пишется в лог
+// При недоступной БД запись откладывается на диск, если есть журнал
+func (l *Logger) write(ctx context.Context, params repository.CreateAuditLogParams) {
+	if l.spool != nil && l.sinkDown.Load() {
+		l.spill(params)
+		return
+	}
+
+	err := l.queries.CreateAuditLog(ctx, params)
+	if err == nil {
+		l.notify(ctx, params)
+		return
+	}
+	if l.spool != nil && unavailable(err) {
+		slog.Warn("⚠️ БД недоступна, записи аудита откладываются на диск", "error", err)
+		l.sinkDown.Store(true)
+		l.spill(params)
+		return
+	}
+	slog.Error("❌ Ошибка записи в журнал аудита",
+		"action", params.Action, "request_id", params.RequestID.String, "error", err)
+	metrics.AuditDropped.WithLabelValues("db_error").Inc()
+}
+
+// List возвращает страницу журнала от новых записей к старым
+func (l *Logger) List(ctx context.Context, req models.ListAuditLogsRequest) (*models.ListAuditLogsResponse, error) {
+	filter := repository.CountAuditLogsParams{
+		ActorID:      nullIntPtr(req.ActorID),
+		TargetUserID: nullIntPtr(req.TargetUserID),
+		Action:       nullString(req.Action),
+	}
+	if req.CreatedAfter != nil {
+		filter.CreatedAfter = pgtype.Timestamp{Time: *req.CreatedAfter, Valid: true}
+	}
+	if req.CreatedBefore != nil {
+		filter.CreatedBefore = pgtype.Timestamp{Time: *req.CreatedBefore, Valid: true}
+	}
+
+	page := query.Page{Number: req.Page, Size: req.PageSize}
+	rows, err := l.queries.ListAuditLogs(ctx, repository.ListAuditLogsParams{
+		ActorID:       filter.ActorID,
+		TargetUserID:  filter.TargetUserID,
+		Action:        filter.Action,
+		CreatedAfter:  filter.CreatedAfter,
+		CreatedBefore: filter.CreatedBefore,
+		Limit:         int32(page.Size),
+		Offset:        int32(page.Offset()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения журнала аудита: %w", err)
+	}
+
+	total, err := l.queries.CountAuditLogs(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчета записей журнала аудита: %w", err)
+	}
+
+	meta := query.NewPageMeta(page, int(total))
+	resp := &models.ListAuditLogsResponse{
+		Logs:       make([]models.AuditLogResponse, 0, len(rows)),
+		TotalCount: meta.TotalCount,
+		Page:       page.Number,
+		PageSize:   page.Size,
+		TotalPages: meta.TotalPages,
+		HasNext:    meta.HasNext,
+		HasPrev:    meta.HasPrev,
+	}
+	for _, row := range rows {
+		resp.Logs = append(resp.Logs, toAuditLogResponse(row))
+	}
+	return resp, nil
+}
+
+// Diff возвращает изменившиеся поля между снимками до и после операции
+//
+// Снимки сравниваются по JSON представлению, поэтому в журнал попадают
+// те же имена полей и значения, что видит клиент API. Для создания
+// (before == nil) это все поля нового объекта, для удаления (after == nil) -
+// все поля удаленного
+func Diff(before, after any) map[string]Change {
+	old, new := fields(before), fields(after)
+
+	changes := make(map[string]Change)
+	for name, value := range new {
+		if ignoredFields[name] {
+			continue
+		}
+		if prev, ok := old[name]; !ok || string(prev) != string(value) {
+			changes[name] = Change{Old: prev, New: value}
+		}
+	}
+	for name, prev := range old {
+		if _, ok := new[name]; !ok && !ignoredFields[name] {
+			changes[name] = Change{Old: prev, New: nil}
+		}
+	}
+	return changes
+}
+
+// fields разбирает снимок на поля верхнего уровня в JSON представлении
+func fields(snapshot any) map[string]json.RawMessage {
+	result := map[string]json.RawMessage{}
+	if snapshot == nil {
+		return result
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return result
+	}
+	// Снимок - не объект (например null от nil указателя): полей нет
+	_ = json.Unmarshal(data, &result)
+	return result
+}
+
+// toAuditLogResponse преобразует запись журнала для API
+func toAuditLogResponse(row repository.ListAuditLogsRow) models.AuditLogResponse {
+	resp := models.AuditLogResponse{
+		ID:        publicid.ID(row.ID),
+		Action:    row.Action,
+		Changes:   row.Changes,
+		CreatedAt: utc.From(row.CreatedAt),
+	}
+	if row.ActorPublicID.Valid {
+		id := row.ActorPublicID.UUID.String()
+		resp.ActorID = &id
+	}
+	if row.TargetPublicID.Valid {
+		id := row.TargetPublicID.UUID.String()
+		resp.TargetUserID = &id
+	}
+	if row.ActorRole.Valid {
+		resp.ActorRole = &row.ActorRole.String
+	}
+	if row.RequestID.Valid {
+		resp.RequestID = &row.RequestID.String
+	}
+	if row.Ip.Valid {
+		resp.IP = &row.Ip.String
+	}
+	if row.UserAgent.Valid {
+		resp.UserAgent = &row.UserAgent.String
+	}
+	return resp
+}
+
+// nullInt - ID для колонки, 0 - NULL
+func nullInt(id int) pgtype.Int4 {
+	return pgtype.Int4{Int32: int32(id), Valid: id != 0}
+}
+
+// nullIntPtr - фильтр по ID, nil - без фильтра
+func nullIntPtr(id *int) pgtype.Int4 {
+	if id == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: int32(*id), Valid: true}
+}
+
+// nullString - строка для колонки, пустая - NULL
+func nullString(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type snapshot struct {
+	Name      string  `json:"name"`
+	Role      string  `json:"role"`
+	Avatar    *string `json:"avatar,omitempty"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+func TestDiffReportsChangedFields(t *testing.T) {
+	avatar := "a.jpg"
+	before := snapshot{Name: "Иван", Role: "user", UpdatedAt: "1"}
+	after := snapshot{Name: "Иван", Role: "admin", Avatar: &avatar, UpdatedAt: "2"}
+
+	changes := Diff(before, after)
+
+	if len(changes) != 2 {
+		t.Fatalf("изменения = %v, want role и avatar", changes)
+	}
+	if got := string(changes["role"].Old); got != `"user"` {
+		t.Errorf("role.old = %s, want \"user\"", got)
+	}
+	if got := string(changes["role"].New); got != `"admin"` {
+		t.Errorf("role.new = %s, want \"admin\"", got)
+	}
+	if changes["avatar"].Old != nil || changes["avatar"].New == nil {
+		t.Errorf("avatar = %v, want old: null, new: \"a.jpg\"", changes["avatar"])
+	}
+}
+
+func TestDiffCreateAndDelete(t *testing.T) {
+	user := &snapshot{Name: "Иван", Role: "user"}
+
+	if created := Diff(nil, user); len(created) != 2 || created["name"].Old != nil {
+		t.Errorf("Diff(nil, user) = %v, want все поля кроме updated_at", created)
+	}
+	if deleted := Diff(user, nil); len(deleted) != 2 || deleted["name"].New != nil {
+		t.Errorf("Diff(user, nil) = %v, want все поля кроме updated_at", deleted)
+	}
+
+	// nil указатель сериализуется в null: полей нет
+	var missing *snapshot
+	if changes := Diff(missing, missing); len(changes) != 0 {
+		t.Errorf("Diff(nil ptr, nil ptr) = %v, want пусто", changes)
+	}
+}
+
+func TestNilLoggerRecordIsNoop(t *testing.T) {
+	var l *Logger
+	l.Record(context.Background(), Event{Action: ActionUserCreate})
+}
+
+func TestUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"нет соединения", errors.New("dial tcp 127.0.0.1:5432: connect: connection refused"), true},
+		{"таймаут", fmt.Errorf("запись: %w", context.DeadlineExceeded), true},
+		{"соединение разорвано", &pgconn.PgError{Code: "08006"}, true},
+		{"слишком много соединений", &pgconn.PgError{Code: "53300"}, true},
+		{"сервер останавливается", &pgconn.PgError{Code: "57P01"}, true},
+		{"нарушение внешнего ключа", &pgconn.PgError{Code: "23503"}, false},
+		{"невалидный JSON", fmt.Errorf("запись: %w", &pgconn.PgError{Code: "22P02"}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unavailable(tt.err); got != tt.want {
+				t.Errorf("unavailable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/wal"
+)
+
+const (
+	// replayInterval - как часто Run пробует сохранить отложенные записи
+	replayInterval = 10 * time.Second
+
+	// replayBatch - сколько отложенных записей читается с диска за раз
+	replayBatch = 500
+)
+
+// spill откладывает запись в журнал на диске
+// Журнал заполнен или недоступен - запись отбрасывается
+func (l *Logger) spill(params repository.CreateAuditLogParams) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		slog.Error("❌ Ошибка подготовки записи аудита", "action", params.Action, "error", err)
+		metrics.AuditDropped.WithLabelValues("encode").Inc()
+		return
+	}
+
+	if err := l.spool.Append(data); err != nil {
+		reason := "spool_error"
+		if errors.Is(err, wal.ErrFull) {
+			reason = "spool_full"
+		}
+		slog.Error("❌ Запись аудита не отложена на диск и отброшена",
+			"action", params.Action, "request_id", params.RequestID.String, "error", err)
+		metrics.AuditDropped.WithLabelValues(reason).Inc()
+		return
+	}
+	metrics.AuditSpooled.WithLabelValues("spooled").Inc()
+	metrics.AuditSpoolBytes.Set(float64(l.spool.Size()))
+}
+
+// replay сохраняет отложенные записи в БД в порядке их появления
+//
+// Пока отложенные записи не сохранены, новые тоже идут на диск (sinkDown):
+// так они не обгоняют более старые. Недоступная БД останавливает replay
+// до следующего тика, запись, которую БД отвергла, отбрасывается
+func (l *Logger) replay(ctx context.Context) {
+	if !l.spool.Pending() {
+		l.sinkDown.Store(false)
+		return
+	}
+
+	total := 0
+	for ctx.Err() == nil && l.spool.Pending() {
+		n, err := l.spool.Replay(replayBatch, func(data []byte) error {
+			var params repository.CreateAuditLogParams
+			if err := json.Unmarshal(data, &params); err != nil {
+				slog.Error("❌ Поврежденная отложенная запись аудита отброшена", "error", err)
+				metrics.AuditDropped.WithLabelValues("encode").Inc()
+				return nil
+			}
+			if err := l.queries.CreateAuditLog(ctx, params); err != nil {
+				if unavailable(err) {
+					return err
+				}
+				slog.Error("❌ Ошибка записи в журнал аудита",
+					"action", params.Action, "request_id", params.RequestID.String, "error", err)
+				metrics.AuditDropped.WithLabelValues("db_error").Inc()
+				return nil
+			}
+			l.notify(ctx, params)
+			return nil
+		})
+		total += n
+		metrics.AuditSpooled.WithLabelValues("replayed").Add(float64(n))
+		metrics.AuditSpoolBytes.Set(float64(l.spool.Size()))
+
+		if err != nil {
+			slog.Warn("⚠️ Отложенные записи аудита ждут восстановления БД",
+				"replayed", total, "spool_bytes", l.spool.Size(), "error", err)
+			return
+		}
+	}
+
+	if total > 0 {
+		slog.Info("✅ Отложенные записи аудита сохранены", "count", total)
+	}
+	if !l.spool.Pending() {
+		l.sinkDown.Store(false)
+	}
+}
+
+// closeSpool закрывает журнал на диске при остановке
+func (l *Logger) closeSpool() {
+	if l.spool == nil {
+		return
+	}
+	if err := l.spool.Close(); err != nil {
+		slog.Error("❌ Ошибка закрытия журнала отложенных записей аудита", "error", err)
+	}
+}
+
+// unavailable сообщает, что запись не сохранена из-за недоступности БД,
+// а не из-за самой записи: такую запись имеет смысл повторить позже
+//
+// Ответ PostgreSQL с кодом класса 08 (соединение), 53 (нехватка ресурсов)
+// или 57 (остановка сервера) - недоступность, прочие ответы - ошибка
+// записи. Ошибка без ответа сервера (сеть, таймаут) - недоступность
+func unavailable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Класс ошибки - первые два символа SQLSTATE
+		switch pgErr.Code[:min(len(pgErr.Code), 2)] {
+		case "08", "53", "57":
+			return true
+		}
+		return false
+	}
+	return true
+}
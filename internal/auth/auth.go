@@ -0,0 +1,229 @@
+// Package auth выпускает и проверяет JWT токены.
+//
+// Используются три типа токенов:
+//   - access - короткоживущий, передается в Authorization: Bearer <token>
+//     и проверяется без обращения к БД
+//   - refresh - долгоживущий, обменивается на новую пару токенов.
+//     Его jti сохраняется в БД (в виде хеша), поэтому его можно отозвать
+//   - sudo - живет несколько минут после повторного ввода пароля,
+//     передается в X-Sudo-Token и открывает разрушительные действия
+//   - two_factor - выдается вместо пары токенов после верного пароля,
+//     если у пользователя включена 2FA. Подтверждает только первый шаг
+//     входа: с ним доступен лишь POST /api/v1/auth/2fa, где он
+//     обменивается на пару токенов по коду из приложения, и восстановление
+//     доступа без второго фактора (/api/v1/auth/2fa/recovery)
+//
+// Кроме них выпускаются одноразовые токены для ссылок из писем
+// (verify_email, password_reset, two_factor_recovery). Их jti тоже хранится в БД в виде хеша
+// и помечается использованным, поэтому ссылка срабатывает один раз.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/config"
+)
+
+// Типы токенов (claim typ)
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	TokenTypeSudo    = "sudo"
+
+	TokenTypeTwoFactor = "two_factor"
+
+	TokenTypeVerifyEmail   = "verify_email"
+	TokenTypePasswordReset = "password_reset"
+
+	TokenTypeTwoFactorRecovery = "two_factor_recovery"
+)
+
+// Встроенные роли (справочник roles заполняется миграцией)
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// ErrInvalidToken возвращается для невалидного, чужого или просроченного токена
+// Причина намеренно не уточняется клиенту
+var ErrInvalidToken = apperrors.Unauthorized("INVALID_TOKEN", "невалидный или просроченный токен")
+
+// Claims - содержимое токенов сервиса
+type Claims struct {
+	jwt.RegisteredClaims
+	Type     string `json:"typ"`                // access, refresh или sudo
+	Username string `json:"username,omitempty"` // Только в access токене
+	Role     string `json:"role,omitempty"`     // Только в access токене
+}
+
+// UserID возвращает ID пользователя из sub
+func (c *Claims) UserID() (int, error) {
+	return strconv.Atoi(c.Subject)
+}
+
+// Token - выпущенный токен
+type Token struct {
+	Value     string    // Подписанный JWT
+	ID        string    // jti, для refresh сохраняется в БД как HashTokenID
+	ExpiresAt time.Time // Момент истечения
+}
+
+// TokenManager выпускает и проверяет токены
+type TokenManager struct {
+	secret     []byte
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	sudoTTL    time.Duration
+
+	twoFactorTTL         time.Duration
+	emailVerificationTTL time.Duration
+	passwordResetTTL     time.Duration
+	twoFactorRecoveryTTL time.Duration
+}
+
+// NewTokenManager создает TokenManager по конфигурации
+func NewTokenManager(cfg config.AuthConfig) *TokenManager {
+	return &TokenManager{
+		secret:     []byte(cfg.JWTSecret),
+		issuer:     cfg.Issuer,
+		accessTTL:  cfg.AccessTTL,
+		refreshTTL: cfg.RefreshTTL,
+		sudoTTL:    cfg.SudoTTL,
+
+		twoFactorTTL:         cfg.TwoFactorTTL,
+		emailVerificationTTL: cfg.EmailVerificationTTL,
+		passwordResetTTL:     cfg.PasswordResetTTL,
+		twoFactorRecoveryTTL: cfg.TwoFactorRecoveryLinkTTL,
+	}
+}
+
+// AccessTTL возвращает время жизни access токена (для expires_in в ответе)
+func (m *TokenManager) AccessTTL() time.Duration {
+	return m.accessTTL
+}
+
+// IssueAccess выпускает access токен пользователя
+// Роль фиксируется в токене: после смены роли она вступит в силу
+// при следующем обновлении токенов (не позже AccessTTL)
+func (m *TokenManager) IssueAccess(userID int, username, role string) (Token, error) {
+	return m.issue(userID, TokenTypeAccess, username, role, m.accessTTL)
+}
+
+// IssueRefresh выпускает refresh токен пользователя
+func (m *TokenManager) IssueRefresh(userID int) (Token, error) {
+	return m.issue(userID, TokenTypeRefresh, "", "", m.refreshTTL)
+}
+
+// SudoTTL возвращает время жизни sudo токена
+func (m *TokenManager) SudoTTL() time.Duration {
+	return m.sudoTTL
+}
+
+// IssueSudo выпускает sudo токен после повторной проверки пароля
+func (m *TokenManager) IssueSudo(userID int) (Token, error) {
+	return m.issue(userID, TokenTypeSudo, "", "", m.sudoTTL)
+}
+
+// TwoFactorTTL возвращает время на ввод кода 2FA после пароля
+func (m *TokenManager) TwoFactorTTL() time.Duration {
+	return m.twoFactorTTL
+}
+
+// IssueTwoFactor выпускает токен незавершенного входа (пароль проверен,
+// код 2FA еще нет). Access токеном он не является
+func (m *TokenManager) IssueTwoFactor(userID int) (Token, error) {
+	return m.issue(userID, TokenTypeTwoFactor, "", "", m.twoFactorTTL)
+}
+
+// IssueEmailVerification выпускает токен для ссылки подтверждения email
+func (m *TokenManager) IssueEmailVerification(userID int) (Token, error) {
+	return m.issue(userID, TokenTypeVerifyEmail, "", "", m.emailVerificationTTL)
+}
+
+// IssuePasswordReset выпускает токен для ссылки сброса пароля
+func (m *TokenManager) IssuePasswordReset(userID int) (Token, error) {
+	return m.issue(userID, TokenTypePasswordReset, "", "", m.passwordResetTTL)
+}
+
+// IssueTwoFactorRecovery выпускает токен для ссылки подтверждения
+// восстановления доступа без второго фактора
+func (m *TokenManager) IssueTwoFactorRecovery(userID int) (Token, error) {
+	return m.issue(userID, TokenTypeTwoFactorRecovery, "", "", m.twoFactorRecoveryTTL)
+}
+
+// Parse проверяет подпись, срок, издателя и тип токена
+func (m *TokenManager) Parse(value, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(value, claims, func(t *jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	},
+		// Фиксируем алгоритм: токен с alg=none или чужим алгоритмом отклоняется
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || claims.Type != tokenType {
+		return nil, ErrInvalidToken
+	}
+	if _, err := claims.UserID(); err != nil {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// issue подписывает токен с уникальным jti
+func (m *TokenManager) issue(userID int, tokenType, username, role string, ttl time.Duration) (Token, error) {
+	id, err := newTokenID()
+	if err != nil {
+		return Token{}, err
+	}
+
+	// ExpiresAt сохраняется в БД (refresh и одноразовые токены), поэтому в UTC
+	now := time.Now().UTC()
+	expiresAt := now.Add(ttl)
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Subject:   strconv.Itoa(userID),
+			Issuer:    m.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Type:     tokenType,
+		Username: username,
+		Role:     role,
+	}
+
+	value, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return Token{}, fmt.Errorf("ошибка подписи токена: %w", err)
+	}
+
+	return Token{Value: value, ID: id, ExpiresAt: expiresAt}, nil
+}
+
+// HashTokenID возвращает хеш jti для хранения в БД
+// Утечка таблицы refresh_tokens не дает готовых идентификаторов токенов
+func HashTokenID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// newTokenID генерирует случайный jti
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("ошибка генерации ID токена: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
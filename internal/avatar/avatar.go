@@ -0,0 +1,111 @@
+// Package avatar проверяет загруженные аватары и приводит их к стандартному виду.
+//
+// Тип изображения определяется по содержимому, а не по имени файла или
+// заголовку Content-Type клиента. Размеры проверяются до декодирования,
+// поэтому маленький файл с огромными заявленными размерами (decompression
+// bomb) отклоняется, не занимая память. Результат - квадратный JPEG
+// фиксированного размера: обрезка по центру и масштабирование.
+package avatar
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net/http"
+
+	// Декодеры поддерживаемых форматов регистрируются в image
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+)
+
+// ContentType - MIME тип обработанного аватара
+const ContentType = "image/jpeg"
+
+// Ограничения исходного изображения
+const (
+	maxSide   = 8000       // Наибольшая сторона в пикселях
+	maxPixels = 16_000_000 // Наибольшая площадь: декодированное изображение занимает 4 байта на пиксель
+	minSide   = 32         // Меньше - не аватар, а иконка
+)
+
+// jpegQuality - качество JPEG результата
+const jpegQuality = 85
+
+// Ошибки проверки загруженного файла
+var (
+	ErrTooLarge        = apperrors.New(apperrors.ErrTooLarge, "AVATAR_TOO_LARGE", "файл аватара слишком большой")
+	ErrUnsupportedType = apperrors.New(apperrors.ErrUnsupported, "AVATAR_UNSUPPORTED_TYPE", "неподдерживаемый формат изображения, ожидается JPEG, PNG, GIF или WebP")
+	ErrInvalidImage    = apperrors.New(apperrors.ErrValidation, "AVATAR_INVALID_IMAGE", "файл поврежден или не является изображением")
+	ErrBadDimensions   = apperrors.New(apperrors.ErrValidation, "AVATAR_INVALID_IMAGE", "недопустимые размеры изображения")
+)
+
+// allowedTypes - форматы, которые принимаются по результату http.DetectContentType
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Process читает изображение из r (не больше maxBytes), проверяет его
+// и возвращает квадратный JPEG со стороной size пикселей
+func Process(r io.Reader, maxBytes int64, size int) ([]byte, error) {
+	// 1. Читаем файл целиком, но не больше лимита
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения аватара: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, ErrTooLarge
+	}
+
+	// 2. Формат по сигнатуре файла
+	if !allowedTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedType
+	}
+
+	// 3. Размеры из заголовка изображения, до выделения памяти под пиксели
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width < minSide || cfg.Height < minSide ||
+		cfg.Width > maxSide || cfg.Height > maxSide ||
+		cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d, допустимо от %dx%d до %dx%d и не больше %d Мпикс",
+			ErrBadDimensions, cfg.Width, cfg.Height, minSide, minSide, maxSide, maxSide, maxPixels/1_000_000)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	// 4. Обрезаем по центру до квадрата и масштабируем
+	// Прозрачные области заливаются белым: в JPEG нет альфа-канала
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, centerSquare(src.Bounds()), draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("ошибка кодирования аватара: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// centerSquare возвращает наибольший квадрат в центре прямоугольника
+func centerSquare(r image.Rectangle) image.Rectangle {
+	side := min(r.Dx(), r.Dy())
+	x := r.Min.X + (r.Dx()-side)/2
+	y := r.Min.Y + (r.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
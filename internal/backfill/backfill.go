@@ -0,0 +1,172 @@
+// Package backfill - заполнение и исправление данных в больших таблицах
+// (fiber-backend backfill), отдельно от миграций схемы.
+//
+// Миграция выполняется одной транзакцией при деплое, поэтому UPDATE всей
+// таблицы в ней держит блокировки и раздувает WAL. Заполнение идет
+// пачками по первичному ключу: каждая пачка - короткая транзакция, в
+// которой вместе с данными сохраняется позиция (таблица backfills).
+// Прерванный запуск (Ctrl+C, деплой, BACKFILL_MAX_DURATION) продолжается
+// с места остановки, а пачка не применяется дважды. Пауза между пачками
+// (BACKFILL_PAUSE_MS) оставляет БД и репликам время на обычную нагрузку.
+//
+// Новое заполнение - значение Backfill с функцией пачки, добавленное
+// в All. Пачка должна быть идемпотентной: после backfill restart она
+// снова увидит уже исправленные строки.
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+)
+
+// Result - итог одной пачки
+type Result struct {
+	LastID  int64 // Наибольший просмотренный ключ: следующая пачка начнется после него
+	Scanned int   // Просмотрено строк; меньше limit - таблица закончилась
+	Updated int   // Изменено строк
+}
+
+// BatchFunc обрабатывает до limit строк с ключом больше after
+// Все запросы делаются через q: это транзакция, в которой сохраняется позиция
+type BatchFunc func(ctx context.Context, q *repository.Queries, after int64, limit int) (Result, error)
+
+// Backfill - заполнение данных
+type Backfill struct {
+	Name        string // Имя для CLI и ключ прогресса в таблице backfills
+	Description string // Что исправляет, для backfill list
+	Batch       BatchFunc
+}
+
+// Runner выполняет заполнения и хранит их прогресс
+type Runner struct {
+	tx      services.Transactor
+	queries *repository.Queries
+	cfg     config.BackfillConfig
+}
+
+// NewRunner создает Runner поверх пула соединений
+func NewRunner(db services.Beginner, queries *repository.Queries, cfg config.BackfillConfig) *Runner {
+	return &Runner{tx: services.NewTransactor(db, queries), queries: queries, cfg: cfg}
+}
+
+// Run выполняет заполнение с сохраненной позиции до конца таблицы,
+// отмены ctx или истечения BACKFILL_MAX_DURATION
+//
+// restart начинает заполнение заново. Возвращает прогресс после запуска;
+// при отмене ctx - прогресс и ошибку ctx: незавершенная пачка откатывается,
+// сохраненная позиция остается верной
+func (r *Runner) Run(ctx context.Context, b Backfill, restart bool) (repository.Backfill, error) {
+	// 1. Запись прогресса при первом запуске, сброс при restart
+	if err := r.queries.CreateBackfill(ctx, b.Name); err != nil {
+		return repository.Backfill{}, fmt.Errorf("ошибка создания записи прогресса: %w", err)
+	}
+	if restart {
+		if err := r.queries.ResetBackfill(ctx, b.Name); err != nil {
+			return repository.Backfill{}, fmt.Errorf("ошибка сброса прогресса: %w", err)
+		}
+	}
+
+	var deadline <-chan time.Time
+	if r.cfg.MaxDuration > 0 {
+		timer := time.NewTimer(r.cfg.MaxDuration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	// 2. Пачки до конца таблицы
+	started := time.Now()
+	for {
+		state, done, err := r.batch(ctx, b)
+		if err != nil {
+			return state, err
+		}
+		if done {
+			slog.Info("✅ Заполнение завершено", "backfill", b.Name,
+				"scanned", state.Scanned, "updated", state.Updated, "elapsed", time.Since(started).Round(time.Second))
+			return state, nil
+		}
+		slog.Info("🔄 Пачка заполнения", "backfill", b.Name,
+			"last_id", state.LastID, "scanned", state.Scanned, "updated", state.Updated)
+
+		// 3. Пауза перед следующей пачкой
+		select {
+		case <-ctx.Done():
+			return state, ctx.Err()
+		case <-deadline:
+			slog.Info("⏸️  Время запуска истекло, заполнение продолжится со следующим запуском",
+				"backfill", b.Name, "last_id", state.LastID, "max_duration", r.cfg.MaxDuration)
+			return state, nil
+		case <-time.After(r.cfg.Pause):
+		}
+	}
+}
+
+// batch выполняет одну пачку в транзакции вместе с сохранением позиции
+// done - заполнение дошло до конца таблицы (или было завершено раньше)
+func (r *Runner) batch(ctx context.Context, b Backfill) (repository.Backfill, bool, error) {
+	var state repository.Backfill
+	var done bool
+	err := r.tx.WithTx(ctx, func(q *repository.Queries) error {
+		// Блокировка строки прогресса: параллельный запуск того же
+		// заполнения ждет конца пачки и продолжает с новой позиции
+		var err error
+		state, err = q.LockBackfill(ctx, b.Name)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения прогресса: %w", err)
+		}
+		if state.CompletedAt.Valid {
+			done = true
+			return nil
+		}
+
+		res, err := b.Batch(ctx, q, state.LastID, r.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("ошибка пачки после id %d: %w", state.LastID, err)
+		}
+		if res.Scanned > 0 {
+			err := q.SaveBackfillProgress(ctx, repository.SaveBackfillProgressParams{
+				Name:    b.Name,
+				LastID:  res.LastID,
+				Scanned: int64(res.Scanned),
+				Updated: int64(res.Updated),
+			})
+			if err != nil {
+				return fmt.Errorf("ошибка сохранения прогресса: %w", err)
+			}
+			state.LastID = res.LastID
+			state.Scanned += int64(res.Scanned)
+			state.Updated += int64(res.Updated)
+		}
+		if res.Scanned < r.cfg.BatchSize {
+			done = true
+			return q.CompleteBackfill(ctx, b.Name)
+		}
+		return nil
+	})
+	return state, done, err
+}
+
+// Status возвращает прогресс всех запускавшихся заполнений
+func (r *Runner) Status(ctx context.Context) ([]repository.Backfill, error) {
+	backfills, err := r.queries.ListBackfills(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения прогресса заполнений: %w", err)
+	}
+	return backfills, nil
+}
+
+// Find возвращает заполнение по имени
+func Find(backfills []Backfill, name string) (Backfill, bool) {
+	for _, b := range backfills {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return Backfill{}, false
+}
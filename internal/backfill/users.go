@@ -0,0 +1,146 @@
+package backfill
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Soundveyve/fiber-backend/internal/emailaddr"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/sanitize"
+)
+
+// All возвращает заполнения, доступные из CLI
+// policy - SANITIZE_POLICY, которой очищаются ранее сохраненные тексты,
+// rules - правила канонических email (EMAIL_STRIP_*)
+func All(policy sanitize.Policy, rules emailaddr.Rules) []Backfill {
+	return []Backfill{
+		SanitizeUserNames(policy),
+		CanonicalEmails(rules),
+	}
+}
+
+// SanitizeUserNames очищает имена и фамилии, сохраненные до появления
+// очистки HTML (SANITIZE_POLICY): новые значения очищаются при записи,
+// а старые отдавались бы клиентам с разметкой
+//
+// Политика reject не может отклонить уже сохраненное значение,
+// поэтому разметка в нем вырезается, как при strip
+func SanitizeUserNames(policy sanitize.Policy) Backfill {
+	if policy == sanitize.PolicyReject {
+		policy = sanitize.PolicyStrip
+	}
+
+	return Backfill{
+		Name:        "sanitize-user-names",
+		Description: "очистка имен и фамилий пользователей от HTML разметки по SANITIZE_POLICY",
+		Batch: func(ctx context.Context, q *repository.Queries, after int64, limit int) (Result, error) {
+			rows, err := q.ListUserNamesAfter(ctx, repository.ListUserNamesAfterParams{
+				AfterID: int32(after),
+				Limit:   int32(limit),
+			})
+			if err != nil {
+				return Result{}, err
+			}
+
+			res := Result{LastID: after, Scanned: len(rows)}
+			for _, row := range rows {
+				res.LastID = int64(row.ID)
+
+				firstName, changedFirst := cleanText(policy, row.FirstName)
+				lastName, changedLast := cleanText(policy, row.LastName)
+				if !changedFirst && !changedLast {
+					continue
+				}
+				err := q.UpdateUserNames(ctx, repository.UpdateUserNamesParams{
+					ID:        row.ID,
+					FirstName: firstName,
+					LastName:  lastName,
+				})
+				if err != nil {
+					return Result{}, err
+				}
+				res.Updated++
+			}
+			return res, nil
+		},
+	}
+}
+
+// cleanText применяет политику к значению колонки
+// Повторный проход не меняет очищенное значение
+func cleanText(policy sanitize.Policy, value pgtype.Text) (pgtype.Text, bool) {
+	if !value.Valid || !sanitize.HasMarkup(value.String) {
+		return value, false
+	}
+	// Экранированная разметка (&lt;b&gt;) для escape уже безопасна:
+	// повторное экранирование испортило бы значение
+	if policy == sanitize.PolicyEscape && !strings.ContainsAny(value.String, "<>") {
+		return value, false
+	}
+	cleaned, err := policy.Text(value.String)
+	if err != nil || cleaned == value.String {
+		return value, false
+	}
+	return pgtype.Text{String: cleaned, Valid: true}, true
+}
+
+// CanonicalEmails нормализует email пользователей, сохраненные до
+// появления нормализации, и заполняет канонический email по rules.
+// После изменения EMAIL_STRIP_* запускается заново (restart)
+//
+// Пользователь, чей адрес совпал с адресом другого неудаленного
+// пользователя, не меняется: дубликат пишется в лог и разбирается вручную
+// (например, слиянием аккаунтов). Без канонического email такой
+// пользователь по-прежнему находится по точному адресу
+func CanonicalEmails(rules emailaddr.Rules) Backfill {
+	return Backfill{
+		Name:        "canonical-emails",
+		Description: "нормализация email и заполнение канонического email по EMAIL_STRIP_*",
+		Batch: func(ctx context.Context, q *repository.Queries, after int64, limit int) (Result, error) {
+			rows, err := q.ListUserEmailsAfter(ctx, repository.ListUserEmailsAfterParams{
+				AfterID: int32(after),
+				Limit:   int32(limit),
+			})
+			if err != nil {
+				return Result{}, err
+			}
+
+			res := Result{LastID: after, Scanned: len(rows)}
+			for _, row := range rows {
+				res.LastID = int64(row.ID)
+
+				email, canonical, changed := canonicalEmail(rules, row.Email, row.CanonicalEmail)
+				if !changed {
+					continue
+				}
+				updated, err := q.UpdateUserEmailCanonical(ctx, repository.UpdateUserEmailCanonicalParams{
+					ID:             row.ID,
+					Email:          email,
+					CanonicalEmail: canonical,
+				})
+				if err != nil {
+					return Result{}, err
+				}
+				if updated == 0 {
+					slog.Warn("⚠️  Email совпадает с адресом другого пользователя, пропущен",
+						"user_id", row.ID, "canonical_email", canonical.String)
+					continue
+				}
+				res.Updated++
+			}
+			return res, nil
+		},
+	}
+}
+
+// canonicalEmail возвращает нормализованный и канонический адрес и
+// признак, что они отличаются от сохраненных
+func canonicalEmail(rules emailaddr.Rules, email string, canonical pgtype.Text) (string, pgtype.Text, bool) {
+	normalized := emailaddr.Normalize(email)
+	want := pgtype.Text{String: rules.Canonical(normalized), Valid: true}
+	changed := normalized != email || canonical != want
+	return normalized, want, changed
+}
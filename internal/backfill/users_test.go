@@ -0,0 +1,77 @@
+package backfill
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Soundveyve/fiber-backend/internal/emailaddr"
+	"github.com/Soundveyve/fiber-backend/internal/sanitize"
+)
+
+func TestCleanText(t *testing.T) {
+	text := func(s string) pgtype.Text { return pgtype.Text{String: s, Valid: true} }
+
+	tests := []struct {
+		policy  sanitize.Policy
+		value   pgtype.Text
+		want    string
+		changed bool
+	}{
+		{sanitize.PolicyStrip, text("<b>Иван</b>"), "Иван", true},
+		{sanitize.PolicyStrip, text("&lt;script&gt;alert(1)&lt;/script&gt;Иван"), "Иван", true},
+		{sanitize.PolicyStrip, text("Иван"), "Иван", false},
+		{sanitize.PolicyStrip, pgtype.Text{}, "", false},
+		{sanitize.PolicyEscape, text("<b>Иван</b>"), "&lt;b&gt;Иван&lt;/b&gt;", true},
+		// Уже экранированное значение не экранируется повторно
+		{sanitize.PolicyEscape, text("&lt;b&gt;Иван&lt;/b&gt;"), "&lt;b&gt;Иван&lt;/b&gt;", false},
+		{sanitize.PolicyEscape, text("Tom & Jerry"), "Tom & Jerry", false},
+	}
+	for _, tc := range tests {
+		got, changed := cleanText(tc.policy, tc.value)
+		if got.String != tc.want || changed != tc.changed {
+			t.Errorf("cleanText(%s, %q) = %q, %v; ожидалось %q, %v",
+				tc.policy, tc.value.String, got.String, changed, tc.want, tc.changed)
+		}
+
+		// Второй проход по результату ничего не меняет
+		if again, changed := cleanText(tc.policy, got); changed {
+			t.Errorf("cleanText(%s, %q): повторный проход изменил значение на %q", tc.policy, got.String, again.String)
+		}
+	}
+}
+
+func TestCanonicalEmail(t *testing.T) {
+	text := func(s string) pgtype.Text { return pgtype.Text{String: s, Valid: true} }
+	gmail := emailaddr.Rules{GmailDots: true}
+
+	tests := []struct {
+		rules     emailaddr.Rules
+		email     string
+		canonical pgtype.Text
+		want      string
+		changed   bool
+	}{
+		{emailaddr.Rules{}, " Ivan@Example.com", pgtype.Text{}, "ivan@example.com", true},
+		{emailaddr.Rules{}, "ivan@example.com", pgtype.Text{}, "ivan@example.com", true},
+		{emailaddr.Rules{}, "ivan@example.com", text("ivan@example.com"), "ivan@example.com", false},
+		// Новые правила пересчитывают канонический адрес, но не email
+		{gmail, "i.van@gmail.com", text("i.van@gmail.com"), "ivan@gmail.com", true},
+		{gmail, "i.van@gmail.com", text("ivan@gmail.com"), "ivan@gmail.com", false},
+	}
+	for _, tc := range tests {
+		email, canonical, changed := canonicalEmail(tc.rules, tc.email, tc.canonical)
+		if canonical.String != tc.want || changed != tc.changed {
+			t.Errorf("canonicalEmail(%+v, %q, %q) = %q, %v; ожидалось %q, %v",
+				tc.rules, tc.email, tc.canonical.String, canonical.String, changed, tc.want, tc.changed)
+		}
+		if email != emailaddr.Normalize(tc.email) {
+			t.Errorf("canonicalEmail(%q): email %q не нормализован", tc.email, email)
+		}
+
+		// Второй проход по результату ничего не меняет
+		if _, _, changed := canonicalEmail(tc.rules, email, canonical); changed {
+			t.Errorf("canonicalEmail(%q): повторный проход изменил значение", tc.email)
+		}
+	}
+}
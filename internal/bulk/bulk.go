@@ -0,0 +1,290 @@
+// Package bulk - потоковое чтение и запись пользователей в CSV и NDJSON
+// для массового импорта и экспорта.
+//
+// Decoder и Encoder работают по одной записи: в памяти не собирается
+// ни весь файл, ни весь список пользователей. Ошибка отдельной строки
+// импорта (*RowError) не прерывает чтение - строку можно пропустить
+// и сообщить о ней в отчете.
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/redact"
+)
+
+// Поддерживаемые форматы
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// maxLineSize - наибольшая длина строки NDJSON
+// Строка пользователя занимает сотни байт, длинная строка - мусор на входе
+const maxLineSize = 64 * 1024
+
+// exportColumns - колонки CSV экспорта
+var exportColumns = []string{"id", "email", "username", "first_name", "last_name", "status", "created_at", "updated_at"}
+
+// ContentType возвращает MIME тип формата
+func ContentType(format string) string {
+	if format == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// ValidFormat сообщает, поддерживается ли формат
+func ValidFormat(format string) bool {
+	return format == FormatCSV || format == FormatNDJSON
+}
+
+// Encoder пишет пользователей в CSV или NDJSON по одной записи
+// Записи буферизуются: после последней нужно вызвать Flush
+type Encoder struct {
+	csv  *csv.Writer
+	json *json.Encoder
+	buf  *bufio.Writer
+
+	// dst - w из NewEncoder, если он сам буферизует запись (bufio.Writer
+	// потокового ответа): Flush сбрасывает и его
+	dst interface{ Flush() error }
+
+	// rules - скрытие полей для того, кто получит файл (см. Redact)
+	rules  redact.Rules
+	selfID int
+}
+
+// NewEncoder создает Encoder формата format
+// Для CSV сразу пишет строку заголовка
+func NewEncoder(w io.Writer, format string) (*Encoder, error) {
+	dst, _ := w.(interface{ Flush() error })
+
+	switch format {
+	case FormatCSV:
+		enc := &Encoder{csv: csv.NewWriter(w), dst: dst}
+		if err := enc.csv.Write(exportColumns); err != nil {
+			return nil, fmt.Errorf("ошибка записи CSV: %w", err)
+		}
+		return enc, nil
+	case FormatNDJSON:
+		buf := bufio.NewWriter(w)
+		return &Encoder{json: json.NewEncoder(buf), buf: buf, dst: dst}, nil
+	default:
+		return nil, fmt.Errorf("неподдерживаемый формат: %s", format)
+	}
+}
+
+// Redact задает правила скрытия полей (RESPONSE_REDACTION) для получателя
+// выгрузки: файл - тот же ответ API, только не JSON, и middleware.Redact
+// его не обрабатывает. selfID - внутренний ID получателя, его собственная
+// запись пишется целиком; 0 - исключений нет
+func (e *Encoder) Redact(rules redact.Rules, selfID int) {
+	e.rules = rules
+	e.selfID = selfID
+}
+
+// Encode пишет одного пользователя
+// NDJSON - тот же объект, что и в ответах API, CSV - колонки exportColumns
+func (e *Encoder) Encode(user *models.UserResponse) error {
+	if len(e.rules) > 0 && (e.selfID == 0 || user.InternalID != e.selfID) {
+		redacted, err := redact.Copy(user, e.rules)
+		if err != nil {
+			return fmt.Errorf("ошибка скрытия полей выгрузки: %w", err)
+		}
+		user = redacted
+	}
+	if e.json != nil {
+		return e.json.Encode(user)
+	}
+	return e.csv.Write([]string{
+		user.ID, // Публичный ID, как в ответах API
+		user.Email,
+		user.Username,
+		deref(user.FirstName),
+		deref(user.LastName),
+		user.Status,
+		user.CreatedAt.Format(time.RFC3339),
+		user.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+// Flush дописывает буферизованные записи в w
+// Для потокового ответа это отправка клиенту: медленный клиент
+// задерживает Flush, а с ним и чтение следующей пачки из БД
+func (e *Encoder) Flush() error {
+	if e.json != nil {
+		if err := e.buf.Flush(); err != nil {
+			return err
+		}
+	} else {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return fmt.Errorf("ошибка записи CSV: %w", err)
+		}
+	}
+	if e.dst != nil {
+		return e.dst.Flush()
+	}
+	return nil
+}
+
+// Row - пользователь из строки импорта
+// Поля CSV берутся по именам колонок заголовка, NDJSON - по ключам объекта
+type Row struct {
+	Line         int    `json:"-"` // Номер записи CSV (с 1, без заголовка) или строки NDJSON
+	Email        string `json:"email" validate:"required,email"`
+	Username     string `json:"username" validate:"required,min=3"`
+	PasswordHash string `json:"password_hash"` // bcrypt хеш, пустой - пароль нужно будет сбросить
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+}
+
+// RowError - ошибка одной строки импорта, чтение можно продолжать
+type RowError struct {
+	Line int
+	Err  error
+}
+
+// Error реализует error
+func (e *RowError) Error() string {
+	return fmt.Sprintf("строка %d: %v", e.Line, e.Err)
+}
+
+// Unwrap возвращает причину ошибки строки
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Decoder читает строки импорта из CSV или NDJSON по одной
+type Decoder struct {
+	line int
+
+	csv     *csv.Reader
+	columns map[string]int // Колонка CSV -> индекс в записи
+	width   int            // Колонок в заголовке CSV
+
+	lines *bufio.Scanner
+}
+
+// NewDecoder создает Decoder формата format
+// Для CSV читает заголовок: колонки email и username обязательны
+func NewDecoder(r io.Reader, format string) (*Decoder, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.TrimLeadingSpace = true
+		reader.FieldsPerRecord = -1 // Число колонок сверяется с заголовком в Next
+		reader.ReuseRecord = true
+
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения заголовка CSV: %w", err)
+		}
+		columns := make(map[string]int, len(header))
+		for i, name := range header {
+			columns[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		for _, required := range []string{"email", "username"} {
+			if _, ok := columns[required]; !ok {
+				return nil, fmt.Errorf("в заголовке CSV нет колонки %s", required)
+			}
+		}
+		return &Decoder{csv: reader, columns: columns, width: len(header)}, nil
+	case FormatNDJSON:
+		lines := bufio.NewScanner(r)
+		lines.Buffer(make([]byte, 0, 4096), maxLineSize)
+		return &Decoder{lines: lines}, nil
+	default:
+		return nil, fmt.Errorf("неподдерживаемый формат: %s", format)
+	}
+}
+
+// Next возвращает следующую строку импорта
+//
+// *RowError - строка не разобрана, но следующие читать можно;
+// io.EOF - данные закончились; другая ошибка - источник нечитаем
+func (d *Decoder) Next() (Row, error) {
+	if d.csv != nil {
+		return d.nextCSV()
+	}
+	return d.nextNDJSON()
+}
+
+// nextCSV читает запись CSV
+func (d *Decoder) nextCSV() (Row, error) {
+	values, err := d.csv.Read()
+	if err == io.EOF {
+		return Row{}, io.EOF
+	}
+	d.line++
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return Row{}, &RowError{Line: d.line, Err: parseErr.Err}
+	}
+	if err != nil {
+		return Row{}, fmt.Errorf("ошибка чтения CSV: %w", err)
+	}
+	if len(values) > d.width {
+		return Row{}, &RowError{Line: d.line, Err: errors.New("колонок больше, чем в заголовке")}
+	}
+
+	get := func(column string) string {
+		if i, ok := d.columns[column]; ok && i < len(values) {
+			return strings.TrimSpace(values[i])
+		}
+		return ""
+	}
+	return Row{
+		Line:         d.line,
+		Email:        get("email"),
+		Username:     get("username"),
+		PasswordHash: get("password_hash"),
+		FirstName:    get("first_name"),
+		LastName:     get("last_name"),
+	}, nil
+}
+
+// nextNDJSON читает объект JSON из следующей непустой строки
+func (d *Decoder) nextNDJSON() (Row, error) {
+	for d.lines.Scan() {
+		d.line++
+		text := strings.TrimSpace(d.lines.Text())
+		if text == "" {
+			continue
+		}
+
+		var row Row
+		if err := json.Unmarshal([]byte(text), &row); err != nil {
+			return Row{}, &RowError{Line: d.line, Err: errors.New("невалидный JSON объект")}
+		}
+		row.Line = d.line
+		row.Email = strings.TrimSpace(row.Email)
+		row.Username = strings.TrimSpace(row.Username)
+		return row, nil
+	}
+	if err := d.lines.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return Row{}, fmt.Errorf("строка %d длиннее %d байт", d.line+1, maxLineSize)
+		}
+		return Row{}, fmt.Errorf("ошибка чтения NDJSON: %w", err)
+	}
+	return Row{}, io.EOF
+}
+
+// deref возвращает значение необязательного поля или пустую строку
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
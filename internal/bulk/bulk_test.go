@@ -0,0 +1,213 @@
+package bulk
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/redact"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+)
+
+// readAll читает все строки: разобранные и номера строк с ошибками
+func readAll(t *testing.T, dec *Decoder) ([]Row, []int) {
+	t.Helper()
+	var rows []Row
+	var failed []int
+	for {
+		row, err := dec.Next()
+		if err == io.EOF {
+			return rows, failed
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			failed = append(failed, rowErr.Line)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestDecoderCSV(t *testing.T) {
+	input := "Username, EMAIL,first_name,extra\n" +
+		"ivan, ivan@example.com ,Иван,x\n" +
+		"petr,petr@example.com,Петр,x,лишняя\n" +
+		"anna,anna@example.com\n" +
+		"\"oops,bad@example.com,,x\n"
+
+	dec, err := NewDecoder(strings.NewReader(input), FormatCSV)
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	rows, failed := readAll(t, dec)
+
+	// Колонки берутся по заголовку, короткая запись допустима, длинная - ошибка строки
+	if len(rows) != 2 {
+		t.Fatalf("строк %d, ожидалось 2: %+v", len(rows), rows)
+	}
+	if got := rows[0]; got.Line != 1 || got.Email != "ivan@example.com" || got.Username != "ivan" || got.FirstName != "Иван" {
+		t.Errorf("строка 1 = %+v", got)
+	}
+	if got := rows[1]; got.Line != 3 || got.Email != "anna@example.com" || got.FirstName != "" {
+		t.Errorf("строка 3 = %+v", got)
+	}
+	if len(failed) != 2 || failed[0] != 2 || failed[1] != 4 {
+		t.Errorf("ошибки в строках %v, ожидались [2 4]", failed)
+	}
+}
+
+func TestDecoderCSVHeader(t *testing.T) {
+	for _, input := range []string{"", "email,first_name\nivan@example.com,Иван\n"} {
+		if _, err := NewDecoder(strings.NewReader(input), FormatCSV); err == nil {
+			t.Errorf("NewDecoder(%q): ожидалась ошибка заголовка", input)
+		}
+	}
+	if _, err := NewDecoder(strings.NewReader(""), "xml"); err == nil {
+		t.Error("NewDecoder(xml): ожидалась ошибка формата")
+	}
+}
+
+func TestDecoderNDJSON(t *testing.T) {
+	input := `{"email":"ivan@example.com","username":"ivan","password_hash":"$2a$10$x"}` + "\n" +
+		"\n" +
+		`{"email": "petr@example.com"` + "\n" +
+		`{"email":" anna@example.com ","username":"anna","last_name":"Петрова"}`
+
+	dec, err := NewDecoder(strings.NewReader(input), FormatNDJSON)
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	rows, failed := readAll(t, dec)
+
+	// Пустые строки пропускаются, но учитываются в нумерации
+	if len(rows) != 2 {
+		t.Fatalf("строк %d, ожидалось 2: %+v", len(rows), rows)
+	}
+	if got := rows[0]; got.Line != 1 || got.PasswordHash != "$2a$10$x" {
+		t.Errorf("строка 1 = %+v", got)
+	}
+	if got := rows[1]; got.Line != 4 || got.Email != "anna@example.com" || got.LastName != "Петрова" {
+		t.Errorf("строка 4 = %+v", got)
+	}
+	if len(failed) != 1 || failed[0] != 3 {
+		t.Errorf("ошибки в строках %v, ожидались [3]", failed)
+	}
+
+	// Слишком длинная строка - ошибка источника, а не строки
+	dec, _ = NewDecoder(strings.NewReader(strings.Repeat("x", maxLineSize+1)), FormatNDJSON)
+	_, err = dec.Next()
+	var rowErr *RowError
+	if err == nil || err == io.EOF || errors.As(err, &rowErr) {
+		t.Errorf("Next для длинной строки = %v", err)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	at := utc.Time{Time: time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)}
+	firstName := "Иван"
+	user := &models.UserResponse{
+		ID:        "3f2b6c1e",
+		Email:     "ivan@example.com",
+		Username:  "ivan",
+		FirstName: &firstName,
+		Status:    "active",
+		CreatedAt: at,
+		UpdatedAt: at,
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FormatCSV, "id,email,username,first_name,last_name,status,created_at,updated_at\n" +
+			"3f2b6c1e,ivan@example.com,ivan,Иван,,active,2024-01-31T12:00:00Z,2024-01-31T12:00:00Z\n"},
+		{FormatNDJSON, `"email":"ivan@example.com"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out strings.Builder
+			enc, err := NewEncoder(&out, tt.format)
+			if err != nil {
+				t.Fatalf("NewEncoder: %v", err)
+			}
+			for i := 0; i < 2; i++ {
+				if err := enc.Encode(user); err != nil {
+					t.Fatalf("Encode: %v", err)
+				}
+			}
+			if err := enc.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+
+			got := out.String()
+			if tt.format == FormatCSV && !strings.HasPrefix(got, tt.want) {
+				t.Errorf("CSV:\n%s", got)
+			}
+			if tt.format == FormatNDJSON && (strings.Count(got, "\n") != 2 || !strings.Contains(got, tt.want)) {
+				t.Errorf("NDJSON:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestEncoderRedact(t *testing.T) {
+	phone := "+79123456789"
+	other := &models.UserResponse{InternalID: 1, ID: "a", Email: "anna@example.com", Username: "anna", Phone: &phone, Status: "active"}
+	own := &models.UserResponse{InternalID: 2, ID: "b", Email: "ivan@example.com", Username: "ivan", Phone: &phone, Status: "active"}
+	rules := redact.Rules{"email": redact.Mask, "phone": redact.Hide}
+
+	tests := []struct {
+		format string
+		want   []string // Строки записей other и own
+	}{
+		{FormatCSV, []string{
+			"a,a***@example.com,anna,,,active,",
+			"b,ivan@example.com,ivan,,,active,",
+		}},
+		{FormatNDJSON, []string{
+			`"email":"a***@example.com","username":"anna","status"`,
+			`"email":"ivan@example.com","username":"ivan","phone":"+79123456789"`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out strings.Builder
+			enc, err := NewEncoder(&out, tt.format)
+			if err != nil {
+				t.Fatalf("NewEncoder: %v", err)
+			}
+			// Выгрузку получает ivan: его запись не скрывается
+			enc.Redact(rules, 2)
+			for _, user := range []*models.UserResponse{other, own} {
+				if err := enc.Encode(user); err != nil {
+					t.Fatalf("Encode: %v", err)
+				}
+			}
+			if err := enc.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if tt.format == FormatCSV {
+				lines = lines[1:]
+			}
+			if len(lines) != len(tt.want) {
+				t.Fatalf("записей %d:\n%s", len(lines), out.String())
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(lines[i], want) {
+					t.Errorf("запись %d: %s, ожидалось %s", i, lines[i], want)
+				}
+			}
+			if other.Email != "anna@example.com" || other.Phone == nil {
+				t.Errorf("Encode изменил исходного пользователя: %+v", other)
+			}
+		})
+	}
+}
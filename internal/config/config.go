@@ -1,19 +1,42 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
-	"os"
-	"strconv"
+	"net/url"
+	"strings"
 	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/Soundveyve/fiber-backend/internal/jsoncodec"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/redact"
+	"github.com/Soundveyve/fiber-backend/internal/tiers"
 )
 
 // Config структура содержит все настройки приложения
 // Мы группируем настройки по категориям для лучшей организации
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
+	App       AppConfig
+	Database  DatabaseConfig
+	Storage   StorageConfig
+	Avatar    AvatarConfig
+	Export    ExportConfig
+	Digest    DigestConfig
+	Notify    NotifyConfig
+	Broadcast BroadcastConfig
+	Audit     AuditConfig
+	Auth      AuthConfig
+	Mail      MailConfig
+	Redis     RedisConfig
+	Tracing   TracingConfig
+	WellKnown WellKnownConfig
+	Jobs      JobsConfig
+	Backfill  BackfillConfig
+	Shutdown  ShutdownConfig
+
+	file     string            // Файл конфигурации, из которого загружена конфигурация
+	origins  map[string]string // Источник значения каждого параметра, см. Origins
+	loadedAt time.Time
 }
 
 // AppConfig содержит основные настройки приложения
@@ -21,117 +44,1050 @@ type AppConfig struct {
 	Name string // Имя приложения
 	Port string // Порт на котором будет слушать HTTP сервер
 	Env  string // Окружение (development, production)
+
+	// Region - регион, в котором запущен экземпляр (eu-west-1)
+	// Попадает в логи, метрики, трассы и заголовок X-Served-By и выбирает
+	// реплику БД своего региона. Пустой - развертывание в одном регионе
+	Region string
+
+	// GRPCPort - порт gRPC API (api/proto), пустой - gRPC сервер не запускается
+	GRPCPort string
+
+	// LogLevel - минимальный уровень логов: debug, info, warn, error
+	// В production логи пишутся JSON, иначе текстом (см. пакет logging)
+	LogLevel string
+
+	// RequestTimeout ограничивает время обработки одного запроса
+	// По истечении контекст запроса отменяется вместе с запросами к БД
+	RequestTimeout time.Duration
+
+	// LatencyBudget - бюджет времени ответа роутов без routes.Spec.Budget
+	// Более медленные запросы пишутся в лог с разбивкой по этапам, 0 - только
+	// роуты с явным бюджетом
+	LatencyBudget time.Duration
+
+	// ReadTimeout ограничивает чтение запроса вместе с телом
+	// Клиент, который не передал тело за это время, получает 408,
+	// а медленные клиенты не держат соединения бесконечно
+	ReadTimeout time.Duration
+
+	// WarmUpTimeout ограничивает прогрев после старта
+	// До его окончания health-check отвечает 503
+	WarmUpTimeout time.Duration
+
+	// HealthCacheTTL - время жизни результата проверки зависимостей для /health/lb
+	HealthCacheTTL time.Duration
+
+	// HealthProbeTimeout - сколько readiness ждет ответа одной зависимости
+	// Не ответившая вовремя зависимость считается недоступной
+	HealthProbeTimeout time.Duration
+
+	// SettingsRefreshInterval - как часто перечитываются переопределения
+	// настроек из БД (изменения через admin API на других инстансах)
+	SettingsRefreshInterval time.Duration
+
+	// CORSAllowOrigins - источники CORS через запятую; "*" - любые
+	// Источники и клиенты из admin API добавляются к этому списку
+	CORSAllowOrigins string
+
+	// ReusePort открывает порт с SO_REUSEPORT: новый процесс может занять
+	// тот же порт, пока старый дообрабатывает запросы, и перезапуск на
+	// одном хосте обходится без отказов в соединении
+	ReusePort bool
+
+	// FakeServices заменяет внешние сервисы (почта, SMS, хранилище, платежи)
+	// фейками в памяти, а исходящие сообщения доступны через GET /dev/outbox
+	// Позволяет проверять полные сценарии локально без учетных данных
+	FakeServices bool
+
+	// AdminUI - встроенный интерфейс администратора на /admin
+	// (поиск пользователей, приостановка, журнал аудита), см. пакет adminui
+	AdminUI bool
+
+	// Защита от патологических тел запросов
+	BodyLimit       int // Максимальный размер тела запроса в байтах (413 при превышении)
+	JSONMaxDepth    int // Максимальная вложенность JSON (422 при превышении)
+	JSONMaxArrayLen int // Максимальная длина массива в JSON (422 при превышении)
+
+	// JSONCodec - библиотека JSON для ответов и BodyParser: std, go-json, sonic
+	// sonic доступен только в сборке с тегом sonic (см. пакет jsoncodec)
+	JSONCodec string
+
+	// IDEncoding - вид целочисленных ID в API: plain (числа) или hashids
+	// (обратимо закодированные строки, см. пакет publicid)
+	IDEncoding string
+
+	// IDSecret - соль hashids; после смены старые ссылки с ID перестают работать
+	// IDMinLength - минимальная длина закодированного ID
+	IDSecret    string
+	IDMinLength int
+
+	// SanitizePolicy - что делать с HTML разметкой в свободном тексте
+	// (имена, объявления) перед сохранением: strip, escape, reject
+	SanitizePolicy string
+
+	// ResponseRedaction - политика скрытия полей JSON ответов по роли
+	// и тенанту вызывающего, формат - в пакете redact. Пустая - без скрытия
+	ResponseRedaction string
+
+	// SecretKey - ключ для подписи ссылок (скачивание экспортов и т.п.)
+	// В production обязан быть задан и храниться в секрете
+	SecretKey string
+
+	// Ограничение одновременных дорогих операций (импорт, экспорт)
+	ImportConcurrency       int           // Одновременных импортов пользователей
+	ExportConcurrency       int           // Одновременных запросов на создание экспорта
+	AvatarConcurrency       int           // Одновременных загрузок аватаров (декодирование изображений)
+	ConcurrencyQueueTimeout time.Duration // Сколько запрос ждет слота до 503
+
+	// Лимиты запросов к /api/v1 по уровням вызывающего (см. LimitPolicy)
+	// в окне RateLimitWindow. После *Soft запросы проходят, но получают
+	// X-RateLimit-Warning, после лимита - 429, 0 отключает лимит.
+	// *Burst - запросов в секунду, 0 - без ограничения
+	//
+	// RateLimit* - анонимные клиенты (anonymous), по IP
+	RateLimit       int
+	RateLimitSoft   int
+	RateLimitBurst  int
+	RateLimitWindow time.Duration
+
+	// UserRateLimit* - пользователи с access токеном (free), по пользователю
+	// Пользователи считаются отдельно от IP: за одним NAT их может быть много
+	UserRateLimit      int
+	UserRateLimitSoft  int
+	UserRateLimitBurst int
+
+	// ProRateLimit* и InternalRateLimit* - уровни pro и internal
+	ProRateLimit           int
+	ProRateLimitSoft       int
+	ProRateLimitBurst      int
+	InternalRateLimit      int
+	InternalRateLimitSoft  int
+	InternalRateLimitBurst int
+
+	// LimitTierRoles - уровни ролей: "admin=internal,partner=pro"
+	// Остальные пользователи с токеном - free
+	LimitTierRoles string
+
+	// Строгие лимиты чувствительных эндпоинтов в том же окне
+	// AuthRateLimit - вход и письма (сброс пароля, подтверждение email) с одного IP,
+	// CreateUserRateLimit - создание пользователей одним пользователем
+	AuthRateLimit       int
+	CreateUserRateLimit int
+
+	// Лимиты экспортов (создание, выгрузка, скачивание файла) в отдельном,
+	// более длинном окне: ExportRateLimit - одним пользователем,
+	// ExportIPRateLimit - с одного IP. Экспорт отдает данные многих
+	// пользователей сразу и первым интересен при утечке доступа
+	ExportRateLimit       int
+	ExportIPRateLimit     int
+	ExportRateLimitWindow time.Duration
+
+	// BulkReadAlertThreshold - запросов к экспортам и массовым чтениям
+	// (списки, поиск) от одного пользователя или IP в окне
+	// BulkReadAlertWindow, после которого срабатывает оповещение о
+	// возможной выгрузке данных (лог, метрика, журнал аудита). Запросы
+	// не отклоняются. 0 - оповещения выключены
+	BulkReadAlertThreshold int
+	BulkReadAlertWindow    time.Duration
+
+	// Ограничения списков по уровню вызывающего (см. query.Quota)
+	// Больший page_size уменьшается до предела, 0 - без ограничения.
+	// Анонимным клиентам фильтры и сортировка доступны только
+	// с AnonymousListFilters, остальным уровням - всегда
+	AnonymousMaxPageSize int
+	AnonymousListFilters bool
+	UserMaxPageSize      int
+	ProMaxPageSize       int
+	InternalMaxPageSize  int
+
+	// UsernameHold - сколько прежнее имя пользователя после переименования
+	// недоступно другим пользователям (ссылки на старое имя ведут на новое)
+	// Значение по умолчанию для настройки users.username_hold
+	UsernameHold time.Duration
+
+	// SyncRetention - сколько хранятся записи о физически удаленных
+	// пользователях для GET /api/v1/users/changes. Клиент, не
+	// синхронизировавшийся дольше, загружает список заново (410)
+	// Значение по умолчанию для настройки users.sync_retention
+	SyncRetention time.Duration
+
+	// ServiceSigningKey - общий ключ HMAC подписи запросов между сервисами
+	// Если задан, запросы к /admin/v1 обязаны быть подписаны
+	ServiceSigningKey string
+
+	// ServiceSignatureMaxAge - допустимое расхождение времени подписи и сервера
+	// Одноразовые nonce запоминаются на это же время
+	ServiceSignatureMaxAge time.Duration
+
+	// InternalServiceKeys - ключи HMAC внутренних сервисов для /internal/v1
+	// в формате <сервис>=<ключ>,... (см. ServiceKeys). Возраст подписи -
+	// ServiceSignatureMaxAge
+	InternalServiceKeys string
+
+	// InternalCertHeader - заголовок с CN клиентского сертификата, который
+	// проверил ingress (mTLS). Пусто - доступ к /internal/v1 только по подписи
+	InternalCertHeader string
+
+	// InternalCertServices - CN сертификатов, которым разрешен /internal/v1
+	InternalCertServices string
+
+	// LegacyErrorsSunset - дата (YYYY-MM-DD), после которой ошибки перестанут
+	// отдаваться в старом формате ErrorResponse. Если задана, такие ответы
+	// получают заголовок Sunset. Пустая - переходный период без даты
+	LegacyErrorsSunset string
 }
 
-// DatabaseConfig содержит настройки подключения к базе данных
-// Эта структура универсальна и подходит для разных типов БД
+// DatabaseConfig содержит настройки подключения к PostgreSQL (pgxpool)
 type DatabaseConfig struct {
-	Driver          string        // Тип БД: postgres, mysql
+	Driver          string        // Тип БД: только postgres
 	Host            string        // Хост БД
 	Port            string        // Порт БД
 	User            string        // Имя пользователя
 	Password        string        // Пароль
 	Name            string        // Имя базы данных
-	SSLMode         string        // Режим SSL (для PostgreSQL)
-	MaxOpenConns    int           // Максимум открытых соединений
-	MaxIdleConns    int           // Максимум простаивающих соединений
+	SSLMode         string        // Режим SSL
+	MaxOpenConns    int           // Максимум соединений пула (MaxConns)
+	MaxIdleConns    int           // Соединения, которые пул держит открытыми в простое (MinConns)
 	ConnMaxLifetime time.Duration // Время жизни соединения
+
+	// StatementTimeout - максимальное время выполнения одного SQL запроса на стороне БД
+	// Страхует от запросов, которые продолжают выполняться после отмены контекста
+	StatementTimeout time.Duration
+
+	// AutoMigrate - применять непримененные миграции при запуске сервера
+	// Удобно для разработки и простых деплоев; иначе - fiber-backend migrate up
+	AutoMigrate bool
+
+	// Replicas - реплики для чтения по регионам через запятую:
+	// eu-west-1=replica-eu:5432,us-east-1=replica-us:5432
+	// Учетные данные и имя БД - как у основной БД, см. ReplicaFor
+	Replicas string
+}
+
+// StorageConfig содержит настройки хранилища файлов
+// Driver выбирает бэкенд: local (локальный диск) или s3 (S3-совместимое хранилище)
+type StorageConfig struct {
+	Driver   string // Тип хранилища: local, s3
+	LocalDir string // Директория для драйвера local
+
+	S3Endpoint  string // Адрес S3 без схемы (s3.amazonaws.com, localhost:9000)
+	S3Region    string // Регион бакета
+	S3Bucket    string // Имя бакета
+	S3AccessKey string // Ключ доступа
+	S3SecretKey string // Секретный ключ
+	S3UseSSL    bool   // Использовать HTTPS
+}
+
+// AvatarConfig содержит настройки загрузки аватаров
+type AvatarConfig struct {
+	MaxSize int // Максимальный размер загружаемого файла в байтах
+	Size    int // Сторона квадратного аватара после обработки в пикселях
 }
 
-// LoadConfig загружает конфигурацию из переменных окружения
-// Она сначала пытается загрузить .env файл, затем читает переменные
-func LoadConfig() (*Config, error) {
-	// Загружаем .env файл если он существует
-	// В продакшене .env может не быть, и это нормально
-	// В Docker контейнерах переменные будут переданы напрямую
-	_ = godotenv.Load()
+// AuditConfig содержит настройки журнала аудита
+type AuditConfig struct {
+	// BufferSize - сколько записей ждут сохранения в памяти
+	// При переполнении (БД не успевает) новые записи откладываются на диск
+	BufferSize int
+
+	// SpoolDir - каталог журнала записей, отложенных при переполнении
+	// очереди или недоступной БД; пустой - такие записи отбрасываются
+	SpoolDir string
+	// SpoolMaxSize - наибольший размер журнала на диске в байтах
+	SpoolMaxSize int64
+
+	// ArchiveAfter - сколько записи хранятся в audit_logs, затем переносятся
+	// в архив в хранилище (задание archive_records); 0 - архив выключен
+	ArchiveAfter time.Duration
+	// ArchiveRetention - сколько хранятся файлы архива; 0 - бессрочно
+	ArchiveRetention time.Duration
+	// ArchiveBatchSize - записей в одном файле архива (для всех журналов)
+	ArchiveBatchSize int
+
+	// LoginHistoryArchiveAfter и LoginHistoryArchiveRetention - те же
+	// сроки для истории входов (login_history); 0 - архив выключен
+	LoginHistoryArchiveAfter     time.Duration
+	LoginHistoryArchiveRetention time.Duration
+}
+
+// ExportConfig содержит настройки асинхронного экспорта
+type ExportConfig struct {
+	URLTTL time.Duration // Время жизни ссылки на скачивание готового файла
+}
+
+// DigestConfig содержит настройки сводки активности аккаунта на email
+type DigestConfig struct {
+	Period       time.Duration // Период сводки (по умолчанию неделя)
+	PollInterval time.Duration // Как часто воркер ищет подписки, по которым пора отправить сводку
+
+	// AutoSubscribe - подписывать создаваемых пользователей на сводку
+	// Значение по умолчанию, переопределяется настройкой digest.auto_subscribe
+	AutoSubscribe bool
+}
+
+// NotifyConfig содержит настройки уведомлений о действиях с аккаунтом (internal/notify)
+type NotifyConfig struct {
+	// BatchWindows - окна накопления по типу события через запятую:
+	// user.update=15m,user.role_update=1m. События одного типа за окно
+	// уходят пользователю одним письмом. Пустое - уведомления выключены
+	BatchWindows string
+}
+
+// BroadcastConfig содержит настройки массовых рассылок администраторов
+type BroadcastConfig struct {
+	PollInterval time.Duration // Как часто воркер проверяет очередь рассылок
+	BatchSize    int           // Получателей в пачке; прогресс сохраняется после каждой пачки
+}
+
+// AuthConfig содержит настройки JWT аутентификации
+type AuthConfig struct {
+	JWTSecret  string        // Ключ подписи токенов (HS256)
+	Issuer     string        // Значение iss в токенах
+	AccessTTL  time.Duration // Время жизни access токена
+	RefreshTTL time.Duration // Время жизни refresh токена
+	SudoTTL    time.Duration // Время жизни sudo токена (режим повышенных прав)
+
+	EmailVerificationTTL time.Duration // Время жизни ссылки подтверждения email
+	PasswordResetTTL     time.Duration // Время жизни ссылки сброса пароля
+
+	// TwoFactorTTL - время на ввод кода 2FA после пароля
+	// (время жизни токена незавершенного входа)
+	TwoFactorTTL time.Duration
+	// TwoFactorIssuer - название сервиса в приложении-аутентификаторе
+	TwoFactorIssuer string
+	// TwoFactorEncryptionKey - ключ AES-256 (64 hex символа), которым
+	// шифруются секреты TOTP в БД. Пустой - включение 2FA недоступно
+	TwoFactorEncryptionKey string
+
+	// Восстановление доступа при потере второго фактора: ссылка
+	// подтверждения email живет TwoFactorRecoveryLinkTTL, после нее
+	// TwoFactorRecoveryDelay владелец может отменить запрос, войдя с 2FA.
+	// TwoFactorRecoveryApproval дополнительно требует одобрения администратора
+	TwoFactorRecoveryLinkTTL  time.Duration
+	TwoFactorRecoveryDelay    time.Duration
+	TwoFactorRecoveryApproval bool
+
+	// BcryptCost - стоимость хешей паролей; при калибровке - нижняя граница
+	// PasswordHashTarget - бюджет времени на хеш, по которому при запуске
+	// подбирается стоимость от BcryptCost до BcryptMaxCost. 0 - без калибровки
+	BcryptCost         int
+	BcryptMaxCost      int
+	PasswordHashTarget time.Duration
+
+	// Канонический email (уникален среди пользователей) без точек в имени
+	// ящика Gmail и без метки после "+". После изменения канонические
+	// адреса пересчитываются: backfill restart canonical-emails
+	EmailStripGmailDots   bool
+	EmailStripPlusAliases bool
+}
+
+// MailConfig содержит настройки отправки писем
+// Driver выбирает бэкенд: smtp или noop (письма только пишутся в лог)
+type MailConfig struct {
+	Driver string // Способ отправки: smtp, noop
+	From   string // Адрес отправителя
+
+	SMTPHost     string // Адрес SMTP сервера
+	SMTPPort     int    // Порт SMTP сервера (587 - submission со STARTTLS)
+	SMTPUsername string // Логин, пустой - без аутентификации
+	SMTPPassword string // Пароль
+
+	// LinkBaseURL - адрес фронтенда, от которого строятся ссылки в письмах
+	// (подтверждение email, сброс пароля)
+	LinkBaseURL string
+}
+
+// RedisConfig содержит настройки подключения к Redis
+// Redis хранит общие для инстансов счетчики лимита запросов.
+// Пустой Addr - Redis не используется, счетчики в памяти процесса
+type RedisConfig struct {
+	Addr     string // host:port
+	Password string // Пароль, пустой - без аутентификации
+	DB       int    // Номер базы
+
+	// Timeout ограничивает подключение и каждую команду: недоступный Redis
+	// не должен задерживать запросы. После ошибки Redis не опрашивается
+	// RetryInterval, запросы сразу идут по запасному пути
+	Timeout       time.Duration
+	RetryInterval time.Duration
+
+	// RateLimitFallback - лимиты запросов без Redis: memory - счетчики в памяти
+	// инстанса, open - запросы пропускаются без лимита, closed - 503
+	RateLimitFallback string
+}
+
+// JobsConfig содержит настройки очереди фоновых заданий (internal/jobs)
+type JobsConfig struct {
+	Workers      int           // Одновременно выполняемых заданий на инстанс
+	PollInterval time.Duration // Как часто свободный воркер проверяет очередь
+	MaxAttempts  int           // Попыток на задание, включая первую
+	Timeout      time.Duration // Ограничение одной попытки
+	Retention    time.Duration // Сколько хранятся завершенные задания
+
+	// InactiveUserDays - через сколько дней без входа аккаунт деактивируется
+	// ночным заданием. 0 - автоматическая деактивация выключена
+	InactiveUserDays int
+}
+
+// BackfillConfig содержит настройки заполнений данных (fiber-backend backfill)
+// Задаются флагами запуска: --backfill-batch-size=500 --backfill-pause-ms=200
+type BackfillConfig struct {
+	BatchSize int           // Строк в пачке; одна пачка - одна транзакция
+	Pause     time.Duration // Пауза между пачками, снижает нагрузку на БД и реплики
+
+	// MaxDuration - сколько длится один запуск, 0 - до конца таблицы
+	// Позволяет уложиться в окно обслуживания и продолжить в следующем
+	MaxDuration time.Duration
+}
+
+// ShutdownConfig содержит сроки остановки подсистем при завершении
+// Фазы идут по порядку (серверы, воркеры, аудит, трассировка), поэтому
+// худшее время остановки - сумма сроков фаз; оно должно укладываться
+// в terminationGracePeriodSeconds
+type ShutdownConfig struct {
+	HTTP    time.Duration // Дождаться текущих HTTP запросов
+	GRPC    time.Duration // Дождаться текущих вызовов gRPC, затем прервать
+	Jobs    time.Duration // Дождаться выполняемых фоновых заданий
+	Workers time.Duration // Остановить опрос экспортов, сводок, рассылок и настроек
+	Audit   time.Duration // Дописать очередь журнала аудита
+	Tracing time.Duration // Отправить накопленные спаны
+}
+
+// TracingConfig содержит настройки распределенной трассировки (OpenTelemetry)
+// Пустой OTLPEndpoint - трассировка выключена, спаны не создаются и не отправляются
+type TracingConfig struct {
+	OTLPEndpoint string  // host:port OTLP/HTTP коллектора (4318)
+	Insecure     bool    // Отправлять по HTTP без TLS (коллектор в той же сети)
+	SampleRatio  float64 // Доля трассируемых запросов от 0 до 1
+}
+
+// WellKnownConfig содержит настройки документов /.well-known/
+// (security.txt, change-password, JWKS, OIDC discovery)
+type WellKnownConfig struct {
+	// BaseURL - внешний адрес API, от которого строятся ссылки в документах
+	BaseURL string
+
+	// SecurityContacts - контакты для сообщений об уязвимостях через запятую
+	// (mailto:security@example.com, https://example.com/security).
+	// Пустой - security.txt не отдается
+	SecurityContacts string
+	SecurityPolicy   string        // Ссылка на политику раскрытия уязвимостей
+	SecurityLanguage string        // Языки сообщений: "ru, en"
+	SecurityExpires  time.Duration // Срок актуальности security.txt от момента запроса
+
+	// ChangePasswordURL - страница смены пароля во фронтенде,
+	// на которую перенаправляет /.well-known/change-password
+	ChangePasswordURL string
+}
+
+// LoadConfig загружает конфигурацию из всех источников
+// Приоритет: флаги > переменные окружения (и .env) > файл --config > значения
+// по умолчанию. Ошибка перечисляет все неверные параметры сразу
+func LoadConfig(src Sources) (*Config, error) {
+	l, err := newLoader(src)
+	if err != nil {
+		return nil, err
+	}
 
 	// Создаем конфигурацию со значениями по умолчанию
 	config := &Config{
 		App: AppConfig{
-			Name: getEnv("APP_NAME", "fiber-backend"),
-			Port: getEnv("APP_PORT", "3000"),
-			Env:  getEnv("APP_ENV", "development"),
+			Name: l.getEnv("APP_NAME", "fiber-backend"),
+			Port: l.getEnv("APP_PORT", "3000"),
+			Env:  l.getEnv("APP_ENV", "development"),
+			// Регион экземпляра при развертывании в нескольких регионах
+			Region: l.getEnv("REGION", ""),
+			// gRPC API выключен, пока не задан порт
+			GRPCPort: l.getEnv("APP_GRPC_PORT", ""),
+			// Уровень логирования
+			LogLevel: l.getEnv("LOG_LEVEL", "info"),
+			// Таймаут запроса задается в секундах
+			RequestTimeout: time.Duration(l.getEnvAsInt("APP_REQUEST_TIMEOUT", 30)) * time.Second,
+			LatencyBudget:  time.Duration(l.getEnvAsInt("APP_LATENCY_BUDGET_MS", 1000)) * time.Millisecond,
+			ReadTimeout:    time.Duration(l.getEnvAsInt("APP_READ_TIMEOUT", 60)) * time.Second,
+			// Прогрев после старта, в секундах
+			WarmUpTimeout: time.Duration(l.getEnvAsInt("APP_WARMUP_TIMEOUT", 30)) * time.Second,
+			// Кеш health-check для балансировщиков, в секундах
+			HealthCacheTTL: time.Duration(l.getEnvAsInt("APP_HEALTH_CACHE_TTL", 2)) * time.Second,
+			// Таймаут проверки одной зависимости, в миллисекундах
+			HealthProbeTimeout: time.Duration(l.getEnvAsInt("APP_HEALTH_PROBE_TIMEOUT_MS", 1000)) * time.Millisecond,
+			// Обновление переопределений настроек, в секундах
+			SettingsRefreshInterval: time.Duration(l.getEnvAsInt("SETTINGS_REFRESH_INTERVAL", 30)) * time.Second,
+			CORSAllowOrigins:        l.getEnv("CORS_ALLOW_ORIGINS", "*"),
+			ReusePort:               l.getEnvAsBool("APP_REUSE_PORT", false),
+			FakeServices:            l.getEnvAsBool("DEV_FAKE_SERVICES", false),
+			AdminUI:                 l.getEnvAsBool("APP_ADMIN_UI", true),
+			// Размер тела задается в килобайтах
+			BodyLimit:         l.getEnvAsInt("APP_BODY_LIMIT_KB", 1024) * 1024,
+			JSONMaxDepth:      l.getEnvAsInt("APP_JSON_MAX_DEPTH", 32),
+			JSONMaxArrayLen:   l.getEnvAsInt("APP_JSON_MAX_ARRAY_LEN", 1000),
+			JSONCodec:         l.getEnv("APP_JSON_CODEC", "std"),
+			IDEncoding:        l.getEnv("APP_ID_ENCODING", publicid.EncodingPlain),
+			IDSecret:          l.getEnv("APP_ID_SECRET", ""),
+			IDMinLength:       l.getEnvAsInt("APP_ID_MIN_LENGTH", 8),
+			SanitizePolicy:    l.getEnv("SANITIZE_POLICY", "strip"),
+			ResponseRedaction: l.getEnv("RESPONSE_REDACTION", ""),
+			SecretKey:         l.getEnv("APP_SECRET_KEY", ""),
+			// Лимиты одновременных операций, ожидание задается в секундах
+			ImportConcurrency:       l.getEnvAsInt("APP_IMPORT_CONCURRENCY", 2),
+			ExportConcurrency:       l.getEnvAsInt("APP_EXPORT_CONCURRENCY", 4),
+			AvatarConcurrency:       l.getEnvAsInt("APP_AVATAR_CONCURRENCY", 4),
+			ConcurrencyQueueTimeout: time.Duration(l.getEnvAsInt("APP_CONCURRENCY_QUEUE_TIMEOUT", 5)) * time.Second,
+			// Лимит запросов, окно задается в секундах
+			RateLimit:           l.getEnvAsInt("APP_RATE_LIMIT", 300),
+			RateLimitSoft:       l.getEnvAsInt("APP_RATE_LIMIT_SOFT", 240),
+			RateLimitBurst:      l.getEnvAsInt("APP_RATE_LIMIT_BURST", 20),
+			RateLimitWindow:     time.Duration(l.getEnvAsInt("APP_RATE_LIMIT_WINDOW", 60)) * time.Second,
+			UserRateLimit:       l.getEnvAsInt("APP_USER_RATE_LIMIT", 600),
+			UserRateLimitSoft:   l.getEnvAsInt("APP_USER_RATE_LIMIT_SOFT", 480),
+			UserRateLimitBurst:  l.getEnvAsInt("APP_USER_RATE_LIMIT_BURST", 30),
+			AuthRateLimit:       l.getEnvAsInt("APP_AUTH_RATE_LIMIT", 10),
+			CreateUserRateLimit: l.getEnvAsInt("APP_CREATE_USER_RATE_LIMIT", 20),
+
+			// Уровни pro и internal; какие роли их получают - APP_LIMIT_TIER_ROLES
+			ProRateLimit:           l.getEnvAsInt("APP_PRO_RATE_LIMIT", 1200),
+			ProRateLimitSoft:       l.getEnvAsInt("APP_PRO_RATE_LIMIT_SOFT", 960),
+			ProRateLimitBurst:      l.getEnvAsInt("APP_PRO_RATE_LIMIT_BURST", 60),
+			InternalRateLimit:      l.getEnvAsInt("APP_INTERNAL_RATE_LIMIT", 1200),
+			InternalRateLimitSoft:  l.getEnvAsInt("APP_INTERNAL_RATE_LIMIT_SOFT", 960),
+			InternalRateLimitBurst: l.getEnvAsInt("APP_INTERNAL_RATE_LIMIT_BURST", 60),
+			LimitTierRoles:         l.getEnv("APP_LIMIT_TIER_ROLES", "admin=internal"),
+
+			// Окна экспортов и оповещений в секундах
+			ExportRateLimit:        l.getEnvAsInt("APP_EXPORT_RATE_LIMIT", 10),
+			ExportIPRateLimit:      l.getEnvAsInt("APP_EXPORT_IP_RATE_LIMIT", 30),
+			ExportRateLimitWindow:  time.Duration(l.getEnvAsInt("APP_EXPORT_RATE_LIMIT_WINDOW", 3600)) * time.Second,
+			BulkReadAlertThreshold: l.getEnvAsInt("APP_BULK_READ_ALERT_THRESHOLD", 300),
+			BulkReadAlertWindow:    time.Duration(l.getEnvAsInt("APP_BULK_READ_ALERT_WINDOW", 3600)) * time.Second,
+			// Ограничения списков по уровню доступа
+			AnonymousMaxPageSize: l.getEnvAsInt("APP_ANONYMOUS_MAX_PAGE_SIZE", 20),
+			AnonymousListFilters: l.getEnvAsBool("APP_ANONYMOUS_LIST_FILTERS", false),
+			UserMaxPageSize:      l.getEnvAsInt("APP_USER_MAX_PAGE_SIZE", 100),
+			ProMaxPageSize:       l.getEnvAsInt("APP_PRO_MAX_PAGE_SIZE", 200),
+			InternalMaxPageSize:  l.getEnvAsInt("APP_INTERNAL_MAX_PAGE_SIZE", 0),
+			// Прежнее имя пользователя резервируется на дни
+			UsernameHold:  time.Duration(l.getEnvAsInt("APP_USERNAME_HOLD_DAYS", 30)) * 24 * time.Hour,
+			SyncRetention: time.Duration(l.getEnvAsInt("APP_SYNC_RETENTION_DAYS", 30)) * 24 * time.Hour,
+			// Подпись межсервисных запросов, окно задается в секундах
+			ServiceSigningKey:      l.getEnv("SERVICE_SIGNING_KEY", ""),
+			ServiceSignatureMaxAge: time.Duration(l.getEnvAsInt("SERVICE_SIGNATURE_MAX_AGE", 300)) * time.Second,
+			InternalServiceKeys:    l.getEnv("INTERNAL_SERVICE_KEYS", ""),
+			InternalCertHeader:     l.getEnv("INTERNAL_CLIENT_CERT_HEADER", ""),
+			InternalCertServices:   l.getEnv("INTERNAL_CERT_SERVICES", ""),
+			LegacyErrorsSunset:     l.getEnv("APP_LEGACY_ERRORS_SUNSET", ""),
 		},
 		Database: DatabaseConfig{
-			Driver:   getEnv("DB_DRIVER", "postgres"),
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			Name:     getEnv("DB_NAME", "fiber_db"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Driver:   l.getEnv("DB_DRIVER", "postgres"),
+			Host:     l.getEnv("DB_HOST", "localhost"),
+			Port:     l.getEnv("DB_PORT", "5432"),
+			User:     l.getEnv("DB_USER", "postgres"),
+			Password: l.getEnv("DB_PASSWORD", "postgres"),
+			Name:     l.getEnv("DB_NAME", "fiber_db"),
+			SSLMode:  l.getEnv("DB_SSLMODE", "disable"),
 			// Парсим числовые значения с дефолтными значениями
-			MaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
-			MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 5),
-			ConnMaxLifetime: time.Duration(getEnvAsInt("DB_CONN_MAX_LIFETIME", 5)) * time.Minute,
+			MaxOpenConns:    l.getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:    l.getEnvAsInt("DB_MAX_IDLE_CONNS", 5),
+			ConnMaxLifetime: time.Duration(l.getEnvAsInt("DB_CONN_MAX_LIFETIME", 5)) * time.Minute,
+			// Таймаут SQL запроса задается в секундах
+			StatementTimeout: time.Duration(l.getEnvAsInt("DB_STATEMENT_TIMEOUT", 30)) * time.Second,
+			AutoMigrate:      l.getEnvAsBool("DB_AUTO_MIGRATE", false),
+			Replicas:         l.getEnv("DB_REPLICAS", ""),
+		},
+		Storage: StorageConfig{
+			Driver:      l.getEnv("STORAGE_DRIVER", "local"),
+			LocalDir:    l.getEnv("STORAGE_LOCAL_DIR", "./data/storage"),
+			S3Endpoint:  l.getEnv("STORAGE_S3_ENDPOINT", ""),
+			S3Region:    l.getEnv("STORAGE_S3_REGION", "us-east-1"),
+			S3Bucket:    l.getEnv("STORAGE_S3_BUCKET", ""),
+			S3AccessKey: l.getEnv("STORAGE_S3_ACCESS_KEY", ""),
+			S3SecretKey: l.getEnv("STORAGE_S3_SECRET_KEY", ""),
+			S3UseSSL:    l.getEnvAsBool("STORAGE_S3_USE_SSL", true),
+		},
+		Avatar: AvatarConfig{
+			MaxSize: l.getEnvAsInt("AVATAR_MAX_SIZE_KB", 512) * 1024,
+			Size:    l.getEnvAsInt("AVATAR_SIZE", 256),
+		},
+		Audit: AuditConfig{
+			BufferSize:   l.getEnvAsInt("AUDIT_BUFFER_SIZE", 10000),
+			SpoolDir:     l.getEnv("AUDIT_SPOOL_DIR", "./data/audit-spool"),
+			SpoolMaxSize: int64(l.getEnvAsInt("AUDIT_SPOOL_MAX_SIZE_MB", 256)) * 1024 * 1024,
+
+			ArchiveAfter:     time.Duration(l.getEnvAsInt("AUDIT_ARCHIVE_AFTER_DAYS", 0)) * 24 * time.Hour,
+			ArchiveRetention: time.Duration(l.getEnvAsInt("AUDIT_ARCHIVE_RETENTION_DAYS", 0)) * 24 * time.Hour,
+			ArchiveBatchSize: l.getEnvAsInt("AUDIT_ARCHIVE_BATCH_SIZE", 10000),
+
+			LoginHistoryArchiveAfter:     time.Duration(l.getEnvAsInt("LOGIN_HISTORY_ARCHIVE_AFTER_DAYS", 0)) * 24 * time.Hour,
+			LoginHistoryArchiveRetention: time.Duration(l.getEnvAsInt("LOGIN_HISTORY_ARCHIVE_RETENTION_DAYS", 0)) * 24 * time.Hour,
+		},
+		Export: ExportConfig{
+			// Время жизни ссылки в минутах
+			URLTTL: time.Duration(l.getEnvAsInt("EXPORT_URL_TTL", 15)) * time.Minute,
+		},
+		Digest: DigestConfig{
+			// Период в днях, интервал опроса в секундах
+			Period:        time.Duration(l.getEnvAsInt("DIGEST_PERIOD_DAYS", 7)) * 24 * time.Hour,
+			PollInterval:  time.Duration(l.getEnvAsInt("DIGEST_POLL_INTERVAL", 60)) * time.Second,
+			AutoSubscribe: l.getEnvAsBool("DIGEST_AUTO_SUBSCRIBE", false),
+		},
+		Notify: NotifyConfig{
+			BatchWindows: l.getEnv("NOTIFY_BATCH_WINDOWS", ""),
+		},
+		Broadcast: BroadcastConfig{
+			// Интервал опроса в секундах
+			PollInterval: time.Duration(l.getEnvAsInt("BROADCAST_POLL_INTERVAL", 5)) * time.Second,
+			BatchSize:    l.getEnvAsInt("BROADCAST_BATCH_SIZE", 50),
+		},
+		Auth: AuthConfig{
+			JWTSecret: l.getEnv("JWT_SECRET", ""),
+			Issuer:    l.getEnv("JWT_ISSUER", "fiber-backend"),
+			// Access токен живет минуты, refresh - часы
+			AccessTTL:  time.Duration(l.getEnvAsInt("JWT_ACCESS_TTL", 15)) * time.Minute,
+			RefreshTTL: time.Duration(l.getEnvAsInt("JWT_REFRESH_TTL", 720)) * time.Hour,
+			SudoTTL:    time.Duration(l.getEnvAsInt("JWT_SUDO_TTL", 5)) * time.Minute,
+			// Ссылка подтверждения email живет часы, ссылка сброса пароля - минуты
+			EmailVerificationTTL: time.Duration(l.getEnvAsInt("JWT_EMAIL_VERIFICATION_TTL", 48)) * time.Hour,
+			PasswordResetTTL:     time.Duration(l.getEnvAsInt("JWT_PASSWORD_RESET_TTL", 60)) * time.Minute,
+			// На ввод кода из приложения-аутентификатора дается несколько минут
+			TwoFactorTTL:           time.Duration(l.getEnvAsInt("TWO_FACTOR_TTL", 5)) * time.Minute,
+			TwoFactorIssuer:        l.getEnv("TWO_FACTOR_ISSUER", "fiber-backend"),
+			BcryptCost:             l.getEnvAsInt("BCRYPT_COST", 10),
+			BcryptMaxCost:          l.getEnvAsInt("BCRYPT_MAX_COST", 14),
+			PasswordHashTarget:     time.Duration(l.getEnvAsInt("PASSWORD_HASH_TARGET_MS", 0)) * time.Millisecond,
+			TwoFactorEncryptionKey: l.getEnv("TWO_FACTOR_ENCRYPTION_KEY", ""),
+			// Ссылка из письма живет минуты, период ожидания - часы
+			TwoFactorRecoveryLinkTTL:  time.Duration(l.getEnvAsInt("TWO_FACTOR_RECOVERY_LINK_TTL", 60)) * time.Minute,
+			TwoFactorRecoveryDelay:    time.Duration(l.getEnvAsInt("TWO_FACTOR_RECOVERY_DELAY", 72)) * time.Hour,
+			TwoFactorRecoveryApproval: l.getEnvAsBool("TWO_FACTOR_RECOVERY_APPROVAL", false),
+			EmailStripGmailDots:       l.getEnvAsBool("EMAIL_STRIP_GMAIL_DOTS", false),
+			EmailStripPlusAliases:     l.getEnvAsBool("EMAIL_STRIP_PLUS_ALIASES", false),
+		},
+		Mail: MailConfig{
+			Driver:       l.getEnv("MAIL_DRIVER", "noop"),
+			From:         l.getEnv("MAIL_FROM", ""),
+			SMTPHost:     l.getEnv("MAIL_SMTP_HOST", ""),
+			SMTPPort:     l.getEnvAsInt("MAIL_SMTP_PORT", 587),
+			SMTPUsername: l.getEnv("MAIL_SMTP_USERNAME", ""),
+			SMTPPassword: l.getEnv("MAIL_SMTP_PASSWORD", ""),
+			LinkBaseURL:  l.getEnv("MAIL_LINK_BASE_URL", "http://localhost:3000"),
+		},
+		Redis: RedisConfig{
+			Addr:     l.getEnv("REDIS_ADDR", ""),
+			Password: l.getEnv("REDIS_PASSWORD", ""),
+			DB:       l.getEnvAsInt("REDIS_DB", 0),
+			// Таймаут в миллисекундах, интервал повторного подключения в секундах
+			Timeout:           time.Duration(l.getEnvAsInt("REDIS_TIMEOUT_MS", 250)) * time.Millisecond,
+			RetryInterval:     time.Duration(l.getEnvAsInt("REDIS_RETRY_INTERVAL", 5)) * time.Second,
+			RateLimitFallback: l.getEnv("REDIS_RATE_LIMIT_FALLBACK", "memory"),
+		},
+		Jobs: JobsConfig{
+			// Интервал опроса и таймаут в секундах, хранение в днях
+			Workers:          l.getEnvAsInt("JOBS_WORKERS", 4),
+			PollInterval:     time.Duration(l.getEnvAsInt("JOBS_POLL_INTERVAL", 2)) * time.Second,
+			MaxAttempts:      l.getEnvAsInt("JOBS_MAX_ATTEMPTS", 5),
+			Timeout:          time.Duration(l.getEnvAsInt("JOBS_TIMEOUT", 300)) * time.Second,
+			Retention:        time.Duration(l.getEnvAsInt("JOBS_RETENTION_DAYS", 7)) * 24 * time.Hour,
+			InactiveUserDays: l.getEnvAsInt("JOBS_INACTIVE_USER_DAYS", 0),
+		},
+		Backfill: BackfillConfig{
+			// Пауза в миллисекундах, длительность запуска в минутах
+			BatchSize:   l.getEnvAsInt("BACKFILL_BATCH_SIZE", 1000),
+			Pause:       time.Duration(l.getEnvAsInt("BACKFILL_PAUSE_MS", 100)) * time.Millisecond,
+			MaxDuration: time.Duration(l.getEnvAsInt("BACKFILL_MAX_DURATION", 0)) * time.Minute,
+		},
+		Shutdown: ShutdownConfig{
+			// Сроки в секундах
+			HTTP:    time.Duration(l.getEnvAsInt("SHUTDOWN_HTTP_TIMEOUT", 10)) * time.Second,
+			GRPC:    time.Duration(l.getEnvAsInt("SHUTDOWN_GRPC_TIMEOUT", 10)) * time.Second,
+			Jobs:    time.Duration(l.getEnvAsInt("SHUTDOWN_JOBS_TIMEOUT", 10)) * time.Second,
+			Workers: time.Duration(l.getEnvAsInt("SHUTDOWN_WORKERS_TIMEOUT", 5)) * time.Second,
+			Audit:   time.Duration(l.getEnvAsInt("SHUTDOWN_AUDIT_TIMEOUT", 5)) * time.Second,
+			Tracing: time.Duration(l.getEnvAsInt("SHUTDOWN_TRACING_TIMEOUT", 5)) * time.Second,
+		},
+		WellKnown: WellKnownConfig{
+			BaseURL:          l.getEnv("WELLKNOWN_BASE_URL", "http://localhost:3000"),
+			SecurityContacts: l.getEnv("SECURITY_CONTACTS", ""),
+			SecurityPolicy:   l.getEnv("SECURITY_POLICY_URL", ""),
+			SecurityLanguage: l.getEnv("SECURITY_LANGUAGES", "ru, en"),
+			// Срок в днях: RFC 9116 советует не больше года
+			SecurityExpires: time.Duration(l.getEnvAsInt("SECURITY_EXPIRES_DAYS", 180)) * 24 * time.Hour,
+		},
+		Tracing: TracingConfig{
+			OTLPEndpoint: l.getEnv("TRACING_OTLP_ENDPOINT", ""),
+			Insecure:     l.getEnvAsBool("TRACING_OTLP_INSECURE", false),
+			SampleRatio:  l.getEnvAsFloat("TRACING_SAMPLE_RATIO", 1),
 		},
 	}
 
-	// Валидируем обязательные параметры
-	if err := config.Validate(); err != nil {
-		return nil, err
+	// Страница смены пароля по умолчанию - во фронтенде, как ссылки из писем
+	config.WellKnown.ChangePasswordURL = l.getEnv("WELLKNOWN_CHANGE_PASSWORD_URL",
+		strings.TrimSuffix(config.Mail.LinkBaseURL, "/")+"/settings/password")
+
+	config.file = src.File
+	config.origins = l.origins
+	config.loadedAt = time.Now()
+
+	// Собираем ошибки разбора, неизвестные параметры и ошибки валидации,
+	// чтобы исправить конфигурацию за один запуск
+	problems := l.problems
+	for _, key := range l.unknown() {
+		problems = append(problems, fmt.Sprintf("%s: неизвестный параметр", key))
+	}
+	problems = append(problems, config.problems()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
 	}
 
 	return config, nil
 }
 
 // Validate проверяет что все критичные параметры заданы
+// Возвращает *ValidationError со всеми найденными проблемами сразу
 func (c *Config) Validate() error {
+	if problems := c.problems(); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// problems возвращает описания всех неверных параметров
+func (c *Config) problems() []string {
+	var problems []string
+	if c.App.SecretKey == "" {
+		problems = append(problems, "APP_SECRET_KEY не может быть пустым")
+	}
+	if c.Auth.JWTSecret == "" {
+		problems = append(problems, "JWT_SECRET не может быть пустым")
+	}
+	if c.Auth.TwoFactorEncryptionKey != "" {
+		if key, err := hex.DecodeString(c.Auth.TwoFactorEncryptionKey); err != nil || len(key) != 32 {
+			problems = append(problems, "TWO_FACTOR_ENCRYPTION_KEY должен быть 32 байтами в hex (64 символа)")
+		}
+	}
+	if c.Auth.TwoFactorTTL <= 0 {
+		problems = append(problems, "TWO_FACTOR_TTL должен быть положительным")
+	}
+	if c.Auth.TwoFactorRecoveryLinkTTL <= 0 || c.Auth.TwoFactorRecoveryDelay < 0 {
+		problems = append(problems, "TWO_FACTOR_RECOVERY_LINK_TTL должен быть положительным, TWO_FACTOR_RECOVERY_DELAY - не отрицательным")
+	}
+	// Границы bcrypt.MinCost и bcrypt.MaxCost
+	if c.Auth.BcryptCost < 4 || c.Auth.BcryptMaxCost > 31 || c.Auth.BcryptCost > c.Auth.BcryptMaxCost {
+		problems = append(problems, "BCRYPT_COST и BCRYPT_MAX_COST должны быть от 4 до 31, BCRYPT_COST не больше BCRYPT_MAX_COST")
+	}
+	if c.Auth.PasswordHashTarget < 0 {
+		problems = append(problems, "PASSWORD_HASH_TARGET_MS не может быть отрицательным")
+	}
+	if c.App.FakeServices && c.App.Env == "production" {
+		problems = append(problems, "DEV_FAKE_SERVICES нельзя включать в production")
+	}
+	switch c.App.SanitizePolicy {
+	case "strip", "escape", "reject":
+	default:
+		problems = append(problems, "SANITIZE_POLICY должен быть strip, escape или reject")
+	}
+	if _, err := jsoncodec.Get(c.App.JSONCodec); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := publicid.New(c.App.IDEncoding, c.App.IDSecret, c.App.IDMinLength); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := c.Database.ReplicaList(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := c.Notify.Windows(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := c.App.ServiceKeys(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if c.App.InternalCertHeader != "" && len(c.App.CertServices()) == 0 {
+		problems = append(problems, "INTERNAL_CERT_SERVICES не может быть пустым, если задан INTERNAL_CLIENT_CERT_HEADER")
+	}
+	if _, err := redact.Parse(c.App.ResponseRedaction); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if c.App.GRPCPort != "" && c.App.GRPCPort == c.App.Port {
+		problems = append(problems, "APP_GRPC_PORT должен отличаться от APP_PORT")
+	}
+	if c.App.LatencyBudget < 0 {
+		problems = append(problems, "APP_LATENCY_BUDGET_MS не может быть отрицательным")
+	}
+	if c.App.HealthProbeTimeout <= 0 {
+		problems = append(problems, "APP_HEALTH_PROBE_TIMEOUT_MS должен быть положительным")
+	}
+	if c.App.LegacyErrorsSunset != "" {
+		if _, err := time.Parse(time.DateOnly, c.App.LegacyErrorsSunset); err != nil {
+			problems = append(problems, "APP_LEGACY_ERRORS_SUNSET должен быть датой в формате YYYY-MM-DD")
+		}
+	}
 	if c.Database.Host == "" {
-		return fmt.Errorf("DB_HOST не может быть пустым")
+		problems = append(problems, "DB_HOST не может быть пустым")
 	}
 	if c.Database.User == "" {
-		return fmt.Errorf("DB_USER не может быть пустым")
+		problems = append(problems, "DB_USER не может быть пустым")
 	}
 	if c.Database.Name == "" {
-		return fmt.Errorf("DB_NAME не может быть пустым")
+		problems = append(problems, "DB_NAME не может быть пустым")
 	}
-	return nil
+	// Ветка MySQL удалена вместе с database/sql: слой БД построен на pgx
+	if c.Database.Driver != "postgres" {
+		problems = append(problems, fmt.Sprintf("DB_DRIVER=%s не поддерживается, доступен только postgres", c.Database.Driver))
+	}
+	if c.Database.MaxOpenConns <= 0 || c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		problems = append(problems, "DB_MAX_OPEN_CONNS должен быть положительным, DB_MAX_IDLE_CONNS - от 0 до DB_MAX_OPEN_CONNS")
+	}
+	if c.Storage.Driver == "s3" && (c.Storage.S3Endpoint == "" || c.Storage.S3Bucket == "") {
+		problems = append(problems, "STORAGE_S3_ENDPOINT и STORAGE_S3_BUCKET обязательны для STORAGE_DRIVER=s3")
+	}
+	// Файл приходит в multipart теле, которое ограничено APP_BODY_LIMIT_KB
+	if c.Avatar.MaxSize <= 0 || c.Avatar.MaxSize >= c.App.BodyLimit {
+		problems = append(problems, "AVATAR_MAX_SIZE_KB должен быть положительным и меньше APP_BODY_LIMIT_KB")
+	}
+	if c.Avatar.Size < 32 || c.Avatar.Size > 1024 {
+		problems = append(problems, "AVATAR_SIZE должен быть от 32 до 1024")
+	}
+	if c.App.UsernameHold < 0 || c.App.UsernameHold > 365*24*time.Hour {
+		problems = append(problems, "APP_USERNAME_HOLD_DAYS должен быть от 0 до 365")
+	}
+	if c.App.SyncRetention < 24*time.Hour || c.App.SyncRetention > 365*24*time.Hour {
+		problems = append(problems, "APP_SYNC_RETENTION_DAYS должен быть от 1 до 365")
+	}
+	if c.App.AnonymousMaxPageSize < 0 || c.App.UserMaxPageSize < 0 || c.App.ProMaxPageSize < 0 || c.App.InternalMaxPageSize < 0 {
+		problems = append(problems, "APP_*_MAX_PAGE_SIZE не могут быть отрицательными")
+	}
+	if _, err := tiers.ParseRoles(c.App.LimitTierRoles); err != nil {
+		problems = append(problems, fmt.Sprintf("APP_LIMIT_TIER_ROLES: %v", err))
+	}
+	if c.Audit.BufferSize <= 0 {
+		problems = append(problems, "AUDIT_BUFFER_SIZE должен быть положительным")
+	}
+	if c.Audit.SpoolDir != "" && c.Audit.SpoolMaxSize <= 0 {
+		problems = append(problems, "AUDIT_SPOOL_MAX_SIZE_MB должен быть положительным")
+	}
+	if c.Audit.ArchiveAfter < 0 || c.Audit.ArchiveRetention < 0 {
+		problems = append(problems, "AUDIT_ARCHIVE_AFTER_DAYS и AUDIT_ARCHIVE_RETENTION_DAYS не могут быть отрицательными")
+	}
+	if c.Audit.LoginHistoryArchiveAfter < 0 || c.Audit.LoginHistoryArchiveRetention < 0 {
+		problems = append(problems, "LOGIN_HISTORY_ARCHIVE_AFTER_DAYS и LOGIN_HISTORY_ARCHIVE_RETENTION_DAYS не могут быть отрицательными")
+	}
+	if (c.Audit.ArchiveAfter > 0 || c.Audit.LoginHistoryArchiveAfter > 0) && c.Audit.ArchiveBatchSize <= 0 {
+		problems = append(problems, "AUDIT_ARCHIVE_BATCH_SIZE должен быть положительным")
+	}
+	if c.Mail.Driver == "smtp" && (c.Mail.SMTPHost == "" || c.Mail.From == "") {
+		problems = append(problems, "MAIL_SMTP_HOST и MAIL_FROM обязательны для MAIL_DRIVER=smtp")
+	}
+	if c.Redis.Timeout <= 0 || c.Redis.RetryInterval <= 0 {
+		problems = append(problems, "REDIS_TIMEOUT_MS и REDIS_RETRY_INTERVAL должны быть положительными")
+	}
+	switch c.Redis.RateLimitFallback {
+	case "memory", "open", "closed":
+	default:
+		problems = append(problems, "REDIS_RATE_LIMIT_FALLBACK должен быть memory, open или closed")
+	}
+	if c.Jobs.Workers <= 0 || c.Jobs.MaxAttempts <= 0 {
+		problems = append(problems, "JOBS_WORKERS и JOBS_MAX_ATTEMPTS должны быть положительными")
+	}
+	if c.Jobs.InactiveUserDays < 0 {
+		problems = append(problems, "JOBS_INACTIVE_USER_DAYS не может быть отрицательным")
+	}
+	if c.App.ExportRateLimitWindow <= 0 || c.App.BulkReadAlertWindow <= 0 {
+		problems = append(problems, "APP_EXPORT_RATE_LIMIT_WINDOW и APP_BULK_READ_ALERT_WINDOW должны быть положительными")
+	}
+	if c.Backfill.BatchSize <= 0 || c.Backfill.Pause < 0 || c.Backfill.MaxDuration < 0 {
+		problems = append(problems, "BACKFILL_BATCH_SIZE должен быть положительным, BACKFILL_PAUSE_MS и BACKFILL_MAX_DURATION - неотрицательными")
+	}
+	if c.Shutdown.HTTP <= 0 || c.Shutdown.GRPC <= 0 || c.Shutdown.Jobs <= 0 ||
+		c.Shutdown.Workers <= 0 || c.Shutdown.Audit <= 0 || c.Shutdown.Tracing <= 0 {
+		problems = append(problems, "SHUTDOWN_*_TIMEOUT должны быть положительными")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, "TRACING_SAMPLE_RATIO должен быть от 0 до 1")
+	}
+	if !isAbsoluteURL(c.WellKnown.BaseURL) {
+		problems = append(problems, "WELLKNOWN_BASE_URL должен быть абсолютным http(s) адресом")
+	}
+	if !isAbsoluteURL(c.WellKnown.ChangePasswordURL) {
+		problems = append(problems, "WELLKNOWN_CHANGE_PASSWORD_URL должен быть абсолютным http(s) адресом")
+	}
+	if c.WellKnown.SecurityExpires <= 0 || c.WellKnown.SecurityExpires > 365*24*time.Hour {
+		problems = append(problems, "SECURITY_EXPIRES_DAYS должен быть от 1 до 365")
+	}
+	return problems
 }
 
-// GetDSN возвращает строку подключения к БД в зависимости от драйвера
+// isAbsoluteURL проверяет, что value - адрес вида https://host[/путь]
+func isAbsoluteURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// GetDSN возвращает строку подключения к PostgreSQL для pgxpool.ParseConfig
 // DSN (Data Source Name) - это строка с параметрами подключения
 func (c *DatabaseConfig) GetDSN() string {
-	switch c.Driver {
-	case "postgres":
-		// Формат для PostgreSQL
-		return fmt.Sprintf(
-			"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-			c.Host, c.Port, c.User, c.Password, c.Name, c.SSLMode,
-		)
-	case "mysql":
-		// Формат для MySQL
-		// parseTime=true позволяет автоматически парсить DATE/DATETIME в time.Time
-		return fmt.Sprintf(
-			"%s:%s@tcp(%s:%s)/%s?parseTime=true",
-			c.User, c.Password, c.Host, c.Port, c.Name,
-		)
-	default:
-		return ""
+	// Неизвестные pgx параметры (statement_timeout, timezone) передаются серверу
+	// как параметры сессии, значение statement_timeout в миллисекундах.
+	// timezone=UTC: колонки TIMESTAMP хранят время без зоны, и CURRENT_TIMESTAMP
+	// должен давать UTC независимо от настроек сервера БД
+	return c.dsn(c.Host, c.Port)
+}
+
+// dsn возвращает DSN сервера host:port с параметрами основной БД
+func (c *DatabaseConfig) dsn(host, port string) string {
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s statement_timeout=%d timezone=UTC",
+		host, port, c.User, c.Password, c.Name, c.SSLMode, c.StatementTimeout.Milliseconds(),
+	)
+}
+
+// Replica - реплика БД для чтения
+type Replica struct {
+	Region string
+	Host   string
+	Port   string
+}
+
+// DSN возвращает DSN реплики: учетные данные и параметры берутся из cfg
+func (r Replica) DSN(cfg *DatabaseConfig) string {
+	return cfg.dsn(r.Host, r.Port)
+}
+
+// ReplicaList разбирает DB_REPLICAS
+// Порт по умолчанию - DB_PORT; у региона может быть только одна реплика
+func (c *DatabaseConfig) ReplicaList() ([]Replica, error) {
+	var replicas []Replica
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(c.Replicas, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		region, addr, ok := strings.Cut(item, "=")
+		region, addr = strings.TrimSpace(region), strings.TrimSpace(addr)
+		if !ok || region == "" || addr == "" {
+			return nil, fmt.Errorf("DB_REPLICAS: %q, ожидается <регион>=<хост>[:<порт>]", item)
+		}
+		if seen[region] {
+			return nil, fmt.Errorf("DB_REPLICAS: регион %q указан дважды", region)
+		}
+		seen[region] = true
+
+		host, port, hasPort := strings.Cut(addr, ":")
+		if !hasPort {
+			port = c.Port
+		}
+		replicas = append(replicas, Replica{Region: region, Host: host, Port: port})
 	}
+	return replicas, nil
 }
 
-// getEnv получает переменную окружения или возвращает дефолтное значение
-// Это удобная функция-хелпер для работы с переменными окружения
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// minServiceKeyLength - минимальная длина ключа внутреннего сервиса
+const minServiceKeyLength = 32
+
+// ServiceKeys разбирает INTERNAL_SERVICE_KEYS в ключи по имени сервиса
+// Ключ не короче minServiceKeyLength символов: подпись коротким ключом
+// подбирается перебором
+func (c *AppConfig) ServiceKeys() (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, item := range strings.Split(c.InternalServiceKeys, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		service, key, ok := strings.Cut(item, "=")
+		service, key = strings.TrimSpace(service), strings.TrimSpace(key)
+		if !ok || service == "" || key == "" {
+			// Сам элемент не выводится: в нем ключ
+			return nil, fmt.Errorf("INTERNAL_SERVICE_KEYS: ожидается <сервис>=<ключ>,...")
+		}
+		if _, seen := keys[service]; seen {
+			return nil, fmt.Errorf("INTERNAL_SERVICE_KEYS: сервис %q указан дважды", service)
+		}
+		if len(key) < minServiceKeyLength {
+			return nil, fmt.Errorf("INTERNAL_SERVICE_KEYS: ключ сервиса %q короче %d символов", service, minServiceKeyLength)
+		}
+		keys[service] = key
 	}
-	return value
+	return keys, nil
 }
 
-// getEnvAsInt получает переменную окружения как число
-// Если не удается распарсить или переменная не задана - возвращает дефолт
-func getEnvAsInt(key string, defaultValue int) int {
-	valueStr := os.Getenv(key)
-	if valueStr == "" {
-		return defaultValue
+// LimitPolicy собирает пороги уровней лимитов (см. пакет tiers)
+// Формат APP_LIMIT_TIER_ROLES проверен в Validate
+func (c *AppConfig) LimitPolicy() tiers.Policy {
+	roles, _ := tiers.ParseRoles(c.LimitTierRoles)
+	return tiers.Policy{
+		Roles: roles,
+		Limits: map[tiers.Tier]tiers.Limits{
+			tiers.Anonymous: {
+				RateLimit: c.RateLimit, RateLimitSoft: c.RateLimitSoft, RateLimitBurst: c.RateLimitBurst,
+				MaxPageSize: c.AnonymousMaxPageSize, ListFilters: c.AnonymousListFilters,
+			},
+			tiers.Free: {
+				RateLimit: c.UserRateLimit, RateLimitSoft: c.UserRateLimitSoft, RateLimitBurst: c.UserRateLimitBurst,
+				MaxPageSize: c.UserMaxPageSize, ListFilters: true,
+			},
+			tiers.Pro: {
+				RateLimit: c.ProRateLimit, RateLimitSoft: c.ProRateLimitSoft, RateLimitBurst: c.ProRateLimitBurst,
+				MaxPageSize: c.ProMaxPageSize, ListFilters: true,
+			},
+			tiers.Internal: {
+				RateLimit: c.InternalRateLimit, RateLimitSoft: c.InternalRateLimitSoft, RateLimitBurst: c.InternalRateLimitBurst,
+				MaxPageSize: c.InternalMaxPageSize, ListFilters: true,
+			},
+		},
 	}
-	value, err := strconv.Atoi(valueStr)
-	if err != nil {
-		return defaultValue
+}
+
+// CertServices разбирает INTERNAL_CERT_SERVICES
+func (c *AppConfig) CertServices() map[string]bool {
+	services := make(map[string]bool)
+	for _, name := range strings.Split(c.InternalCertServices, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			services[name] = true
+		}
+	}
+	return services
+}
+
+// Windows разбирает NOTIFY_BATCH_WINDOWS в окна по типу события
+// Окно - длительность time.ParseDuration (30s, 15m, 1h) не больше суток;
+// 0 - письмо при ближайшей отправке, без накопления
+func (c *NotifyConfig) Windows() (map[string]time.Duration, error) {
+	windows := make(map[string]time.Duration)
+	for _, item := range strings.Split(c.BatchWindows, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		event, value, ok := strings.Cut(item, "=")
+		event, value = strings.TrimSpace(event), strings.TrimSpace(value)
+		if !ok || event == "" || value == "" {
+			return nil, fmt.Errorf("NOTIFY_BATCH_WINDOWS: %q, ожидается <событие>=<окно>", item)
+		}
+		if _, seen := windows[event]; seen {
+			return nil, fmt.Errorf("NOTIFY_BATCH_WINDOWS: событие %q указано дважды", event)
+		}
+		window, err := time.ParseDuration(value)
+		if err != nil || window < 0 || window > 24*time.Hour {
+			return nil, fmt.Errorf("NOTIFY_BATCH_WINDOWS: окно %q для %s должно быть от 0 до 24h", value, event)
+		}
+		windows[event] = window
+	}
+	return windows, nil
+}
+
+// ReplicaFor возвращает реплику региона region
+// ok = false - в регионе нет реплики (или регион не задан): чтения идут
+// в основную БД. Реплика другого региона не выбирается: запрос через
+// регион медленнее, чем в основную БД, а отставание то же
+func (c *DatabaseConfig) ReplicaFor(region string) (Replica, bool) {
+	if region == "" {
+		return Replica{}, false
+	}
+	replicas, _ := c.ReplicaList()
+	for _, replica := range replicas {
+		if replica.Region == region {
+			return replica, true
+		}
 	}
-	return value
+	return Replica{}, false
 }
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/tiers"
+)
+
+// setRequired задает обязательные секреты через окружение
+func setRequired(t *testing.T) {
+	t.Helper()
+	t.Setenv("APP_SECRET_KEY", "secret")
+	t.Setenv("JWT_SECRET", "jwt")
+}
+
+// writeFile создает файл конфигурации во временной папке
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	setRequired(t)
+	file := writeFile(t, "config.yaml", `
+app:
+  port: "4000"
+  name: from-file
+db:
+  host: db.internal
+cors_allow_origins:
+  - https://a.example.com
+  - https://b.example.com
+`)
+	t.Setenv("APP_PORT", "5000")
+	t.Setenv("APP_NAME", "from-env")
+
+	cfg, err := LoadConfig(Sources{
+		File:  file,
+		Flags: map[string]string{"APP_PORT": "6000"},
+	})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	tests := []struct {
+		key    string
+		got    string
+		want   string
+		source string
+	}{
+		{"APP_PORT", cfg.App.Port, "6000", SourceFlag},
+		{"APP_NAME", cfg.App.Name, "from-env", SourceEnv},
+		{"DB_HOST", cfg.Database.Host, "db.internal", SourceFile},
+		{"CORS_ALLOW_ORIGINS", cfg.App.CORSAllowOrigins, "https://a.example.com,https://b.example.com", SourceFile},
+		{"DB_USER", cfg.Database.User, "postgres", SourceDefault},
+	}
+	for _, tc := range tests {
+		if tc.got != tc.want {
+			t.Errorf("%s = %q, ожидалось %q", tc.key, tc.got, tc.want)
+		}
+		if source := cfg.Origins()[tc.key]; source != tc.source {
+			t.Errorf("источник %s = %q, ожидался %q", tc.key, source, tc.source)
+		}
+	}
+	if cfg.File() != file {
+		t.Errorf("File() = %q, ожидался %q", cfg.File(), file)
+	}
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	setRequired(t)
+	file := writeFile(t, "config.toml", `
+[app]
+port = 4000
+rate_limit = 50
+`)
+
+	cfg, err := LoadConfig(Sources{File: file})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.App.Port != "4000" || cfg.App.RateLimit != 50 {
+		t.Errorf("порт %q и лимит %d, ожидались 4000 и 50", cfg.App.Port, cfg.App.RateLimit)
+	}
+}
+
+func TestLoadConfigReportsAllProblems(t *testing.T) {
+	// Секреты не заданы, число не разбирается, в флаге опечатка
+	t.Setenv("APP_SECRET_KEY", "")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("APP_RATE_LIMIT", "много")
+	t.Setenv("APP_ID_ENCODING", "hashids")
+
+	_, err := LoadConfig(Sources{Flags: map[string]string{"APP_POTR": "3000"}})
+
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("ошибка %v, ожидалась *ValidationError", err)
+	}
+	for _, want := range []string{"APP_RATE_LIMIT", "APP_POTR", "APP_SECRET_KEY", "JWT_SECRET", "APP_ID_SECRET"} {
+		found := false
+		for _, problem := range validation.Problems {
+			if strings.HasPrefix(problem, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("нет проблемы с %s в %q", want, validation.Problems)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	src, rest, err := ParseArgs([]string{"--config", "app.yaml", "--app-port=4000", "migrate", "up"})
+	if err != nil {
+		t.Fatalf("ParseArgs: %v", err)
+	}
+	if src.File != "app.yaml" {
+		t.Errorf("File = %q, ожидался app.yaml", src.File)
+	}
+	if src.Flags["APP_PORT"] != "4000" {
+		t.Errorf("APP_PORT = %q, ожидался 4000", src.Flags["APP_PORT"])
+	}
+	if strings.Join(rest, " ") != "migrate up" {
+		t.Errorf("остаток %q, ожидался migrate up", rest)
+	}
+
+	if _, _, err := ParseArgs([]string{"--app-port"}); err == nil {
+		t.Error("флаг без значения: ожидалась ошибка")
+	}
+}
+
+func TestChanged(t *testing.T) {
+	a := &Config{}
+	b := &Config{}
+	b.App.Port = "4000"
+	b.Auth.JWTSecret = "new"
+
+	got := strings.Join(a.Changed(b), ",")
+	if got != "App.Port,Auth.JWTSecret" {
+		t.Errorf("Changed = %q", got)
+	}
+}
+
+func TestReplicaFor(t *testing.T) {
+	cfg := DatabaseConfig{Port: "5432", Replicas: " eu-west-1=replica-eu:6432, us-east-1=replica-us "}
+
+	replica, ok := cfg.ReplicaFor("eu-west-1")
+	if !ok || replica != (Replica{Region: "eu-west-1", Host: "replica-eu", Port: "6432"}) {
+		t.Errorf("ReplicaFor(eu-west-1) = %+v, %v", replica, ok)
+	}
+	// Порт по умолчанию - порт основной БД
+	if replica, ok := cfg.ReplicaFor("us-east-1"); !ok || replica.Port != "5432" {
+		t.Errorf("ReplicaFor(us-east-1) = %+v, %v", replica, ok)
+	}
+	// Реплика другого региона не выбирается
+	for _, region := range []string{"", "ap-south-1"} {
+		if replica, ok := cfg.ReplicaFor(region); ok {
+			t.Errorf("ReplicaFor(%q) = %+v, ожидалось отсутствие реплики", region, replica)
+		}
+	}
+
+	for _, replicas := range []string{"replica-eu:5432", "eu-west-1=", "eu=a,eu=b"} {
+		cfg.Replicas = replicas
+		if _, err := cfg.ReplicaList(); err == nil {
+			t.Errorf("ReplicaList(%q): ожидалась ошибка", replicas)
+		}
+	}
+}
+
+func TestNotifyWindows(t *testing.T) {
+	cfg := NotifyConfig{BatchWindows: " user.update=15m, user.two_factor_enable=0 "}
+	windows, err := cfg.Windows()
+	if err != nil {
+		t.Fatalf("Windows: %v", err)
+	}
+	if len(windows) != 2 || windows["user.update"] != 15*time.Minute || windows["user.two_factor_enable"] != 0 {
+		t.Errorf("Windows() = %v", windows)
+	}
+
+	for _, value := range []string{"user.update", "user.update=", "user.update=15", "user.update=-1m", "user.update=48h", "user.update=1m,user.update=2m"} {
+		cfg.BatchWindows = value
+		if _, err := cfg.Windows(); err == nil {
+			t.Errorf("Windows(%q): ожидалась ошибка", value)
+		}
+	}
+}
+
+func TestServiceKeys(t *testing.T) {
+	key := strings.Repeat("k", minServiceKeyLength)
+	cfg := AppConfig{InternalServiceKeys: " billing=" + key + ", search = " + key + "x "}
+	keys, err := cfg.ServiceKeys()
+	if err != nil {
+		t.Fatalf("ServiceKeys: %v", err)
+	}
+	if len(keys) != 2 || keys["billing"] != key || keys["search"] != key+"x" {
+		t.Errorf("ServiceKeys() = %v", keys)
+	}
+
+	for _, value := range []string{"billing", "billing=", "=" + key, "billing=short", "billing=" + key + ",billing=" + key} {
+		cfg.InternalServiceKeys = value
+		_, err := cfg.ServiceKeys()
+		if err == nil {
+			t.Errorf("ServiceKeys(%q): ожидалась ошибка", value)
+			continue
+		}
+		if strings.Contains(err.Error(), key) {
+			t.Errorf("ServiceKeys(%q): ключ попал в текст ошибки: %v", value, err)
+		}
+	}
+}
+
+func TestLimitPolicy(t *testing.T) {
+	setRequired(t)
+	t.Setenv("APP_USER_RATE_LIMIT", "700")
+	t.Setenv("APP_LIMIT_TIER_ROLES", "admin=internal,partner=pro")
+
+	cfg, err := LoadConfig(Sources{})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	policy := cfg.App.LimitPolicy()
+
+	// Прежние переменные - пороги уровней anonymous и free
+	if got := policy.Limits[tiers.Free].RateLimit; got != 700 {
+		t.Errorf("free: RateLimit = %d, ожидалось 700", got)
+	}
+	if got := policy.Limits[tiers.Anonymous]; got.MaxPageSize != 20 || got.ListFilters {
+		t.Errorf("anonymous: %+v", got)
+	}
+	if policy.Resolve("partner", true) != tiers.Pro || policy.Resolve("admin", true) != tiers.Internal {
+		t.Errorf("Roles = %v", policy.Roles)
+	}
+
+	t.Setenv("APP_LIMIT_TIER_ROLES", "admin=gold")
+	if _, err := LoadConfig(Sources{}); err == nil || !strings.Contains(err.Error(), "APP_LIMIT_TIER_ROLES") {
+		t.Errorf("неизвестный уровень: ошибка %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// redactedValue заменяет значение секретного параметра
+const redactedValue = "***"
+
+// sensitiveFieldMarkers - части имен строковых полей с секретами (ключи, пароли)
+// Новое поле с секретом, названное по этому правилу, скрывается автоматически.
+// Нестроковые поля (PasswordResetTTL) не скрываются
+var sensitiveFieldMarkers = []string{"Secret", "Password", "Key"}
+
+// Redacted возвращает конфигурацию для вывода в лог или диагностику
+// Секреты заменяются на "***" (пустые остаются пустыми, чтобы было видно,
+// что параметр не задан), длительности выводятся строкой ("15m0s")
+func (c *Config) Redacted() map[string]interface{} {
+	return redactStruct(reflect.ValueOf(*c))
+}
+
+// redactStruct рекурсивно превращает структуру в map с именами полей
+func redactStruct(v reflect.Value) map[string]interface{} {
+	result := make(map[string]interface{}, v.NumField())
+	t := v.Type()
+
+	for i := 0; i < v.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+
+		switch {
+		case value.Kind() == reflect.Struct:
+			result[field.Name] = redactStruct(value)
+		case value.Kind() == reflect.String && isSensitiveField(field.Name):
+			if value.IsZero() {
+				result[field.Name] = ""
+			} else {
+				result[field.Name] = redactedValue
+			}
+		case value.Type() == reflect.TypeOf(time.Duration(0)):
+			result[field.Name] = time.Duration(value.Int()).String()
+		default:
+			result[field.Name] = value.Interface()
+		}
+	}
+
+	return result
+}
+
+// isSensitiveField проверяет, хранит ли поле секрет
+func isSensitiveField(name string) bool {
+	for _, marker := range sensitiveFieldMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// Current хранит действующую конфигурацию процесса
+// Перезагрузка (SIGHUP) заменяет ее целиком, читатели получают
+// согласованный снимок без блокировок
+type Current struct {
+	state atomic.Pointer[currentState]
+}
+
+// currentState - конфигурация и поля, которые вступят в силу после перезапуска
+type currentState struct {
+	cfg             *Config
+	restartRequired []string
+}
+
+// NewCurrent создает хранилище с конфигурацией, загруженной при запуске
+func NewCurrent(cfg *Config) *Current {
+	c := &Current{}
+	c.Set(cfg, nil)
+	return c
+}
+
+// Get возвращает последнюю загруженную конфигурацию
+// Возвращенную конфигурацию нельзя изменять
+func (c *Current) Get() *Config {
+	return c.state.Load().cfg
+}
+
+// RestartRequired возвращает поля последней загруженной конфигурации,
+// которые изменились, но применятся только после перезапуска
+func (c *Current) RestartRequired() []string {
+	return c.state.Load().restartRequired
+}
+
+// Set заменяет конфигурацию
+// restartRequired - измененные поля, которые нельзя применить на лету
+func (c *Current) Set(cfg *Config, restartRequired []string) {
+	c.state.Store(&currentState{cfg: cfg, restartRequired: restartRequired})
+}
+
+// LoadedAt возвращает время загрузки конфигурации
+func (c *Config) LoadedAt() time.Time {
+	return c.loadedAt
+}
+
+// Changed возвращает поля, значения которых в next отличаются,
+// в виде "App.Port". Секреты сравниваются, но выводятся только именами
+func (c *Config) Changed(next *Config) []string {
+	var fields []string
+	diffStruct("", reflect.ValueOf(*c), reflect.ValueOf(*next), &fields)
+	return fields
+}
+
+// diffStruct рекурсивно сравнивает экспортируемые поля двух структур
+func diffStruct(prefix string, a, b reflect.Value, fields *[]string) {
+	t := a.Type()
+	for i := 0; i < a.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		if a.Field(i).Kind() == reflect.Struct {
+			diffStruct(name, a.Field(i), b.Field(i), fields)
+			continue
+		}
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			*fields = append(*fields, name)
+		}
+	}
+}
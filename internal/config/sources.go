@@ -0,0 +1,288 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Источники значения параметра в порядке убывания приоритета
+const (
+	SourceFlag    = "flag"    // Аргумент командной строки --app-port=3000
+	SourceEnv     = "env"     // Переменная окружения или .env
+	SourceFile    = "file"    // Файл конфигурации (--config)
+	SourceDefault = "default" // Значение по умолчанию из кода
+)
+
+// Sources - источники конфигурации кроме переменных окружения
+//
+// Параметры во всех источниках называются так же, как переменные
+// окружения: флаг --app-port и ключ app_port (или app: {port: ...})
+// файла задают APP_PORT
+type Sources struct {
+	File  string            // Путь к YAML или TOML файлу, пустой - без файла
+	Flags map[string]string // Значения флагов по имени переменной окружения
+}
+
+// ParseArgs разбирает аргументы командной строки
+//
+// --config <path> (или --config=path) задает файл конфигурации,
+// остальные флаги передаются как --name=value и переопределяют
+// переменную NAME (дефисы заменяются подчеркиваниями). Аргументы
+// без "--" возвращаются как есть: это подкоманды (migrate up)
+func ParseArgs(args []string) (Sources, []string, error) {
+	src := Sources{Flags: make(map[string]string)}
+	var rest []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, ok := strings.CutPrefix(arg, "--")
+		if !ok || name == "" {
+			rest = append(rest, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(name, "=")
+		if name == "config" {
+			if !hasValue {
+				if i+1 >= len(args) {
+					return Sources{}, nil, fmt.Errorf("--config: не указан путь к файлу")
+				}
+				i++
+				value = args[i]
+			}
+			src.File = value
+			continue
+		}
+		if !hasValue {
+			return Sources{}, nil, fmt.Errorf("--%s: значение передается как --%s=<значение>", name, name)
+		}
+		src.Flags[flagKey(name)] = value
+	}
+
+	return src, rest, nil
+}
+
+// flagKey переводит имя флага в имя переменной окружения: app-port -> APP_PORT
+func flagKey(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// readFile читает файл конфигурации в плоский набор параметров
+// Формат определяется по расширению: .yaml, .yml или .toml.
+// Вложенные разделы склеиваются через "_": db: {host: x} - это DB_HOST,
+// списки - через запятую (CORS_ALLOW_ORIGINS)
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла конфигурации: %w", err)
+	}
+
+	raw := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("файл конфигурации %s: поддерживаются .yaml, .yml и .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла конфигурации %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten("", raw, values)
+	return values, nil
+}
+
+// flatten раскладывает вложенные разделы файла в параметры с именами
+// переменных окружения
+func flatten(prefix string, raw map[string]interface{}, out map[string]string) {
+	for name, value := range raw {
+		key := strings.ToUpper(name)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(key, v, out)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// loader читает параметры из источников по приоритету и копит ошибки
+// разбора, чтобы LoadConfig сообщил обо всех неверных параметрах сразу
+type loader struct {
+	flags  map[string]string
+	dotenv map[string]string // Переменные из .env, уступают переменным окружения
+	file   map[string]string
+
+	origins  map[string]string // Источник значения каждого прочитанного параметра
+	problems []string
+}
+
+// newLoader создает loader поверх флагов и файла
+func newLoader(src Sources) (*loader, error) {
+	l := &loader{
+		flags:   src.Flags,
+		file:    map[string]string{},
+		origins: make(map[string]string),
+	}
+
+	// .env читается при каждой загрузке, а не копируется в окружение
+	// процесса: иначе перезагрузка не увидела бы изменений в файле.
+	// В продакшене .env может не быть, и это нормально
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("ошибка чтения .env: %w", err)
+	}
+	l.dotenv = dotenv
+
+	if src.File != "" {
+		values, err := readFile(src.File)
+		if err != nil {
+			return nil, err
+		}
+		l.file = values
+	}
+	return l, nil
+}
+
+// lookup возвращает значение параметра: флаг, затем окружение, затем файл
+// Пустое значение считается незаданным, как и раньше для переменных окружения
+func (l *loader) lookup(key string) (string, bool) {
+	if value := l.flags[key]; value != "" {
+		l.origins[key] = SourceFlag
+		return value, true
+	}
+	if value := os.Getenv(key); value != "" {
+		l.origins[key] = SourceEnv
+		return value, true
+	}
+	if value := l.dotenv[key]; value != "" {
+		l.origins[key] = SourceEnv
+		return value, true
+	}
+	if value := l.file[key]; value != "" {
+		l.origins[key] = SourceFile
+		return value, true
+	}
+	l.origins[key] = SourceDefault
+	return "", false
+}
+
+// invalid запоминает неверное значение параметра
+func (l *loader) invalid(key, value, expected string) {
+	l.problems = append(l.problems, fmt.Sprintf("%s=%q: ожидается %s", key, value, expected))
+}
+
+// unknown возвращает параметры флагов и файла, которые конфигурация
+// не читает: скорее всего, в имени опечатка
+func (l *loader) unknown() []string {
+	var keys []string
+	for _, values := range []map[string]string{l.flags, l.file} {
+		for key := range values {
+			if _, ok := l.origins[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getEnv получает параметр или возвращает дефолтное значение
+// Это удобная функция-хелпер для работы с переменными окружения
+func (l *loader) getEnv(key, defaultValue string) string {
+	value, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvAsInt получает параметр как число
+// Если параметр не задан - возвращает дефолт, если не число - запоминает ошибку
+func (l *loader) getEnvAsInt(key string, defaultValue int) int {
+	valueStr, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		l.invalid(key, valueStr, "целое число")
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvAsBool получает параметр как bool
+// Принимает значения strconv.ParseBool: 1, t, true, 0, f, false и т.д.
+func (l *loader) getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		l.invalid(key, valueStr, "true или false")
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvAsFloat получает параметр как число с плавающей точкой
+// Если параметр не задан - возвращает дефолт, если не число - запоминает ошибку
+func (l *loader) getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		l.invalid(key, valueStr, "число")
+		return defaultValue
+	}
+	return value
+}
+
+// File возвращает путь к файлу конфигурации, пустой - файл не задан
+func (c *Config) File() string {
+	return c.file
+}
+
+// Origins возвращает источник значения каждого параметра по имени
+// переменной окружения: flag, env, file или default
+func (c *Config) Origins() map[string]string {
+	return c.origins
+}
+
+// ValidationError перечисляет все неверные параметры конфигурации
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "некорректная конфигурация:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
@@ -0,0 +1,242 @@
+// Package contract - контрактные тесты приложения против его спецификации
+// OpenAPI (GET /openapi.json).
+//
+// Спецификация строится из routes.Spec, но обработчик может разбирать
+// тело иначе, чем описано, или отвечать статусом и полями, которых в
+// описании нет. Suite.Run вызывает каждую операцию спецификации в
+// собранном приложении телами, построенными по схеме запроса:
+// допустимым (Example) и испорченными (Invalid), и проверяет, что
+//
+//   - испорченное тело отклонено документированным 400 или 422;
+//   - допустимое тело не отклонено проверкой тела;
+//   - статус ответа описан в спецификации (5xx - ответ инфраструктуры,
+//     он не описывается), а тело JSON соответствует схеме этого статуса.
+//
+// Undocumented находит роуты Fiber, которых нет в спецификации.
+// Тесты запускаются обычным go test без БД (см. cmd/api/contract_test.go):
+// запросы к БД завершаются ошибкой, поэтому ответы после проверки тела
+// чаще всего 500, а ответы без БД сверяются со схемой полностью. Если
+// обработчик читает БД до разбора тела, проверка тела не достигается:
+// такой 500 не считается расхождением.
+package contract
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/routes"
+)
+
+// Suite - приложение и его спецификация
+type Suite struct {
+	App *fiber.App
+	Doc *routes.Document
+
+	// Authorize добавляет к запросу учетные данные, которых требует
+	// операция (x-scopes); nil - запросы без учетных данных
+	Authorize func(req *http.Request, op routes.Operation) error
+
+	// Param возвращает значение параметра name в пути path (шаблон
+	// OpenAPI); nil или пустая строка - "1"
+	Param func(path, name string) string
+
+	// Bodies - допустимые тела операций "METHOD /path", которые схема не
+	// определяет (значение настройки зависит от ключа в пути); по
+	// умолчанию - Example схемы запроса
+	Bodies map[string]any
+
+	// Skip - операции "METHOD /path", которые не вызываются
+	// (потоковые ответы, которые не завершаются сами)
+	Skip []string
+}
+
+// Run проверяет каждую операцию спецификации подтестом "METHOD /path"
+func (s Suite) Run(t *testing.T) {
+	t.Helper()
+	for _, path := range sortedKeys(s.Doc.Paths) {
+		for _, method := range sortedKeys(s.Doc.Paths[path]) {
+			op := s.Doc.Paths[path][method]
+			name := strings.ToUpper(method) + " " + path
+			t.Run(name, func(t *testing.T) {
+				for _, skip := range s.Skip {
+					if skip == name {
+						t.Skip("операция в Suite.Skip")
+					}
+				}
+				for _, c := range s.cases(name, op) {
+					s.check(t, strings.ToUpper(method), path, op, c)
+				}
+			})
+		}
+	}
+}
+
+// cases - тела запросов операции: допустимое и испорченные
+func (s Suite) cases(name string, op routes.Operation) []Case {
+	if op.RequestBody == nil {
+		return []Case{{Name: "без тела"}}
+	}
+	schema := op.RequestBody.Content[fiber.MIMEApplicationJSON].Schema
+	body, ok := s.Bodies[name]
+	if !ok {
+		body = Example(s.Doc, schema)
+	}
+	valid := jsonCase("допустимое тело", body, 0)
+	return append([]Case{valid}, Invalid(s.Doc, schema)...)
+}
+
+// check отправляет запрос случая c и сверяет ответ со спецификацией
+func (s Suite) check(t testing.TB, method, path string, op routes.Operation, c Case) {
+	t.Helper()
+	req, err := s.request(method, path, op, c.Body)
+	if err != nil {
+		t.Fatalf("%s: %v", c.Name, err)
+	}
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s: %v", c.Name, err)
+	}
+	defer resp.Body.Close()
+	var body bytes.Buffer
+	_, _ = body.ReadFrom(resp.Body)
+
+	status := resp.StatusCode
+	fail := func(format string, args ...any) {
+		t.Errorf("%s: ответ %d %s: %s", c.Name, status, fmt.Sprintf(format, args...), truncate(body.String()))
+	}
+
+	isJSON := strings.HasPrefix(resp.Header.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON)
+	var value any
+	if isJSON {
+		if value, err = Decode(body.Bytes()); err != nil {
+			fail("невалидный JSON: %v", err)
+			return
+		}
+	}
+
+	// 1. Статус: ожидаемый для испорченного тела и описанный в спецификации
+	// Допустимое тело может нарушать правила сервиса (400 с другим кодом),
+	// но не разбор и не теги validate. 5xx на испорченное тело - обработчик
+	// обратился к БД до разбора тела (доступ к ресурсу из пути)
+	switch {
+	case c.Status != 0 && status != c.Status && status < fiber.StatusInternalServerError:
+		fail("ожидался %d", c.Status)
+	case c.Status == 0 && op.RequestBody != nil && slices.Contains(BodyErrorCodes, errorCode(value)):
+		fail("допустимое по схеме тело отклонено")
+	}
+	response, documented := op.Responses[strconv.Itoa(status)]
+	if !documented && status < fiber.StatusInternalServerError {
+		fail("статус не описан в спецификации")
+		return
+	}
+
+	// 2. Тело: по схеме статуса, у неописанных 5xx - ErrorResponse,
+	// если ответ JSON (ошибки инфраструктуры могут быть текстом)
+	var schema *routes.Schema
+	if media, ok := response.Content[fiber.MIMEApplicationJSON]; ok {
+		schema = media.Schema
+	}
+	if !documented {
+		if !isJSON {
+			return
+		}
+		schema = &routes.Schema{Ref: refPrefix + "ErrorResponse"}
+	}
+	if schema == nil || method == fiber.MethodHead {
+		return
+	}
+	if !isJSON {
+		fail("ожидался %s", fiber.MIMEApplicationJSON)
+		return
+	}
+	if err := Validate(s.Doc, schema, value); err != nil {
+		fail("тело не соответствует схеме: %v", err)
+	}
+}
+
+// BodyErrorCodes - коды ErrorResponse, которыми handlers отклоняют тело
+// запроса: ошибка BodyParser и проверка validation.Struct
+var BodyErrorCodes = []string{"INVALID_JSON", "VALIDATION_ERROR"}
+
+// errorCode возвращает поле code тела ошибки
+func errorCode(value any) string {
+	object, _ := value.(map[string]any)
+	code, _ := object["code"].(string)
+	return code
+}
+
+// request собирает запрос операции с параметрами пути и телом
+func (s Suite) request(method, path string, op routes.Operation, body []byte) (*http.Request, error) {
+	template := path
+	for _, param := range op.Parameters {
+		value := "1"
+		if s.Param != nil {
+			if v := s.Param(template, param.Name); v != "" {
+				value = v
+			}
+		}
+		path = strings.ReplaceAll(path, "{"+param.Name+"}", url.PathEscape(value))
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	if body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	req.Header.Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+	if s.Authorize != nil {
+		if err := s.Authorize(req, op); err != nil {
+			return nil, fmt.Errorf("учетные данные: %w", err)
+		}
+	}
+	return req, nil
+}
+
+// Undocumented возвращает роуты приложения ("METHOD /path"), которых нет
+// в спецификации. HEAD и OPTIONS Fiber добавляет к роутам сам
+func Undocumented(app *fiber.App, doc *routes.Document) []string {
+	var missing []string
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == fiber.MethodHead || route.Method == fiber.MethodOptions {
+			continue
+		}
+		// Fiber без StrictRouting отвечает на путь и с "/" в конце
+		path, _ := routes.OpenAPIPath(route.Path)
+		if len(path) > 1 {
+			path = strings.TrimSuffix(path, "/")
+		}
+		if _, ok := doc.Paths[path][strings.ToLower(route.Method)]; !ok {
+			missing = append(missing, route.Method+" "+path)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// sortedKeys - ключи в алфавитном порядке: подтесты идут в одном порядке
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// truncate обрезает тело ответа для сообщения об ошибке
+func truncate(s string) string {
+	const limit = 300
+	if len(s) > limit {
+		return s[:limit] + "..."
+	}
+	return s
+}
@@ -0,0 +1,156 @@
+package contract
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/routes"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+)
+
+type itemRequest struct {
+	Name  string   `json:"name" validate:"required,min=2,max=20"`
+	Kind  string   `json:"kind" validate:"required,oneof=book film"`
+	Email string   `json:"email" validate:"omitempty,email"`
+	Count int      `json:"count" validate:"omitempty,min=1,max=10"`
+	Tags  []string `json:"tags"`
+}
+
+type itemResponse struct {
+	ID   int     `json:"id"`
+	Name string  `json:"name"`
+	Note *string `json:"note"`
+}
+
+// itemApp - приложение с одной операцией, которая разбирает тело так же,
+// как handlers: BodyParser - 400, validation.Struct - 422
+func itemApp(t *testing.T, respond func(c *fiber.Ctx) error) (*fiber.App, *routes.Document) {
+	t.Helper()
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error(), "code": "VALIDATION_ERROR"})
+	}})
+	registry := routes.NewRegistry(routes.Policy{})
+	group := registry.Group(app, "")
+	group.Post("/items", func(c *fiber.Ctx) error {
+		var req itemRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Невалидный JSON", "code": "INVALID_JSON"})
+		}
+		if err := validation.Struct(req); err != nil {
+			return err
+		}
+		return respond(c)
+	}, routes.Spec{Request: itemRequest{}, Response: itemResponse{}, Status: fiber.StatusCreated})
+	doc := registry.OpenAPI(routes.Info{Title: "test", Version: "v1"})
+	return app, doc
+}
+
+// recorder запоминает ошибки проверки вместо того, чтобы провалить тест
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Errorf(string, ...any) { r.failed = true }
+
+func requestSchema(doc *routes.Document) *routes.Schema {
+	return doc.Paths["/items"]["post"].RequestBody.Content[fiber.MIMEApplicationJSON].Schema
+}
+
+func TestExample(t *testing.T) {
+	_, doc := itemApp(t, nil)
+	got := Example(doc, requestSchema(doc))
+	want := map[string]any{"name": "contract", "kind": "book"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Example %v, ожидался %v", got, want)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	_, doc := itemApp(t, nil)
+	statuses := map[string]int{}
+	for _, c := range Invalid(doc, requestSchema(doc)) {
+		statuses[c.Name] = c.Status
+	}
+	want := map[string]int{
+		"невалидный JSON":                     fiber.StatusBadRequest,
+		"массив вместо объекта":               fiber.StatusBadRequest,
+		"name: неверный тип":                  fiber.StatusBadRequest,
+		"name: нарушены ограничения":          fiber.StatusUnprocessableEntity,
+		"name: отсутствует обязательное поле": fiber.StatusUnprocessableEntity,
+		"kind: неверный тип":                  fiber.StatusBadRequest,
+		"kind: нарушены ограничения":          fiber.StatusUnprocessableEntity,
+		"kind: отсутствует обязательное поле": fiber.StatusUnprocessableEntity,
+		"email: неверный тип":                 fiber.StatusBadRequest,
+		"email: нарушены ограничения":         fiber.StatusUnprocessableEntity,
+		"count: неверный тип":                 fiber.StatusBadRequest,
+		"count: нарушены ограничения":         fiber.StatusUnprocessableEntity,
+		"tags: неверный тип":                  fiber.StatusBadRequest,
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("случаи %v, ожидались %v", statuses, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	_, doc := itemApp(t, nil)
+	schema := &routes.Schema{Ref: refPrefix + "itemResponse"}
+
+	tests := []struct {
+		body  string
+		valid bool
+	}{
+		{`{"id": 1, "name": "a", "note": null}`, true},
+		{`{"id": 1, "name": "a", "note": "b"}`, true},
+		{`{"id": 1.5, "name": "a", "note": null}`, false},
+		{`{"id": 1, "name": 2, "note": null}`, false},
+		{`["a"]`, false},
+		{`{"id": 1, "name": "a", "note": null, "secret": "x"}`, false},
+	}
+	for _, tc := range tests {
+		value, err := Decode([]byte(tc.body))
+		if err != nil {
+			t.Fatalf("Decode(%s): %v", tc.body, err)
+		}
+		if err := Validate(doc, schema, value); (err == nil) != tc.valid {
+			t.Errorf("Validate(%s) = %v, ожидалось допустимо: %v", tc.body, err, tc.valid)
+		}
+	}
+}
+
+func TestSuite(t *testing.T) {
+	// Ответ по схеме: все случаи проходят
+	app, doc := itemApp(t, func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusCreated).JSON(itemResponse{ID: 1, Name: "a"})
+	})
+	Suite{App: app, Doc: doc}.Run(t)
+	if missing := Undocumented(app, doc); len(missing) != 0 {
+		t.Errorf("Undocumented %v", missing)
+	}
+
+	// Лишнее поле и недокументированный статус - расхождения
+	for name, respond := range map[string]func(c *fiber.Ctx) error{
+		"лишнее поле": func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusCreated).JSON(fiber.Map{"id": 1, "name": "a", "note": nil, "secret": "x"})
+		},
+		"статус": func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusConflict)
+		},
+	} {
+		app, doc := itemApp(t, respond)
+		rec := &recorder{TB: t}
+		Suite{App: app, Doc: doc}.check(rec, fiber.MethodPost, "/items", doc.Paths["/items"]["post"],
+			Case{Name: "допустимое тело", Body: []byte(`{"name":"ab","kind":"book"}`)})
+		if !rec.failed {
+			t.Errorf("%s: расхождение не найдено", name)
+		}
+	}
+
+	// Роут без описания
+	app.Get("/hidden", func(c *fiber.Ctx) error { return nil })
+	if missing := Undocumented(app, doc); !reflect.DeepEqual(missing, []string{"GET /hidden"}) {
+		t.Errorf("Undocumented %v", missing)
+	}
+}
@@ -0,0 +1,249 @@
+package contract
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/routes"
+)
+
+// Case - тело запроса и статус, которым приложение обязано ответить
+type Case struct {
+	Name   string
+	Body   []byte
+	Status int // 0 - тело допустимо: любой документированный ответ, кроме 400 и 422
+}
+
+// maxDepth - глубина вложенности примеров: рекурсивные схемы обрываются null
+const maxDepth = 8
+
+// Значения строк по формату: проходят проверки тегов validate
+var formatExamples = map[string]string{
+	"email":     "contract@example.com",
+	"uuid":      "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+	"uri":       "https://example.com/contract",
+	"origin":    "https://app.example.com",
+	"country":   "RU",
+	"date-time": "2024-01-01T09:00:00Z",
+	"byte":      "Y29udHJhY3Q=",
+}
+
+// Значения, которые нарушают формат, но остаются строкой
+var formatViolations = map[string]string{
+	"email":     "not-an-email",
+	"uuid":      "not-a-uuid",
+	"uri":       "not a url",
+	"origin":    "not an origin",
+	"country":   "ZZ",
+	"date-time": "not a date",
+	"byte":      "not base64!",
+}
+
+// decodedFormats - форматы, которые проверяет разбор JSON (utc.Time,
+// []byte), а не теги validate: нарушение отклоняется с 400
+var decodedFormats = map[string]bool{"date-time": true, "byte": true}
+
+// Example возвращает минимальное допустимое значение схемы:
+// у объектов - только обязательные поля, строки - по формату и длине
+func Example(doc *routes.Document, schema *routes.Schema) any {
+	return example(doc, schema, 0)
+}
+
+func example(doc *routes.Document, schema *routes.Schema, depth int) any {
+	schema = Resolve(doc, schema)
+	if schema == nil {
+		return "contract"
+	}
+	if depth > maxDepth {
+		return nil
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+
+	switch schema.Type {
+	case "string":
+		return exampleString(schema)
+	case "integer", "number":
+		n := 1
+		if schema.Minimum != nil && *schema.Minimum > n {
+			n = *schema.Minimum
+		}
+		if schema.Maximum != nil && *schema.Maximum < n {
+			n = *schema.Maximum
+		}
+		return n
+	case "boolean":
+		return false
+	case "array":
+		n := 1
+		if schema.MinItems != nil && *schema.MinItems > n {
+			n = *schema.MinItems
+		}
+		items := make([]any, n)
+		for i := range items {
+			items[i] = example(doc, schema.Items, depth+1)
+		}
+		return items
+	case "object":
+		object := make(map[string]any, len(schema.Required))
+		for _, name := range schema.Required {
+			object[name] = example(doc, schema.Properties[name], depth+1)
+		}
+		return object
+	}
+	// Схема без типа (interface{}) допускает любое значение
+	return "contract"
+}
+
+// exampleString - строка, которая проходит формат, pattern и длину схемы
+func exampleString(schema *routes.Schema) string {
+	if value, ok := formatExamples[schema.Format]; ok {
+		return value
+	}
+	value := "contract"
+	if schema.Pattern != "" {
+		value = "1" // Единственный pattern схем - numeric
+	}
+	return fitLength(value, schema)
+}
+
+// fitLength дополняет или обрезает value до длины, допустимой схемой
+func fitLength(value string, schema *routes.Schema) string {
+	if schema.MinLength != nil && len(value) < *schema.MinLength {
+		value += strings.Repeat(value[len(value)-1:], *schema.MinLength-len(value))
+	}
+	if schema.MaxLength != nil && len(value) > *schema.MaxLength {
+		value = value[:*schema.MaxLength]
+	}
+	return value
+}
+
+// Invalid возвращает тела, которые приложение обязано отклонить:
+// не JSON и значения неверного типа - 400, нарушение required, формата,
+// enum и длины - 422. Каждый случай портит одно поле минимального
+// допустимого тела (Example); вложенные объекты не перебираются
+func Invalid(doc *routes.Document, schema *routes.Schema) []Case {
+	schema = Resolve(doc, schema)
+	cases := []Case{
+		{Name: "невалидный JSON", Body: []byte(`{"`), Status: fiber.StatusBadRequest},
+	}
+	if schema == nil || schema.Type != "object" {
+		return cases
+	}
+	cases = append(cases, Case{Name: "массив вместо объекта", Body: []byte(`[]`), Status: fiber.StatusBadRequest})
+
+	valid, _ := Example(doc, schema).(map[string]any)
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property := Resolve(doc, schema.Properties[name])
+		if wrong, ok := wrongType(property); ok {
+			cases = append(cases, withField(valid, name, wrong, name+": неверный тип", fiber.StatusBadRequest))
+		}
+		if violation, status, ok := violate(doc, property); ok {
+			cases = append(cases, withField(valid, name, violation, name+": нарушены ограничения", status))
+		}
+	}
+	for _, name := range schema.Required {
+		body := make(map[string]any, len(valid))
+		for key, value := range valid {
+			if key != name {
+				body[key] = value
+			}
+		}
+		cases = append(cases, jsonCase(name+": отсутствует обязательное поле", body, fiber.StatusUnprocessableEntity))
+	}
+	return cases
+}
+
+// withField - случай с телом valid, в котором поле name заменено на value
+func withField(valid map[string]any, name string, value any, caseName string, status int) Case {
+	body := make(map[string]any, len(valid)+1)
+	for key, v := range valid {
+		body[key] = v
+	}
+	body[name] = value
+	return jsonCase(caseName, body, status)
+}
+
+func jsonCase(name string, body any, status int) Case {
+	data, _ := json.Marshal(body)
+	return Case{Name: name, Body: data, Status: status}
+}
+
+// wrongType возвращает значение JSON другого типа, чем у схемы
+// Для строк - true, а не число: время принимается и Unix секундами
+func wrongType(schema *routes.Schema) (any, bool) {
+	if schema == nil {
+		return nil, false
+	}
+	switch schema.Type {
+	case "string":
+		return true, true
+	case "integer", "number", "boolean", "array", "object":
+		return "contract", true
+	}
+	return nil, false
+}
+
+// violate возвращает значение того же типа, которое нарушает enum,
+// формат, pattern или длину схемы, и статус, которым его отклоняют
+func violate(doc *routes.Document, schema *routes.Schema) (any, int, bool) {
+	if schema == nil {
+		return nil, 0, false
+	}
+	const status = fiber.StatusUnprocessableEntity
+	switch schema.Type {
+	case "string":
+		if len(schema.Enum) > 0 {
+			return "not-in-enum", status, true
+		}
+		if value, ok := formatViolations[schema.Format]; ok {
+			if decodedFormats[schema.Format] {
+				return value, fiber.StatusBadRequest, true
+			}
+			return value, status, true
+		}
+		if schema.Pattern != "" {
+			return fitLength("x", schema), status, true
+		}
+		// Строка короче минимума; пустая строка с omitempty допустима
+		if schema.MinLength != nil && *schema.MinLength > 1 {
+			return strings.Repeat("a", *schema.MinLength-1), status, true
+		}
+		if schema.MaxLength != nil {
+			return strings.Repeat("a", *schema.MaxLength+1), status, true
+		}
+	case "integer", "number":
+		// Число больше максимума; 0 ниже минимума допустим с omitempty
+		if schema.Maximum != nil {
+			return *schema.Maximum + 1, status, true
+		}
+		if schema.Minimum != nil && *schema.Minimum != 1 {
+			return *schema.Minimum - 1, status, true
+		}
+	case "array":
+		if item, itemStatus, ok := violate(doc, Resolve(doc, schema.Items)); ok {
+			return []any{item}, itemStatus, true
+		}
+		if schema.MinItems != nil && *schema.MinItems > 0 {
+			return []any{}, status, true
+		}
+		if schema.MaxItems != nil {
+			items := make([]any, *schema.MaxItems+1)
+			for i := range items {
+				items[i] = Example(doc, schema.Items)
+			}
+			return items, status, true
+		}
+	}
+	return nil, 0, false
+}
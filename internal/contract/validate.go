@@ -0,0 +1,174 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Soundveyve/fiber-backend/internal/routes"
+)
+
+// refPrefix - префикс ссылок на components.schemas
+const refPrefix = "#/components/schemas/"
+
+// Resolve возвращает схему, на которую ссылается $ref, или саму схему
+// Пустая схема (interface{}) и неизвестная ссылка - nil: подходит любое значение
+func Resolve(doc *routes.Document, schema *routes.Schema) *routes.Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = doc.Components.Schemas[strings.TrimPrefix(schema.Ref, refPrefix)]
+	}
+	if schema == nil || (schema.Type == "" && schema.Properties == nil) {
+		return nil
+	}
+	return schema
+}
+
+// Decode разбирает JSON так, чтобы Validate различал целые и дробные числа
+func Decode(data []byte) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Validate проверяет значение, разобранное Decode, по схеме
+// Поля объекта, которых нет в схеме, - ошибка: ответ, в котором
+// появилось недокументированное поле, расходится со спецификацией
+func Validate(doc *routes.Document, schema *routes.Schema, value any) error {
+	return validate(doc, schema, value, "$")
+}
+
+func validate(doc *routes.Document, schema *routes.Schema, value any, path string) error {
+	nullable := schema != nil && schema.Nullable
+	schema = Resolve(doc, schema)
+	if schema == nil {
+		return nil
+	}
+	if value == nil {
+		if nullable || schema.Nullable {
+			return nil
+		}
+		return fmt.Errorf("%s: null, ожидался %s", path, schema.Type)
+	}
+
+	switch schema.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return typeError(path, schema.Type, value)
+		}
+		return validateString(schema, s, path)
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return typeError(path, schema.Type, value)
+		}
+		if _, err := n.Int64(); err != nil && schema.Type == "integer" {
+			return fmt.Errorf("%s: %s, ожидалось целое число", path, n)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return typeError(path, schema.Type, value)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return typeError(path, schema.Type, value)
+		}
+		for i, item := range items {
+			if err := validate(doc, schema.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return typeError(path, schema.Type, value)
+		}
+		return validateObject(doc, schema, object, path)
+	}
+	return nil
+}
+
+// validateObject проверяет обязательные, описанные и лишние поля объекта
+func validateObject(doc *routes.Document, schema *routes.Schema, object map[string]any, path string) error {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("%s: нет обязательного поля %s", path, name)
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := schema.Properties[name]
+		switch {
+		case ok:
+		case schema.AdditionalProperties != nil:
+			property = schema.AdditionalProperties
+		case len(schema.Properties) == 0:
+			// Объект без описанных полей (map без типа значения)
+			continue
+		default:
+			return fmt.Errorf("%s: поле %s не описано в спецификации", path, name)
+		}
+		if err := validate(doc, property, object[name], path+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// numeric - pattern тега validate numeric
+var numeric = regexp.MustCompile(`^[0-9]+$`)
+
+// validateString проверяет enum, формат, pattern и длину строки
+func validateString(schema *routes.Schema, s, path string) error {
+	if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, s) {
+		return fmt.Errorf("%s: %q не входит в %v", path, s, schema.Enum)
+	}
+
+	var err error
+	switch schema.Format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339Nano, s)
+	case "uuid":
+		_, err = uuid.Parse(s)
+	case "email":
+		if !strings.Contains(s, "@") {
+			err = fmt.Errorf("нет @")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %q не в формате %s: %v", path, s, schema.Format, err)
+	}
+	if schema.Pattern != "" && !numeric.MatchString(s) {
+		return fmt.Errorf("%s: %q не соответствует %s", path, s, schema.Pattern)
+	}
+
+	length := len([]rune(s))
+	if schema.MinLength != nil && length < *schema.MinLength && s != "" {
+		return fmt.Errorf("%s: длина %d меньше %d", path, length, *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		return fmt.Errorf("%s: длина %d больше %d", path, length, *schema.MaxLength)
+	}
+	return nil
+}
+
+func typeError(path, want string, value any) error {
+	return fmt.Errorf("%s: значение %T, ожидался %s", path, value, want)
+}
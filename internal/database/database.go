@@ -2,123 +2,152 @@ package database
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
-	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
 
-	// Импортируем драйверы БД
-	// _ означает что мы импортируем пакет только для его side-effects (регистрации драйвера)
-	_ "github.com/lib/pq" // PostgreSQL драйвер
-	// _ "github.com/go-sql-driver/mysql" // MySQL драйвер (раскомментируйте если нужен)
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/tracing"
 )
 
-// Database инкапсулирует подключение к БД
-// Это абстракция над sql.DB которая может работать с разными БД
+// Database инкапсулирует пул соединений с PostgreSQL
+// Пул pgxpool сам проверяет соединения перед выдачей и кеширует
+// подготовленные выражения на каждом соединении
 type Database struct {
-	DB     *sql.DB                // Объект подключения к БД
-	Driver string                 // Тип драйвера (postgres, mysql)
-	Config config.DatabaseConfig  // Конфигурация БД
+	Pool    *pgxpool.Pool         // Пул соединений
+	Replica *pgxpool.Pool         // Реплика своего региона, nil - нет (см. ConnectReplica)
+	Config  config.DatabaseConfig // Конфигурация БД
 }
 
 // NewDatabase создает новое подключение к базе данных
 // Это фабричная функция которая настраивает пул соединений
 func NewDatabase(cfg config.DatabaseConfig) (*Database, error) {
-	// Получаем DSN строку для подключения
-	dsn := cfg.GetDSN()
-	if dsn == "" {
-		return nil, fmt.Errorf("неподдерживаемый драйвер БД: %s", cfg.Driver)
+	pool, err := openPool(cfg, cfg.GetDSN())
+	if err != nil {
+		return nil, err
 	}
 
-	// Открываем подключение к БД
-	// sql.Open не создает соединение сразу, а только проверяет параметры
-	db, err := sql.Open(cfg.Driver, dsn)
+	slog.Info("✅ Успешное подключение к БД",
+		"driver", "pgx", "host", cfg.Host, "port", cfg.Port)
+
+	return &Database{
+		Pool:   pool,
+		Config: cfg,
+	}, nil
+}
+
+// openPool открывает пул соединений по dsn с настройками пула из cfg
+func openPool(cfg config.DatabaseConfig, dsn string) (*pgxpool.Pool, error) {
+	// Разбираем DSN строку в конфигурацию пула
+	poolCfg, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка открытия БД: %w", err)
+		return nil, fmt.Errorf("ошибка разбора параметров БД: %w", err)
 	}
 
-	// Настраиваем пул соединений
-	// MaxOpenConns ограничивает максимальное количество открытых соединений
+	// MaxConns ограничивает максимальное количество открытых соединений
 	// Это защищает БД от перегрузки
-	db.SetMaxOpenConns(cfg.MaxOpenConns)
-	
-	// MaxIdleConns определяет сколько соединений держать открытыми в режиме ожидания
+	poolCfg.MaxConns = int32(cfg.MaxOpenConns)
+
+	// MinConns - сколько соединений пул держит открытыми даже в простое
 	// Это ускоряет последующие запросы, так как не нужно создавать новое соединение
-	db.SetMaxIdleConns(cfg.MaxIdleConns)
-	
-	// ConnMaxLifetime определяет как долго соединение может быть переиспользовано
+	poolCfg.MinConns = int32(cfg.MaxIdleConns)
+
+	// MaxConnLifetime определяет как долго соединение может быть переиспользовано
 	// После этого времени соединение закрывается и создается новое
 	// Это помогает избежать проблем с "протухшими" соединениями
-	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	poolCfg.MaxConnLifetime = cfg.ConnMaxLifetime
+
+	// Запросы внутри трассы получают SQL спаны
+	poolCfg.ConnConfig.Tracer = tracing.SQLTracer{}
+
+	// pgxpool.NewWithConfig не создает соединение сразу, а только проверяет параметры
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия БД: %w", err)
+	}
 
 	// Проверяем что БД действительно доступна
 	// Ping создает реальное подключение и проверяет связь
-	if err := db.Ping(); err != nil {
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("ошибка подключения к БД: %w", err)
 	}
+	return pool, nil
+}
 
-	log.Printf("✅ Успешное подключение к БД (%s) на %s:%s", 
-		cfg.Driver, cfg.Host, cfg.Port)
+// ConnectReplica подключает реплику для чтения из региона region (DB_REPLICAS)
+// Реплика другого региона не используется. Ошибка подключения не фатальна:
+// чтения остаются в основной БД. false - реплика не подключена
+func (d *Database) ConnectReplica(region string) bool {
+	replica, ok := d.Config.ReplicaFor(region)
+	if !ok {
+		return false
+	}
 
-	return &Database{
-		DB:     db,
-		Driver: cfg.Driver,
-		Config: cfg,
-	}, nil
+	pool, err := openPool(d.Config, replica.DSN(&d.Config))
+	if err != nil {
+		slog.Warn("⚠️  Реплика БД недоступна, чтения идут в основную БД",
+			"region", region, "host", replica.Host, "error", err)
+		return false
+	}
+
+	slog.Info("✅ Подключена реплика БД", "region", region, "host", replica.Host, "port", replica.Port)
+	d.Replica = pool
+	return true
 }
 
-// Close закрывает подключение к БД
+// ReadPool возвращает пул для чтений, которые допускают отставание
+// реплики (поиск, публичные профили): реплику своего региона или основную БД
+// Чтение после записи в том же запросе должно идти через Pool
+func (d *Database) ReadPool() *pgxpool.Pool {
+	if d.Replica != nil {
+		return d.Replica
+	}
+	return d.Pool
+}
+
+// Close закрывает пул соединений
 // Всегда вызывайте Close когда приложение завершается
-func (d *Database) Close() error {
-	if d.DB != nil {
-		log.Println("Закрытие подключения к БД...")
-		return d.DB.Close()
+func (d *Database) Close() {
+	if d.Pool != nil {
+		slog.Info("Закрытие подключения к БД...")
+		d.Pool.Close()
+	}
+	if d.Replica != nil {
+		d.Replica.Close()
 	}
-	return nil
 }
 
 // HealthCheck проверяет состояние подключения к БД
 // Полезно для health-check эндпоинтов в API
-func (d *Database) HealthCheck() error {
-	// Создаем контекст с таймаутом чтобы проверка не зависла
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+func (d *Database) HealthCheck(ctx context.Context) error {
+	// Ограничиваем проверку чтобы она не зависла
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	// PingContext пытается установить соединение с учетом контекста
-	if err := d.DB.PingContext(ctx); err != nil {
+	// Ping берет соединение из пула и проверяет его с учетом контекста
+	if err := d.Pool.Ping(ctx); err != nil {
 		return fmt.Errorf("БД недоступна: %w", err)
 	}
-	
-	return nil
-}
 
-// GetStats возвращает статистику пула соединений
-// Полезно для мониторинга и отладки
-func (d *Database) GetStats() sql.DBStats {
-	return d.DB.Stats()
+	return nil
 }
 
 // LogStats выводит статистику пула соединений в лог
 func (d *Database) LogStats() {
-	stats := d.GetStats()
-	log.Printf(`
-📊 Статистика пула соединений БД:
-   - Открытые соединения: %d
-   - Используемые: %d
-   - Простаивающие: %d
-   - Ожидающие: %d
-   - Макс. открытых: %d
-   - Макс. простаивающих: %d
-   - Макс. время жизни: %v
-	`,
-		stats.OpenConnections,
-		stats.InUse,
-		stats.Idle,
-		stats.WaitCount,
-		d.Config.MaxOpenConns,
-		d.Config.MaxIdleConns,
-		d.Config.ConnMaxLifetime,
+	stats := d.Pool.Stat()
+	slog.Info("📊 Статистика пула соединений БД",
+		"total", stats.TotalConns(),
+		"in_use", stats.AcquiredConns(),
+		"idle", stats.IdleConns(),
+		"acquire_count", stats.AcquireCount(),
+		"max_conns", stats.MaxConns(),
+		"min_conns", d.Config.MaxIdleConns,
+		"max_lifetime", d.Config.ConnMaxLifetime,
 	)
 }
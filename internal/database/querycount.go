@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// queryCounterKey - ключ контекста для счетчика запросов
+// Неэкспортируемый тип исключает коллизии с ключами других пакетов
+type queryCounterKey struct{}
+
+// QueryCounter считает SQL запросы, выполненные в рамках одного HTTP запроса,
+// и время, проведенное в них
+// Безопасен для конкурентного использования (сервис может делать запросы из горутин)
+type QueryCounter struct {
+	count   atomic.Int64
+	elapsed atomic.Int64 // Наносекунды
+}
+
+// Count возвращает количество выполненных запросов
+func (qc *QueryCounter) Count() int64 {
+	return qc.count.Load()
+}
+
+// Duration возвращает суммарное время запросов: от отправки до получения
+// последней строки. Параллельные запросы суммируются, поэтому время
+// может превышать длительность HTTP запроса
+func (qc *QueryCounter) Duration() time.Duration {
+	return time.Duration(qc.elapsed.Load())
+}
+
+// observe добавляет время запроса, начатого в start
+func (qc *QueryCounter) observe(start time.Time) {
+	qc.elapsed.Add(int64(time.Since(start)))
+}
+
+// WithQueryCounter кладет в контекст новый счетчик запросов и возвращает его
+func WithQueryCounter(ctx context.Context) (context.Context, *QueryCounter) {
+	qc := &QueryCounter{}
+	return context.WithValue(ctx, queryCounterKey{}, qc), qc
+}
+
+// QueryCounterFromContext возвращает счетчик из контекста или nil
+func QueryCounterFromContext(ctx context.Context) *QueryCounter {
+	qc, _ := ctx.Value(queryCounterKey{}).(*QueryCounter)
+	return qc
+}
+
+// countQuery увеличивает счетчик из контекста и возвращает его
+// nil - в контексте нет счетчика, время не замеряется
+func countQuery(ctx context.Context) *QueryCounter {
+	qc := QueryCounterFromContext(ctx)
+	if qc != nil {
+		qc.count.Add(1)
+	}
+	return qc
+}
+
+// CountingDB оборачивает пул соединений и считает каждый запрос в счетчик из контекста
+// Реализует интерфейс repository.DBTX, поэтому передается в repository.New
+// вместо *pgxpool.Pool - сгенерированный sqlc код менять не нужно
+type CountingDB struct {
+	*pgxpool.Pool
+}
+
+// NewCountingDB создает обертку над пулом соединений
+func NewCountingDB(pool *pgxpool.Pool) *CountingDB {
+	return &CountingDB{Pool: pool}
+}
+
+// Exec выполняет запрос без результата (INSERT/UPDATE/DELETE)
+func (d *CountingDB) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	qc := countQuery(ctx)
+	if qc == nil {
+		return d.Pool.Exec(ctx, query, args...)
+	}
+	defer qc.observe(time.Now())
+	return d.Pool.Exec(ctx, query, args...)
+}
+
+// Query выполняет запрос, возвращающий несколько строк
+// Время считается до закрытия строк: чтение результата - часть запроса
+func (d *CountingDB) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	qc := countQuery(ctx)
+	if qc == nil {
+		return d.Pool.Query(ctx, query, args...)
+	}
+	start := time.Now()
+	rows, err := d.Pool.Query(ctx, query, args...)
+	if err != nil {
+		qc.observe(start)
+		return rows, err
+	}
+	return &timedRows{Rows: rows, qc: qc, start: start}, nil
+}
+
+// QueryRow выполняет запрос, возвращающий одну строку
+// Время считается до Scan: запрос выполняется при чтении строки
+func (d *CountingDB) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	qc := countQuery(ctx)
+	if qc == nil {
+		return d.Pool.QueryRow(ctx, query, args...)
+	}
+	start := time.Now()
+	return timedRow{row: d.Pool.QueryRow(ctx, query, args...), qc: qc, start: start}
+}
+
+// timedRows добавляет время запроса в счетчик при закрытии строк
+type timedRows struct {
+	pgx.Rows
+	qc     *QueryCounter
+	start  time.Time
+	closed bool
+}
+
+// Close реализует pgx.Rows
+func (r *timedRows) Close() {
+	r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		r.qc.observe(r.start)
+	}
+}
+
+// Next реализует pgx.Rows: после последней строки pgx закрывает строки
+// сам, и Close может не вызываться
+func (r *timedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.Close()
+	return false
+}
+
+// timedRow добавляет время запроса в счетчик при чтении строки
+type timedRow struct {
+	row   pgx.Row
+	qc    *QueryCounter
+	start time.Time
+}
+
+// Scan реализует pgx.Row
+func (r timedRow) Scan(dest ...interface{}) error {
+	defer r.qc.observe(r.start)
+	return r.row.Scan(dest...)
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WarmUp заранее открывает соединения пула и готовит на каждом из них
+// горячие запросы, чтобы первые запросы после деплоя не платили за
+// установку соединения (TCP, TLS, аутентификация), разбор запроса и
+// холодный кеш каталога PostgreSQL на новом backend-процессе.
+//
+// Открывается MaxIdleConns соединений - столько пул держит в простое
+// (MinConns), больше открывать бессмысленно: лишние закроются по
+// простою. Выражения подготавливаются под именем, равным тексту
+// запроса: pgx выполняет такой запрос через уже подготовленное
+// выражение, и оно остается на соединении.
+func (d *Database) WarmUp(ctx context.Context, statements ...string) error {
+	n := d.Config.MaxIdleConns
+	if n < 1 {
+		n = 1
+	}
+
+	// Соединения берутся одновременно, иначе пул отдавал бы одно и то же
+	conns := make([]*pgxpool.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			conn.Release()
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		conn, err := d.Pool.Acquire(ctx)
+		if err != nil {
+			return fmt.Errorf("ошибка открытия соединения: %w", err)
+		}
+		conns = append(conns, conn)
+
+		for _, query := range statements {
+			if _, err := conn.Conn().Prepare(ctx, query, query); err != nil {
+				return fmt.Errorf("ошибка подготовки запроса %q: %w", query, err)
+			}
+		}
+	}
+
+	return nil
+}
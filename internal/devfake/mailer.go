@@ -0,0 +1,32 @@
+package devfake
+
+import (
+	"context"
+
+	"github.com/Soundveyve/fiber-backend/internal/mailer"
+)
+
+// Mailer - фейковая отправка писем
+// Письма не уходят наружу, а сохраняются в Outbox (канал email),
+// откуда ссылки подтверждения и сброса пароля можно взять через GET /dev/outbox
+type Mailer struct {
+	outbox *Outbox
+}
+
+// NewMailer создает фейковый Mailer, пишущий письма в outbox
+func NewMailer(outbox *Outbox) *Mailer {
+	return &Mailer{
+		outbox: outbox,
+	}
+}
+
+// Send реализует mailer.Mailer
+func (m *Mailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.outbox.Record(Message{
+		Channel: ChannelEmail,
+		To:      msg.To,
+		Subject: msg.Subject,
+		Body:    msg.Body,
+	})
+	return nil
+}
@@ -0,0 +1,87 @@
+package devfake
+
+import (
+	"sync"
+	"time"
+)
+
+// Каналы исходящих сообщений, которые перехватывают фейковые сервисы
+const (
+	ChannelEmail   = "email"
+	ChannelSMS     = "sms"
+	ChannelStorage = "storage"
+	ChannelPayment = "payment"
+)
+
+// Message - одно исходящее действие, перехваченное фейковым сервисом
+// Вместо реальной отправки письма, SMS или платежа оно сохраняется в Outbox,
+// откуда его можно посмотреть через GET /dev/outbox
+type Message struct {
+	ID        int               `json:"id"`
+	Channel   string            `json:"channel"`            // email, sms, storage, payment
+	To        string            `json:"to,omitempty"`       // Получатель: email, телефон, ключ файла
+	Subject   string            `json:"subject,omitempty"`  // Тема письма или тип операции
+	Body      string            `json:"body,omitempty"`     // Содержимое сообщения
+	Metadata  map[string]string `json:"metadata,omitempty"` // Дополнительные данные (сумма платежа, размер файла)
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Outbox хранит исходящие сообщения фейковых сервисов в памяти
+// Используется только в режиме DEV_FAKE_SERVICES=true
+type Outbox struct {
+	mu       sync.RWMutex
+	messages []Message
+	nextID   int
+	limit    int // Максимум хранимых сообщений, старые вытесняются
+}
+
+// NewOutbox создает хранилище, ограниченное limit последними сообщениями
+// Ограничение защищает от роста памяти при долгой работе dev окружения
+func NewOutbox(limit int) *Outbox {
+	return &Outbox{
+		nextID: 1,
+		limit:  limit,
+	}
+}
+
+// Record сохраняет сообщение, присваивая ему ID и время создания
+func (o *Outbox) Record(msg Message) Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	msg.ID = o.nextID
+	o.nextID++
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+
+	o.messages = append(o.messages, msg)
+	if o.limit > 0 && len(o.messages) > o.limit {
+		o.messages = o.messages[len(o.messages)-o.limit:]
+	}
+
+	return msg
+}
+
+// List возвращает сообщения канала (или все, если channel пустой)
+// Новые сообщения идут первыми - обычно нужно последнее письмо
+func (o *Outbox) List(channel string) []Message {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	result := make([]Message, 0, len(o.messages))
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if channel == "" || o.messages[i].Channel == channel {
+			result = append(result, o.messages[i])
+		}
+	}
+	return result
+}
+
+// Reset удаляет все сообщения
+func (o *Outbox) Reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.messages = nil
+}
@@ -0,0 +1,103 @@
+package devfake
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/storage"
+)
+
+// memoryObject - объект фейкового хранилища
+type memoryObject struct {
+	data []byte
+	info storage.ObjectInfo
+}
+
+// BlobStore - фейковое хранилище файлов в памяти
+// Каждая загрузка и удаление записываются в Outbox (канал storage)
+type BlobStore struct {
+	outbox *Outbox
+
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+// NewBlobStore создает фейковое хранилище, пишущее события в outbox
+func NewBlobStore(outbox *Outbox) *BlobStore {
+	return &BlobStore{
+		outbox:  outbox,
+		objects: make(map[string]memoryObject),
+	}
+}
+
+// Put сохраняет объект в памяти
+func (s *BlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	info := storage.ObjectInfo{
+		Key:         key,
+		Size:        int64(len(data)),
+		ContentType: contentType,
+		ModifiedAt:  time.Now(),
+	}
+
+	s.mu.Lock()
+	s.objects[key] = memoryObject{data: data, info: info}
+	s.mu.Unlock()
+
+	s.outbox.Record(Message{
+		Channel: ChannelStorage,
+		To:      key,
+		Subject: "put",
+		Metadata: map[string]string{
+			"size":         strconv.Itoa(len(data)),
+			"content_type": contentType,
+		},
+	})
+	return nil
+}
+
+// Get возвращает объект из памяти
+func (s *BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, storage.ObjectInfo, error) {
+	s.mu.RLock()
+	obj, ok := s.objects[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, storage.ObjectInfo{}, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), obj.info, nil
+}
+
+// Stat возвращает информацию об объекте
+func (s *BlobStore) Stat(ctx context.Context, key string) (storage.ObjectInfo, error) {
+	s.mu.RLock()
+	obj, ok := s.objects[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return storage.ObjectInfo{}, storage.ErrNotFound
+	}
+	return obj.info, nil
+}
+
+// Delete удаляет объект из памяти
+func (s *BlobStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.objects, key)
+	s.mu.Unlock()
+
+	s.outbox.Record(Message{
+		Channel: ChannelStorage,
+		To:      key,
+		Subject: "delete",
+	})
+	return nil
+}
@@ -0,0 +1,188 @@
+// Package diagnostics собирает пакет диагностики инстанса для обращений
+// в поддержку (POST /admin/v1/diagnostics).
+//
+// Пакет - zip архив с тем, что обычно запрашивают первым делом:
+//
+//   - manifest.json - инстанс, время сборки пакета и ошибки источников
+//   - goroutines.txt - стеки всех горутин (как /debug/pprof/goroutine?debug=2)
+//   - errors.json - последние записи лога уровня ERROR (logging.RecentErrors)
+//   - config.json - действующая конфигурация, секреты скрыты
+//   - system.json - память, пул соединений БД, очереди в памяти, кеши и
+//     счетчики ошибок (как GET /admin/v1/system)
+//   - jobs.json - очередь фоновых заданий (как GET /admin/v1/jobs)
+//
+// Пакет нужен именно тогда, когда что-то сломано, поэтому отказ одного
+// источника (например, недоступная БД для jobs.json) не отменяет
+// остальные: файл пропускается, а ошибка попадает в manifest.json.
+package diagnostics
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/logging"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/system"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+)
+
+// JobStats - источник статистики очереди заданий (jobs.Queue)
+type JobStats interface {
+	Stats(ctx context.Context) (*models.JobQueueResponse, error)
+}
+
+// Manifest - описание пакета (manifest.json)
+type Manifest struct {
+	CreatedAt utc.Time `json:"created_at"`
+	Hostname  string   `json:"hostname"`
+	Region    string   `json:"region,omitempty"`
+	GoVersion string   `json:"go_version"`
+	Files     []string `json:"files"`
+
+	// Errors - ошибки источников по имени файла, который не попал в пакет
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Collector собирает пакет диагностики
+// Любой источник может быть nil - его файла не будет в пакете
+type Collector struct {
+	inspector *system.Inspector
+	config    *config.Current
+	jobs      JobStats
+}
+
+// NewCollector создает сборщик пакета диагностики
+func NewCollector(inspector *system.Inspector, current *config.Current, jobs JobStats) *Collector {
+	return &Collector{
+		inspector: inspector,
+		config:    current,
+		jobs:      jobs,
+	}
+}
+
+// file - один файл пакета
+type file struct {
+	name  string
+	write func(w io.Writer) error
+}
+
+// Write пишет пакет диагностики в w
+// Ошибка возвращается, только если не удалось записать сам архив
+func (c *Collector) Write(ctx context.Context, w io.Writer) (*Manifest, error) {
+	hostname, _ := os.Hostname()
+	manifest := &Manifest{
+		CreatedAt: utc.Now(),
+		Hostname:  hostname,
+		GoVersion: runtime.Version(),
+		Files:     []string{},
+		Errors:    map[string]string{},
+	}
+
+	files := []file{
+		{"goroutines.txt", func(w io.Writer) error {
+			return pprof.Lookup("goroutine").WriteTo(w, 2)
+		}},
+		{"errors.json", func(w io.Writer) error {
+			return writeJSON(w, logging.RecentErrors())
+		}},
+	}
+	if c.config != nil {
+		cfg := c.config.Get()
+		manifest.Region = cfg.App.Region
+		files = append(files, file{"config.json", func(w io.Writer) error {
+			restartRequired := c.config.RestartRequired()
+			if restartRequired == nil {
+				restartRequired = []string{}
+			}
+			return writeJSON(w, models.ConfigResponse{
+				Config:          cfg.Redacted(),
+				Sources:         cfg.Origins(),
+				File:            cfg.File(),
+				LoadedAt:        utc.From(cfg.LoadedAt()),
+				RestartRequired: restartRequired,
+			})
+		}})
+	}
+	if c.inspector != nil {
+		files = append(files, file{"system.json", func(w io.Writer) error {
+			snapshot, err := c.inspector.Snapshot()
+			if err != nil {
+				return err
+			}
+			return writeJSON(w, snapshot)
+		}})
+	}
+	if c.jobs != nil {
+		files = append(files, file{"jobs.json", func(w io.Writer) error {
+			stats, err := c.jobs.Stats(ctx)
+			if err != nil {
+				return err
+			}
+			return writeJSON(w, stats)
+		}})
+	}
+
+	// Файл собирается целиком до записи в архив: при ошибке источника
+	// в архиве не остается обрезанного файла
+	archive := zip.NewWriter(w)
+	for _, f := range files {
+		var buf bytes.Buffer
+		if err := f.write(&buf); err != nil {
+			manifest.Errors[f.name] = err.Error()
+			continue
+		}
+		if err := addFile(archive, f.name, manifest.CreatedAt.Time, buf.Bytes()); err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, f.name)
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, manifest); err != nil {
+		return nil, err
+	}
+	if err := addFile(archive, "manifest.json", manifest.CreatedAt.Time, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("ошибка записи архива диагностики: %w", err)
+	}
+	return manifest, nil
+}
+
+// FileName возвращает имя файла пакета для Content-Disposition
+func (m *Manifest) FileName() string {
+	name := "diagnostics"
+	if m.Hostname != "" {
+		name += "-" + m.Hostname
+	}
+	return name + "-" + m.CreatedAt.Time.Format("20060102T150405Z") + ".zip"
+}
+
+// addFile добавляет файл в архив
+func addFile(archive *zip.Writer, name string, modified time.Time, content []byte) error {
+	w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return fmt.Errorf("ошибка записи архива диагностики: %w", err)
+	}
+	if _, err := w.Write(content); err != nil {
+		return fmt.Errorf("ошибка записи архива диагностики: %w", err)
+	}
+	return nil
+}
+
+// writeJSON пишет v в w с отступами для чтения человеком
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,86 @@
+package diagnostics
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/logging"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+)
+
+// failingJobs - очередь заданий при недоступной БД
+type failingJobs struct{}
+
+func (failingJobs) Stats(context.Context) (*models.JobQueueResponse, error) {
+	return nil, errors.New("БД недоступна")
+}
+
+// readBundle возвращает содержимое файлов архива по именам
+func readBundle(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestBundleSurvivesFailingSource(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Database.Password = "db-secret"
+	logging.New(io.Discard, "production", nil).Error("тестовая ошибка диагностики", "code", 42)
+
+	var buf bytes.Buffer
+	manifest, err := NewCollector(nil, config.NewCurrent(cfg), failingJobs{}).Write(context.Background(), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := readBundle(t, buf.Bytes())
+
+	// Недоступная очередь не отменяет остальные файлы, ошибка - в манифесте
+	if _, ok := files["jobs.json"]; ok {
+		t.Error("jobs.json в пакете при ошибке источника")
+	}
+	var written Manifest
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &written); err != nil {
+		t.Fatal(err)
+	}
+	if written.Errors["jobs.json"] != "БД недоступна" || manifest.Errors["jobs.json"] == "" {
+		t.Errorf("ошибка источника не записана в манифест: %+v", written.Errors)
+	}
+	for _, name := range []string{"goroutines.txt", "errors.json", "config.json"} {
+		if files[name] == "" {
+			t.Errorf("%s нет в пакете", name)
+		}
+	}
+
+	if !strings.Contains(files["goroutines.txt"], "TestBundleSurvivesFailingSource") {
+		t.Error("стеки горутин не содержат текущий тест")
+	}
+	if !strings.Contains(files["errors.json"], "тестовая ошибка диагностики") {
+		t.Error("последние ошибки лога не попали в пакет")
+	}
+	if strings.Contains(files["config.json"], "db-secret") {
+		t.Error("секрет конфигурации попал в пакет")
+	}
+}
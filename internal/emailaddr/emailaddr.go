@@ -0,0 +1,83 @@
+// Package emailaddr нормализует адреса email пользователей.
+//
+// Адрес хранится в users.email нормализованным: без пробелов по краям и в
+// нижнем регистре, поэтому "Ivan@Example.com " и "ivan@example.com" - один
+// адрес при регистрации, входе и поиске. Кроме того, у пользователя есть
+// канонический адрес (users.canonical_email), уникальный среди
+// неудаленных пользователей: он дополнительно отбрасывает то, что почтовый
+// ящик не различает, - точки в имени Gmail (ivan.petrov@gmail.com) и
+// метки после "+" (ivan+shop@example.com), если они включены
+// (EMAIL_STRIP_GMAIL_DOTS, EMAIL_STRIP_PLUS_ALIASES). Так второй аккаунт
+// нельзя создать тем же ящиком в другом написании.
+//
+// Письма отправляются на users.email - адрес в том виде, в котором его
+// ввел пользователь (кроме регистра).
+package emailaddr
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// Rules - что канонический адрес отбрасывает сверх нормализации
+type Rules struct {
+	GmailDots   bool // Точки в имени ящика gmail.com и googlemail.com
+	PlusAliases bool // Метка после "+" в имени ящика любого домена
+}
+
+// gmailDomains - домены Gmail; googlemail.com - тот же ящик, что и gmail.com
+var gmailDomains = map[string]bool{"gmail.com": true, "googlemail.com": true}
+
+// current - правила, заданные Use (по умолчанию ничего не отбрасывается)
+var current atomic.Pointer[Rules]
+
+// Use задает правила канонических адресов для процесса
+// Вызывается один раз при запуске, до обработки запросов
+func Use(rules Rules) {
+	current.Store(&rules)
+}
+
+// Current возвращает правила, заданные Use
+func Current() Rules {
+	if rules := current.Load(); rules != nil {
+		return *rules
+	}
+	return Rules{}
+}
+
+// Normalize приводит адрес к виду для хранения: без пробелов по краям,
+// в нижнем регистре
+func Normalize(addr string) string {
+	return strings.ToLower(strings.TrimSpace(addr))
+}
+
+// Canonical возвращает канонический адрес по правилам Use
+func Canonical(addr string) string {
+	return Current().Canonical(addr)
+}
+
+// Canonical возвращает канонический адрес: нормализованный адрес без
+// частей, которые по правилам не различают ящики
+// Адрес без "@" возвращается нормализованным
+func (r Rules) Canonical(addr string) string {
+	addr = Normalize(addr)
+	at := strings.LastIndex(addr, "@")
+	if at <= 0 {
+		return addr
+	}
+	local, domain := addr[:at], addr[at+1:]
+
+	if r.PlusAliases {
+		// "+shop@example.com" - не метка, а имя ящика целиком
+		if plus := strings.IndexByte(local, '+'); plus > 0 {
+			local = local[:plus]
+		}
+	}
+	if r.GmailDots && gmailDomains[domain] {
+		if stripped := strings.ReplaceAll(local, ".", ""); stripped != "" {
+			local = stripped
+		}
+		domain = "gmail.com"
+	}
+	return local + "@" + domain
+}
@@ -0,0 +1,34 @@
+package emailaddr
+
+import "testing"
+
+func TestCanonical(t *testing.T) {
+	all := Rules{GmailDots: true, PlusAliases: true}
+	tests := []struct {
+		rules Rules
+		addr  string
+		want  string
+	}{
+		// Нормализация - всегда
+		{Rules{}, "  Ivan.Petrov+Shop@GMAIL.com ", "ivan.petrov+shop@gmail.com"},
+		{Rules{}, "ivan@example.com", "ivan@example.com"},
+
+		{Rules{GmailDots: true}, "Ivan.Petrov@gmail.com", "ivanpetrov@gmail.com"},
+		{Rules{GmailDots: true}, "ivan.petrov@googlemail.com", "ivanpetrov@gmail.com"},
+		// Точки значимы в других доменах
+		{Rules{GmailDots: true}, "ivan.petrov@example.com", "ivan.petrov@example.com"},
+		{Rules{GmailDots: true}, "ivan+shop@gmail.com", "ivan+shop@gmail.com"},
+
+		{Rules{PlusAliases: true}, "ivan+shop@example.com", "ivan@example.com"},
+		{Rules{PlusAliases: true}, "ivan+a+b@example.com", "ivan@example.com"},
+		{Rules{PlusAliases: true}, "+ivan@example.com", "+ivan@example.com"},
+
+		{all, "I.van.Petrov+news@GoogleMail.com", "ivanpetrov@gmail.com"},
+		{all, "not-an-email", "not-an-email"},
+	}
+	for _, tc := range tests {
+		if got := tc.rules.Canonical(tc.addr); got != tc.want {
+			t.Errorf("%+v.Canonical(%q) = %q, ожидалось %q", tc.rules, tc.addr, got, tc.want)
+		}
+	}
+}
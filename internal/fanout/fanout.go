@@ -0,0 +1,136 @@
+// Package fanout выполняет части составного ответа параллельно.
+//
+// Эндпоинты, которые собирают ответ из нескольких источников (сводка
+// пользователя, health-check), запускают источники одновременно через
+// errgroup. Каждая часть ограничена своим таймаутом, а ее результат
+// попадает в статус части, поэтому частичный сбой обрабатывается
+// одинаково: ошибка обязательной части отменяет остальные и возвращается
+// вызывающему, ошибка необязательной только отмечается в статусе, и ответ
+// отдается без этой части.
+//
+//	statuses, err := fanout.Run(ctx, time.Second,
+//		fanout.Part{Name: "user", Required: true, Run: loadUser},
+//		fanout.Part{Name: "audit", Run: countAuditEvents},
+//	)
+package fanout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/safe"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Статусы части
+const (
+	StatusOK       = "ok"
+	StatusError    = "error"
+	StatusTimeout  = "timeout"
+	StatusCanceled = "canceled" // Не завершилась: отменена ошибкой обязательной части или запроса
+)
+
+// Part - один источник составного ответа
+type Part struct {
+	Name string
+
+	// Required - без части ответ не имеет смысла: ее ошибка
+	// отменяет остальные части и возвращается из Run
+	Required bool
+
+	// Timeout ограничивает часть, 0 - таймаут Run
+	Timeout time.Duration
+
+	// Run заполняет свою часть ответа (захваченную переменную)
+	// Должна соблюдать дедлайн ctx
+	Run func(ctx context.Context) error
+}
+
+// Status - результат одной части
+type Status struct {
+	Name     string
+	Required bool
+	Status   string // StatusOK, StatusError, StatusTimeout или StatusCanceled
+	Latency  time.Duration
+	Err      error
+}
+
+// OK сообщает, что часть выполнена и ее данные можно отдавать
+func (s Status) OK() bool {
+	return s.Status == StatusOK
+}
+
+// Run выполняет части параллельно, каждую не дольше своего таймаута
+// (timeout по умолчанию). Статусы возвращаются в порядке частей.
+// Ошибка - ошибка первой упавшей обязательной части
+func Run(ctx context.Context, timeout time.Duration, parts ...Part) ([]Status, error) {
+	statuses := make([]Status, len(parts))
+	group, groupCtx := errgroup.WithContext(ctx)
+
+	for i, part := range parts {
+		i, part := i, part
+		group.Go(func() error {
+			statuses[i] = run(groupCtx, part, timeout)
+			if part.Required && !statuses[i].OK() {
+				return statuses[i].Err
+			}
+			return nil
+		})
+	}
+
+	return statuses, group.Wait()
+}
+
+// run выполняет одну часть и замеряет время
+func run(ctx context.Context, part Part, timeout time.Duration) Status {
+	if part.Timeout > 0 {
+		timeout = part.Timeout
+	}
+	partCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Паника части - ошибка этой части: необязательная часть не роняет ответ
+	start := time.Now()
+	err := safe.Call(partCtx, "fanout:"+part.Name, func() error {
+		return part.Run(partCtx)
+	})
+	// Часть, не соблюдающая дедлайн, все равно считается невыполненной
+	if err == nil && partCtx.Err() != nil {
+		err = partCtx.Err()
+	}
+
+	status := Status{
+		Name:     part.Name,
+		Required: part.Required,
+		Status:   StatusOK,
+		Latency:  time.Since(start),
+		Err:      err,
+	}
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		// Отменен весь запрос или упала обязательная часть
+		status.Status = StatusCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		status.Status = StatusTimeout
+	default:
+		status.Status = StatusError
+	}
+	return status
+}
+
+// Report переводит статусы частей для ответа API
+// Текст ошибки (адреса, SQL) остается в логе, клиент видит только статус
+func Report(statuses []Status) map[string]models.PartStatus {
+	report := make(map[string]models.PartStatus, len(statuses))
+	for _, s := range statuses {
+		report[s.Name] = models.PartStatus{
+			Status:    s.Status,
+			LatencyMs: float64(s.Latency.Microseconds()) / 1000,
+		}
+	}
+	return report
+}
@@ -0,0 +1,88 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// block ждет отмены ctx, как зависший источник
+func block(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRunOptionalFailuresKeepOtherParts(t *testing.T) {
+	errSource := errors.New("источник недоступен")
+	var value int
+
+	statuses, err := Run(context.Background(), 50*time.Millisecond,
+		Part{Name: "user", Required: true, Run: func(context.Context) error { value = 42; return nil }},
+		Part{Name: "audit", Run: func(context.Context) error { return errSource }},
+		Part{Name: "slow", Run: block},
+	)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if value != 42 {
+		t.Errorf("value = %d, обязательная часть не выполнена", value)
+	}
+
+	want := []string{StatusOK, StatusError, StatusTimeout}
+	for i, status := range statuses {
+		if status.Status != want[i] {
+			t.Errorf("%s: статус %s, ожидался %s", status.Name, status.Status, want[i])
+		}
+	}
+	if !errors.Is(statuses[1].Err, errSource) {
+		t.Errorf("audit: ошибка %v, ожидалась %v", statuses[1].Err, errSource)
+	}
+
+	report := Report(statuses)
+	if len(report) != 3 || report["slow"].Status != StatusTimeout {
+		t.Errorf("Report() = %+v", report)
+	}
+}
+
+func TestRunRequiredFailureCancelsOthers(t *testing.T) {
+	errNotFound := errors.New("не найден")
+
+	start := time.Now()
+	statuses, err := Run(context.Background(), time.Minute,
+		Part{Name: "user", Required: true, Run: func(context.Context) error { return errNotFound }},
+		Part{Name: "slow", Run: block},
+	)
+	if !errors.Is(err, errNotFound) {
+		t.Fatalf("Run: %v, ожидалась ошибка обязательной части", err)
+	}
+	// Зависшая часть отменена, а не дождалась своего таймаута
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Run занял %v", elapsed)
+	}
+	if statuses[1].Status != StatusCanceled {
+		t.Errorf("slow: статус %s, ожидался %s", statuses[1].Status, StatusCanceled)
+	}
+}
+
+func TestRunPartTimeoutOverridesDefault(t *testing.T) {
+	statuses, _ := Run(context.Background(), time.Minute,
+		Part{Name: "fast", Timeout: 10 * time.Millisecond, Run: block},
+	)
+	if statuses[0].Status != StatusTimeout {
+		t.Errorf("статус %s, ожидался %s", statuses[0].Status, StatusTimeout)
+	}
+}
+
+func TestRunPanickingPartIsAnError(t *testing.T) {
+	statuses, err := Run(context.Background(), time.Second,
+		Part{Name: "user", Required: true, Run: func(context.Context) error { return nil }},
+		Part{Name: "audit", Run: func(context.Context) error { panic("nil map") }},
+	)
+	if err != nil {
+		t.Fatalf("Run: %v, паника необязательной части не должна отменять ответ", err)
+	}
+	if statuses[1].Status != StatusError {
+		t.Errorf("audit: статус %s, ожидался %s", statuses[1].Status, StatusError)
+	}
+}
@@ -0,0 +1,167 @@
+// Package golden сравнивает JSON ответы API с эталонными файлами.
+//
+// Тест handler'а проверяет тело ответа целиком, а не отдельные поля:
+// случайно переименованное или пропавшее поле ломает тест, и контракт
+// API не меняется незаметно. Эталоны лежат в testdata/golden/<name>.json
+// пакета с тестом, входные данные - в testdata/fixtures/<name>.json.
+//
+// Перед сравнением JSON нормализуется: ключи объектов сортируются,
+// отступы одинаковые, а значения изменчивых полей (идентификаторы запросов,
+// время) заменяются на "<redacted>". Эталоны обновляются запуском тестов
+// с GOLDEN_UPDATE=1:
+//
+//	GOLDEN_UPDATE=1 go test ./internal/handlers/...
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// UpdateEnv - переменная окружения, с которой эталоны перезаписываются
+const UpdateEnv = "GOLDEN_UPDATE"
+
+// Redacted - значение, которым заменяются изменчивые поля
+const Redacted = "<redacted>"
+
+// DefaultRedact - поля, которые меняются от запуска к запуску в любом ответе
+var DefaultRedact = []string{"request_id", "created_at", "updated_at", "expires_at", "last_login_at"}
+
+// LoadFixture читает testdata/fixtures/<name>.json в v
+// Неизвестные поля - ошибка: фикстура не должна устаревать незаметно
+func LoadFixture(t testing.TB, name string, v interface{}) {
+	t.Helper()
+	data := Fixture(t, name)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		t.Fatalf("фикстура %s: %v", name, err)
+	}
+}
+
+// Fixture возвращает содержимое testdata/fixtures/<name>.json как есть
+// Удобно для тел запросов
+func Fixture(t testing.TB, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "fixtures", name+".json"))
+	if err != nil {
+		t.Fatalf("фикстура %s: %v", name, err)
+	}
+	return data
+}
+
+// AssertJSON сравнивает body с эталоном testdata/golden/<name>.json
+// redact - поля (на любой глубине), значения которых не сравниваются,
+// в дополнение к DefaultRedact. null в таких полях не заменяется:
+// эталон фиксирует, что поле пустое
+func AssertJSON(t testing.TB, name string, body []byte, redact ...string) {
+	t.Helper()
+	got, err := Normalize(body, append(append([]string(nil), DefaultRedact...), redact...)...)
+	if err != nil {
+		t.Fatalf("ответ %s: %v\n%s", name, err, body)
+	}
+	compare(t, name, got)
+}
+
+// AssertResponse сравнивает статус и JSON тело ответа с эталоном
+// Эталон - объект {"status": ..., "body": ...}
+func AssertResponse(t testing.TB, name string, resp *http.Response, redact ...string) {
+	t.Helper()
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ответ %s: %v", name, err)
+	}
+	var raw json.RawMessage = body
+	if len(bytes.TrimSpace(body)) == 0 {
+		raw = json.RawMessage("null")
+	}
+
+	wrapped, err := json.Marshal(struct {
+		Status int             `json:"status"`
+		Body   json.RawMessage `json:"body"`
+	}{resp.StatusCode, raw})
+	if err != nil {
+		t.Fatalf("ответ %s: тело не JSON: %v\n%s", name, err, body)
+	}
+	AssertJSON(t, name, wrapped, redact...)
+}
+
+// Normalize приводит JSON к виду для сравнения: ключи по алфавиту,
+// отступ в два пробела, поля redact заменены на Redacted
+func Normalize(data []byte, redact ...string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// Числа остаются в исходном виде: 1.0 и большие id не искажаются float64
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]bool, len(redact))
+	for _, field := range redact {
+		fields[field] = true
+	}
+	redactValue(v, fields)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	// Эталон читается человеком: <, > и & остаются как есть
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// redactValue заменяет значения полей fields во всех вложенных объектах
+func redactValue(v interface{}, fields map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if fields[key] && value != nil {
+				v[key] = Redacted
+				continue
+			}
+			redactValue(value, fields)
+		}
+	case []interface{}:
+		for _, item := range v {
+			redactValue(item, fields)
+		}
+	}
+}
+
+// compare сравнивает нормализованный ответ с эталоном или записывает эталон
+func compare(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".json")
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("эталон %s: %v (создайте его запуском с %s=1)", path, err, UpdateEnv)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("ответ не совпадает с эталоном %s (обновить: %s=1)\n--- ожидалось\n%s--- получено\n%s",
+			path, UpdateEnv, want, got)
+	}
+}
@@ -0,0 +1,37 @@
+package golden
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	body := `{"b":1.0,"a":{"request_id":"req-123","deleted_at":null,"id":9007199254740993},"items":[{"created_at":"2024-05-01T00:00:00Z","name":"<x>"}]}`
+
+	got, err := Normalize([]byte(body), "request_id", "created_at", "deleted_at")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "a": {
+    "deleted_at": null,
+    "id": 9007199254740993,
+    "request_id": "<redacted>"
+  },
+  "b": 1.0,
+  "items": [
+    {
+      "created_at": "<redacted>",
+      "name": "<x>"
+    }
+  ]
+}
+`
+	if string(got) != want {
+		t.Errorf("Normalize:\n%s\nожидалось:\n%s", got, want)
+	}
+}
+
+func TestNormalizeRejectsInvalidJSON(t *testing.T) {
+	if _, err := Normalize([]byte("<html>")); err == nil {
+		t.Error("ожидалась ошибка для не-JSON")
+	}
+}
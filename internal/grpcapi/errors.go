@@ -0,0 +1,138 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+)
+
+// errorDomain - домен google.rpc.ErrorInfo для кодов ошибок API
+const errorDomain = "fiber-backend"
+
+// kindCode - код gRPC для категории ошибки apperrors
+// Соответствует kindStatus в handlers/errors.go
+var kindCode = []struct {
+	kind error
+	code codes.Code
+}{
+	{apperrors.ErrInvalid, codes.InvalidArgument},
+	{apperrors.ErrUnauthorized, codes.Unauthenticated},
+	{apperrors.ErrForbidden, codes.PermissionDenied},
+	{apperrors.ErrNotFound, codes.NotFound},
+	{apperrors.ErrConflict, codes.AlreadyExists},
+	{apperrors.ErrGone, codes.FailedPrecondition},
+	{apperrors.ErrTooLarge, codes.ResourceExhausted},
+	{apperrors.ErrUnsupported, codes.InvalidArgument},
+	{apperrors.ErrValidation, codes.InvalidArgument},
+}
+
+// statusError создает ошибку gRPC с кодом ошибки API в ErrorInfo.reason
+func statusError(code codes.Code, reason, message string) error {
+	st := status.New(code, message)
+	if withDetails, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// toStatus переводит ошибку сервиса в ошибку gRPC, как ErrorHandler для HTTP
+//
+// Ошибки валидации - InvalidArgument с ошибками по полям в BadRequest,
+// ошибки apperrors - код их категории, нарушение уникальности - AlreadyExists.
+// Текст прочих ошибок клиенту не отдается: он попадает только в лог вызова
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// 1. Ошибки валидации с ошибками по полям
+	var validationErr *validation.Error
+	if errors.As(err, &validationErr) {
+		return validationStatus(validationErr)
+	}
+
+	// 2. Ошибки предметной области; дубликат, не распознанный сервисом, - тоже
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		if unique := apperrors.Unique(err); unique != nil {
+			appErr, err = unique, unique
+		}
+	}
+	if appErr != nil {
+		return statusError(appCode(appErr), appErr.Code, err.Error())
+	}
+
+	// 3. Отмена вызова клиентом и истекший дедлайн
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	return &internalError{err: err}
+}
+
+// validationStatus переводит ошибки по полям в InvalidArgument с BadRequest
+func validationStatus(err *validation.Error) error {
+	fields := make([]string, 0, len(err.Fields))
+	for field := range err.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(fields))
+	for _, field := range fields {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: err.Fields[field],
+		})
+	}
+
+	st := status.New(codes.InvalidArgument, "Ошибка валидации данных")
+	if withDetails, detailsErr := st.WithDetails(
+		&errdetails.ErrorInfo{Reason: "VALIDATION_ERROR", Domain: errorDomain},
+		&errdetails.BadRequest{FieldViolations: violations},
+	); detailsErr == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// appCode возвращает код gRPC категории ошибки, неизвестная - Internal
+func appCode(err *apperrors.Error) codes.Code {
+	for _, kc := range kindCode {
+		if err.Kind == kc.kind {
+			return kc.code
+		}
+	}
+	return codes.Internal
+}
+
+// internalError - непредвиденная ошибка: клиент получает Internal без
+// подробностей, а Logging пишет в лог исходный текст
+type internalError struct {
+	err error
+}
+
+// Error возвращает текст исходной ошибки
+func (e *internalError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap возвращает исходную ошибку
+func (e *internalError) Unwrap() error {
+	return e.err
+}
+
+// GRPCStatus возвращает статус для ответа клиенту
+func (e *internalError) GRPCStatus() *status.Status {
+	return status.New(codes.Internal, "внутренняя ошибка сервера")
+}
@@ -0,0 +1,36 @@
+// Package grpcapi - gRPC API пользователей (api/proto/user/v1) рядом с HTTP.
+//
+// Методы работают через тот же сервисный слой, что и HTTP handlers:
+// бизнес-логика, аудит, вебхуки и кеширование не дублируются. Пакет только
+// переводит сообщения protobuf в модели и обратно, а ошибки сервисов -
+// в статусы gRPC (см. errors.go).
+//
+// Перехватчики (interceptors.go) повторяют middleware HTTP: восстановление
+// после паники, request ID и лог вызова, проверка access токена и роли.
+package grpcapi
+
+import (
+	"google.golang.org/grpc"
+
+	userv1 "github.com/Soundveyve/fiber-backend/api/proto/user/v1"
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+)
+
+// NewServer создает gRPC сервер с сервисом пользователей и перехватчиками
+// Порядок перехватчиков: паника в любом из следующих тоже становится
+// ответом Internal, а лог получает request_id и пользователя
+func NewServer(tokens *auth.TokenManager, userService *services.UserService, accountService *services.AccountService) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			Recovery(),
+			Logging(),
+			Auth(tokens, methodAccess),
+		),
+	)
+	userv1.RegisterUserServiceServer(server, &userServer{
+		users:    userService,
+		accounts: accountService,
+	})
+	return server
+}
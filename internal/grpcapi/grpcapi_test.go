@@ -0,0 +1,268 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	userv1 "github.com/Soundveyve/fiber-backend/api/proto/user/v1"
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/redact"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+)
+
+// stubUserServer отвечает данными из контекста вызова вместо сервисов
+type stubUserServer struct {
+	userv1.UnimplementedUserServiceServer
+}
+
+// GetUser возвращает пользователя вызова, анонимный - пустой Id
+func (stubUserServer) GetUser(ctx context.Context, _ *userv1.GetUserRequest) (*userv1.User, error) {
+	user, _ := reqctx.UserFromContext(ctx)
+	return &userv1.User{Id: fmt.Sprint(user.ID), Role: user.Role}, nil
+}
+
+// ListUsers возвращает пустой список
+func (stubUserServer) ListUsers(context.Context, *userv1.ListUsersRequest) (*userv1.ListUsersResponse, error) {
+	return &userv1.ListUsersResponse{}, nil
+}
+
+// DeleteUser падает с паникой
+func (stubUserServer) DeleteUser(context.Context, *userv1.DeleteUserRequest) (*emptypb.Empty, error) {
+	panic("boom")
+}
+
+// newTestClient запускает сервер с перехватчиками NewServer в памяти
+// access - требования методов, как methodAccess в NewServer
+func newTestClient(t *testing.T, tokens *auth.TokenManager, access map[string]Access) userv1.UserServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(Recovery(), Logging(), Auth(tokens, access)))
+	userv1.RegisterUserServiceServer(server, stubUserServer{})
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return userv1.NewUserServiceClient(conn)
+}
+
+// withToken добавляет access токен в метаданные вызова
+func withToken(t *testing.T, tokens *auth.TokenManager, userID int, role string) context.Context {
+	t.Helper()
+	token, err := tokens.IssueAccess(userID, "user", role)
+	if err != nil {
+		t.Fatalf("IssueAccess: %v", err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token.Value)
+}
+
+// reason возвращает ErrorInfo.reason ошибки gRPC
+func reason(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func TestAuth(t *testing.T) {
+	tokens := auth.NewTokenManager(config.AuthConfig{JWTSecret: "test-secret", AccessTTL: time.Minute})
+	client := newTestClient(t, tokens, methodAccess)
+	ctx := context.Background()
+
+	// GetUser отдает профиль целиком, поэтому анонимный вызов отклоняется
+	if _, err := client.GetUser(ctx, &userv1.GetUserRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("GetUser без токена: %v, ожидался Unauthenticated", err)
+	}
+	user, err := client.GetUser(withToken(t, tokens, 7, auth.RoleUser), &userv1.GetUserRequest{})
+	if err != nil || user.Id != "7" {
+		t.Errorf("GetUser с токеном: %v, %v", user, err)
+	}
+
+	// Публичный метод: без токена - анонимно, с токеном - пользователь опознан
+	public := newTestClient(t, tokens, map[string]Access{userv1.UserService_GetUser_FullMethodName: AccessPublic})
+	user, err = public.GetUser(ctx, &userv1.GetUserRequest{})
+	if err != nil || user.Id != "0" {
+		t.Errorf("публичный GetUser без токена: %v, %v", user, err)
+	}
+	user, err = public.GetUser(withToken(t, tokens, 7, auth.RoleUser), &userv1.GetUserRequest{})
+	if err != nil || user.Id != "7" {
+		t.Errorf("публичный GetUser с токеном: %v, %v", user, err)
+	}
+
+	// Метод администраторов
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		code   codes.Code
+		reason string
+	}{
+		{"без токена", ctx, codes.Unauthenticated, "UNAUTHORIZED"},
+		{"невалидный токен", metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer garbage"), codes.Unauthenticated, "INVALID_TOKEN"},
+		{"не администратор", withToken(t, tokens, 7, auth.RoleUser), codes.PermissionDenied, "FORBIDDEN"},
+		{"администратор", withToken(t, tokens, 1, auth.RoleAdmin), codes.OK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.ListUsers(tt.ctx, &userv1.ListUsersRequest{})
+			if status.Code(err) != tt.code || reason(err) != tt.reason {
+				t.Errorf("ListUsers: код %v (%s), ожидался %v (%s)", status.Code(err), reason(err), tt.code, tt.reason)
+			}
+		})
+	}
+}
+
+func TestRecoveryAndRequestID(t *testing.T) {
+	tokens := auth.NewTokenManager(config.AuthConfig{JWTSecret: "test-secret", AccessTTL: time.Minute})
+	client := newTestClient(t, tokens, methodAccess)
+
+	// Паника обработчика - Internal, сервер продолжает отвечать
+	_, err := client.DeleteUser(withToken(t, tokens, 1, auth.RoleAdmin), &userv1.DeleteUserRequest{})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("DeleteUser с паникой: %v, ожидался Internal", err)
+	}
+
+	// Request ID клиента возвращается в заголовке ответа, невалидный заменяется
+	var header metadata.MD
+	userCtx := withToken(t, tokens, 7, auth.RoleUser)
+	ctx := metadata.AppendToOutgoingContext(userCtx, "x-request-id", "req-42")
+	if _, err := client.GetUser(ctx, &userv1.GetUserRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatalf("GetUser после паники: %v", err)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "req-42" {
+		t.Errorf("x-request-id = %v", got)
+	}
+
+	ctx = metadata.AppendToOutgoingContext(userCtx, "x-request-id", "bad id")
+	if _, err := client.GetUser(ctx, &userv1.GetUserRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || len(got[0]) != 32 {
+		t.Errorf("x-request-id = %v, ожидался новый идентификатор", got)
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	notFound := apperrors.NotFound("USER_NOT_FOUND", "пользователь не найден")
+
+	tests := []struct {
+		name   string
+		err    error
+		code   codes.Code
+		reason string
+		msg    string
+	}{
+		{"apperrors", notFound, codes.NotFound, "USER_NOT_FOUND", "пользователь не найден"},
+		{"обернутая", fmt.Errorf("%w: id 5", notFound), codes.NotFound, "USER_NOT_FOUND", "пользователь не найден: id 5"},
+		{"конфликт", apperrors.Conflict("USER_ALREADY_EXISTS", "занято"), codes.AlreadyExists, "USER_ALREADY_EXISTS", "занято"},
+		{"валидация", &validation.Error{Fields: map[string]string{"email": "невалидный email"}}, codes.InvalidArgument, "VALIDATION_ERROR", "Ошибка валидации данных"},
+		{"дедлайн", fmt.Errorf("запрос: %w", context.DeadlineExceeded), codes.DeadlineExceeded, "", "запрос: context deadline exceeded"},
+		{"прочая", errors.New("pq: connection refused"), codes.Internal, "", "внутренняя ошибка сервера"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := toStatus(tt.err)
+			st := status.Convert(err)
+			if st.Code() != tt.code || reason(err) != tt.reason || st.Message() != tt.msg {
+				t.Errorf("toStatus = %v (%s) %q, ожидалось %v (%s) %q", st.Code(), reason(err), st.Message(), tt.code, tt.reason, tt.msg)
+			}
+		})
+	}
+
+	// Ошибки по полям передаются в BadRequest
+	st := status.Convert(toStatus(&validation.Error{Fields: map[string]string{"email": "невалидный email"}}))
+	var fields []string
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				fields = append(fields, v.Field+": "+v.Description)
+			}
+		}
+	}
+	if len(fields) != 1 || fields[0] != "email: невалидный email" {
+		t.Errorf("BadRequest = %v", fields)
+	}
+
+	// Текст непредвиденной ошибки остается для лога
+	if err := toStatus(errors.New("pq: connection refused")); err.Error() != "pq: connection refused" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+func TestListRequestFromProto(t *testing.T) {
+	req, err := listRequestFromProto(&userv1.ListUsersRequest{Statuses: []string{"active"}, SortBy: "email", SortAsc: true})
+	if err != nil {
+		t.Fatalf("listRequestFromProto: %v", err)
+	}
+	if req.Page != 1 || req.PageSize != defaultPageSize || req.SortBy != "email" || req.SortDesc || len(req.Statuses) != 1 {
+		t.Errorf("запрос %+v", req)
+	}
+
+	for _, bad := range []*userv1.ListUsersRequest{
+		{Statuses: []string{"deleted"}},
+		{Statuses: []string{"unknown"}},
+		{SortBy: "password_hash"},
+	} {
+		var validationErr *validation.Error
+		if _, err := listRequestFromProto(bad); !errors.As(err, &validationErr) {
+			t.Errorf("%v: ошибка %v, ожидалась ошибка валидации", bad, err)
+		}
+	}
+}
+
+func TestToProtoRedactsUser(t *testing.T) {
+	policy, err := redact.Parse("user:email=mask,phone=hide")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &userServer{users: services.NewUserService(nil, nil, nil, "", nil, nil, policy)}
+	phone := "+79123456789"
+	user := &models.UserResponse{InternalID: 7, ID: "pub-7", Email: "ivan@example.com", Phone: &phone}
+
+	other := reqctx.WithUser(context.Background(), reqctx.User{ID: 1, Role: auth.RoleUser})
+	msg, err := s.toProto(other, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.GetEmail() != "i***@example.com" || msg.GetPhone() != "" {
+		t.Errorf("чужая запись: %s %q, ожидалась маска и скрытый телефон", msg.GetEmail(), msg.GetPhone())
+	}
+
+	// Свою запись пользователь получает целиком
+	own := reqctx.WithUser(context.Background(), reqctx.User{ID: 7, Role: auth.RoleUser})
+	msg, err = s.toProto(own, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.GetEmail() != "ivan@example.com" || msg.GetPhone() != phone {
+		t.Errorf("своя запись: %s %q, ожидалась без изменений", msg.GetEmail(), msg.GetPhone())
+	}
+}
@@ -0,0 +1,189 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	userv1 "github.com/Soundveyve/fiber-backend/api/proto/user/v1"
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/middleware"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/safe"
+)
+
+// Метаданные вызова (ключи gRPC метаданных всегда в нижнем регистре)
+const (
+	metadataAuthorization = "authorization"
+	metadataRequestID     = "x-request-id"
+	metadataUserAgent     = "user-agent"
+)
+
+// Access - требование метода к вызывающему
+type Access int
+
+const (
+	// AccessAuthenticated - нужен валидный access токен
+	// Нулевое значение: методы без явного требования закрыты
+	AccessAuthenticated Access = iota
+	// AccessPublic - токен не обязателен, но если передан, пользователь опознается
+	AccessPublic
+	// AccessAdmin - access токен с ролью admin
+	AccessAdmin
+)
+
+// methodAccess - требования методов, повторяют middleware HTTP роутов /api/v1/users
+var methodAccess = map[string]Access{
+	userv1.UserService_GetUser_FullMethodName:    AccessAuthenticated,
+	userv1.UserService_ListUsers_FullMethodName:  AccessAdmin,
+	userv1.UserService_CreateUser_FullMethodName: AccessAuthenticated,
+	userv1.UserService_UpdateUser_FullMethodName: AccessAuthenticated,
+	userv1.UserService_DeleteUser_FullMethodName: AccessAdmin,
+}
+
+// Recovery переводит панику обработчика в ответ Internal,
+// как middleware.Recover для HTTP: сервер продолжает работать
+func Recovery() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				safe.Report(ctx, "grpc", r)
+				err = status.Error(codes.Internal, "внутренняя ошибка сервера")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// Logging присваивает вызову request ID и пишет по одной записи лога
+// на вызов: метод, код ответа, время обработки и адрес клиента
+//
+// Идентификатор из метаданных x-request-id сохраняется, если он валиден,
+// иначе генерируется новый; клиент получает его в заголовке ответа.
+// Уровни как у RequestLogger: ошибки сервера - ERROR, ошибки клиента - WARN
+func Logging() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		requestID := firstMetadata(ctx, metadataRequestID)
+		if !middleware.ValidRequestID(requestID) {
+			requestID = middleware.NewRequestID()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(metadataRequestID, requestID))
+
+		client := reqctx.Client{UserAgent: firstMetadata(ctx, metadataUserAgent)}
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			client.IP = hostOf(p.Addr.String())
+		}
+		ctx = reqctx.WithClient(reqctx.WithRequestID(ctx, requestID), client)
+
+		// Пользователя опознает Auth, он вызывается после Logging
+		var user reqctx.User
+		resp, err := handler(withUserSink(ctx, &user), req)
+
+		code := status.Code(err)
+		level := slog.LevelInfo
+		switch code {
+		case codes.OK, codes.Canceled:
+		case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss, codes.Unimplemented:
+			level = slog.LevelError
+		default:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", info.FullMethod),
+			slog.String("code", code.String()),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("ip", client.IP),
+		}
+		if user.ID != 0 {
+			attrs = append(attrs, slog.Int("user_id", user.ID))
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+
+		slog.LogAttrs(ctx, level, "gRPC вызов", attrs...)
+		return resp, err
+	}
+}
+
+// Auth проверяет access токен из метаданных authorization: "Bearer <token>"
+// и роль пользователя по требованию метода, как RequireAuth и RequireRole.
+// Пользователь доступен сервисам через reqctx.UserFromContext
+func Auth(tokens *auth.TokenManager, access map[string]Access) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		required := access[info.FullMethod]
+
+		value, ok := strings.CutPrefix(firstMetadata(ctx, metadataAuthorization), "Bearer ")
+		if !ok || value == "" {
+			if required == AccessPublic {
+				return handler(ctx, req)
+			}
+			return nil, statusError(codes.Unauthenticated, "UNAUTHORIZED", "Требуется аутентификация")
+		}
+
+		claims, err := tokens.Parse(value, auth.TokenTypeAccess)
+		if err != nil {
+			// Как IdentifyUser: публичный метод с невалидным токеном - анонимный вызов
+			if required == AccessPublic {
+				return handler(ctx, req)
+			}
+			return nil, statusError(codes.Unauthenticated, "INVALID_TOKEN", err.Error())
+		}
+
+		// Parse уже проверил, что sub - число
+		userID, _ := claims.UserID()
+		user := reqctx.User{
+			ID:       userID,
+			Username: claims.Username,
+			Role:     claims.Role,
+		}
+		if required == AccessAdmin && user.Role != auth.RoleAdmin {
+			return nil, statusError(codes.PermissionDenied, "FORBIDDEN", "Недостаточно прав")
+		}
+
+		reportUser(ctx, user)
+		return handler(reqctx.WithUser(ctx, user), req)
+	}
+}
+
+// userSinkKey - ключ контекста, через который Auth сообщает Logging пользователя
+type userSinkKey struct{}
+
+// withUserSink возвращает контекст, в который Auth запишет пользователя вызова
+func withUserSink(ctx context.Context, user *reqctx.User) context.Context {
+	return context.WithValue(ctx, userSinkKey{}, user)
+}
+
+// reportUser передает пользователя перехватчику Logging, если он подключен
+func reportUser(ctx context.Context, user reqctx.User) {
+	if sink, ok := ctx.Value(userSinkKey{}).(*reqctx.User); ok {
+		*sink = user
+	}
+}
+
+// firstMetadata возвращает первое значение входящих метаданных key
+func firstMetadata(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// hostOf возвращает адрес без порта
+func hostOf(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
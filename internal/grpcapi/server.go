@@ -0,0 +1,255 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	userv1 "github.com/Soundveyve/fiber-backend/api/proto/user/v1"
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/lifecycle"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+)
+
+// defaultPageSize - размер страницы ListUsers, если клиент его не указал
+const defaultPageSize = 10
+
+// userServer реализует userv1.UserServiceServer поверх services.UserService
+type userServer struct {
+	userv1.UnimplementedUserServiceServer
+	users    *services.UserService
+	accounts *services.AccountService
+}
+
+// GetUser возвращает пользователя по публичному ID
+// Чужой профиль может получить только администратор
+func (s *userServer) GetUser(ctx context.Context, req *userv1.GetUserRequest) (*userv1.User, error) {
+	// 1. Находим внутренний ID по публичному и проверяем права:
+	// полный профиль (email, телефон) видят владелец и администратор
+	id, err := s.users.ResolveUserID(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	caller, _ := reqctx.UserFromContext(ctx)
+	if caller.ID != id && caller.Role != auth.RoleAdmin {
+		return nil, statusError(codes.PermissionDenied, "FORBIDDEN", "Недостаточно прав")
+	}
+
+	// 2. Получаем пользователя из сервиса
+	user, err := s.users.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return s.toProto(ctx, user)
+}
+
+// ListUsers возвращает страницу пользователей
+func (s *userServer) ListUsers(ctx context.Context, req *userv1.ListUsersRequest) (*userv1.ListUsersResponse, error) {
+	// 1. Собираем запрос сервиса; нулевые значения - значения по умолчанию HTTP
+	listReq, err := listRequestFromProto(req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if err := validation.Struct(&listReq); err != nil {
+		return nil, toStatus(err)
+	}
+
+	// 2. Получаем список пользователей
+	list, err := s.users.ListUsers(ctx, listReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	// 3. Переводим ответ
+	users := make([]*userv1.User, 0, len(list.Users))
+	for i := range list.Users {
+		user, err := s.toProto(ctx, &list.Users[i])
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return &userv1.ListUsersResponse{
+		Users:      users,
+		TotalCount: int64(list.TotalCount),
+		Page:       int32(list.Page),
+		PageSize:   int32(list.PageSize),
+		TotalPages: int32(list.TotalPages),
+		HasNext:    list.HasNext,
+		HasPrev:    list.HasPrev,
+	}, nil
+}
+
+// CreateUser создает пользователя и отправляет письмо подтверждения email
+func (s *userServer) CreateUser(ctx context.Context, req *userv1.CreateUserRequest) (*userv1.User, error) {
+	// 1. Проверяем поля по тегам validate, как для JSON тела
+	createReq := models.CreateUserRequest{
+		Email:     req.GetEmail(),
+		Username:  req.GetUsername(),
+		Password:  req.GetPassword(),
+		FirstName: req.GetFirstName(),
+		LastName:  req.GetLastName(),
+		Phone:     req.GetPhone(),
+		Country:   req.GetCountry(),
+	}
+	if err := validation.Struct(&createReq); err != nil {
+		return nil, toStatus(err)
+	}
+
+	// 2. Создаем пользователя
+	// Занятый email не раскрывается, как в HTTP handler: ответ нового
+	// аккаунта и письмо владельцу адреса
+	user, err := s.users.CreateUser(ctx, createReq)
+	var registered *services.EmailRegisteredError
+	if errors.As(err, &registered) {
+		if err := s.accounts.NotifyEmailRegistered(ctx, createReq.Email); err != nil {
+			slog.ErrorContext(ctx, "❌ Ошибка отправки письма о повторной регистрации", "error", err)
+		}
+		return s.toProto(ctx, registered.User)
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	// 3. Письмо подтверждения; ошибка только логируется, как в HTTP handler
+	if err := s.accounts.SendEmailVerification(ctx, user.InternalID); err != nil {
+		slog.ErrorContext(ctx, "❌ Ошибка отправки подтверждения email", "target_user_id", user.InternalID, "error", err)
+	}
+
+	return s.toProto(ctx, user)
+}
+
+// UpdateUser частично обновляет пользователя
+// Изменить чужой профиль может только администратор
+func (s *userServer) UpdateUser(ctx context.Context, req *userv1.UpdateUserRequest) (*userv1.User, error) {
+	// 1. Находим пользователя и проверяем права
+	id, err := s.users.ResolveUserID(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	caller, _ := reqctx.UserFromContext(ctx)
+	if caller.ID != id && caller.Role != auth.RoleAdmin {
+		return nil, statusError(codes.PermissionDenied, "FORBIDDEN", "Недостаточно прав")
+	}
+
+	// 2. Незаданные optional поля остаются nil и не меняются
+	updateReq := models.UpdateUserRequest{
+		Email:     req.Email,
+		Username:  req.Username,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Phone:     req.Phone,
+		Country:   req.Country,
+	}
+	if err := validation.Struct(&updateReq); err != nil {
+		return nil, toStatus(err)
+	}
+
+	// 3. Обновляем пользователя
+	user, err := s.users.UpdateUser(ctx, id, updateReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return s.toProto(ctx, user)
+}
+
+// DeleteUser мягко удаляет пользователя
+func (s *userServer) DeleteUser(ctx context.Context, req *userv1.DeleteUserRequest) (*emptypb.Empty, error) {
+	id, err := s.users.ResolveUserID(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.users.DeleteUser(ctx, id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// listRequestFromProto переводит запрос списка в модель сервиса
+// Неизвестные состояния и поля сортировки - ошибка валидации по полю
+func listRequestFromProto(req *userv1.ListUsersRequest) (models.ListUsersRequest, error) {
+	listReq := models.ListUsersRequest{
+		Page:     int(req.GetPage()),
+		PageSize: int(req.GetPageSize()),
+		Search:   req.GetSearch(),
+		SortBy:   models.UserSortCreatedAt,
+		SortDesc: !req.GetSortAsc(),
+	}
+	if listReq.Page == 0 {
+		listReq.Page = 1
+	}
+	if listReq.PageSize == 0 {
+		listReq.PageSize = defaultPageSize
+	}
+
+	for _, name := range req.GetStatuses() {
+		status, err := lifecycle.Parse(name)
+		if err != nil || status == lifecycle.StatusDeleted {
+			return listReq, &validation.Error{Fields: map[string]string{
+				"statuses": fmt.Sprintf("неизвестное состояние аккаунта: %s", name),
+			}}
+		}
+		listReq.Statuses = append(listReq.Statuses, status)
+	}
+
+	if sortBy := req.GetSortBy(); sortBy != "" {
+		if !slices.Contains(models.UserSortFields, models.UserSortField(sortBy)) {
+			return listReq, &validation.Error{Fields: map[string]string{
+				"sort_by": fmt.Sprintf("сортировка по полю %s недоступна", sortBy),
+			}}
+		}
+		listReq.SortBy = models.UserSortField(sortBy)
+	}
+	return listReq, nil
+}
+
+// toProto скрывает поля пользователя по политике RESPONSE_REDACTION
+// и переводит его в сообщение: ответы gRPC не проходят через
+// middleware.Redact, поэтому политика применяется здесь
+func (s *userServer) toProto(ctx context.Context, user *models.UserResponse) (*userv1.User, error) {
+	redacted, err := s.users.RedactUser(ctx, user)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return userToProto(redacted), nil
+}
+
+// userToProto переводит пользователя из ответа сервиса в сообщение
+func userToProto(user *models.UserResponse) *userv1.User {
+	return &userv1.User{
+		Id:              user.ID,
+		Email:           user.Email,
+		Username:        user.Username,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Phone:           user.Phone,
+		Country:         user.Country,
+		Status:          user.Status,
+		IsActive:        user.IsActive,
+		Role:            user.Role,
+		CreatedAt:       timestamppb.New(user.CreatedAt.Time),
+		UpdatedAt:       timestamppb.New(user.UpdatedAt.Time),
+		LastLoginAt:     timestampOrNil(user.LastLoginAt),
+		LoginCount:      int64(user.LoginCount),
+		EmailVerifiedAt: timestampOrNil(user.EmailVerifiedAt),
+		AvatarUrl:       user.AvatarURL,
+	}
+}
+
+// timestampOrNil переводит необязательное время, nil - поле не задано
+func timestampOrNil(t *utc.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(t.Time)
+}
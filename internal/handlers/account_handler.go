@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AccountHandler обрабатывает подтверждение email и сброс пароля (/api/v1/auth)
+type AccountHandler struct {
+	accountService *services.AccountService
+}
+
+// NewAccountHandler создает новый обработчик подтверждения email и сброса пароля
+func NewAccountHandler(accountService *services.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// VerifyEmail обрабатывает POST /api/v1/auth/verify-email
+// Подтверждает email по токену из письма и возвращает пользователя
+func (h *AccountHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req models.VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	user, err := h.accountService.VerifyEmail(c.UserContext(), req.Token)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(user)
+}
+
+// ResendVerification обрабатывает POST /api/v1/auth/resend-verification
+// Всегда отвечает 202, даже если email не зарегистрирован
+func (h *AccountHandler) ResendVerification(c *fiber.Ctx) error {
+	var req models.EmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	if err := h.accountService.ResendVerification(c.UserContext(), req.Email); err != nil {
+		return err
+	}
+
+	// 202 Accepted - письмо (если адрес известен) будет отправлено в фоне
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// ForgotPassword обрабатывает POST /api/v1/auth/forgot-password
+// Всегда отвечает 202, даже если email не зарегистрирован
+func (h *AccountHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req models.EmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	if err := h.accountService.ForgotPassword(c.UserContext(), req.Email); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// ResetPassword обрабатывает POST /api/v1/auth/reset-password
+// Задает новый пароль по токену из письма и завершает все сессии пользователя
+func (h *AccountHandler) ResetPassword(c *fiber.Ctx) error {
+	var req models.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	if err := h.accountService.ResetPassword(c.UserContext(), req.Token, req.Password); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/Soundveyve/fiber-backend/internal/importer"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/operations"
+	"github.com/Soundveyve/fiber-backend/internal/query"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/sqlfilter"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminHandler обрабатывает административные HTTP запросы (/admin/v1)
+// Эндпоинты предназначены для панелей поддержки и внутренних инструментов
+type AdminHandler struct {
+	userService *services.UserService
+	userSearch  *services.UserSearchService
+	operations  *operations.Manager // Prefer: respond-async, nil - только синхронно
+}
+
+// NewAdminHandler создает новый обработчик административных запросов
+func NewAdminHandler(userService *services.UserService, userSearch *services.UserSearchService, manager *operations.Manager) *AdminHandler {
+	return &AdminHandler{
+		userService: userService,
+		userSearch:  userSearch,
+		operations:  manager,
+	}
+}
+
+// SearchUsers обрабатывает GET /admin/v1/users/search
+// Расширенный поиск для панелей поддержки: фильтры filter[поле][оператор]
+// по любому сочетанию полей services.UserSearchSchema и сортировка
+// по нескольким полям (sort=-last_login_at,email), см. пакет sqlfilter
+func (h *AdminHandler) SearchUsers(c *fiber.Ctx) error {
+	// 1. Парсим пагинацию
+	page, err := query.ParsePage(c, query.PageOptions{
+		DefaultSize: 50,
+		MaxSize:     100,
+	})
+	if err != nil {
+		return listQueryError(err, "Невалидные параметры запроса")
+	}
+
+	// 2. Фильтры и сортировка по белому списку полей
+	values := make(url.Values)
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		values.Add(string(key), string(value))
+	})
+	spec, err := sqlfilter.Parse(services.UserSearchSchema, values)
+	if err != nil {
+		return listQueryError(err, err.Error())
+	}
+
+	// 3. Ищем пользователей
+	response, err := h.userSearch.Search(c.UserContext(), spec, page)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+// GetUserStats обрабатывает GET /admin/v1/users/:id/stats
+// Возвращает агрегированную статистику активности пользователя
+func (h *AdminHandler) GetUserStats(c *fiber.Ctx) error {
+	// 1. Получаем ID из URL
+	id, err := h.userService.ResolveUserID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	// 2. Собираем статистику
+	stats, err := h.userService.GetUserStats(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	// 3. Возвращаем статистику
+	return c.JSON(stats)
+}
+
+// ImportUsers обрабатывает POST /admin/v1/users/import
+// Импортирует пользователей из выгрузки внешней системы
+//
+// Ожидает multipart/form-data:
+//   - file    - CSV (с заголовком) или JSON массив объектов
+//   - format  - csv или json (по умолчанию определяется по расширению файла)
+//   - mapping - JSON схема сопоставления полей, см. importer.Mapping
+//     (по умолчанию поля сопоставляются по одноименным колонкам)
+//
+// С Prefer: respond-async файл разбирается сразу, а записи импортирует
+// операция (202, GET /admin/v1/operations/:id)
+func (h *AdminHandler) ImportUsers(c *fiber.Ctx) error {
+	// 1. Получаем файл
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Не передан файл для импорта",
+			Code:  "IMPORT_FILE_REQUIRED",
+		})
+	}
+
+	format := c.FormValue("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+
+	// 2. Разбираем схему сопоставления полей
+	mapping := importer.DefaultMapping()
+	if raw := c.FormValue("mapping"); raw != "" {
+		mapping = importer.Mapping{}
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "Невалидная схема импорта",
+				Code:  "INVALID_IMPORT_MAPPING",
+			})
+		}
+	}
+
+	// 3. Читаем записи
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Не удалось прочитать файл",
+			Code:  "IMPORT_FILE_UNREADABLE",
+		})
+	}
+	defer file.Close()
+
+	records, rowErrors, err := importer.Decode(file, format, mapping)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_IMPORT_DATA",
+		})
+	}
+
+	// 4. Импортируем и возвращаем отчет по записям
+	if preferAsync(c, h.operations) {
+		return startOperation(c, h.operations, operations.KindImportUsers, services.ImportInput{Records: records, Errors: rowErrors})
+	}
+	return c.JSON(h.userService.ImportUsers(c.UserContext(), records, rowErrors))
+}
+
+// MergeUsers обрабатывает POST /admin/v1/users/:id/merge
+// Сливает дубликат (duplicate_id в теле) в основной аккаунт :id
+// С dry_run = true возвращает результат без сохранения изменений
+// С Prefer: respond-async сливает в операции (202, GET /admin/v1/operations/:id)
+func (h *AdminHandler) MergeUsers(c *fiber.Ctx) error {
+	// 1. Получаем ID основного аккаунта из URL
+	id, err := h.userService.ResolveUserID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	// 2. Парсим тело запроса
+	var req models.MergeUsersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	duplicateID, err := h.userService.ResolveUserID(c.UserContext(), req.DuplicateID)
+	if err != nil {
+		return err
+	}
+
+	// 3. Выполняем слияние
+	if preferAsync(c, h.operations) {
+		return startOperation(c, h.operations, operations.KindMergeUsers, services.MergeInput{
+			PrimaryID:   id,
+			DuplicateID: duplicateID,
+			DryRun:      req.DryRun,
+		})
+	}
+	result, err := h.userService.MergeUsers(c.UserContext(), id, duplicateID, req.DryRun)
+	if err != nil {
+		return err
+	}
+
+	// 4. Возвращаем основной аккаунт после слияния
+	return c.JSON(result)
+}
+
+// HardDeleteUser обрабатывает DELETE /admin/v1/users/:id
+// Физически удаляет пользователя, включая мягко удаленного, вместе со
+// связанными записями. Необратимо, поэтому требует sudo режим
+func (h *AdminHandler) HardDeleteUser(c *fiber.Ctx) error {
+	id, err := h.userService.ResolveUserID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	if err := h.userService.HardDeleteUser(c.UserContext(), id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
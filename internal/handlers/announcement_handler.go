@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/Soundveyve/fiber-backend/internal/httpctx"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AnnouncementHandler обрабатывает HTTP запросы объявлений
+// Публикация - административная (/admin/v1), чтение - публичное (/api/v1)
+type AnnouncementHandler struct {
+	announcementService *services.AnnouncementService
+}
+
+// NewAnnouncementHandler создает новый обработчик объявлений
+func NewAnnouncementHandler(announcementService *services.AnnouncementService) *AnnouncementHandler {
+	return &AnnouncementHandler{
+		announcementService: announcementService,
+	}
+}
+
+// CreateAnnouncement обрабатывает POST /admin/v1/announcements
+// Публикует объявление сразу или по расписанию (starts_at/ends_at)
+func (h *AnnouncementHandler) CreateAnnouncement(c httpctx.Ctx) error {
+	// 1. Парсим тело запроса
+	var req models.CreateAnnouncementRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	// 2. Создаем объявление
+	// Разметка в тексте при SANITIZE_POLICY=reject - 422 через ErrorHandler
+	announcement, err := h.announcementService.CreateAnnouncement(c.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	// 3. Возвращаем созданное объявление
+	return c.Status(fiber.StatusCreated).JSON(announcement)
+}
+
+// ListActiveAnnouncements обрабатывает GET /api/v1/announcements/active
+// Возвращает объявления, которые клиент должен показать сейчас
+// Клиент передает свою платформу: ?audience=web (ios, android)
+func (h *AnnouncementHandler) ListActiveAnnouncements(c httpctx.Ctx) error {
+	announcements, err := h.announcementService.ListActiveAnnouncements(c.Context(), c.Query("audience"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAnnouncement) {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "INVALID_QUERY_PARAMS",
+			})
+		}
+		return err
+	}
+
+	return c.JSON(models.ListAnnouncementsResponse{
+		Announcements: announcements,
+	})
+}
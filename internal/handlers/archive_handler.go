@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/archive"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/query"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ArchiveHandler обрабатывает чтение архива журналов (/admin/v1/archives)
+type ArchiveHandler struct {
+	archiver *archive.Archiver
+}
+
+// NewArchiveHandler создает новый обработчик архива
+func NewArchiveHandler(archiver *archive.Archiver) *ArchiveHandler {
+	return &ArchiveHandler{
+		archiver: archiver,
+	}
+}
+
+// ListArchives обрабатывает GET /admin/v1/archives
+// Фильтры: source, created_after, created_before - архивы, период
+// записей которых пересекается с заданным. От старых к новым
+func (h *ArchiveHandler) ListArchives(c *fiber.Ctx) error {
+	// 1. Парсим пагинацию
+	page, err := query.ParsePage(c, query.PageOptions{
+		DefaultSize: 50,
+		MaxSize:     100,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидные параметры запроса",
+			Code:  "INVALID_QUERY_PARAMS",
+		})
+	}
+	req := models.ListArchivesRequest{
+		Page:     page.Number,
+		PageSize: page.Size,
+		Source:   c.Query("source"),
+	}
+
+	// 2. Период: YYYY-MM-DD (2024-01-31) или RFC3339 время
+	dateFilters := []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_after", &req.CreatedAfter},
+		{"created_before", &req.CreatedBefore},
+	}
+	for _, f := range dateFilters {
+		if *f.dst, err = query.Filter(c, f.name, query.ParseDateOrTime); err != nil {
+			return queryParamError(c, err, fmt.Sprintf("Невалидный параметр %s, ожидается YYYY-MM-DD или RFC3339", f.name))
+		}
+	}
+	if req.CreatedAfter != nil && req.CreatedBefore != nil && !req.CreatedAfter.Before(*req.CreatedBefore) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "created_after должен быть раньше created_before",
+			Code:  "INVALID_QUERY_PARAMS",
+		})
+	}
+
+	// 3. Получаем страницу архивов
+	resp, err := h.archiver.List(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+// ListEntries обрабатывает GET /admin/v1/archives/:id/entries
+// Фильтры: created_after, created_before и filter[поле]=значение по
+// строковым полям записи (filter[action]=user.update)
+func (h *ArchiveHandler) ListEntries(c *fiber.Ctx) error {
+	// 1. ID архива
+	id, err := publicid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный ID архива",
+			Code:  "INVALID_ARCHIVE_ID",
+		})
+	}
+
+	// 2. Парсим пагинацию
+	page, err := query.ParsePage(c, query.PageOptions{
+		DefaultSize: 50,
+		MaxSize:     100,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидные параметры запроса",
+			Code:  "INVALID_QUERY_PARAMS",
+		})
+	}
+	req := models.ListArchiveEntriesRequest{
+		ArchiveID: int64(id),
+		Page:      page.Number,
+		PageSize:  page.Size,
+		Fields:    map[string]string{},
+	}
+
+	// 3. Фильтры по полям записи: filter[поле]=значение
+	for key, value := range c.Queries() {
+		name, ok := strings.CutPrefix(key, "filter[")
+		if !ok {
+			continue
+		}
+		name, ok = strings.CutSuffix(name, "]")
+		if !ok || name == "" || strings.ContainsAny(name, "[]") {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: fmt.Sprintf("Невалидный фильтр %s, ожидается filter[поле]=значение", key),
+				Code:  "INVALID_QUERY_PARAMS",
+			})
+		}
+		req.Fields[name] = value
+	}
+
+	// 4. Период: YYYY-MM-DD (2024-01-31) или RFC3339 время
+	dateFilters := []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_after", &req.CreatedAfter},
+		{"created_before", &req.CreatedBefore},
+	}
+	for _, f := range dateFilters {
+		if *f.dst, err = query.Filter(c, f.name, query.ParseDateOrTime); err != nil {
+			return queryParamError(c, err, fmt.Sprintf("Невалидный параметр %s, ожидается YYYY-MM-DD или RFC3339", f.name))
+		}
+	}
+	if req.CreatedAfter != nil && req.CreatedBefore != nil && !req.CreatedAfter.Before(*req.CreatedBefore) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "created_after должен быть раньше created_before",
+			Code:  "INVALID_QUERY_PARAMS",
+		})
+	}
+
+	// 5. Читаем страницу записей из файла архива
+	resp, err := h.archiver.Entries(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
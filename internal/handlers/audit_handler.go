@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/audit"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/query"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditHandler обрабатывает чтение журнала аудита (/api/v1/audit-logs)
+type AuditHandler struct {
+	auditLog    *audit.Logger
+	userService *services.UserService
+}
+
+// NewAuditHandler создает новый обработчик журнала аудита
+func NewAuditHandler(auditLog *audit.Logger, userService *services.UserService) *AuditHandler {
+	return &AuditHandler{
+		auditLog:    auditLog,
+		userService: userService,
+	}
+}
+
+// ListAuditLogs обрабатывает GET /api/v1/audit-logs
+// Фильтры: actor_id, target_id (публичные ID пользователей), action,
+// created_after, created_before. Записи от новых к старым
+func (h *AuditHandler) ListAuditLogs(c *fiber.Ctx) error {
+	// 1. Парсим пагинацию
+	page, err := query.ParsePage(c, query.PageOptions{
+		DefaultSize: 50,
+		MaxSize:     100,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидные параметры запроса",
+			Code:  "INVALID_QUERY_PARAMS",
+		})
+	}
+	req := models.ListAuditLogsRequest{
+		Page:     page.Number,
+		PageSize: page.Size,
+	}
+
+	// 2. Фильтры по автору и пользователю, над которым выполнено действие
+	userFilters := []struct {
+		name string
+		dst  **int
+	}{
+		{"actor_id", &req.ActorID},
+		{"target_id", &req.TargetUserID},
+	}
+	for _, f := range userFilters {
+		publicID := c.Query(f.name)
+		if publicID == "" {
+			continue
+		}
+		// Удаленные пользователи не находятся по публичному ID,
+		// их записи доступны без фильтра
+		id, err := h.userService.ResolveUserID(c.UserContext(), publicID)
+		if err != nil {
+			return err
+		}
+		*f.dst = &id
+	}
+
+	// 3. Фильтр по действию
+	req.Action = c.Query("action")
+	if req.Action != "" && !slices.Contains(audit.Actions, req.Action) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный параметр action, допустимые значения: " + strings.Join(audit.Actions, ", "),
+			Code:  "INVALID_QUERY_PARAMS",
+		})
+	}
+
+	// 4. Период: YYYY-MM-DD (2024-01-31) или RFC3339 время
+	dateFilters := []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_after", &req.CreatedAfter},
+		{"created_before", &req.CreatedBefore},
+	}
+	for _, f := range dateFilters {
+		if *f.dst, err = query.Filter(c, f.name, query.ParseDateOrTime); err != nil {
+			return queryParamError(c, err, fmt.Sprintf("Невалидный параметр %s, ожидается YYYY-MM-DD или RFC3339", f.name))
+		}
+	}
+	if req.CreatedAfter != nil && req.CreatedBefore != nil && !req.CreatedAfter.Before(*req.CreatedBefore) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "created_after должен быть раньше created_before",
+			Code:  "INVALID_QUERY_PARAMS",
+		})
+	}
+
+	// 5. Получаем страницу журнала
+	resp, err := h.auditLog.List(c.UserContext(), req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "LIST_AUDIT_LOGS_ERROR",
+		})
+	}
+
+	return c.JSON(resp)
+}
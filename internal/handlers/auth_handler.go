@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuthHandler обрабатывает вход, обновление токенов и выход (/api/v1/auth)
+type AuthHandler struct {
+	authService *services.AuthService
+}
+
+// NewAuthHandler создает новый обработчик аутентификации
+func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+// Login обрабатывает POST /api/v1/auth/login
+// Проверяет email и пароль и выдает access и refresh токены
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	// 1. Парсим тело запроса
+	var req models.LoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	// 2. Проверяем учетные данные и выдаем токены
+	tokens, err := h.authService.Login(c.UserContext(), req.Email, req.Password)
+	if err != nil {
+		return err
+	}
+
+	// 3. Возвращаем токены
+	return c.JSON(tokens)
+}
+
+// CompleteTwoFactor обрабатывает POST /api/v1/auth/2fa
+// Второй шаг входа с 2FA: токен two_factor из ответа на login передается
+// в Authorization, код из приложения или код восстановления - в теле
+func (h *AuthHandler) CompleteTwoFactor(c *fiber.Ctx) error {
+	userID, ok := reqctx.PendingTwoFactor(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// 1. Парсим тело запроса
+	var req models.TwoFactorLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	// 2. Проверяем код и выдаем токены
+	tokens, err := h.authService.CompleteTwoFactor(c.UserContext(), userID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(tokens)
+}
+
+// Refresh обрабатывает POST /api/v1/auth/refresh
+// Обменивает refresh токен на новую пару токенов
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req models.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	tokens, err := h.authService.Refresh(c.UserContext(), req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(tokens)
+}
+
+// Logout обрабатывает POST /api/v1/auth/logout
+// Отзывает refresh токен. Access токен истечет сам через несколько минут
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var req models.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	if err := h.authService.Logout(c.UserContext(), req.RefreshToken); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Sudo обрабатывает POST /api/v1/auth/sudo
+// Повторная проверка пароля текущего пользователя, выдает короткоживущий
+// sudo токен для разрушительных действий (физическое удаление, смена роли)
+func (h *AuthHandler) Sudo(c *fiber.Ctx) error {
+	user, ok := reqctx.GetUser(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	var req models.SudoRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	sudo, err := h.authService.Sudo(c.UserContext(), user.ID, req.Password)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(sudo)
+}
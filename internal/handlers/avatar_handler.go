@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/avatar"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// avatarFormField - поле multipart формы с файлом аватара
+const avatarFormField = "file"
+
+// Кеширование аватара клиентами
+const (
+	// Ссылка с актуальной версией (?v= из avatar_url) всегда отдает один файл
+	avatarCacheVersioned = "public, max-age=31536000, immutable"
+
+	// Без версии файл может смениться: клиент перепроверяет его по ETag
+	avatarCacheUnversioned = "public, no-cache"
+)
+
+// AvatarHandler обрабатывает загрузку и выдачу аватаров (/api/v1/users/:id/avatar
+// и /api/v1/profiles/:username/avatar)
+type AvatarHandler struct {
+	avatarService  *services.AvatarService
+	userService    *services.UserService
+	profileService *services.ProfileService
+}
+
+// NewAvatarHandler создает новый обработчик аватаров
+func NewAvatarHandler(avatarService *services.AvatarService, userService *services.UserService, profileService *services.ProfileService) *AvatarHandler {
+	return &AvatarHandler{
+		avatarService:  avatarService,
+		userService:    userService,
+		profileService: profileService,
+	}
+}
+
+// UploadAvatar обрабатывает POST /api/v1/users/:id/avatar
+// Принимает изображение в поле file multipart формы; менять аватар
+// может сам пользователь или администратор
+func (h *AvatarHandler) UploadAvatar(c *fiber.Ctx) error {
+	// 1. Получаем ID из URL
+	id, err := h.userService.ResolveUserID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	// 2. Проверяем права
+	user, ok := reqctx.GetUser(c)
+	if !ok {
+		return unauthorized(c)
+	}
+	if user.ID != id && user.Role != auth.RoleAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{
+			Error: "Можно менять только свой аватар",
+			Code:  "FORBIDDEN",
+		})
+	}
+
+	// 3. Достаем файл из формы
+	file, err := c.FormFile(avatarFormField)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Ожидается multipart/form-data с файлом в поле " + avatarFormField,
+			Code:  "AVATAR_FILE_REQUIRED",
+		})
+	}
+	if file.Size > h.avatarService.MaxSize() {
+		return avatar.ErrTooLarge
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "UPLOAD_AVATAR_ERROR",
+		})
+	}
+	defer src.Close()
+
+	// 4. Обрабатываем и сохраняем
+	updated, err := h.avatarService.UploadAvatar(c.UserContext(), id, src)
+	if err != nil {
+		return err
+	}
+
+	// 5. Возвращаем пользователя с новым avatar_url
+	return c.JSON(updated)
+}
+
+// GetAvatar обрабатывает GET /api/v1/users/:id/avatar
+// Отдает аватар без аутентификации, как и профиль пользователя
+func (h *AvatarHandler) GetAvatar(c *fiber.Ctx) error {
+	// 1. Получаем ID из URL
+	id, err := h.userService.ResolveUserID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	// 2. Отдаем файл
+	return h.sendAvatar(c, id)
+}
+
+// GetProfileAvatar обрабатывает GET /api/v1/profiles/:username/avatar
+// Ссылка из публичного профиля: строится по username, а не по публичному ID,
+// и отдает аватар, только если пользователь разрешил его показывать
+func (h *AvatarHandler) GetProfileAvatar(c *fiber.Ctx) error {
+	// 1. Находим владельца публичного профиля
+	id, err := h.profileService.PublicAvatarOwner(c.UserContext(), c.Params("username"))
+	if err != nil {
+		return err
+	}
+
+	// 2. Отдаем файл
+	return h.sendAvatar(c, id)
+}
+
+// sendAvatar отдает аватар пользователя с заголовками кеширования
+func (h *AvatarHandler) sendAvatar(c *fiber.Ctx, id int) error {
+	// 1. Открываем файл
+	reader, info, err := h.avatarService.GetAvatar(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	// 2. Заголовки кеширования: версия файла - случайная часть его ключа
+	version := services.AvatarVersion(info.Key)
+	etag := `"` + version + `"`
+	c.Set(fiber.HeaderETag, etag)
+	if c.Query("v") == version {
+		c.Set(fiber.HeaderCacheControl, avatarCacheVersioned)
+	} else {
+		c.Set(fiber.HeaderCacheControl, avatarCacheUnversioned)
+	}
+
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		_ = reader.Close()
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	// Fiber закроет reader после отправки, если он реализует io.Closer
+	c.Set(fiber.HeaderContentType, info.ContentType)
+	return c.SendStream(reader, int(info.Size))
+}
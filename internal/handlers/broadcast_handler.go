@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// BroadcastHandler обрабатывает массовые рассылки администраторов (/admin/v1/broadcasts)
+type BroadcastHandler struct {
+	broadcastService *services.BroadcastService
+}
+
+// NewBroadcastHandler создает новый обработчик рассылок
+func NewBroadcastHandler(broadcastService *services.BroadcastService) *BroadcastHandler {
+	return &BroadcastHandler{
+		broadcastService: broadcastService,
+	}
+}
+
+// CreateBroadcast обрабатывает POST /admin/v1/broadcasts
+// Создает рассылку и сразу возвращает ее со статусом pending
+func (h *BroadcastHandler) CreateBroadcast(c *fiber.Ctx) error {
+	// 1. Парсим тело запроса
+	var req models.CreateBroadcastRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	// 2. Создаем рассылку от имени текущего администратора
+	user, _ := reqctx.GetUser(c)
+	broadcast, err := h.broadcastService.CreateBroadcast(c.UserContext(), req, user.ID)
+	if err != nil {
+		return err
+	}
+
+	// 3. 202 Accepted - письма отправит воркер
+	// Location указывает, где проверять прогресс
+	c.Location("/admin/v1/broadcasts/" + broadcast.ID.String())
+	return c.Status(fiber.StatusAccepted).JSON(broadcast)
+}
+
+// GetBroadcast обрабатывает GET /admin/v1/broadcasts/:id
+// Возвращает статус и прогресс рассылки
+func (h *BroadcastHandler) GetBroadcast(c *fiber.Ctx) error {
+	id, err := publicid.Parse(c.Params("id"))
+	if err != nil {
+		return invalidBroadcastID(c)
+	}
+
+	broadcast, err := h.broadcastService.GetBroadcast(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(broadcast)
+}
+
+// CancelBroadcast обрабатывает POST /admin/v1/broadcasts/:id/cancel
+// Останавливает незавершенную рассылку; отправленные письма не отзываются
+func (h *BroadcastHandler) CancelBroadcast(c *fiber.Ctx) error {
+	id, err := publicid.Parse(c.Params("id"))
+	if err != nil {
+		return invalidBroadcastID(c)
+	}
+
+	broadcast, err := h.broadcastService.CancelBroadcast(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(broadcast)
+}
+
+// invalidBroadcastID отвечает 400 на нечисловой ID рассылки
+func invalidBroadcastID(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+		Error: "Невалидный ID рассылки",
+		Code:  "INVALID_BROADCAST_ID",
+	})
+}
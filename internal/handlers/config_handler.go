@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ConfigHandler обрабатывает просмотр действующей конфигурации (/admin/v1/config)
+type ConfigHandler struct {
+	current *config.Current
+}
+
+// NewConfigHandler создает новый обработчик конфигурации
+func NewConfigHandler(current *config.Current) *ConfigHandler {
+	return &ConfigHandler{
+		current: current,
+	}
+}
+
+// GetConfig обрабатывает GET /admin/v1/config
+// Возвращает конфигурацию инстанса, который обработал запрос, с учетом
+// перезагрузок по SIGHUP. Секреты скрыты
+func (h *ConfigHandler) GetConfig(c *fiber.Ctx) error {
+	cfg := h.current.Get()
+
+	restartRequired := h.current.RestartRequired()
+	if restartRequired == nil {
+		restartRequired = []string{}
+	}
+
+	return c.JSON(models.ConfigResponse{
+		Config:          cfg.Redacted(),
+		Sources:         cfg.Origins(),
+		File:            cfg.File(),
+		LoadedAt:        utc.From(cfg.LoadedAt()),
+		RestartRequired: restartRequired,
+	})
+}
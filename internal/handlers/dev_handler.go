@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/devfake"
+	"github.com/gofiber/fiber/v2"
+)
+
+// DevHandler обрабатывает служебные запросы локальной разработки (/dev)
+// Роуты регистрируются только при DEV_FAKE_SERVICES=true
+type DevHandler struct {
+	outbox *devfake.Outbox
+}
+
+// NewDevHandler создает обработчик dev эндпоинтов
+func NewDevHandler(outbox *devfake.Outbox) *DevHandler {
+	return &DevHandler{
+		outbox: outbox,
+	}
+}
+
+// ListOutbox обрабатывает GET /dev/outbox
+// Возвращает сообщения, перехваченные фейковыми сервисами
+// Фильтр по каналу: /dev/outbox?channel=email
+func (h *DevHandler) ListOutbox(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"messages": h.outbox.List(c.Query("channel")),
+	})
+}
+
+// ClearOutbox обрабатывает DELETE /dev/outbox
+// Очищает перехваченные сообщения, например между ручными проверками
+func (h *DevHandler) ClearOutbox(c *fiber.Ctx) error {
+	h.outbox.Reset()
+	return c.SendStatus(fiber.StatusNoContent)
+}
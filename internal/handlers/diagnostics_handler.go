@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"bytes"
+	"log/slog"
+
+	"github.com/Soundveyve/fiber-backend/internal/diagnostics"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// DiagnosticsHandler обрабатывает сборку пакета диагностики (/admin/v1/diagnostics)
+type DiagnosticsHandler struct {
+	collector *diagnostics.Collector
+}
+
+// NewDiagnosticsHandler создает новый обработчик пакета диагностики
+func NewDiagnosticsHandler(collector *diagnostics.Collector) *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		collector: collector,
+	}
+}
+
+// CreateBundle обрабатывает POST /admin/v1/diagnostics
+// Отдает zip архив диагностики инстанса, который обработал запрос:
+// стеки горутин, последние ошибки, конфигурацию, пул БД и очереди
+func (h *DiagnosticsHandler) CreateBundle(c *fiber.Ctx) error {
+	// 1. Собираем пакет целиком: при ошибке клиент получает JSON, а не
+	// обрезанный архив
+	var buf bytes.Buffer
+	manifest, err := h.collector.Write(c.UserContext(), &buf)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "DIAGNOSTICS_ERROR",
+		})
+	}
+	slog.InfoContext(c.UserContext(), "🩺 Собран пакет диагностики",
+		"files", manifest.Files,
+		"failed", len(manifest.Errors),
+		"bytes", buf.Len(),
+	)
+
+	// 2. Отдаем файлом; пакет содержит конфигурацию и не должен кешироваться
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Attachment(manifest.FileName())
+	c.Set(fiber.HeaderContentType, "application/zip")
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// DigestHandler обрабатывает подписку текущего пользователя
+// на сводку активности аккаунта (/api/v1/users/me/digest)
+type DigestHandler struct {
+	digestService *services.DigestService
+}
+
+// NewDigestHandler создает новый обработчик подписки на сводку
+func NewDigestHandler(digestService *services.DigestService) *DigestHandler {
+	return &DigestHandler{
+		digestService: digestService,
+	}
+}
+
+// GetDigest обрабатывает GET /api/v1/users/me/digest
+// Возвращает состояние подписки
+func (h *DigestHandler) GetDigest(c *fiber.Ctx) error {
+	user, ok := reqctx.GetUser(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	subscription, err := h.digestService.GetSubscription(c.UserContext(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(subscription)
+}
+
+// Subscribe обрабатывает PUT /api/v1/users/me/digest
+// Включает сводку; повторный запрос ничего не меняет
+func (h *DigestHandler) Subscribe(c *fiber.Ctx) error {
+	user, ok := reqctx.GetUser(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	subscription, err := h.digestService.Subscribe(c.UserContext(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(subscription)
+}
+
+// Unsubscribe обрабатывает DELETE /api/v1/users/me/digest
+// Отключает сводку; без подписки тоже отвечает 204
+func (h *DigestHandler) Unsubscribe(c *fiber.Ctx) error {
+	user, ok := reqctx.GetUser(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	if err := h.digestService.Unsubscribe(c.UserContext(), user.ID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
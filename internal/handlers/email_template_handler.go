@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// EmailTemplateHandler обрабатывает проверку шаблонов писем (/admin/v1/email-templates)
+type EmailTemplateHandler struct {
+	emailTemplateService *services.EmailTemplateService
+}
+
+// NewEmailTemplateHandler создает новый обработчик шаблонов писем
+func NewEmailTemplateHandler(emailTemplateService *services.EmailTemplateService) *EmailTemplateHandler {
+	return &EmailTemplateHandler{
+		emailTemplateService: emailTemplateService,
+	}
+}
+
+// Preview обрабатывает GET /admin/v1/email-templates/:name/preview
+// Возвращает тему и текст шаблона, заполненного примером данных
+func (h *EmailTemplateHandler) Preview(c *fiber.Ctx) error {
+	preview, err := h.emailTemplateService.Preview(c.Params("name"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(preview)
+}
+
+// TestSend обрабатывает POST /admin/v1/email-templates/:name/test-send
+// Отправляет шаблон с примером данных на указанный адрес
+// или на email текущего администратора
+func (h *EmailTemplateHandler) TestSend(c *fiber.Ctx) error {
+	// 1. Парсим тело запроса; пустое тело - отправка себе
+	var req models.TestSendEmailTemplateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "Невалидный JSON",
+				Code:  "INVALID_JSON",
+			})
+		}
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	// 2. Отправляем письмо и ждем ответа SMTP сервера
+	user, _ := reqctx.GetUser(c)
+	sent, err := h.emailTemplateService.TestSend(c.UserContext(), c.Params("name"), req.To, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(sent)
+}
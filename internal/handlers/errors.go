@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+	"github.com/Soundveyve/fiber-backend/internal/middleware"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/safe"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// kindStatus - HTTP статус для категории ошибки apperrors
+var kindStatus = []struct {
+	kind   error
+	status int
+}{
+	{apperrors.ErrInvalid, fiber.StatusBadRequest},
+	{apperrors.ErrUnauthorized, fiber.StatusUnauthorized},
+	{apperrors.ErrForbidden, fiber.StatusForbidden},
+	{apperrors.ErrNotFound, fiber.StatusNotFound},
+	{apperrors.ErrConflict, fiber.StatusConflict},
+	{apperrors.ErrGone, fiber.StatusGone},
+	{apperrors.ErrTooLarge, fiber.StatusRequestEntityTooLarge},
+	{apperrors.ErrUnsupported, fiber.StatusUnsupportedMediaType},
+	{apperrors.ErrValidation, fiber.StatusUnprocessableEntity},
+}
+
+// ErrorHandler - ErrorHandler Fiber приложения: переводит ошибку,
+// которую вернул handler или middleware, в HTTP ответ
+//
+// Handlers не выбирают статус для ошибок сервисов: ошибки apperrors
+// (в том числе обернутые) отвечаются статусом своей категории и своим
+// кодом, нарушение уникальности PostgreSQL - 409. Остальное - 500
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	// 1. 429/503 от лимитеров и режима обслуживания
+	// Отдаем вместе с Retry-After и X-RateLimit-* заголовками
+	var retryErr *middleware.RetryError
+	if errors.As(err, &retryErr) {
+		retryErr.SetHeaders(c)
+		return c.Status(retryErr.Status).JSON(models.ErrorResponse{
+			Error: retryErr.Message,
+			Code:  retryErr.Code,
+		})
+	}
+
+	// 2. 408/499: клиент не передал тело запроса - это не ошибка сервера,
+	// поэтому такие ответы не попадают в 5xx метрики
+	if readErr := middleware.ClassifyBodyReadError(err); readErr != nil {
+		metrics.RequestsAborted.WithLabelValues(readErr.Reason).Inc()
+		return c.Status(readErr.Status).JSON(models.ErrorResponse{
+			Error: readErr.Message,
+			Code:  readErr.Code,
+		})
+	}
+
+	// 3. 422 от проверки тел запросов с ошибками по полям
+	var validationErr *validation.Error
+	if errors.As(err, &validationErr) {
+		details := make(map[string]interface{}, len(validationErr.Fields))
+		for field, message := range validationErr.Fields {
+			details[field] = message
+		}
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{
+			Error:   "Ошибка валидации данных",
+			Code:    "VALIDATION_ERROR",
+			Details: details,
+		})
+	}
+
+	// 4. Ошибки предметной области; дубликат, не распознанный сервисом, - 409
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		if unique := apperrors.Unique(err); unique != nil {
+			appErr, err = unique, unique
+		}
+	}
+	if appErr != nil {
+		return c.Status(appStatus(appErr)).JSON(models.ErrorResponse{
+			Error:   err.Error(), // С уточнением, если ошибку обернули
+			Code:    appErr.Code,
+			Details: appErr.Details,
+		})
+	}
+
+	// 5. Ошибки Fiber (404 роутинга, 405, 413) со своим статусом
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(models.ErrorResponse{
+			Error: fiberErr.Message,
+		})
+	}
+
+	// 6. Паника (middleware.Recover): значение и стек только в логе
+	var panicErr *safe.PanicError
+	if errors.As(err, &panicErr) {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "внутренняя ошибка сервера",
+			Code:  "INTERNAL_ERROR",
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+		Error: err.Error(),
+		Code:  "INTERNAL_ERROR",
+	})
+}
+
+// appStatus возвращает HTTP статус категории ошибки, неизвестная - 500
+func appStatus(err *apperrors.Error) int {
+	for _, ks := range kindStatus {
+		if err.Kind == ks.kind {
+			return ks.status
+		}
+	}
+	return fiber.StatusInternalServerError
+}
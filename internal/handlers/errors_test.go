@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/safe"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestErrorHandler(t *testing.T) {
+	cases := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{
+			name:    "ошибка сервиса",
+			err:     services.ErrUserNotFound,
+			status:  fiber.StatusNotFound,
+			code:    "USER_NOT_FOUND",
+			message: services.ErrUserNotFound.Error(),
+		},
+		{
+			name:    "обернутая ошибка сервиса",
+			err:     fmt.Errorf("%w: format %q", services.ErrInvalidExport, "xml"),
+			status:  fiber.StatusBadRequest,
+			code:    "CREATE_EXPORT_ERROR",
+			message: services.ErrInvalidExport.Error() + `: format "xml"`,
+		},
+		{
+			// Занятый email регистрация не раскрывает (TestCreateUserHidesRegisteredEmail),
+			// остальные дубликаты пользователя - 409 без уточнения поля
+			name:   "дубликат имени пользователя из базы",
+			err:    fmt.Errorf("update user: %w", &pgconn.PgError{Code: "23505", ConstraintName: "idx_users_username_not_deleted"}),
+			status: fiber.StatusConflict,
+			code:   "USER_ALREADY_EXISTS",
+		},
+		{
+			name:   "ошибка Fiber",
+			err:    fiber.ErrMethodNotAllowed,
+			status: fiber.StatusMethodNotAllowed,
+		},
+		{
+			// Значение паники не попадает в ответ
+			name:    "паника handler'а",
+			err:     &safe.PanicError{Component: "http", Value: "token=secret"},
+			status:  fiber.StatusInternalServerError,
+			code:    "INTERNAL_ERROR",
+			message: "внутренняя ошибка сервера",
+		},
+		{
+			name:    "неизвестная ошибка",
+			err:     errors.New("connection refused"),
+			status:  fiber.StatusInternalServerError,
+			code:    "INTERNAL_ERROR",
+			message: "connection refused",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			app.Get("/", func(c *fiber.Ctx) error { return tc.err })
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var body models.ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.StatusCode != tc.status || body.Code != tc.code {
+				t.Errorf("ответ %d %q, ожидался %d %q", resp.StatusCode, body.Code, tc.status, tc.code)
+			}
+			if tc.message != "" && body.Error != tc.message {
+				t.Errorf("error = %q, ожидалось %q", body.Error, tc.message)
+			}
+		})
+	}
+}
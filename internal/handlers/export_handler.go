@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/storage"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExportHandler обрабатывает HTTP запросы асинхронного экспорта
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+// NewExportHandler создает новый обработчик экспорта
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// CreateExport обрабатывает POST /api/v1/exports
+// Создает задание на экспорт и сразу возвращает операцию export_users,
+// которая сформирует файл
+func (h *ExportHandler) CreateExport(c *fiber.Ctx) error {
+	// 1. Парсим тело запроса
+	req := models.CreateExportRequest{
+		Resource: "users",
+		Format:   services.ExportFormatCSV,
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	// 2. Создаем задание и операцию
+	op, err := h.exportService.CreateExport(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	// 3. 202 Accepted - задание принято, результат будет позже
+	// Location указывает операцию: ее result - задание со ссылкой на файл
+	c.Location("/admin/v1/operations/" + op.ID)
+	return c.Status(fiber.StatusAccepted).JSON(op)
+}
+
+// GetExport обрабатывает GET /api/v1/exports/:id
+// Возвращает статус задания и временную ссылку, если файл готов
+func (h *ExportHandler) GetExport(c *fiber.Ctx) error {
+	id, err := publicid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный ID экспорта",
+			Code:  "INVALID_EXPORT_ID",
+		})
+	}
+
+	job, err := h.exportService.GetExport(c.UserContext(), id, c.BaseURL())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(job)
+}
+
+// DownloadExport обрабатывает GET /api/v1/exports/:id/download
+// Отдает файл по подписанной временной ссылке из GetExport
+// Ссылку заранее проверяет middleware.SignedURL
+func (h *ExportHandler) DownloadExport(c *fiber.Ctx) error {
+	id, err := publicid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный ID экспорта",
+			Code:  "INVALID_EXPORT_ID",
+		})
+	}
+
+	reader, info, err := h.exportService.OpenDownload(c.UserContext(), id)
+	if err != nil {
+		// Файл удален из хранилища по сроку - для клиента экспорта нет
+		if errors.Is(err, storage.ErrNotFound) {
+			return services.ErrExportNotFound
+		}
+		return err
+	}
+
+	// Fiber закроет reader после отправки, если он реализует io.Closer
+	c.Attachment("export-" + strconv.Itoa(id) + "." + formatExtension(info.ContentType))
+	c.Set(fiber.HeaderContentType, info.ContentType)
+	return c.SendStream(reader, int(info.Size))
+}
+
+// formatExtension возвращает расширение файла по MIME типу экспорта
+func formatExtension(contentType string) string {
+	if contentType == "application/x-ndjson" {
+		return services.ExportFormatNDJSON
+	}
+	return services.ExportFormatCSV
+}
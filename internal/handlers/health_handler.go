@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/health"
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+	"github.com/Soundveyve/fiber-backend/internal/middleware"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/settings"
+	"github.com/gofiber/fiber/v2"
+)
+
+// appVersion - версия приложения в ответах health-check
+const appVersion = "1.0.0"
+
+// HealthHandler обрабатывает health-check запросы
+// Liveness проверяет только сам процесс, readiness - еще и зависимости
+// (БД, Redis, почту), чтобы Kubernetes и балансировщики переставали
+// направлять трафик на инстанс, потерявший критичную зависимость
+type HealthHandler struct {
+	deps         []health.Dependency
+	probeTimeout time.Duration      // Таймаут проверки одной зависимости
+	settings     *settings.Settings // Сколько живет закешированный результат для /health/lb (health.cache_ttl)
+
+	// ready выставляется после прогрева (MarkReady)
+	// До этого readiness отвечает 503, и балансировщик не шлет трафик
+	ready atomic.Bool
+
+	mu        sync.Mutex
+	cached    models.HealthResponse
+	checkedAt time.Time
+}
+
+// NewHealthHandler создает новый обработчик health-check
+// deps - зависимости, которые опрашивает readiness, каждая не дольше probeTimeout
+// Настройка health.cache_ttl определяет, как часто /health/lb реально проверяет зависимости
+func NewHealthHandler(runtimeSettings *settings.Settings, probeTimeout time.Duration, deps ...health.Dependency) *HealthHandler {
+	return &HealthHandler{
+		deps:         deps,
+		probeTimeout: probeTimeout,
+		settings:     runtimeSettings,
+	}
+}
+
+// MarkReady отмечает, что прогрев завершен и инстанс готов принимать трафик
+func (h *HealthHandler) MarkReady() {
+	h.ready.Store(true)
+
+	// Сбрасываем кеш /health/lb, чтобы он не отдавал "starting" еще health.cache_ttl
+	h.mu.Lock()
+	h.checkedAt = time.Time{}
+	h.mu.Unlock()
+}
+
+// Liveness обрабатывает GET /health/live
+// Процесс жив, если отвечает: зависимости не проверяются, иначе сбой
+// БД приведет к перезапуску всех подов, который ничего не исправит
+func (h *HealthHandler) Liveness(c *fiber.Ctx) error {
+	return c.JSON(models.HealthResponse{
+		Status:  "ok",
+		Version: appVersion,
+	})
+}
+
+// Readiness обрабатывает GET /health/ready (и GET /health)
+// Проверяет зависимости при каждом запросе и отвечает 503, если недоступна
+// критичная зависимость или еще идет прогрев
+func (h *HealthHandler) Readiness(c *fiber.Ctx) error {
+	return h.respond(c, h.check(c.UserContext()))
+}
+
+// CachedHealthCheck обрабатывает GET /health/lb
+// Дешевый вариант для балансировщиков: результат проверки зависимостей
+// кешируется на health.cache_ttl, поэтому частые пробы (каждую секунду с каждого
+// инстанса балансировщика) не нагружают БД
+func (h *HealthHandler) CachedHealthCheck(c *fiber.Ctx) error {
+	h.mu.Lock()
+	// Проверяем под мьютексом: при истекшем кеше конкурентные пробы
+	// дождутся одной проверки, а не пойдут в БД все разом
+	if time.Since(h.checkedAt) >= h.settings.Duration(settings.HealthCacheTTL) {
+		// Контекст не от запроса: результат достанется и другим пробам
+		h.cached = h.check(context.Background())
+		h.checkedAt = time.Now()
+		metrics.CacheLookups.WithLabelValues("health_lb", "miss").Inc()
+	} else {
+		metrics.CacheLookups.WithLabelValues("health_lb", "hit").Inc()
+	}
+	result := h.cached
+	h.mu.Unlock()
+
+	return h.respond(c, result)
+}
+
+// check проверяет все зависимости и формирует ответ
+func (h *HealthHandler) check(ctx context.Context) models.HealthResponse {
+	report := health.Probe(ctx, h.deps, h.probeTimeout)
+
+	result := models.HealthResponse{
+		Status:  "ok",
+		Checks:  make(map[string]models.DependencyHealth, len(report.Results)),
+		Version: appVersion,
+	}
+
+	for _, r := range report.Results {
+		dep := models.DependencyHealth{
+			Status:    r.Status,
+			Critical:  r.Critical,
+			LatencyMs: float64(r.Latency.Microseconds()) / 1000,
+		}
+		if r.Err != nil {
+			// Текст ошибки (адреса, имена хостов) остается в логе
+			dep.Error = "unavailable"
+			dep.Fallback = r.Fallback
+			if errors.Is(r.Err, context.DeadlineExceeded) {
+				dep.Error = "timeout"
+			}
+			slog.WarnContext(ctx, "⚠️  Зависимость недоступна",
+				"dependency", r.Name, "critical", r.Critical, "latency", r.Latency, "error", r.Err)
+
+			// Некритичная зависимость не снимает трафик, но видна в ответе
+			if result.Status == "ok" {
+				result.Status = "degraded"
+			}
+		}
+		result.Checks[r.Name] = dep
+	}
+
+	if !report.Healthy {
+		result.Status = "error"
+	} else if !h.ready.Load() {
+		// Прогрев еще идет - инстанс жив, но трафик ему отдавать рано
+		result.Status = "starting"
+	}
+
+	return result
+}
+
+// respond отправляет результат проверки
+// При недоступной критичной зависимости возвращаем 503, чтобы балансировщик
+// перестал направлять трафик на этот инстанс
+func (h *HealthHandler) respond(c *fiber.Ctx, result models.HealthResponse) error {
+	if result.Status == "error" || result.Status == "starting" {
+		middleware.SetRetryAfter(c, h.settings.Duration(settings.HealthCacheTTL))
+		return c.Status(fiber.StatusServiceUnavailable).JSON(result)
+	}
+	return c.JSON(result)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/httpctx"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// IdentityHandler обрабатывает привязку внешних учетных записей
+// текущего пользователя (/api/v1/users/me/identities)
+type IdentityHandler struct {
+	identityService *services.IdentityService
+}
+
+// NewIdentityHandler создает новый обработчик привязок
+func NewIdentityHandler(identityService *services.IdentityService) *IdentityHandler {
+	return &IdentityHandler{
+		identityService: identityService,
+	}
+}
+
+// ListIdentities обрабатывает GET /api/v1/users/me/identities
+// Возвращает способы входа текущего пользователя
+func (h *IdentityHandler) ListIdentities(c *fiber.Ctx) error {
+	user, ok := reqctx.GetUser(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	identities, err := h.identityService.ListIdentities(c.UserContext(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(identities)
+}
+
+// LinkIdentity обрабатывает POST /api/v1/users/me/identities
+// Привязывает учетную запись провайдера по токену, полученному клиентом после OAuth
+func (h *IdentityHandler) LinkIdentity(c *fiber.Ctx) error {
+	user, ok := reqctx.GetUser(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	var req models.LinkIdentityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	linked, err := h.identityService.LinkIdentity(c.UserContext(), user.ID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(linked)
+}
+
+// UnlinkIdentity обрабатывает DELETE /api/v1/users/me/identities/:provider
+// Последний способ входа отвязать нельзя (409)
+func (h *IdentityHandler) UnlinkIdentity(c *fiber.Ctx) error {
+	user, ok := reqctx.GetUser(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	if err := h.identityService.UnlinkIdentity(c.UserContext(), user.ID, c.Params("provider")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// unauthorized отвечает 401 для запросов без аутентифицированного пользователя
+func unauthorized(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+		Error: "Требуется аутентификация",
+		Code:  "UNAUTHORIZED",
+	})
+}
+
+// unauthorizedCtx - unauthorized для handlers на httpctx.Ctx
+func unauthorizedCtx(c httpctx.Ctx) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+		Error: "Требуется аутентификация",
+		Code:  "UNAUTHORIZED",
+	})
+}
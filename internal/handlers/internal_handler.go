@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// InternalHandler обрабатывает запросы внутренних сервисов (/internal/v1)
+// Доступ проверяет middleware.RequireService, токен пользователя не нужен
+type InternalHandler struct {
+	userService *services.UserService
+}
+
+// NewInternalHandler создает новый обработчик внутреннего API
+func NewInternalHandler(userService *services.UserService) *InternalHandler {
+	return &InternalHandler{
+		userService: userService,
+	}
+}
+
+// LookupUser обрабатывает GET /internal/v1/users/lookup?email=...
+// Находит пользователя по email: поиск по email недоступен в публичном API
+func (h *InternalHandler) LookupUser(c *fiber.Ctx) error {
+	email := strings.TrimSpace(c.Query("email"))
+	if email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Параметр email обязателен",
+			Code:  "INVALID_QUERY_PARAMS",
+		})
+	}
+
+	// Нет пользователя - 404 USER_NOT_FOUND через ErrorHandler
+	user, err := h.userService.GetUserByEmail(c.UserContext(), email)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(user)
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/jobs"
+	"github.com/gofiber/fiber/v2"
+)
+
+// JobsHandler обрабатывает просмотр очереди фоновых заданий
+type JobsHandler struct {
+	queue *jobs.Queue
+}
+
+// NewJobsHandler создает новый обработчик очереди заданий
+func NewJobsHandler(queue *jobs.Queue) *JobsHandler {
+	return &JobsHandler{
+		queue: queue,
+	}
+}
+
+// GetQueue обрабатывает GET /admin/v1/jobs
+// Возвращает число заданий в ожидании, в работе и неудачных
+// с разбивкой по типам. Очередь общая для всех инстансов
+func (h *JobsHandler) GetQueue(c *fiber.Ctx) error {
+	stats, err := h.queue.Stats(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(stats)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/Soundveyve/fiber-backend/internal/operations"
+	"github.com/gofiber/fiber/v2"
+)
+
+// OperationHandler отдает состояние длительных операций (/admin/v1/operations)
+type OperationHandler struct {
+	operations *operations.Manager
+}
+
+// NewOperationHandler создает новый обработчик операций
+func NewOperationHandler(manager *operations.Manager) *OperationHandler {
+	return &OperationHandler{
+		operations: manager,
+	}
+}
+
+// GetOperation обрабатывает GET /admin/v1/operations/:id
+// Возвращает статус и прогресс операции, а после завершения -
+// результат или ошибку синхронного запроса
+func (h *OperationHandler) GetOperation(c *fiber.Ctx) error {
+	op, err := h.operations.Get(c.UserContext(), c.Params("id"), c.BaseURL())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(op)
+}
+
+// preferAsync сообщает, что клиент просит выполнить запрос асинхронно
+// (Prefer: respond-async, RFC 7240) и операции для этого настроены
+func preferAsync(c *fiber.Ctx, manager *operations.Manager) bool {
+	if manager == nil {
+		return false
+	}
+	for _, header := range c.GetReqHeaders()["Prefer"] {
+		for _, preference := range strings.Split(header, ",") {
+			token, _, _ := strings.Cut(preference, ";")
+			if strings.EqualFold(strings.TrimSpace(token), "respond-async") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// startOperation запускает операцию и отвечает 202 Accepted
+// Location указывает, где проверять прогресс и забрать результат
+func startOperation(c *fiber.Ctx, manager *operations.Manager, kind string, input any) error {
+	op, err := manager.Start(c.UserContext(), kind, input)
+	if err != nil {
+		return err
+	}
+
+	c.Location("/admin/v1/operations/" + op.ID)
+	c.Set("Preference-Applied", "respond-async")
+	return c.Status(fiber.StatusAccepted).JSON(op)
+}
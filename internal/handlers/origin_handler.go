@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// OriginHandler обрабатывает источники CORS и собственных клиентов
+// (/admin/v1/cors-origins, /admin/v1/clients)
+type OriginHandler struct {
+	originService *services.OriginService
+}
+
+// NewOriginHandler создает новый обработчик источников CORS
+func NewOriginHandler(originService *services.OriginService) *OriginHandler {
+	return &OriginHandler{
+		originService: originService,
+	}
+}
+
+// CreateOrigin обрабатывает POST /admin/v1/cors-origins
+// Разрешает кросс-доменные запросы с источника
+func (h *OriginHandler) CreateOrigin(c *fiber.Ctx) error {
+	// 1. Парсим тело запроса
+	var req models.CreateCORSOriginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	// 2. Сохраняем источник от имени текущего администратора
+	user, _ := reqctx.GetUser(c)
+	origin, err := h.originService.CreateOrigin(c.UserContext(), req, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(origin)
+}
+
+// ListOrigins обрабатывает GET /admin/v1/cors-origins
+// Возвращает источники, разрешенные через admin API
+func (h *OriginHandler) ListOrigins(c *fiber.Ctx) error {
+	origins, err := h.originService.ListOrigins(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(origins)
+}
+
+// DeleteOrigin обрабатывает DELETE /admin/v1/cors-origins/:id
+func (h *OriginHandler) DeleteOrigin(c *fiber.Ctx) error {
+	id, err := publicid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный ID источника",
+			Code:  "INVALID_CORS_ORIGIN_ID",
+		})
+	}
+
+	if err := h.originService.DeleteOrigin(c.UserContext(), id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// CreateClient обрабатывает POST /admin/v1/clients
+// Регистрирует собственного клиента (фронтенд) с его источниками
+func (h *OriginHandler) CreateClient(c *fiber.Ctx) error {
+	// 1. Парсим тело запроса
+	var req models.CreateTrustedClientRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	// 2. Регистрируем клиента от имени текущего администратора
+	user, _ := reqctx.GetUser(c)
+	client, err := h.originService.CreateClient(c.UserContext(), req, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(client)
+}
+
+// ListClients обрабатывает GET /admin/v1/clients
+func (h *OriginHandler) ListClients(c *fiber.Ctx) error {
+	clients, err := h.originService.ListClients(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(clients)
+}
+
+// UpdateClient обрабатывает PUT /admin/v1/clients/:id
+// Заменяет описание и список источников клиента
+func (h *OriginHandler) UpdateClient(c *fiber.Ctx) error {
+	// 1. Получаем ID из URL
+	id, err := publicid.Parse(c.Params("id"))
+	if err != nil {
+		return invalidClientID(c)
+	}
+
+	// 2. Парсим тело запроса
+	var req models.UpdateTrustedClientRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	// 3. Обновляем клиента
+	client, err := h.originService.UpdateClient(c.UserContext(), id, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(client)
+}
+
+// DeleteClient обрабатывает DELETE /admin/v1/clients/:id
+// Источники клиента перестают быть разрешены
+func (h *OriginHandler) DeleteClient(c *fiber.Ctx) error {
+	id, err := publicid.Parse(c.Params("id"))
+	if err != nil {
+		return invalidClientID(c)
+	}
+
+	if err := h.originService.DeleteClient(c.UserContext(), id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// invalidClientID отвечает 400 на нечисловой ID клиента
+func invalidClientID(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+		Error: "Невалидный ID клиента",
+		Code:  "INVALID_CLIENT_ID",
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/Soundveyve/fiber-backend/internal/httpctx"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProfileHandler обрабатывает публичные профили (/api/v1/profiles/:username)
+// и настройки приватности текущего пользователя (/api/v1/users/me/privacy)
+type ProfileHandler struct {
+	profileService *services.ProfileService
+}
+
+// NewProfileHandler создает новый обработчик публичных профилей
+func NewProfileHandler(profileService *services.ProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+	}
+}
+
+// GetProfile обрабатывает GET /api/v1/profiles/:username
+// Доступен без аутентификации, отдает только разрешенные пользователем поля.
+// По прежнему имени переименованного пользователя отвечает 301
+// с Location на профиль по текущему имени
+func (h *ProfileHandler) GetProfile(c httpctx.Ctx) error {
+	profile, err := h.profileService.GetPublicProfile(c.Context(), c.Params("username"))
+	if err != nil {
+		var moved *services.ProfileMovedError
+		if errors.As(err, &moved) {
+			// Прежнее имя со временем может занять другой пользователь,
+			// поэтому клиенты не должны запоминать перенаправление
+			c.Set(fiber.HeaderCacheControl, "no-cache")
+			c.Location("/api/v1/profiles/" + url.PathEscape(moved.Username))
+			return c.Status(fiber.StatusMovedPermanently).JSON(models.ProfileMovedResponse{
+				Error:    "Пользователь сменил имя",
+				Code:     "PROFILE_MOVED",
+				Username: moved.Username,
+			})
+		}
+		return err
+	}
+
+	return c.JSON(profile)
+}
+
+// GetPrivacy обрабатывает GET /api/v1/users/me/privacy
+func (h *ProfileHandler) GetPrivacy(c httpctx.Ctx) error {
+	user, ok := reqctx.UserFromContext(c.Context())
+	if !ok {
+		return unauthorizedCtx(c)
+	}
+
+	settings, err := h.profileService.GetPrivacy(c.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(settings)
+}
+
+// UpdatePrivacy обрабатывает PUT /api/v1/users/me/privacy
+// Меняет только переданные поля
+func (h *ProfileHandler) UpdatePrivacy(c httpctx.Ctx) error {
+	user, ok := reqctx.UserFromContext(c.Context())
+	if !ok {
+		return unauthorizedCtx(c)
+	}
+
+	// 1. Парсим тело запроса
+	var req models.UpdatePrivacySettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	// 2. Сохраняем настройки
+	settings, err := h.profileService.UpdatePrivacy(c.Context(), user.ID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(settings)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reference"
+	"github.com/gofiber/fiber/v2"
+)
+
+// referencePath - адрес справочников, из него собираются ссылки индекса
+const referencePath = "/api/v1/reference"
+
+// Кеширование справочников клиентами и CDN
+const (
+	// Ссылка с версией сборки (?v= из индекса) всегда отдает одни данные
+	referenceCacheVersioned = "public, max-age=31536000, immutable"
+
+	// Без версии данные сменятся с новой сборкой: сутки без перепроверки,
+	// дальше - по ETag
+	referenceCacheUnversioned = "public, max-age=86400"
+
+	// Индекс содержит текущую версию и должен обновляться после выкладки
+	referenceCacheIndex = "public, max-age=300"
+)
+
+// ReferenceHandler обрабатывает справочники (/api/v1/reference)
+type ReferenceHandler struct {
+	catalog *reference.Catalog
+}
+
+// NewReferenceHandler создает новый обработчик справочников
+func NewReferenceHandler(catalog *reference.Catalog) *ReferenceHandler {
+	return &ReferenceHandler{
+		catalog: catalog,
+	}
+}
+
+// Index обрабатывает GET /api/v1/reference
+// Возвращает имена справочников и ссылки на их текущую версию
+func (h *ReferenceHandler) Index(c *fiber.Ctx) error {
+	resp := models.ReferenceIndexResponse{
+		Version:  h.catalog.Version(),
+		Datasets: make([]models.ReferenceDataset, 0, len(h.catalog.Names())),
+	}
+	for _, name := range h.catalog.Names() {
+		resp.Datasets = append(resp.Datasets, models.ReferenceDataset{
+			Name: name,
+			URL:  referencePath + "/" + name + "?v=" + h.catalog.Version(),
+		})
+	}
+
+	c.Set(fiber.HeaderCacheControl, referenceCacheIndex)
+	return c.JSON(resp)
+}
+
+// GetDataset обрабатывает GET /api/v1/reference/:name
+// Отдает справочник без аутентификации; устаревшая версия в ?v= не
+// ошибка - клиент получает текущие данные с обычным кешированием
+func (h *ReferenceHandler) GetDataset(c *fiber.Ctx) error {
+	// 1. Ищем справочник
+	dataset, ok := h.catalog.Get(c.Params("name"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Справочник не найден",
+			Code:  "REFERENCE_NOT_FOUND",
+		})
+	}
+
+	// 2. Заголовки кеширования: данные определяются версией сборки
+	etag := `"` + h.catalog.Version() + `"`
+	c.Set(fiber.HeaderETag, etag)
+	if c.Query("v") == h.catalog.Version() {
+		c.Set(fiber.HeaderCacheControl, referenceCacheVersioned)
+	} else {
+		c.Set(fiber.HeaderCacheControl, referenceCacheUnversioned)
+	}
+
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(dataset.Body)
+}
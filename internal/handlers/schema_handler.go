@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/migrations"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SchemaHandler обрабатывает сверку схемы БД с миграциями (/admin/v1/schema)
+type SchemaHandler struct {
+	pool *pgxpool.Pool
+}
+
+// NewSchemaHandler создает новый обработчик сверки схемы БД
+func NewSchemaHandler(pool *pgxpool.Pool) *SchemaHandler {
+	return &SchemaHandler{
+		pool: pool,
+	}
+}
+
+// GetSchemaDrift обрабатывает GET /admin/v1/schema
+// Сверяет схему БД с примененными миграциями заново при каждом запросе:
+// после ручного исправления БД расхождение пропадает без перезапуска
+func (h *SchemaHandler) GetSchemaDrift(c *fiber.Ctx) error {
+	drift, err := migrations.CheckDrift(c.UserContext(), h.pool)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "SCHEMA_CHECK_ERROR",
+		})
+	}
+
+	return c.JSON(models.SchemaDriftResponse{
+		Version:        drift.Version,
+		Dirty:          drift.Dirty,
+		OK:             drift.Empty() && !drift.Dirty,
+		MissingTables:  nonNil(drift.MissingTables),
+		MissingColumns: nonNil(drift.MissingColumns),
+		MissingIndexes: nonNil(drift.MissingIndexes),
+	})
+}
+
+// nonNil заменяет nil на пустой список: в JSON [] вместо null
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/settings"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SettingsHandler обрабатывает настройки, изменяемые во время работы (/admin/v1/settings)
+type SettingsHandler struct {
+	settings *settings.Settings
+}
+
+// NewSettingsHandler создает новый обработчик настроек
+func NewSettingsHandler(runtimeSettings *settings.Settings) *SettingsHandler {
+	return &SettingsHandler{
+		settings: runtimeSettings,
+	}
+}
+
+// ListSettings обрабатывает GET /admin/v1/settings
+// Возвращает все настройки с действующими значениями и значениями по умолчанию
+func (h *SettingsHandler) ListSettings(c *fiber.Ctx) error {
+	entries := h.settings.List()
+	resp := models.ListSettingsResponse{
+		Settings: make([]models.SettingResponse, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		resp.Settings = append(resp.Settings, toSettingResponse(entry))
+	}
+	return c.JSON(resp)
+}
+
+// UpdateSetting обрабатывает PUT /admin/v1/settings/:key
+// Переопределяет значение; другие инстансы применят его при следующем обновлении
+func (h *SettingsHandler) UpdateSetting(c *fiber.Ctx) error {
+	var req models.UpdateSettingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	user, _ := reqctx.GetUser(c)
+	entry, err := h.settings.Set(c.UserContext(), c.Params("key"), req.Value, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(toSettingResponse(entry))
+}
+
+// ResetSetting обрабатывает DELETE /admin/v1/settings/:key
+// Удаляет переопределение: действует значение из переменных окружения
+func (h *SettingsHandler) ResetSetting(c *fiber.Ctx) error {
+	entry, err := h.settings.Reset(c.UserContext(), c.Params("key"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(toSettingResponse(entry))
+}
+
+// toSettingResponse конвертирует настройку в API ответ
+func toSettingResponse(entry settings.Entry) models.SettingResponse {
+	resp := models.SettingResponse{
+		Key:         entry.Key,
+		Type:        entry.Type,
+		Description: entry.Description,
+		Value:       entry.JSONValue(entry.Value),
+		Default:     entry.JSONValue(entry.Default),
+		Overridden:  entry.Override != nil,
+	}
+	if entry.Override != nil {
+		if entry.Override.UpdatedBy != "" {
+			resp.UpdatedBy = &entry.Override.UpdatedBy
+		}
+		resp.UpdatedAt = utc.Ptr(entry.Override.UpdatedAt)
+	}
+	return resp
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/system"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SystemHandler обрабатывает сводку о состоянии процесса (/admin/v1/system)
+type SystemHandler struct {
+	inspector *system.Inspector
+}
+
+// NewSystemHandler создает новый обработчик сводки о состоянии процесса
+func NewSystemHandler(inspector *system.Inspector) *SystemHandler {
+	return &SystemHandler{
+		inspector: inspector,
+	}
+}
+
+// GetSystem обрабатывает GET /admin/v1/system
+// Возвращает горутины, память, uptime, очереди, кеши и счетчики ошибок
+// инстанса, который обработал запрос
+func (h *SystemHandler) GetSystem(c *fiber.Ctx) error {
+	snapshot, err := h.inspector.Snapshot()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "SYSTEM_INFO_ERROR",
+		})
+	}
+
+	return c.JSON(snapshot)
+}
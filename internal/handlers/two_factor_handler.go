@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TwoFactorHandler обрабатывает включение двухфакторной аутентификации
+// текущего пользователя (/api/v1/users/me/2fa)
+type TwoFactorHandler struct {
+	twoFactorService *services.TwoFactorService
+}
+
+// NewTwoFactorHandler создает новый обработчик 2FA
+func NewTwoFactorHandler(twoFactorService *services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+// Enable обрабатывает POST /api/v1/users/me/2fa/enable
+// Возвращает секрет и ссылку otpauth:// для QR кода. 2FA включится
+// после подтверждения кодом в POST /api/v1/users/me/2fa/verify
+func (h *TwoFactorHandler) Enable(c *fiber.Ctx) error {
+	user, ok := reqctx.GetUser(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	enrollment, err := h.twoFactorService.Enable(c.UserContext(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(enrollment)
+}
+
+// Verify обрабатывает POST /api/v1/users/me/2fa/verify
+// Подтверждает включение первым кодом и возвращает коды восстановления
+func (h *TwoFactorHandler) Verify(c *fiber.Ctx) error {
+	user, ok := reqctx.GetUser(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	// 1. Парсим тело запроса
+	var req models.TwoFactorVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	// 2. Проверяем код и включаем 2FA
+	codes, err := h.twoFactorService.Verify(c.UserContext(), user.ID, req.Code)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(codes)
+}
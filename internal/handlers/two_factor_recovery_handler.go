@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TwoFactorRecoveryHandler обрабатывает восстановление доступа при потере
+// второго фактора (/api/v1/auth/2fa/recovery) и его проверку
+// администраторами (/admin/v1/2fa-recoveries)
+type TwoFactorRecoveryHandler struct {
+	recoveryService *services.TwoFactorRecoveryService
+	authService     *services.AuthService
+}
+
+// NewTwoFactorRecoveryHandler создает новый обработчик восстановления доступа
+func NewTwoFactorRecoveryHandler(recoveryService *services.TwoFactorRecoveryService, authService *services.AuthService) *TwoFactorRecoveryHandler {
+	return &TwoFactorRecoveryHandler{
+		recoveryService: recoveryService,
+		authService:     authService,
+	}
+}
+
+// Start обрабатывает POST /api/v1/auth/2fa/recovery
+// Создает запрос и отправляет письмо со ссылкой подтверждения
+// В Authorization - two_factor_token из ответа login
+func (h *TwoFactorRecoveryHandler) Start(c *fiber.Ctx) error {
+	userID, ok := reqctx.PendingTwoFactor(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	recovery, err := h.recoveryService.Start(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	// 202 Accepted - доступ восстановится после всех этапов
+	return c.Status(fiber.StatusAccepted).JSON(recovery)
+}
+
+// Status обрабатывает GET /api/v1/auth/2fa/recovery
+// Возвращает этапы открытого запроса и готовность к завершению
+func (h *TwoFactorRecoveryHandler) Status(c *fiber.Ctx) error {
+	userID, ok := reqctx.PendingTwoFactor(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	recovery, err := h.recoveryService.Status(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(recovery)
+}
+
+// Confirm обрабатывает POST /api/v1/auth/2fa/recovery/confirm
+// Подтверждение по токену из письма, начинает период ожидания
+func (h *TwoFactorRecoveryHandler) Confirm(c *fiber.Ctx) error {
+	// 1. Парсим тело запроса
+	var req models.ConfirmTwoFactorRecoveryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	// 2. Подтверждаем запрос
+	recovery, err := h.recoveryService.ConfirmEmail(c.UserContext(), req.Token)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(recovery)
+}
+
+// Complete обрабатывает POST /api/v1/auth/2fa/recovery/complete
+// Отключает 2FA по готовому запросу и выдает пару токенов
+func (h *TwoFactorRecoveryHandler) Complete(c *fiber.Ctx) error {
+	userID, ok := reqctx.PendingTwoFactor(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	tokens, err := h.authService.CompleteTwoFactorRecovery(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(tokens)
+}
+
+// ListRecoveries обрабатывает GET /admin/v1/2fa-recoveries
+// Открытые запросы, старые первыми
+func (h *TwoFactorRecoveryHandler) ListRecoveries(c *fiber.Ctx) error {
+	recoveries, err := h.recoveryService.ListActive(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(recoveries)
+}
+
+// ApproveRecovery обрабатывает POST /admin/v1/2fa-recoveries/:id/approve
+func (h *TwoFactorRecoveryHandler) ApproveRecovery(c *fiber.Ctx) error {
+	id, err := publicid.Parse(c.Params("id"))
+	if err != nil {
+		return invalidRecoveryID(c)
+	}
+
+	admin, _ := reqctx.GetUser(c)
+	recovery, err := h.recoveryService.Approve(c.UserContext(), id, admin.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(recovery)
+}
+
+// RejectRecovery обрабатывает POST /admin/v1/2fa-recoveries/:id/reject
+// Закрывает запрос, 2FA пользователя остается включенной
+func (h *TwoFactorRecoveryHandler) RejectRecovery(c *fiber.Ctx) error {
+	id, err := publicid.Parse(c.Params("id"))
+	if err != nil {
+		return invalidRecoveryID(c)
+	}
+
+	admin, _ := reqctx.GetUser(c)
+	recovery, err := h.recoveryService.Reject(c.UserContext(), id, admin.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(recovery)
+}
+
+// invalidRecoveryID отвечает 400 на нечисловой ID запроса восстановления
+func invalidRecoveryID(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+		Error: "Невалидный ID запроса восстановления",
+		Code:  "INVALID_RECOVERY_ID",
+	})
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/bulk"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/operations"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// exportStreamTimeout ограничивает время выгрузки пользователей
+// Выгрузка всей таблицы медленному клиенту занимает больше listStreamTimeout
+const exportStreamTimeout = 30 * time.Minute
+
+// ImportUsers обрабатывает POST /api/v1/users/import
+// Создает пользователей из тела запроса в CSV или NDJSON
+//
+// Формат задается параметром format (csv, ndjson) или Content-Type
+// (text/csv, application/x-ndjson). Строки CSV и NDJSON - поля email,
+// username, password_hash (bcrypt), first_name, last_name. Импорт идет
+// в одной транзакции, невалидные и занятые строки попадают в отчет
+//
+// С Prefer: respond-async импорт выполняет операция: ответ 202 с ее
+// состоянием, отчет - в GET /admin/v1/operations/:id
+func (h *UserHandler) ImportUsers(c *fiber.Ctx) error {
+	// 1. Определяем формат
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = importFormat(c.Get(fiber.HeaderContentType))
+	}
+	if !bulk.ValidFormat(format) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Неподдерживаемый формат импорта, допустимые значения: csv, ndjson",
+			Code:  "INVALID_IMPORT_FORMAT",
+		})
+	}
+
+	// 2. Импортируем; размер тела ограничен BodyLimit сервера
+	if preferAsync(c, h.operations) {
+		return startOperation(c, h.operations, operations.KindBulkImportUsers, services.BulkImportInput{
+			Format: format,
+			Data:   string(c.Body()),
+		})
+	}
+	result, err := h.userService.BulkImportUsers(c.UserContext(), bytes.NewReader(c.Body()), format)
+	if err != nil {
+		return err
+	}
+
+	// 3. Возвращаем отчет по строкам
+	return c.JSON(result)
+}
+
+// ExportUsers обрабатывает GET /api/v1/users/export
+// Выгружает всех пользователей в CSV или NDJSON (format, по умолчанию csv)
+// Фильтры и сортировка - те же параметры, что у GET /api/v1/users
+func (h *UserHandler) ExportUsers(c *fiber.Ctx) error {
+	// 1. Определяем формат
+	format := strings.ToLower(c.Query("format", bulk.FormatCSV))
+	if !bulk.ValidFormat(format) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Неподдерживаемый формат выгрузки, допустимые значения: csv, ndjson",
+			Code:  "INVALID_EXPORT_FORMAT",
+		})
+	}
+
+	// 2. Поиск, фильтры и сортировка
+	var req models.ListUsersRequest
+	if err := parseUserListQuery(c, &req); err != nil {
+		return err
+	}
+
+	// 3. Тело пишется после возврата из handler, как в streamUsers
+	ctx := context.WithoutCancel(c.UserContext())
+
+	c.Set(fiber.HeaderContentType, bulk.ContentType(format))
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="users.`+format+`"`)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(ctx, exportStreamTimeout)
+		defer cancel()
+
+		count, err := h.userService.ExportUsers(ctx, req, w, format)
+		if err != nil {
+			slog.ErrorContext(ctx, "❌ Ошибка выгрузки пользователей", "format", format, "exported", count, "error", err)
+			return
+		}
+		slog.InfoContext(ctx, "📤 Пользователи выгружены", "format", format, "exported", count)
+	})
+
+	return nil
+}
+
+// importFormat определяет формат импорта по Content-Type
+func importFormat(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "text/csv":
+		return bulk.FormatCSV
+	case "application/x-ndjson":
+		return bulk.FormatNDJSON
+	}
+	return ""
+}
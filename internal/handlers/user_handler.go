@@ -1,11 +1,42 @@
 package handlers
 
 import (
-	"strconv"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 
-	"github.com/gofiber/fiber/v2"
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/lifecycle"
 	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/operations"
+	"github.com/Soundveyve/fiber-backend/internal/query"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
 	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// maxListPageSize - наибольший page_size, который отдается обычным JSON ответом
+	maxListPageSize = 100
+
+	// maxStreamPageSize - наибольший page_size для потоковой выдачи.
+	// Страницы больше maxListPageSize пишутся в ответ по одной записи
+	maxStreamPageSize = 10000
+
+	// listStreamTimeout ограничивает время потоковой выдачи. Поток пишется
+	// после возврата из handler, когда таймаут RequestContext уже снят
+	listStreamTimeout = 5 * time.Minute
+
+	// maxUserSearchLength - наибольшая длина строки поиска q в символах
+	maxUserSearchLength = 100
 )
 
 // UserHandler обрабатывает HTTP запросы связанные с пользователями
@@ -15,13 +46,17 @@ import (
 // 3. Вызывает сервисный слой
 // 4. Формирует HTTP ответ
 type UserHandler struct {
-	userService *services.UserService
+	userService    *services.UserService
+	accountService *services.AccountService
+	operations     *operations.Manager // Prefer: respond-async, nil - только синхронно
 }
 
 // NewUserHandler создает новый обработчик пользователей
-func NewUserHandler(userService *services.UserService) *UserHandler {
+func NewUserHandler(userService *services.UserService, accountService *services.AccountService, manager *operations.Manager) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:    userService,
+		accountService: accountService,
+		operations:     manager,
 	}
 }
 
@@ -39,109 +74,352 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// 2. Здесь можно добавить валидацию через validator пакет
-	// Например: validate.Struct(req)
+	// 2. Проверяем теги validate (обязательные поля, формат email, длина)
+	// При ошибке ErrorHandler ответит 422 с ошибками по полям в details
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
 
 	// 3. Вызываем сервисный слой
-	// c.Context() передает контекст запроса для отмены операции если клиент отключился
-	user, err := h.userService.CreateUser(c.Context(), req)
+	// c.UserContext() передает контекст запроса (с таймаутом из middleware.RequestContext),
+	// чтобы запрос к БД отменялся, когда обработка запроса прервана
+	// Ошибки сервиса отвечает ErrorHandler: телефон или страна не прошли
+	// нормализацию - 422, занятое имя пользователя - 409
+	user, err := h.userService.CreateUser(c.UserContext(), req)
+	var registered *services.EmailRegisteredError
+	if errors.As(err, &registered) {
+		// Email занят: отвечаем как на новый аккаунт, а владельцу адреса
+		// уходит письмо вместо подтверждения - по ответу нельзя узнать,
+		// зарегистрирован ли email
+		if err := h.accountService.NotifyEmailRegistered(c.UserContext(), req.Email); err != nil {
+			slog.ErrorContext(c.UserContext(), "❌ Ошибка отправки письма о повторной регистрации", "error", err)
+		}
+		return c.Status(fiber.StatusCreated).JSON(registered.User)
+	}
 	if err != nil {
-		// Можно добавить логику для разных типов ошибок
-		// Например, проверка на дублирование email
-		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
-			Error: err.Error(),
-			Code:  "CREATE_USER_ERROR",
-		})
+		return err
+	}
+
+	// 4. Отправляем письмо подтверждения email
+	// Пользователь уже создан, поэтому ошибка только логируется:
+	// письмо можно запросить повторно через /auth/resend-verification
+	if err := h.accountService.SendEmailVerification(c.UserContext(), user.InternalID); err != nil {
+		slog.ErrorContext(c.UserContext(), "❌ Ошибка отправки подтверждения email", "target_user_id", user.InternalID, "error", err)
 	}
 
-	// 4. Возвращаем созданного пользователя со статусом 201 Created
+	// 5. Возвращаем созданного пользователя со статусом 201 Created
 	// fiber.StatusCreated это константа для 201
 	return c.Status(fiber.StatusCreated).JSON(user)
 }
 
-// GetUser обрабатывает GET /api/v1/users/:id
+// GetUser обрабатывает GET /api/v1/users/:id и GET /internal/v1/users/:id
 // Получает пользователя по ID
 func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 	// 1. Получаем ID из URL параметров
-	// c.Params("id") извлекает значение из маршрута
-	idStr := c.Params("id")
-	id, err := strconv.Atoi(idStr)
+	// c.Params("id") извлекает публичный ID (UUID), сервис находит по нему внутренний
+	id, err := h.userService.ResolveUserID(c.UserContext(), c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Error: "Невалидный ID пользователя",
-			Code:  "INVALID_USER_ID",
-		})
+		return err
 	}
 
-	// 2. Получаем пользователя из сервиса
-	user, err := h.userService.GetUserByID(c.Context(), id)
+	// 2. Проверяем права: полный профиль (email, телефон) видят владелец
+	// и администратор, как в gRPC GetUser. Внутренние сервисы
+	// (/internal/v1) опознаны подписью и получают любой профиль
+	if reqctx.Service(c) == "" {
+		caller, ok := reqctx.GetUser(c)
+		if !ok {
+			return unauthorized(c)
+		}
+		if caller.ID != id && caller.Role != auth.RoleAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{
+				Error: "Можно получить только свой профиль",
+				Code:  "FORBIDDEN",
+			})
+		}
+	}
+
+	// 3. Получаем пользователя из сервиса
+	// Нет пользователя - 404 USER_NOT_FOUND через ErrorHandler
+	user, err := h.userService.GetUserByID(c.UserContext(), id)
 	if err != nil {
-		// Если пользователь не найден - возвращаем 404
-		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
-			Error: err.Error(),
-			Code:  "USER_NOT_FOUND",
-		})
+		return err
 	}
 
-	// 3. Возвращаем пользователя
+	// 4. Возвращаем пользователя
 	return c.JSON(user)
 }
 
 // ListUsers обрабатывает GET /api/v1/users
-// Возвращает список пользователей с пагинацией
+// Возвращает список пользователей с пагинацией, поиском, фильтрами и сортировкой
 func (h *UserHandler) ListUsers(c *fiber.Ctx) error {
 	// 1. Парсим query параметры (page, page_size)
-	var req models.ListUsersRequest
-	
-	// Устанавливаем значения по умолчанию
-	req.Page = 1
-	req.PageSize = 10
-
-	// QueryParser извлекает параметры из query string
 	// Например: /api/v1/users?page=2&page_size=20
-	if err := c.QueryParser(&req); err != nil {
+	page, err := query.ParsePage(c, query.PageOptions{
+		DefaultSize: 10,
+		MaxSize:     maxStreamPageSize,
+	})
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
 			Error: "Невалидные параметры запроса",
 			Code:  "INVALID_QUERY_PARAMS",
 		})
 	}
+	req := models.ListUsersRequest{
+		Page:     page.Number,
+		PageSize: page.Size,
+	}
+
+	// 2. Поиск, фильтры и сортировка
+	// Например: /api/v1/users?q=ivan&status=active&sort=-created_at
+	if err := parseUserListQuery(c, &req); err != nil {
+		return err
+	}
+
+	// Режим курсора (?cursor=...&limit=50) для больших таблиц
+	if query.IsCursorMode(c) {
+		return h.listUsersAfter(c, req)
+	}
+
+	// Большие страницы не собираются в памяти целиком, а выдаются потоком
+	if req.PageSize > maxListPageSize {
+		return h.streamUsers(c, req)
+	}
+
+	// 4. Получаем список пользователей
+	response, err := h.userService.ListUsers(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	// 5. Возвращаем список
+	return c.JSON(response)
+}
+
+// parseUserListQuery разбирает поиск, фильтры и сортировку списка
+// пользователей (q, status, is_active, даты, sort) в req
+// Общий для GET /api/v1/users и экспорта, ошибки отвечает ErrorHandler
+func parseUserListQuery(c *fiber.Ctx, req *models.ListUsersRequest) error {
+	// 1. Поиск и фильтры
+	// Например: /api/v1/users?q=ivan&status=active&created_after=2024-01-01
+	search, err := query.Filter(c, "q", query.ParseText)
+	if err != nil {
+		return listQueryError(err, "Невалидный параметр q")
+	}
+	if search != nil {
+		req.Search = *search
+	}
+	if utf8.RuneCountInString(req.Search) > maxUserSearchLength {
+		return apperrors.Invalid("INVALID_QUERY_PARAMS", fmt.Sprintf("Строка поиска q длиннее %d символов", maxUserSearchLength))
+	}
+
+	// Состояния аккаунта через запятую: status=active,suspended
+	statuses, err := query.Filter(c, "status", parseStatusFilter)
+	if err != nil {
+		return listQueryError(err, "Невалидный параметр status, допустимые значения: "+strings.Join(listableStatuses(), ", "))
+	}
+	// is_active - устаревший фильтр, оставлен для старых клиентов:
+	// true - аккаунты, которые могут входить, false - остальные
+	isActive, err := query.Filter(c, "is_active", query.ParseBool)
+	if err != nil {
+		return listQueryError(err, "Невалидный параметр is_active, ожидается true или false")
+	}
+	switch {
+	case statuses != nil && isActive != nil:
+		return apperrors.Invalid("INVALID_QUERY_PARAMS", "Параметры status и is_active нельзя передавать вместе")
+	case statuses != nil:
+		req.Statuses = *statuses
+	case isActive != nil && *isActive:
+		req.Statuses = []lifecycle.Status{lifecycle.StatusPendingVerification, lifecycle.StatusActive}
+	case isActive != nil:
+		req.Statuses = []lifecycle.Status{lifecycle.StatusSuspended, lifecycle.StatusDeactivated}
+	}
 
-	// 2. Валидируем параметры
-	if req.Page < 1 {
-		req.Page = 1
+	// Даты принимаются как YYYY-MM-DD (2024-01-31) или RFC3339 время
+	dateFilters := []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_after", &req.CreatedAfter},
+		{"created_before", &req.CreatedBefore},
+		{"inactive_since", &req.InactiveSince}, // Не входившие с даты (или ни разу)
+	}
+	for _, f := range dateFilters {
+		if *f.dst, err = query.Filter(c, f.name, query.ParseDateOrTime); err != nil {
+			return listQueryError(err, fmt.Sprintf("Невалидный параметр %s, ожидается YYYY-MM-DD или RFC3339", f.name))
+		}
 	}
-	if req.PageSize < 1 || req.PageSize > 100 {
-		req.PageSize = 10
+	if req.CreatedAfter != nil && req.CreatedBefore != nil && !req.CreatedAfter.Before(*req.CreatedBefore) {
+		return apperrors.Invalid("INVALID_QUERY_PARAMS", "created_after должен быть раньше created_before")
 	}
 
-	// 3. Получаем список пользователей
-	response, err := h.userService.ListUsers(c.Context(), req)
+	// 2. Сортировка: sort=created_at:desc или sort=-created_at
+	// Поле проверяется по списку, поэтому строка клиента не попадает в SQL
+	sort, err := query.ParseSort(c, models.UserSortFields, query.Sort[models.UserSortField]{
+		Field: models.UserSortCreatedAt,
+		Desc:  true,
+	})
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+		return listQueryError(err, err.Error())
+	}
+	req.SortBy, req.SortDesc = sort.Field, sort.Desc
+	return nil
+}
+
+// listQueryError - ошибка разбора параметров списка для ErrorHandler:
+// фильтр, недоступный уровню доступа, - 403 QUERY_NOT_ALLOWED, остальное - 400
+func listQueryError(err error, message string) error {
+	if errors.Is(err, query.ErrNotAllowed) {
+		return apperrors.Forbidden("QUERY_NOT_ALLOWED", err.Error())
+	}
+	return apperrors.Invalid("INVALID_QUERY_PARAMS", message)
+}
+
+// queryParamError отвечает на ошибку разбора параметров списка (пакет query)
+// Параметр, недоступный по уровню доступа, - 403, невалидный - 400 с message
+func queryParamError(c *fiber.Ctx, err error, message string) error {
+	if errors.Is(err, query.ErrNotAllowed) {
+		return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{
 			Error: err.Error(),
-			Code:  "LIST_USERS_ERROR",
+			Code:  "QUERY_NOT_ALLOWED",
 		})
 	}
+	return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+		Error: message,
+		Code:  "INVALID_QUERY_PARAMS",
+	})
+}
+
+// parseStatusFilter разбирает фильтр status: состояния через запятую
+// deleted не принимается: удаленные аккаунты в списки не попадают
+func parseStatusFilter(value string) ([]lifecycle.Status, error) {
+	parts := strings.Split(value, ",")
+	statuses := make([]lifecycle.Status, 0, len(parts))
+	for _, part := range parts {
+		status, err := lifecycle.Parse(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		if status == lifecycle.StatusDeleted {
+			return nil, fmt.Errorf("%w: %s", lifecycle.ErrUnknownStatus, status)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// listableStatuses - состояния, допустимые в фильтре status
+func listableStatuses() []string {
+	names := lifecycle.Names()
+	return slices.DeleteFunc(names, func(name string) bool {
+		return name == string(lifecycle.StatusDeleted)
+	})
+}
+
+// listUsersAfter выдает страницу списка пользователей в режиме курсора
+// Без OFFSET и COUNT(*), поэтому скорость не зависит от номера страницы
+func (h *UserHandler) listUsersAfter(c *fiber.Ctx, req models.ListUsersRequest) error {
+	// 1. Курсор и размер страницы; смешивать с page/page_size нельзя
+	page, err := query.ParseCursorPage(c, query.PageOptions{
+		DefaultSize: 50,
+		MaxSize:     maxListPageSize,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_QUERY_PARAMS",
+		})
+	}
+
+	// 2. Курсор задает позицию по (created_at, id), другой порядок невозможен
+	if req.SortBy != models.UserSortCreatedAt || !req.SortDesc {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "С cursor поддерживается только сортировка created_at:desc",
+			Code:  "INVALID_QUERY_PARAMS",
+		})
+	}
+
+	// 3. Получаем страницу
+	response, err := h.userService.ListUsersAfter(c.UserContext(), req, page)
+	if err != nil {
+		if errors.Is(err, query.ErrInvalidParam) {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "Невалидный курсор, начните с первой страницы",
+				Code:  "INVALID_CURSOR",
+			})
+		}
+		return err
+	}
 
-	// 4. Возвращаем список
 	return c.JSON(response)
 }
 
-// UpdateUser обрабатывает PUT /api/v1/users/:id
-// Обновляет данные пользователя
-func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
+// ListUserChanges обрабатывает GET /api/v1/users/changes
+// Изменения пользователей после токена since для инкрементальной
+// синхронизации клиентов: вместо загрузки всего списка заново
+func (h *UserHandler) ListUserChanges(c *fiber.Ctx) error {
+	// 1. Размер страницы изменений (limit), токен прошлой синхронизации - since
+	page, err := query.ParseCursorPage(c, query.PageOptions{
+		DefaultSize: maxListPageSize,
+		MaxSize:     maxListPageSize,
+	})
+	if err != nil {
+		return apperrors.Invalid("INVALID_QUERY_PARAMS", err.Error())
+	}
+
+	// 2. Получаем изменения; устаревший токен - 410 SYNC_TOKEN_EXPIRED
+	// через ErrorHandler, клиент загружает список заново
+	changes, err := h.userService.ListUserChanges(c.UserContext(), c.Query("since"), page.Limit)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(changes)
+}
+
+// UpdateUserRole обрабатывает PUT /api/v1/users/:id/role
+// Назначает пользователю роль (только для администраторов, в sudo режиме)
+func (h *UserHandler) UpdateUserRole(c *fiber.Ctx) error {
 	// 1. Получаем ID из URL
-	idStr := c.Params("id")
-	id, err := strconv.Atoi(idStr)
+	id, err := h.userService.ResolveUserID(c.UserContext(), c.Params("id"))
 	if err != nil {
+		return err
+	}
+
+	// 2. Парсим тело запроса
+	var req models.UpdateUserRoleRequest
+	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Error: "Невалидный ID пользователя",
-			Code:  "INVALID_USER_ID",
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
 		})
 	}
 
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	// 3. Назначаем роль
+	user, err := h.userService.UpdateUserRole(c.UserContext(), id, req.Role)
+	if err != nil {
+		return err
+	}
+
+	// 4. Возвращаем пользователя с новой ролью
+	return c.JSON(user)
+}
+
+// UpdateUserStatus обрабатывает PUT /api/v1/users/:id/status
+// Переводит аккаунт в другое состояние (только для администраторов)
+// Недопустимый переход (например из deleted) - 409
+func (h *UserHandler) UpdateUserStatus(c *fiber.Ctx) error {
+	// 1. Получаем ID из URL
+	id, err := h.userService.ResolveUserID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
 	// 2. Парсим тело запроса
-	var req models.UpdateUserRequest
+	var req models.UpdateUserStatusRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
 			Error: "Невалидный JSON",
@@ -149,16 +427,93 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// 3. Обновляем пользователя
-	user, err := h.userService.UpdateUser(c.Context(), id, req)
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	status, err := lifecycle.Parse(req.Status)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
-			Error: err.Error(),
-			Code:  "UPDATE_USER_ERROR",
+		return &validation.Error{Fields: map[string]string{"status": err.Error()}}
+	}
+
+	// 3. Меняем состояние; недопустимый переход - 409 INVALID_STATUS_TRANSITION
+	user, err := h.userService.ChangeStatus(c.UserContext(), id, status)
+	if err != nil {
+		return err
+	}
+
+	// 4. Возвращаем пользователя в новом состоянии
+	return c.JSON(user)
+}
+
+// streamUsers выдает большую страницу списка пользователей потоком
+// Формат ответа совпадает с обычным ListUsers
+func (h *UserHandler) streamUsers(c *fiber.Ctx, req models.ListUsersRequest) error {
+	// 1. Подсчет до начала потока: ошибку еще можно вернуть статусом 500
+	stream, err := h.userService.NewUserStream(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	// 2. Тело пишется после возврата из handler, поэтому контекст запроса
+	// отвязывается от отмены RequestContext и получает свой таймаут
+	ctx := context.WithoutCancel(c.UserContext())
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(ctx, listStreamTimeout)
+		defer cancel()
+
+		if err := stream.WriteTo(ctx, w); err != nil {
+			slog.ErrorContext(ctx, "❌ Ошибка потоковой выдачи пользователей", "error", err)
+		}
+	})
+
+	return nil
+}
+
+// UpdateUser обрабатывает PUT /api/v1/users/:id
+// Обновляет данные пользователя: свои или любого (администратор)
+func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
+	// 1. Получаем ID из URL
+	id, err := h.userService.ResolveUserID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	// 2. Проверяем права: чужой профиль (и email, на который уйдет ссылка
+	// сброса пароля) меняет только администратор, как в gRPC UpdateUser
+	caller, ok := reqctx.GetUser(c)
+	if !ok {
+		return unauthorized(c)
+	}
+	if caller.ID != id && caller.Role != auth.RoleAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{
+			Error: "Можно менять только свой профиль",
+			Code:  "FORBIDDEN",
 		})
 	}
 
-	// 4. Возвращаем обновленного пользователя
+	// 3. Парсим тело запроса
+	var req models.UpdateUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	// 4. Обновляем пользователя
+	user, err := h.userService.UpdateUser(c.UserContext(), id, req)
+	if err != nil {
+		return err
+	}
+
+	// 5. Возвращаем обновленного пользователя
 	return c.JSON(user)
 }
 
@@ -166,39 +521,17 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 // Удаляет пользователя
 func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 	// 1. Получаем ID
-	idStr := c.Params("id")
-	id, err := strconv.Atoi(idStr)
+	id, err := h.userService.ResolveUserID(c.UserContext(), c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Error: "Невалидный ID пользователя",
-			Code:  "INVALID_USER_ID",
-		})
+		return err
 	}
 
-	// 2. Удаляем пользователя
-	// В production лучше использовать DeactivateUser (soft delete)
-	err = h.userService.DeleteUser(c.Context(), id)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
-			Error: err.Error(),
-			Code:  "DELETE_USER_ERROR",
-		})
+	// 2. Удаляем пользователя (мягкое удаление)
+	// Email и username удаленного пользователя освобождаются
+	if err := h.userService.DeleteUser(c.UserContext(), id); err != nil {
+		return err
 	}
 
 	// 3. Возвращаем 204 No Content (успешное удаление без тела ответа)
 	return c.SendStatus(fiber.StatusNoContent)
 }
-
-// HealthCheck обрабатывает GET /health
-// Проверяет состояние сервиса и его зависимостей
-func (h *UserHandler) HealthCheck(c *fiber.Ctx) error {
-	// Можно добавить проверку БД и других зависимостей
-	return c.JSON(models.HealthResponse{
-		Status: "ok",
-		Services: map[string]string{
-			"api":      "healthy",
-			"database": "healthy", // Здесь можно добавить реальную проверку
-		},
-		Version: "1.0.0",
-	})
-}
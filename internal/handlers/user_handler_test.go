@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/golden"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/sanitize"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/settings"
+)
+
+// errRecorded - ответ записывающей БД на любой запрос
+var errRecorded = errors.New("запрос записан")
+
+// recordingDB запоминает аргументы запросов к БД вместо их выполнения:
+// так проверяется, что именно сервис передал бы в INSERT/UPDATE
+// Реализует repository.DBTX и services.Beginner
+type recordingDB struct {
+	mu     sync.Mutex
+	args   [][]interface{}
+	rowErr error // Ошибка QueryRow, nil - errRecorded
+
+	// resolveID - внутренний ID, который находит GetUserIDByPublicID, 0 - не найден
+	resolveID int32
+}
+
+func (d *recordingDB) record(args []interface{}) {
+	d.mu.Lock()
+	d.args = append(d.args, args)
+	d.mu.Unlock()
+}
+
+// queries возвращает аргументы всех записанных запросов
+func (d *recordingDB) queries() [][]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([][]interface{}(nil), d.args...)
+}
+
+func (d *recordingDB) Exec(_ context.Context, _ string, args ...interface{}) (pgconn.CommandTag, error) {
+	d.record(args)
+	return pgconn.CommandTag{}, errRecorded
+}
+
+func (d *recordingDB) Query(_ context.Context, _ string, args ...interface{}) (pgx.Rows, error) {
+	d.record(args)
+	return nil, errRecorded
+}
+
+func (d *recordingDB) QueryRow(_ context.Context, _ string, args ...interface{}) pgx.Row {
+	d.record(args)
+	if d.resolveID != 0 && len(args) == 1 {
+		if _, ok := args[0].(uuid.UUID); ok {
+			return idRow{id: d.resolveID}
+		}
+	}
+	if d.rowErr != nil {
+		return errRow{err: d.rowErr}
+	}
+	return errRow{err: errRecorded}
+}
+
+func (d *recordingDB) Begin(context.Context) (pgx.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+// errRow - результат QueryRow записывающей БД
+type errRow struct{ err error }
+
+func (r errRow) Scan(...interface{}) error { return r.err }
+
+// idRow - найденный по публичному ID пользователь
+type idRow struct{ id int32 }
+
+func (r idRow) Scan(dest ...interface{}) error {
+	*dest[0].(*int32) = r.id
+	return nil
+}
+
+// newUserTestApp собирает приложение с POST /users, GET и PUT /users/:id
+// поверх записывающей БД
+// ErrorHandler - тот же, что в cmd/api
+func newUserTestApp(t *testing.T, policy sanitize.Policy) (*fiber.App, *recordingDB) {
+	t.Helper()
+	db := &recordingDB{}
+
+	// Настройки по умолчанию: без резерва прежних имен (лишних запросов нет)
+	runtime := settings.New(nil, settings.Definitions(&config.Config{}))
+	userService := services.NewUserService(repository.New(db), db, runtime, policy, nil, nil, nil)
+	accountService := services.NewAccountService(repository.New(db), db, nil, nil, "")
+	handler := NewUserHandler(userService, accountService, nil)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/users", handler.CreateUser)
+	app.Get("/users/:id", testCaller, handler.GetUser)
+	app.Put("/users/:id", testCaller, handler.UpdateUser)
+	return app, db
+}
+
+// testCaller опознает вызывающего по заголовкам вместо access токена
+// или подписи сервиса
+func testCaller(c *fiber.Ctx) error {
+	if service := c.Get("X-Test-Service"); service != "" {
+		reqctx.SetService(c, service)
+		return c.Next()
+	}
+	if c.Get("X-Test-User-ID") != "" {
+		id, _ := strconv.Atoi(c.Get("X-Test-User-ID"))
+		reqctx.SetUser(c, reqctx.User{ID: id, Role: c.Get("X-Test-Role")})
+	}
+	return c.Next()
+}
+
+// postUser отправляет запрос на создание пользователя с указанными именами
+func postUser(t *testing.T, app *fiber.App, firstName, lastName string) (int, models.ErrorResponse) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{
+		"email":      "ivan@example.com",
+		"username":   "ivan",
+		"password":   "password123",
+		"first_name": firstName,
+		"last_name":  lastName,
+	})
+
+	req := httptest.NewRequest(fiber.MethodPost, "/users", strings.NewReader(string(body)))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req, 5000)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var errResp models.ErrorResponse
+	_ = json.NewDecoder(resp.Body).Decode(&errResp)
+	return resp.StatusCode, errResp
+}
+
+// insertedArgs возвращает строковые аргументы единственного INSERT
+// (string и непустые pgtype.Text)
+func insertedArgs(t *testing.T, d *recordingDB) []string {
+	t.Helper()
+	queries := d.queries()
+	if len(queries) != 1 {
+		t.Fatalf("запросов к БД: %d, want 1", len(queries))
+	}
+	var args []string
+	for _, v := range queries[0] {
+		switch v := v.(type) {
+		case string:
+			args = append(args, v)
+		case pgtype.Text:
+			if v.Valid {
+				args = append(args, v.String)
+			}
+		}
+	}
+	return args
+}
+
+// containsArg сообщает, передано ли значение в запрос
+func containsArg(args []string, want string) bool {
+	for _, arg := range args {
+		if arg == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCreateUserStripsXSSFromNames(t *testing.T) {
+	app, d := newUserTestApp(t, sanitize.PolicyStrip)
+
+	postUser(t, app, `<b>Иван</b><script>alert(document.cookie)</script>`, `Петров<img src=x onerror=alert(1)>`)
+
+	args := insertedArgs(t, d)
+	for _, arg := range args {
+		if strings.ContainsAny(arg, "<>") || strings.Contains(arg, "alert") {
+			t.Errorf("в БД передана разметка: %q", arg)
+		}
+	}
+	if !containsArg(args, "Иван") || !containsArg(args, "Петров") {
+		t.Errorf("аргументы INSERT = %q, want имена Иван и Петров", args)
+	}
+}
+
+func TestCreateUserEscapesXSSInNames(t *testing.T) {
+	app, d := newUserTestApp(t, sanitize.PolicyEscape)
+
+	postUser(t, app, `<script>alert(1)</script>`, "Петров")
+
+	args := insertedArgs(t, d)
+	if !containsArg(args, "&lt;script&gt;alert(1)&lt;/script&gt;") {
+		t.Errorf("аргументы INSERT = %q, want экранированное имя", args)
+	}
+}
+
+func TestCreateUserRejectsXSSInNames(t *testing.T) {
+	app, d := newUserTestApp(t, sanitize.PolicyReject)
+
+	status, resp := postUser(t, app, "Иван", `<svg/onload=alert(1)>`)
+
+	if status != fiber.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", status, fiber.StatusUnprocessableEntity)
+	}
+	if _, ok := resp.Details["last_name"]; !ok {
+		t.Errorf("details = %v, want ошибку для last_name", resp.Details)
+	}
+	if n := len(d.queries()); n != 0 {
+		t.Errorf("запросов к БД: %d, want 0", n)
+	}
+}
+
+func TestCreateUserKeepsPlainNames(t *testing.T) {
+	for _, policy := range []sanitize.Policy{sanitize.PolicyStrip, sanitize.PolicyReject} {
+		t.Run(string(policy), func(t *testing.T) {
+			app, d := newUserTestApp(t, policy)
+
+			postUser(t, app, "Анна-Мария", "O'Brien")
+
+			args := insertedArgs(t, d)
+			if !containsArg(args, "Анна-Мария") || !containsArg(args, "O'Brien") {
+				t.Errorf("аргументы INSERT = %q, want имена без изменений", args)
+			}
+		})
+	}
+}
+
+func TestCreateUserValidationErrorResponse(t *testing.T) {
+	app, d := newUserTestApp(t, sanitize.PolicyStrip)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/users", strings.NewReader(string(golden.Fixture(t, "create_user_invalid"))))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req, 5000)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+
+	golden.AssertResponse(t, "create_user_invalid", resp)
+	if n := len(d.queries()); n != 0 {
+		t.Errorf("запросов к БД: %d, want 0", n)
+	}
+}
+
+// uniqueViolation - ошибка INSERT пользователя при занятом значении
+func uniqueViolation(constraint string) error {
+	return &pgconn.PgError{Code: "23505", ConstraintName: constraint}
+}
+
+func TestCreateUserHidesRegisteredEmail(t *testing.T) {
+	for _, constraint := range []string{"idx_users_email_not_deleted", "idx_users_canonical_email_not_deleted"} {
+		t.Run(constraint, func(t *testing.T) {
+			app, d := newUserTestApp(t, sanitize.PolicyStrip)
+			d.rowErr = uniqueViolation(constraint)
+
+			body := `{"email": "Ivan@Example.com", "username": "random-name", "password": "password123"}`
+			req := httptest.NewRequest(fiber.MethodPost, "/users", strings.NewReader(body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req, 5000)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			// Ответ того же вида, что у нового аккаунта
+			var user models.UserResponse
+			if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusCreated {
+				t.Errorf("status = %d, want %d", resp.StatusCode, fiber.StatusCreated)
+			}
+			if user.ID == "" || user.Email != "ivan@example.com" || user.Username != "random-name" || user.Status != "pending_verification" {
+				t.Errorf("ответ %+v, want новый аккаунт ivan@example.com", user)
+			}
+
+			// После INSERT - поиск владельца адреса для письма
+			queries := d.queries()
+			if len(queries) != 2 || !slices.Contains(queries[1], interface{}("ivan@example.com")) {
+				t.Errorf("запросы к БД %v, want INSERT и поиск владельца ivan@example.com", queries)
+			}
+		})
+	}
+}
+
+func TestCreateUserRejectsTakenUsername(t *testing.T) {
+	app, d := newUserTestApp(t, sanitize.PolicyStrip)
+	d.rowErr = uniqueViolation("idx_users_username_not_deleted")
+
+	status, resp := postUser(t, app, "Иван", "Петров")
+
+	if status != fiber.StatusConflict || resp.Code != "USER_ALREADY_EXISTS" {
+		t.Errorf("ответ %d %q, want %d USER_ALREADY_EXISTS", status, resp.Code, fiber.StatusConflict)
+	}
+}
+
+func TestUpdateUserRequiresOwnerOrAdmin(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller string
+		role   string
+		denied bool
+	}{
+		{"чужой профиль", "7", auth.RoleUser, true},
+		{"свой профиль", "42", auth.RoleUser, false},
+		{"администратор", "7", auth.RoleAdmin, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app, d := newUserTestApp(t, sanitize.PolicyStrip)
+			d.resolveID = 42
+
+			req := httptest.NewRequest(fiber.MethodPut, "/users/"+uuid.NewString(), strings.NewReader(`{"email": "attacker@example.com"}`))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			req.Header.Set("X-Test-User-ID", tc.caller)
+			req.Header.Set("X-Test-Role", tc.role)
+			resp, err := app.Test(req, 5000)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+
+			// Отказ - до изменения: к БД был только поиск пользователя
+			if denied := resp.StatusCode == fiber.StatusForbidden; denied != tc.denied {
+				t.Errorf("status = %d, ожидался отказ: %v", resp.StatusCode, tc.denied)
+			}
+			if queries := len(d.queries()); tc.denied && queries != 1 {
+				t.Errorf("запросов к БД: %d, want 1", queries)
+			}
+		})
+	}
+}
+
+func TestGetUserRequiresOwnerOrAdmin(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int // Ожидаемый отказ; 0 - запрос дошел до чтения пользователя
+	}{
+		{"анонимный", nil, fiber.StatusUnauthorized},
+		{"чужой профиль", map[string]string{"X-Test-User-ID": "7", "X-Test-Role": auth.RoleUser}, fiber.StatusForbidden},
+		{"свой профиль", map[string]string{"X-Test-User-ID": "42", "X-Test-Role": auth.RoleUser}, 0},
+		{"администратор", map[string]string{"X-Test-User-ID": "7", "X-Test-Role": auth.RoleAdmin}, 0},
+		{"внутренний сервис", map[string]string{"X-Test-Service": "billing"}, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app, d := newUserTestApp(t, sanitize.PolicyStrip)
+			d.resolveID = 42
+
+			req := httptest.NewRequest(fiber.MethodGet, "/users/"+uuid.NewString(), nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			resp, err := app.Test(req, 5000)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+
+			// Отказ - до чтения профиля: к БД был только поиск пользователя
+			queries := len(d.queries())
+			if tc.status != 0 && (resp.StatusCode != tc.status || queries != 1) {
+				t.Errorf("status = %d, запросов %d; want %d, 1", resp.StatusCode, queries, tc.status)
+			}
+			if tc.status == 0 && queries < 2 {
+				t.Errorf("status = %d, профиль не читался", resp.StatusCode)
+			}
+		})
+	}
+}
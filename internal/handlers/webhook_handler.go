@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/services"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// WebhookHandler обрабатывает подписки на события пользователей (/admin/v1/webhooks)
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+// NewWebhookHandler создает новый обработчик подписок на события
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook обрабатывает POST /admin/v1/webhooks
+// Регистрирует подписку; ключ подписи есть только в этом ответе
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	// 1. Парсим тело запроса
+	var req models.CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Невалидный JSON",
+			Code:  "INVALID_JSON",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	// 2. Создаем подписку от имени текущего администратора
+	user, _ := reqctx.GetUser(c)
+	webhook, err := h.webhookService.CreateWebhook(c.UserContext(), req, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(webhook)
+}
+
+// ListWebhooks обрабатывает GET /admin/v1/webhooks
+// Возвращает все подписки без ключей подписи
+func (h *WebhookHandler) ListWebhooks(c *fiber.Ctx) error {
+	webhooks, err := h.webhookService.ListWebhooks(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(webhooks)
+}
+
+// DeleteWebhook обрабатывает DELETE /admin/v1/webhooks/:id
+// Удаляет подписку; недоставленные события больше не отправляются
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	id, err := publicid.Parse(c.Params("id"))
+	if err != nil {
+		return invalidWebhookID(c)
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.UserContext(), id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListDeliveries обрабатывает GET /admin/v1/webhooks/:id/deliveries
+// Возвращает последние доставки событий подписки со статусом и ошибкой
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	id, err := publicid.Parse(c.Params("id"))
+	if err != nil {
+		return invalidWebhookID(c)
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(deliveries)
+}
+
+// invalidWebhookID отвечает 400 на нечисловой ID подписки
+func invalidWebhookID(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+		Error: "Невалидный ID подписки",
+		Code:  "INVALID_WEBHOOK_ID",
+	})
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/wellknown"
+	"github.com/gofiber/fiber/v2"
+)
+
+// wellKnownCacheControl - документы меняются только с конфигурацией,
+// поэтому их можно кешировать
+const wellKnownCacheControl = "public, max-age=3600"
+
+// WellKnownHandler обрабатывает документы /.well-known/
+type WellKnownHandler struct {
+	documents *wellknown.Documents
+}
+
+// NewWellKnownHandler создает новый обработчик документов /.well-known/
+func NewWellKnownHandler(documents *wellknown.Documents) *WellKnownHandler {
+	return &WellKnownHandler{
+		documents: documents,
+	}
+}
+
+// SecurityTxt обрабатывает GET /.well-known/security.txt
+// 404, если контакты для сообщений об уязвимостях не заданы
+func (h *WellKnownHandler) SecurityTxt(c *fiber.Ctx) error {
+	text, ok := h.documents.SecurityTxt(time.Now())
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "security.txt не настроен",
+			Code:  "NOT_FOUND",
+		})
+	}
+
+	c.Set(fiber.HeaderCacheControl, wellKnownCacheControl)
+	c.Set(fiber.HeaderContentType, "text/plain; charset=utf-8")
+	return c.SendString(text)
+}
+
+// ChangePassword обрабатывает GET /.well-known/change-password
+// Перенаправляет на страницу смены пароля во фронтенде
+func (h *WellKnownHandler) ChangePassword(c *fiber.Ctx) error {
+	return c.Redirect(h.documents.ChangePasswordURL(), fiber.StatusFound)
+}
+
+// JWKS обрабатывает GET /.well-known/jwks.json
+func (h *WellKnownHandler) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, wellKnownCacheControl)
+	return c.JSON(h.documents.JWKS())
+}
+
+// OpenIDConfiguration обрабатывает GET /.well-known/openid-configuration
+func (h *WellKnownHandler) OpenIDConfiguration(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, wellKnownCacheControl)
+	return c.JSON(h.documents.OpenIDConfiguration())
+}
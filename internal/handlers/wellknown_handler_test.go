@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/golden"
+	"github.com/Soundveyve/fiber-backend/internal/wellknown"
+)
+
+func TestWellKnownDocuments(t *testing.T) {
+	cfg := &config.Config{
+		Auth:      config.AuthConfig{Issuer: "fiber-backend"},
+		WellKnown: config.WellKnownConfig{BaseURL: "https://api.example.com"},
+	}
+	handler := NewWellKnownHandler(wellknown.New(cfg))
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get(wellknown.SecurityTxtPath, handler.SecurityTxt)
+	app.Get(wellknown.JWKSPath, handler.JWKS)
+	app.Get(wellknown.OpenIDConfigurationPath, handler.OpenIDConfiguration)
+
+	tests := []struct {
+		golden string
+		path   string
+	}{
+		{"wellknown_openid_configuration", wellknown.OpenIDConfigurationPath},
+		{"wellknown_jwks", wellknown.JWKSPath},
+		// Без SECURITY_CONTACTS документа нет
+		{"wellknown_security_txt_unconfigured", wellknown.SecurityTxtPath},
+	}
+	for _, tc := range tests {
+		t.Run(tc.golden, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tc.path, nil))
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			golden.AssertResponse(t, tc.golden, resp)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Package health проверяет доступность внешних зависимостей сервиса.
+//
+// Зависимости (БД, Redis, SMTP) опрашиваются параллельно (пакет fanout),
+// каждая со своим таймаутом, поэтому одна зависшая зависимость не задерживает ответ пробы
+// дольше таймаута. Критичная зависимость без ответа означает, что инстанс
+// не может обслуживать запросы и трафик на него направлять нельзя;
+// некритичная только помечается в отчете.
+package health
+
+import (
+	"context"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/fanout"
+)
+
+// Статусы зависимости
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Dependency - проверяемая зависимость
+type Dependency struct {
+	Name     string
+	Critical bool // Без нее инстанс не готов принимать трафик
+
+	// Fallback описывает, как сервис работает без зависимости
+	// (rate_limit=memory); попадает в отчет, когда она недоступна
+	Fallback string
+
+	// Check возвращает ошибку, если зависимость недоступна
+	// Должна соблюдать дедлайн ctx
+	Check func(ctx context.Context) error
+}
+
+// Result - результат проверки одной зависимости
+type Result struct {
+	Name     string
+	Critical bool
+	Fallback string // Dependency.Fallback, только для StatusDown
+	Status   string // StatusUp или StatusDown
+	Latency  time.Duration
+	Err      error
+}
+
+// Report - результат проверки всех зависимостей
+type Report struct {
+	Results []Result // В порядке зависимостей
+
+	// Healthy - все критичные зависимости доступны
+	Healthy bool
+}
+
+// Probe проверяет зависимости параллельно, ограничивая каждую проверку timeout
+// Все зависимости - необязательные части fanout: недоступная зависимость
+// не отменяет проверку остальных и попадает в отчет
+func Probe(ctx context.Context, deps []Dependency, timeout time.Duration) Report {
+	parts := make([]fanout.Part, len(deps))
+	for i, dep := range deps {
+		parts[i] = fanout.Part{Name: dep.Name, Run: dep.Check}
+	}
+	statuses, _ := fanout.Run(ctx, timeout, parts...)
+
+	report := Report{
+		Results: make([]Result, len(deps)),
+		Healthy: true,
+	}
+	for i, dep := range deps {
+		report.Results[i] = toResult(dep, statuses[i])
+		if dep.Critical && report.Results[i].Status != StatusUp {
+			report.Healthy = false
+		}
+	}
+
+	return report
+}
+
+// toResult переводит статус проверки в результат зависимости
+func toResult(dep Dependency, status fanout.Status) Result {
+	result := Result{
+		Name:     dep.Name,
+		Critical: dep.Critical,
+		Status:   StatusUp,
+		Latency:  status.Latency,
+	}
+	if !status.OK() {
+		result.Status = StatusDown
+		result.Fallback = dep.Fallback
+		result.Err = status.Err
+	}
+	return result
+}
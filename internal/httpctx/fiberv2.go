@@ -0,0 +1,36 @@
+//go:build !fiberv3
+
+package httpctx
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Adapt превращает Handler в handler Fiber v2
+func Adapt(h Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return h(v2Ctx{c})
+	}
+}
+
+// v2Ctx - Ctx поверх *fiber.Ctx Fiber v2
+type v2Ctx struct {
+	c *fiber.Ctx
+}
+
+func (x v2Ctx) Context() context.Context    { return x.c.UserContext() }
+func (x v2Ctx) Params(key string) string    { return x.c.Params(key) }
+func (x v2Ctx) Query(key string) string     { return x.c.Query(key) }
+func (x v2Ctx) Get(key string) string       { return x.c.Get(key) }
+func (x v2Ctx) Set(key, value string)       { x.c.Set(key, value) }
+func (x v2Ctx) Location(path string)        { x.c.Location(path) }
+func (x v2Ctx) Bind(out interface{}) error  { return x.c.BodyParser(out) }
+func (x v2Ctx) JSON(data interface{}) error { return x.c.JSON(data) }
+func (x v2Ctx) SendStatus(status int) error { return x.c.SendStatus(status) }
+
+func (x v2Ctx) Status(status int) Ctx {
+	x.c.Status(status)
+	return x
+}
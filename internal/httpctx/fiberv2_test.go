@@ -0,0 +1,85 @@
+//go:build !fiberv3
+
+package httpctx
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ctxKey struct{}
+
+func TestAdapt(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.SetUserContext(context.WithValue(c.UserContext(), ctxKey{}, "alice"))
+		return c.Next()
+	})
+	app.Post("/items/:id", Adapt(func(c Ctx) error {
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := c.Bind(&body); err != nil {
+			return err
+		}
+		c.Set("X-Test", c.Get("X-Request"))
+		c.Location("/items/" + c.Params("id"))
+		return c.Status(fiber.StatusCreated).JSON(map[string]string{
+			"id":   c.Params("id"),
+			"sort": c.Query("sort"),
+			"name": body.Name,
+			"user": c.Context().Value(ctxKey{}).(string),
+		})
+	}))
+
+	req := httptest.NewRequest("POST", "/items/42?sort=name", strings.NewReader(`{"name":"box"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request", "ping")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+
+	if resp.StatusCode != fiber.StatusCreated {
+		t.Errorf("статус %d, ожидался 201", resp.StatusCode)
+	}
+	if got := resp.Header.Get("X-Test"); got != "ping" {
+		t.Errorf("X-Test %q, ожидался ping", got)
+	}
+	if got := resp.Header.Get("Location"); got != "/items/42" {
+		t.Errorf("Location %q, ожидался /items/42", got)
+	}
+
+	raw, _ := io.ReadAll(resp.Body)
+	var got map[string]string
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("ответ %s: %v", raw, err)
+	}
+	want := map[string]string{"id": "42", "sort": "name", "name": "box", "user": "alice"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, ожидалось %q", k, got[k], v)
+		}
+	}
+}
+
+func TestAdaptSendStatus(t *testing.T) {
+	app := fiber.New()
+	app.Delete("/items/:id", Adapt(func(c Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	}))
+
+	resp, err := app.Test(httptest.NewRequest("DELETE", "/items/1", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusNoContent {
+		t.Errorf("статус %d, ожидался 204", resp.StatusCode)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build fiberv3
+
+package httpctx
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Adapt превращает Handler в handler Fiber v3
+func Adapt(h Handler) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		return h(v3Ctx{c})
+	}
+}
+
+// v3Ctx - Ctx поверх fiber.Ctx Fiber v3
+type v3Ctx struct {
+	c fiber.Ctx
+}
+
+func (x v3Ctx) Context() context.Context    { return x.c.Context() }
+func (x v3Ctx) Params(key string) string    { return x.c.Params(key) }
+func (x v3Ctx) Query(key string) string     { return x.c.Query(key) }
+func (x v3Ctx) Get(key string) string       { return x.c.Get(key) }
+func (x v3Ctx) Set(key, value string)       { x.c.Set(key, value) }
+func (x v3Ctx) Location(path string)        { x.c.Location(path) }
+func (x v3Ctx) Bind(out interface{}) error  { return x.c.Bind().Body(out) }
+func (x v3Ctx) JSON(data interface{}) error { return x.c.JSON(data) }
+func (x v3Ctx) SendStatus(status int) error { return x.c.SendStatus(status) }
+
+func (x v3Ctx) Status(status int) Ctx {
+	x.c.Status(status)
+	return x
+}
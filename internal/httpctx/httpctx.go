@@ -0,0 +1,61 @@
+// Package httpctx - контекст запроса для handlers без привязки к версии Fiber.
+//
+// В Fiber v3 *fiber.Ctx стал интерфейсом fiber.Ctx, UserContext()
+// переименован в Context(), а BodyParser заменен на Bind().Body(). Чтобы
+// не переписывать все handlers одним изменением, handler принимает
+// httpctx.Ctx - небольшой интерфейс из методов, которые handlers
+// действительно используют, - и регистрируется в роутере через Adapt:
+//
+//	api.Get("/profiles/:username", httpctx.Adapt(profileHandler.GetProfile))
+//
+// Adapt собирается под версию Fiber тегом сборки:
+//
+//   - без тегов - fiberv2.go, поверх *fiber.Ctx из github.com/gofiber/fiber/v2;
+//   - fiberv3   - fiberv3.go, поверх fiber.Ctx из github.com/gofiber/fiber/v3.
+//
+// Переведенный на Ctx handler собирается с обеими версиями без изменений.
+// Значения запроса (пользователь, тенант, локаль) handler читает функциями
+// reqctx.*FromContext(c.Context()), а не по *fiber.Ctx. Если handler нужен
+// метод, которого нет в Ctx, метод добавляется в интерфейс и в оба адаптера,
+// а не вызывается через приведение к *fiber.Ctx.
+package httpctx
+
+import "context"
+
+// Ctx - контекст запроса, который видит handler
+type Ctx interface {
+	// Context возвращает context.Context запроса со значениями reqctx
+	// (UserContext в Fiber v2)
+	Context() context.Context
+
+	// Params возвращает параметр пути (:username)
+	Params(key string) string
+
+	// Query возвращает параметр строки запроса
+	Query(key string) string
+
+	// Get возвращает заголовок запроса
+	Get(key string) string
+
+	// Set устанавливает заголовок ответа
+	Set(key, value string)
+
+	// Location устанавливает заголовок Location ответа
+	Location(path string)
+
+	// Bind разбирает тело запроса по Content-Type в out
+	// (BodyParser в Fiber v2, Bind().Body в Fiber v3)
+	Bind(out interface{}) error
+
+	// Status устанавливает статус ответа
+	Status(status int) Ctx
+
+	// JSON отправляет data в JSON кодеком приложения (APP_JSON_CODEC)
+	JSON(data interface{}) error
+
+	// SendStatus отправляет ответ без тела
+	SendStatus(status int) error
+}
+
+// Handler - handler, переведенный на Ctx
+type Handler func(c Ctx) error
@@ -0,0 +1,39 @@
+// Package identity описывает проверку внешних (OAuth) учетных записей.
+//
+// Клиент проходит OAuth у провайдера сам и передает серверу полученный
+// токен. Verifier проверяет токен у провайдера и возвращает ID пользователя
+// провайдера - доверять ID, присланному клиентом напрямую, нельзя.
+package identity
+
+import (
+	"context"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+)
+
+// ErrProviderNotSupported возвращается для провайдера без настроенной проверки
+var ErrProviderNotSupported = apperrors.Invalid("PROVIDER_NOT_SUPPORTED", "провайдер входа не поддерживается")
+
+// ErrInvalidToken возвращается для невалидного или просроченного токена
+var ErrInvalidToken = apperrors.Invalid("INVALID_PROVIDER_TOKEN", "невалидный токен провайдера")
+
+// External - проверенная учетная запись у внешнего провайдера
+type External struct {
+	Provider       string // google, github, apple
+	ProviderUserID string // Стабильный ID пользователя у провайдера
+	Email          string // Email у провайдера, может быть пустым
+}
+
+// Verifier проверяет токен, полученный клиентом от провайдера
+type Verifier interface {
+	Verify(ctx context.Context, provider, token string) (External, error)
+}
+
+// Unconfigured - Verifier без провайдеров: привязка внешних учетных
+// записей отключена, пока не настроен ни один провайдер
+type Unconfigured struct{}
+
+// Verify реализует Verifier
+func (Unconfigured) Verify(ctx context.Context, provider, token string) (External, error) {
+	return External{}, ErrProviderNotSupported
+}
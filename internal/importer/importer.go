@@ -0,0 +1,213 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Поддерживаемые форматы входных данных
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Mapping описывает, из каких полей исходной системы брать поля пользователя
+// Значения - имена колонок CSV (из заголовка) или ключей JSON объектов.
+// Пустое значение означает, что поле в источнике отсутствует
+type Mapping struct {
+	Email        string `json:"email"`
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash"` // bcrypt хеш ($2a$, $2b$, $2y$)
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	CreatedAt    string `json:"created_at"`
+
+	// CreatedAtLayout - формат даты создания в терминах Go
+	// (по умолчанию - любой формат utc.Parse, включая RFC3339)
+	CreatedAtLayout string `json:"created_at_layout"`
+}
+
+// DefaultMapping - сопоставление по одноименным полям
+// Используется, когда клиент не передал свою схему
+func DefaultMapping() Mapping {
+	return Mapping{
+		Email:        "email",
+		Username:     "username",
+		PasswordHash: "password_hash",
+		FirstName:    "first_name",
+		LastName:     "last_name",
+		CreatedAt:    "created_at",
+	}
+}
+
+// Validate проверяет, что обязательные поля сопоставлены
+func (m Mapping) Validate() error {
+	if m.Email == "" || m.Username == "" {
+		return errors.New("в схеме импорта обязательны поля email и username")
+	}
+	return nil
+}
+
+// Record - пользователь, извлеченный из исходных данных
+type Record struct {
+	Row          int // Номер записи в источнике (с 1, без заголовка CSV)
+	Email        string
+	Username     string
+	PasswordHash string // Пустой, если источник не содержит хеш
+	FirstName    string
+	LastName     string
+	CreatedAt    *time.Time // nil, если дата создания не передана
+}
+
+// Decode читает записи в формате format и применяет к ним схему m
+// Ошибки отдельных записей не прерывают разбор и возвращаются списком,
+// ошибка верхнего уровня означает, что источник прочитать невозможно
+func Decode(r io.Reader, format string, m Mapping) ([]Record, []models.ImportRowError, error) {
+	if err := m.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	var rows []map[string]string
+	var err error
+	switch format {
+	case FormatCSV:
+		rows, err = readCSV(r)
+	case FormatJSON:
+		rows, err = readJSON(r)
+	default:
+		return nil, nil, fmt.Errorf("неподдерживаемый формат импорта: %s", format)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records := make([]Record, 0, len(rows))
+	var rowErrors []models.ImportRowError
+	for i, row := range rows {
+		record, err := m.apply(row)
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		record.Row = i + 1
+		records = append(records, record)
+	}
+
+	return records, rowErrors, nil
+}
+
+// apply строит Record из одной исходной записи
+func (m Mapping) apply(row map[string]string) (Record, error) {
+	get := func(field string) string {
+		if field == "" {
+			return ""
+		}
+		return strings.TrimSpace(row[field])
+	}
+
+	record := Record{
+		Email:        get(m.Email),
+		Username:     get(m.Username),
+		PasswordHash: get(m.PasswordHash),
+		FirstName:    get(m.FirstName),
+		LastName:     get(m.LastName),
+	}
+
+	if record.Email == "" || record.Username == "" {
+		return Record{}, errors.New("пустой email или username")
+	}
+
+	// Принимаем только bcrypt хеши: другой алгоритм не пройдет VerifyPassword
+	if record.PasswordHash != "" {
+		if _, err := bcrypt.Cost([]byte(record.PasswordHash)); err != nil {
+			return Record{}, errors.New("password_hash не является bcrypt хешем")
+		}
+	}
+
+	if raw := get(m.CreatedAt); raw != "" {
+		// Без явного формата принимаются те же форматы, что и в API
+		// Время без зоны считается UTC
+		var createdAt time.Time
+		var err error
+		if m.CreatedAtLayout == "" {
+			createdAt, err = utc.Parse(raw)
+		} else {
+			createdAt, err = time.ParseInLocation(m.CreatedAtLayout, raw, time.UTC)
+		}
+		if err != nil {
+			return Record{}, fmt.Errorf("невалидная дата создания %q", raw)
+		}
+		createdAt = createdAt.UTC()
+		record.CreatedAt = &createdAt
+	}
+
+	return record, nil
+}
+
+// readCSV читает CSV с заголовком в список записей "колонка -> значение"
+func readCSV(r io.Reader) ([]map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения заголовка CSV: %w", err)
+	}
+
+	var rows []map[string]string
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения CSV: %w", err)
+		}
+
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			if i < len(values) {
+				row[column] = values[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// readJSON читает JSON массив объектов
+// Нестроковые значения (числа, bool) приводятся к строке
+func readJSON(r io.Reader) ([]map[string]string, error) {
+	var raw []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("ошибка чтения JSON: %w", err)
+	}
+
+	rows := make([]map[string]string, len(raw))
+	for i, obj := range raw {
+		row := make(map[string]string, len(obj))
+		for key, value := range obj {
+			switch v := value.(type) {
+			case nil:
+			case string:
+				row[key] = v
+			default:
+				row[key] = fmt.Sprint(v)
+			}
+		}
+		rows[i] = row
+	}
+
+	return rows, nil
+}
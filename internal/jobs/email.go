@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Soundveyve/fiber-backend/internal/mailer"
+)
+
+// SendEmail - обработчик заданий KindSendEmail
+// payload - mailer.Message; ошибка SMTP сервера повторяется с задержкой
+func SendEmail(m mailer.Mailer) Handler {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var msg mailer.Message
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return Permanent(fmt.Errorf("невалидное письмо в задании: %w", err))
+		}
+		return m.Send(ctx, msg)
+	}
+}
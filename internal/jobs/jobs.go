@@ -0,0 +1,349 @@
+// Package jobs - очередь фоновых заданий в PostgreSQL.
+//
+// Задание - строка таблицы jobs с типом (kind) и JSON параметрами.
+// Сервисы ставят задания через Enqueue, воркеры всех инстансов разбирают
+// очередь через FOR UPDATE SKIP LOCKED и вызывают обработчик, который
+// зарегистрирован для типа (Register). Неудачная попытка повторяется
+// с экспоненциальной задержкой (Backoff), пока не кончатся попытки.
+// Ошибку, которую повтор не исправит, обработчик оборачивает в Permanent.
+//
+// Задания по расписанию (Schedule) ставятся с ключом периода: сколько бы
+// инстансов ни работало, за период выполняется одно задание.
+//
+// Очередь в БД, а не в памяти: задание переживает перезапуск инстанса,
+// а постановка задания видна в той же БД, что и данные, которые его
+// породили. При остановке (отмена ctx в Run) воркеры не берут новых
+// заданий и дожидаются текущих.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/safe"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Типы заданий
+const (
+	KindSendEmail               = "send_email"                // Отправка письма (mailer.Message)
+	KindPurgeRefreshTokens      = "purge_refresh_tokens"      // Удаление истекших refresh токенов
+	KindDeactivateInactiveUsers = "deactivate_inactive_users" // Деактивация давно не входивших пользователей
+	KindDeliverWebhook          = "deliver_webhook"           // Доставка события подписчику (webhooks)
+	KindFlushNotifications      = "flush_notifications"       // Отправка накопленных уведомлений (notify)
+	KindPurgeUserTombstones     = "purge_user_tombstones"     // Удаление старых записей об удаленных пользователях
+	KindRunOperation            = "run_operation"             // Выполнение длительной операции (operations)
+	KindPurgeOperations         = "purge_operations"          // Удаление старых завершенных операций
+	KindArchiveRecords          = "archive_records"           // Перенос старых записей журналов в архив (archive)
+)
+
+// Задержки между попытками: 30s, 1m, 2m, ... не больше часа
+const (
+	backoffBase = 30 * time.Second
+	backoffMax  = time.Hour
+)
+
+// maintenanceInterval - как часто ставятся задания по расписанию,
+// возвращаются зависшие задания и удаляются старые завершенные
+const maintenanceInterval = time.Minute
+
+// Handler выполняет задание с параметрами payload
+// Ошибка означает неудачную попытку; Permanent(err) - без повторов
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// permanentError - ошибка, которую повтор задания не исправит
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку обработчика как окончательную:
+// задание сразу получает статус failed без повторов
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// attemptKey - ключ контекста обработчика с номером попытки
+type attemptKey struct{}
+
+// attempt - номер текущей попытки и число попыток задания
+type attempt struct {
+	current, max int32
+}
+
+// LastAttempt сообщает обработчику, что попытка последняя: после ее
+// неудачи задание получит статус failed. Вне обработчика - false
+func LastAttempt(ctx context.Context) bool {
+	a, ok := ctx.Value(attemptKey{}).(attempt)
+	return ok && a.current >= a.max
+}
+
+// schedule - задание, которое ставится раз в период every
+type schedule struct {
+	kind  string
+	every time.Duration
+}
+
+// Queue - очередь фоновых заданий
+type Queue struct {
+	queries   *repository.Queries
+	cfg       config.JobsConfig
+	handlers  map[string]Handler
+	schedules []schedule
+}
+
+// New создает очередь заданий
+// Обработчики и расписание регистрируются до запуска Run
+func New(queries *repository.Queries, cfg config.JobsConfig) *Queue {
+	return &Queue{
+		queries:  queries,
+		cfg:      cfg,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register задает обработчик заданий типа kind
+func (q *Queue) Register(kind string, handler Handler) {
+	q.handlers[kind] = handler
+}
+
+// Schedule ставит задание kind без параметров раз в период every
+// Период отсчитывается от полуночи UTC: задание с every = 24h
+// выполняется ночью по UTC
+func (q *Queue) Schedule(kind string, every time.Duration) {
+	q.schedules = append(q.schedules, schedule{kind: kind, every: every})
+}
+
+// Enqueue ставит задание в очередь
+// payload сериализуется в JSON и передается обработчику
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации задания %s: %w", kind, err)
+	}
+
+	_, err = q.queries.EnqueueJob(ctx, repository.EnqueueJobParams{
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: int32(q.cfg.MaxAttempts),
+		RunAt:       time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка постановки задания %s: %w", kind, err)
+	}
+	return nil
+}
+
+// Stats возвращает глубину очереди по типам и статусам
+func (q *Queue) Stats(ctx context.Context) (*models.JobQueueResponse, error) {
+	rows, err := q.queries.JobQueueStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения статистики очереди: %w", err)
+	}
+
+	resp := &models.JobQueueResponse{Kinds: make([]models.JobKindStats, 0, len(rows))}
+	for _, row := range rows {
+		resp.Kinds = append(resp.Kinds, models.JobKindStats{
+			Kind:        row.Kind,
+			Status:      row.Status,
+			Count:       int(row.Count),
+			OldestRunAt: utc.From(row.OldestRunAt),
+		})
+		switch row.Status {
+		case "pending":
+			resp.Pending += int(row.Count)
+		case "running":
+			resp.Running += int(row.Count)
+		case "failed":
+			resp.Failed += int(row.Count)
+		}
+	}
+	return resp, nil
+}
+
+// Run запускает воркеры и обслуживание очереди, пока не отменен ctx
+// Возвращается, когда текущие задания выполнены
+func (q *Queue) Run(ctx context.Context) {
+	slog.Info("🧰 Очередь заданий запущена", "workers", q.cfg.Workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < q.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		q.maintain(ctx)
+	}()
+	wg.Wait()
+
+	slog.Info("🧰 Очередь заданий остановлена")
+}
+
+// work выполняет задания одно за другим; если очередь пуста,
+// ждет PollInterval
+func (q *Queue) work(ctx context.Context) {
+	for {
+		processed, err := q.processNext(ctx)
+		// Ошибки из-за остановки воркера не логируем
+		if err != nil && ctx.Err() == nil {
+			slog.Error("❌ Ошибка очереди заданий", "error", err)
+		}
+		if processed && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(q.cfg.PollInterval):
+		}
+	}
+}
+
+// processNext захватывает и выполняет одно задание
+// Возвращает false, если готовых заданий нет
+func (q *Queue) processNext(ctx context.Context) (bool, error) {
+	job, err := q.queries.ClaimNextJob(ctx)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("ошибка захвата задания: %w", err)
+	}
+
+	// Остановка воркера не прерывает начатое задание: оно дорабатывает
+	// в пределах Timeout, иначе при каждом деплое попытки тратились бы зря
+	runErr := q.execute(context.WithoutCancel(ctx), &job)
+
+	// Статус сохраняем даже после отмены ctx, иначе задание зависнет в running
+	ctx = context.WithoutCancel(ctx)
+	switch {
+	case runErr == nil:
+		metrics.JobsProcessed.WithLabelValues(job.Kind, "completed").Inc()
+		if err := q.queries.CompleteJob(ctx, job.ID); err != nil {
+			return true, fmt.Errorf("ошибка сохранения статуса задания %d: %w", job.ID, err)
+		}
+		return true, nil
+
+	case errors.As(runErr, new(permanentError)) || int(job.Attempts) >= int(job.MaxAttempts):
+		metrics.JobsProcessed.WithLabelValues(job.Kind, "failed").Inc()
+		slog.Error("❌ Задание завершилось ошибкой", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", runErr)
+		return true, q.queries.FailJob(ctx, repository.FailJobParams{
+			ID:        job.ID,
+			LastError: pgtype.Text{String: runErr.Error(), Valid: true},
+		})
+
+	default:
+		delay := Backoff(int(job.Attempts))
+		metrics.JobsProcessed.WithLabelValues(job.Kind, "retried").Inc()
+		slog.Warn("⚠️  Попытка задания не удалась, повтор позже", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "retry_in", delay, "error", runErr)
+		return true, q.queries.RetryJob(ctx, repository.RetryJobParams{
+			ID:        job.ID,
+			RunAt:     time.Now().Add(delay),
+			LastError: pgtype.Text{String: runErr.Error(), Valid: true},
+		})
+	}
+}
+
+// execute вызывает обработчик задания с таймаутом
+// Паника обработчика считается неудачной попыткой, а не роняет процесс
+func (q *Queue) execute(ctx context.Context, job *repository.Job) error {
+	handler, ok := q.handlers[job.Kind]
+	if !ok {
+		return Permanent(fmt.Errorf("нет обработчика заданий %s", job.Kind))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, q.cfg.Timeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, attemptKey{}, attempt{current: job.Attempts, max: job.MaxAttempts})
+
+	return safe.Call(ctx, "job:"+job.Kind, func() error {
+		return handler(ctx, job.Payload)
+	})
+}
+
+// Backoff возвращает задержку перед следующей попыткой после attempt
+// неудачных: 30s, 1m, 2m, 4m, ... не больше часа
+func Backoff(attempt int) time.Duration {
+	delay := backoffBase
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= backoffMax {
+			return backoffMax
+		}
+	}
+	return delay
+}
+
+// maintain раз в maintenanceInterval ставит задания по расписанию,
+// возвращает в очередь зависшие и удаляет старые завершенные задания
+func (q *Queue) maintain(ctx context.Context) {
+	for {
+		if err := q.maintainOnce(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			slog.Error("❌ Ошибка обслуживания очереди заданий", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(maintenanceInterval):
+		}
+	}
+}
+
+// maintainOnce выполняет один проход обслуживания очереди
+func (q *Queue) maintainOnce(ctx context.Context, now time.Time) error {
+	for _, s := range q.schedules {
+		if _, err := q.queries.EnqueueUniqueJob(ctx, repository.EnqueueUniqueJobParams{
+			Kind:        s.kind,
+			Payload:     json.RawMessage("{}"),
+			MaxAttempts: int32(q.cfg.MaxAttempts),
+			UniqueKey:   pgtype.Text{String: periodKey(s.kind, s.every, now), Valid: true},
+		}); err != nil {
+			return fmt.Errorf("ошибка постановки задания %s по расписанию: %w", s.kind, err)
+		}
+	}
+
+	// Задание дольше Timeout в running - инстанс упал, не сохранив статус
+	requeued, err := q.queries.RequeueStaleJobs(ctx, pgtype.Timestamp{
+		Time:  now.Add(-q.cfg.Timeout - maintenanceInterval),
+		Valid: true,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка возврата зависших заданий: %w", err)
+	}
+	if requeued > 0 {
+		slog.Warn("⚠️  Зависшие задания возвращены в очередь", "count", requeued)
+	}
+
+	if _, err := q.queries.DeleteFinishedJobs(ctx, pgtype.Timestamp{
+		Time:  now.Add(-q.cfg.Retention),
+		Valid: true,
+	}); err != nil {
+		return fmt.Errorf("ошибка удаления завершенных заданий: %w", err)
+	}
+	return nil
+}
+
+// periodKey - ключ задания по расписанию для периода, в который попадает now
+// Периоды отсчитываются от нулевого времени, то есть от полуночи UTC
+func periodKey(kind string, every time.Duration, now time.Time) string {
+	return kind + ":" + now.UTC().Truncate(every).Format(time.RFC3339)
+}
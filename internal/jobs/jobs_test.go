@@ -0,0 +1,127 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/mailer"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, ожидалось %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestPeriodKey(t *testing.T) {
+	night := time.Date(2026, 5, 10, 0, 30, 0, 0, time.UTC)
+	evening := time.Date(2026, 5, 10, 23, 59, 0, 0, time.UTC)
+	next := time.Date(2026, 5, 11, 0, 0, 0, 0, time.UTC)
+
+	if periodKey("k", 24*time.Hour, night) != periodKey("k", 24*time.Hour, evening) {
+		t.Error("в пределах суток ключ должен совпадать")
+	}
+	if periodKey("k", 24*time.Hour, evening) == periodKey("k", 24*time.Hour, next) {
+		t.Error("в новые сутки ключ должен меняться")
+	}
+	if periodKey("a", time.Hour, night) == periodKey("b", time.Hour, night) {
+		t.Error("ключи разных типов заданий не должны совпадать")
+	}
+
+	// Часовой пояс не влияет на период
+	moscow := night.In(time.FixedZone("MSK", 3*60*60))
+	if periodKey("k", time.Hour, night) != periodKey("k", time.Hour, moscow) {
+		t.Error("ключ должен считаться по UTC")
+	}
+}
+
+// recordingMailer запоминает отправленные письма
+type recordingMailer struct {
+	sent []mailer.Message
+	err  error
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return m.err
+}
+
+func TestSendEmail(t *testing.T) {
+	m := &recordingMailer{}
+	payload, _ := json.Marshal(mailer.Message{To: "user@example.com", Subject: "Тема", Body: "Текст"})
+
+	if err := SendEmail(m)(context.Background(), payload); err != nil {
+		t.Fatalf("SendEmail: %v", err)
+	}
+	if len(m.sent) != 1 || m.sent[0].To != "user@example.com" || m.sent[0].Subject != "Тема" {
+		t.Errorf("отправлено %+v", m.sent)
+	}
+
+	// Невалидные параметры повтор не исправит
+	err := SendEmail(m)(context.Background(), json.RawMessage(`"not a message"`))
+	if !errors.As(err, new(permanentError)) {
+		t.Errorf("ошибка %v, ожидалась Permanent", err)
+	}
+
+	// Ошибка SMTP - обычная неудачная попытка
+	m.err = errors.New("421 try again later")
+	err = SendEmail(m)(context.Background(), payload)
+	if err == nil || errors.As(err, new(permanentError)) {
+		t.Errorf("ошибка %v, ожидалась ошибка с повтором", err)
+	}
+}
+
+func TestExecuteRecoversPanic(t *testing.T) {
+	q := &Queue{handlers: map[string]Handler{}}
+	q.cfg.Timeout = time.Second
+	q.Register("panic", func(context.Context, json.RawMessage) error {
+		panic("boom")
+	})
+
+	err := q.execute(context.Background(), &repository.Job{Kind: "panic"})
+	if err == nil {
+		t.Fatal("паника обработчика должна стать ошибкой попытки")
+	}
+
+	err = q.execute(context.Background(), &repository.Job{Kind: "unknown"})
+	if !errors.As(err, new(permanentError)) {
+		t.Errorf("ошибка %v, для неизвестного типа ожидалась Permanent", err)
+	}
+}
+
+func TestLastAttempt(t *testing.T) {
+	q := &Queue{handlers: map[string]Handler{}}
+	q.cfg.Timeout = time.Second
+
+	var last []bool
+	q.Register("probe", func(ctx context.Context, _ json.RawMessage) error {
+		last = append(last, LastAttempt(ctx))
+		return nil
+	})
+	_ = q.execute(context.Background(), &repository.Job{Kind: "probe", Attempts: 1, MaxAttempts: 3})
+	_ = q.execute(context.Background(), &repository.Job{Kind: "probe", Attempts: 3, MaxAttempts: 3})
+
+	if len(last) != 2 || last[0] || !last[1] {
+		t.Errorf("LastAttempt = %v, ожидалось [false true]", last)
+	}
+	if LastAttempt(context.Background()) {
+		t.Error("вне обработчика попытка не последняя")
+	}
+}
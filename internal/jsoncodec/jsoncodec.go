@@ -0,0 +1,72 @@
+// Package jsoncodec - выбор библиотеки JSON для Fiber (JSONEncoder/JSONDecoder).
+//
+// encoding/json тратит заметную долю времени ответа на больших списках
+// (GET /api/v1/users с page_size=100). Библиотека выбирается APP_JSON_CODEC:
+//
+//   - std     - encoding/json (по умолчанию);
+//   - go-json - github.com/goccy/go-json, чистый Go, работает везде;
+//   - sonic   - github.com/bytedance/sonic, JIT под amd64/arm64;
+//     собирается только с тегом sonic: go build -tags sonic ./cmd/api
+//
+// Все варианты совместимы с encoding/json по выводу: HTML экранируется,
+// ключи map сортируются, MarshalJSON типов (utc.Time) вызывается.
+// Сравнение скорости - бенчмарки в jsoncodec_test.go.
+package jsoncodec
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Default - библиотека JSON, если APP_JSON_CODEC не задан
+const Default = "std"
+
+// Codec - пара функций сериализации для fiber.Config
+type Codec struct {
+	Name      string
+	Marshal   utils.JSONMarshal
+	Unmarshal utils.JSONUnmarshal
+}
+
+// codecs - библиотеки, доступные в этой сборке
+// sonic добавляется из sonic.go при сборке с тегом sonic
+var codecs = map[string]Codec{
+	"std": {
+		Name:      "std",
+		Marshal:   json.Marshal,
+		Unmarshal: json.Unmarshal,
+	},
+	"go-json": {
+		Name:      "go-json",
+		Marshal:   gojson.Marshal,
+		Unmarshal: gojson.Unmarshal,
+	},
+}
+
+// Get возвращает библиотеку JSON по имени
+// Ошибка для неизвестного имени и для sonic в сборке без тега sonic
+func Get(name string) (Codec, error) {
+	codec, ok := codecs[name]
+	if !ok {
+		if name == "sonic" {
+			return Codec{}, fmt.Errorf("APP_JSON_CODEC=sonic требует сборки с тегом sonic (go build -tags sonic)")
+		}
+		return Codec{}, fmt.Errorf("APP_JSON_CODEC должен быть одним из: %s", strings.Join(Names(), ", "))
+	}
+	return codec, nil
+}
+
+// Names возвращает имена библиотек, доступных в этой сборке
+func Names() []string {
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
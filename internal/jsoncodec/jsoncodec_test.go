@@ -0,0 +1,115 @@
+package jsoncodec
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+)
+
+// listUsersPayload - ответ GET /api/v1/users со страницей из n пользователей
+func listUsersPayload(n int) models.ListUsersResponse {
+	created := utc.From(time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC))
+	firstName, country := "Иван <script>", "RU"
+
+	users := make([]models.UserResponse, n)
+	for i := range users {
+		users[i] = models.UserResponse{
+			ID:         fmt.Sprintf("0190d3c2-7b1e-7c4a-9f2e-%012d", i),
+			InternalID: i + 1,
+			Email:      fmt.Sprintf("user%d@example.com", i),
+			Username:   fmt.Sprintf("user%d", i),
+			FirstName:  &firstName,
+			Country:    &country,
+			Status:     "active",
+			IsActive:   true,
+			Role:       "user",
+			CreatedAt:  created,
+			UpdatedAt:  created,
+			LoginCount: i,
+		}
+	}
+	return models.ListUsersResponse{
+		Users:      users,
+		TotalCount: 10 * n,
+		Page:       1,
+		PageSize:   n,
+		TotalPages: 10,
+		HasNext:    true,
+	}
+}
+
+func TestCodecsMatchStd(t *testing.T) {
+	payload := listUsersPayload(3)
+	want, err := codecs["std"].Marshal(payload)
+	if err != nil {
+		t.Fatalf("std: %v", err)
+	}
+
+	for _, name := range Names() {
+		codec, _ := Get(name)
+		got, err := codec.Marshal(payload)
+		if err != nil {
+			t.Errorf("%s: Marshal: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: вывод отличается от encoding/json:\n%s\n%s", name, got, want)
+		}
+
+		var decoded models.ListUsersResponse
+		if err := codec.Unmarshal(want, &decoded); err != nil {
+			t.Errorf("%s: Unmarshal: %v", name, err)
+			continue
+		}
+		if len(decoded.Users) != 3 || *decoded.Users[0].FirstName != "Иван <script>" || !decoded.Users[2].CreatedAt.Equal(payload.Users[2].CreatedAt.Time) {
+			t.Errorf("%s: декодировано %+v", name, decoded.Users)
+		}
+	}
+}
+
+func TestGetUnknown(t *testing.T) {
+	if _, err := Get("easyjson"); err == nil {
+		t.Error("для неизвестной библиотеки ожидалась ошибка")
+	}
+}
+
+// BenchmarkMarshalListUsers - сериализация страницы из 100 пользователей
+//
+//	go test -bench . -benchmem ./internal/jsoncodec
+//	go test -tags sonic -bench . -benchmem ./internal/jsoncodec
+func BenchmarkMarshalListUsers(b *testing.B) {
+	payload := listUsersPayload(100)
+	for _, name := range Names() {
+		codec, _ := Get(name)
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := codec.Marshal(payload); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkUnmarshalListUsers - разбор той же страницы (BodyParser)
+func BenchmarkUnmarshalListUsers(b *testing.B) {
+	data, _ := codecs["std"].Marshal(listUsersPayload(100))
+	for _, name := range Names() {
+		codec, _ := Get(name)
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				var resp models.ListUsersResponse
+				if err := codec.Unmarshal(data, &resp); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
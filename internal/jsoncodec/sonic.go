@@ -0,0 +1,15 @@
+//go:build sonic
+
+package jsoncodec
+
+import "github.com/bytedance/sonic"
+
+// sonic.ConfigStd, а не ConfigDefault: ConfigDefault не экранирует HTML
+// и не сортирует ключи map, и ответы отличались бы от encoding/json
+func init() {
+	codecs["sonic"] = Codec{
+		Name:      "sonic",
+		Marshal:   sonic.ConfigStd.Marshal,
+		Unmarshal: sonic.ConfigStd.Unmarshal,
+	}
+}
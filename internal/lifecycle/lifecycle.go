@@ -0,0 +1,109 @@
+// Package lifecycle описывает жизненный цикл аккаунта пользователя:
+// состояния и допустимые переходы между ними.
+//
+//	pending_verification -> active (подтверждение email), suspended, deactivated, deleted
+//	active               -> suspended, deactivated, deleted
+//	suspended            -> active, deactivated, deleted
+//	deactivated          -> active, deleted
+//	deleted              -> конечное состояние, аккаунт не восстанавливается
+//
+// Переходы проверяет сервисный слой (services.UserService.ChangeStatus),
+// база хранит только текущее состояние в users.status.
+package lifecycle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+)
+
+// Status - состояние аккаунта
+type Status string
+
+// Состояния аккаунта
+const (
+	// StatusPendingVerification - аккаунт создан, email еще не подтвержден
+	// Вход разрешен: подтверждение приходит ссылкой в письме
+	StatusPendingVerification Status = "pending_verification"
+
+	// StatusActive - обычное рабочее состояние
+	StatusActive Status = "active"
+
+	// StatusSuspended - временная блокировка администратором (нарушения, проверка)
+	StatusSuspended Status = "suspended"
+
+	// StatusDeactivated - аккаунт выключен по просьбе пользователя или администратором
+	StatusDeactivated Status = "deactivated"
+
+	// StatusDeleted - аккаунт мягко удален, в выборках не виден
+	StatusDeleted Status = "deleted"
+)
+
+// Statuses - все состояния в порядке жизненного цикла
+var Statuses = []Status{
+	StatusPendingVerification,
+	StatusActive,
+	StatusSuspended,
+	StatusDeactivated,
+	StatusDeleted,
+}
+
+// transitions - допустимые переходы: из состояния -> в состояния
+var transitions = map[Status][]Status{
+	StatusPendingVerification: {StatusActive, StatusSuspended, StatusDeactivated, StatusDeleted},
+	StatusActive:              {StatusSuspended, StatusDeactivated, StatusDeleted},
+	StatusSuspended:           {StatusActive, StatusDeactivated, StatusDeleted},
+	StatusDeactivated:         {StatusActive, StatusDeleted},
+	StatusDeleted:             nil,
+}
+
+// ErrUnknownStatus возвращается для строки, которая не является состоянием
+var ErrUnknownStatus = apperrors.Invalid("UNKNOWN_STATUS", "неизвестное состояние аккаунта")
+
+// ErrInvalidTransition возвращается для недопустимого перехода
+var ErrInvalidTransition = apperrors.Conflict("INVALID_STATUS_TRANSITION", "недопустимая смена состояния аккаунта")
+
+// Parse проверяет строку состояния из запроса или БД
+func Parse(value string) (Status, error) {
+	status := Status(value)
+	if _, ok := transitions[status]; !ok {
+		return "", fmt.Errorf("%w: %q, допустимые: %s", ErrUnknownStatus, value, strings.Join(Names(), ", "))
+	}
+	return status, nil
+}
+
+// Names возвращает имена всех состояний для сообщений об ошибках и документации
+func Names() []string {
+	names := make([]string, len(Statuses))
+	for i, status := range Statuses {
+		names[i] = string(status)
+	}
+	return names
+}
+
+// CanTransition сообщает, допустим ли переход из s в to
+// Переход в то же состояние недопустим: он ничего не меняет
+func (s Status) CanTransition(to Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition проверяет переход из s в to
+// Возвращает ошибку, обернутую в ErrInvalidTransition, с обоими состояниями
+func (s Status) Transition(to Status) error {
+	if !s.CanTransition(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, s, to)
+	}
+	return nil
+}
+
+// CanSignIn сообщает, может ли аккаунт в этом состоянии входить
+// и получать письма (сброс пароля, рассылки)
+func (s Status) CanSignIn() bool {
+	return s == StatusPendingVerification || s == StatusActive
+}
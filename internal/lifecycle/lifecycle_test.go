@@ -0,0 +1,42 @@
+package lifecycle
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		allowed  bool
+	}{
+		{StatusPendingVerification, StatusActive, true},
+		{StatusActive, StatusSuspended, true},
+		{StatusSuspended, StatusActive, true},
+		{StatusDeactivated, StatusActive, true},
+		{StatusActive, StatusDeleted, true},
+		{StatusActive, StatusPendingVerification, false},
+		{StatusDeactivated, StatusSuspended, false},
+		{StatusActive, StatusActive, false},
+		{StatusDeleted, StatusActive, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.from.Transition(tt.to)
+		if tt.allowed && err != nil {
+			t.Errorf("%s -> %s: неожиданная ошибка %v", tt.from, tt.to, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("%s -> %s: ожидалась ErrInvalidTransition, получено %v", tt.from, tt.to, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if status, err := Parse("suspended"); err != nil || status != StatusSuspended {
+		t.Errorf("Parse(suspended) = %q, %v", status, err)
+	}
+	if _, err := Parse("banned"); !errors.Is(err, ErrUnknownStatus) {
+		t.Errorf("Parse(banned): ожидалась ErrUnknownStatus, получено %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package logging - структурированные логи приложения на log/slog.
+//
+// В production логи пишутся JSON строками для сборщика логов, в остальных
+// окружениях - читаемым текстом (см. prettyHandler). Уровень задается LOG_LEVEL.
+//
+// Записи, сделанные с контекстом запроса (slog.InfoContext(ctx, ...)),
+// автоматически получают request_id и user_id из reqctx, поэтому каждую
+// строку лога можно связать с HTTP запросом. Handlers передают
+// c.UserContext(), сервисы - полученный ctx. Внутри трассы OpenTelemetry
+// запись получает и trace_id/span_id (см. пакет tracing).
+//
+// Последние записи уровня ERROR хранятся в памяти (RecentErrors) для
+// пакета диагностики POST /admin/v1/diagnostics.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+)
+
+// ParseLevel разбирает уровень логирования: debug, info, warn, error
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		return 0, fmt.Errorf("неизвестный уровень логирования: %q", value)
+	}
+	return level, nil
+}
+
+// defaultLevel - уровень логгера по умолчанию, меняется SetLevel без пересоздания логгера
+var defaultLevel slog.LevelVar
+
+// New создает логгер: JSON в production, читаемый текст в остальных окружениях
+func New(w io.Writer, env string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if env == "production" {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = newPrettyHandler(w, opts)
+	}
+
+	// Ошибки запоминаются вместе с request_id и user_id из контекста
+	return slog.New(contextHandler{sampleHandler{Handler: handler, ring: errorSamples}})
+}
+
+// Setup делает логгер логгером по умолчанию для slog и стандартного log
+// Строки сторонних библиотек, которые пишут через log.Printf,
+// попадают в тот же поток с уровнем INFO. Непустой region (REGION)
+// добавляется к каждой записи
+func Setup(w io.Writer, env, level, region string) error {
+	if err := SetLevel(level); err != nil {
+		return err
+	}
+
+	logger := New(w, env, &defaultLevel)
+	if region != "" {
+		logger = logger.With("region", region)
+	}
+	slog.SetDefault(logger)
+	// Время и уровень добавляет slog, префикс log дублировал бы их
+	log.SetFlags(0)
+	return nil
+}
+
+// SetLevel меняет уровень логгера, созданного Setup
+// Используется при перезагрузке конфигурации (SIGHUP)
+func SetLevel(value string) error {
+	lvl, err := ParseLevel(value)
+	if err != nil {
+		return err
+	}
+	defaultLevel.Set(lvl)
+	return nil
+}
+
+// contextHandler добавляет к записи значения запроса из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle реализует slog.Handler
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if requestID := reqctx.RequestIDFromContext(ctx); requestID != "" {
+			r.AddAttrs(slog.String("request_id", requestID))
+		}
+		if user, ok := reqctx.UserFromContext(ctx); ok {
+			r.AddAttrs(slog.Int("user_id", user.ID))
+		}
+		if service := reqctx.ServiceFromContext(ctx); service != "" {
+			r.AddAttrs(slog.String("service", service))
+		}
+		if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+			r.AddAttrs(
+				slog.String("trace_id", span.TraceID().String()),
+				slog.String("span_id", span.SpanID().String()),
+			)
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs реализует slog.Handler
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup реализует slog.Handler
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
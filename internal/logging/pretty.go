@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// prettyHandler - читаемый формат для локальной разработки:
+//
+//	12:30:00.000 INFO  HTTP запрос method=GET path=/api/v1/users status=200
+//
+// Сообщение пишется как есть (в том числе многострочное), без кавычек,
+// атрибуты - в формате key=value slog.TextHandler
+type prettyHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Leveler
+
+	// attrs форматирует атрибуты записи в buf
+	attrs slog.Handler
+	buf   *bytes.Buffer
+}
+
+// newPrettyHandler создает читаемый обработчик записей
+func newPrettyHandler(w io.Writer, opts *slog.HandlerOptions) *prettyHandler {
+	buf := &bytes.Buffer{}
+	return &prettyHandler{
+		mu:    &sync.Mutex{},
+		w:     w,
+		level: opts.Level,
+		buf:   buf,
+		attrs: slog.NewTextHandler(buf, &slog.HandlerOptions{
+			// Время, уровень и сообщение пишет сам prettyHandler
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}),
+	}
+}
+
+// Enabled реализует slog.Handler
+func (h *prettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle реализует slog.Handler
+func (h *prettyHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buf.Reset()
+	attrs := slog.NewRecord(time.Time{}, r.Level, "", r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs.AddAttrs(a)
+		return true
+	})
+	if err := h.attrs.Handle(ctx, attrs); err != nil {
+		return err
+	}
+
+	line := fmt.Sprintf("%s %-5s %s %s", r.Time.Format("15:04:05.000"), r.Level, r.Message, h.buf.String())
+	_, err := io.WriteString(h.w, strings.TrimRight(line, " \n")+"\n")
+	return err
+}
+
+// WithAttrs реализует slog.Handler
+func (h *prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = h.attrs.WithAttrs(attrs)
+	return &clone
+}
+
+// WithGroup реализует slog.Handler
+func (h *prettyHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.attrs = h.attrs.WithGroup(name)
+	return &clone
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// errorSamplesLimit - сколько последних ошибок хранится в памяти
+const errorSamplesLimit = 100
+
+// ErrorSample - запись лога уровня ERROR
+// Атрибуты приведены к строкам, вложенные группы - через точку
+type ErrorSample struct {
+	Time    time.Time         `json:"time"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs"`
+}
+
+// errorSamples - последние ошибки процесса для пакета диагностики
+var errorSamples = &sampleRing{limit: errorSamplesLimit}
+
+// RecentErrors возвращает последние записи уровня ERROR, от старых к новым
+// Записи копятся с момента запуска, старые вытесняются новыми
+func RecentErrors() []ErrorSample {
+	return errorSamples.list()
+}
+
+// sampleRing - кольцевой буфер записей
+type sampleRing struct {
+	mu      sync.Mutex
+	limit   int
+	samples []ErrorSample
+	next    int
+}
+
+func (r *sampleRing) add(sample ErrorSample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.samples) < r.limit {
+		r.samples = append(r.samples, sample)
+		return
+	}
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % r.limit
+}
+
+func (r *sampleRing) list() []ErrorSample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]ErrorSample, 0, len(r.samples))
+	result = append(result, r.samples[r.next:]...)
+	return append(result, r.samples[:r.next]...)
+}
+
+// sampleHandler запоминает записи уровня ERROR в errorSamples и передает
+// все записи дальше
+type sampleHandler struct {
+	slog.Handler
+	ring   *sampleRing
+	attrs  []slog.Attr // Атрибуты логгера (With) с учетом групп
+	prefix string      // Группа логгера (WithGroup) для следующих атрибутов
+}
+
+// Handle реализует slog.Handler
+func (h sampleHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelError {
+		sample := ErrorSample{Time: r.Time, Message: r.Message, Attrs: make(map[string]string)}
+		for _, attr := range h.attrs {
+			addSampleAttr(sample.Attrs, "", attr)
+		}
+		r.Attrs(func(attr slog.Attr) bool {
+			addSampleAttr(sample.Attrs, h.prefix, attr)
+			return true
+		})
+		h.ring.add(sample)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs реализует slog.Handler
+func (h sampleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	grouped := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	grouped = append(grouped, h.attrs...)
+	for _, attr := range attrs {
+		attr.Key = h.prefix + attr.Key
+		grouped = append(grouped, attr)
+	}
+	return sampleHandler{Handler: h.Handler.WithAttrs(attrs), ring: h.ring, attrs: grouped, prefix: h.prefix}
+}
+
+// WithGroup реализует slog.Handler
+func (h sampleHandler) WithGroup(name string) slog.Handler {
+	return sampleHandler{Handler: h.Handler.WithGroup(name), ring: h.ring, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// addSampleAttr добавляет атрибут строкой, раскрывая группы
+func addSampleAttr(dst map[string]string, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		// Группа без имени встраивается в текущий уровень
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, nested := range value.Group() {
+			addSampleAttr(dst, prefix, nested)
+		}
+		return
+	}
+	if attr.Key != "" {
+		dst[prefix+attr.Key] = value.String()
+	}
+}
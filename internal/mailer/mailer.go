@@ -0,0 +1,65 @@
+// Package mailer отправляет письма пользователям.
+//
+// Сервисы работают только с интерфейсом Mailer, а способ доставки
+// (SMTP, запись в лог, outbox фейковых сервисов) выбирается конфигурацией.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+)
+
+// Message - письмо в виде простого текста
+type Message struct {
+	To      string // Адрес получателя
+	Subject string // Тема
+	Body    string // Текст письма (text/plain, UTF-8)
+}
+
+// Mailer - абстракция отправки писем
+type Mailer interface {
+	// Send отправляет письмо; ошибка означает, что письмо не принято к доставке
+	Send(ctx context.Context, msg Message) error
+}
+
+// Pinger - Mailer, который умеет проверять доступность сервера доставки
+// Используется readiness пробой; Noop и фейки его не реализуют
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// New создает Mailer по конфигурации
+// Поддерживаемые драйверы: smtp и noop
+func New(cfg config.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg), nil
+	case "noop":
+		return Noop{}, nil
+	default:
+		return nil, fmt.Errorf("неподдерживаемый драйвер почты: %s", cfg.Driver)
+	}
+}
+
+// Noop не отправляет письма, а только пишет в лог получателя и тему
+// Используется в локальной разработке без SMTP сервера
+type Noop struct{}
+
+// Send реализует Mailer
+func (Noop) Send(ctx context.Context, msg Message) error {
+	slog.InfoContext(ctx, "✉️ Письмо не отправлено (MAIL_DRIVER=noop)", "to", msg.To, "subject", msg.Subject)
+	return nil
+}
+
+// validateHeaders запрещает переводы строк в заголовках письма,
+// иначе через адрес или тему можно дописать свои заголовки
+func validateHeaders(msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("недопустимый перевод строки в заголовке письма")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package mailer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// sampleTime - момент времени в примерах писем
+// Фиксированный, чтобы предпросмотр не менялся от запроса к запросу
+var sampleTime = time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+
+// samples - данные для предпросмотра и тестовой отправки шаблонов
+// У каждого шаблона из templates/ должен быть пример (см. samples_test.go)
+var samples = map[string]interface{}{
+	TemplateVerifyEmail: LinkData{
+		Username:  "ivan",
+		Email:     "ivan@example.com",
+		Link:      "https://example.com/verify-email?token=sample-token",
+		ExpiresAt: sampleTime.Add(24 * time.Hour),
+	},
+	TemplatePasswordReset: LinkData{
+		Username:  "ivan",
+		Email:     "ivan@example.com",
+		Link:      "https://example.com/reset-password?token=sample-token",
+		ExpiresAt: sampleTime.Add(time.Hour),
+	},
+	TemplateAccountExists: AccountExistsData{
+		Username:  "ivan",
+		Email:     "ivan@example.com",
+		LoginLink: "https://example.com/login",
+		ResetLink: "https://example.com/forgot-password",
+	},
+	TemplateTwoFactorRecovery: TwoFactorRecoveryData{
+		Username:         "ivan",
+		Link:             "https://example.com/2fa-recovery?token=sample-token",
+		ExpiresAt:        sampleTime.Add(time.Hour),
+		DelayHours:       72,
+		ApprovalRequired: true,
+	},
+	TemplateTwoFactorRecoveryCancelled: TwoFactorRecoveryCancelledData{
+		Username: "ivan",
+		Reason:   "вы вошли с кодом двухфакторной аутентификации",
+	},
+	TemplateWeeklyDigest: DigestData{
+		Username:    "ivan",
+		PeriodStart: sampleTime.AddDate(0, 0, -7),
+		PeriodEnd:   sampleTime,
+		Security: []DigestEvent{
+			{At: sampleTime.AddDate(0, 0, -3), Text: "Вход в аккаунт"},
+			{At: sampleTime.AddDate(0, 0, -1), Text: "Запрос сброса пароля"},
+		},
+		Profile: []DigestEvent{
+			{At: sampleTime.AddDate(0, 0, -2), Text: "Изменено имя пользователя"},
+		},
+		SettingsURL: "https://example.com/settings",
+	},
+	TemplateNotificationBatch: NotificationBatchData{
+		Username: "ivan",
+		Summary:  "5 изменений профиля",
+		Count:    5,
+		FirstAt:  sampleTime.Add(-15 * time.Minute),
+		LastAt:   sampleTime,
+	},
+	TemplateBroadcast: BroadcastData{
+		Username: "ivan",
+		Subject:  "Плановые работы",
+		Body:     "В воскресенье с 02:00 до 04:00 (UTC) сервис будет недоступен.",
+	},
+}
+
+// TemplateNames возвращает имена всех шаблонов писем по алфавиту
+func TemplateNames() []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasTemplate сообщает, есть ли шаблон письма name
+func HasTemplate(name string) bool {
+	_, ok := templates[name]
+	return ok
+}
+
+// RenderSample заполняет шаблон name примером данных
+// Используется для проверки шаблона без реальных пользователей и токенов
+func RenderSample(name, to string) (Message, error) {
+	data, ok := samples[name]
+	if !ok {
+		return Message{}, fmt.Errorf("неизвестный шаблон письма: %s", name)
+	}
+	return Render(name, to, data)
+}
@@ -0,0 +1,20 @@
+package mailer
+
+import "testing"
+
+func TestEveryTemplateHasSample(t *testing.T) {
+	for _, name := range TemplateNames() {
+		msg, err := RenderSample(name, "admin@example.com")
+		if err != nil {
+			t.Errorf("шаблон %s: %v", name, err)
+			continue
+		}
+		if msg.Subject == "" || msg.Body == "" {
+			t.Errorf("шаблон %s: пустое письмо %+v", name, msg)
+		}
+	}
+
+	if _, err := RenderSample("unknown", "admin@example.com"); err == nil {
+		t.Error("для неизвестного шаблона ожидалась ошибка")
+	}
+}
@@ -0,0 +1,146 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+)
+
+// SMTPMailer отправляет письма через SMTP сервер
+// Если сервер поддерживает STARTTLS, соединение шифруется до аутентификации
+type SMTPMailer struct {
+	addr string
+	host string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer создает Mailer для SMTP сервера из конфигурации
+func NewSMTPMailer(cfg config.MailConfig) *SMTPMailer {
+	m := &SMTPMailer{
+		addr: net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		host: cfg.SMTPHost,
+		from: cfg.From,
+	}
+	if cfg.SMTPUsername != "" {
+		// PlainAuth сам отказывается передавать пароль без TLS (кроме localhost)
+		m.auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return m
+}
+
+// Send реализует Mailer
+// Дедлайн ctx распространяется на весь SMTP диалог
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := validateHeaders(msg); err != nil {
+		return err
+	}
+	data, err := m.buildMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к SMTP серверу: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("ошибка подключения к SMTP серверу: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("ошибка STARTTLS: %w", err)
+		}
+	}
+	if m.auth != nil {
+		if err := client.Auth(m.auth); err != nil {
+			return fmt.Errorf("ошибка аутентификации SMTP: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from); err != nil {
+		return fmt.Errorf("ошибка отправки письма: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("ошибка отправки письма: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("ошибка отправки письма: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("ошибка отправки письма: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("ошибка отправки письма: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// Ping реализует Pinger
+// Подключается к серверу, дожидается приветствия и завершает сессию,
+// не аутентифицируясь и не отправляя писем
+func (m *SMTPMailer) Ping(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к SMTP серверу: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("ошибка подключения к SMTP серверу: %w", err)
+	}
+	defer client.Close()
+
+	return client.Quit()
+}
+
+// buildMessage формирует письмо с заголовками
+// Тема кодируется по RFC 2047, текст - quoted-printable, чтобы
+// кириллица доходила без искажений через любые серверы
+func (m *SMTPMailer) buildMessage(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", m.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,152 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFS - шаблоны писем, встроенные в бинарник
+// Каждый файл templates/<name>.tmpl определяет блоки "subject" и "body"
+//
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// templateFuncs - функции, доступные в шаблонах
+var templateFuncs = template.FuncMap{
+	// datetime форматирует момент времени в UTC: 02.01.2006 15:04
+	"datetime": func(t time.Time) string {
+		return t.UTC().Format("02.01.2006 15:04")
+	},
+	// date форматирует дату в UTC: 02.01.2006
+	"date": func(t time.Time) string {
+		return t.UTC().Format("02.01.2006")
+	},
+}
+
+// templates - разобранные шаблоны по имени файла без расширения
+// Ошибка в шаблоне обнаруживается при старте, а не при отправке письма
+var templates = mustParseTemplates()
+
+// Шаблоны писем
+const (
+	TemplateVerifyEmail   = "verify_email"
+	TemplatePasswordReset = "password_reset"
+	TemplateWeeklyDigest  = "weekly_digest"
+	TemplateBroadcast     = "broadcast"
+
+	// Несколько событий одного типа одним письмом (пакет notify)
+	TemplateNotificationBatch = "notification_batch"
+
+	TemplateTwoFactorRecovery          = "two_factor_recovery"
+	TemplateTwoFactorRecoveryCancelled = "two_factor_recovery_cancelled"
+
+	// Регистрация на адрес, у которого уже есть аккаунт
+	TemplateAccountExists = "account_exists"
+)
+
+// LinkData - данные писем со ссылкой (подтверждение email, сброс пароля)
+type LinkData struct {
+	Username  string
+	Email     string
+	Link      string
+	ExpiresAt time.Time
+}
+
+// AccountExistsData - данные письма владельцу email, на который
+// пытались зарегистрировать второй аккаунт
+type AccountExistsData struct {
+	Username  string
+	Email     string
+	LoginLink string
+	ResetLink string // Запрос сброса пароля
+}
+
+// TwoFactorRecoveryData - данные письма подтверждения восстановления
+// доступа без второго фактора
+type TwoFactorRecoveryData struct {
+	Username         string
+	Link             string
+	ExpiresAt        time.Time
+	DelayHours       int  // Период ожидания после подтверждения
+	ApprovalRequired bool // Запрос проверит администратор
+}
+
+// TwoFactorRecoveryCancelledData - данные письма об отмене восстановления
+type TwoFactorRecoveryCancelledData struct {
+	Username string
+	Reason   string // Причина для пользователя: "вы вошли с кодом 2FA"
+}
+
+// DigestData - данные еженедельной сводки активности аккаунта
+type DigestData struct {
+	Username    string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Security    []DigestEvent // Входы, запросы сброса пароля, привязка провайдеров
+	Profile     []DigestEvent // Изменения профиля и email
+	SettingsURL string        // Где отключить сводку
+}
+
+// DigestEvent - строка сводки активности
+type DigestEvent struct {
+	At   time.Time
+	Text string
+}
+
+// NotificationBatchData - данные письма о накопленных событиях аккаунта
+type NotificationBatchData struct {
+	Username string
+	Summary  string // Число и описание событий: "5 изменений профиля"
+	Count    int
+	FirstAt  time.Time
+	LastAt   time.Time
+}
+
+// BroadcastData - данные письма массовой рассылки
+type BroadcastData struct {
+	Username string
+	Subject  string
+	Body     string
+}
+
+// Render заполняет шаблон name данными data и возвращает письмо для to
+func Render(name, to string, data interface{}) (Message, error) {
+	tmpl, ok := templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("неизвестный шаблон письма: %s", name)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("ошибка заполнения темы письма %s: %w", name, err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return Message{}, fmt.Errorf("ошибка заполнения письма %s: %w", name, err)
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimLeft(body.String(), "\n"),
+	}, nil
+}
+
+// mustParseTemplates разбирает все встроенные шаблоны
+func mustParseTemplates() map[string]*template.Template {
+	files, err := templateFS.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+
+	parsed := make(map[string]*template.Template, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), ".tmpl")
+		parsed[name] = template.Must(template.New(name).Funcs(templateFuncs).
+			ParseFS(templateFS, "templates/"+file.Name()))
+	}
+	return parsed
+}
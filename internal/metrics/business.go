@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Бизнес-метрики: события продукта, а не HTTP запросы. По ним строятся
+// продуктовые дашборды (регистрации, входы, отток) без отдельного
+// конвейера аналитики. Метки - только фиксированные значения из констант
+// ниже, без ID пользователей: иначе число рядов растет с аудиторией
+
+// Значения меток бизнес-метрик
+const (
+	SignupSourceAPI    = "api"    // Регистрация через REST или gRPC API
+	SignupSourceImport = "import" // Импорт администратором
+
+	LoginMethodPassword  = "password"   // Вход по паролю без 2FA
+	LoginMethodTwoFactor = "two_factor" // Второй шаг входа с 2FA
+	LoginMethodRecovery  = "recovery"   // Вход с отключением 2FA по запросу восстановления
+
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+
+	DeactivationSelf   = "self"   // Пользователь деактивировал свой аккаунт
+	DeactivationAdmin  = "admin"  // Администратор
+	DeactivationSystem = "system" // Фоновое задание (неактивные аккаунты)
+
+	PasswordResetRequested = "requested" // Отправлено письмо со ссылкой
+	PasswordResetCompleted = "completed" // Пароль задан по ссылке
+)
+
+// Signups - созданные аккаунты по источнику
+var Signups = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "signups_total",
+	Help:      "Количество созданных аккаунтов по источнику",
+}, []string{"source"})
+
+// Logins - попытки входа по способу и результату
+// Успешным считается вход, на котором выданы токены: ввод пароля
+// перед кодом 2FA не учитывается, учитывается второй шаг
+var Logins = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "logins_total",
+	Help:      "Количество попыток входа по способу и результату",
+}, []string{"method", "result"})
+
+// Deactivations - аккаунты, переведенные в состояние deactivated
+var Deactivations = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "user_deactivations_total",
+	Help:      "Количество деактивированных аккаунтов по инициатору",
+}, []string{"initiator"})
+
+// PasswordResets - сброс пароля по этапам
+// Доля completed от requested показывает, доходят ли письма
+var PasswordResets = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "password_resets_total",
+	Help:      "Количество сбросов пароля по этапу",
+}, []string{"stage"})
+
+// Ряды всех значений меток создаются сразу с нулем: иначе до первого
+// события ряда нет, и rate() и дашборды показывают "нет данных"
+func init() {
+	for _, source := range []string{SignupSourceAPI, SignupSourceImport} {
+		Signups.WithLabelValues(source)
+	}
+	for _, method := range []string{LoginMethodPassword, LoginMethodTwoFactor, LoginMethodRecovery} {
+		for _, result := range []string{LoginSuccess, LoginFailure} {
+			Logins.WithLabelValues(method, result)
+		}
+	}
+	for _, initiator := range []string{DeactivationSelf, DeactivationAdmin, DeactivationSystem} {
+		Deactivations.WithLabelValues(initiator)
+	}
+	for _, stage := range []string{PasswordResetRequested, PasswordResetCompleted} {
+		PasswordResets.WithLabelValues(stage)
+	}
+}
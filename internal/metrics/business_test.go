@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestBusinessSeriesInitialized(t *testing.T) {
+	// Ряды есть до первого события, иначе дашборды пустые после запуска
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"signups_total", 2},
+		{"logins_total", 6},
+		{"user_deactivations_total", 3},
+		{"password_resets_total", 2},
+	}
+	for _, tc := range tests {
+		samples, err := Read(prometheus.DefaultGatherer, tc.name)
+		if err != nil {
+			t.Fatalf("Read(%s): %v", tc.name, err)
+		}
+		if len(samples) != tc.want {
+			t.Errorf("%s: %d рядов, ожидалось %d", tc.name, len(samples), tc.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterDBStats публикует статистику пула соединений БД
+// (открытые, занятые, простаивающие соединения, ожидание свободного соединения)
+// Значения читаются из pgxpool.Pool.Stat() при каждом опросе /metrics,
+// в отличие от Database.LogStats, который пишет их в лог один раз при старте
+func RegisterDBStats(pool *pgxpool.Pool, dbName string) error {
+	return prometheus.Register(newPoolCollector(pool, dbName))
+}
+
+// poolCollector - prometheus.Collector поверх pgxpool.Stat
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	maxConns        *prometheus.Desc
+	totalConns      *prometheus.Desc
+	acquiredConns   *prometheus.Desc
+	idleConns       *prometheus.Desc
+	acquireCount    *prometheus.Desc
+	acquireDuration *prometheus.Desc
+	emptyAcquire    *prometheus.Desc
+	canceledAcquire *prometheus.Desc
+	newConns        *prometheus.Desc
+	lifetimeClosed  *prometheus.Desc
+	idleClosed      *prometheus.Desc
+}
+
+// newPoolCollector описывает метрики пула с меткой db_name
+func newPoolCollector(pool *pgxpool.Pool, dbName string) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "db_pool", name), help,
+			nil, prometheus.Labels{"db_name": dbName},
+		)
+	}
+	return &poolCollector{
+		pool:            pool,
+		maxConns:        desc("max_conns", "Максимум соединений пула"),
+		totalConns:      desc("conns", "Открытые соединения (занятые, простаивающие и устанавливаемые)"),
+		acquiredConns:   desc("acquired_conns", "Соединения, занятые запросами"),
+		idleConns:       desc("idle_conns", "Простаивающие соединения"),
+		acquireCount:    desc("acquires_total", "Количество выдач соединения из пула"),
+		acquireDuration: desc("acquire_duration_seconds_total", "Суммарное время ожидания соединения"),
+		emptyAcquire:    desc("empty_acquires_total", "Выдачи, которым пришлось ждать: свободных соединений не было"),
+		canceledAcquire: desc("canceled_acquires_total", "Ожидания соединения, прерванные отменой контекста"),
+		newConns:        desc("new_conns_total", "Установленные соединения"),
+		lifetimeClosed:  desc("max_lifetime_closed_total", "Соединения, закрытые по DB_CONN_MAX_LIFETIME"),
+		idleClosed:      desc("max_idle_closed_total", "Соединения, закрытые по времени простоя"),
+	}
+}
+
+// Describe реализует prometheus.Collector
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxConns
+	ch <- c.totalConns
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquire
+	ch <- c.canceledAcquire
+	ch <- c.newConns
+	ch <- c.lifetimeClosed
+	ch <- c.idleClosed
+}
+
+// Collect реализует prometheus.Collector
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquire, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.newConns, prometheus.CounterValue, float64(stat.NewConnsCount()))
+	ch <- prometheus.MustNewConstMetric(c.lifetimeClosed, prometheus.CounterValue, float64(stat.MaxLifetimeDestroyCount()))
+	ch <- prometheus.MustNewConstMetric(c.idleClosed, prometheus.CounterValue, float64(stat.MaxIdleDestroyCount()))
+}
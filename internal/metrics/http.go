@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// HTTPRequestsTotal - количество обработанных HTTP запросов
+var HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "http_requests_total",
+	Help:      "Количество обработанных HTTP запросов по роуту и статусу ответа",
+}, []string{"method", "route", "status"})
+
+// HTTPRequestDuration - время обработки HTTP запросов
+// Бакеты покрывают диапазон от быстрых чтений до таймаута запроса
+var HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "http_request_duration_seconds",
+	Help:      "Время обработки HTTP запроса в секундах",
+	Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+}, []string{"method", "route"})
+
+// Middleware считает запросы и время их обработки по роутам
+//
+// Подключается первым, до recover: так учитываются и запросы,
+// завершившиеся паникой. Ошибка обработчика передается в ErrorHandler
+// приложения здесь же, чтобы в метрику попал итоговый статус ответа
+// (как это делает logger Fiber)
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		// Шаблон роута (/users/:id), а не конкретный путь - число меток ограничено
+		// Метод копируется: строка Fiber ссылается на буфер запроса, который
+		// переиспользуется следующими запросами, а метка живет в счетчике
+		route := c.Route().Path
+		method := utils.CopyString(c.Method())
+
+		HTTPRequestsTotal.
+			WithLabelValues(method, route, strconv.Itoa(c.Response().StatusCode())).
+			Inc()
+		HTTPRequestDuration.
+			WithLabelValues(method, route).
+			Observe(time.Since(start).Seconds())
+
+		return nil
+	}
+}
+
+// RequestsAborted - запросы, прерванные при чтении тела (408, 499)
+// Считаются отдельно от ошибок сервера: обрыв загрузки на мобильной сети
+// не повод для алерта по доле 5xx. Запросы, прерванные до роутинга,
+// в HTTPRequestsTotal не попадают - только сюда
+var RequestsAborted = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "http_requests_aborted_total",
+	Help:      "Количество запросов, прерванных при чтении тела (обрыв клиентом или таймаут)",
+}, []string{"reason"})
+
+// ErrorResponses - ответы с ошибкой по формату тела (legacy, problem)
+// Показывает, сколько клиентов еще получают старый формат ErrorResponse:
+// по ней решают, когда заканчивать переходный период
+var ErrorResponses = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "http_error_responses_total",
+	Help:      "Количество ответов с ошибкой по формату тела",
+}, []string{"format"})
@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace - общий префикс всех метрик приложения
+const namespace = "fiber_backend"
+
+// DBQueriesPerRequest - распределение количества SQL запросов на один HTTP запрос
+// Рост верхних бакетов для роута обычно означает N+1 в новом коде
+var DBQueriesPerRequest = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "db_queries_per_request",
+	Help:      "Количество SQL запросов, выполненных при обработке одного HTTP запроса",
+	Buckets:   []float64{0, 1, 2, 3, 5, 10, 20, 50, 100},
+}, []string{"method", "route"})
+
+// SlowRequests - запросы, превысившие бюджет времени роута (middleware.LatencyBudget)
+// Подробности каждого такого запроса - в логе "Запрос превысил бюджет времени"
+var SlowRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "slow_requests_total",
+	Help:      "Количество HTTP запросов, превысивших бюджет времени роута",
+}, []string{"method", "route"})
+
+// Handler возвращает Fiber обработчик для эндпоинта /metrics
+// Формат выбирается по Accept: текстовый формат Prometheus или OpenMetrics
+// (application/openmetrics-text), который нужен, например, для exemplars.
+// Непустой region (REGION) добавляется меткой region ко всем рядам
+func Handler(region string) fiber.Handler {
+	gatherer := prometheus.DefaultGatherer
+	if region != "" {
+		gatherer = WithRegion(gatherer, region)
+	}
+	return adaptor.HTTPHandler(promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	))
+}
+
+// RateLimitWarnings - запросы, превысившие мягкий лимит
+// Они обработаны, но получили X-RateLimit-Warning. Рост метрики -
+// повод связаться с интеграторами до того, как они упрутся в 429
+var RateLimitWarnings = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "rate_limit_warnings_total",
+	Help:      "Количество запросов сверх мягкого лимита (обработаны с предупреждением)",
+}, []string{"limit"})
+
+// RateLimitRejected - запросы, отклоненные жестким лимитом (429)
+var RateLimitRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "rate_limit_rejected_total",
+	Help:      "Количество запросов, отклоненных лимитом (429)",
+}, []string{"limit"})
+
+// BulkReadAnomalies - оповещения о подозрительном объеме экспортов и
+// массовых чтений (см. middleware.BulkReadMonitor). subject - user или ip
+// Любой рост - повод проверить журнал аудита (security.bulk_read_anomaly)
+var BulkReadAnomalies = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "bulk_read_anomalies_total",
+	Help:      "Количество оповещений о подозрительном объеме экспортов и массовых чтений",
+}, []string{"route", "subject"})
+
+// AuditDropped - записи журнала аудита, которые не удалось сохранить
+// reason: queue_full - очередь переполнена, db_error - ошибка записи в БД,
+// encode - снимок объекта не сериализуется в JSON, spool_full - журнал
+// на диске заполнен, spool_error - ошибка записи журнала на диск
+var AuditDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "audit_dropped_total",
+	Help:      "Количество записей журнала аудита, которые не удалось сохранить",
+}, []string{"reason"})
+
+// AuditSpooled - записи журнала аудита, отложенные на диск и сохраненные в БД
+// state: spooled - отложена (очередь переполнена или БД недоступна),
+// replayed - сохранена в БД после восстановления
+var AuditSpooled = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "audit_spooled_total",
+	Help:      "Количество записей журнала аудита, отложенных на диск и сохраненных после восстановления БД",
+}, []string{"state"})
+
+// AuditSpoolBytes - место, занятое отложенными записями аудита на диске
+var AuditSpoolBytes = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "audit_spool_bytes",
+	Help:      "Размер журнала отложенных записей аудита на диске в байтах",
+})
+
+// SchemaDrift - объекты схемы из миграций, которых нет в БД (migrations.CheckDrift)
+// Обновляется при запуске и при запросе GET /admin/v1/schema
+var SchemaDrift = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "schema_drift_objects",
+	Help:      "Количество таблиц, колонок и индексов из миграций, отсутствующих в БД",
+}, []string{"kind"})
+
+// CacheLookups - обращения к кешам в памяти процесса
+// result: hit - значение взято из кеша, miss - вычислено заново
+var CacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "cache_lookups_total",
+	Help:      "Количество обращений к кешам по результату (hit, miss)",
+}, []string{"cache", "result"})
+
+// CoalescedReads - чтения, которые получили результат чужого запроса к БД
+// (singleflight в сервисах) вместо собственного
+var CoalescedReads = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "coalesced_reads_total",
+	Help:      "Количество чтений, объединенных с одновременным одинаковым запросом",
+}, []string{"operation"})
+
+// JobsProcessed - выполненные попытки фоновых заданий (internal/jobs)
+// result: completed - успешно, retried - ошибка, задание будет повторено,
+// failed - ошибка после последней попытки или без смысла повторять
+var JobsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "jobs_processed_total",
+	Help:      "Количество попыток выполнения фоновых заданий по типу и результату",
+}, []string{"kind", "result"})
+
+// PasswordHashDuration - длительность хеширования и проверки паролей
+// operation: hash - новый хеш (регистрация, смена пароля),
+// compare - проверка при входе. Рост compare на том же железе означает,
+// что стоимость выросла или процессор перегружен
+var PasswordHashDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "password_hash_duration_seconds",
+	Help:      "Длительность хеширования и проверки паролей",
+	Buckets:   []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2, 5},
+}, []string{"algorithm", "operation"})
+
+// PasswordHashCost - стоимость хеширования новых паролей (после калибровки)
+var PasswordHashCost = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "password_hash_cost",
+	Help:      "Стоимость хеширования новых паролей",
+}, []string{"algorithm"})
+
+// DependencyDegraded - 1, пока функция работает без зависимости
+// по запасному пути (лимиты запросов без Redis)
+var DependencyDegraded = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "dependency_degraded",
+	Help:      "Функция работает без зависимости по запасному пути",
+}, []string{"dependency", "feature"})
+
+// Panics - паники, перехваченные без падения процесса
+// component: http - handler запроса, http_error - формирование ответа
+// об ошибке, grpc - обработчик вызова, job:<тип> - фоновое задание,
+// fanout:<часть> - часть составного ответа, audit, audit_observer,
+// webhooks - побочные действия после изменения
+var Panics = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "panics_total",
+	Help:      "Количество паник, перехваченных без падения процесса",
+}, []string{"component"})
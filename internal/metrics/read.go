@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Sample - значение одного ряда метрики с его метками
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Read возвращает текущие значения счетчиков и gauge метрики приложения
+// name - имя без префикса приложения (http_requests_total)
+// Нужен для сводок внутри процесса (admin API), которым не с руки
+// ходить в Prometheus; метрики без значений возвращают пустой срез
+func Read(gatherer prometheus.Gatherer, name string) ([]Sample, error) {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения метрик: %w", err)
+	}
+
+	fqName := prometheus.BuildFQName(namespace, "", name)
+	for _, family := range families {
+		if family.GetName() != fqName {
+			continue
+		}
+
+		samples := make([]Sample, 0, len(family.GetMetric()))
+		for _, metric := range family.GetMetric() {
+			sample := Sample{Labels: make(map[string]string, len(metric.GetLabel()))}
+			for _, label := range metric.GetLabel() {
+				sample.Labels[label.GetName()] = label.GetValue()
+			}
+			switch {
+			case metric.GetCounter() != nil:
+				sample.Value = metric.GetCounter().GetValue()
+			case metric.GetGauge() != nil:
+				sample.Value = metric.GetGauge().GetValue()
+			default:
+				continue
+			}
+			samples = append(samples, sample)
+		}
+		return samples, nil
+	}
+	return nil, nil
+}
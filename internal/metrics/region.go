@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// regionLabel - метка региона экземпляра
+const regionLabel = "region"
+
+// WithRegion возвращает Gatherer, который добавляет метку region ко всем
+// рядам gatherer. Метка добавляется при опросе, а не при регистрации:
+// метрики пакета объявлены через promauto до загрузки конфигурации
+// Ряд, у которого метка region уже есть, не меняется
+func WithRegion(gatherer prometheus.Gatherer, region string) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		// Gather каждый раз собирает новые структуры, менять их безопасно
+		families, err := gatherer.Gather()
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				metric.Label = withLabel(metric.Label, regionLabel, region)
+			}
+		}
+		return families, err
+	})
+}
+
+// withLabel добавляет метку к отсортированному по имени списку меток
+func withLabel(labels []*dto.LabelPair, name, value string) []*dto.LabelPair {
+	i := sort.Search(len(labels), func(i int) bool { return labels[i].GetName() >= name })
+	if i < len(labels) && labels[i].GetName() == name {
+		return labels
+	}
+	labels = append(labels, nil)
+	copy(labels[i+1:], labels[i:])
+	labels[i] = &dto.LabelPair{Name: &name, Value: &value}
+	return labels
+}
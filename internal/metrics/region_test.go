@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestWithRegion(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "region_test_total",
+	}, []string{"a_method", "status"})
+	registry.MustRegister(counter)
+	counter.WithLabelValues("GET", "200").Inc()
+
+	samples, err := Read(WithRegion(registry, "eu-west-1"), "region_test_total")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(samples) != 1 {
+		t.Fatalf("рядов %d, ожидался 1", len(samples))
+	}
+	labels := samples[0].Labels
+	if labels["region"] != "eu-west-1" || labels["a_method"] != "GET" || labels["status"] != "200" {
+		t.Errorf("метки %v", labels)
+	}
+
+	// Метки остаются отсортированными: иначе экспозиция будет некорректной
+	families, _ := WithRegion(registry, "eu-west-1").Gather()
+	var names []string
+	for _, label := range families[0].GetMetric()[0].GetLabel() {
+		names = append(names, label.GetName())
+	}
+	if len(names) != 3 || names[0] != "a_method" || names[1] != "region" || names[2] != "status" {
+		t.Errorf("порядок меток %v", names)
+	}
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+)
+
+// RequireAuth пропускает только запросы с валидным access токеном
+// в заголовке Authorization: Bearer <token>.
+// Пользователь из токена доступен handlers через reqctx.GetUser.
+// Токен проверяется без обращения к БД, поэтому отзыв действует
+// на access токен только после его истечения (минуты)
+func RequireAuth(tokens *auth.TokenManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		value, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || value == "" {
+			c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error: "Требуется аутентификация",
+				Code:  "UNAUTHORIZED",
+			})
+		}
+
+		claims, err := tokens.Parse(value, auth.TokenTypeAccess)
+		if err != nil {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+
+			// Токен незавершенного входа: пароль верный, но код 2FA не введен.
+			// Отдельный код подсказывает клиенту вернуться к вводу кода
+			if _, pendingErr := tokens.Parse(value, auth.TokenTypeTwoFactor); pendingErr == nil {
+				return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+					Error: "Вход не завершен: требуется код двухфакторной аутентификации",
+					Code:  "TWO_FACTOR_REQUIRED",
+				})
+			}
+
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "INVALID_TOKEN",
+			})
+		}
+
+		// Parse уже проверил, что sub - число
+		userID, _ := claims.UserID()
+		reqctx.SetUser(c, reqctx.User{
+			ID:       userID,
+			Username: claims.Username,
+			Role:     claims.Role,
+		})
+
+		return c.Next()
+	}
+}
+
+// RequirePendingTwoFactor пропускает только запросы с токеном
+// незавершенного входа (two_factor) в Authorization: Bearer <token>.
+// Access токен здесь не принимается, и наоборот: RequireAuth отклоняет
+// токен two_factor. ID пользователя доступен через reqctx.PendingTwoFactor
+func RequirePendingTwoFactor(tokens *auth.TokenManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		value, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !ok || value == "" {
+			c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error: "Требуется токен из ответа на вход",
+				Code:  "UNAUTHORIZED",
+			})
+		}
+
+		claims, err := tokens.Parse(value, auth.TokenTypeTwoFactor)
+		if err != nil {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "INVALID_TOKEN",
+			})
+		}
+
+		userID, _ := claims.UserID()
+		reqctx.SetPendingTwoFactor(c, userID)
+
+		return c.Next()
+	}
+}
+
+// IdentifyUser опознает пользователя по access токену, если он передан,
+// и никогда не отклоняет запрос. Нужен middleware, которые работают
+// до RequireAuth и различают клиентов (лимит запросов по пользователю).
+// Не заменяет RequireAuth: без токена или с невалидным токеном
+// запрос проходит анонимно
+func IdentifyUser(tokens *auth.TokenManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		value, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !ok || value == "" {
+			return c.Next()
+		}
+
+		claims, err := tokens.Parse(value, auth.TokenTypeAccess)
+		if err != nil {
+			return c.Next()
+		}
+
+		userID, _ := claims.UserID()
+		reqctx.SetUser(c, reqctx.User{
+			ID:       userID,
+			Username: claims.Username,
+			Role:     claims.Role,
+		})
+
+		return c.Next()
+	}
+}
+
+// HeaderSudoToken - заголовок с sudo токеном (POST /api/v1/auth/sudo)
+const HeaderSudoToken = "X-Sudo-Token"
+
+// RequireSudo пропускает только запросы с действующим sudo токеном
+// того же пользователя, что и access токен. Подключается после RequireAuth
+// на разрушительных эндпоинтах: физическое удаление, смена роли,
+// вход от имени другого пользователя.
+// 403 SUDO_REQUIRED подсказывает клиенту запросить пароль и повторить запрос
+func RequireSudo(tokens *auth.TokenManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := reqctx.GetUser(c)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error: "Требуется аутентификация",
+				Code:  "UNAUTHORIZED",
+			})
+		}
+
+		claims, err := tokens.Parse(c.Get(HeaderSudoToken), auth.TokenTypeSudo)
+		if err == nil {
+			if sudoUserID, _ := claims.UserID(); sudoUserID == user.ID {
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{
+			Error: "Действие требует повторного ввода пароля",
+			Code:  "SUDO_REQUIRED",
+		})
+	}
+}
+
+// RequireRole пропускает только пользователей с одной из ролей roles
+// Подключается после RequireAuth, роль берется из access токена
+func RequireRole(roles ...string) fiber.Handler {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(c *fiber.Ctx) error {
+		user, ok := reqctx.GetUser(c)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error: "Требуется аутентификация",
+				Code:  "UNAUTHORIZED",
+			})
+		}
+
+		if !allowed[user.Role] {
+			return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{
+				Error: "Недостаточно прав",
+				Code:  "FORBIDDEN",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+)
+
+// newTwoFactorApp собирает приложение с обычным маршрутом и маршрутом
+// второго шага входа
+func newTwoFactorApp(tokens *auth.TokenManager) *fiber.App {
+	app := fiber.New()
+	app.Get("/me", RequireAuth(tokens), func(c *fiber.Ctx) error {
+		user, _ := reqctx.GetUser(c)
+		return c.JSON(fiber.Map{"id": user.ID})
+	})
+	app.Post("/2fa", RequirePendingTwoFactor(tokens), func(c *fiber.Ctx) error {
+		if _, ok := reqctx.GetUser(c); ok {
+			return c.Status(fiber.StatusInternalServerError).SendString("пользователь незавершенного входа считается аутентифицированным")
+		}
+		userID, _ := reqctx.PendingTwoFactor(c)
+		return c.JSON(fiber.Map{"id": userID})
+	})
+	return app
+}
+
+func TestTwoFactorTokenIsNotAccessToken(t *testing.T) {
+	tokens := auth.NewTokenManager(config.AuthConfig{
+		JWTSecret:    "secret",
+		Issuer:       "test",
+		AccessTTL:    time.Minute,
+		TwoFactorTTL: time.Minute,
+	})
+	access, _ := tokens.IssueAccess(7, "ivan", auth.RoleUser)
+	pending, _ := tokens.IssueTwoFactor(7)
+	app := newTwoFactorApp(tokens)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+		code   string
+	}{
+		{"access токен на обычном маршруте", fiber.MethodGet, "/me", access.Value, fiber.StatusOK, ""},
+		{"токен 2FA на обычном маршруте", fiber.MethodGet, "/me", pending.Value, fiber.StatusUnauthorized, "TWO_FACTOR_REQUIRED"},
+		{"токен 2FA на втором шаге", fiber.MethodPost, "/2fa", pending.Value, fiber.StatusOK, ""},
+		{"access токен на втором шаге", fiber.MethodPost, "/2fa", access.Value, fiber.StatusUnauthorized, "INVALID_TOKEN"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tc.token)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.status {
+				t.Fatalf("статус = %d, ожидался %d", resp.StatusCode, tc.status)
+			}
+			if tc.code == "" {
+				return
+			}
+			var body models.ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != tc.code {
+				t.Fatalf("код = %q, ожидался %q", body.Code, tc.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// StatusClientClosedRequest - клиент закрыл соединение, не дождавшись ответа
+// Нестандартный код nginx: в HTTP нет статуса для этой ситуации
+const StatusClientClosedRequest = 499
+
+// Причины прерванных запросов (метка reason в metrics.RequestsAborted)
+const (
+	AbortReasonClientClosed = "client_closed" // Клиент оборвал загрузку тела
+	AbortReasonReadTimeout  = "read_timeout"  // Тело не передано за ReadTimeout
+)
+
+// BodyReadError - запрос прерван при чтении тела: клиент оборвал загрузку
+// или не успел передать тело за ReadTimeout сервера.
+// Это не ошибка сервера: ответ 499 или 408, и в 5xx метрики такие
+// запросы не попадают (мобильные клиенты на плохой сети теряют
+// соединение постоянно)
+type BodyReadError struct {
+	Status  int    // 408 или 499
+	Code    string // Код ошибки для ErrorResponse
+	Message string // Текст ошибки для клиента
+	Reason  string // AbortReasonClientClosed или AbortReasonReadTimeout
+	Err     error  // Исходная ошибка чтения
+}
+
+// Error реализует интерфейс error
+func (e *BodyReadError) Error() string {
+	return e.Message
+}
+
+// Unwrap возвращает исходную ошибку чтения
+func (e *BodyReadError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyBodyReadError распознает обрыв загрузки и таймаут чтения тела
+// Возвращает nil, если err - другая ошибка
+func ClassifyBodyReadError(err error) *BodyReadError {
+	var readErr *BodyReadError
+	if errors.As(err, &readErr) {
+		return readErr
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, os.ErrDeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return &BodyReadError{
+			Status:  fiber.StatusRequestTimeout,
+			Code:    "REQUEST_TIMEOUT",
+			Message: "Тело запроса не получено вовремя",
+			Reason:  AbortReasonReadTimeout,
+			Err:     err,
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.EPIPE),
+		errors.Is(err, net.ErrClosed):
+		return &BodyReadError{
+			Status:  StatusClientClosedRequest,
+			Code:    "CLIENT_CLOSED_REQUEST",
+			Message: "Клиент закрыл соединение до получения тела запроса",
+			Reason:  AbortReasonClientClosed,
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+// ServerErrorHandler перехватывает ошибки чтения запроса на уровне fasthttp
+//
+// Тело запроса читается до роутинга, и Fiber сводит ошибки чтения к 400/502
+// без исходной причины. Обрывы загрузки и таймауты здесь распознаются
+// и передаются в ErrorHandler приложения как *BodyReadError, остальные
+// ошибки обрабатывает Fiber. Подключается после fiber.New:
+//
+//	app.Server().ErrorHandler = middleware.ServerErrorHandler(app)
+func ServerErrorHandler(app *fiber.App) func(*fasthttp.RequestCtx, error) {
+	next := app.Server().ErrorHandler
+	return func(fctx *fasthttp.RequestCtx, err error) {
+		readErr := ClassifyBodyReadError(err)
+		if readErr == nil {
+			next(fctx, err)
+			return
+		}
+
+		c := app.AcquireCtx(fctx)
+		defer app.ReleaseCtx(c)
+
+		// Запрос не дошел до middleware, поэтому пишем в лог здесь
+		// Метод и путь недоступны: fasthttp сбрасывает запрос при ошибке чтения
+		slog.Info("HTTP запрос прерван при чтении тела",
+			"ip", c.IP(), "reason", readErr.Reason, "error", err.Error())
+
+		if err := app.ErrorHandler(c, readErr); err != nil {
+			_ = c.SendStatus(readErr.Status)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/database"
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+)
+
+// timingKey - ключ Locals с замерами запроса для LatencyBudget
+type timingKey struct{}
+
+// requestTiming - бюджет роута и моменты начала и конца его обработчика
+type requestTiming struct {
+	budget time.Duration
+
+	handlerStart, handlerEnd     time.Time     // Нулевые - обработчик роута не вызывался (404)
+	handlerDBStart, handlerDBEnd time.Duration // Время SQL к началу и концу обработчика
+}
+
+// LatencyBudget пишет в лог запросы, которые обрабатывались дольше
+// бюджета своего роута (routes.Spec.Budget, без него - defaultBudget),
+// с разбивкой времени по этапам:
+//
+//   - middleware - до обработчика роута: проверки доступа, лимиты, разбор
+//   - handler - обработчик роута без SQL, включая кодирование c.JSON
+//   - db - SQL запросы всего запроса (database.CountingDB)
+//   - response - после обработчика: ответ из ошибки, скрытие полей
+//
+// Регрессия видна по логу без трассировки: какой этап вырос у какого роута.
+// Подключается ближе к началу цепочки, чтобы время включало остальные
+// middleware, и до RequestLogger, который переводит ошибки в ответ.
+// defaultBudget <= 0 - проверяются только роуты с явным бюджетом
+func LatencyBudget(defaultBudget time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		timing := &requestTiming{budget: defaultBudget}
+		c.Locals(timingKey{}, timing)
+		start := time.Now()
+
+		err := c.Next()
+
+		latency := time.Since(start)
+		if timing.budget <= 0 || latency <= timing.budget {
+			return err
+		}
+
+		var dbTime time.Duration
+		var queries int64
+		if qc := database.QueryCounterFromContext(c.UserContext()); qc != nil {
+			dbTime, queries = qc.Duration(), qc.Count()
+		}
+
+		// Без обработчика роута (404, статика) все время - middleware
+		before, handler, after := latency-dbTime, time.Duration(0), time.Duration(0)
+		if !timing.handlerStart.IsZero() {
+			before = timing.handlerStart.Sub(start) - timing.handlerDBStart
+			handler = timing.handlerEnd.Sub(timing.handlerStart) - (timing.handlerDBEnd - timing.handlerDBStart)
+			after = start.Add(latency).Sub(timing.handlerEnd) - (dbTime - timing.handlerDBEnd)
+		}
+
+		metrics.SlowRequests.WithLabelValues(c.Method(), c.Route().Path).Inc()
+		slog.LogAttrs(c.UserContext(), slog.LevelWarn, "🐢 Запрос превысил бюджет времени",
+			slog.String("method", c.Method()),
+			slog.String("route", c.Route().Path),
+			slog.Int("status", c.Response().StatusCode()),
+			slog.Duration("latency", latency),
+			slog.Duration("budget", timing.budget),
+			slog.Group("breakdown",
+				slog.Duration("middleware", nonNegative(before)),
+				slog.Duration("handler", nonNegative(handler)),
+				slog.Duration("db", dbTime),
+				slog.Duration("response", nonNegative(after)),
+			),
+			slog.Int64("db_queries", queries),
+			slog.Int("response_bytes", len(c.Response().Body())),
+		)
+		return err
+	}
+}
+
+// RouteBudget оборачивает обработчик роута для LatencyBudget:
+// задает бюджет роута и замеряет время обработчика (routes.Policy.Budget)
+// budget 0 оставляет бюджет по умолчанию, отрицательный отключает проверку
+func RouteBudget(budget time.Duration, handler fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		timing, ok := c.Locals(timingKey{}).(*requestTiming)
+		if !ok {
+			return handler(c)
+		}
+		if budget != 0 {
+			timing.budget = budget
+		}
+
+		qc := database.QueryCounterFromContext(c.UserContext())
+		if qc != nil {
+			timing.handlerDBStart = qc.Duration()
+		}
+		timing.handlerStart = time.Now()
+
+		err := handler(c)
+
+		timing.handlerEnd = time.Now()
+		if qc != nil {
+			timing.handlerDBEnd = qc.Duration()
+		}
+		return err
+	}
+}
+
+// nonNegative отбрасывает отрицательное время этапа: параллельные SQL
+// запросы (fanout) суммируются и могут превысить время самого этапа
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// captureLog перенаправляет стандартный логгер в буфер до конца теста
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestLatencyBudget(t *testing.T) {
+	logs := captureLog(t)
+
+	app := fiber.New()
+	app.Use(LatencyBudget(time.Hour))
+	// Медленный middleware до обработчика роута
+	app.Use(func(c *fiber.Ctx) error {
+		time.Sleep(20 * time.Millisecond)
+		return c.Next()
+	})
+	slow := func(c *fiber.Ctx) error {
+		time.Sleep(30 * time.Millisecond)
+		return c.SendString("ok")
+	}
+	app.Get("/slow", RouteBudget(10*time.Millisecond, slow))
+	app.Get("/default", RouteBudget(0, slow))
+	app.Get("/stream", RouteBudget(-1, slow))
+
+	for _, path := range []string{"/slow", "/default", "/stream"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// Записан только /slow: у /default бюджет по умолчанию (час),
+	// у /stream бюджета нет
+	var entries []map[string]interface{}
+	decoder := json.NewDecoder(logs)
+	for decoder.More() {
+		var entry map[string]interface{}
+		if err := decoder.Decode(&entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 1 || entries[0]["route"] != "/slow" {
+		t.Fatalf("записи лога: %v", entries)
+	}
+
+	breakdown, _ := entries[0]["breakdown"].(map[string]interface{})
+	middleware, _ := breakdown["middleware"].(float64)
+	handler, _ := breakdown["handler"].(float64)
+	if time.Duration(middleware) < 20*time.Millisecond || time.Duration(handler) < 30*time.Millisecond {
+		t.Errorf("разбивка %v: ожидалось middleware >= 20ms, handler >= 30ms", breakdown)
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+)
+
+// Субъекты, по которым считаются массовые чтения
+const (
+	BulkReadSubjectUser = "user"
+	BulkReadSubjectIP   = "ip"
+)
+
+// BulkReadAnomaly - клиент превысил порог массовых чтений в окне
+type BulkReadAnomaly struct {
+	Route    string // Роут запроса, на котором превышен порог
+	Subject  string // user или ip
+	Client   string // ID пользователя или IP
+	Requests int    // Запросов в окне, включая текущий
+	Window   time.Duration
+}
+
+// BulkReadMonitorConfig - настройки BulkReadMonitor
+type BulkReadMonitorConfig struct {
+	Store     RateLimitStore // Те же счетчики, что у лимитов запросов
+	Threshold int            // Запросов в окне, после которого - оповещение; 0 - выключено
+	Window    time.Duration
+
+	// OnAnomaly вызывается один раз за окно для каждого клиента,
+	// превысившего порог (например, запись в журнал аудита)
+	OnAnomaly func(ctx context.Context, anomaly BulkReadAnomaly)
+}
+
+// BulkReadMonitor следит за объемом экспортов и массовых чтений
+// (списки, поиск) и оповещает о клиентах, которые выгружают данные
+// подозрительно активно: украденный токен администратора первым делом
+// используют для выгрузки базы пользователей.
+//
+// Запросы считаются отдельно по пользователю (если он опознан) и по IP,
+// общим счетчиком для всех роутов, на которые подключен монитор. Когда
+// счетчик впервые в окне превышает Threshold, монитор пишет предупреждение
+// в лог, увеличивает metrics.BulkReadAnomalies и вызывает OnAnomaly.
+// Запрос при этом не отклоняется: ограничивают лимиты экспорта.
+// Подключается перед лимитами, чтобы отклоненные попытки тоже учитывались.
+// Недоступное хранилище счетчиков не мешает запросу
+func BulkReadMonitor(cfg BulkReadMonitorConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Threshold <= 0 {
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		clients := [][2]string{{BulkReadSubjectIP, c.IP()}}
+		if userID := UserKey(c); userID != "" {
+			clients = append(clients, [2]string{BulkReadSubjectUser, userID})
+		}
+
+		for _, client := range clients {
+			subject, id := client[0], client[1]
+			count, _, err := cfg.Store.Increment(ctx, "bulk_read:"+subject+":"+id, cfg.Window)
+			if err != nil {
+				slog.DebugContext(ctx, "⚠️  Счетчики массовых чтений недоступны", "error", err)
+				continue
+			}
+			// Ровно один раз за окно: дальше счетчик только растет
+			if count != cfg.Threshold+1 {
+				continue
+			}
+
+			anomaly := BulkReadAnomaly{
+				Route:    c.Route().Path,
+				Subject:  subject,
+				Client:   id,
+				Requests: count,
+				Window:   cfg.Window,
+			}
+			metrics.BulkReadAnomalies.WithLabelValues(anomaly.Route, subject).Inc()
+			slog.WarnContext(ctx, "🚨 Подозрительный объем массовых чтений",
+				"route", anomaly.Route, "subject", subject, "client", id,
+				"requests", count, "window", cfg.Window)
+			if cfg.OnAnomaly != nil {
+				cfg.OnAnomaly(ctx, anomaly)
+			}
+		}
+
+		return c.Next()
+	}
+}
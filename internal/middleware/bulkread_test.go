@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+)
+
+func TestBulkReadMonitorAlertsOncePerWindow(t *testing.T) {
+	var anomalies []BulkReadAnomaly
+	app := fiber.New()
+	// Заголовок X-Test-User имитирует пользователя, опознанного RequireAuth
+	app.Use(func(c *fiber.Ctx) error {
+		if c.Get("X-Test-User") != "" {
+			reqctx.SetUser(c, reqctx.User{ID: 7, Role: auth.RoleAdmin})
+		}
+		return c.Next()
+	})
+	app.Get("/export", BulkReadMonitor(BulkReadMonitorConfig{
+		Store:     NewMemoryRateLimitStore(),
+		Threshold: 3,
+		Window:    time.Hour,
+		OnAnomaly: func(_ context.Context, anomaly BulkReadAnomaly) {
+			anomalies = append(anomalies, anomaly)
+		},
+	}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	request := func(user bool) {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodGet, "/export", nil)
+		if user {
+			req.Header.Set("X-Test-User", "1")
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		// Монитор только оповещает и никогда не отклоняет запрос
+		if resp.StatusCode != fiber.StatusNoContent {
+			t.Fatalf("статус %d, ожидался 204", resp.StatusCode)
+		}
+	}
+
+	// Пользователь делает 3 запроса - порог не превышен
+	for i := 0; i < 3; i++ {
+		request(true)
+	}
+	if len(anomalies) != 0 {
+		t.Fatalf("оповещения до превышения порога: %+v", anomalies)
+	}
+
+	// 4-й запрос превышает порог и по пользователю, и по IP
+	request(true)
+	if len(anomalies) != 2 {
+		t.Fatalf("оповещений %d, ожидалось 2 (user и ip): %+v", len(anomalies), anomalies)
+	}
+	for _, anomaly := range anomalies {
+		if anomaly.Route != "/export" || anomaly.Requests != 4 {
+			t.Errorf("оповещение %+v", anomaly)
+		}
+	}
+	if anomalies[1].Subject != BulkReadSubjectUser || anomalies[1].Client != "7" {
+		t.Errorf("оповещение по пользователю %+v", anomalies[1])
+	}
+
+	// В том же окне повторных оповещений нет
+	request(true)
+	request(false)
+	if len(anomalies) != 2 {
+		t.Errorf("повторные оповещения в окне: %+v", anomalies[2:])
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// concurrencyRetryAfter - подсказка клиенту, когда повторить запрос,
+// если ожидание в очереди не задано
+const concurrencyRetryAfter = time.Second
+
+// ConcurrencyLimit ограничивает число одновременно выполняемых запросов
+// к дорогому эндпоинту (импорт, экспорт). В отличие от лимитера запросов
+// считает не частоту, а запросы, которые обрабатываются прямо сейчас,
+// общие для всех клиентов.
+//
+// Каждый вызов создает свой семафор, поэтому для независимых лимитов
+// на разные эндпоинты подключайте отдельные экземпляры.
+//
+// Если все слоты заняты, запрос ждет освобождения не дольше queueTimeout
+// (0 - не ждать) и затем получает 503 с Retry-After.
+// limit <= 0 отключает ограничение.
+func ConcurrencyLimit(limit int, queueTimeout time.Duration) fiber.Handler {
+	if limit <= 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	slots := make(chan struct{}, limit)
+
+	saturated := func() error {
+		retryAfter := queueTimeout
+		if retryAfter <= 0 {
+			retryAfter = concurrencyRetryAfter
+		}
+		return &RetryError{
+			Status:     fiber.StatusServiceUnavailable,
+			Code:       "CONCURRENCY_LIMIT_EXCEEDED",
+			Message:    "Сервер занят обработкой аналогичных запросов, повторите позже",
+			RetryAfter: retryAfter,
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		// 1. Свободный слот - без ожидания
+		select {
+		case slots <- struct{}{}:
+		default:
+			if queueTimeout <= 0 {
+				return saturated()
+			}
+
+			// 2. Ожидание в очереди, пока не освободится слот,
+			// не истечет queueTimeout или не отменится запрос
+			timer := time.NewTimer(queueTimeout)
+			defer timer.Stop()
+
+			select {
+			case slots <- struct{}{}:
+			case <-timer.C:
+				return saturated()
+			case <-c.UserContext().Done():
+				return saturated()
+			}
+		}
+		defer func() { <-slots }()
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestContext создает для каждого запроса отменяемый контекст с таймаутом
+// и кладет его в c.UserContext(), откуда его берут handlers и сервисы.
+//
+// Зачем это нужно: c.Context() в Fiber это *fasthttp.RequestCtx, его Done()
+// закрывается только при остановке сервера, поэтому запросы к БД с ним
+// никогда не отменяются. fasthttp также не сообщает об отключении клиента
+// во время работы handler'а, поэтому брошенный клиентом запрос ограничиваем
+// таймаутом: по его истечении драйвер БД отменяет выполняющийся запрос.
+// Дополнительно на стороне PostgreSQL действует statement_timeout (см. GetDSN)
+//
+// Нулевой или отрицательный timeout отключает ограничение
+func RequestContext(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if timeout <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		// cancel освобождает ресурсы контекста сразу после ответа,
+		// даже если таймаут еще не истек
+		defer cancel()
+
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		// Если время на запрос вышло, ответ handler'а (обычно 500 от отмененного
+		// запроса к БД) заменяем на понятный 504 через общий ErrorHandler
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fiber.NewError(fiber.StatusGatewayTimeout, "Превышено время обработки запроса")
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// blockingDB имитирует медленный запрос к БД:
+// Query блокируется, пока не отменят контекст
+type blockingDB struct {
+	canceled atomic.Int32 // Сколько запросов было прервано отменой контекста
+}
+
+func (d *blockingDB) Query(ctx context.Context, _ string) error {
+	select {
+	case <-ctx.Done():
+		d.canceled.Add(1)
+		return ctx.Err()
+	case <-time.After(10 * time.Second):
+		return errors.New("запрос не был отменен")
+	}
+}
+
+func TestRequestContextCancelsAbandonedQuery(t *testing.T) {
+	db := &blockingDB{}
+
+	app := fiber.New()
+	app.Use(RequestContext(50 * time.Millisecond))
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		if err := db.Query(c.UserContext(), "SELECT pg_sleep(60)"); err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	start := time.Now()
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/slow", nil), 5000)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+
+	if resp.StatusCode != fiber.StatusGatewayTimeout {
+		t.Errorf("status = %d, want %d", resp.StatusCode, fiber.StatusGatewayTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("запрос выполнялся %v, запрос к БД не был прерван", elapsed)
+	}
+	if got := db.canceled.Load(); got != 1 {
+		t.Errorf("отменено запросов к БД: %d, want 1", got)
+	}
+}
+
+func TestRequestContextCanceledAfterResponse(t *testing.T) {
+	var reqCtx context.Context
+
+	app := fiber.New()
+	app.Use(RequestContext(time.Minute))
+	app.Get("/", func(c *fiber.Ctx) error {
+		reqCtx = c.UserContext()
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusOK)
+	}
+
+	// Горутины, запущенные handler'ом с этим контекстом, не должны
+	// продолжать работу с БД после того как ответ отправлен
+	select {
+	case <-reqCtx.Done():
+	default:
+		t.Error("контекст запроса не отменен после ответа")
+	}
+}
+
+func TestRequestContextDisabled(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequestContext(0))
+	app.Get("/", func(c *fiber.Ctx) error {
+		if _, ok := c.UserContext().Deadline(); ok {
+			t.Error("у контекста не должно быть дедлайна при нулевом таймауте")
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil)); err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+}
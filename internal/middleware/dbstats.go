@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/database"
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+)
+
+// HeaderDBQueries - отладочный заголовок с количеством SQL запросов
+const HeaderDBQueries = "X-DB-Queries"
+
+// QueryStats считает SQL запросы, выполненные при обработке запроса,
+// и записывает их количество в гистограмму metrics.DBQueriesPerRequest.
+// Считаются только запросы, прошедшие через database.CountingDB
+// с контекстом из c.UserContext().
+//
+// debugHeader включает заголовок X-DB-Queries в ответе
+// Предназначен для development - в production раскрывает детали реализации
+func QueryStats(debugHeader bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, counter := database.WithQueryCounter(c.UserContext())
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		count := counter.Count()
+		// c.Route() после c.Next() указывает на шаблон сработавшего роута (/users/:id),
+		// а не на конкретный путь - так у метрики ограниченное число меток
+		metrics.DBQueriesPerRequest.
+			WithLabelValues(c.Method(), c.Route().Path).
+			Observe(float64(count))
+
+		if debugHeader {
+			c.Set(HeaderDBQueries, strconv.FormatInt(count, 10))
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/safe"
+)
+
+// MIMEApplicationProblemJSON - тип ошибок в формате RFC 9457
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// ErrorFormat отдает ошибки в формате, который выбрал клиент
+//
+// Клиенты с Accept: application/problem+json получают ProblemResponse
+// (RFC 9457) с request_id, остальные - прежний ErrorResponse
+// {"error": "...", "code": "..."}. Handlers по-прежнему пишут
+// ErrorResponse: тело переписывается здесь, после ErrorHandler.
+//
+// sunset - дата окончания переходного периода: если задана, ответы
+// в старом формате получают заголовок Sunset (RFC 8594). Нулевое
+// значение - без даты. Подключается после RequestID
+func ErrorFormat(sunset time.Time) fiber.Handler {
+	var sunsetHeader string
+	if !sunset.IsZero() {
+		sunsetHeader = sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			handleError(c, err)
+		}
+
+		status := c.Response().StatusCode()
+		if status < fiber.StatusBadRequest ||
+			!bytes.HasPrefix(c.Response().Header.ContentType(), []byte(fiber.MIMEApplicationJSON)) {
+			return nil
+		}
+		// Ответ зависит от Accept - кеши не должны отдавать его другим клиентам
+		c.Vary(fiber.HeaderAccept)
+
+		if !wantsProblem(c) {
+			if sunsetHeader != "" {
+				c.Set("Sunset", sunsetHeader)
+			}
+			metrics.ErrorResponses.WithLabelValues("legacy").Inc()
+			return nil
+		}
+
+		var legacy models.ErrorResponse
+		if err := json.Unmarshal(c.Response().Body(), &legacy); err != nil || legacy.Error == "" {
+			// Не ошибка API (например, тело ответа handler'а с 4xx) - отдаем как есть
+			return nil
+		}
+		metrics.ErrorResponses.WithLabelValues("problem").Inc()
+		// Если переписать не удалось, клиент получит ответ в старом формате
+		_ = safe.Call(c.UserContext(), "http_error", func() error {
+			return c.Status(status).JSON(models.ProblemResponse{
+				Type:      "about:blank",
+				Title:     http.StatusText(status),
+				Status:    status,
+				Detail:    legacy.Error,
+				Code:      legacy.Code,
+				Details:   legacy.Details,
+				RequestID: reqctx.RequestID(c),
+			}, MIMEApplicationProblemJSON)
+		})
+		return nil
+	}
+}
+
+// wantsProblem проверяет, что клиент предпочитает application/problem+json
+// Accept без problem+json (в том числе */*) оставляет старый формат
+func wantsProblem(c *fiber.Ctx) bool {
+	return c.Accepts(fiber.MIMEApplicationJSON, MIMEApplicationProblemJSON) == MIMEApplicationProblemJSON
+}
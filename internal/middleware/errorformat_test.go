@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+)
+
+// newErrorFormatApp собирает приложение с роутом, который отвечает 404,
+// и роутом, ошибку которого отвечает ErrorHandler
+func newErrorFormatApp(sunset time.Time) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: err.Error()})
+		},
+	})
+	app.Use(RequestID())
+	app.Use(ErrorFormat(sunset))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "пользователь не найден",
+			Code:  "USER_NOT_FOUND",
+		})
+	})
+	app.Get("/error", func(c *fiber.Ctx) error {
+		return fiber.ErrConflict
+	})
+	return app
+}
+
+func TestErrorFormatLegacy(t *testing.T) {
+	sunset := time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC)
+	app := newErrorFormatApp(sunset)
+
+	for _, accept := range []string{"", "*/*", "application/json"} {
+		req := httptest.NewRequest(fiber.MethodGet, "/users/1", nil)
+		if accept != "" {
+			req.Header.Set(fiber.HeaderAccept, accept)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+
+		var body models.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		resp.Body.Close()
+		if body.Error != "пользователь не найден" || body.Code != "USER_NOT_FOUND" {
+			t.Errorf("Accept %q: тело %+v, ожидался старый формат", accept, body)
+		}
+		if got := resp.Header.Get("Sunset"); got != "Mon, 01 Mar 2027 00:00:00 GMT" {
+			t.Errorf("Accept %q: Sunset = %q", accept, got)
+		}
+	}
+}
+
+func TestErrorFormatProblem(t *testing.T) {
+	app := newErrorFormatApp(time.Time{})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/users/1", nil)
+	req.Header.Set(fiber.HeaderAccept, "application/problem+json, application/json;q=0.9")
+	req.Header.Set(fiber.HeaderXRequestID, "req-1")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("статус %d, ожидался 404", resp.StatusCode)
+	}
+	if ct := resp.Header.Get(fiber.HeaderContentType); ct != MIMEApplicationProblemJSON {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if resp.Header.Get("Sunset") != "" {
+		t.Error("Sunset без даты перехода не нужен")
+	}
+
+	var body models.ProblemResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := models.ProblemResponse{
+		Type:      "about:blank",
+		Title:     "Not Found",
+		Status:    fiber.StatusNotFound,
+		Detail:    "пользователь не найден",
+		Code:      "USER_NOT_FOUND",
+		RequestID: "req-1",
+	}
+	if body.Type != want.Type || body.Title != want.Title || body.Status != want.Status ||
+		body.Detail != want.Detail || body.Code != want.Code || body.RequestID != want.RequestID {
+		t.Errorf("тело %+v, ожидалось %+v", body, want)
+	}
+}
+
+func TestErrorFormatReturnedError(t *testing.T) {
+	app := newErrorFormatApp(time.Time{})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/error", nil)
+	req.Header.Set(fiber.HeaderAccept, MIMEApplicationProblemJSON)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Ошибку сначала отвечает ErrorHandler, затем ответ переписывается
+	var body models.ProblemResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusConflict || body.Status != fiber.StatusConflict || body.Detail != "Conflict" {
+		t.Errorf("ответ %d %+v, ожидался 409 в формате problem", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+)
+
+// errJSONTooDeep и errJSONArrayTooLong - нарушения лимитов структуры JSON
+var (
+	errJSONTooDeep      = errors.New("превышена максимальная вложенность JSON")
+	errJSONArrayTooLong = errors.New("превышена максимальная длина массива в JSON")
+)
+
+// JSONLimits защищает от патологических JSON тел запросов, которые
+// укладываются в лимит размера, но дорого обходятся при разборе и обработке:
+// глубоко вложенные объекты и массивы с огромным числом элементов.
+//
+// Тело проверяется потоковым токенайзером без построения дерева значений,
+// поэтому сама проверка не аллоцирует память пропорционально вложенности.
+// При нарушении возвращается 422 с лимитом в Details.
+// Невалидный JSON пропускается дальше - его отклонит BodyParser в handler.
+//
+// Нулевое значение лимита отключает соответствующую проверку.
+// Для bulk эндпоинтов подключайте отдельный экземпляр со своим maxArrayLen
+func JSONLimits(maxDepth, maxArrayLen int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		body := c.Body()
+		if len(body) == 0 || !isJSONContentType(c.Get(fiber.HeaderContentType)) {
+			return c.Next()
+		}
+
+		err := checkJSONLimits(body, maxDepth, maxArrayLen)
+		switch {
+		case errors.Is(err, errJSONTooDeep):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{
+				Error:   err.Error(),
+				Code:    "JSON_TOO_DEEP",
+				Details: map[string]interface{}{"max_depth": maxDepth},
+			})
+		case errors.Is(err, errJSONArrayTooLong):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{
+				Error:   err.Error(),
+				Code:    "JSON_ARRAY_TOO_LONG",
+				Details: map[string]interface{}{"max_array_length": maxArrayLen},
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// isJSONContentType сообщает, разберет ли BodyParser тело как JSON
+// Тип приводится так же, как в Fiber: нижний регистр, суффикс vendor
+// типа (application/vnd.x+json), без параметров - и оканчивается на json.
+// Иначе Application/JSON или text/json обходили бы лимиты, но разбирались
+func isJSONContentType(contentType string) bool {
+	ctype := utils.ParseVendorSpecificContentType(utils.ToLower(contentType))
+	if end := strings.IndexByte(ctype, ';'); end != -1 {
+		ctype = ctype[:end]
+	}
+	return strings.HasSuffix(strings.TrimSpace(ctype), "json")
+}
+
+// checkJSONLimits обходит токены JSON и проверяет вложенность и длину массивов
+// Возвращает errJSONTooDeep, errJSONArrayTooLong или ошибку разбора JSON
+func checkJSONLimits(body []byte, maxDepth, maxArrayLen int) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+
+	// Для каждого открытого уровня храним счетчик элементов;
+	// -1 означает объект, у которого элементы не считаем
+	var stack []int
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		delim, isDelim := tok.(json.Delim)
+
+		// Значение внутри массива (скаляр или начало вложенной структуры)
+		// увеличивает его счетчик; закрывающие скобки значениями не являются
+		if !isDelim || delim == '[' || delim == '{' {
+			if n := len(stack); n > 0 && stack[n-1] >= 0 {
+				stack[n-1]++
+				if maxArrayLen > 0 && stack[n-1] > maxArrayLen {
+					return errJSONArrayTooLong
+				}
+			}
+		}
+
+		if !isDelim {
+			continue
+		}
+
+		switch delim {
+		case '[', '{':
+			if maxDepth > 0 && len(stack)+1 > maxDepth {
+				return errJSONTooDeep
+			}
+			if delim == '[' {
+				stack = append(stack, 0)
+			} else {
+				stack = append(stack, -1)
+			}
+		case ']', '}':
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestJSONLimits(t *testing.T) {
+	app := fiber.New()
+	app.Post("/", JSONLimits(3, 2), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	deep := `{"a": {"b": {"c": {"d": 1}}}}`
+	long := `{"ids": [1, 2, 3]}`
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"допустимое тело", fiber.MIMEApplicationJSON, `{"a": [1, 2]}`, fiber.StatusNoContent},
+		{"вложенность", fiber.MIMEApplicationJSON, deep, fiber.StatusUnprocessableEntity},
+		{"длина массива", fiber.MIMEApplicationJSON, long, fiber.StatusUnprocessableEntity},
+		{"с параметром charset", fiber.MIMEApplicationJSONCharsetUTF8, deep, fiber.StatusUnprocessableEntity},
+		// BodyParser разбирает их как JSON, поэтому и лимиты те же
+		{"другой регистр", "Application/JSON", deep, fiber.StatusUnprocessableEntity},
+		{"vendor тип", "application/vnd.api+json", long, fiber.StatusUnprocessableEntity},
+		{"vendor тип с параметром", "application/vnd.x+JSON; charset=utf-8", deep, fiber.StatusUnprocessableEntity},
+		{"text/json", "text/json", deep, fiber.StatusUnprocessableEntity},
+		// Не JSON и невалидный JSON проверяет не этот middleware
+		{"форма", fiber.MIMEApplicationForm, deep, fiber.StatusNoContent},
+		{"без типа", "", deep, fiber.StatusNoContent},
+		{"невалидный JSON", fiber.MIMEApplicationJSON, `{"a": `, fiber.StatusNoContent},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			req.Header.Set(fiber.HeaderContentType, tc.contentType)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s (%q): статус %d, ожидался %d", tc.name, tc.contentType, resp.StatusCode, tc.status)
+		}
+	}
+}
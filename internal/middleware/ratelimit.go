@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+)
+
+// HeaderRateLimitWarning - предупреждение о превышении мягкого лимита
+// Значение только ASCII: заголовки с другими символами ломают часть клиентов
+const HeaderRateLimitWarning = "X-RateLimit-Warning"
+
+// RateLimitStore считает запросы клиентов в фиксированных окнах
+// Для нескольких инстансов сервиса нужна общая реализация (например, Redis),
+// иначе каждый инстанс считает лимит отдельно
+type RateLimitStore interface {
+	// Increment увеличивает счетчик key в текущем окне
+	// Возвращает значение счетчика после увеличения и момент обнуления окна
+	Increment(ctx context.Context, key string, window time.Duration) (count int, reset time.Time, err error)
+}
+
+// memoryWindow - счетчик одного клиента в текущем окне
+type memoryWindow struct {
+	count int
+	reset time.Time
+}
+
+// MemoryRateLimitStore - RateLimitStore в памяти процесса
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	lastPrune time.Time
+}
+
+// NewMemoryRateLimitStore создает счетчики лимита в памяти
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		windows: make(map[string]*memoryWindow),
+	}
+}
+
+// Increment реализует RateLimitStore
+func (m *MemoryRateLimitStore) Increment(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Истекшие окна удаляются не чаще раза в window,
+	// поэтому в памяти только клиенты последних двух окон
+	if now.Sub(m.lastPrune) >= window {
+		for k, w := range m.windows {
+			if !now.Before(w.reset) {
+				delete(m.windows, k)
+			}
+		}
+		m.lastPrune = now
+	}
+
+	w, ok := m.windows[key]
+	if !ok || !now.Before(w.reset) {
+		w = &memoryWindow{reset: now.Add(window)}
+		m.windows[key] = w
+	}
+	w.count++
+
+	return w.count, w.reset, nil
+}
+
+// burstWindow - окно лимита всплеска (RateLimitConfig.Burst)
+const burstWindow = time.Second
+
+// RateLimitConfig - настройки лимита запросов
+type RateLimitConfig struct {
+	Name      string        // Имя лимита в метриках (api, user, auth)
+	Limit     int           // Жесткий лимит: сверх него 429
+	SoftLimit int           // Мягкий лимит: сверх него X-RateLimit-Warning, 0 - без предупреждений
+	Burst     int           // Запросов в секунду: сверх него 429, 0 - без ограничения
+	Window    time.Duration // Длина окна
+	Store     RateLimitStore
+
+	// FailClosed - при недоступном хранилище отвечать 503 вместо того,
+	// чтобы пропускать запросы без лимита
+	FailClosed bool
+
+	// KeyFunc определяет клиента, по умолчанию - IP адрес
+	// Пустой ключ - лимит к запросу не применяется
+	KeyFunc func(c *fiber.Ctx) string
+}
+
+// IPKey определяет клиента по IP адресу
+func IPKey(c *fiber.Ctx) string {
+	return c.IP()
+}
+
+// UserKey определяет клиента по пользователю из access токена
+// Пользователи за одним NAT не делят лимит между собой.
+// Подключается после IdentifyUser или RequireAuth
+func UserKey(c *fiber.Ctx) string {
+	user, ok := reqctx.GetUser(c)
+	if !ok {
+		return ""
+	}
+	return strconv.Itoa(user.ID)
+}
+
+// RateLimit ограничивает частоту запросов клиента в два уровня
+//
+//   - до SoftLimit запросы обрабатываются как обычно
+//   - после SoftLimit запросы обрабатываются, но ответ получает
+//     X-RateLimit-Warning, а запрос учитывается в metrics.RateLimitWarnings.
+//     Это дает интеграторам время исправить клиента до жесткого лимита
+//   - после Limit - 429 RATE_LIMIT_EXCEEDED с Retry-After
+//
+// Burst дополнительно ограничивает всплески: запросы сверх Burst в секунду
+// получают 429, даже если лимит окна не исчерпан. Такие запросы
+// не расходуют лимит окна.
+//
+// Каждый ответ несет X-RateLimit-* заголовки с остатком жесткого лимита.
+// Если хранилище счетчиков недоступно, запрос пропускается: лимитер
+// не должен ронять API вместе с собой. С FailClosed такой запрос
+// получает 503 RATE_LIMIT_UNAVAILABLE.
+// Limit <= 0 отключает ограничение.
+func RateLimit(cfg RateLimitConfig) fiber.Handler {
+	return NewRateLimiter(cfg).Handler()
+}
+
+// RateLimiter - лимит запросов, значения которого меняются без перезапуска
+// (перезагрузка конфигурации по SIGHUP). Счетчики в Store при этом
+// сохраняются: клиент, уже исчерпавший часть окна, не получает его заново
+type RateLimiter struct {
+	cfg atomic.Pointer[RateLimitConfig]
+}
+
+// NewRateLimiter создает лимит запросов, см. RateLimit
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = IPKey
+	}
+	r := &RateLimiter{}
+	r.cfg.Store(&cfg)
+	return r
+}
+
+// SetLimits меняет значения лимита для следующих запросов
+// Имя, хранилище и KeyFunc не меняются
+func (r *RateLimiter) SetLimits(limit, softLimit, burst int, window time.Duration) {
+	cfg := *r.cfg.Load()
+	cfg.Limit = limit
+	cfg.SoftLimit = softLimit
+	cfg.Burst = burst
+	cfg.Window = window
+	r.cfg.Store(&cfg)
+}
+
+// Handler возвращает middleware, которое применяет текущие значения лимита
+func (r *RateLimiter) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cfg := r.cfg.Load()
+		if cfg.Limit <= 0 {
+			return c.Next()
+		}
+
+		client := cfg.KeyFunc(c)
+		if client == "" {
+			return c.Next()
+		}
+
+		// Всплеск проверяется первым, чтобы отклоненные запросы не расходовали окно
+		if cfg.Burst > 0 {
+			count, reset, err := cfg.Store.Increment(c.UserContext(), cfg.Name+":burst:"+client, burstWindow)
+			if err != nil {
+				return storeFailed(c, cfg, err)
+			}
+			if count > cfg.Burst {
+				metrics.RateLimitRejected.WithLabelValues(cfg.Name + "_burst").Inc()
+				return &RetryError{
+					Status:     fiber.StatusTooManyRequests,
+					Code:       "RATE_LIMIT_EXCEEDED",
+					Message:    "Слишком много запросов, повторите позже",
+					RetryAfter: time.Until(reset),
+				}
+			}
+		}
+
+		key := cfg.Name + ":" + client
+		count, reset, err := cfg.Store.Increment(c.UserContext(), key, cfg.Window)
+		if err != nil {
+			return storeFailed(c, cfg, err)
+		}
+
+		state := RateLimitState{
+			Limit:     cfg.Limit,
+			Remaining: cfg.Limit - count,
+			Reset:     reset,
+		}
+
+		// Жесткий лимит
+		if count > cfg.Limit {
+			metrics.RateLimitRejected.WithLabelValues(cfg.Name).Inc()
+			return &RetryError{
+				Status:     fiber.StatusTooManyRequests,
+				Code:       "RATE_LIMIT_EXCEEDED",
+				Message:    "Слишком много запросов, повторите позже",
+				RetryAfter: time.Until(reset),
+				RateLimit:  &state,
+			}
+		}
+
+		SetRateLimitHeaders(c, state)
+
+		// Мягкий лимит
+		if cfg.SoftLimit > 0 && count > cfg.SoftLimit {
+			metrics.RateLimitWarnings.WithLabelValues(cfg.Name).Inc()
+			c.Set(HeaderRateLimitWarning, fmt.Sprintf(
+				"soft limit of %d requests per %s exceeded, requests over %d will be rejected with 429",
+				cfg.SoftLimit, cfg.Window, cfg.Limit))
+		}
+
+		return c.Next()
+	}
+}
+
+// rateLimitUnavailableRetry - Retry-After ответа 503, когда хранилище
+// счетчиков недоступно (FailClosed)
+const rateLimitUnavailableRetry = 5 * time.Second
+
+// storeFailed обрабатывает запрос, для которого не удалось посчитать лимит
+func storeFailed(c *fiber.Ctx, cfg *RateLimitConfig, err error) error {
+	if !cfg.FailClosed {
+		slog.DebugContext(c.UserContext(), "⚠️  Лимитер недоступен, запрос пропущен", "limit", cfg.Name, "error", err)
+		return c.Next()
+	}
+
+	slog.DebugContext(c.UserContext(), "⚠️  Лимитер недоступен, запрос отклонен", "limit", cfg.Name, "error", err)
+	metrics.RateLimitRejected.WithLabelValues(cfg.Name + "_unavailable").Inc()
+	return &RetryError{
+		Status:     fiber.StatusServiceUnavailable,
+		Code:       "RATE_LIMIT_UNAVAILABLE",
+		Message:    "Сервис временно недоступен, повторите позже",
+		RetryAfter: rateLimitUnavailableRetry,
+	}
+}
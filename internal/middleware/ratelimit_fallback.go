@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+)
+
+// ErrRateLimitUnavailable - основное хранилище счетчиков недоступно,
+// а запасного нет
+var ErrRateLimitUnavailable = errors.New("хранилище счетчиков лимита недоступно")
+
+// FallbackRateLimitStore - RateLimitStore, который переживает недоступность
+// основного хранилища (Redis)
+//
+// После ошибки основного хранилища запросы retryInterval считаются
+// в запасном, а основное не опрашивается: иначе каждый запрос ждал бы
+// таймаута Redis. Первый запрос после интервала снова пробует основное.
+// Без запасного хранилища Increment сразу возвращает ErrRateLimitUnavailable,
+// и RateLimiter пропускает или отклоняет запрос по RateLimitConfig.FailClosed
+type FallbackRateLimitStore struct {
+	name          string // Имя зависимости в логах и метриках
+	primary       RateLimitStore
+	fallback      RateLimitStore // nil - запасного хранилища нет
+	retryInterval time.Duration
+
+	retryAt  atomic.Int64 // UnixNano, до которого основное хранилище не опрашивается
+	degraded atomic.Bool
+}
+
+// NewFallbackRateLimitStore создает хранилище с запасным путем
+// fallback может быть nil
+func NewFallbackRateLimitStore(name string, primary, fallback RateLimitStore, retryInterval time.Duration) *FallbackRateLimitStore {
+	metrics.DependencyDegraded.WithLabelValues(name, "rate_limit").Set(0)
+	return &FallbackRateLimitStore{
+		name:          name,
+		primary:       primary,
+		fallback:      fallback,
+		retryInterval: retryInterval,
+	}
+}
+
+// Increment реализует RateLimitStore
+func (s *FallbackRateLimitStore) Increment(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	now := time.Now()
+	if now.UnixNano() < s.retryAt.Load() {
+		return s.incrementFallback(ctx, key, window)
+	}
+
+	count, reset, err := s.primary.Increment(ctx, key, window)
+	if err == nil {
+		if s.degraded.CompareAndSwap(true, false) {
+			slog.Info("✅ Лимиты запросов снова считаются в основном хранилище", "dependency", s.name)
+			metrics.DependencyDegraded.WithLabelValues(s.name, "rate_limit").Set(0)
+		}
+		return count, reset, nil
+	}
+
+	// Отмена запроса клиентом не говорит о состоянии хранилища
+	if ctx.Err() != nil {
+		return 0, time.Time{}, err
+	}
+
+	s.retryAt.Store(now.Add(s.retryInterval).UnixNano())
+	if s.degraded.CompareAndSwap(false, true) {
+		slog.Warn("⚠️  Хранилище лимитов недоступно, работаем по запасному пути",
+			"dependency", s.name, "fallback", s.fallback != nil, "retry_interval", s.retryInterval, "error", err)
+		metrics.DependencyDegraded.WithLabelValues(s.name, "rate_limit").Set(1)
+	}
+	return s.incrementFallback(ctx, key, window)
+}
+
+// incrementFallback считает запрос в запасном хранилище
+func (s *FallbackRateLimitStore) incrementFallback(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	if s.fallback == nil {
+		return 0, time.Time{}, ErrRateLimitUnavailable
+	}
+	return s.fallback.Increment(ctx, key, window)
+}
+
+// Degraded сообщает, что лимиты сейчас считаются без основного хранилища
+func (s *FallbackRateLimitStore) Degraded() bool {
+	return s.degraded.Load()
+}
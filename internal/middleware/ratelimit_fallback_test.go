@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// failingStore - хранилище, которое считает вызовы и отвечает ошибкой, пока down
+type failingStore struct {
+	calls int
+	down  bool
+}
+
+func (s *failingStore) Increment(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	s.calls++
+	if s.down {
+		return 0, time.Time{}, errors.New("connection refused")
+	}
+	return 1, time.Now().Add(window), nil
+}
+
+func TestFallbackRateLimitStoreSkipsPrimaryAfterFailure(t *testing.T) {
+	primary := &failingStore{down: true}
+	store := NewFallbackRateLimitStore("test", primary, NewMemoryRateLimitStore(), time.Hour)
+
+	for i := 1; i <= 3; i++ {
+		count, _, err := store.Increment(context.Background(), "k", time.Minute)
+		if err != nil {
+			t.Fatalf("запрос %d: %v", i, err)
+		}
+		if count != i {
+			t.Errorf("запрос %d: счетчик %d", i, count)
+		}
+	}
+	if primary.calls != 1 {
+		t.Errorf("основное хранилище опрошено %d раз, ожидался 1", primary.calls)
+	}
+	if !store.Degraded() {
+		t.Error("Degraded() = false после ошибки")
+	}
+
+	// После интервала основное хранилище опрашивается снова
+	store.retryAt.Store(0)
+	primary.down = false
+	if _, _, err := store.Increment(context.Background(), "k", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if store.Degraded() {
+		t.Error("Degraded() = true после восстановления")
+	}
+}
+
+func TestRateLimitWithoutStore(t *testing.T) {
+	tests := []struct {
+		name       string
+		failClosed bool
+		want       int
+	}{
+		{"open", false, fiber.StatusOK},
+		{"closed", true, fiber.StatusServiceUnavailable},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := NewFallbackRateLimitStore("test", &failingStore{down: true}, nil, time.Hour)
+			app := fiber.New(fiber.Config{
+				ErrorHandler: func(c *fiber.Ctx, err error) error {
+					var retry *RetryError
+					if errors.As(err, &retry) {
+						retry.SetHeaders(c)
+						return c.SendStatus(retry.Status)
+					}
+					return fiber.DefaultErrorHandler(c, err)
+				},
+			})
+			app.Use(RateLimit(RateLimitConfig{Name: "test", Limit: 10, Window: time.Minute, Store: store, FailClosed: tc.failClosed}))
+			app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.want {
+				t.Errorf("статус %d, ожидался %d", resp.StatusCode, tc.want)
+			}
+			if tc.failClosed && resp.Header.Get(fiber.HeaderRetryAfter) == "" {
+				t.Error("нет Retry-After")
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisRateLimitPrefix отделяет ключи лимитера от других данных в Redis
+const redisRateLimitPrefix = "ratelimit:"
+
+// redisIncrementScript увеличивает счетчик и при первом запросе окна
+// выставляет время жизни ключа. Скрипт выполняется атомарно, поэтому
+// счетчик не может остаться без TTL, если клиент оборвался между командами
+var redisIncrementScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {count, redis.call('PTTL', KEYS[1])}
+`)
+
+// RedisRateLimitStore - RateLimitStore в Redis
+// Счетчики общие для всех инстансов сервиса, поэтому лимит соблюдается
+// независимо от того, на какой инстанс балансировщик отправил запрос
+type RedisRateLimitStore struct {
+	client redis.Scripter
+}
+
+// NewRedisRateLimitStore создает счетчики лимита в Redis
+func NewRedisRateLimitStore(client redis.Scripter) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client}
+}
+
+// Increment реализует RateLimitStore
+func (r *RedisRateLimitStore) Increment(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	res, err := redisIncrementScript.Run(ctx, r.client, []string{redisRateLimitPrefix + key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("ошибка счетчика лимита в Redis: %w", err)
+	}
+
+	count, ttl := res[0], time.Duration(res[1])*time.Millisecond
+	// PTTL < 0: ключ без срока жизни (не должно случаться) - считаем окно новым
+	if ttl < 0 {
+		ttl = window
+	}
+
+	return int(count), time.Now().Add(ttl), nil
+}
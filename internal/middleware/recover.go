@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/safe"
+)
+
+// fallbackErrorBody - ответ на панику при формировании ответа об ошибке:
+// готовые байты, в которых нечему упасть
+var fallbackErrorBody = []byte(`{"error":"внутренняя ошибка сервера","code":"INTERNAL_ERROR"}`)
+
+// Recover переводит панику handler'а или middleware после него в ошибку
+// *safe.PanicError: ErrorHandler отвечает 500 INTERNAL_ERROR без текста
+// паники, а паника пишется в лог со стеком и в метрику
+// fiber_backend_panics_total{component="http"}
+//
+// В отличие от recover.New из Fiber, паника при сериализации ответа
+// (MarshalJSON модели, кодек APP_JSON_CODEC) тоже перехватывается здесь:
+// c.JSON выполняется внутри handler'а
+func Recover() fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer safe.Recover(c.UserContext(), "http", &err)
+		return c.Next()
+	}
+}
+
+// handleError вызывает ErrorHandler приложения; если он вернул ошибку или
+// упал сам (например, не сериализуются Details ошибки), отдает
+// fallbackErrorBody
+func handleError(c *fiber.Ctx, err error) {
+	err = safe.Call(c.UserContext(), "http_error", func() error {
+		return c.App().ErrorHandler(c, err)
+	})
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError)
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		c.Response().SetBodyRaw(fallbackErrorBody)
+	}
+}
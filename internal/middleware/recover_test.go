@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/safe"
+)
+
+// unmarshalable паникует при сериализации, как модель с ошибкой в MarshalJSON
+type unmarshalable struct{}
+
+func (unmarshalable) MarshalJSON() ([]byte, error) {
+	panic("ошибка сериализации")
+}
+
+func TestRecoverPanicInSerialization(t *testing.T) {
+	var handled error
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			handled = err
+			// ErrorHandler, который сам падает на Details ошибки
+			return c.Status(fiber.StatusInternalServerError).JSON(map[string]any{"details": unmarshalable{}})
+		},
+	})
+	app.Use(ErrorFormat(time.Time{}))
+	app.Use(Recover())
+	app.Get("/users", func(c *fiber.Ctx) error {
+		return c.JSON(unmarshalable{})
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/users", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	var panicErr *safe.PanicError
+	if !errors.As(handled, &panicErr) || panicErr.Component != "http" {
+		t.Errorf("ErrorHandler получил %v, ожидалась *safe.PanicError", handled)
+	}
+	if resp.StatusCode != fiber.StatusInternalServerError || string(body) != string(fallbackErrorBody) {
+		t.Errorf("ответ %d %s, ожидался запасной ответ 500", resp.StatusCode, body)
+	}
+}
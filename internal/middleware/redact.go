@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/redact"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+)
+
+// PublicIDResolver возвращает публичный ID пользователя по внутреннему
+type PublicIDResolver func(ctx context.Context, userID int) (string, error)
+
+// Redact скрывает и маскирует поля JSON ответов по политике
+// (RESPONSE_REDACTION) для роли и тенанта вызывающего
+//
+// Тело переписывается после handler, поэтому новые эндпоинты защищены
+// без изменений в handlers. Пользователь запроса берется из reqctx:
+// IdentifyUser и RequireAuth выполняются внутри c.Next(). Потоковые
+// ответы при действующих правилах собираются в памяти целиком.
+// Ответы не JSON телом (gRPC, выгрузки CSV и NDJSON) скрывают поля
+// при сериализации, см. redact.Copy.
+//
+// Свою запись вызывающий видит целиком; она узнается по публичному ID,
+// который publicID находит по ID из access токена.
+//
+// Если тело не удалось разобрать, запрос завершается ошибкой 500:
+// отдать поля без маскировки хуже, чем не ответить
+func Redact(policy *redact.Policy, publicID PublicIDResolver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if !bytes.HasPrefix(c.Response().Header.ContentType(), []byte(fiber.MIMEApplicationJSON)) {
+			return nil
+		}
+
+		user, ok := reqctx.GetUser(c)
+		rules := policy.For(user.Role, reqctx.Tenant(c))
+		if len(rules) == 0 {
+			return nil
+		}
+
+		var self string
+		if ok {
+			id, err := publicID(c.UserContext(), user.ID)
+			if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+				return fmt.Errorf("ошибка скрытия полей ответа: %w", err)
+			}
+			self = id
+		}
+
+		body, err := redact.Apply(c.Response().Body(), rules, self)
+		if err != nil {
+			return fmt.Errorf("ошибка скрытия полей ответа: %w", err)
+		}
+		c.Response().SetBodyRaw(body)
+		return nil
+	}
+}
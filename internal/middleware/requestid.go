@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+)
+
+// maxRequestIDLength - максимальная длина входящего X-Request-ID
+const maxRequestIDLength = 128
+
+// RequestID присваивает запросу идентификатор и возвращает его
+// в заголовке X-Request-ID
+//
+// Идентификатор от клиента или балансировщика сохраняется, чтобы запрос
+// можно было проследить через несколько сервисов. Пустой или подозрительный
+// (длинный, с пробелами и управляющими символами) заменяется новым.
+// Идентификатор доступен через reqctx.RequestID и попадает
+// в каждую запись лога с контекстом запроса (см. пакет logging)
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(fiber.HeaderXRequestID)
+		if !ValidRequestID(requestID) {
+			requestID = NewRequestID()
+		}
+
+		reqctx.SetRequestID(c, requestID)
+		c.Set(fiber.HeaderXRequestID, requestID)
+
+		return c.Next()
+	}
+}
+
+// HeaderServedBy - регион экземпляра, обработавшего запрос
+const HeaderServedBy = "X-Served-By"
+
+// ServedBy возвращает регион экземпляра (REGION) в заголовке X-Served-By
+// При маршрутизации по задержке клиент и поддержка видят, какой регион
+// ответил; вместе с X-Request-ID это находит запрос в логах региона
+func ServedBy(region string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(HeaderServedBy, region)
+		return c.Next()
+	}
+}
+
+// maxUserAgentLength - сколько символов User-Agent сохраняется для аудита
+const maxUserAgentLength = 512
+
+// ClientInfo сохраняет IP и User-Agent клиента в контексте запроса
+// Сервисы получают только context.Context и читают их через
+// reqctx.ClientFromContext (метаданные записей журнала аудита)
+func ClientInfo() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userAgent := c.Get(fiber.HeaderUserAgent)
+		if len(userAgent) > maxUserAgentLength {
+			// Обрезка могла разрезать UTF-8 символ, а PostgreSQL не примет такую строку
+			userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+		}
+		reqctx.SetClient(c, reqctx.Client{
+			IP:        c.IP(),
+			UserAgent: userAgent,
+		})
+		return c.Next()
+	}
+}
+
+// ValidRequestID проверяет, что идентификатор можно безопасно
+// записать в лог и вернуть в заголовке (или в метаданных gRPC ответа)
+func ValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewRequestID генерирует случайный идентификатор из 32 hex символов
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand не отказывает на поддерживаемых платформах,
+		// но идентификатор запроса не повод ронять запрос
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// RequestLogger пишет по одной записи лога на каждый HTTP запрос:
+// метод, путь, роут, статус, время обработки и IP клиента
+//
+// Подключается после RequestID, чтобы запись получила request_id.
+// Ошибку handler'а переводит в ответ через ErrorHandler приложения, иначе
+// статус ответа еще не известен. Ответы 5xx пишутся с уровнем ERROR,
+// 4xx - WARN, остальные - INFO. 499 (клиент ушел сам) - тоже INFO:
+// на мобильных сетях это обычное дело
+func RequestLogger() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		chainErr := c.Next()
+		if chainErr != nil {
+			if err := c.App().ErrorHandler(c, chainErr); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status == StatusClientClosedRequest:
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.String("route", c.Route().Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("ip", c.IP()),
+		}
+		if chainErr != nil {
+			attrs = append(attrs, slog.String("error", chainErr.Error()))
+		}
+
+		slog.LogAttrs(c.UserContext(), level, "HTTP запрос", attrs...)
+		return nil
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Заголовки лимитера запросов
+// Де-факто стандарт, который понимают большинство HTTP клиентов и SDK
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"     // Сколько запросов разрешено в окне
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining" // Сколько запросов осталось в текущем окне
+	HeaderRateLimitReset     = "X-RateLimit-Reset"     // Через сколько секунд окно обнулится
+)
+
+// RateLimitState описывает текущее состояние лимитера для конкретного клиента
+// Лимитеры заполняют эту структуру из своих счетчиков, а не из констант
+type RateLimitState struct {
+	Limit     int       // Максимум запросов в окне
+	Remaining int       // Осталось запросов в окне
+	Reset     time.Time // Момент обнуления окна
+}
+
+// RetryError возвращается из middleware и handlers, когда сервис временно
+// не может обработать запрос (429 - лимит запросов, 503 - обслуживание,
+// разомкнутый circuit breaker) и клиенту стоит повторить попытку позже.
+// ErrorHandler приложения выставляет по ней Retry-After и X-RateLimit-* заголовки
+type RetryError struct {
+	Status     int             // HTTP статус: 429 или 503
+	Code       string          // Код ошибки для ErrorResponse
+	Message    string          // Текст ошибки для клиента
+	RetryAfter time.Duration   // Через сколько можно повторить запрос
+	RateLimit  *RateLimitState // Состояние лимитера, nil если ответ не связан с лимитом
+}
+
+// Error реализует интерфейс error
+func (e *RetryError) Error() string {
+	return e.Message
+}
+
+// SetHeaders выставляет Retry-After и, если известно состояние лимитера, X-RateLimit-* заголовки
+func (e *RetryError) SetHeaders(c *fiber.Ctx) {
+	SetRetryAfter(c, e.RetryAfter)
+	if e.RateLimit != nil {
+		SetRateLimitHeaders(c, *e.RateLimit)
+	}
+}
+
+// SetRetryAfter выставляет заголовок Retry-After в секундах
+// Значение округляется вверх и не бывает меньше 1 секунды,
+// чтобы клиенты не начинали повторять запрос мгновенно
+func SetRetryAfter(c *fiber.Ctx, d time.Duration) {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(d)))
+}
+
+// SetRateLimitHeaders выставляет X-RateLimit-* заголовки по состоянию лимитера
+// Можно вызывать и для успешных ответов, чтобы клиент видел остаток лимита
+func SetRateLimitHeaders(c *fiber.Ctx, state RateLimitState) {
+	remaining := state.Remaining
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	c.Set(HeaderRateLimitLimit, strconv.Itoa(state.Limit))
+	c.Set(HeaderRateLimitRemaining, strconv.Itoa(remaining))
+	c.Set(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(time.Until(state.Reset))))
+}
+
+// ceilSeconds переводит длительность в целые секунды с округлением вверх, минимум 1
+func ceilSeconds(d time.Duration) int {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/pkg/svcauth"
+)
+
+// ServiceAuth - способы аутентификации внутренних сервисов
+type ServiceAuth struct {
+	// Keys - ключ HMAC подписи по имени сервиса (INTERNAL_SERVICE_KEYS)
+	Keys   map[string]string
+	MaxAge time.Duration // Допустимый возраст подписи, см. verifySignature
+	Replay ReplayCache
+
+	// CertHeader - заголовок, в который ingress кладет CN проверенного
+	// клиентского сертификата (mTLS). Пусто - mTLS не используется.
+	// Ingress обязан перезаписывать заголовок в каждом запросе, иначе
+	// клиент подставит его сам
+	CertHeader string
+
+	// CertServices - CN сертификатов, которым разрешен доступ
+	CertServices map[string]bool
+}
+
+// RequireService пропускает только запросы внутренних сервисов:
+// с клиентским сертификатом из CertServices или с подписью ключом
+// сервиса из заголовка X-Service (pkg/svcauth).
+// Имя сервиса доступно handlers через reqctx.Service
+//
+// Неизвестный сервис отклоняется так же, как неверная подпись:
+// клиент не узнает, какие имена сервисов настроены
+func RequireService(auth ServiceAuth) fiber.Handler {
+	unauthorized := func(c *fiber.Ctx, message, code string) error {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+			Error: message,
+			Code:  code,
+		})
+	}
+
+	return func(c *fiber.Ctx) error {
+		// 1. mTLS: сертификат уже проверен ingress
+		if auth.CertHeader != "" {
+			if name := c.Get(auth.CertHeader); name != "" {
+				if !auth.CertServices[name] {
+					return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{
+						Error: "Сервису не разрешен доступ к внутреннему API",
+						Code:  "SERVICE_FORBIDDEN",
+					})
+				}
+				reqctx.SetService(c, name)
+				return c.Next()
+			}
+		}
+
+		// 2. Подпись ключом сервиса
+		name := c.Get(svcauth.HeaderService)
+		if name == "" {
+			return unauthorized(c, "Запрос не подписан", "SIGNATURE_REQUIRED")
+		}
+		key, ok := auth.Keys[name]
+		if !ok {
+			return unauthorized(c, "Невалидная подпись запроса", "INVALID_SIGNATURE")
+		}
+		if message, code := verifySignature(c, []byte(key), auth.MaxAge, auth.Replay, name+":"); code != "" {
+			return unauthorized(c, message, code)
+		}
+
+		reqctx.SetService(c, name)
+		return c.Next()
+	}
+}
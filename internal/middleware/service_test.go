@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/pkg/svcauth"
+)
+
+const testServiceKey = "billing-key-0123456789abcdef0123456789"
+
+// newServiceApp собирает приложение с внутренним маршрутом,
+// который возвращает имя сервиса запроса
+func newServiceApp() *fiber.App {
+	app := fiber.New()
+	app.Post("/internal/v1/ping", RequireService(ServiceAuth{
+		Keys:         map[string]string{"billing": testServiceKey},
+		MaxAge:       time.Minute,
+		Replay:       NewMemoryReplayCache(),
+		CertHeader:   "X-Client-Cert-CN",
+		CertServices: map[string]bool{"search": true},
+	}), func(c *fiber.Ctx) error {
+		return c.SendString(reqctx.Service(c))
+	})
+	return app
+}
+
+// signedRequest возвращает запрос, подписанный pkg/svcauth
+func signedRequest(t *testing.T, service, key, body string) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, "/internal/v1/ping?x=1", strings.NewReader(body))
+	if err := svcauth.SignRequest(req, service, []byte(key)); err != nil {
+		t.Fatalf("SignRequest: %v", err)
+	}
+	return req
+}
+
+func TestRequireService(t *testing.T) {
+	app := newServiceApp()
+
+	replayed := signedRequest(t, "billing", testServiceKey, `{"a":1}`)
+	// Заголовки подписи от одного тела, тело - другое
+	tampered := httptest.NewRequest(fiber.MethodPost, "/internal/v1/ping?x=1", strings.NewReader(`{"a":2}`))
+	tampered.Header = signedRequest(t, "billing", testServiceKey, `{"a":1}`).Header
+	unsigned := httptest.NewRequest(fiber.MethodPost, "/internal/v1/ping", nil)
+	allowedCert := httptest.NewRequest(fiber.MethodPost, "/internal/v1/ping", nil)
+	allowedCert.Header.Set("X-Client-Cert-CN", "search")
+	unknownCert := httptest.NewRequest(fiber.MethodPost, "/internal/v1/ping", nil)
+	unknownCert.Header.Set("X-Client-Cert-CN", "billing")
+
+	tests := []struct {
+		name    string
+		req     *http.Request
+		status  int
+		code    string
+		service string
+	}{
+		{"подпись ключом сервиса", replayed, fiber.StatusOK, "", "billing"},
+		{"повтор подписанного запроса", replayed, fiber.StatusUnauthorized, "REPLAYED_REQUEST", ""},
+		{"подмена тела", tampered, fiber.StatusUnauthorized, "INVALID_SIGNATURE", ""},
+		{"неизвестный сервис", signedRequest(t, "search", testServiceKey, ""), fiber.StatusUnauthorized, "INVALID_SIGNATURE", ""},
+		{"чужой ключ", signedRequest(t, "billing", testServiceKey+"x", ""), fiber.StatusUnauthorized, "INVALID_SIGNATURE", ""},
+		{"без подписи", unsigned, fiber.StatusUnauthorized, "SIGNATURE_REQUIRED", ""},
+		{"разрешенный сертификат", allowedCert, fiber.StatusOK, "", "search"},
+		{"сертификат вне списка", unknownCert, fiber.StatusForbidden, "SERVICE_FORBIDDEN", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Тело подписанного запроса читается при каждой отправке
+			if tc.req.GetBody != nil {
+				tc.req.Body, _ = tc.req.GetBody()
+			}
+			resp, err := app.Test(tc.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tc.status {
+				t.Fatalf("статус %d, ожидался %d: %s", resp.StatusCode, tc.status, body)
+			}
+			if tc.code != "" {
+				var errResp models.ErrorResponse
+				if err := json.Unmarshal(body, &errResp); err != nil || errResp.Code != tc.code {
+					t.Errorf("код %q, ожидался %q", errResp.Code, tc.code)
+				}
+			}
+			if tc.service != "" && string(body) != tc.service {
+				t.Errorf("сервис %q, ожидался %q", body, tc.service)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/pkg/svcauth"
+)
+
+// Заголовки подписанных межсервисных запросов
+// Определены в pkg/svcauth, которым подписывают запросы клиенты
+const (
+	HeaderSignatureTimestamp = svcauth.HeaderTimestamp
+	HeaderSignatureNonce     = svcauth.HeaderNonce
+	HeaderSignature          = svcauth.HeaderSignature
+)
+
+// maxNonceLength ограничивает размер nonce, который хранится в кеше
+const maxNonceLength = 128
+
+// ReplayCache запоминает использованные nonce
+// Для нескольких инстансов сервиса нужна общая реализация (например, Redis),
+// иначе перехваченный запрос можно повторить на соседний инстанс
+type ReplayCache interface {
+	// Remember сохраняет nonce на ttl и возвращает false, если nonce уже был
+	Remember(nonce string, ttl time.Duration) bool
+}
+
+// MemoryReplayCache - ReplayCache в памяти процесса
+type MemoryReplayCache struct {
+	mu        sync.Mutex
+	nonces    map[string]time.Time // nonce -> момент, после которого его можно забыть
+	lastPrune time.Time
+}
+
+// NewMemoryReplayCache создает кеш nonce в памяти
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{
+		nonces: make(map[string]time.Time),
+	}
+}
+
+// Remember реализует ReplayCache
+func (m *MemoryReplayCache) Remember(nonce string, ttl time.Duration) bool {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Просроченные nonce удаляются не чаще раза в ttl,
+	// поэтому размер кеша ограничен числом запросов за два окна
+	if now.Sub(m.lastPrune) >= ttl {
+		for n, expiresAt := range m.nonces {
+			if now.After(expiresAt) {
+				delete(m.nonces, n)
+			}
+		}
+		m.lastPrune = now
+	}
+
+	if expiresAt, ok := m.nonces[nonce]; ok && now.Before(expiresAt) {
+		return false
+	}
+	m.nonces[nonce] = now.Add(ttl)
+	return true
+}
+
+// SignRequest вычисляет подпись запроса (см. svcauth.Sign)
+// Клиенты (внутренние сервисы) подписывают запросы пакетом pkg/svcauth
+func SignRequest(key []byte, method, uri, timestamp, nonce string, body []byte) string {
+	return svcauth.Sign(key, method, uri, timestamp, nonce, body)
+}
+
+// SignedRequest проверяет HMAC подпись межсервисных запросов общим ключом
+// и защищает от повторной отправки перехваченного запроса (см. verifySignature)
+func SignedRequest(key string, maxAge time.Duration, cache ReplayCache) fiber.Handler {
+	secret := []byte(key)
+
+	return func(c *fiber.Ctx) error {
+		if message, code := verifySignature(c, secret, maxAge, cache, ""); code != "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error: message,
+				Code:  code,
+			})
+		}
+		return c.Next()
+	}
+}
+
+// verifySignature проверяет подпись запроса ключом key
+// Возвращает текст и код ошибки, пустой код - подпись верна.
+// noncePrefix разделяет nonce разных ключей в общем кеше
+//
+// Проверки по порядку:
+//  1. timestamp отличается от времени сервера не больше чем на maxAge -
+//     старый запрос нельзя повторить после того, как его nonce забыт
+//  2. подпись совпадает - иначе чужой запрос не сможет "сжечь" nonce
+//  3. nonce не встречался в течение окна - запрос нельзя повторить внутри окна
+//
+// Ошибка не уточняет для клиента, что именно подделано, кроме просрочки
+// и повтора, которые полезны для отладки честных клиентов.
+func verifySignature(c *fiber.Ctx, key []byte, maxAge time.Duration, cache ReplayCache, noncePrefix string) (string, string) {
+	timestamp := c.Get(HeaderSignatureTimestamp)
+	nonce := c.Get(HeaderSignatureNonce)
+	signature := c.Get(HeaderSignature)
+
+	if timestamp == "" || nonce == "" || signature == "" || len(nonce) > maxNonceLength {
+		return "Запрос не подписан", "SIGNATURE_REQUIRED"
+	}
+
+	// 1. Проверяем свежесть подписи
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "Невалидная подпись запроса", "INVALID_SIGNATURE"
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > maxAge || age < -maxAge {
+		return "Подпись запроса устарела", "SIGNATURE_EXPIRED"
+	}
+
+	// 2. Проверяем подпись
+	expected := SignRequest(key, c.Method(), c.OriginalURL(), timestamp, nonce, c.Body())
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "Невалидная подпись запроса", "INVALID_SIGNATURE"
+	}
+
+	// 3. Проверяем повтор. Окно удваивается: timestamp может быть
+	// и в прошлом, и в будущем на maxAge
+	if !cache.Remember(noncePrefix+nonce, 2*maxAge) {
+		return "Повторный запрос отклонен", "REPLAYED_REQUEST"
+	}
+	return "", ""
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+)
+
+// ttlCache запоминает срок, на который middleware сохраняет nonce
+type ttlCache struct {
+	ttl time.Duration
+}
+
+func (c *ttlCache) Remember(_ string, ttl time.Duration) bool {
+	c.ttl = ttl
+	return true
+}
+
+func TestSignedRequest(t *testing.T) {
+	const key = "test-key"
+	const maxAge = time.Minute
+	app := fiber.New()
+	app.Post("/internal", SignedRequest(key, maxAge, NewMemoryReplayCache()), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	body := `{"id": 1}`
+	now := time.Now()
+	// Случаи выполняются по порядку: повтор использует nonce первого запроса
+	tests := []struct {
+		name     string
+		signedAt time.Time
+		nonce    string
+		key      string
+		body     string // Тело, отправленное после подписи; пустое - подписанное
+		unsigned bool
+		wantCode string // Пустой - запрос пропущен
+	}{
+		{name: "верная подпись", signedAt: now, nonce: "n1", key: key},
+		{name: "повтор nonce", signedAt: now, nonce: "n1", key: key, wantCode: "REPLAYED_REQUEST"},
+		{name: "без подписи", unsigned: true, wantCode: "SIGNATURE_REQUIRED"},
+		{name: "устаревший timestamp", signedAt: now.Add(-maxAge - time.Second), nonce: "n2", key: key, wantCode: "SIGNATURE_EXPIRED"},
+		{name: "timestamp из будущего", signedAt: now.Add(maxAge + time.Second), nonce: "n3", key: key, wantCode: "SIGNATURE_EXPIRED"},
+		{name: "на границе окна", signedAt: now.Add(-maxAge + 5*time.Second), nonce: "n4", key: key},
+		{name: "чужой ключ", signedAt: now, nonce: "n5", key: "other-key", wantCode: "INVALID_SIGNATURE"},
+		{name: "подмененное тело", signedAt: now, nonce: "n6", key: key, body: `{"id": 2}`, wantCode: "INVALID_SIGNATURE"},
+		// Неудачная подпись не "сжигает" nonce: честный запрос с ним проходит
+		{name: "nonce после неверной подписи", signedAt: now, nonce: "n5", key: key},
+	}
+	for _, tc := range tests {
+		sent := body
+		if tc.body != "" {
+			sent = tc.body
+		}
+		req := httptest.NewRequest(fiber.MethodPost, "/internal", strings.NewReader(sent))
+		if !tc.unsigned {
+			timestamp := strconv.FormatInt(tc.signedAt.Unix(), 10)
+			req.Header.Set(HeaderSignatureTimestamp, timestamp)
+			req.Header.Set(HeaderSignatureNonce, tc.nonce)
+			req.Header.Set(HeaderSignature, SignRequest([]byte(tc.key), fiber.MethodPost, "/internal", timestamp, tc.nonce, []byte(body)))
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if tc.wantCode == "" {
+			if resp.StatusCode != fiber.StatusNoContent {
+				t.Errorf("%s: статус %d, ожидался 204", tc.name, resp.StatusCode)
+			}
+			resp.Body.Close()
+			continue
+		}
+		var errResp models.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusUnauthorized || errResp.Code != tc.wantCode {
+			t.Errorf("%s: %d %s, ожидался 401 %s", tc.name, resp.StatusCode, errResp.Code, tc.wantCode)
+		}
+	}
+}
+
+func TestSignedRequestRemembersNonceForTwoWindows(t *testing.T) {
+	cache := &ttlCache{}
+	app := fiber.New()
+	app.Get("/", SignedRequest("key", time.Minute, cache), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(HeaderSignatureTimestamp, timestamp)
+	req.Header.Set(HeaderSignatureNonce, "n")
+	req.Header.Set(HeaderSignature, SignRequest([]byte("key"), fiber.MethodGet, "/", timestamp, "n", nil))
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+
+	// timestamp допустим на maxAge в обе стороны, поэтому nonce
+	// должен помниться два окна
+	if cache.ttl != 2*time.Minute {
+		t.Errorf("nonce сохранен на %v, ожидалось 2m", cache.ttl)
+	}
+}
+
+func TestMemoryReplayCacheForgetsExpiredNonces(t *testing.T) {
+	cache := NewMemoryReplayCache()
+	const ttl = 30 * time.Millisecond
+
+	if !cache.Remember("a", ttl) {
+		t.Fatal("первый nonce отклонен")
+	}
+	if cache.Remember("a", ttl) {
+		t.Error("повтор внутри окна принят")
+	}
+	if !cache.Remember("b", ttl) {
+		t.Error("другой nonce отклонен")
+	}
+
+	// После ttl nonce забыт и удаляется из кеша при очистке
+	time.Sleep(ttl + 10*time.Millisecond)
+	if !cache.Remember("a", ttl) {
+		t.Error("nonce после окна отклонен")
+	}
+	cache.mu.Lock()
+	_, kept := cache.nonces["b"]
+	cache.mu.Unlock()
+	if kept {
+		t.Error("просроченный nonce остался в кеше")
+	}
+}
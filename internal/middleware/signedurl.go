@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/signedurl"
+)
+
+// SignedURL пропускает запрос только по действующей подписанной ссылке
+// (см. signedurl.Signer.Sign). Проверка выполняется до handler и без
+// обращения к БД, поэтому перебор ссылок не нагружает базу.
+//
+// Невалидная или просроченная ссылка - 403 INVALID_DOWNLOAD_LINK.
+func SignedURL(signer *signedurl.Signer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := signer.Verify(c.Path(), c.Query(signedurl.ParamExpires), c.Query(signedurl.ParamSignature))
+		if err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "INVALID_DOWNLOAD_LINK",
+			})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/query"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/tiers"
+)
+
+// ResolveTier определяет уровень лимитов вызывающего (см. пакет tiers)
+// один раз на запрос: TierRateLimit и ListQuota берут пороги этого уровня.
+// Подключается после IdentifyUser, чтобы пользователь с токеном был уже известен
+func ResolveTier(policy tiers.Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := reqctx.GetUser(c)
+		tiers.Set(c, policy.Resolve(user.Role, ok))
+		return c.Next()
+	}
+}
+
+// TierRateLimit ограничивает частоту запросов лимитом уровня вызывающего
+// У каждого уровня свой RateLimiter (свои счетчики и имя в метриках):
+// анонимные клиенты считаются по IP, пользователи - по пользователю.
+// Уровень без лимитера не ограничен
+func TierRateLimit(limiters map[tiers.Tier]*RateLimiter) fiber.Handler {
+	handlers := make(map[tiers.Tier]fiber.Handler, len(limiters))
+	for t, limiter := range limiters {
+		handlers[t] = limiter.Handler()
+	}
+	return func(c *fiber.Ctx) error {
+		if handler, ok := handlers[tiers.Of(c)]; ok {
+			return handler(c)
+		}
+		return c.Next()
+	}
+}
+
+// CallerKey определяет клиента для лимита уровня: пользователя из access
+// токена или, для анонимного запроса, IP адрес
+func CallerKey(c *fiber.Ctx) string {
+	if user, ok := reqctx.GetUser(c); ok {
+		return strconv.Itoa(user.ID)
+	}
+	return c.IP()
+}
+
+// ListQuota задает ограничения списков (размер страницы, фильтры)
+// по уровню вызывающего (ResolveTier).
+//
+// Сами ограничения применяют функции пакета query (ParsePage, Filter,
+// ParseSort), поэтому handlers списков их не проверяют
+func ListQuota(policy tiers.Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if limits, ok := policy.Limits[tiers.Of(c)]; ok {
+			query.SetQuota(c, query.Quota{MaxPageSize: limits.MaxPageSize, Filters: limits.ListFilters})
+		}
+		return c.Next()
+	}
+}
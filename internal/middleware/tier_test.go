@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/query"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/tiers"
+)
+
+// testPolicy - уровни лимитов тестов: у администратора (internal) только
+// ограничения самого списка
+var testPolicy = tiers.Policy{
+	Roles: map[string]tiers.Tier{auth.RoleAdmin: tiers.Internal},
+	Limits: map[tiers.Tier]tiers.Limits{
+		tiers.Anonymous: {RateLimit: 2, MaxPageSize: 20},
+		tiers.Free:      {RateLimit: 3, MaxPageSize: 100, ListFilters: true},
+		tiers.Internal:  {ListFilters: true},
+	},
+}
+
+// newListQuotaApp собирает приложение со списком поверх ListQuota
+// Заголовок X-Test-Role имитирует пользователя, опознанного IdentifyUser
+func newListQuotaApp() *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if role := c.Get("X-Test-Role"); role != "" {
+			reqctx.SetUser(c, reqctx.User{ID: 1, Role: role})
+		}
+		return c.Next()
+	})
+	app.Use(ResolveTier(testPolicy))
+	app.Use(ListQuota(testPolicy))
+	app.Get("/list", func(c *fiber.Ctx) error {
+		page, err := query.ParsePage(c, query.PageOptions{DefaultSize: 50, MaxSize: 500})
+		if err != nil {
+			return err
+		}
+		if _, err := query.Filter(c, "is_active", query.ParseBool); err != nil {
+			return c.Status(fiber.StatusForbidden).SendString(err.Error())
+		}
+		return c.JSON(fiber.Map{"page_size": page.Size})
+	})
+	return app
+}
+
+func TestListQuotaLimitsPageSizeByTier(t *testing.T) {
+	app := newListQuotaApp()
+
+	tests := []struct {
+		name string
+		role string
+		url  string
+		want int
+	}{
+		{"аноним, размер по умолчанию", "", "/list", 20},
+		{"аноним, большая страница", "", "/list?page_size=200", 20},
+		{"аноним, малая страница", "", "/list?page_size=5", 5},
+		{"пользователь", auth.RoleUser, "/list?page_size=200", 100},
+		{"пользователь, размер по умолчанию", auth.RoleUser, "/list", 50},
+		{"администратор", auth.RoleAdmin, "/list?page_size=200", 200},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tc.url, nil)
+			if tc.role != "" {
+				req.Header.Set("X-Test-Role", tc.role)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var body struct {
+				PageSize int `json:"page_size"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.PageSize != tc.want {
+				t.Errorf("page_size = %d, want %d", body.PageSize, tc.want)
+			}
+		})
+	}
+}
+
+func TestListQuotaRejectsFiltersForAnonymous(t *testing.T) {
+	app := newListQuotaApp()
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/list?is_active=true", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusForbidden {
+		t.Errorf("аноним: status = %d, want %d", resp.StatusCode, fiber.StatusForbidden)
+	}
+
+	req := httptest.NewRequest(fiber.MethodGet, "/list?is_active=true", nil)
+	req.Header.Set("X-Test-Role", auth.RoleUser)
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("пользователь: status = %d, want %d", resp.StatusCode, fiber.StatusOK)
+	}
+}
+
+func TestTierRateLimitCountsByTier(t *testing.T) {
+	limiters := make(map[tiers.Tier]*RateLimiter)
+	for tier, limits := range testPolicy.Limits {
+		limiters[tier] = NewRateLimiter(RateLimitConfig{
+			Name: string(tier), Limit: limits.RateLimit, Window: time.Minute,
+			Store: NewMemoryRateLimitStore(), KeyFunc: CallerKey,
+		})
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		return c.SendStatus(fiber.StatusTooManyRequests)
+	}})
+	app.Use(func(c *fiber.Ctx) error {
+		if role := c.Get("X-Test-Role"); role != "" {
+			reqctx.SetUser(c, reqctx.User{ID: 1, Role: role})
+		}
+		return c.Next()
+	})
+	app.Use(ResolveTier(testPolicy))
+	app.Use(TierRateLimit(limiters))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	// Сколько запросов подряд проходит до 429
+	allowed := func(role string) int {
+		for n := 0; n < 10; n++ {
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			if role != "" {
+				req.Header.Set("X-Test-Role", role)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode == fiber.StatusTooManyRequests {
+				return n
+			}
+		}
+		return 10
+	}
+
+	// Лимит уровня, а не общий: исчерпанный анонимный лимит
+	// не расходует лимит пользователя с того же IP
+	if got := allowed(""); got != 2 {
+		t.Errorf("anonymous: пропущено %d запросов, ожидалось 2", got)
+	}
+	if got := allowed(auth.RoleUser); got != 3 {
+		t.Errorf("free: пропущено %d запросов, ожидалось 3", got)
+	}
+	// У internal лимит запросов 0 - без ограничения
+	if got := allowed(auth.RoleAdmin); got != 10 {
+		t.Errorf("internal: пропущено %d запросов, ожидалось 10", got)
+	}
+}
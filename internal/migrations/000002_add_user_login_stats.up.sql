@@ -0,0 +1,16 @@
+-- Статистика входов пользователя
+-- Обновляется при каждой успешной аутентификации
+
+-- last_login_at - время последнего успешного входа
+-- NULL означает что пользователь ни разу не входил
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP;
+
+-- login_count - сколько раз пользователь успешно входил
+-- NOT NULL DEFAULT 0 заполнит значение для уже существующих строк
+ALTER TABLE users ADD COLUMN IF NOT EXISTS login_count INTEGER NOT NULL DEFAULT 0;
+
+-- Индекс для фильтра "неактивен с" (last_login_at < дата)
+CREATE INDEX IF NOT EXISTS idx_users_last_login_at ON users(last_login_at);
+
+COMMENT ON COLUMN users.last_login_at IS 'Дата и время последнего успешного входа';
+COMMENT ON COLUMN users.login_count IS 'Количество успешных входов';
@@ -0,0 +1,5 @@
+-- Откат таблицы заданий экспорта
+
+DROP INDEX IF EXISTS idx_export_jobs_pending;
+
+DROP TABLE IF EXISTS export_jobs CASCADE;
@@ -0,0 +1,32 @@
+-- Задания на асинхронный экспорт данных
+-- Клиент создает задание, воркер формирует файл в хранилище,
+-- после чего файл можно скачать по временной ссылке
+
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id SERIAL PRIMARY KEY,
+
+    -- Что экспортируем (пока только users) и в каком формате (csv, ndjson)
+    resource VARCHAR(50) NOT NULL,
+    format VARCHAR(20) NOT NULL,
+
+    -- Статус задания: pending -> running -> completed | failed
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+
+    -- Ключ готового файла в хранилище (BlobStore)
+    storage_key VARCHAR(255),
+
+    -- Количество выгруженных записей и текст ошибки при неудаче
+    row_count INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+-- Воркер выбирает самое старое задание в статусе pending
+CREATE INDEX IF NOT EXISTS idx_export_jobs_pending ON export_jobs(created_at) WHERE status = 'pending';
+
+COMMENT ON TABLE export_jobs IS 'Задания на асинхронный экспорт данных';
+COMMENT ON COLUMN export_jobs.status IS 'pending, running, completed, failed';
+COMMENT ON COLUMN export_jobs.storage_key IS 'Ключ файла в хранилище';
@@ -0,0 +1,13 @@
+-- Откат мягкого удаления пользователей
+-- ВНИМАНИЕ: мягко удаленные пользователи удаляются физически,
+-- иначе восстановить UNIQUE ограничения при повторных email невозможно
+
+DELETE FROM users WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_users_username_not_deleted;
+DROP INDEX IF EXISTS idx_users_email_not_deleted;
+
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+ALTER TABLE users ADD CONSTRAINT users_username_key UNIQUE (username);
+
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
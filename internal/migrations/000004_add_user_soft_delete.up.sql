@@ -0,0 +1,19 @@
+-- Мягкое удаление пользователей
+-- Удаленный пользователь остается в таблице (для аудита и истории),
+-- но его email и username можно снова использовать при регистрации
+
+-- deleted_at - время удаления, NULL у живых пользователей
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Обычные UNIQUE ограничения мешают повторной регистрации с email удаленного
+-- пользователя, поэтому заменяем их частичными уникальными индексами,
+-- которые учитывают только не удаленные строки
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_username_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_not_deleted
+    ON users(email) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_not_deleted
+    ON users(username) WHERE deleted_at IS NULL;
+
+COMMENT ON COLUMN users.deleted_at IS 'Дата и время мягкого удаления (NULL - пользователь не удален)';
@@ -0,0 +1,5 @@
+-- Откат таблицы объявлений
+
+DROP INDEX IF EXISTS idx_announcements_window;
+
+DROP TABLE IF EXISTS announcements CASCADE;
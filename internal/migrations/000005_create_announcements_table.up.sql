@@ -0,0 +1,31 @@
+-- Объявления администраторов (плановые работы, важные уведомления)
+-- Клиенты запрашивают активные объявления и показывают их пользователям
+
+CREATE TABLE IF NOT EXISTS announcements (
+    id SERIAL PRIMARY KEY,
+
+    title VARCHAR(200) NOT NULL,
+    body TEXT NOT NULL,
+
+    -- Важность для оформления в клиенте: info, warning, critical
+    severity VARCHAR(20) NOT NULL DEFAULT 'info',
+
+    -- Аудитория: all или конкретная платформа клиента (web, ios, android)
+    audience VARCHAR(20) NOT NULL DEFAULT 'all',
+
+    -- Окно показа: объявление активно с starts_at до ends_at
+    -- ends_at = NULL означает "до отмены"
+    starts_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ends_at TIMESTAMP,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT announcements_window_check CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+-- Выборка активных объявлений идет по окну показа
+CREATE INDEX IF NOT EXISTS idx_announcements_window ON announcements(starts_at, ends_at);
+
+COMMENT ON TABLE announcements IS 'Объявления администраторов для клиентов';
+COMMENT ON COLUMN announcements.severity IS 'info, warning, critical';
+COMMENT ON COLUMN announcements.audience IS 'all, web, ios, android';
@@ -0,0 +1,3 @@
+-- Откат таблицы привязанных учетных записей
+
+DROP TABLE IF EXISTS user_identities CASCADE;
@@ -0,0 +1,23 @@
+-- Внешние (OAuth) учетные записи, привязанные к пользователю
+-- Пользователь может входить паролем и любым из привязанных провайдеров
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- Провайдер (google, github, apple) и ID пользователя у провайдера
+    provider VARCHAR(50) NOT NULL,
+    provider_user_id VARCHAR(255) NOT NULL,
+
+    -- Email у провайдера, только для отображения в списке привязок
+    email VARCHAR(255),
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Внешний аккаунт привязан не больше чем к одному пользователю
+    CONSTRAINT user_identities_provider_key UNIQUE (provider, provider_user_id),
+    -- У пользователя не больше одной привязки на провайдера
+    CONSTRAINT user_identities_user_provider_key UNIQUE (user_id, provider)
+);
+
+COMMENT ON TABLE user_identities IS 'Привязанные OAuth учетные записи пользователей';
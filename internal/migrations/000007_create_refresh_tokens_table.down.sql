@@ -0,0 +1,5 @@
+-- Откат таблицы refresh токенов
+
+DROP INDEX IF EXISTS idx_refresh_tokens_user_id;
+
+DROP TABLE IF EXISTS refresh_tokens CASCADE;
@@ -0,0 +1,22 @@
+-- Выданные refresh токены
+-- Хранятся, чтобы токен можно было отозвать (logout, ротация, компрометация)
+-- В БД лежит только SHA-256 от идентификатора токена (jti), не сам токен
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+
+    expires_at TIMESTAMP NOT NULL,
+    -- Момент отзыва, NULL для действующего токена
+    revoked_at TIMESTAMP,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Отзыв всех токенов пользователя
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+
+COMMENT ON TABLE refresh_tokens IS 'Выданные refresh токены для отзыва';
+COMMENT ON COLUMN refresh_tokens.token_hash IS 'SHA-256 от jti токена в hex';
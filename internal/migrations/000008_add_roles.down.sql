@@ -0,0 +1,5 @@
+-- Откат ролей пользователей
+
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+
+DROP TABLE IF EXISTS roles CASCADE;
@@ -0,0 +1,21 @@
+-- Роли пользователей (RBAC)
+-- Справочник ролей хранится в БД, роль пользователя ссылается на него,
+-- поэтому назначить несуществующую роль невозможно
+
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('user', 'Обычный пользователь'),
+    ('admin', 'Администратор: управление пользователями и ролями')
+ON CONFLICT (name) DO NOTHING;
+
+-- Все существующие пользователи получают роль user
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role VARCHAR(50) NOT NULL DEFAULT 'user' REFERENCES roles(name);
+
+COMMENT ON TABLE roles IS 'Справочник ролей пользователей';
+COMMENT ON COLUMN users.role IS 'Роль пользователя, ссылка на roles.name';
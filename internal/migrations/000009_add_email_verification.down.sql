@@ -0,0 +1,7 @@
+-- Откат подтверждения email и сброса пароля
+
+DROP INDEX IF EXISTS idx_user_tokens_user_purpose;
+
+DROP TABLE IF EXISTS user_tokens CASCADE;
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,28 @@
+-- Подтверждение email и сброс пароля
+-- Одноразовые токены из писем хранятся в user_tokens: в БД лежит только
+-- SHA-256 от идентификатора токена (jti), не сам токен
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- Назначение токена: verify_email или password_reset
+    purpose VARCHAR(32) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+
+    expires_at TIMESTAMP NOT NULL,
+    -- Момент использования, NULL для неиспользованного токена
+    used_at TIMESTAMP,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Аннулирование действующих токенов пользователя при выдаче нового
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_purpose ON user_tokens(user_id, purpose);
+
+COMMENT ON COLUMN users.email_verified_at IS 'Момент подтверждения email, NULL если не подтвержден';
+COMMENT ON TABLE user_tokens IS 'Одноразовые токены подтверждения email и сброса пароля';
+COMMENT ON COLUMN user_tokens.token_hash IS 'SHA-256 от jti токена в hex';
@@ -0,0 +1,5 @@
+-- Откат подписок на сводку активности
+
+DROP INDEX IF EXISTS idx_digest_subscriptions_last_sent_at;
+
+DROP TABLE IF EXISTS digest_subscriptions CASCADE;
@@ -0,0 +1,18 @@
+-- Еженедельная сводка активности аккаунта на email
+-- Сводка отправляется только пользователям, которые на нее подписались
+
+CREATE TABLE IF NOT EXISTS digest_subscriptions (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+
+    -- Момент последней отправки, NULL если сводка еще не отправлялась
+    -- Следующая сводка охватывает период с этого момента
+    last_sent_at TIMESTAMP,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Поиск подписок, по которым пора отправить сводку
+CREATE INDEX IF NOT EXISTS idx_digest_subscriptions_last_sent_at ON digest_subscriptions(last_sent_at);
+
+COMMENT ON TABLE digest_subscriptions IS 'Подписки пользователей на еженедельную сводку активности';
+COMMENT ON COLUMN digest_subscriptions.last_sent_at IS 'Момент последней отправки сводки';
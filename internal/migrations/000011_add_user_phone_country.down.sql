@@ -0,0 +1,9 @@
+-- Откат телефона и страны в профиле
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_country_check,
+    DROP CONSTRAINT IF EXISTS users_phone_e164_check;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS country,
+    DROP COLUMN IF EXISTS phone;
@@ -0,0 +1,15 @@
+-- Телефон и страна в профиле пользователя
+-- Телефон хранится в формате E.164 (+79123456789), страна - кодом
+-- ISO 3166-1 alpha-2 (RU). Нормализация выполняется приложением (см. пакет phone)
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS phone VARCHAR(16),
+    ADD COLUMN IF NOT EXISTS country CHAR(2);
+
+-- Защита от записи в обход приложения: только канонический формат
+ALTER TABLE users
+    ADD CONSTRAINT users_phone_e164_check CHECK (phone ~ '^\+[1-9][0-9]{1,14}$'),
+    ADD CONSTRAINT users_country_check CHECK (country ~ '^[A-Z]{2}$');
+
+COMMENT ON COLUMN users.phone IS 'Телефон в формате E.164';
+COMMENT ON COLUMN users.country IS 'Страна, код ISO 3166-1 alpha-2';
@@ -0,0 +1,5 @@
+-- Откат массовых рассылок
+
+DROP INDEX IF EXISTS idx_broadcasts_active;
+
+DROP TABLE IF EXISTS broadcasts CASCADE;
@@ -0,0 +1,43 @@
+-- Массовые рассылки администраторов по сегменту пользователей
+-- Рассылка выполняется фоновым воркером пачками по возрастанию id
+-- пользователя. После каждой пачки сохраняется курсор (last_user_id) и
+-- счетчики, поэтому прерванная рассылка продолжается с места остановки,
+-- а не отправляется повторно с начала
+
+CREATE TABLE IF NOT EXISTS broadcasts (
+    id SERIAL PRIMARY KEY,
+
+    subject VARCHAR(200) NOT NULL,
+    body TEXT NOT NULL,
+
+    -- Фильтры сегмента (те же, что у списка пользователей), NULL - без фильтра
+    inactive_since TIMESTAMP,
+
+    -- Статус: pending -> running -> completed | failed | cancelled
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+
+    -- Прогресс: размер сегмента на момент создания, отправлено, не доставлено
+    total_count INTEGER NOT NULL DEFAULT 0,
+    sent_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+
+    -- Курсор: id последнего обработанного пользователя
+    last_user_id INTEGER NOT NULL DEFAULT 0,
+
+    error TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    -- Обновляется после каждой пачки: по нему воркер находит рассылки,
+    -- брошенные остановленным инстансом
+    heartbeat_at TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+-- Воркер выбирает ожидающие и брошенные рассылки
+CREATE INDEX IF NOT EXISTS idx_broadcasts_active ON broadcasts(created_at) WHERE status IN ('pending', 'running');
+
+COMMENT ON TABLE broadcasts IS 'Массовые рассылки администраторов';
+COMMENT ON COLUMN broadcasts.status IS 'pending, running, completed, failed, cancelled';
+COMMENT ON COLUMN broadcasts.last_user_id IS 'Курсор: id последнего обработанного пользователя';
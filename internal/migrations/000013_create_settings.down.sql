@@ -0,0 +1,3 @@
+-- Откат переопределений настроек
+
+DROP TABLE IF EXISTS settings CASCADE;
@@ -0,0 +1,17 @@
+-- Переопределения настроек приложения во время работы
+-- Значение по умолчанию задается переменной окружения, строка в таблице
+-- его переопределяет. Ключи и типы значений описаны в коде (internal/settings),
+-- значение хранится строкой в каноническом виде ("50", "true", "30s")
+
+CREATE TABLE IF NOT EXISTS settings (
+    key VARCHAR(100) PRIMARY KEY,
+    value TEXT NOT NULL,
+
+    -- Администратор, изменивший настройку
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE settings IS 'Переопределения настроек приложения (значения по умолчанию - из переменных окружения)';
+COMMENT ON COLUMN settings.value IS 'Значение в каноническом виде для типа ключа';
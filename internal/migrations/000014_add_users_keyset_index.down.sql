@@ -0,0 +1,3 @@
+-- Откат индекса keyset пагинации пользователей
+
+DROP INDEX IF EXISTS idx_users_keyset;
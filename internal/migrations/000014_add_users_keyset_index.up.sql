@@ -0,0 +1,7 @@
+-- Индекс для постраничного обхода пользователей курсором (keyset пагинация)
+-- Совпадает с порядком ListUsersAfter: следующая страница читается
+-- по индексу с позиции курсора, без OFFSET и без сортировки
+
+CREATE INDEX IF NOT EXISTS idx_users_keyset
+    ON users(created_at DESC, id DESC)
+    WHERE deleted_at IS NULL;
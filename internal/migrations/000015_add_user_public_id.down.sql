@@ -0,0 +1,6 @@
+-- Откат публичного идентификатора пользователя
+
+DROP INDEX IF EXISTS idx_users_public_id;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS public_id;
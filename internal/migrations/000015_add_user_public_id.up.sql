@@ -0,0 +1,13 @@
+-- Публичный идентификатор пользователя для API
+-- Последовательный id раскрывает число регистраций и позволяет перебирать
+-- пользователей, поэтому в маршрутах и ответах API используется случайный
+-- UUID. id остается первичным ключом для внешних ключей и JOIN.
+-- DEFAULT заполняет public_id и для уже существующих строк
+-- (gen_random_uuid встроена в PostgreSQL 13+)
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS public_id UUID NOT NULL DEFAULT gen_random_uuid();
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_public_id ON users(public_id);
+
+COMMENT ON COLUMN users.public_id IS 'Идентификатор пользователя в API (UUID v4)';
@@ -0,0 +1,5 @@
+-- Откат аватара пользователя
+-- Файлы в хранилище не удаляются
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS avatar_key;
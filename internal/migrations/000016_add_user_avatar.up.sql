@@ -0,0 +1,9 @@
+-- Аватар пользователя
+-- Файл хранится в хранилище (internal/storage), в таблице - только ключ
+-- объекта. Ключ случайный и меняется при каждой загрузке, поэтому
+-- по нему строится версия в avatar_url для кеширования клиентами
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS avatar_key VARCHAR(255);
+
+COMMENT ON COLUMN users.avatar_key IS 'Ключ файла аватара в хранилище, NULL - аватар не загружен';
@@ -0,0 +1,3 @@
+-- Откат журнала аудита
+
+DROP TABLE IF EXISTS audit_logs;
@@ -0,0 +1,36 @@
+-- Журнал аудита: кто, когда и что изменил
+-- Записи только добавляются. Ссылки на пользователей намеренно без
+-- внешних ключей: запись об изменении должна пережить физическое
+-- удаление и слияние аккаунтов, которых она касается
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+
+    -- Кто: пользователь из access токена, NULL - система или аноним
+    actor_id INTEGER,
+    actor_role VARCHAR(50),
+
+    -- Что: действие (user.create, user.update, ...) и над кем
+    action VARCHAR(64) NOT NULL,
+    target_user_id INTEGER,
+
+    -- Изменения по полям: {"email": {"old": "...", "new": "..."}}
+    changes JSONB NOT NULL DEFAULT '{}',
+
+    -- Метаданные запроса
+    request_id VARCHAR(128),
+    ip VARCHAR(64),
+    user_agent TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Выборки журнала идут от новых записей к старым, с фильтром или без
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor ON audit_logs(actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON audit_logs(target_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs(action, created_at DESC);
+
+COMMENT ON TABLE audit_logs IS 'Журнал аудита изменяющих операций';
+COMMENT ON COLUMN audit_logs.actor_id IS 'users.id автора изменения, NULL - система';
+COMMENT ON COLUMN audit_logs.changes IS 'Изменения по полям: {"поле": {"old": ..., "new": ...}}';
@@ -0,0 +1,17 @@
+-- Откат жизненного цикла аккаунта к флагу is_active
+-- suspended и deactivated становятся is_active = FALSE
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS is_active BOOLEAN DEFAULT TRUE;
+
+UPDATE users SET is_active = status IN ('pending_verification', 'active');
+
+COMMENT ON COLUMN users.is_active IS 'Флаг активности пользователя';
+
+DROP INDEX IF EXISTS idx_users_status;
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_status_check;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,25 @@
+-- Жизненный цикл аккаунта вместо флага is_active
+-- Допустимые переходы между состояниями проверяет сервисный слой
+-- (internal/lifecycle), CHECK только не пускает неизвестные значения
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS status VARCHAR(32) NOT NULL DEFAULT 'active';
+
+-- Существующие аккаунты: удаленные, деактивированные, остальные активны
+-- (неподтвержденный email раньше не ограничивал вход и не ограничивает сейчас)
+UPDATE users SET status = CASE
+    WHEN deleted_at IS NOT NULL THEN 'deleted'
+    WHEN is_active = FALSE THEN 'deactivated'
+    ELSE 'active'
+END;
+
+ALTER TABLE users
+    ADD CONSTRAINT users_status_check
+    CHECK (status IN ('pending_verification', 'active', 'suspended', 'deactivated', 'deleted'));
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS is_active;
+
+CREATE INDEX IF NOT EXISTS idx_users_status ON users(status) WHERE deleted_at IS NULL;
+
+COMMENT ON COLUMN users.status IS 'Состояние аккаунта: pending_verification, active, suspended, deactivated, deleted';
@@ -0,0 +1,3 @@
+-- Откат очереди фоновых заданий
+
+DROP TABLE IF EXISTS jobs;
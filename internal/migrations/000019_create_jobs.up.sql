@@ -0,0 +1,40 @@
+-- Очередь фоновых заданий (internal/jobs)
+-- Задание ставится в очередь в статусе pending, воркер захватывает его
+-- через FOR UPDATE SKIP LOCKED, поэтому очередь разбирают все инстансы
+-- параллельно. Неудачные попытки повторяются с экспоненциальной задержкой
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+
+    -- Тип задания (send_email, purge_refresh_tokens, ...) и его параметры
+    kind VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+
+    -- Статус: pending -> running -> completed | failed
+    -- После неудачной попытки задание возвращается в pending с новым run_at
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    last_error TEXT,
+
+    -- Ключ для заданий по расписанию: одно задание на период для всех инстансов
+    unique_key VARCHAR(255),
+
+    -- Не раньше какого времени выполнять (отложенный повтор)
+    run_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Когда воркер захватил задание: зависшие в running после падения
+    -- инстанса возвращаются в очередь по этому времени
+    locked_at TIMESTAMP,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+-- Воркер выбирает самое раннее готовое задание в статусе pending
+CREATE INDEX IF NOT EXISTS idx_jobs_pending ON jobs(run_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_jobs_running ON jobs(locked_at) WHERE status = 'running';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_unique_key ON jobs(unique_key) WHERE unique_key IS NOT NULL;
+
+COMMENT ON TABLE jobs IS 'Очередь фоновых заданий';
+COMMENT ON COLUMN jobs.status IS 'pending, running, completed, failed';
+COMMENT ON COLUMN jobs.unique_key IS 'Ключ периода для заданий по расписанию';
@@ -0,0 +1,45 @@
+-- Подписки внешних систем на события пользователей (internal/webhooks)
+-- и журнал доставки событий подписчикам
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+
+    -- Куда отправлять события (POST) и на какие события подписка
+    url TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+
+    -- Ключ HMAC подписи тела запроса (X-Webhook-Signature)
+    -- Показывается администратору один раз при создании подписки
+    secret VARCHAR(64) NOT NULL,
+
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+
+    -- Удаление подписки удаляет и ее недоставленные события
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+
+    -- Событие (user.created, ...) и тело запроса к подписчику
+    event VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+
+    -- Статус: pending -> delivered | failed
+    -- Повторы с задержкой выполняет очередь заданий (jobs)
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER, -- HTTP статус последнего ответа подписчика
+    last_error TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP
+);
+
+-- Журнал доставки подписки от новых событий к старым
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id DESC);
+
+COMMENT ON TABLE webhooks IS 'Подписки на события пользователей';
+COMMENT ON TABLE webhook_deliveries IS 'Доставка событий подписчикам';
+COMMENT ON COLUMN webhook_deliveries.status IS 'pending, delivered, failed';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS trusted_clients;
+DROP TABLE IF EXISTS cors_origins;
@@ -0,0 +1,33 @@
+-- Источники (Origin), которым разрешены кросс-доменные запросы из браузера,
+-- и собственные клиенты (фронтенды) со своими источниками (internal/origins)
+
+CREATE TABLE IF NOT EXISTS cors_origins (
+    id SERIAL PRIMARY KEY,
+
+    -- Origin в нормализованном виде: https://app.example.com[:port]
+    -- Поддомены разрешаются шаблоном https://*.example.com
+    origin VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT,
+
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS trusted_clients (
+    id SERIAL PRIMARY KEY,
+
+    -- Имя клиента для админки и логов: web, admin-panel, ...
+    name VARCHAR(64) NOT NULL UNIQUE,
+    description TEXT,
+
+    -- Источники клиента (production, staging, превью) в том же виде,
+    -- что и cors_origins.origin
+    origins TEXT[] NOT NULL,
+
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE cors_origins IS 'Разрешенные источники кросс-доменных запросов';
+COMMENT ON TABLE trusted_clients IS 'Собственные клиенты (фронтенды) и их источники';
@@ -0,0 +1,5 @@
+-- Откат настроек приватности публичного профиля
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS show_avatar,
+    DROP COLUMN IF EXISTS show_name;
@@ -0,0 +1,10 @@
+-- Настройки приватности публичного профиля (GET /api/v1/profiles/:username)
+-- Имя - персональные данные, поэтому по умолчанию скрыто; аватар и раньше
+-- отдавался без аутентификации, поэтому по умолчанию показывается
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS show_name BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS show_avatar BOOLEAN NOT NULL DEFAULT TRUE;
+
+COMMENT ON COLUMN users.show_name IS 'Показывать имя и фамилию в публичном профиле';
+COMMENT ON COLUMN users.show_avatar IS 'Показывать аватар в публичном профиле';
@@ -0,0 +1,30 @@
+-- Двухфакторная аутентификация по одноразовым кодам (TOTP, internal/totp)
+
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+
+    -- Секрет, зашифрованный ключом TWO_FACTOR_ENCRYPTION_KEY (AES-256-GCM)
+    secret_encrypted BYTEA NOT NULL,
+
+    -- Момент подтверждения первым кодом, NULL - включение не завершено
+    -- и вход пока не требует кода
+    enabled_at TIMESTAMP,
+
+    -- Последний принятый шаг TOTP: код нельзя использовать повторно
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Коды восстановления на случай потери телефона, каждый срабатывает один раз
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- hex(SHA-256) кода, сам код показывается пользователю один раз
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, code_hash)
+);
@@ -0,0 +1,14 @@
+-- Прежние имена пользователей: по ним профиль находит новое имя,
+-- и некоторое время их не может занять другой пользователь
+
+CREATE TABLE IF NOT EXISTS username_history (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- Имя до переименования
+    username VARCHAR(100) NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Поиск по прежнему имени: последнее переименование первым
+CREATE INDEX IF NOT EXISTS idx_username_history_username ON username_history(username, changed_at DESC);
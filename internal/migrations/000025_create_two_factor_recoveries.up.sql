@@ -0,0 +1,39 @@
+-- Восстановление доступа при потере второго фактора (и кодов восстановления)
+-- Запрос проходит этапы: подтверждение email, период ожидания и, если
+-- включено TWO_FACTOR_RECOVERY_APPROVAL, одобрение администратором.
+-- Только после этого 2FA отключается и выдаются токены
+
+CREATE TABLE IF NOT EXISTS two_factor_recoveries (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- email_pending - ждет перехода по ссылке из письма
+    -- waiting - email подтвержден, идет период ожидания
+    -- completed, cancelled, rejected - запрос закрыт
+    status VARCHAR(20) NOT NULL DEFAULT 'email_pending',
+
+    email_confirmed_at TIMESTAMP,
+    -- Момент окончания периода ожидания, задается при подтверждении email
+    available_at TIMESTAMP,
+
+    -- Требуется ли одобрение администратора (фиксируется при создании)
+    approval_required BOOLEAN NOT NULL DEFAULT FALSE,
+    approved_at TIMESTAMP,
+    approved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+
+    -- Закрытие запроса: кто отклонил или отменил (NULL - сам пользователь)
+    resolved_at TIMESTAMP,
+    resolved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT two_factor_recoveries_status_check
+        CHECK (status IN ('email_pending', 'waiting', 'completed', 'cancelled', 'rejected'))
+);
+
+-- Не больше одного открытого запроса на пользователя
+CREATE UNIQUE INDEX IF NOT EXISTS idx_two_factor_recoveries_active
+    ON two_factor_recoveries(user_id)
+    WHERE status IN ('email_pending', 'waiting');
+
+COMMENT ON TABLE two_factor_recoveries IS 'Запросы восстановления доступа при потере второго фактора';
@@ -0,0 +1,22 @@
+-- Прогресс фоновых заполнений данных (fiber-backend backfill, пакет backfill)
+-- В отличие от миграций схемы, заполнение идет пачками по ключу id и
+-- сохраняет позицию после каждой пачки в той же транзакции, поэтому
+-- прерванный запуск продолжается с места остановки
+
+CREATE TABLE IF NOT EXISTS backfills (
+    name VARCHAR(100) PRIMARY KEY,
+
+    -- Последний обработанный id: следующая пачка начинается после него
+    last_id BIGINT NOT NULL DEFAULT 0,
+
+    -- Просмотрено и изменено строк с начала заполнения
+    scanned BIGINT NOT NULL DEFAULT 0,
+    updated BIGINT NOT NULL DEFAULT 0,
+
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- NULL - заполнение не дошло до конца таблицы
+    completed_at TIMESTAMP
+);
+
+COMMENT ON TABLE backfills IS 'Позиция и счетчики заполнений данных, запускаемых из CLI';
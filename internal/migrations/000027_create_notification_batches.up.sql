@@ -0,0 +1,26 @@
+-- Накопленные уведомления пользователя (пакет notify)
+-- События одного типа для одного пользователя в пределах окна собираются
+-- в одну строку и уходят одним письмом ("5 изменений профиля"), а не
+-- письмом на каждое событие
+
+CREATE TABLE IF NOT EXISTS notification_batches (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Тип события: действие журнала аудита (user.update)
+    event_type VARCHAR(100) NOT NULL,
+
+    -- Число событий в пачке
+    count INTEGER NOT NULL DEFAULT 1,
+
+    first_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Когда отправить: первое событие плюс окно типа, новые события
+    -- окно не продлевают, поэтому поток событий не откладывает письмо
+    send_after TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (user_id, event_type)
+);
+
+-- Поиск пачек, которые пора отправить
+CREATE INDEX IF NOT EXISTS idx_notification_batches_send_after ON notification_batches(send_after);
+
+COMMENT ON TABLE notification_batches IS 'События пользователя, ожидающие отправки одним уведомлением';
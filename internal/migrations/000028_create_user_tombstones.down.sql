@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_sync;
+DROP TABLE IF EXISTS user_tombstones;
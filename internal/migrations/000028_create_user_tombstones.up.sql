@@ -0,0 +1,18 @@
+-- Синхронизация изменений пользователей (GET /api/v1/users/changes)
+-- Клиент запрашивает изменения с позиции (updated_at, id) прошлого ответа.
+-- Мягко удаленные пользователи остаются в users и видны по deleted_at,
+-- а о физически удаленных (DeleteUser) помнит user_tombstones
+
+CREATE TABLE IF NOT EXISTS user_tombstones (
+    id BIGSERIAL PRIMARY KEY,
+
+    -- Публичный ID удаленного пользователя: внутренний id клиентам не отдается
+    public_id UUID NOT NULL,
+    deleted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Выборка изменений читает оба индекса с позиции курсора
+CREATE INDEX IF NOT EXISTS idx_user_tombstones_sync ON user_tombstones(deleted_at, id);
+CREATE INDEX IF NOT EXISTS idx_users_sync ON users(updated_at, id);
+
+COMMENT ON TABLE user_tombstones IS 'Физически удаленные пользователи для синхронизации клиентов';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_canonical_email_not_deleted;
+ALTER TABLE users DROP COLUMN IF EXISTS canonical_email;
@@ -0,0 +1,15 @@
+-- Канонический email: один почтовый ящик - один аккаунт
+-- canonical_email - адрес без различий, которые ящик не учитывает (регистр,
+-- точки Gmail и метки после "+" по EMAIL_STRIP_*), см. пакет emailaddr.
+-- Новые и измененные адреса получают его при записи, существующие строки
+-- заполняет backfill canonical-emails: UPDATE всей таблицы в миграции
+-- держал бы блокировку, а правила канонизации задаются конфигурацией.
+-- До заполнения колонка NULL, и такие строки ищутся по email, как раньше
+ALTER TABLE users ADD COLUMN IF NOT EXISTS canonical_email VARCHAR(255);
+
+-- NULL не конфликтуют между собой, поэтому индекс создается сразу, а
+-- дубликаты среди старых строк обнаружит заполнение
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_canonical_email_not_deleted
+    ON users(canonical_email) WHERE deleted_at IS NULL;
+
+COMMENT ON COLUMN users.canonical_email IS 'Канонический email для проверки уникальности (NULL - еще не заполнен)';
@@ -0,0 +1,46 @@
+-- Длительные операции администратора (GET /admin/v1/operations/:id)
+-- Запрос с Prefer: respond-async создает операцию и сразу отвечает 202,
+-- а выполняет ее воркер очереди заданий (run_operation). Клиент узнает
+-- прогресс и результат по ID операции
+
+CREATE TABLE IF NOT EXISTS operations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- Тип операции (import_users, merge_users, ...) и ее параметры
+    -- Параметры (в том числе файл импорта) удаляются по завершении
+    kind VARCHAR(64) NOT NULL,
+    input JSONB,
+
+    -- Статус: pending -> running -> succeeded | failed
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+
+    -- Прогресс: обработано записей из progress_total (NULL - неизвестно)
+    progress_done INTEGER NOT NULL DEFAULT 0,
+    progress_total INTEGER,
+
+    -- Итог: тело ответа синхронного эндпоинта или ошибка
+    result JSONB,
+    error TEXT,
+    error_code VARCHAR(64),
+    error_details JSONB,
+
+    -- Автор и запрос: операция выполняется от имени автора, записи
+    -- аудита и логи связаны с исходным запросом
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    actor_role VARCHAR(50),
+    request_id VARCHAR(128),
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+
+    CONSTRAINT operations_status_check CHECK (status IN ('pending', 'running', 'succeeded', 'failed'))
+);
+
+-- Удаление завершенных операций старше JOBS_RETENTION_DAYS
+CREATE INDEX IF NOT EXISTS idx_operations_completed ON operations(completed_at) WHERE completed_at IS NOT NULL;
+
+COMMENT ON TABLE operations IS 'Длительные операции администратора с прогрессом и результатом';
+COMMENT ON COLUMN operations.status IS 'pending, running, succeeded, failed';
+COMMENT ON COLUMN operations.input IS 'Параметры операции, удаляются по завершении';
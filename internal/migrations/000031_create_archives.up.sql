@@ -0,0 +1,33 @@
+-- Архив старых записей журналов (GET /admin/v1/archives)
+-- Задание archive_records переносит записи старше срока хранения в
+-- таблице (AUDIT_ARCHIVE_AFTER_DAYS) в сжатые файлы хранилища, чтобы
+-- таблицы журналов оставались небольшими. Строка описывает один файл:
+-- источник, период записей и ключ объекта в хранилище
+
+CREATE TABLE IF NOT EXISTS archives (
+    id BIGSERIAL PRIMARY KEY,
+
+    -- Таблица, из которой перенесены записи (audit_logs, ...)
+    source VARCHAR(64) NOT NULL,
+
+    -- Период записей файла (created_at первой и последней) и их ID в
+    -- исходной таблице
+    range_start TIMESTAMP NOT NULL,
+    range_end TIMESTAMP NOT NULL,
+    first_id BIGINT NOT NULL,
+    last_id BIGINT NOT NULL,
+
+    -- Файл: NDJSON, сжатый gzip
+    entry_count INTEGER NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key TEXT NOT NULL,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Выборка архивов источника, пересекающих период
+CREATE INDEX IF NOT EXISTS idx_archives_source_range ON archives(source, range_end, range_start);
+
+COMMENT ON TABLE archives IS 'Файлы архива старых записей журналов в хранилище';
+COMMENT ON COLUMN archives.source IS 'Исходная таблица: audit_logs, ...';
+COMMENT ON COLUMN archives.storage_key IS 'Ключ объекта NDJSON + gzip в хранилище';
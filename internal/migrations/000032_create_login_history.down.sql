@@ -0,0 +1,3 @@
+-- Откат истории входов
+
+DROP TABLE IF EXISTS login_history;
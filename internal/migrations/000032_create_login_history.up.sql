@@ -0,0 +1,20 @@
+-- История входов: строка на каждый завершенный вход (после проверки
+-- состояния аккаунта и второго фактора). Таблица растет с каждым входом,
+-- поэтому старые записи задание archive_records переносит в архив
+-- (LOGIN_HISTORY_ARCHIVE_AFTER_DAYS)
+
+CREATE TABLE IF NOT EXISTS login_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- Клиент, с которого выполнен вход
+    ip VARCHAR(64),
+    user_agent TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Входы пользователя от новых к старым
+CREATE INDEX IF NOT EXISTS idx_login_history_user ON login_history(user_id, created_at DESC);
+
+COMMENT ON TABLE login_history IS 'Завершенные входы пользователей';
@@ -0,0 +1,199 @@
+// Package migrations - SQL миграции схемы БД, встроенные в бинарник.
+//
+// Файлы в формате golang-migrate: NNNNNN_name.up.sql и NNNNNN_name.down.sql.
+// Примененная версия хранится в таблице schema_migrations, поэтому база,
+// которую раньше обновляли CLI migrate, продолжает обновляться отсюда.
+// Одновременный запуск с нескольких инстансов безопасен: golang-migrate
+// берет advisory lock Postgres на время применения.
+//
+// CheckDrift сверяет живую схему с той, что дают примененные миграции:
+// ручная правка БД (удаленная колонка или индекс) видна при запуске и в
+// GET /admin/v1/schema, а не ошибкой запроса посреди работы.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Dir - каталог миграций относительно корня репозитория
+// Сюда Create пишет новые файлы и отсюда их читает sqlc
+const Dir = "internal/migrations"
+
+// nameRegex - допустимое имя новой миграции
+var nameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// fileRegex разбирает имя файла миграции
+var fileRegex = regexp.MustCompile(`^(\d+)_.+\.(up|down)\.sql$`)
+
+// Status - состояние схемы БД
+type Status struct {
+	Version uint   // Последняя примененная миграция, 0 - ни одной
+	Dirty   bool   // Миграция Version упала на середине: нужна ручная правка и Force
+	Pending []uint // Версии, которые применит Up
+}
+
+// Migrator применяет встроенные миграции к базе
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+// New создает Migrator поверх пула соединений приложения
+// golang-migrate работает через database/sql, поэтому пул оборачивается
+// в *sql.DB (stdlib.OpenDBFromPool). Миграции выполняются на отдельном
+// соединении из пула, Close возвращает его в пул, не закрывая сам пул
+func New(pool *pgxpool.Pool) (*Migrator, error) {
+	src, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения миграций: %w", err)
+	}
+
+	db := stdlib.OpenDBFromPool(pool)
+	driver, err := pgxmigrate.WithInstance(db, &pgxmigrate.Config{})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ошибка инициализации миграций: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "pgx5", driver)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("ошибка инициализации миграций: %w", err)
+	}
+	m.Log = logger{}
+
+	return &Migrator{m: m, source: src}, nil
+}
+
+// Close освобождает соединение с БД
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
+
+// Up применяет все непримененные миграции
+// Возвращает false, если схема уже актуальна
+func (m *Migrator) Up() (bool, error) {
+	if err := m.m.Up(); err != nil {
+		if errors.Is(err, migrate.ErrNoChange) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Down откатывает steps последних миграций
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("количество шагов отката должно быть положительным: %d", steps)
+	}
+	if err := m.m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Force записывает версию схемы без выполнения миграций и снимает флаг dirty
+// Нужен после ручного исправления упавшей миграции
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+// Status возвращает примененную версию и список непримененных миграций
+func (m *Migrator) Status() (Status, error) {
+	var status Status
+
+	version, dirty, err := m.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, err
+	}
+	status.Version, status.Dirty = version, dirty
+
+	next, err := m.source.First()
+	for err == nil {
+		if next > status.Version {
+			status.Pending = append(status.Pending, next)
+		}
+		next, err = m.source.Next(next)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return status, fmt.Errorf("ошибка чтения миграций: %w", err)
+	}
+
+	return status, nil
+}
+
+// Create создает пустую пару файлов миграции со следующим номером в каталоге dir
+// Возвращает пути созданных файлов
+func Create(dir, name string) ([]string, error) {
+	if !nameRegex.MatchString(name) {
+		return nil, fmt.Errorf("имя миграции может содержать только a-z, 0-9 и _: %q", name)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения каталога миграций: %w", err)
+	}
+
+	var last uint64
+	for _, entry := range entries {
+		match := fileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		last = max(last, version)
+	}
+
+	paths := make([]string, 0, 2)
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%06d_%s.%s.sql", last+1, name, direction))
+		// O_EXCL: не перезаписываем файл, созданный параллельно
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return paths, fmt.Errorf("ошибка создания файла миграции: %w", err)
+		}
+		f.Close()
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// logger пишет ход миграций в slog
+type logger struct{}
+
+// Printf реализует migrate.Logger
+func (logger) Printf(format string, v ...interface{}) {
+	slog.Info("🗄️  " + strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+// Verbose реализует migrate.Logger
+func (logger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,341 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Schema - таблицы с колонками и именованные индексы схемы
+type Schema struct {
+	Tables  map[string]map[string]bool // Таблица -> колонки
+	Indexes map[string]string          // Индекс -> таблица
+}
+
+// Drift - расхождение живой схемы с ожидаемой по миграциям
+// Пустой Drift - схема совпадает
+type Drift struct {
+	Version        uint     // Примененная версия, по которой строилась ожидаемая схема
+	Dirty          bool     // Миграция Version упала на середине: схема может быть неполной
+	MissingTables  []string // Таблицы из миграций, которых нет в БД
+	MissingColumns []string // Колонки вида users.phone
+	MissingIndexes []string
+}
+
+// Empty сообщает, что расхождений нет
+func (d *Drift) Empty() bool {
+	return len(d.MissingTables) == 0 && len(d.MissingColumns) == 0 && len(d.MissingIndexes) == 0
+}
+
+// CheckDrift сравнивает схему БД с ожидаемой по примененным миграциям
+//
+// Ожидаемая схема - та же, из которой sqlc генерирует запросы, поэтому
+// колонка или индекс, удаленные ручной правкой БД, обнаруживаются при
+// запуске, а не ошибкой запроса посреди работы. Сравнение идет с
+// примененной версией (schema_migrations): непримененные миграции не
+// считаются расхождением. Лишние колонки и индексы тоже: запросы sqlc
+// их не используют
+func CheckDrift(ctx context.Context, pool *pgxpool.Pool) (*Drift, error) {
+	version, dirty, err := appliedVersion(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := Expected(version)
+	if err != nil {
+		return nil, err
+	}
+	live, err := liveSchema(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	drift := Compare(expected, live, version)
+	drift.Dirty = dirty
+
+	metrics.SchemaDrift.WithLabelValues("table").Set(float64(len(drift.MissingTables)))
+	metrics.SchemaDrift.WithLabelValues("column").Set(float64(len(drift.MissingColumns)))
+	metrics.SchemaDrift.WithLabelValues("index").Set(float64(len(drift.MissingIndexes)))
+	return drift, nil
+}
+
+// appliedVersion читает версию из таблицы golang-migrate
+// Таблицы нет - миграции не применялись (версия 0)
+func appliedVersion(ctx context.Context, pool *pgxpool.Pool) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := pool.QueryRow(ctx, `
+		SELECT version, dirty FROM schema_migrations
+		WHERE to_regclass('schema_migrations') IS NOT NULL
+		LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("ошибка чтения версии схемы: %w", err)
+	}
+	return uint(version), dirty, nil
+}
+
+// Compare возвращает то, что есть в expected и отсутствует в live
+func Compare(expected, live *Schema, version uint) *Drift {
+	drift := &Drift{Version: version}
+	for table, columns := range expected.Tables {
+		liveColumns, ok := live.Tables[table]
+		if !ok {
+			drift.MissingTables = append(drift.MissingTables, table)
+			continue
+		}
+		for column := range columns {
+			if !liveColumns[column] {
+				drift.MissingColumns = append(drift.MissingColumns, table+"."+column)
+			}
+		}
+	}
+	for index, table := range expected.Indexes {
+		// Индексы пропавшей таблицы уже отмечены вместе с ней
+		if _, ok := live.Tables[table]; !ok {
+			continue
+		}
+		if _, ok := live.Indexes[index]; !ok {
+			drift.MissingIndexes = append(drift.MissingIndexes, index)
+		}
+	}
+	sort.Strings(drift.MissingTables)
+	sort.Strings(drift.MissingColumns)
+	sort.Strings(drift.MissingIndexes)
+	return drift
+}
+
+// liveSchema читает таблицы, колонки и индексы текущей схемы БД
+func liveSchema(ctx context.Context, pool *pgxpool.Pool) (*Schema, error) {
+	schema := &Schema{Tables: map[string]map[string]bool{}, Indexes: map[string]string{}}
+
+	rows, err := pool.Query(ctx, `
+		SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = current_schema()`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения колонок схемы: %w", err)
+	}
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("ошибка чтения колонок схемы: %w", err)
+		}
+		schema.addColumn(table, column)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения колонок схемы: %w", err)
+	}
+
+	rows, err = pool.Query(ctx, `
+		SELECT indexname, tablename FROM pg_indexes
+		WHERE schemaname = current_schema()`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения индексов схемы: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var index, table string
+		if err := rows.Scan(&index, &table); err != nil {
+			return nil, fmt.Errorf("ошибка чтения индексов схемы: %w", err)
+		}
+		schema.Indexes[index] = table
+	}
+	return schema, rows.Err()
+}
+
+// Expected строит схему, которую дают миграции до версии version включительно
+func Expected(version uint) (*Schema, error) {
+	schema := &Schema{Tables: map[string]map[string]bool{}, Indexes: map[string]string{}}
+
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения миграций: %w", err)
+	}
+	sort.Strings(names) // Номер с ведущими нулями: порядок имен - порядок версий
+	for _, name := range names {
+		match := fileRegex.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		v, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || uint(v) > version {
+			continue
+		}
+		data, err := files.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения миграции %s: %w", name, err)
+		}
+		for _, statement := range splitSQL(string(data)) {
+			schema.apply(statement)
+		}
+	}
+	return schema, nil
+}
+
+// Разбор DDL миграций: только то, что влияет на таблицы, колонки и индексы
+var (
+	createTableRegex = regexp.MustCompile(`(?is)^CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(\S+)\s*\((.*)\)`)
+	alterTableRegex  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?(\S+)\s+(.*)$`)
+	dropTableRegex   = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?(.+)$`)
+	createIndexRegex = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(\S+)\s+ON\s+(?:ONLY\s+)?(\S+?)\s*(?:USING\s+\S+\s*)?\(`)
+	dropIndexRegex   = regexp.MustCompile(`(?is)^DROP\s+INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+EXISTS\s+)?(.+)$`)
+
+	addColumnRegex    = regexp.MustCompile(`(?is)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(\S+)`)
+	dropColumnRegex   = regexp.MustCompile(`(?is)^DROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?(\S+)`)
+	renameColumnRegex = regexp.MustCompile(`(?is)^RENAME\s+(?:COLUMN\s+)?(\S+)\s+TO\s+(\S+)$`)
+)
+
+// constraintWords - элементы CREATE TABLE и ALTER TABLE ADD/DROP, которые не колонки
+var constraintWords = map[string]bool{
+	"CONSTRAINT": true, "PRIMARY": true, "UNIQUE": true, "FOREIGN": true, "CHECK": true, "EXCLUDE": true,
+}
+
+// apply применяет одно выражение миграции к схеме
+func (s *Schema) apply(statement string) {
+	if m := createTableRegex.FindStringSubmatch(statement); m != nil {
+		table := identifier(m[1])
+		if s.Tables[table] == nil {
+			s.Tables[table] = map[string]bool{}
+		}
+		for _, item := range splitTopLevel(m[2], ',') {
+			fields := strings.Fields(item)
+			if len(fields) > 0 && !constraintWords[strings.ToUpper(fields[0])] {
+				s.addColumn(table, identifier(fields[0]))
+			}
+		}
+		return
+	}
+	if m := createIndexRegex.FindStringSubmatch(statement); m != nil {
+		s.Indexes[identifier(m[1])] = identifier(m[2])
+		return
+	}
+	if m := dropIndexRegex.FindStringSubmatch(statement); m != nil {
+		for _, index := range strings.Split(m[1], ",") {
+			delete(s.Indexes, identifier(strings.Fields(index)[0]))
+		}
+		return
+	}
+	if m := dropTableRegex.FindStringSubmatch(statement); m != nil {
+		for _, table := range strings.Split(m[1], ",") {
+			table = identifier(strings.Fields(table)[0])
+			delete(s.Tables, table)
+			for index, indexTable := range s.Indexes {
+				if indexTable == table {
+					delete(s.Indexes, index)
+				}
+			}
+		}
+		return
+	}
+	if m := alterTableRegex.FindStringSubmatch(statement); m != nil {
+		table := identifier(m[1])
+		for _, action := range splitTopLevel(m[2], ',') {
+			s.alter(table, strings.TrimSpace(action))
+		}
+	}
+}
+
+// alter применяет одно действие ALTER TABLE
+func (s *Schema) alter(table, action string) {
+	if m := addColumnRegex.FindStringSubmatch(action); m != nil {
+		if !constraintWords[strings.ToUpper(m[1])] {
+			s.addColumn(table, identifier(m[1]))
+		}
+		return
+	}
+	if m := dropColumnRegex.FindStringSubmatch(action); m != nil {
+		if !constraintWords[strings.ToUpper(m[1])] && s.Tables[table] != nil {
+			delete(s.Tables[table], identifier(m[1]))
+		}
+		return
+	}
+	if m := renameColumnRegex.FindStringSubmatch(action); m != nil && s.Tables[table] != nil {
+		delete(s.Tables[table], identifier(m[1]))
+		s.addColumn(table, identifier(m[2]))
+	}
+}
+
+// addColumn добавляет колонку, создавая таблицу при необходимости
+func (s *Schema) addColumn(table, column string) {
+	if s.Tables[table] == nil {
+		s.Tables[table] = map[string]bool{}
+	}
+	s.Tables[table][column] = true
+}
+
+// identifier приводит имя из SQL к виду в каталоге PostgreSQL:
+// без схемы public и кавычек, без кавычек - в нижнем регистре
+func identifier(name string) string {
+	name = strings.TrimPrefix(name, "public.")
+	if unquoted, ok := strings.CutPrefix(name, `"`); ok {
+		return strings.TrimSuffix(unquoted, `"`)
+	}
+	return strings.ToLower(name)
+}
+
+// splitSQL делит текст миграции на выражения по ";" вне строк
+// и удаляет комментарии "--"
+func splitSQL(text string) []string {
+	var statements []string
+	var current strings.Builder
+	inString := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\'':
+			inString = !inString
+		case !inString && c == '-' && i+1 < len(text) && text[i+1] == '-':
+			// Комментарий до конца строки
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+			continue
+		case !inString && c == ';':
+			if statement := strings.TrimSpace(current.String()); statement != "" {
+				statements = append(statements, statement)
+			}
+			current.Reset()
+			continue
+		}
+		current.WriteByte(c)
+	}
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+// splitTopLevel делит text по sep вне скобок и строк
+func splitTopLevel(text string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	inString := false
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\'':
+			inString = !inString
+		case inString:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, text[start:])
+}
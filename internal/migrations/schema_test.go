@@ -0,0 +1,103 @@
+package migrations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpectedReplaysMigrations(t *testing.T) {
+	schema, err := Expected(27)
+	if err != nil {
+		t.Fatalf("Expected: %v", err)
+	}
+
+	users := schema.Tables["users"]
+	for _, column := range []string{"id", "email", "public_id", "status", "deleted_at"} {
+		if !users[column] {
+			t.Errorf("users.%s отсутствует в ожидаемой схеме", column)
+		}
+	}
+	// Колонка, замененная status в 000018, не должна ожидаться
+	if users["is_active"] {
+		t.Error("users.is_active удалена миграцией, но ожидается")
+	}
+	if schema.Indexes["idx_users_public_id"] != "users" {
+		t.Errorf("idx_users_public_id: таблица %q, ожидалась users", schema.Indexes["idx_users_public_id"])
+	}
+	if schema.Tables["notification_batches"] == nil {
+		t.Error("notification_batches отсутствует в схеме версии 27")
+	}
+
+	// Непримененные миграции не входят в ожидаемую схему
+	early, err := Expected(1)
+	if err != nil {
+		t.Fatalf("Expected(1): %v", err)
+	}
+	if early.Tables["notification_batches"] != nil || early.Tables["users"]["public_id"] {
+		t.Error("схема версии 1 содержит объекты более поздних миграций")
+	}
+}
+
+func TestSchemaApply(t *testing.T) {
+	schema := &Schema{Tables: map[string]map[string]bool{}, Indexes: map[string]string{}}
+	for _, statement := range splitSQL(`
+		-- комментарий; с точкой с запятой
+		CREATE TABLE IF NOT EXISTS items (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(100) NOT NULL DEFAULT 'a;b',
+			price NUMERIC(10, 2),
+			CONSTRAINT items_name_unique UNIQUE (name)
+		);
+		CREATE UNIQUE INDEX idx_items_name ON items (lower(name));
+		ALTER TABLE items ADD COLUMN sku TEXT, DROP COLUMN price;
+		ALTER TABLE items RENAME COLUMN name TO title;
+		CREATE TABLE tmp (id INT);
+		CREATE INDEX idx_tmp_id ON tmp USING btree (id);
+		DROP TABLE IF EXISTS tmp;
+	`) {
+		schema.apply(statement)
+	}
+
+	want := &Schema{
+		Tables:  map[string]map[string]bool{"items": {"id": true, "title": true, "sku": true}},
+		Indexes: map[string]string{"idx_items_name": "items"},
+	}
+	if !reflect.DeepEqual(schema, want) {
+		t.Errorf("схема = %+v, ожидалась %+v", schema, want)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	expected := &Schema{
+		Tables: map[string]map[string]bool{
+			"users":  {"id": true, "email": true, "phone": true},
+			"orders": {"id": true},
+		},
+		Indexes: map[string]string{"idx_users_email": "users", "idx_orders_id": "orders"},
+	}
+	live := &Schema{
+		Tables: map[string]map[string]bool{
+			"users": {"id": true, "email": true, "extra": true},
+		},
+		Indexes: map[string]string{"users_pkey": "users"},
+	}
+
+	drift := Compare(expected, live, 5)
+	if drift.Empty() {
+		t.Fatal("расхождение не обнаружено")
+	}
+	if !reflect.DeepEqual(drift.MissingTables, []string{"orders"}) {
+		t.Errorf("MissingTables = %v", drift.MissingTables)
+	}
+	if !reflect.DeepEqual(drift.MissingColumns, []string{"users.phone"}) {
+		t.Errorf("MissingColumns = %v", drift.MissingColumns)
+	}
+	// Индекс пропавшей таблицы не дублируется в MissingIndexes
+	if !reflect.DeepEqual(drift.MissingIndexes, []string{"idx_users_email"}) {
+		t.Errorf("MissingIndexes = %v", drift.MissingIndexes)
+	}
+
+	if drift := Compare(expected, expected, 5); !drift.Empty() {
+		t.Errorf("одинаковые схемы дали расхождение: %+v", drift)
+	}
+}
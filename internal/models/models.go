@@ -1,15 +1,27 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/lifecycle"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+)
 
 // CreateUserRequest представляет данные для создания пользователя
 // Эти поля приходят от клиента в JSON формате
 type CreateUserRequest struct {
-	Email     string `json:"email" validate:"required,email"`     // Email обязателен и должен быть валидным
-	Username  string `json:"username" validate:"required,min=3"`  // Username минимум 3 символа
-	Password  string `json:"password" validate:"required,min=8"`  // Пароль минимум 8 символов
-	FirstName string `json:"first_name,omitempty"`                // Опциональное поле
-	LastName  string `json:"last_name,omitempty"`                 // Опциональное поле
+	Email     string `json:"email" validate:"required,email"`    // Email обязателен и должен быть валидным
+	Username  string `json:"username" validate:"required,min=3"` // Username минимум 3 символа
+	Password  string `json:"password" validate:"required,min=8"` // Пароль минимум 8 символов
+	FirstName string `json:"first_name,omitempty"`               // Опциональное поле
+	LastName  string `json:"last_name,omitempty"`                // Опциональное поле
+
+	// Телефон в любом распространенном формате, сохраняется в E.164
+	// Номер без "+" разбирается как национальный номер страны country
+	Phone   string `json:"phone,omitempty" validate:"omitempty,max=32"`
+	Country string `json:"country,omitempty" validate:"omitempty,country"` // ISO 3166-1 alpha-2: RU, US
 }
 
 // UpdateUserRequest представляет данные для обновления пользователя
@@ -19,26 +31,220 @@ type UpdateUserRequest struct {
 	Username  *string `json:"username,omitempty" validate:"omitempty,min=3"`
 	FirstName *string `json:"first_name,omitempty"`
 	LastName  *string `json:"last_name,omitempty"`
-	IsActive  *bool   `json:"is_active,omitempty"`
+
+	// Пустая строка очищает телефон и страну
+	Phone   *string `json:"phone,omitempty" validate:"omitempty,max=32"`
+	Country *string `json:"country,omitempty" validate:"omitempty,country"`
 }
 
 // UserResponse представляет пользователя в ответе API
 // Не включаем password_hash для безопасности
 type UserResponse struct {
-	ID        int       `json:"id"`
-	Email     string    `json:"email"`
-	Username  string    `json:"username"`
-	FirstName *string   `json:"first_name,omitempty"` // Указатель чтобы null был null, а не пустой строкой
-	LastName  *string   `json:"last_name,omitempty"`
-	IsActive  bool      `json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	// ID - публичный идентификатор (UUID): последовательный id не раскрывается,
+	// чтобы по нему нельзя было оценить число регистраций и перебирать пользователей
+	ID         string `json:"id"`
+	InternalID int    `json:"-"` // Первичный ключ в БД для вызовов сервисов
+
+	Email     string   `json:"email"`
+	Username  string   `json:"username"`
+	FirstName *string  `json:"first_name,omitempty"` // Указатель чтобы null был null, а не пустой строкой
+	LastName  *string  `json:"last_name,omitempty"`
+	Phone     *string  `json:"phone,omitempty"`   // E.164: +79123456789
+	Country   *string  `json:"country,omitempty"` // ISO 3166-1 alpha-2: RU
+	Status    string   `json:"status"`            // Состояние аккаунта, см. lifecycle
+	IsActive  bool     `json:"is_active"`         // Устарело: true для pending_verification и active
+	Role      string   `json:"role"`              // Роль пользователя: user, admin
+	CreatedAt utc.Time `json:"created_at"`
+	UpdatedAt utc.Time `json:"updated_at"`
+
+	LastLoginAt *utc.Time `json:"last_login_at,omitempty"` // nil если пользователь ни разу не входил
+	LoginCount  int       `json:"login_count"`             // Количество успешных входов
+
+	EmailVerifiedAt *utc.Time `json:"email_verified_at,omitempty"` // nil если email не подтвержден
+
+	// AvatarURL - ссылка на аватар, nil если аватар не загружен
+	// Меняется при каждой загрузке, поэтому клиент может кешировать файл бессрочно
+	AvatarURL *string `json:"avatar_url,omitempty"`
+}
+
+// LoginRequest представляет запрос на вход по email и паролю
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// RefreshTokenRequest представляет запрос на обновление или отзыв токенов
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TokenResponse представляет выданную пару токенов
+// Если у пользователя включена 2FA, вход по паролю вместо пары токенов
+// возвращает two_factor_required и two_factor_token, а expires_in - время
+// на ввод кода в POST /api/v1/auth/2fa
+type TokenResponse struct {
+	AccessToken  string        `json:"access_token,omitempty"`
+	RefreshToken string        `json:"refresh_token,omitempty"`
+	TokenType    string        `json:"token_type"` // Всегда Bearer
+	ExpiresIn    int           `json:"expires_in"` // Время жизни access токена в секундах
+	User         *UserResponse `json:"user,omitempty"`
+
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	TwoFactorToken    string `json:"two_factor_token,omitempty"`
+}
+
+// TwoFactorLoginRequest представляет второй шаг входа: код из приложения
+// или, при потере телефона, один из кодов восстановления
+type TwoFactorLoginRequest struct {
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// TwoFactorEnrollmentResponse представляет секрет для приложения-аутентификатора
+// Секрет показывается один раз; 2FA включится после подтверждения кодом
+type TwoFactorEnrollmentResponse struct {
+	Secret          string `json:"secret"`           // base32, для ручного ввода
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth://totp/..., содержимое QR кода
+}
+
+// TwoFactorVerifyRequest представляет подтверждение включения 2FA первым кодом
+type TwoFactorVerifyRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// TwoFactorVerifyResponse представляет коды восстановления
+// Коды показываются один раз, в БД хранятся только их хеши
+type TwoFactorVerifyResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorRecoveryResponse представляет запрос восстановления доступа
+// без второго фактора и его этапы
+type TwoFactorRecoveryResponse struct {
+	ID     publicid.ID `json:"id"`
+	Status string      `json:"status"` // email_pending, waiting, completed, cancelled, rejected
+
+	EmailConfirmedAt *utc.Time `json:"email_confirmed_at,omitempty"`
+	AvailableAt      *utc.Time `json:"available_at,omitempty"` // Конец периода ожидания
+	ApprovalRequired bool      `json:"approval_required"`
+	ApprovedAt       *utc.Time `json:"approved_at,omitempty"`
+
+	// Ready - все этапы пройдены, восстановление можно завершить
+	Ready bool `json:"ready"`
+
+	// Пользователь - только в списке для администраторов
+	UserID   string `json:"user_id,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Username string `json:"username,omitempty"`
+
+	CreatedAt  utc.Time  `json:"created_at"`
+	ResolvedAt *utc.Time `json:"resolved_at,omitempty"`
+}
+
+// ConfirmTwoFactorRecoveryRequest представляет переход по ссылке из письма
+type ConfirmTwoFactorRecoveryRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// SudoRequest представляет повторный ввод пароля для режима повышенных прав
+type SudoRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// SudoTokenResponse представляет выданный sudo токен
+// Токен передается в заголовке X-Sudo-Token вместе с access токеном
+type SudoTokenResponse struct {
+	SudoToken string `json:"sudo_token"`
+	ExpiresIn int    `json:"expires_in"` // Время жизни в секундах
+}
+
+// VerifyEmailRequest представляет подтверждение email по токену из письма
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// EmailRequest представляет запрос письма на email: повторное
+// подтверждение адреса или ссылку сброса пароля
+type EmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest представляет установку нового пароля по токену из письма
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"` // Те же требования, что при регистрации
+}
+
+// UpdateUserRoleRequest представляет запрос на назначение роли
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" validate:"required"` // Имя роли из справочника roles
+}
+
+// UpdateUserStatusRequest представляет запрос на смену состояния аккаунта
+type UpdateUserStatusRequest struct {
+	Status string `json:"status" validate:"required"` // Новое состояние: active, suspended, deactivated, deleted
 }
 
 // ListUsersRequest представляет параметры для получения списка пользователей
 type ListUsersRequest struct {
-	Page     int `query:"page" validate:"min=1"`               // Номер страницы (начиная с 1)
+	Page     int `query:"page" validate:"min=1"`              // Номер страницы (начиная с 1)
 	PageSize int `query:"page_size" validate:"min=1,max=100"` // Размер страницы (макс 100)
+
+	// Search - подстрока email, username, имени или фамилии (параметр q)
+	Search string `query:"-"`
+
+	// Фильтры ниже парсятся в handler через query.Filter, nil - фильтр не задан
+	Statuses      []lifecycle.Status `query:"-"` // Состояния аккаунта (status), пусто - любые, кроме deleted
+	CreatedAfter  *time.Time         `query:"-"` // Созданные не раньше (created_after)
+	CreatedBefore *time.Time         `query:"-"` // Созданные раньше (created_before)
+
+	// InactiveSince - административный фильтр: пользователи, которые не входили
+	// с указанной даты (или не входили ни разу). Парсится в handler из
+	// query параметра inactive_since через query.Filter
+	InactiveSince *time.Time `query:"-"`
+
+	// Сортировка из параметра sort, поле проверяется по UserSortFields
+	SortBy   UserSortField `query:"-"`
+	SortDesc bool          `query:"-"`
+}
+
+// UserSortField - поле сортировки списка пользователей
+type UserSortField string
+
+// Поля, по которым можно сортировать список пользователей
+const (
+	UserSortCreatedAt   UserSortField = "created_at"
+	UserSortEmail       UserSortField = "email"
+	UserSortUsername    UserSortField = "username"
+	UserSortLastLoginAt UserSortField = "last_login_at"
+)
+
+// UserSortFields - допустимые значения параметра sort для списка пользователей
+var UserSortFields = []UserSortField{UserSortCreatedAt, UserSortEmail, UserSortUsername, UserSortLastLoginAt}
+
+// ListUsersCursorResponse - страница списка пользователей в режиме курсора
+// Общее количество не считается: COUNT(*) на большой таблице дороже самой страницы
+type ListUsersCursorResponse struct {
+	Users []UserResponse `json:"users"` // Список пользователей
+	Limit int            `json:"limit"` // Размер страницы
+
+	// NextCursor передается в cursor для следующей страницы, null - страница последняя
+	NextCursor *string `json:"next_cursor"`
+	HasNext    bool    `json:"has_next"`
+}
+
+// UserChangesResponse - изменения пользователей после токена синхронизации
+// (GET /api/v1/users/changes). Клиент применяет created и updated поверх
+// своей копии списка, удаляет deleted и сохраняет next_cursor
+type UserChangesResponse struct {
+	Created []UserResponse `json:"created"` // Созданы после прошлой синхронизации
+	Updated []UserResponse `json:"updated"` // Созданы раньше, изменены после нее
+	Deleted []string       `json:"deleted"` // Публичные ID удаленных пользователей
+
+	// NextCursor передается в since следующего запроса, даже если изменений нет
+	NextCursor string `json:"next_cursor"`
+	// HasMore - изменений больше limit, следующую страницу стоит запросить сразу
+	HasMore bool `json:"has_more"`
 }
 
 // ListUsersResponse представляет ответ со списком пользователей
@@ -48,6 +254,359 @@ type ListUsersResponse struct {
 	Page       int            `json:"page"`        // Текущая страница
 	PageSize   int            `json:"page_size"`   // Размер страницы
 	TotalPages int            `json:"total_pages"` // Всего страниц
+
+	// Навигация рассчитывается сервисом, клиентам не нужно выводить ее из total_pages
+	HasNext bool `json:"has_next"` // Есть ли следующая страница
+	HasPrev bool `json:"has_prev"` // Есть ли предыдущая страница
+
+	// created_at первого и последнего пользователя на странице (nil для пустой страницы)
+	FirstItemAt *utc.Time `json:"first_item_at,omitempty"`
+	LastItemAt  *utc.Time `json:"last_item_at,omitempty"`
+}
+
+// UserStatsResponse представляет агрегированную статистику пользователя
+// для панелей поддержки (GET /admin/v1/users/:id/stats)
+type UserStatsResponse struct {
+	UserID         string    `json:"user_id"`                 // Публичный ID
+	LoginCount     int       `json:"login_count"`             // Всего успешных входов
+	LastLoginAt    *utc.Time `json:"last_login_at,omitempty"` // Последний вход (nil если не входил)
+	LastActivityAt utc.Time  `json:"last_activity_at"`        // Последнее действие: вход или изменение профиля
+	AccountAgeDays int       `json:"account_age_days"`        // Сколько дней назад создан аккаунт
+	CreatedAt      utc.Time  `json:"created_at"`              // Дата регистрации
+
+	// Данные из других источников: nil, если источник не ответил
+	// (причина - в Parts)
+	AuditEvents      *int64 `json:"audit_events,omitempty"`       // Записей журнала аудита об аккаунте
+	LinkedIdentities *int64 `json:"linked_identities,omitempty"`  // Привязанных внешних учетных записей
+	TwoFactorEnabled *bool  `json:"two_factor_enabled,omitempty"` // Включена ли 2FA
+
+	// Parts - статус каждого источника сводки: user, audit, identities, two_factor
+	Parts map[string]PartStatus `json:"parts"`
+}
+
+// PartStatus - результат одной части составного ответа (пакет fanout)
+type PartStatus struct {
+	Status    string  `json:"status"`     // ok, error, timeout или canceled
+	LatencyMs float64 `json:"latency_ms"` // Время выполнения части
+}
+
+// MergeUsersRequest представляет запрос на слияние дубликата с основным аккаунтом
+type MergeUsersRequest struct {
+	DuplicateID string `json:"duplicate_id" validate:"required,uuid"` // Публичный ID аккаунта, который будет поглощен и удален
+	DryRun      bool   `json:"dry_run"`                               // Только показать результат, ничего не сохраняя
+}
+
+// MergeUsersResponse представляет результат слияния аккаунтов
+type MergeUsersResponse struct {
+	DryRun      bool         `json:"dry_run"`
+	Primary     UserResponse `json:"primary"`      // Основной аккаунт после слияния
+	DuplicateID string       `json:"duplicate_id"` // Публичный ID удаленного дубликата
+
+	// Сколько связанных записей перенесено на основной аккаунт или отозвано
+	// у дубликата, по таблицам; avatar - 1, если аватар перешел к основному
+	Reassigned map[string]int64 `json:"reassigned"`
+}
+
+// LinkIdentityRequest представляет запрос на привязку внешней учетной записи
+type LinkIdentityRequest struct {
+	Provider string `json:"provider" validate:"required"` // google, github, apple
+	Token    string `json:"token" validate:"required"`    // Токен, полученный клиентом от провайдера после OAuth
+}
+
+// IdentityResponse представляет привязанную внешнюю учетную запись
+type IdentityResponse struct {
+	Provider string   `json:"provider"`
+	Email    *string  `json:"email,omitempty"` // Email у провайдера
+	LinkedAt utc.Time `json:"linked_at"`
+}
+
+// ListIdentitiesResponse представляет способы входа пользователя
+type ListIdentitiesResponse struct {
+	HasPassword bool               `json:"has_password"` // Можно ли войти паролем
+	Identities  []IdentityResponse `json:"identities"`
+}
+
+// ImportUsersResponse представляет результат импорта пользователей
+// Импорт не прерывается на ошибочных записях - они перечислены в Errors
+type ImportUsersResponse struct {
+	Imported int              `json:"imported"` // Сколько пользователей создано
+	Failed   int              `json:"failed"`   // Сколько записей пропущено
+	Errors   []ImportRowError `json:"errors"`   // Причины пропуска по записям
+}
+
+// ImportRowError описывает ошибку импорта конкретной записи
+type ImportRowError struct {
+	Row   int    `json:"row"`   // Номер записи в источнике (с 1)
+	Error string `json:"error"` // Причина ошибки
+}
+
+// CreateExportRequest представляет запрос на создание экспорта
+type CreateExportRequest struct {
+	Resource string `json:"resource" validate:"oneof=users"`    // Что экспортировать (пока только users)
+	Format   string `json:"format" validate:"oneof=csv ndjson"` // Формат файла: csv или ndjson
+}
+
+// ExportJobResponse представляет задание экспорта в ответе API
+type ExportJobResponse struct {
+	ID          publicid.ID `json:"id"`
+	Resource    string      `json:"resource"`
+	Format      string      `json:"format"`
+	Status      string      `json:"status"`    // pending, running, completed, failed
+	RowCount    int         `json:"row_count"` // Количество выгруженных записей
+	Error       *string     `json:"error,omitempty"`
+	CreatedAt   utc.Time    `json:"created_at"`
+	CompletedAt *utc.Time   `json:"completed_at,omitempty"`
+
+	// Временная ссылка на скачивание, только для status = completed
+	DownloadURL          *string   `json:"download_url,omitempty"`
+	DownloadURLExpiresAt *utc.Time `json:"download_url_expires_at,omitempty"`
+}
+
+// OperationResponse представляет длительную операцию в ответе API
+// (Prefer: respond-async, GET /admin/v1/operations/:id)
+type OperationResponse struct {
+	ID          string            `json:"id"` // UUID
+	Kind        string            `json:"kind"`
+	Status      string            `json:"status"` // pending, running, succeeded, failed
+	Progress    OperationProgress `json:"progress"`
+	CreatedAt   utc.Time          `json:"created_at"`
+	StartedAt   *utc.Time         `json:"started_at,omitempty"`
+	CompletedAt *utc.Time         `json:"completed_at,omitempty"`
+
+	// Result - тело, которое вернул бы синхронный запрос (status = succeeded)
+	Result json.RawMessage `json:"result,omitempty"`
+	// Error - ошибка, которую вернул бы синхронный запрос (status = failed)
+	Error *ErrorResponse `json:"error,omitempty"`
+}
+
+// OperationProgress представляет прогресс операции
+type OperationProgress struct {
+	Done  int  `json:"done"`            // Обработано записей
+	Total *int `json:"total,omitempty"` // Всего записей, если известно
+}
+
+// EmailTemplatePreviewResponse представляет шаблон письма, заполненный примером данных
+type EmailTemplatePreviewResponse struct {
+	Name    string `json:"name"`
+	To      string `json:"to,omitempty"` // Адрес тестового письма
+	Subject string `json:"subject"`
+	Body    string `json:"body"` // Текст письма (text/plain)
+}
+
+// TestSendEmailTemplateRequest представляет запрос на тестовую отправку шаблона
+type TestSendEmailTemplateRequest struct {
+	To string `json:"to" validate:"omitempty,email"` // По умолчанию - email текущего администратора
+}
+
+// CreateWebhookRequest представляет запрос на подписку на события пользователей
+type CreateWebhookRequest struct {
+	// URL - куда отправлять события (POST)
+	URL string `json:"url" validate:"required,http_url,max=2048"`
+	// Events - на какие события подписка: user.created, user.updated, user.deleted, user.deactivated
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=user.created user.updated user.deleted user.deactivated"`
+}
+
+// WebhookResponse представляет подписку на события
+type WebhookResponse struct {
+	ID     publicid.ID `json:"id"`
+	URL    string      `json:"url"`
+	Events []string    `json:"events"`
+
+	// Secret - ключ проверки подписи X-Webhook-Signature
+	// Возвращается только при создании подписки
+	Secret string `json:"secret,omitempty"`
+
+	CreatedAt utc.Time `json:"created_at"`
+}
+
+// ListWebhooksResponse представляет список подписок на события
+type ListWebhooksResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+// WebhookDeliveryResponse представляет доставку события подписчику
+type WebhookDeliveryResponse struct {
+	ID             publicid.ID `json:"id"`
+	Event          string      `json:"event"`
+	Status         string      `json:"status"`   // pending, delivered, failed
+	Attempts       int         `json:"attempts"` // Сделано попыток
+	ResponseStatus *int        `json:"response_status,omitempty"`
+	LastError      *string     `json:"last_error,omitempty"`
+	CreatedAt      utc.Time    `json:"created_at"`
+	DeliveredAt    *utc.Time   `json:"delivered_at,omitempty"`
+}
+
+// ListWebhookDeliveriesResponse представляет журнал доставки подписки
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+}
+
+// CreateCORSOriginRequest представляет запрос на разрешение источника CORS
+type CreateCORSOriginRequest struct {
+	// Origin - https://app.example.com или шаблон поддоменов https://*.example.com
+	Origin      string `json:"origin" validate:"required,max=255,origin"`
+	Description string `json:"description" validate:"max=500"` // Кто и зачем обращается с этого источника
+}
+
+// CORSOriginResponse представляет разрешенный источник CORS
+type CORSOriginResponse struct {
+	ID          publicid.ID `json:"id"`
+	Origin      string      `json:"origin"` // В нормализованном виде
+	Description *string     `json:"description,omitempty"`
+	CreatedAt   utc.Time    `json:"created_at"`
+}
+
+// ListCORSOriginsResponse представляет список разрешенных источников
+type ListCORSOriginsResponse struct {
+	Origins []CORSOriginResponse `json:"origins"`
+}
+
+// CreateTrustedClientRequest представляет запрос на регистрацию собственного клиента
+type CreateTrustedClientRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=64"`                        // web, admin-panel, ...
+	Description string   `json:"description" validate:"max=500"`                               // Описание клиента
+	Origins     []string `json:"origins" validate:"required,min=1,max=20,dive,max=255,origin"` // Источники клиента
+}
+
+// UpdateTrustedClientRequest представляет запрос на изменение клиента
+// Список источников заменяется целиком
+type UpdateTrustedClientRequest struct {
+	Description string   `json:"description" validate:"max=500"`
+	Origins     []string `json:"origins" validate:"required,min=1,max=20,dive,max=255,origin"`
+}
+
+// TrustedClientResponse представляет собственного клиента и его источники
+type TrustedClientResponse struct {
+	ID          publicid.ID `json:"id"`
+	Name        string      `json:"name"`
+	Description *string     `json:"description,omitempty"`
+	Origins     []string    `json:"origins"`
+	CreatedAt   utc.Time    `json:"created_at"`
+	UpdatedAt   utc.Time    `json:"updated_at"`
+}
+
+// ListTrustedClientsResponse представляет список собственных клиентов
+type ListTrustedClientsResponse struct {
+	Clients []TrustedClientResponse `json:"clients"`
+}
+
+// BroadcastFilter - сегмент получателей рассылки
+// Фильтры совпадают с фильтрами списка пользователей (GET /api/v1/users)
+type BroadcastFilter struct {
+	InactiveSince *utc.Time `json:"inactive_since,omitempty"` // Не входили с даты (YYYY-MM-DD или RFC3339) или ни разу
+}
+
+// CreateBroadcastRequest представляет запрос на массовую рассылку
+type CreateBroadcastRequest struct {
+	Subject string          `json:"subject" validate:"required,max=200"` // Тема письма
+	Body    string          `json:"body" validate:"required"`            // Текст письма (text/plain)
+	Filter  BroadcastFilter `json:"filter"`
+}
+
+// BroadcastResponse представляет рассылку и ее прогресс
+type BroadcastResponse struct {
+	ID      publicid.ID     `json:"id"`
+	Subject string          `json:"subject"`
+	Filter  BroadcastFilter `json:"filter"`
+	Status  string          `json:"status"` // pending, running, completed, failed, cancelled
+
+	TotalCount  int     `json:"total_count"`  // Размер сегмента на момент создания
+	SentCount   int     `json:"sent_count"`   // Отправлено писем
+	FailedCount int     `json:"failed_count"` // Не удалось отправить
+	Progress    float64 `json:"progress"`     // Доля обработанных получателей, 0-100
+
+	Error       *string   `json:"error,omitempty"`
+	CreatedAt   utc.Time  `json:"created_at"`
+	StartedAt   *utc.Time `json:"started_at,omitempty"`
+	CompletedAt *utc.Time `json:"completed_at,omitempty"`
+}
+
+// CreateAnnouncementRequest представляет запрос на публикацию объявления
+type CreateAnnouncementRequest struct {
+	Title    string    `json:"title" validate:"required,max=200"`                         // Заголовок (до 200 символов)
+	Body     string    `json:"body" validate:"required"`                                  // Текст объявления
+	Severity string    `json:"severity" validate:"omitempty,oneof=info warning critical"` // info, warning, critical (по умолчанию info)
+	Audience string    `json:"audience" validate:"omitempty,oneof=all web ios android"`   // all, web, ios, android (по умолчанию all)
+	StartsAt *utc.Time `json:"starts_at,omitempty"`                                       // Начало показа (по умолчанию сразу)
+	EndsAt   *utc.Time `json:"ends_at,omitempty"`                                         // Конец показа (по умолчанию до отмены)
+}
+
+// AnnouncementResponse представляет объявление в ответе API
+type AnnouncementResponse struct {
+	ID        publicid.ID `json:"id"`
+	Title     string      `json:"title"`
+	Body      string      `json:"body"`
+	Severity  string      `json:"severity"`
+	Audience  string      `json:"audience"`
+	StartsAt  utc.Time    `json:"starts_at"`
+	EndsAt    *utc.Time   `json:"ends_at,omitempty"`
+	CreatedAt utc.Time    `json:"created_at"`
+}
+
+// ListAnnouncementsResponse представляет список активных объявлений
+type ListAnnouncementsResponse struct {
+	Announcements []AnnouncementResponse `json:"announcements"`
+}
+
+// DigestSubscriptionResponse представляет подписку на сводку активности аккаунта
+type DigestSubscriptionResponse struct {
+	Enabled    bool      `json:"enabled"`
+	LastSentAt *utc.Time `json:"last_sent_at,omitempty"` // Когда была отправлена последняя сводка
+}
+
+// PublicProfileResponse - публичный профиль пользователя (GET /api/v1/profiles/:username)
+// В отличие от UserResponse не содержит email, роль, состояние и историю входов;
+// имя и аватар есть, только если пользователь разрешил их показывать
+type PublicProfileResponse struct {
+	Username  string   `json:"username"`
+	FirstName *string  `json:"first_name,omitempty"`
+	LastName  *string  `json:"last_name,omitempty"`
+	AvatarURL *string  `json:"avatar_url,omitempty"`
+	CreatedAt utc.Time `json:"created_at"` // Дата регистрации
+}
+
+// ProfileMovedResponse представляет ответ 301 на запрос профиля
+// по прежнему имени пользователя
+type ProfileMovedResponse struct {
+	Error    string `json:"error"`
+	Code     string `json:"code"`     // PROFILE_MOVED
+	Username string `json:"username"` // Текущее имя, профиль - в заголовке Location
+}
+
+// PrivacySettingsResponse представляет настройки приватности публичного профиля
+type PrivacySettingsResponse struct {
+	ShowName   bool `json:"show_name"`   // Показывать имя и фамилию
+	ShowAvatar bool `json:"show_avatar"` // Показывать аватар
+}
+
+// UpdatePrivacySettingsRequest представляет изменение настроек приватности
+// Отсутствующее поле не меняется
+type UpdatePrivacySettingsRequest struct {
+	ShowName   *bool `json:"show_name"`
+	ShowAvatar *bool `json:"show_avatar"`
+}
+
+// UpdateSettingRequest представляет запрос на переопределение настройки
+// Value - JSON значение типа настройки: число, true/false или строка длительности ("30s")
+type UpdateSettingRequest struct {
+	Value json.RawMessage `json:"value" validate:"required"`
+}
+
+// SettingResponse представляет настройку, изменяемую во время работы
+type SettingResponse struct {
+	Key         string      `json:"key"`
+	Type        string      `json:"type"` // int, bool, duration
+	Description string      `json:"description"`
+	Value       interface{} `json:"value"`                // Действующее значение
+	Default     interface{} `json:"default"`              // Значение из переменных окружения
+	Overridden  bool        `json:"overridden"`           // Значение переопределено в БД
+	UpdatedBy   *string     `json:"updated_by,omitempty"` // Публичный ID администратора
+	UpdatedAt   *utc.Time   `json:"updated_at,omitempty"`
+}
+
+// ListSettingsResponse представляет список настроек
+type ListSettingsResponse struct {
+	Settings []SettingResponse `json:"settings"`
 }
 
 // ErrorResponse представляет ошибку в API ответе
@@ -58,6 +617,19 @@ type ErrorResponse struct {
 	Details map[string]interface{} `json:"details,omitempty"` // Дополнительные детали
 }
 
+// ProblemResponse - ошибка в формате RFC 9457 (application/problem+json)
+// Отдается клиентам, которые запросили его заголовком Accept, остальные
+// получают ErrorResponse (см. middleware.ErrorFormat)
+type ProblemResponse struct {
+	Type      string                 `json:"type"`                 // URI типа ошибки, about:blank - только статус
+	Title     string                 `json:"title"`                // Краткое описание статуса
+	Status    int                    `json:"status"`               // HTTP статус
+	Detail    string                 `json:"detail,omitempty"`     // Текст ошибки (ErrorResponse.Error)
+	Code      string                 `json:"code,omitempty"`       // Код ошибки (ErrorResponse.Code)
+	Details   map[string]interface{} `json:"details,omitempty"`    // Дополнительные детали
+	RequestID string                 `json:"request_id,omitempty"` // X-Request-ID для обращения в поддержку
+}
+
 // SuccessResponse представляет успешный ответ без данных
 type SuccessResponse struct {
 	Message string `json:"message"` // Сообщение об успехе
@@ -65,7 +637,278 @@ type SuccessResponse struct {
 
 // HealthResponse представляет статус здоровья сервиса
 type HealthResponse struct {
-	Status   string            `json:"status"`   // "ok" или "error"
-	Services map[string]string `json:"services"` // Статусы подсервисов (БД и т.д.)
-	Version  string            `json:"version"`  // Версия приложения
+	Status  string                      `json:"status"`           // "ok", "degraded", "starting" или "error"
+	Checks  map[string]DependencyHealth `json:"checks,omitempty"` // Проверки зависимостей (нет в /health/live)
+	Version string                      `json:"version"`          // Версия приложения
+}
+
+// DependencyHealth - результат проверки одной зависимости
+type DependencyHealth struct {
+	Status    string  `json:"status"`          // "up" или "down"
+	Critical  bool    `json:"critical"`        // Недоступность переводит инстанс в 503
+	LatencyMs float64 `json:"latency_ms"`      // Время ответа зависимости
+	Error     string  `json:"error,omitempty"` // Причина недоступности
+
+	// Fallback - как сервис работает без недоступной зависимости
+	Fallback string `json:"fallback,omitempty"`
+}
+
+// ListAuditLogsRequest представляет фильтры журнала аудита
+// Заполняется в handler из query параметров, nil - фильтр не задан
+type ListAuditLogsRequest struct {
+	Page     int
+	PageSize int
+
+	ActorID       *int       // Внутренний ID автора изменения (actor_id)
+	TargetUserID  *int       // Внутренний ID пользователя, которого касается запись (target_id)
+	Action        string     // Действие: user.create, user.update, ...
+	CreatedAfter  *time.Time // Записи не раньше (created_after)
+	CreatedBefore *time.Time // Записи раньше (created_before)
+}
+
+// AuditLogResponse представляет запись журнала аудита
+type AuditLogResponse struct {
+	ID publicid.ID `json:"id"`
+
+	// Пользователи - публичные ID; null - система, аноним или физически удаленный пользователь
+	ActorID      *string `json:"actor_id"`
+	ActorRole    *string `json:"actor_role,omitempty"`
+	Action       string  `json:"action"`
+	TargetUserID *string `json:"target_user_id"`
+
+	// Изменения по полям: {"email": {"old": "a@example.com", "new": "b@example.com"}}
+	Changes json.RawMessage `json:"changes"`
+
+	RequestID *string  `json:"request_id,omitempty"`
+	IP        *string  `json:"ip,omitempty"`
+	UserAgent *string  `json:"user_agent,omitempty"`
+	CreatedAt utc.Time `json:"created_at"`
+}
+
+// ListAuditLogsResponse представляет страницу журнала аудита
+type ListAuditLogsResponse struct {
+	Logs       []AuditLogResponse `json:"logs"`
+	TotalCount int                `json:"total_count"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"page_size"`
+	TotalPages int                `json:"total_pages"`
+	HasNext    bool               `json:"has_next"`
+	HasPrev    bool               `json:"has_prev"`
+}
+
+// LoginHistoryEntry представляет завершенный вход в архиве истории входов
+type LoginHistoryEntry struct {
+	ID        publicid.ID `json:"id"`
+	UserID    string      `json:"user_id"` // Публичный ID пользователя
+	IP        *string     `json:"ip,omitempty"`
+	UserAgent *string     `json:"user_agent,omitempty"`
+	CreatedAt utc.Time    `json:"created_at"`
+}
+
+// ArchiveResponse представляет файл архива старых записей журнала
+type ArchiveResponse struct {
+	ID     publicid.ID `json:"id"`
+	Source string      `json:"source"` // Исходная таблица: audit_logs, ...
+
+	// Период записей: created_at первой и последней записи файла
+	RangeStart utc.Time `json:"range_start"`
+	RangeEnd   utc.Time `json:"range_end"`
+
+	EntryCount int      `json:"entry_count"`
+	SizeBytes  int64    `json:"size_bytes"` // Размер сжатого файла
+	CreatedAt  utc.Time `json:"created_at"` // Когда записи перенесены в архив
+}
+
+// ListArchivesRequest представляет фильтры списка архивов
+// Заполняется в handler из query параметров, nil - фильтр не задан
+type ListArchivesRequest struct {
+	Page     int
+	PageSize int
+
+	Source        string     // Исходная таблица (source)
+	CreatedAfter  *time.Time // Архивы с записями не раньше (created_after)
+	CreatedBefore *time.Time // Архивы с записями раньше (created_before)
+}
+
+// ListArchivesResponse представляет страницу архивов
+type ListArchivesResponse struct {
+	Archives   []ArchiveResponse `json:"archives"`
+	TotalCount int               `json:"total_count"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+	HasNext    bool              `json:"has_next"`
+	HasPrev    bool              `json:"has_prev"`
+}
+
+// ListArchiveEntriesRequest представляет фильтры записей одного архива
+type ListArchiveEntriesRequest struct {
+	ArchiveID int64
+	Page      int
+	PageSize  int
+
+	Fields        map[string]string // Равенство строковых полей записи (filter[поле]=значение)
+	CreatedAfter  *time.Time        // Записи не раньше (created_after)
+	CreatedBefore *time.Time        // Записи раньше (created_before)
+}
+
+// ListArchiveEntriesResponse представляет страницу записей архива
+// Записи - в том виде, в каком их отдавал API источника
+// (для audit_logs - AuditLogResponse)
+type ListArchiveEntriesResponse struct {
+	Archive    ArchiveResponse   `json:"archive"`
+	Entries    []json.RawMessage `json:"entries"`
+	TotalCount int               `json:"total_count"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+	HasNext    bool              `json:"has_next"`
+	HasPrev    bool              `json:"has_prev"`
+}
+
+// SystemResponse представляет сводку о состоянии процесса (GET /admin/v1/system)
+// Счетчики ошибок и кешей накоплены с момента запуска (started_at)
+type SystemResponse struct {
+	StartedAt     utc.Time `json:"started_at"`
+	UptimeSeconds int64    `json:"uptime_seconds"`
+	Goroutines    int      `json:"goroutines"`
+
+	Memory SystemMemory           `json:"memory"`
+	DBPool *SystemDBPool          `json:"db_pool,omitempty"` // nil - пул не подключен
+	Queues map[string]SystemQueue `json:"queues"`            // Очереди в памяти: audit, ...
+	Caches map[string]SystemCache `json:"caches"`            // Кеши в памяти: health_lb, ...
+	Errors SystemErrors           `json:"errors"`
+}
+
+// SystemMemory - память процесса по данным runtime.MemStats
+type SystemMemory struct {
+	HeapAllocBytes uint64    `json:"heap_alloc_bytes"`  // Занято живыми объектами кучи
+	HeapInuseBytes uint64    `json:"heap_inuse_bytes"`  // Занято спанами кучи
+	SysBytes       uint64    `json:"sys_bytes"`         // Получено у ОС всего
+	NumGC          uint32    `json:"num_gc"`            // Завершенных циклов GC
+	GCPauseTotalMs float64   `json:"gc_pause_total_ms"` // Суммарные паузы GC
+	LastGCAt       *utc.Time `json:"last_gc_at,omitempty"`
+}
+
+// SystemDBPool - состояние пула соединений БД
+type SystemDBPool struct {
+	MaxOpen   int     `json:"max_open"`
+	Open      int     `json:"open"`
+	InUse     int     `json:"in_use"`
+	Idle      int     `json:"idle"`
+	WaitCount int64   `json:"wait_count"` // Сколько раз запрос ждал свободного соединения
+	WaitMs    float64 `json:"wait_ms"`    // Суммарное время ожидания
+}
+
+// SystemQueue - заполненность очереди в памяти
+type SystemQueue struct {
+	Depth    int `json:"depth"`
+	Capacity int `json:"capacity"`
+}
+
+// SystemCache - обращения к кешу
+type SystemCache struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"` // Доля попаданий от 0 до 1, 0 - обращений не было
+}
+
+// SystemErrors - счетчики ошибок
+type SystemErrors struct {
+	ServerErrors      int64 `json:"server_errors"`       // Ответы 5xx
+	ClientErrors      int64 `json:"client_errors"`       // Ответы 4xx
+	RequestsAborted   int64 `json:"requests_aborted"`    // Обрыв или таймаут чтения тела
+	RateLimitRejected int64 `json:"rate_limit_rejected"` // Ответы 429 лимитов запросов
+	AuditDropped      int64 `json:"audit_dropped"`       // Несохраненные записи журнала аудита
+
+	// Роуты с наибольшим числом ответов 5xx, по убыванию
+	TopServerErrorRoutes []SystemRouteErrors `json:"top_server_error_routes"`
+}
+
+// SystemRouteErrors - ответы 5xx одного роута
+type SystemRouteErrors struct {
+	Method string `json:"method"`
+	Route  string `json:"route"`
+	Count  int64  `json:"count"`
+}
+
+// SchemaDriftResponse - сверка схемы БД с миграциями (GET /admin/v1/schema)
+type SchemaDriftResponse struct {
+	Version        uint     `json:"version"` // Примененная версия миграций
+	Dirty          bool     `json:"dirty"`   // Миграция version упала на середине
+	OK             bool     `json:"ok"`      // Расхождений нет
+	MissingTables  []string `json:"missing_tables"`
+	MissingColumns []string `json:"missing_columns"` // Вида users.phone
+	MissingIndexes []string `json:"missing_indexes"`
+}
+
+// JobQueueResponse - глубина очереди фоновых заданий (GET /admin/v1/jobs)
+type JobQueueResponse struct {
+	Pending int            `json:"pending"` // Ждут выполнения, в том числе отложенные повторы
+	Running int            `json:"running"` // Выполняются сейчас
+	Failed  int            `json:"failed"`  // Исчерпали попытки (хранятся JOBS_RETENTION_DAYS)
+	Kinds   []JobKindStats `json:"kinds"`   // Разбивка по типам и статусам
+}
+
+// JobKindStats - задания одного типа в одном статусе
+type JobKindStats struct {
+	Kind        string   `json:"kind"`
+	Status      string   `json:"status"`
+	Count       int      `json:"count"`
+	OldestRunAt utc.Time `json:"oldest_run_at"` // Самое раннее время запуска: по нему видно отставание очереди
+}
+
+// ConfigResponse - действующая конфигурация инстанса (GET /admin/v1/config)
+type ConfigResponse struct {
+	Config   map[string]interface{} `json:"config"`         // Секреты скрыты
+	Sources  map[string]string      `json:"sources"`        // Источник каждого параметра: flag, env, file, default
+	File     string                 `json:"file,omitempty"` // Файл --config, если задан
+	LoadedAt utc.Time               `json:"loaded_at"`      // Запуск или последняя перезагрузка (SIGHUP)
+
+	// Поля, измененные перезагрузкой, которые вступят в силу после перезапуска
+	RestartRequired []string `json:"restart_required"`
+}
+
+// OpenIDConfiguration - описание аутентификации сервиса
+// (GET /.well-known/openid-configuration, OpenID Connect Discovery)
+// Сервис не является полноценным OpenID провайдером: документ перечисляет
+// только то, что есть на самом деле - вход по паролю и refresh токены
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"` // Совпадает с iss в токенах (JWT_ISSUER)
+	JWKSURI                           string   `json:"jwks_uri"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// JWKSResponse - публичные ключи проверки токенов (GET /.well-known/jwks.json)
+// Токены подписываются симметричным HS256, ключ которого публиковать нельзя,
+// поэтому список пуст, пока не появятся асимметричные ключи
+type JWKSResponse struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK - публичный ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+}
+
+// ReferenceIndexResponse - список справочников (GET /api/v1/reference)
+type ReferenceIndexResponse struct {
+	Version  string             `json:"version"`
+	Datasets []ReferenceDataset `json:"datasets"`
+}
+
+// ReferenceDataset - справочник и ссылка на его текущую версию
+// Ответ по url можно кешировать бессрочно: с новой версией меняется ссылка
+type ReferenceDataset struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
 }
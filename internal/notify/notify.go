@@ -0,0 +1,175 @@
+// Package notify - уведомления пользователям о действиях с их аккаунтом.
+//
+// Письмо на каждое событие превращается в поток писем, когда событий
+// много: скрипт меняет профиль десять раз подряд, администратор
+// правит аккаунт по полям. Поэтому события одного типа для одного
+// пользователя копятся в таблице notification_batches и уходят одним
+// письмом ("5 изменений профиля"), когда истекает окно типа.
+// Окно отсчитывается от первого события и не продлевается следующими:
+// непрерывный поток событий не откладывает письмо бесконечно.
+//
+// События берутся из журнала аудита (Batcher.Observe подписывается
+// через audit.Logger.Observe) после сохранения записи, поэтому
+// уведомления не добавляют запросов к обработке запроса. Пачки
+// отправляет задание jobs.KindFlushNotifications раз в минуту.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/audit"
+	"github.com/Soundveyve/fiber-backend/internal/jobs"
+	"github.com/Soundveyve/fiber-backend/internal/mailer"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// FlushInterval - как часто ставится задание отправки пачек
+// Письмо уходит не позже окна типа плюс интервал
+const FlushInterval = time.Minute
+
+// eventNames - описание события для письма в формах для 1, 2-4 и 5+ событий
+// Уведомлять можно только о событиях из этого списка
+var eventNames = map[string][3]string{
+	audit.ActionUserUpdate:          {"изменение профиля", "изменения профиля", "изменений профиля"},
+	audit.ActionUserAvatarUpdate:    {"смена аватара", "смены аватара", "смен аватара"},
+	audit.ActionUserRoleUpdate:      {"изменение роли", "изменения роли", "изменений роли"},
+	audit.ActionUserStatusUpdate:    {"изменение состояния аккаунта", "изменения состояния аккаунта", "изменений состояния аккаунта"},
+	audit.ActionUserTwoFactorEnable: {"включение двухфакторной аутентификации", "включения двухфакторной аутентификации", "включений двухфакторной аутентификации"},
+}
+
+// EventTypes возвращает типы событий, о которых можно уведомлять, по алфавиту
+func EventTypes() []string {
+	types := make([]string, 0, len(eventNames))
+	for eventType := range eventNames {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Batcher копит события пользователей и отправляет их пачками
+type Batcher struct {
+	queries *repository.Queries
+	queue   *jobs.Queue
+	windows map[string]time.Duration // Окно по типу события; нет типа - не уведомлять
+}
+
+// NewBatcher создает накопитель уведомлений
+// windows - окно накопления по типу события (NOTIFY_BATCH_WINDOWS),
+// тип без описания в eventNames - ошибка конфигурации
+func NewBatcher(queries *repository.Queries, queue *jobs.Queue, windows map[string]time.Duration) (*Batcher, error) {
+	for eventType := range windows {
+		if _, ok := eventNames[eventType]; !ok {
+			return nil, fmt.Errorf("NOTIFY_BATCH_WINDOWS: уведомления о %q не поддерживаются (доступны: %s)",
+				eventType, strings.Join(EventTypes(), ", "))
+		}
+	}
+	return &Batcher{queries: queries, queue: queue, windows: windows}, nil
+}
+
+// Enabled сообщает, включено ли хотя бы одно уведомление
+func (b *Batcher) Enabled() bool {
+	return len(b.windows) > 0
+}
+
+// Observe добавляет сохраненную запись аудита в пачку пользователя,
+// над которым совершено действие (audit.Observer)
+// Ошибка только пишется в лог: уведомление не важнее журнала
+func (b *Batcher) Observe(ctx context.Context, entry repository.CreateAuditLogParams) {
+	window, ok := b.windows[entry.Action]
+	if !ok || !entry.TargetUserID.Valid {
+		return
+	}
+
+	err := b.queries.AddNotificationEvent(ctx, repository.AddNotificationEventParams{
+		UserID:     entry.TargetUserID.Int32,
+		EventType:  entry.Action,
+		OccurredAt: entry.CreatedAt,
+		SendAfter:  entry.CreatedAt.Add(window),
+	})
+	if err != nil {
+		slog.Error("❌ Ошибка добавления события в уведомление",
+			"action", entry.Action, "user_id", entry.TargetUserID.Int32, "error", err)
+	}
+}
+
+// Flush - обработчик заданий jobs.KindFlushNotifications
+// Отправляет все пачки, окно которых истекло
+func (b *Batcher) Flush(ctx context.Context, _ json.RawMessage) error {
+	sent := 0
+	for {
+		batch, err := b.queries.ClaimDueNotificationBatch(ctx, time.Now().UTC())
+		if errors.Is(err, pgx.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка захвата уведомления: %w", err)
+		}
+		// Пользователь заблокирован или удален: пачка уже удалена без письма
+		if !batch.Deliverable {
+			continue
+		}
+
+		if err := b.send(ctx, batch); err != nil {
+			slog.ErrorContext(ctx, "❌ Ошибка отправки уведомления",
+				"user_id", batch.UserID, "event_type", batch.EventType, "error", err)
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		slog.InfoContext(ctx, "📬 Уведомления отправлены", "count", sent)
+	}
+	return nil
+}
+
+// send ставит письмо о пачке в очередь отправки
+func (b *Batcher) send(ctx context.Context, batch repository.ClaimDueNotificationBatchRow) error {
+	msg, err := mailer.Render(mailer.TemplateNotificationBatch, batch.Email, mailer.NotificationBatchData{
+		Username: batch.Username,
+		Summary:  Summary(batch.EventType, int(batch.Count)),
+		Count:    int(batch.Count),
+		FirstAt:  batch.FirstAt,
+		LastAt:   batch.LastAt,
+	})
+	if err != nil {
+		return err
+	}
+	return b.queue.Enqueue(ctx, jobs.KindSendEmail, msg)
+}
+
+// Summary описывает count событий типа eventType: "5 изменений профиля"
+func Summary(eventType string, count int) string {
+	forms, ok := eventNames[eventType]
+	if !ok {
+		return fmt.Sprintf("%d событий %s", count, eventType)
+	}
+	return fmt.Sprintf("%d %s", count, forms[pluralForm(count)])
+}
+
+// pluralForm выбирает форму слова для числа n по правилам русского языка:
+// 0 - 1, 21, 31 (изменение), 1 - 2-4, 22-24 (изменения), 2 - остальные (изменений)
+func pluralForm(n int) int {
+	n %= 100
+	if n >= 11 && n <= 14 {
+		return 2
+	}
+	switch n % 10 {
+	case 1:
+		return 0
+	case 2, 3, 4:
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/audit"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+)
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		count int
+		want  string
+	}{
+		{1, "1 изменение профиля"},
+		{3, "3 изменения профиля"},
+		{5, "5 изменений профиля"},
+		{11, "11 изменений профиля"},
+		{14, "14 изменений профиля"},
+		{21, "21 изменение профиля"},
+		{22, "22 изменения профиля"},
+		{111, "111 изменений профиля"},
+	}
+	for _, tt := range tests {
+		if got := Summary(audit.ActionUserUpdate, tt.count); got != tt.want {
+			t.Errorf("Summary(%d) = %q, ожидалось %q", tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestNewBatcherRejectsUnknownEvent(t *testing.T) {
+	if _, err := NewBatcher(nil, nil, map[string]time.Duration{audit.ActionUserUpdate: time.Minute}); err != nil {
+		t.Fatalf("NewBatcher: %v", err)
+	}
+	// Журнал записывает и действия, о которых не уведомляем
+	if _, err := NewBatcher(nil, nil, map[string]time.Duration{audit.ActionUserHardDelete: time.Minute}); err == nil {
+		t.Error("для события без описания ожидалась ошибка")
+	}
+}
+
+func TestObserveSkipsUnconfiguredEvents(t *testing.T) {
+	batcher, err := NewBatcher(nil, nil, map[string]time.Duration{audit.ActionUserUpdate: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !batcher.Enabled() {
+		t.Error("Enabled() = false при заданном окне")
+	}
+
+	// Без окна типа и без пользователя запросов к БД нет (queries == nil)
+	batcher.Observe(context.Background(), repository.CreateAuditLogParams{Action: audit.ActionUserRoleUpdate})
+	batcher.Observe(context.Background(), repository.CreateAuditLogParams{Action: audit.ActionUserUpdate})
+}
@@ -0,0 +1,385 @@
+// Package operations - длительные административные операции с прогрессом.
+//
+// Запрос, который может работать дольше таймаута клиента (массовый импорт,
+// слияние аккаунтов), с заголовком Prefer: respond-async не выполняется
+// сразу: Manager.Start сохраняет входные данные в таблицу operations,
+// ставит задание run_operation и возвращает 202 с идентификатором.
+// Воркер очереди вызывает Runner, зарегистрированный для типа операции,
+// от имени того же администратора и с тем же request ID. Клиент опрашивает
+// GET /admin/v1/operations/:id: статус, прогресс и, когда операция
+// завершена, тело ответа или ошибку, которые вернул бы синхронный запрос.
+//
+// Runner сообщает прогресс через Progress из контекста (ProgressFromContext),
+// поэтому сервисы не знают, выполняются они в запросе или в операции:
+// вне операции Progress - nil, и его методы ничего не делают.
+//
+// Результат, который устаревает (подписанная ссылка на файл экспорта),
+// не сохраняется готовым: Runner сохраняет ссылку на данные, а Presenter
+// того же типа строит из нее ответ при каждом чтении операции.
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/jobs"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/safe"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Статусы операций
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Типы операций
+// Заполнения данных (backfill) сюда пока не перенесены, причины - в README,
+// раздел «Длительные операции»
+const (
+	KindBulkImportUsers = "bulk_import_users" // POST /api/v1/users/import
+	KindImportUsers     = "import_users"      // POST /admin/v1/users/import
+	KindMergeUsers      = "merge_users"       // POST /admin/v1/users/:id/merge
+	KindExportUsers     = "export_users"      // POST /api/v1/exports
+)
+
+// progressInterval - как часто прогресс записывается в БД
+const progressInterval = time.Second
+
+// ErrOperationNotFound возвращается, когда операции нет
+var ErrOperationNotFound = apperrors.NotFound("OPERATION_NOT_FOUND", "операция не найдена")
+
+// Runner выполняет операцию с входными данными input и возвращает
+// тело ответа синхронного запроса
+type Runner func(ctx context.Context, input json.RawMessage) (any, error)
+
+// Presenter строит результат завершенной операции из сохраненного
+// результата Runner при каждом чтении; baseURL - адрес сервиса для ссылок
+type Presenter func(ctx context.Context, result json.RawMessage, baseURL string) (any, error)
+
+// payload - параметры задания run_operation
+type payload struct {
+	OperationID uuid.UUID `json:"operation_id"`
+}
+
+// Manager запускает операции и отдает их состояние
+type Manager struct {
+	queries    *repository.Queries
+	queue      *jobs.Queue
+	runners    map[string]Runner
+	presenters map[string]Presenter
+}
+
+// New создает менеджер операций
+// Обработчик задания run_operation регистрирует main (Manager.Run)
+func New(queries *repository.Queries, queue *jobs.Queue) *Manager {
+	return &Manager{
+		queries:    queries,
+		queue:      queue,
+		runners:    make(map[string]Runner),
+		presenters: make(map[string]Presenter),
+	}
+}
+
+// Register задает Runner для типа операции
+// Вызывается при старте до Start
+func (m *Manager) Register(kind string, runner Runner) {
+	m.runners[kind] = runner
+}
+
+// RegisterPresenter задает Presenter для типа операции
+// Без него результат отдается таким, каким его вернул Runner
+func (m *Manager) RegisterPresenter(kind string, presenter Presenter) {
+	m.presenters[kind] = presenter
+}
+
+// Start создает операцию типа kind и ставит ее в очередь
+// input сохраняется в operations.input и передается Runner в JSON
+func (m *Manager) Start(ctx context.Context, kind string, input any) (*models.OperationResponse, error) {
+	if _, ok := m.runners[kind]; !ok {
+		return nil, fmt.Errorf("неизвестный тип операции %s", kind)
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации операции %s: %w", kind, err)
+	}
+
+	// Операция выполняется от имени того, кто ее запустил
+	params := repository.CreateOperationParams{Kind: kind, Input: data}
+	if user, ok := reqctx.UserFromContext(ctx); ok {
+		params.ActorID = pgtype.Int4{Int32: int32(user.ID), Valid: true}
+		params.ActorRole = pgtype.Text{String: user.Role, Valid: true}
+	}
+	if requestID := reqctx.RequestIDFromContext(ctx); requestID != "" {
+		params.RequestID = pgtype.Text{String: requestID, Valid: true}
+	}
+
+	op, err := m.queries.CreateOperation(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания операции: %w", err)
+	}
+
+	if err := m.queue.Enqueue(ctx, jobs.KindRunOperation, payload{OperationID: op.ID}); err != nil {
+		// Без задания операция навсегда осталась бы pending
+		m.complete(ctx, op.ID, nil, nil, err)
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "⏳ Операция поставлена в очередь", "operation_id", op.ID, "kind", kind)
+	return toResponse(op), nil
+}
+
+// Get возвращает операцию по id
+// Результат завершенной операции строит Presenter ее типа, если он задан
+func (m *Manager) Get(ctx context.Context, id, baseURL string) (*models.OperationResponse, error) {
+	opID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrOperationNotFound
+	}
+	op, err := m.queries.GetOperation(ctx, opID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrOperationNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения операции: %w", err)
+	}
+
+	resp := toResponse(op)
+	if presenter, ok := m.presenters[op.Kind]; ok && op.Status == StatusSucceeded {
+		result, err := presenter(ctx, op.Result, baseURL)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Result, err = json.Marshal(result); err != nil {
+			return nil, fmt.Errorf("ошибка сериализации результата операции: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// Run выполняет операцию (обработчик задания run_operation)
+//
+// Ошибка Runner - результат операции (status = failed), а не неудачная
+// попытка задания: повтор импорта с теми же строками даст ту же ошибку.
+// Повторяется только операция, прерванная остановкой инстанса
+func (m *Manager) Run(ctx context.Context, raw json.RawMessage) error {
+	var p payload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return jobs.Permanent(fmt.Errorf("невалидные параметры операции: %w", err))
+	}
+
+	// 1. Переводим в running; завершенная операция не выполняется повторно
+	op, err := m.queries.StartOperation(ctx, p.OperationID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("ошибка запуска операции: %w", err)
+	}
+
+	runner, ok := m.runners[op.Kind]
+	if !ok {
+		m.complete(ctx, op.ID, nil, nil, fmt.Errorf("неизвестный тип операции %s", op.Kind))
+		return nil
+	}
+
+	// 2. Контекст запроса, который запустил операцию
+	if op.ActorID.Valid {
+		ctx = reqctx.WithUser(ctx, reqctx.User{ID: int(op.ActorID.Int32), Role: op.ActorRole.String})
+	}
+	if op.RequestID.Valid {
+		ctx = reqctx.WithRequestID(ctx, op.RequestID.String)
+	}
+	progress := &Progress{}
+	ctx = WithProgress(ctx, progress)
+
+	// 3. Выполняем, периодически сохраняя прогресс
+	stop := m.trackProgress(ctx, op.ID, progress)
+	var result any
+	err = safe.Call(ctx, "operation:"+op.Kind, func() error {
+		var runErr error
+		result, runErr = runner(ctx, op.Input)
+		return runErr
+	})
+	stop()
+
+	// Остановка инстанса: операцию выполнит следующая попытка задания
+	if err != nil && ctx.Err() != nil && !jobs.LastAttempt(ctx) {
+		return err
+	}
+
+	// 4. Сохраняем результат
+	m.complete(ctx, op.ID, progress, result, err)
+	if err != nil {
+		slog.WarnContext(ctx, "⚠️ Операция завершилась ошибкой", "operation_id", op.ID, "kind", op.Kind, "error", err)
+	} else {
+		slog.InfoContext(ctx, "✅ Операция выполнена", "operation_id", op.ID, "kind", op.Kind)
+	}
+	return nil
+}
+
+// Purge удаляет завершенные операции старше retention
+// (задание purge_operations)
+func (m *Manager) Purge(ctx context.Context, retention time.Duration) error {
+	before := pgtype.Timestamp{Time: time.Now().Add(-retention), Valid: true}
+	deleted, err := m.queries.PurgeOperations(ctx, before)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления старых операций: %w", err)
+	}
+	slog.InfoContext(ctx, "🧹 Старые операции удалены", "count", deleted)
+	return nil
+}
+
+// trackProgress записывает прогресс в БД раз в progressInterval,
+// пока не вызвана возвращенная функция
+func (m *Manager) trackProgress(ctx context.Context, id uuid.UUID, progress *Progress) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+
+		var last [2]int
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current, total := progress.snapshot()
+			if current == last[0] && total == last[1] {
+				continue
+			}
+			last = [2]int{current, total}
+			err := m.queries.UpdateOperationProgress(ctx, repository.UpdateOperationProgressParams{
+				ProgressDone:  int32(current),
+				ProgressTotal: progressTotal(total),
+				ID:            id,
+			})
+			if err != nil {
+				slog.WarnContext(ctx, "⚠️ Не удалось сохранить прогресс операции", "operation_id", id, "error", err)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// complete сохраняет результат или ошибку операции
+// Входные данные операции при этом удаляются: они больше не нужны,
+// а импорт содержит персональные данные
+func (m *Manager) complete(ctx context.Context, id uuid.UUID, progress *Progress, result any, runErr error) {
+	params := repository.CompleteOperationParams{Status: StatusSucceeded, ID: id}
+	if progress != nil {
+		current, total := progress.snapshot()
+		params.ProgressDone, params.ProgressTotal = int32(current), progressTotal(total)
+	}
+
+	if runErr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			runErr = fmt.Errorf("ошибка сериализации результата: %w", err)
+		} else {
+			params.Result = data
+		}
+	}
+	if runErr != nil {
+		failure := ErrorResponse(runErr)
+		params.Status = StatusFailed
+		params.Error = pgtype.Text{String: failure.Error, Valid: true}
+		params.ErrorCode = pgtype.Text{String: failure.Code, Valid: true}
+		if failure.Details != nil {
+			params.ErrorDetails, _ = json.Marshal(failure.Details)
+		}
+	}
+
+	// Результат сохраняется и после отмены контекста задания
+	if err := m.queries.CompleteOperation(context.WithoutCancel(ctx), params); err != nil {
+		slog.ErrorContext(ctx, "❌ Не удалось сохранить результат операции", "operation_id", id, "error", err)
+	}
+}
+
+// ErrorResponse - тело ошибки, которое вернул бы синхронный запрос
+// Повторяет handlers.ErrorHandler для ошибок, которые возвращают сервисы
+func ErrorResponse(err error) models.ErrorResponse {
+	var validationErr *validation.Error
+	if errors.As(err, &validationErr) {
+		details := make(map[string]interface{}, len(validationErr.Fields))
+		for field, message := range validationErr.Fields {
+			details[field] = message
+		}
+		return models.ErrorResponse{Error: "Ошибка валидации данных", Code: "VALIDATION_ERROR", Details: details}
+	}
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		if unique := apperrors.Unique(err); unique != nil {
+			appErr, err = unique, unique
+		}
+	}
+	if appErr != nil {
+		return models.ErrorResponse{Error: err.Error(), Code: appErr.Code, Details: appErr.Details}
+	}
+
+	// Значение паники - только в логе
+	var panicErr *safe.PanicError
+	if errors.As(err, &panicErr) {
+		return models.ErrorResponse{Error: "внутренняя ошибка сервера", Code: "INTERNAL_ERROR"}
+	}
+	return models.ErrorResponse{Error: err.Error(), Code: "INTERNAL_ERROR"}
+}
+
+// toResponse преобразует строку operations в ответ API
+func toResponse(op repository.Operation) *models.OperationResponse {
+	resp := &models.OperationResponse{
+		ID:          op.ID.String(),
+		Kind:        op.Kind,
+		Status:      op.Status,
+		Progress:    models.OperationProgress{Done: int(op.ProgressDone)},
+		CreatedAt:   utc.From(op.CreatedAt),
+		StartedAt:   utc.FromNull(op.StartedAt),
+		CompletedAt: utc.FromNull(op.CompletedAt),
+	}
+	if op.ProgressTotal.Valid {
+		total := int(op.ProgressTotal.Int32)
+		resp.Progress.Total = &total
+	}
+
+	switch op.Status {
+	case StatusSucceeded:
+		resp.Result = op.Result
+	case StatusFailed:
+		resp.Error = &models.ErrorResponse{Error: op.Error.String, Code: op.ErrorCode.String}
+		if len(op.ErrorDetails) > 0 {
+			_ = json.Unmarshal(op.ErrorDetails, &resp.Error.Details)
+		}
+	}
+	return resp
+}
+
+// progressTotal - NULL, пока общее число записей неизвестно
+func progressTotal(total int) pgtype.Int4 {
+	return pgtype.Int4{Int32: int32(total), Valid: total > 0}
+}
@@ -0,0 +1,94 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/safe"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestProgress(t *testing.T) {
+	// Вне операции прогресса нет, вызовы сервиса ничего не делают
+	outside := ProgressFromContext(context.Background())
+	if outside != nil {
+		t.Fatalf("ProgressFromContext вне операции = %v, ожидался nil", outside)
+	}
+	outside.SetTotal(10)
+	outside.Add(1)
+
+	p := &Progress{}
+	ctx := WithProgress(context.Background(), p)
+	ProgressFromContext(ctx).SetTotal(3)
+	ProgressFromContext(ctx).Add(2)
+	if done, total := p.snapshot(); done != 2 || total != 3 {
+		t.Errorf("snapshot() = %d, %d, ожидалось 2, 3", done, total)
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	notFound := apperrors.NotFound("USER_NOT_FOUND", "пользователь не найден")
+
+	tests := []struct {
+		name      string
+		err       error
+		wantCode  string
+		wantError string
+	}{
+		{"ошибка предметной области", fmt.Errorf("%w: 42", notFound), "USER_NOT_FOUND", "пользователь не найден: 42"},
+		{"валидация", &validation.Error{Fields: map[string]string{"email": "обязательное поле"}}, "VALIDATION_ERROR", "Ошибка валидации данных"},
+		{"паника", &safe.PanicError{Component: "operation:import_users", Value: "секрет"}, "INTERNAL_ERROR", "внутренняя ошибка сервера"},
+		{"прочее", errors.New("соединение потеряно"), "INTERNAL_ERROR", "соединение потеряно"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ErrorResponse(tt.err)
+			if got.Code != tt.wantCode || got.Error != tt.wantError {
+				t.Errorf("ErrorResponse() = %+v, ожидалось %s: %s", got, tt.wantCode, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestToResponse(t *testing.T) {
+	created := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	op := repository.Operation{
+		ID:            uuid.New(),
+		Kind:          KindImportUsers,
+		Status:        StatusFailed,
+		ProgressDone:  7,
+		ProgressTotal: pgtype.Int4{Int32: 10, Valid: true},
+		Result:        json.RawMessage(`{"imported":7}`),
+		Error:         pgtype.Text{String: "Ошибка валидации данных", Valid: true},
+		ErrorCode:     pgtype.Text{String: "VALIDATION_ERROR", Valid: true},
+		ErrorDetails:  json.RawMessage(`{"email":"обязательное поле"}`),
+		CreatedAt:     created,
+		CompletedAt:   pgtype.Timestamp{Time: created.Add(time.Minute), Valid: true},
+	}
+
+	resp := toResponse(op)
+	if resp.Progress.Done != 7 || resp.Progress.Total == nil || *resp.Progress.Total != 10 {
+		t.Errorf("Progress = %+v", resp.Progress)
+	}
+	// У неудачной операции нет результата, только ошибка
+	if resp.Result != nil || resp.Error == nil || resp.Error.Details["email"] != "обязательное поле" {
+		t.Errorf("Result = %s, Error = %+v", resp.Result, resp.Error)
+	}
+	if resp.StartedAt != nil || resp.CompletedAt == nil {
+		t.Errorf("StartedAt = %v, CompletedAt = %v", resp.StartedAt, resp.CompletedAt)
+	}
+
+	op.Status, op.ProgressTotal = StatusRunning, pgtype.Int4{}
+	if resp := toResponse(op); resp.Result != nil || resp.Error != nil || resp.Progress.Total != nil {
+		t.Errorf("выполняемая операция: %+v", resp)
+	}
+}
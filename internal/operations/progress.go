@@ -0,0 +1,55 @@
+package operations
+
+import (
+	"context"
+	"sync"
+)
+
+// progressKey - ключ контекста с прогрессом операции
+type progressKey struct{}
+
+// Progress - прогресс выполняемой операции
+// Методы nil Progress ничего не делают: сервис вызывает их, не проверяя,
+// выполняется ли он в операции
+type Progress struct {
+	mu          sync.Mutex
+	done, total int
+}
+
+// WithProgress возвращает контекст с прогрессом операции
+func WithProgress(ctx context.Context, p *Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// ProgressFromContext возвращает прогресс операции, nil - вне операции
+func ProgressFromContext(ctx context.Context) *Progress {
+	p, _ := ctx.Value(progressKey{}).(*Progress)
+	return p
+}
+
+// SetTotal задает общее число записей, когда оно известно заранее
+func (p *Progress) SetTotal(total int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.total = total
+	p.mu.Unlock()
+}
+
+// Add отмечает n обработанных записей
+func (p *Progress) Add(n int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.done += n
+	p.mu.Unlock()
+}
+
+// snapshot возвращает обработанное и общее число записей (0 - неизвестно)
+func (p *Progress) snapshot() (done, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.done, p.total
+}
@@ -0,0 +1,223 @@
+// Package origins - источники (Origin), которым разрешены кросс-доменные
+// запросы из браузера (CORS).
+//
+// Источники задаются переменной CORS_ALLOW_ORIGINS и через admin API:
+// отдельные источники (таблица cors_origins) и собственные клиенты -
+// фронтенды со своими списками источников (trusted_clients). Проверка
+// в CORS middleware не обращается к БД: Registry держит снимок в памяти,
+// перечитывает его каждые SETTINGS_REFRESH_INTERVAL и сразу после
+// изменения через admin API на том инстансе, который его принял.
+package origins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+)
+
+// ErrInvalidOrigin возвращается Normalize для строки, которая не является origin
+var ErrInvalidOrigin = errors.New("origin должен иметь вид https://app.example.com или https://*.example.com")
+
+// wildcardPrefix - начало хоста шаблона поддоменов
+const wildcardPrefix = "*."
+
+// Normalize проверяет origin и приводит его к виду заголовка Origin:
+// схема http или https, хост в нижнем регистре, порт, без пути и параметров
+//
+// Хост может начинаться с "*." - шаблон любых поддоменов:
+// https://*.example.com разрешает https://preview-42.example.com,
+// но не https://example.com
+func Normalize(origin string) (string, error) {
+	u, err := url.Parse(strings.ToLower(strings.TrimSpace(origin)))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.User != nil || u.Opaque != "" || (u.Path != "" && u.Path != "/") ||
+		u.RawQuery != "" || u.Fragment != "" || u.ForceQuery {
+		return "", ErrInvalidOrigin
+	}
+
+	host := u.Hostname()
+	if rest, ok := strings.CutPrefix(host, wildcardPrefix); ok {
+		// Шаблон на весь домен верхнего уровня (*.com) слишком широкий
+		if !strings.Contains(rest, ".") {
+			return "", ErrInvalidOrigin
+		}
+		host = rest
+	}
+	if host == "" || strings.ContainsAny(host, "*_ ") {
+		return "", ErrInvalidOrigin
+	}
+
+	// Браузер не передает в Origin порт по умолчанию
+	hostport := u.Host
+	if port := u.Port(); (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		hostport = strings.TrimSuffix(hostport, ":"+port)
+	}
+	return u.Scheme + "://" + hostport, nil
+}
+
+// wildcard - шаблон поддоменов: "https://" + любые поддомены + suffix
+type wildcard struct {
+	prefix string // Схема: "https://"
+	suffix string // Домен с точкой и портом: ".example.com:8443"
+}
+
+// match сообщает, подходит ли origin под шаблон
+func (w wildcard) match(origin string) bool {
+	if len(origin) <= len(w.prefix)+len(w.suffix) ||
+		!strings.HasPrefix(origin, w.prefix) || !strings.HasSuffix(origin, w.suffix) {
+		return false
+	}
+	sub := origin[len(w.prefix) : len(origin)-len(w.suffix)]
+	return !strings.ContainsAny(sub, "/:@")
+}
+
+// snapshot - разрешенные источники, заменяется целиком при перезагрузке
+type snapshot struct {
+	exact     map[string]struct{}
+	wildcards []wildcard
+}
+
+// add добавляет нормализованный origin или шаблон
+func (s *snapshot) add(origin string) {
+	scheme, host, _ := strings.Cut(origin, "://")
+	if rest, ok := strings.CutPrefix(host, wildcardPrefix); ok {
+		s.wildcards = append(s.wildcards, wildcard{prefix: scheme + "://", suffix: "." + rest})
+		return
+	}
+	s.exact[origin] = struct{}{}
+}
+
+// allowed сообщает, разрешен ли origin
+func (s *snapshot) allowed(origin string) bool {
+	if _, ok := s.exact[origin]; ok {
+		return true
+	}
+	for _, w := range s.wildcards {
+		if w.match(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry хранит разрешенные источники
+// Allowed не обращается к БД и безопасен из любых горутин
+type Registry struct {
+	queries *repository.Queries
+
+	// mu упорядочивает замену снимка: Load и SetStatic собирают его
+	// из источников конфигурации и последних строк БД
+	mu     sync.Mutex
+	static []string // Из CORS_ALLOW_ORIGINS, нормализованные
+	stored []string // Из БД (cors_origins и trusted_clients), как есть
+
+	current atomic.Pointer[snapshot]
+}
+
+// New создает реестр с источниками из конфигурации
+// Источники из БД подгружает Load
+func New(queries *repository.Queries, static []string) (*Registry, error) {
+	r := &Registry{queries: queries}
+	if err := r.SetStatic(static); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetStatic заменяет источники из конфигурации (CORS_ALLOW_ORIGINS)
+// Источники из БД сохраняются. При ошибке действуют прежние источники
+func (r *Registry) SetStatic(static []string) error {
+	normalized := make([]string, 0, len(static))
+	for _, origin := range static {
+		n, err := Normalize(origin)
+		if err != nil {
+			return fmt.Errorf("CORS_ALLOW_ORIGINS: %s: %w", origin, err)
+		}
+		normalized = append(normalized, n)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.static = normalized
+	r.current.Store(r.build())
+	return nil
+}
+
+// Load перечитывает источники и клиентов из БД
+// Невалидные строки пропускаются с предупреждением, как в settings
+func (r *Registry) Load(ctx context.Context) error {
+	origins, err := r.queries.ListCORSOrigins(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки источников CORS: %w", err)
+	}
+	clients, err := r.queries.ListTrustedClients(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки клиентов: %w", err)
+	}
+	r.setStored(origins, clients)
+	return nil
+}
+
+// setStored заменяет источники из БД
+func (r *Registry) setStored(origins []repository.CorsOrigin, clients []repository.TrustedClient) {
+	stored := make([]string, 0, len(origins))
+	for _, row := range origins {
+		stored = append(stored, row.Origin)
+	}
+	for _, client := range clients {
+		stored = append(stored, client.Origins...)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stored = stored
+	r.current.Store(r.build())
+}
+
+// build собирает снимок из конфигурации и строк БД, вызывается под mu
+func (r *Registry) build() *snapshot {
+	snap := &snapshot{exact: make(map[string]struct{}, len(r.static)+len(r.stored))}
+	for _, origin := range r.static {
+		snap.add(origin)
+	}
+	for _, origin := range r.stored {
+		normalized, err := Normalize(origin)
+		if err != nil {
+			slog.Warn("⚠️  Невалидный источник CORS в БД пропущен", "origin", origin)
+			continue
+		}
+		snap.add(normalized)
+	}
+	return snap
+}
+
+// Allowed сообщает, разрешены ли запросы с origin (значение заголовка Origin)
+func (r *Registry) Allowed(origin string) bool {
+	return r.current.Load().allowed(strings.ToLower(origin))
+}
+
+// RunRefresher перечитывает источники каждые interval, пока не отменен ctx
+// При ошибке продолжают действовать прежние источники
+func (r *Registry) RunRefresher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Load(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("❌ Ошибка обновления источников CORS", "error", err)
+			}
+		}
+	}
+}
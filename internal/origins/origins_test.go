@@ -0,0 +1,113 @@
+package origins
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+)
+
+func TestNormalize(t *testing.T) {
+	valid := []struct {
+		input string
+		want  string
+	}{
+		{"https://app.example.com", "https://app.example.com"},
+		{" HTTPS://App.Example.com/ ", "https://app.example.com"},
+		{"http://localhost:5173", "http://localhost:5173"},
+		{"https://app.example.com:443", "https://app.example.com"},
+		{"http://app.example.com:80", "http://app.example.com"},
+		{"https://app.example.com:8443", "https://app.example.com:8443"},
+		{"https://*.example.com", "https://*.example.com"},
+	}
+	for _, tt := range valid {
+		got, err := Normalize(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"*",
+		"app.example.com",
+		"ftp://app.example.com",
+		"https://app.example.com/path",
+		"https://app.example.com?q=1",
+		"https://user@app.example.com",
+		"https://*.com",
+		"https://app.*.example.com",
+		"null",
+	}
+	for _, input := range invalid {
+		if got, err := Normalize(input); !errors.Is(err, ErrInvalidOrigin) {
+			t.Errorf("Normalize(%q) = %q, %v, want ErrInvalidOrigin", input, got, err)
+		}
+	}
+}
+
+func TestRegistryAllowed(t *testing.T) {
+	r, err := New(nil, []string{"http://localhost:5173"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	// Источники из БД без обращения к ней: как после Load
+	r.setStored(
+		[]repository.CorsOrigin{{Origin: "https://partner.example.org"}, {Origin: "garbage"}},
+		[]repository.TrustedClient{{Name: "web", Origins: []string{"https://app.example.com", "https://*.preview.example.com"}}},
+	)
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"http://localhost:5173", true},
+		{"https://partner.example.org", true},
+		{"https://app.example.com", true},
+		{"https://APP.example.com", true},
+		{"https://pr-42.preview.example.com", true},
+		{"https://a.b.preview.example.com", true},
+		{"https://preview.example.com", false},
+		{"http://pr-42.preview.example.com", false},
+		{"https://evil-preview.example.com", false},
+		{"https://app.example.com.evil.com", false},
+		{"https://app.example.com:8443", false},
+		{"http://localhost:3000", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := r.Allowed(tt.origin); got != tt.want {
+			t.Errorf("Allowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+
+	if _, err := New(nil, []string{"localhost:5173"}); err == nil {
+		t.Error("New с невалидным источником: ожидалась ошибка")
+	}
+}
+
+func TestRegistrySetStaticKeepsStored(t *testing.T) {
+	r, err := New(nil, []string{"http://localhost:5173"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	r.setStored([]repository.CorsOrigin{{Origin: "https://partner.example.org"}}, nil)
+
+	if err := r.SetStatic([]string{"https://app.example.com"}); err != nil {
+		t.Fatalf("SetStatic: %v", err)
+	}
+	if r.Allowed("http://localhost:5173") {
+		t.Error("прежний источник из конфигурации остался разрешен")
+	}
+	if !r.Allowed("https://app.example.com") || !r.Allowed("https://partner.example.org") {
+		t.Error("после SetStatic должны быть разрешены новые источники и источники из БД")
+	}
+
+	// Невалидный список не применяется
+	if err := r.SetStatic([]string{"app.example.com"}); err == nil {
+		t.Fatal("SetStatic с невалидным источником: ожидалась ошибка")
+	}
+	if !r.Allowed("https://app.example.com") {
+		t.Error("после ошибки SetStatic должны действовать прежние источники")
+	}
+}
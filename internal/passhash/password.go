@@ -0,0 +1,103 @@
+// Package passhash - хеширование паролей (bcrypt) с метриками длительности.
+//
+// Стоимость новых хешей задается при запуске: BCRYPT_COST или калибровкой
+// (Calibrate), которая подбирает наибольшую стоимость, укладывающуюся
+// в PASSWORD_HASH_TARGET_MS на текущем железе. Хеши со старой стоимостью
+// продолжают проверяться: bcrypt хранит стоимость в самом хеше.
+package passhash
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+)
+
+// algorithm - метка алгоритма в метриках
+const algorithm = "bcrypt"
+
+// cost - стоимость новых хешей
+var cost atomic.Int32
+
+func init() {
+	_ = SetCost(bcrypt.DefaultCost)
+}
+
+// SetCost задает стоимость новых хешей
+// Вызывается при запуске, до обработки запросов
+func SetCost(value int) error {
+	if value < bcrypt.MinCost || value > bcrypt.MaxCost {
+		return fmt.Errorf("стоимость bcrypt должна быть от %d до %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	cost.Store(int32(value))
+	metrics.PasswordHashCost.WithLabelValues(algorithm).Set(float64(value))
+	return nil
+}
+
+// Cost возвращает стоимость новых хешей
+func Cost() int {
+	return int(cost.Load())
+}
+
+// Hash хеширует пароль с текущей стоимостью
+func Hash(password string) ([]byte, error) {
+	start := time.Now()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), Cost())
+	observe("hash", start)
+	return hash, err
+}
+
+// Compare сравнивает пароль с хешем за постоянное время
+// Возвращает ошибку, если пароль не подходит
+func Compare(hash []byte, password string) error {
+	start := time.Now()
+	err := bcrypt.CompareHashAndPassword(hash, []byte(password))
+	observe("compare", start)
+	return err
+}
+
+// observe записывает длительность операции в метрики
+func observe(operation string, start time.Time) {
+	metrics.PasswordHashDuration.WithLabelValues(algorithm, operation).Observe(time.Since(start).Seconds())
+}
+
+// Calibrate подбирает наибольшую стоимость от minCost до maxCost, при которой
+// хеширование укладывается в target, и возвращает ее вместе с замеренной
+// длительностью. Стоимость не опускается ниже minCost, даже если
+// железо не укладывается в target: это нижняя граница безопасности.
+//
+// Каждый шаг стоимости удваивает время, поэтому следующая стоимость
+// не замеряется, если заведомо не уложится: калибровка занимает
+// не больше 2*target сверх замера minCost
+func Calibrate(target time.Duration, minCost, maxCost int) (int, time.Duration, error) {
+	if minCost < bcrypt.MinCost || maxCost > bcrypt.MaxCost || minCost > maxCost {
+		return 0, 0, fmt.Errorf("диапазон стоимости bcrypt %d..%d вне %d..%d", minCost, maxCost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	sample := make([]byte, 32)
+	if _, err := rand.Read(sample); err != nil {
+		return 0, 0, fmt.Errorf("ошибка генерации пароля для калибровки: %w", err)
+	}
+
+	chosen, measured := minCost, time.Duration(0)
+	for c := minCost; c <= maxCost; c++ {
+		start := time.Now()
+		if _, err := bcrypt.GenerateFromPassword(sample, c); err != nil {
+			return 0, 0, fmt.Errorf("ошибка калибровки bcrypt: %w", err)
+		}
+		elapsed := time.Since(start)
+
+		if c > minCost && elapsed > target {
+			break
+		}
+		chosen, measured = c, elapsed
+		if elapsed*2 > target {
+			break
+		}
+	}
+	return chosen, measured, nil
+}
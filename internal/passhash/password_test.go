@@ -0,0 +1,62 @@
+package passhash
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashAndCompare(t *testing.T) {
+	if err := SetCost(bcrypt.MinCost); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = SetCost(bcrypt.DefaultCost) }()
+
+	hash, err := Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if got, _ := bcrypt.Cost(hash); got != bcrypt.MinCost {
+		t.Errorf("стоимость хеша %d, ожидалась %d", got, bcrypt.MinCost)
+	}
+	if err := Compare(hash, "correct horse"); err != nil {
+		t.Errorf("верный пароль не подошел: %v", err)
+	}
+	if err := Compare(hash, "wrong"); err == nil {
+		t.Error("неверный пароль подошел")
+	}
+}
+
+func TestSetCostRejectsOutOfRange(t *testing.T) {
+	if err := SetCost(bcrypt.MaxCost + 1); err == nil {
+		t.Error("ожидалась ошибка для стоимости вне диапазона")
+	}
+	if Cost() != bcrypt.DefaultCost {
+		t.Errorf("стоимость изменилась после ошибки: %d", Cost())
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	// Бюджет меньше любого замера: остается нижняя граница
+	got, _, err := Calibrate(time.Nanosecond, bcrypt.MinCost, bcrypt.MinCost+2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != bcrypt.MinCost {
+		t.Errorf("стоимость %d, ожидалась нижняя граница %d", got, bcrypt.MinCost)
+	}
+
+	// Щедрый бюджет: верхняя граница
+	got, _, err = Calibrate(time.Minute, bcrypt.MinCost, bcrypt.MinCost+2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != bcrypt.MinCost+2 {
+		t.Errorf("стоимость %d, ожидалась верхняя граница %d", got, bcrypt.MinCost+2)
+	}
+
+	if _, _, err := Calibrate(time.Second, 12, 10); err == nil {
+		t.Error("ожидалась ошибка для пустого диапазона")
+	}
+}
@@ -0,0 +1,69 @@
+// Package phone нормализует телефонные номера и коды стран профиля.
+//
+// Номера хранятся в БД в каноническом формате E.164 ("+79123456789"),
+// поэтому одинаковые номера, введенные по-разному ("8 (912) 345-67-89",
+// "+7 912 345 67 89"), совпадают при поиске и сравнении. Разбор и проверка
+// выполняются по метаданным libphonenumber (порт библиотеки Google).
+// Страна хранится кодом ISO 3166-1 alpha-2 в верхнем регистре ("RU").
+package phone
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ttacon/libphonenumber"
+)
+
+// ErrInvalidNumber возвращается для номера, который нельзя разобрать
+// или который не существует в плане нумерации страны
+var ErrInvalidNumber = errors.New("невалидный номер телефона")
+
+// ErrCountryRequired возвращается для номера без международного кода,
+// если страна не указана
+var ErrCountryRequired = errors.New("номер без кода страны (+) требует указания страны")
+
+// ErrInvalidCountry возвращается для неизвестного кода страны
+var ErrInvalidCountry = errors.New("невалидный код страны, ожидается ISO 3166-1 alpha-2")
+
+// Normalize приводит номер к формату E.164
+//
+// Номер с "+" разбирается как международный. Номер без "+" считается
+// национальным номером страны country (ISO 3166-1 alpha-2), например
+// "8 912 345-67-89" для RU
+func Normalize(number, country string) (string, error) {
+	number = strings.TrimSpace(number)
+	if number == "" {
+		return "", ErrInvalidNumber
+	}
+
+	region := ""
+	if !strings.HasPrefix(number, "+") {
+		if country == "" {
+			return "", ErrCountryRequired
+		}
+		normalized, err := NormalizeCountry(country)
+		if err != nil {
+			return "", err
+		}
+		region = normalized
+	}
+
+	parsed, err := libphonenumber.Parse(number, region)
+	if err != nil || !libphonenumber.IsValidNumber(parsed) {
+		return "", ErrInvalidNumber
+	}
+
+	return libphonenumber.Format(parsed, libphonenumber.E164), nil
+}
+
+// NormalizeCountry проверяет код страны и приводит его к верхнему регистру
+func NormalizeCountry(country string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(country))
+	if len(code) != 2 {
+		return "", ErrInvalidCountry
+	}
+	if _, ok := libphonenumber.GetSupportedRegions()[code]; !ok {
+		return "", ErrInvalidCountry
+	}
+	return code, nil
+}
@@ -0,0 +1,189 @@
+package publicid
+
+import (
+	"errors"
+	"strings"
+)
+
+// Параметры алгоритма hashids (https://hashids.org), совместимые с
+// библиотеками для других языков: ID, закодированный здесь, декодирует
+// hashids.js или go-hashids с той же солью и алфавитом по умолчанию
+const (
+	hashidsAlphabet   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890"
+	hashidsSeparators = "cfhistuCFHISTU"
+	hashidsSepDiv     = 3.5
+	hashidsGuardDiv   = 12
+)
+
+// Hashids кодирует ID алгоритмом hashids с солью
+// Без знания соли по строке нельзя восстановить число и получить соседний ID
+type Hashids struct {
+	salt      []byte
+	minLength int
+	alphabet  []byte
+	seps      []byte
+	guards    []byte
+}
+
+// NewHashids подготавливает алфавиты hashids для соли salt
+// minLength - минимальная длина строки, короткие дополняются
+func NewHashids(salt string, minLength int) (*Hashids, error) {
+	if salt == "" {
+		return nil, errors.New("соль hashids не может быть пустой")
+	}
+	if minLength < 0 {
+		return nil, errors.New("минимальная длина hashids не может быть отрицательной")
+	}
+
+	h := &Hashids{salt: []byte(salt), minLength: minLength}
+
+	// Разделители - только те, что есть в алфавите, и не входят в него
+	alphabet := []byte(hashidsAlphabet)
+	var seps []byte
+	for _, c := range []byte(hashidsSeparators) {
+		if i := strings.IndexByte(string(alphabet), c); i >= 0 {
+			seps = append(seps, c)
+			alphabet = append(alphabet[:i:i], alphabet[i+1:]...)
+		}
+	}
+	shuffle(seps, h.salt)
+
+	if len(seps) == 0 || float64(len(alphabet))/float64(len(seps)) > hashidsSepDiv {
+		sepsLength := int(ceilDiv(float64(len(alphabet)), hashidsSepDiv))
+		if sepsLength == 1 {
+			sepsLength++
+		}
+		if sepsLength > len(seps) {
+			diff := sepsLength - len(seps)
+			seps = append(seps, alphabet[:diff]...)
+			alphabet = alphabet[diff:]
+		} else {
+			seps = seps[:sepsLength]
+		}
+	}
+	shuffle(alphabet, h.salt)
+
+	guardCount := int(ceilDiv(float64(len(alphabet)), hashidsGuardDiv))
+	if len(alphabet) < 3 {
+		h.guards, seps = seps[:guardCount], seps[guardCount:]
+	} else {
+		h.guards, alphabet = alphabet[:guardCount], alphabet[guardCount:]
+	}
+	h.alphabet, h.seps = alphabet, seps
+	return h, nil
+}
+
+// Encode кодирует неотрицательный ID
+func (h *Hashids) Encode(id int64) string {
+	alphabet := append([]byte(nil), h.alphabet...)
+	numbersHash := id % 100
+
+	lottery := alphabet[numbersHash%int64(len(alphabet))]
+	result := []byte{lottery}
+
+	buffer := append(append([]byte{lottery}, h.salt...), alphabet...)
+	shuffle(alphabet, buffer[:len(alphabet)])
+	result = append(result, hash(id, alphabet)...)
+
+	// Дополнение до минимальной длины: сначала охранные символы,
+	// затем половины перемешанного алфавита с обеих сторон
+	if len(result) < h.minLength {
+		guard := h.guards[(numbersHash+int64(result[0]))%int64(len(h.guards))]
+		result = append([]byte{guard}, result...)
+		if len(result) < h.minLength {
+			guard = h.guards[(numbersHash+int64(result[2]))%int64(len(h.guards))]
+			result = append(result, guard)
+		}
+	}
+	half := len(alphabet) / 2
+	for len(result) < h.minLength {
+		shuffle(alphabet, append([]byte(nil), alphabet...))
+		result = append(append(append([]byte(nil), alphabet[half:]...), result...), alphabet[:half]...)
+		if excess := len(result) - h.minLength; excess > 0 {
+			result = result[excess/2 : excess/2+h.minLength]
+		}
+	}
+	return string(result)
+}
+
+// Decode восстанавливает ID из строки Encode
+// Строка, которую Encode не мог выдать (чужая соль, несколько чисел,
+// опечатка), - ошибка
+func (h *Hashids) Decode(s string) (int64, error) {
+	// Охранные символы отделяют дополнение от значения
+	marked := []byte(s)
+	for i, c := range marked {
+		if strings.IndexByte(string(h.guards), c) >= 0 {
+			marked[i] = ' '
+		}
+	}
+	parts := strings.Split(string(marked), " ")
+	value := parts[0]
+	if len(parts) == 2 || len(parts) == 3 {
+		value = parts[1]
+	}
+	if len(value) < 2 || strings.ContainsAny(value[1:], string(h.seps)) {
+		return 0, ErrInvalid
+	}
+
+	alphabet := append([]byte(nil), h.alphabet...)
+	lottery := value[0]
+	buffer := append(append([]byte{lottery}, h.salt...), alphabet...)
+	shuffle(alphabet, buffer[:len(alphabet)])
+
+	id, ok := unhash(value[1:], alphabet)
+	if !ok || h.Encode(id) != s {
+		return 0, ErrInvalid
+	}
+	return id, nil
+}
+
+// shuffle - детерминированная перестановка alphabet по salt (алгоритм hashids)
+func shuffle(alphabet, salt []byte) {
+	if len(salt) == 0 {
+		return
+	}
+	for i, v, p := len(alphabet)-1, 0, 0; i > 0; i, v = i-1, v+1 {
+		v %= len(salt)
+		n := int(salt[v])
+		p += n
+		j := (n + v + p) % i
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+	}
+}
+
+// hash записывает число в системе счисления по алфавиту
+func hash(n int64, alphabet []byte) []byte {
+	base := int64(len(alphabet))
+	var result []byte
+	for {
+		result = append([]byte{alphabet[n%base]}, result...)
+		n /= base
+		if n == 0 {
+			return result
+		}
+	}
+}
+
+// unhash - обратное hash; ok = false для символа вне алфавита или переполнения
+func unhash(s string, alphabet []byte) (int64, bool) {
+	base := int64(len(alphabet))
+	var n int64
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(string(alphabet), s[i])
+		if digit < 0 || n > (1<<63-1-int64(digit))/base {
+			return 0, false
+		}
+		n = n*base + int64(digit)
+	}
+	return n, true
+}
+
+// ceilDiv - деление с округлением вверх
+func ceilDiv(a, b float64) float64 {
+	q := a / b
+	if q != float64(int(q)) {
+		return float64(int(q) + 1)
+	}
+	return q
+}
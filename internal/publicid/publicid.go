@@ -0,0 +1,140 @@
+// Package publicid переводит целочисленные ID таблиц во внешний вид на
+// границе API - в путях роутов и полях ответов.
+//
+// Пользователи адресуются UUID (public_id), а у остальных сущностей
+// (вебхуки, выгрузки, рассылки, аудит) в API торчит последовательный
+// SERIAL: по нему видно число записей и легко перебрать соседние.
+// Инсталляции, которые не могут перевести таблицы на UUID, включают
+// обратимую обфускацию (hashids) - в базе ID остаются числами.
+//
+// Кодек выбирается один раз при старте (Use) и действует везде:
+// поля моделей типа ID кодируются в JSON, handlers разбирают параметры
+// пути через Parse, ссылки собираются через Format. По умолчанию
+// кодек Plain - ID отдаются числами, как раньше.
+package publicid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+// Имена кодеков для APP_ID_ENCODING
+const (
+	EncodingPlain   = "plain"
+	EncodingHashids = "hashids"
+)
+
+// ErrInvalid - строка не является ID текущего кодека
+var ErrInvalid = errors.New("некорректный идентификатор")
+
+// Codec переводит внутренний ID во внешний и обратно
+type Codec interface {
+	Encode(id int64) string
+	Decode(s string) (int64, error)
+}
+
+// Plain - кодек без обфускации: ID в десятичной записи
+type Plain struct{}
+
+// Encode возвращает десятичную запись ID
+func (Plain) Encode(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// Decode разбирает десятичную запись ID
+func (Plain) Decode(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 0 {
+		return 0, ErrInvalid
+	}
+	return id, nil
+}
+
+// New создает кодек по имени из конфигурации
+// secret и minLength используются только hashids
+func New(encoding, secret string, minLength int) (Codec, error) {
+	switch encoding {
+	case EncodingPlain:
+		return Plain{}, nil
+	case EncodingHashids:
+		if secret == "" {
+			return nil, errors.New("APP_ID_SECRET обязателен при APP_ID_ENCODING=hashids")
+		}
+		return NewHashids(secret, minLength)
+	default:
+		return nil, fmt.Errorf("APP_ID_ENCODING должен быть одним из: %s, %s", EncodingPlain, EncodingHashids)
+	}
+}
+
+// codecHolder - обертка для atomic.Value: значение всегда одного типа
+type codecHolder struct{ Codec }
+
+var current atomic.Value
+
+func init() {
+	current.Store(codecHolder{Plain{}})
+}
+
+// Use задает кодек для всего процесса; вызывается при старте
+func Use(codec Codec) {
+	current.Store(codecHolder{codec})
+}
+
+// Current возвращает действующий кодек
+func Current() Codec {
+	return current.Load().(codecHolder).Codec
+}
+
+// Obfuscated сообщает, что ID отдаются строками, а не числами
+func Obfuscated() bool {
+	_, plain := Current().(Plain)
+	return !plain
+}
+
+// ID - целочисленный ID таблицы в модели ответа
+// В JSON - число для Plain и строка для остальных кодеков
+type ID int64
+
+// String возвращает внешний вид ID
+func (id ID) String() string {
+	return Current().Encode(int64(id))
+}
+
+// MarshalJSON кодирует ID действующим кодеком
+func (id ID) MarshalJSON() ([]byte, error) {
+	if !Obfuscated() {
+		return strconv.AppendInt(nil, int64(id), 10), nil
+	}
+	return json.Marshal(id.String())
+}
+
+// UnmarshalJSON принимает и число, и строку кодека
+func (id *ID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		s = string(data)
+	}
+	decoded, err := Current().Decode(s)
+	if err != nil {
+		return err
+	}
+	*id = ID(decoded)
+	return nil
+}
+
+// Parse разбирает ID из параметра пути
+func Parse(s string) (int, error) {
+	id, err := Current().Decode(s)
+	if err != nil || int64(int(id)) != id {
+		return 0, ErrInvalid
+	}
+	return int(id), nil
+}
+
+// Format возвращает внешний вид ID для путей и ссылок
+func Format(id int) string {
+	return Current().Encode(int64(id))
+}
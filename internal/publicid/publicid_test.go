@@ -0,0 +1,87 @@
+package publicid
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHashidsVectors(t *testing.T) {
+	// Значения эталонной реализации hashids.js
+	h, err := NewHashids("this is my salt", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	padded, err := NewHashids("this is my salt", 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		codec *Hashids
+		id    int64
+		want  string
+	}{
+		{h, 12345, "NkK9"},
+		{h, 1, "NV"},
+		{padded, 1, "gB0NV05e"},
+	}
+	for _, tc := range tests {
+		got := tc.codec.Encode(tc.id)
+		if got != tc.want {
+			t.Errorf("Encode(%d) = %q, ожидалось %q", tc.id, got, tc.want)
+		}
+		decoded, err := tc.codec.Decode(got)
+		if err != nil || decoded != tc.id {
+			t.Errorf("Decode(%q) = %d, %v", got, decoded, err)
+		}
+	}
+}
+
+func TestHashidsRejectsForeign(t *testing.T) {
+	h, _ := NewHashids("this is my salt", 8)
+	other, _ := NewHashids("another salt", 8)
+
+	for id := int64(0); id < 2000; id++ {
+		if decoded, err := h.Decode(h.Encode(id)); err != nil || decoded != id {
+			t.Fatalf("ID %d не восстановился: %d, %v", id, decoded, err)
+		}
+	}
+	for _, s := range []string{"", "1", "42", "gB0NV05", "gB0NV05ex", other.Encode(1), "!@#$%^&*"} {
+		if id, err := h.Decode(s); err == nil {
+			t.Errorf("Decode(%q) = %d, ожидалась ошибка", s, id)
+		}
+	}
+}
+
+func TestIDJSON(t *testing.T) {
+	type payload struct {
+		ID ID `json:"id"`
+	}
+
+	data, _ := json.Marshal(payload{ID: 7})
+	if string(data) != `{"id":7}` {
+		t.Errorf("Plain: %s", data)
+	}
+
+	h, _ := NewHashids("test salt", 6)
+	Use(h)
+	t.Cleanup(func() { Use(Plain{}) })
+
+	data, _ = json.Marshal(payload{ID: 7})
+	want := `{"id":"` + h.Encode(7) + `"}`
+	if string(data) != want {
+		t.Errorf("Hashids: %s, ожидалось %s", data, want)
+	}
+	var decoded payload
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.ID != 7 {
+		t.Errorf("Unmarshal(%s) = %d, %v", data, decoded.ID, err)
+	}
+
+	// Последовательный номер при включенной обфускации не принимается
+	if _, err := Parse("7"); err == nil {
+		t.Error("Parse(\"7\") принял число без кодирования")
+	}
+	if id, err := Parse(Format(7)); err != nil || id != 7 {
+		t.Errorf("Parse(Format(7)) = %d, %v", id, err)
+	}
+}
@@ -0,0 +1,254 @@
+// Package query содержит общий разбор параметров списков из query string:
+// пагинацию, сортировку и фильтры.
+//
+// Каждый новый ресурс со списком (заказы, посты и т.д.) использует эти
+// функции вместо копирования логики ListUsers, поэтому все списки API
+// одинаково понимают page, page_size, sort и фильтры и одинаково
+// сообщают об ошибках.
+package query
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+)
+
+// ErrInvalidParam возвращается для невалидного параметра списка
+// Handlers отвечают на нее 400 INVALID_QUERY_PARAMS
+var ErrInvalidParam = errors.New("невалидный параметр запроса")
+
+// PageOptions задает размеры страницы для конкретного списка
+type PageOptions struct {
+	DefaultSize int // Размер страницы, если page_size не передан или вне диапазона
+	MaxSize     int // Наибольший допустимый page_size
+}
+
+// Page - номер и размер запрошенной страницы
+type Page struct {
+	Number int // Номер страницы, начиная с 1
+	Size   int // Размер страницы
+}
+
+// Offset возвращает смещение для SQL запроса
+// Например: страница 2, размер 10 -> offset = (2-1) * 10 = 10
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.Size
+}
+
+// ParsePage разбирает page и page_size
+//
+// Нечисловое значение - ошибка ErrInvalidParam. Значения вне диапазона
+// не считаются ошибкой и заменяются: page < 1 на 1, page_size вне
+// [1, MaxSize] на DefaultSize. Затем размер уменьшается до предела
+// уровня доступа вызывающего (см. Quota)
+func ParsePage(c *fiber.Ctx, opts PageOptions) (Page, error) {
+	page := Page{Number: 1, Size: opts.DefaultSize}
+
+	if raw := c.Query("page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return Page{}, fmt.Errorf("%w: page", ErrInvalidParam)
+		}
+		page.Number = n
+	}
+	if raw := c.Query("page_size"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return Page{}, fmt.Errorf("%w: page_size", ErrInvalidParam)
+		}
+		page.Size = n
+	}
+
+	if page.Number < 1 {
+		page.Number = 1
+	}
+	if page.Size < 1 || page.Size > opts.MaxSize {
+		page.Size = opts.DefaultSize
+	}
+	page.Size = limitSize(c, page.Size)
+
+	return page, nil
+}
+
+// CursorPage - запрошенная страница в режиме курсора (keyset пагинация)
+type CursorPage struct {
+	Cursor string // Непрозрачный токен из next_cursor, пустой - первая страница
+	Limit  int    // Размер страницы
+}
+
+// IsCursorMode сообщает, запрошена ли пагинация курсором
+// Режим включают параметры cursor или limit (первая страница: ?limit=50),
+// без них список работает по page/page_size
+func IsCursorMode(c *fiber.Ctx) bool {
+	args := c.Context().QueryArgs()
+	return args.Has("cursor") || args.Has("limit")
+}
+
+// ParseCursorPage разбирает cursor и limit
+//
+// Смешивать режимы нельзя: page или page_size вместе с курсором - ошибка
+// ErrInvalidParam. limit вне [1, MaxSize] заменяется на DefaultSize
+// и ограничивается уровнем доступа, как page_size в ParsePage
+func ParseCursorPage(c *fiber.Ctx, opts PageOptions) (CursorPage, error) {
+	if c.Query("page") != "" || c.Query("page_size") != "" {
+		return CursorPage{}, fmt.Errorf("%w: page и page_size нельзя передавать вместе с cursor и limit", ErrInvalidParam)
+	}
+
+	page := CursorPage{Cursor: c.Query("cursor"), Limit: opts.DefaultSize}
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return CursorPage{}, fmt.Errorf("%w: limit", ErrInvalidParam)
+		}
+		page.Limit = n
+	}
+	if page.Limit < 1 || page.Limit > opts.MaxSize {
+		page.Limit = opts.DefaultSize
+	}
+	page.Limit = limitSize(c, page.Limit)
+
+	return page, nil
+}
+
+// EncodeCursor упаковывает позицию в списке в непрозрачный токен
+// Клиенты не должны разбирать токен: формат позиции - дело сервиса
+func EncodeCursor(position any) (string, error) {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return "", fmt.Errorf("ошибка кодирования курсора: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor распаковывает токен EncodeCursor в position
+// Поврежденный или чужой токен - ошибка ErrInvalidParam
+func DecodeCursor(token string, position any) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return fmt.Errorf("%w: cursor", ErrInvalidParam)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(position); err != nil {
+		return fmt.Errorf("%w: cursor", ErrInvalidParam)
+	}
+	return nil
+}
+
+// PageMeta - навигация по списку для ответа API
+type PageMeta struct {
+	TotalCount int
+	TotalPages int
+	HasNext    bool
+	HasPrev    bool
+}
+
+// NewPageMeta рассчитывает навигацию по общему количеству записей
+func NewPageMeta(page Page, totalCount int) PageMeta {
+	totalPages := totalCount / page.Size
+	if totalCount%page.Size != 0 {
+		totalPages++
+	}
+
+	return PageMeta{
+		TotalCount: totalCount,
+		TotalPages: totalPages,
+		// Страница за пределами списка (page > total_pages) тоже имеет
+		// предыдущую страницу, но не имеет следующей
+		HasNext: page.Number < totalPages,
+		HasPrev: page.Number > 1,
+	}
+}
+
+// Sort - поле и направление сортировки
+// F - тип поля конкретного ресурса, чтобы поле одного списка
+// нельзя было по ошибке передать в запрос другого
+type Sort[F ~string] struct {
+	Field F
+	Desc  bool
+}
+
+// ParseSort разбирает параметр sort в формате "field" (по возрастанию),
+// "-field" (по убыванию) или "field:asc" / "field:desc". Допустимы только
+// поля из allowed, без параметра возвращается def.
+//
+// Поле сверяется со списком, поэтому в SQL попадает только известное
+// значение F, а не строка клиента. Если уровень доступа не разрешает
+// сортировку, переданный sort - ошибка ErrNotAllowed
+func ParseSort[F ~string](c *fiber.Ctx, allowed []F, def Sort[F]) (Sort[F], error) {
+	raw := c.Query("sort")
+	if raw == "" {
+		return def, nil
+	}
+	if err := checkFilter(c, "sort"); err != nil {
+		return Sort[F]{}, err
+	}
+
+	sort := Sort[F]{}
+	if field, direction, ok := strings.Cut(raw, ":"); ok {
+		switch direction {
+		case "asc":
+		case "desc":
+			sort.Desc = true
+		default:
+			return Sort[F]{}, fmt.Errorf("%w: направление сортировки %q, ожидается asc или desc", ErrInvalidParam, direction)
+		}
+		raw = field
+	} else if strings.HasPrefix(raw, "-") {
+		sort.Desc = true
+		raw = raw[1:]
+	}
+
+	for _, field := range allowed {
+		if string(field) == raw {
+			sort.Field = field
+			return sort, nil
+		}
+	}
+
+	return Sort[F]{}, fmt.Errorf("%w: сортировка по %q не поддерживается", ErrInvalidParam, raw)
+}
+
+// Filter разбирает необязательный фильтр name функцией parse
+// Возвращает nil, если параметр не передан. Если уровень доступа
+// не разрешает фильтры, переданный фильтр - ошибка ErrNotAllowed
+func Filter[T any](c *fiber.Ctx, name string, parse func(string) (T, error)) (*T, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	if err := checkFilter(c, name); err != nil {
+		return nil, err
+	}
+
+	value, err := parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidParam, name)
+	}
+	return &value, nil
+}
+
+// ParseDateOrTime парсит дату (YYYY-MM-DD) или время в любом формате utc.Parse
+// и возвращает его в UTC. Подходит как parse для Filter по датам
+func ParseDateOrTime(value string) (time.Time, error) {
+	return utc.Parse(value)
+}
+
+// ParseText возвращает строку без пробелов по краям для Filter по тексту (поиск)
+func ParseText(value string) (string, error) {
+	return strings.TrimSpace(value), nil
+}
+
+// ParseBool парсит true/false, 1/0 для Filter по флагам
+func ParseBool(value string) (bool, error) {
+	return strconv.ParseBool(value)
+}
@@ -0,0 +1,60 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrNotAllowed возвращается для фильтра или сортировки, недоступных
+// вызывающему по его уровню доступа (см. Quota)
+// Handlers отвечают на нее 403 QUERY_NOT_ALLOWED
+var ErrNotAllowed = errors.New("параметр списка недоступен для вашего уровня доступа")
+
+// Quota - ограничения списков для уровня лимитов вызывающего (пакет tiers)
+//
+// Ограничения действуют поверх PageOptions конкретного списка: список
+// не может отдать больше своего MaxSize, а уровень доступа - больше
+// MaxPageSize, даже если список позволяет
+type Quota struct {
+	// MaxPageSize - наибольший page_size и limit; 0 - без ограничения уровня
+	// Больший размер страницы уменьшается до MaxPageSize, а не отклоняется
+	MaxPageSize int
+
+	// Filters разрешает фильтры и сортировку (Filter, ParseSort)
+	// Без них доступен только порядок и состав списка по умолчанию
+	Filters bool
+}
+
+// quotaKey - ключ ограничений запроса в Locals
+type quotaKey struct{}
+
+// SetQuota задает ограничения списков для запроса
+// Вызывается middleware.ListQuota по уровню лимитов вызывающего;
+// без него (например в тестах) списки не ограничены
+func SetQuota(c *fiber.Ctx, quota Quota) {
+	c.Locals(quotaKey{}, quota)
+}
+
+// quotaOf возвращает ограничения запроса, ok = false - ограничений нет
+func quotaOf(c *fiber.Ctx) (Quota, bool) {
+	quota, ok := c.Locals(quotaKey{}).(Quota)
+	return quota, ok
+}
+
+// limitSize уменьшает размер страницы до предела уровня доступа
+func limitSize(c *fiber.Ctx, size int) int {
+	if quota, ok := quotaOf(c); ok && quota.MaxPageSize > 0 && size > quota.MaxPageSize {
+		return quota.MaxPageSize
+	}
+	return size
+}
+
+// checkFilter проверяет, что уровень доступа разрешает параметр name
+func checkFilter(c *fiber.Ctx, name string) error {
+	if quota, ok := quotaOf(c); ok && !quota.Filters {
+		return fmt.Errorf("%w: %s", ErrNotAllowed, name)
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+// Package redact скрывает и маскирует поля JSON ответов (email, телефон)
+// по роли и тенанту вызывающего.
+//
+// Политика задается один раз в конфигурации (RESPONSE_REDACTION) и
+// применяется централизованно: к JSON телу ответа (middleware.Redact),
+// к ответам gRPC и к выгрузкам CSV и NDJSON (Copy), поэтому handlers
+// не должны помнить, кому какие поля отдавать.
+//
+// Формат политики - правила через ";", в правиле субъект и поля:
+//
+//	*:phone=mask; user:email=mask,phone=hide; tenant/acme:email=hide
+//
+// Субъект - "*" (все вызывающие, включая анонимных), имя роли или
+// tenant/<id>. Действие: mask - значение заменяется маской
+// (i***@example.com), hide - поле удаляется из ответа. Вызывающему
+// достаются правила "*", его роли и тенанта; если поле есть в нескольких,
+// действует более строгое (hide). Поля ищутся по имени на любой глубине.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Action - что сделать с полем
+type Action string
+
+// Действия с полем
+const (
+	Mask Action = "mask" // Заменить значение маской
+	Hide Action = "hide" // Удалить поле
+)
+
+// tenantPrefix - префикс субъекта правила для тенанта
+const tenantPrefix = "tenant/"
+
+// Rules - действия по имени поля
+type Rules map[string]Action
+
+// Policy - правила по субъектам
+// Нулевое значение и nil - политика без правил
+type Policy struct {
+	all     Rules
+	roles   map[string]Rules
+	tenants map[string]Rules
+}
+
+// Parse разбирает политику из строки конфигурации
+// Пустая строка - политика без правил
+func Parse(spec string) (*Policy, error) {
+	p := &Policy{roles: make(map[string]Rules), tenants: make(map[string]Rules)}
+
+	for _, rule := range strings.Split(spec, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		subject, fields, ok := strings.Cut(rule, ":")
+		subject = strings.TrimSpace(subject)
+		if !ok || subject == "" || subject == tenantPrefix {
+			return nil, fmt.Errorf("RESPONSE_REDACTION: правило %q: ожидается <роль|tenant/<id>|*>:<поле>=<mask|hide>", rule)
+		}
+
+		rules := make(Rules)
+		for _, field := range strings.Split(fields, ",") {
+			name, action, _ := strings.Cut(field, "=")
+			name = strings.TrimSpace(name)
+			action = strings.TrimSpace(action)
+			if name == "" || (Action(action) != Mask && Action(action) != Hide) {
+				return nil, fmt.Errorf("RESPONSE_REDACTION: правило %q: поле %q: ожидается <поле>=<mask|hide>", rule, strings.TrimSpace(field))
+			}
+			rules[name] = Action(action)
+		}
+
+		switch {
+		case subject == "*":
+			p.all = merge(p.all, rules)
+		case strings.HasPrefix(subject, tenantPrefix):
+			tenant := strings.TrimPrefix(subject, tenantPrefix)
+			p.tenants[tenant] = merge(p.tenants[tenant], rules)
+		default:
+			p.roles[subject] = merge(p.roles[subject], rules)
+		}
+	}
+	return p, nil
+}
+
+// Empty сообщает, что в политике нет правил
+func (p *Policy) Empty() bool {
+	return p == nil || (len(p.all) == 0 && len(p.roles) == 0 && len(p.tenants) == 0)
+}
+
+// For возвращает правила для вызывающего с ролью role и тенантом tenant
+// Пустые role и tenant (анонимный запрос) получают только правила "*".
+// nil - полей для скрытия нет
+func (p *Policy) For(role, tenant string) Rules {
+	if p.Empty() {
+		return nil
+	}
+	rules := merge(nil, p.all)
+	if role != "" {
+		rules = merge(rules, p.roles[role])
+	}
+	if tenant != "" {
+		rules = merge(rules, p.tenants[tenant])
+	}
+	return rules
+}
+
+// merge добавляет правила from в to, оставляя более строгое действие
+func merge(to, from Rules) Rules {
+	if len(from) == 0 {
+		return to
+	}
+	if to == nil {
+		to = make(Rules, len(from))
+	}
+	for field, action := range from {
+		if to[field] != Hide {
+			to[field] = action
+		}
+	}
+	return to
+}
+
+// Apply применяет правила к JSON телу ответа
+//
+// Порядок полей и форматирование значений сохраняются. self - публичный ID
+// вызывающего: объект с таким id - его собственная запись, и ее поля
+// не скрываются (пользователь видит свой email в профиле). Пустой self -
+// исключений нет. Ошибка - тело не является JSON
+func Apply(body []byte, rules Rules, self string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(len(body))
+	if err := rewrite(&buf, body, rules, self); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Copy возвращает копию v с примененными правилами - для ответов,
+// которые отдаются не JSON телом (gRPC, выгрузки CSV и NDJSON)
+//
+// v проходит через JSON, как в ответе API: скрытое поле в копии нулевое,
+// маска строки - строка маски, маска нестроки - нулевое значение.
+// Поля без JSON имени (json:"-") в копии тоже нулевые. Собственную
+// запись вызывающего определяет тот, кто вызывает Copy
+func Copy[T any](v *T, rules Rules) (*T, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	body, err = Apply(body, rules, "")
+	if err != nil {
+		return nil, err
+	}
+	redacted := new(T)
+	if err := json.Unmarshal(body, redacted); err != nil {
+		return nil, err
+	}
+	return redacted, nil
+}
+
+// rewrite записывает значение raw в buf, применяя правила к вложенным объектам
+func rewrite(buf *bytes.Buffer, raw []byte, rules Rules, self string) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return fmt.Errorf("пустое значение JSON")
+	}
+
+	switch raw[0] {
+	case '{':
+		return rewriteObject(buf, raw, rules, self)
+	case '[':
+		return rewriteArray(buf, raw, rules, self)
+	default:
+		if !json.Valid(raw) {
+			return fmt.Errorf("некорректное значение JSON")
+		}
+		buf.Write(raw)
+		return nil
+	}
+}
+
+// member - поле объекта с необработанным значением
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+// rewriteObject записывает объект, скрывая и маскируя поля по правилам
+func rewriteObject(buf *bytes.Buffer, raw []byte, rules Rules, self string) error {
+	// 1. Читаем поля в исходном порядке
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	var members []member
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		members = append(members, member{key: key, value: value})
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	// 2. Собственная запись вызывающего не скрывается
+	own := self != "" && isOwn(members, self)
+
+	// 3. Записываем поля; вложенные объекты обрабатываются всегда
+	buf.WriteByte('{')
+	first := true
+	for _, m := range members {
+		action := rules[m.key]
+		if own {
+			action = ""
+		}
+		if action == Hide {
+			continue
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		key, _ := json.Marshal(m.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		if action == Mask {
+			buf.Write(maskValue(m.value))
+			continue
+		}
+		if err := rewrite(buf, m.value, rules, self); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// rewriteArray записывает массив, обрабатывая каждый элемент
+func rewriteArray(buf *bytes.Buffer, raw []byte, rules Rules, self string) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	buf.WriteByte('[')
+	for i := 0; dec.More(); i++ {
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := rewrite(buf, value, rules, self); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// isOwn проверяет, что id объекта совпадает с публичным ID вызывающего
+// Username для этого не подходит: его можно сменить на прежнее имя
+// другого пользователя, а ID записи постоянен
+func isOwn(members []member, self string) bool {
+	for _, m := range members {
+		if m.key != "id" {
+			continue
+		}
+		var id string
+		return json.Unmarshal(m.value, &id) == nil && id == self
+	}
+	return false
+}
+
+// maskValue возвращает маску значения в виде JSON
+// null остается null; числа, объекты и массивы не маскируются
+// посимвольно и заменяются на null
+func maskValue(raw json.RawMessage) []byte {
+	var value *string
+	if err := json.Unmarshal(raw, &value); err != nil || value == nil {
+		return []byte("null")
+	}
+	masked, _ := json.Marshal(MaskString(*value))
+	return masked
+}
+
+// MaskString маскирует строку, оставляя часть, по которой владелец
+// узнает значение: ivan@example.com -> i***@example.com,
+// +79123456789 -> +7********89. Строки до 4 символов маскируются целиком
+func MaskString(value string) string {
+	if local, domain, ok := strings.Cut(value, "@"); ok && local != "" {
+		runes := []rune(local)
+		return string(runes[0]) + "***@" + domain
+	}
+
+	runes := []rune(value)
+	if len(runes) <= 4 {
+		return "***"
+	}
+	return string(runes[:2]) + strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-2:])
+}
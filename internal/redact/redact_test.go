@@ -0,0 +1,133 @@
+package redact
+
+import (
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"email=mask",
+		"user:email",
+		"user:email=drop",
+		"tenant/:email=hide",
+		"user:=mask",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q): ожидалась ошибка", spec)
+		}
+	}
+
+	p, err := Parse(" ; ")
+	if err != nil || !p.Empty() {
+		t.Errorf("Parse пустой политики: %v, Empty = %v", err, p.Empty())
+	}
+}
+
+func TestPolicyFor(t *testing.T) {
+	p, err := Parse("*:phone=mask; user:email=mask,phone=hide; tenant/acme:email=hide")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		role, tenant string
+		want         Rules
+	}{
+		{"", "", Rules{"phone": Mask}},
+		{"admin", "", Rules{"phone": Mask}},
+		{"user", "", Rules{"email": Mask, "phone": Hide}},
+		{"user", "acme", Rules{"email": Hide, "phone": Hide}},
+	}
+	for _, tc := range tests {
+		got := p.For(tc.role, tc.tenant)
+		if len(got) != len(tc.want) {
+			t.Errorf("For(%q, %q) = %v, ожидалось %v", tc.role, tc.tenant, got, tc.want)
+			continue
+		}
+		for field, action := range tc.want {
+			if got[field] != action {
+				t.Errorf("For(%q, %q) = %v, ожидалось %v", tc.role, tc.tenant, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	rules := Rules{"email": Mask, "phone": Hide, "login_count": Mask}
+	body := `{"data":[` +
+		`{"id":"1","username":"ivan","email":"ivan@example.com","phone":"+79123456789"},` +
+		`{"id":"2","username":"anna","email":"anna@example.com","phone":null,"login_count":7}` +
+		`],"total":2.50}`
+
+	got, err := Apply([]byte(body), rules, "2")
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	// Порядок полей и запись чисел сохраняются, своя запись не скрывается
+	want := `{"data":[` +
+		`{"id":"1","username":"ivan","email":"i***@example.com"},` +
+		`{"id":"2","username":"anna","email":"anna@example.com","phone":null,"login_count":7}` +
+		`],"total":2.50}`
+	if string(got) != want {
+		t.Errorf("Apply:\n%s\nожидалось:\n%s", got, want)
+	}
+
+	got, err = Apply([]byte(`{"login_count":7,"email":null}`), rules, "")
+	if err != nil || string(got) != `{"login_count":null,"email":null}` {
+		t.Errorf("Apply нестроковых значений = %s, %v", got, err)
+	}
+
+	if _, err := Apply([]byte(`{"email":`), rules, ""); err == nil {
+		t.Error("Apply некорректного JSON: ожидалась ошибка")
+	}
+}
+
+func TestApplyMatchesOwnRecordByID(t *testing.T) {
+	rules := Rules{"email": Hide}
+
+	// Другой пользователь взял прежнее имя вызывающего: его запись чужая
+	body := `{"id":"7","username":"ivan","email":"ivan@example.com"}`
+	got, err := Apply([]byte(body), rules, "2")
+	if err != nil || string(got) != `{"id":"7","username":"ivan"}` {
+		t.Errorf("Apply чужой записи с тем же username = %s, %v", got, err)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	type user struct {
+		ID       int     `json:"-"`
+		Email    string  `json:"email"`
+		Phone    *string `json:"phone,omitempty"`
+		Username string  `json:"username"`
+	}
+	phone := "+79123456789"
+	original := &user{ID: 5, Email: "ivan@example.com", Phone: &phone, Username: "ivan"}
+
+	got, err := Copy(original, Rules{"email": Mask, "phone": Hide})
+	if err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	if got.Email != "i***@example.com" || got.Phone != nil || got.Username != "ivan" {
+		t.Errorf("Copy = %+v", got)
+	}
+	// Исходное значение не меняется
+	if original.Email != "ivan@example.com" || original.Phone == nil {
+		t.Errorf("исходное значение изменено: %+v", original)
+	}
+}
+
+func TestMaskString(t *testing.T) {
+	tests := map[string]string{
+		"ivan@example.com": "i***@example.com",
+		"+79123456789":     "+7********89",
+		"Иванов":           "Ив**ов",
+		"abc":              "***",
+		"@example.com":     "@e********om",
+	}
+	for value, want := range tests {
+		if got := MaskString(value); got != want {
+			t.Errorf("MaskString(%q) = %q, ожидалось %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package reference - справочники API (роли, страны), которые меняются
+// только вместе с версией сервиса.
+//
+// Данные лежат в data/<имя>.json и встраиваются go:embed, поэтому запрос
+// справочника не ходит в БД, а его содержимое определяется сборкой.
+// Version - версия данных этой сборки: клиент, запросивший справочник
+// со ссылкой ?v=<Version>, может кешировать ответ бессрочно, остальные
+// перепроверяют его по ETag.
+//
+// Новый справочник - это новый файл в data/: он появляется в индексе
+// и по адресу /api/v1/reference/<имя> без изменений кода.
+package reference
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+//go:embed data/*.json
+var data embed.FS
+
+// Dataset - один справочник
+type Dataset struct {
+	Name string
+	Body []byte // JSON без лишних пробелов
+}
+
+// Catalog - справочники сборки
+type Catalog struct {
+	datasets map[string]Dataset
+	names    []string
+	version  string
+}
+
+// New загружает встроенные справочники
+// Ошибка возможна только при невалидном JSON в data/ и ловится тестами
+func New() (*Catalog, error) {
+	entries, err := data.ReadDir("data")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{datasets: make(map[string]Dataset, len(entries))}
+	for _, entry := range entries {
+		raw, err := data.ReadFile(path.Join("data", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var body bytes.Buffer
+		if err := json.Compact(&body, raw); err != nil {
+			return nil, fmt.Errorf("справочник %s: %w", entry.Name(), err)
+		}
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		c.datasets[name] = Dataset{Name: name, Body: body.Bytes()}
+		c.names = append(c.names, name)
+	}
+	sort.Strings(c.names)
+	c.version = version(c)
+	return c, nil
+}
+
+// Get возвращает справочник по имени
+func (c *Catalog) Get(name string) (Dataset, bool) {
+	dataset, ok := c.datasets[name]
+	return dataset, ok
+}
+
+// Names возвращает имена справочников по алфавиту
+func (c *Catalog) Names() []string {
+	return c.names
+}
+
+// Version возвращает версию справочников этой сборки
+func (c *Catalog) Version() string {
+	return c.version
+}
+
+// version считает версию по ревизии сборки и содержимому справочников
+// Ревизия (vcs.revision) есть, если бинарник собран из git; без нее
+// версия меняется только вместе с данными
+func version(c *Catalog) string {
+	h := sha256.New()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" || setting.Key == "vcs.modified" {
+				fmt.Fprintf(h, "%s=%s\n", setting.Key, setting.Value)
+			}
+		}
+	}
+	for _, name := range c.names {
+		fmt.Fprintf(h, "%s\n", name)
+		h.Write(c.datasets[name].Body)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,57 @@
+package reference
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/phone"
+)
+
+func load(t *testing.T, name string, v any) {
+	t.Helper()
+	catalog, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataset, ok := catalog.Get(name)
+	if !ok {
+		t.Fatalf("справочника %s нет", name)
+	}
+	if err := json.Unmarshal(dataset.Body, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRolesMatchBuiltInRoles(t *testing.T) {
+	var roles []struct {
+		Name string `json:"name"`
+	}
+	load(t, "roles", &roles)
+
+	// Справочник повторяет роли из миграции 000008: расхождение значит,
+	// что роль добавлена в код, но не в data/roles.json
+	want := []string{auth.RoleUser, auth.RoleAdmin}
+	if len(roles) != len(want) {
+		t.Fatalf("ролей в справочнике %d, ожидалось %d", len(roles), len(want))
+	}
+	for i, role := range roles {
+		if role.Name != want[i] {
+			t.Errorf("роль %d = %q, ожидалась %q", i, role.Name, want[i])
+		}
+	}
+}
+
+func TestCountriesAcceptedByProfile(t *testing.T) {
+	var countries []struct {
+		Code string `json:"code"`
+	}
+	load(t, "countries", &countries)
+
+	// Клиент выбирает страну профиля из справочника - API должен ее принять
+	for _, country := range countries {
+		if code, err := phone.NormalizeCountry(country.Code); err != nil || code != country.Code {
+			t.Errorf("код %q не принимается полем country: %v", country.Code, err)
+		}
+	}
+}
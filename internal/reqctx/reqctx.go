@@ -0,0 +1,189 @@
+// Package reqctx хранит значения уровня запроса (пользователь, сервис,
+// тенант, request ID, локаль) с типизированными геттерами и сеттерами.
+//
+// Вместо c.Locals("user") со строковыми ключами и приведением типов
+// в каждом месте middleware и handlers используют функции этого пакета.
+// Ключи неэкспортируемые, поэтому значения нельзя случайно перезаписать
+// или прочитать с неверным типом.
+//
+// Сеттеры пишут значение и в c.Locals, и в c.UserContext(), поэтому
+// сервисы, которые получают только context.Context, читают те же значения
+// через функции *FromContext.
+//
+// Транспорты без fiber.Ctx (gRPC) кладут те же значения в context.Context
+// функциями With*.
+package reqctx
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// key - тип ключей пакета, исключает коллизии с ключами других пакетов
+type key int
+
+const (
+	userKey key = iota
+	tenantKey
+	requestIDKey
+	localeKey
+	clientKey
+	twoFactorKey
+	serviceKey
+)
+
+// DefaultLocale - локаль, если клиент ее не указал
+const DefaultLocale = "ru"
+
+// User описывает аутентифицированного пользователя текущего запроса
+type User struct {
+	ID       int
+	Username string
+	Role     string
+}
+
+// Client описывает клиента, отправившего запрос
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// set сохраняет значение в Locals и в контексте запроса
+func set(c *fiber.Ctx, k key, value interface{}) {
+	c.Locals(k, value)
+	c.SetUserContext(context.WithValue(c.UserContext(), k, value))
+}
+
+// SetUser сохраняет аутентифицированного пользователя
+func SetUser(c *fiber.Ctx, user User) {
+	set(c, userKey, user)
+}
+
+// WithUser возвращает контекст с аутентифицированным пользователем
+func WithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// GetUser возвращает пользователя запроса
+// ok = false, если запрос не аутентифицирован
+func GetUser(c *fiber.Ctx) (User, bool) {
+	user, ok := c.Locals(userKey).(User)
+	return user, ok
+}
+
+// UserFromContext возвращает пользователя из контекста запроса
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userKey).(User)
+	return user, ok
+}
+
+// SetPendingTwoFactor сохраняет ID пользователя, который ввел пароль,
+// но еще не ввел код 2FA. Такой запрос не аутентифицирован: GetUser
+// для него возвращает ok = false
+func SetPendingTwoFactor(c *fiber.Ctx, userID int) {
+	set(c, twoFactorKey, userID)
+}
+
+// PendingTwoFactor возвращает ID пользователя незавершенного входа
+// ok = false, если запрос пришел не с токеном two_factor
+func PendingTwoFactor(c *fiber.Ctx) (int, bool) {
+	userID, ok := c.Locals(twoFactorKey).(int)
+	return userID, ok
+}
+
+// SetService сохраняет имя внутреннего сервиса, отправившего запрос
+// (подпись или клиентский сертификат, см. middleware.RequireService)
+func SetService(c *fiber.Ctx, service string) {
+	set(c, serviceKey, service)
+}
+
+// Service возвращает имя внутреннего сервиса или пустую строку,
+// если запрос пришел не от сервиса
+func Service(c *fiber.Ctx) string {
+	service, _ := c.Locals(serviceKey).(string)
+	return service
+}
+
+// ServiceFromContext возвращает имя внутреннего сервиса из контекста запроса
+func ServiceFromContext(ctx context.Context) string {
+	service, _ := ctx.Value(serviceKey).(string)
+	return service
+}
+
+// SetTenant сохраняет идентификатор тенанта (организации) запроса
+func SetTenant(c *fiber.Ctx, tenant string) {
+	set(c, tenantKey, tenant)
+}
+
+// Tenant возвращает тенанта запроса или пустую строку
+func Tenant(c *fiber.Ctx) string {
+	tenant, _ := c.Locals(tenantKey).(string)
+	return tenant
+}
+
+// TenantFromContext возвращает тенанта из контекста запроса
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey).(string)
+	return tenant
+}
+
+// SetRequestID сохраняет идентификатор запроса
+func SetRequestID(c *fiber.Ctx, requestID string) {
+	set(c, requestIDKey, requestID)
+}
+
+// WithRequestID возвращает контекст с идентификатором запроса
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID возвращает идентификатор запроса или пустую строку
+func RequestID(c *fiber.Ctx) string {
+	requestID, _ := c.Locals(requestIDKey).(string)
+	return requestID
+}
+
+// RequestIDFromContext возвращает идентификатор запроса из контекста
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// SetLocale сохраняет локаль запроса (ru, en)
+func SetLocale(c *fiber.Ctx, locale string) {
+	set(c, localeKey, locale)
+}
+
+// Locale возвращает локаль запроса или DefaultLocale
+func Locale(c *fiber.Ctx) string {
+	if locale, ok := c.Locals(localeKey).(string); ok && locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// LocaleFromContext возвращает локаль из контекста или DefaultLocale
+func LocaleFromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey).(string); ok && locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// SetClient сохраняет адрес и User-Agent клиента
+func SetClient(c *fiber.Ctx, client Client) {
+	set(c, clientKey, client)
+}
+
+// WithClient возвращает контекст с адресом и User-Agent клиента
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientKey, client)
+}
+
+// ClientFromContext возвращает клиента из контекста запроса
+// ok = false вне HTTP запроса (фоновые воркеры)
+func ClientFromContext(ctx context.Context) (Client, bool) {
+	client, ok := ctx.Value(clientKey).(Client)
+	return client, ok
+}
@@ -0,0 +1,436 @@
+package routes
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/signedurl"
+	"github.com/Soundveyve/fiber-backend/pkg/svcauth"
+)
+
+// Document - спецификация OpenAPI 3.0
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+// Info - название и версия API
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Operation - описание метода пути
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+
+	// Требования доступа и уровень лимита как в Spec: их читает
+	// не только человек, но и проверки клиентов и шлюза
+	Scopes []Scope `json:"x-scopes,omitempty"`
+	Tier   Tier    `json:"x-rate-limit-tier,omitempty"`
+
+	// Бюджет времени ответа в миллисекундах, если задан у роута
+	BudgetMs int64 `json:"x-latency-budget-ms,omitempty"`
+}
+
+// Parameter - параметр пути
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody - тело запроса
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response - ответ
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType - схема тела для типа содержимого
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components - общие схемы и способы аутентификации
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme - способ аутентификации
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+}
+
+// Schema - схема JSON значения (подмножество OpenAPI 3.0)
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+
+	// Ограничения значения из тегов validate: по ним клиенты и
+	// контрактные тесты (пакет contract) строят допустимые значения
+	Enum      []string `json:"enum,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	MinLength *int     `json:"minLength,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+	Minimum   *int     `json:"minimum,omitempty"`
+	Maximum   *int     `json:"maximum,omitempty"`
+	MinItems  *int     `json:"minItems,omitempty"`
+	MaxItems  *int     `json:"maxItems,omitempty"`
+}
+
+// Способы аутентификации в components.securitySchemes
+const (
+	securityBearer    = "bearerAuth"
+	securitySignedURL = "signedURL"
+	securityService   = "serviceSignature"
+)
+
+// OpenAPI строит спецификацию по зарегистрированным роутам
+func (r *Registry) OpenAPI(info Info) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   make(map[string]map[string]Operation),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]SecurityScheme{
+				securityBearer:    {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				securitySignedURL: {Type: "apiKey", Name: signedurl.ParamSignature, In: "query"},
+				securityService:   {Type: "apiKey", Name: svcauth.HeaderSignature, In: "header"},
+			},
+		},
+	}
+	schemas := &schemaBuilder{components: doc.Components.Schemas}
+	errorSchema := schemas.of(reflect.TypeOf(models.ErrorResponse{}))
+
+	for _, route := range r.routes {
+		path, params := OpenAPIPath(route.Path)
+		op := Operation{
+			Summary:     route.Summary,
+			Description: route.Description,
+			Tags:        route.Tags,
+			Parameters:  params,
+			Responses:   make(map[string]Response),
+			Scopes:      route.Scopes,
+			Tier:        route.Tier,
+		}
+		if route.Budget > 0 {
+			op.BudgetMs = route.Budget.Milliseconds()
+		}
+
+		// 1. Тело запроса и успешный ответ
+		status := route.Status
+		if status == 0 {
+			status = fiber.StatusOK
+		}
+		success := Response{Description: http.StatusText(status)}
+		if route.Response != nil {
+			success.Content = jsonContent(schemas.of(reflect.TypeOf(route.Response)))
+		}
+		op.Responses[strconv.Itoa(status)] = success
+
+		// 2. Ответы проверок тела, доступа и лимитов
+		errorResponse := func(status int) {
+			op.Responses[strconv.Itoa(status)] = Response{
+				Description: http.StatusText(status),
+				Content:     jsonContent(errorSchema),
+			}
+		}
+		if route.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  jsonContent(schemas.of(reflect.TypeOf(route.Request))),
+			}
+			// Невалидный JSON - 400, нарушение тегов validate - 422
+			errorResponse(fiber.StatusBadRequest)
+			errorResponse(fiber.StatusUnprocessableEntity)
+		}
+		// Ресурса из параметров пути может не быть
+		if len(params) > 0 {
+			errorResponse(fiber.StatusNotFound)
+		}
+		if slices.Contains(route.Scopes, ScopeSignedURL) {
+			op.Security = append(op.Security, map[string][]string{securitySignedURL: {}})
+			errorResponse(fiber.StatusForbidden)
+		}
+		if slices.Contains(route.Scopes, ScopeService) {
+			op.Security = append(op.Security, map[string][]string{securityService: {}})
+			errorResponse(fiber.StatusUnauthorized)
+		}
+		if slices.ContainsFunc(route.Scopes, func(s Scope) bool { return s != ScopeSignedURL && s != ScopeService }) {
+			op.Security = append(op.Security, map[string][]string{securityBearer: {}})
+			errorResponse(fiber.StatusUnauthorized)
+		}
+		if slices.Contains(route.Scopes, ScopeAdmin) || slices.Contains(route.Scopes, ScopeSudo) {
+			errorResponse(fiber.StatusForbidden)
+		}
+		if route.Tier != TierDefault {
+			errorResponse(fiber.StatusTooManyRequests)
+		}
+		for status, model := range route.Responses {
+			if model == nil {
+				errorResponse(status)
+				continue
+			}
+			op.Responses[strconv.Itoa(status)] = Response{
+				Description: http.StatusText(status),
+				Content:     jsonContent(schemas.of(reflect.TypeOf(model))),
+			}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]Operation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+	return doc
+}
+
+// Handler отдает спецификацию в JSON
+// Спецификация строится при первом запросе, когда все роуты уже зарегистрированы
+func (r *Registry) Handler(info Info) fiber.Handler {
+	var once sync.Once
+	var body []byte
+	var err error
+	return func(c *fiber.Ctx) error {
+		once.Do(func() {
+			body, err = json.Marshal(r.OpenAPI(info))
+		})
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(body)
+	}
+}
+
+// OpenAPIPath переводит путь Fiber (/users/:id) в путь OpenAPI (/users/{id})
+// и возвращает параметры пути
+func OpenAPIPath(path string) (string, []Parameter) {
+	segments := strings.Split(path, "/")
+	var params []Parameter
+	for i, segment := range segments {
+		name, ok := strings.CutPrefix(segment, ":")
+		if !ok {
+			continue
+		}
+		name, _, _ = strings.Cut(name, "<") // Ограничения Fiber (:id<int>)
+		name = strings.TrimSuffix(name, "?")
+		segments[i] = "{" + name + "}"
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// jsonContent - тело application/json со схемой
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{fiber.MIMEApplicationJSON: {Schema: schema}}
+}
+
+// schemaBuilder строит схемы по типам Go
+// Именованные структуры попадают в components.schemas и подставляются ссылкой
+type schemaBuilder struct {
+	components map[string]*Schema
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	publicIDType      = reflect.TypeOf(publicid.ID(0))
+	rawMessageType    = reflect.TypeOf(json.RawMessage(nil))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// of возвращает схему значения типа t так, как его сериализует encoding/json
+func (b *schemaBuilder) of(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t, nullable = t.Elem(), true
+	}
+
+	// Типы со своей сериализацией - строки; время (time.Time и utc.Time,
+	// встраивающий time.Time) - в формате date-time
+	if t == timeType || (t.Kind() == reflect.Struct && t.NumField() > 0 && t.Field(0).Anonymous && t.Field(0).Type == timeType) {
+		return &Schema{Type: "string", Format: "date-time", Nullable: nullable}
+	}
+	// ID таблиц - число или строка в зависимости от кодека publicid
+	if t == publicIDType {
+		if publicid.Obfuscated() {
+			return &Schema{Type: "string", Nullable: nullable}
+		}
+		return &Schema{Type: "integer", Format: "int64", Nullable: nullable}
+	}
+	// json.RawMessage - любое значение JSON как есть
+	if t == rawMessageType {
+		return &Schema{Nullable: nullable}
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: "string", Nullable: nullable}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean", Nullable: nullable}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32", Nullable: nullable}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64", Nullable: nullable}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Nullable: nullable}
+	case reflect.String:
+		return &Schema{Type: "string", Nullable: nullable}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte", Nullable: nullable}
+		}
+		return &Schema{Type: "array", Items: b.of(t.Elem()), Nullable: nullable}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.of(t.Elem()), Nullable: nullable}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		// Ссылка записывается до обхода полей: рекурсивный тип ссылается на себя
+		if _, ok := b.components[t.Name()]; !ok {
+			b.components[t.Name()] = &Schema{}
+			*b.components[t.Name()] = *b.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	default:
+		// interface{} - любое значение
+		return &Schema{}
+	}
+}
+
+// object строит схему структуры по тегам json
+// Обязательные поля - с validate:"required", ограничения - см. constrain
+func (b *schemaBuilder) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// Встроенная структура без имени в json - ее поля на том же уровне
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := b.object(field.Type)
+			for key, value := range embedded.Properties {
+				schema.Properties[key] = value
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		rules := strings.Split(field.Tag.Get("validate"), ",")
+		schema.Properties[name] = constrain(b.of(field.Type), rules)
+		if slices.Contains(rules, "required") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// Форматы строк, которые проверяют теги validate
+// country и origin - собственные проверки пакета validation
+var ruleFormats = map[string]string{
+	"email":    "email",
+	"uuid":     "uuid",
+	"http_url": "uri",
+	"country":  "country",
+	"origin":   "origin",
+}
+
+// constrain переносит теги validate поля в ограничения схемы:
+// min/max/len - длина строки, число или размер массива, oneof - enum,
+// numeric - pattern, правила после dive относятся к элементам массива
+// Схемы-ссылки не меняются: у моделей свои ограничения
+func constrain(schema *Schema, rules []string) *Schema {
+	for i, rule := range rules {
+		if schema.Ref != "" {
+			return schema
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "dive" {
+			if schema.Items != nil {
+				constrain(schema.Items, rules[i+1:])
+			}
+			return schema
+		}
+		if format, ok := ruleFormats[name]; ok {
+			schema.Format = format
+			continue
+		}
+
+		n, err := strconv.Atoi(param)
+		switch {
+		case name == "oneof":
+			schema.Enum = strings.Fields(param)
+		case name == "numeric":
+			schema.Pattern = "^[0-9]+$"
+		case err != nil:
+			// Остальные правила схема не описывает
+		case schema.Type == "string" && (name == "min" || name == "len"):
+			schema.MinLength = &n
+			if name == "len" {
+				schema.MaxLength = &n
+			}
+		case schema.Type == "string" && name == "max":
+			schema.MaxLength = &n
+		case schema.Type == "array" && name == "min":
+			schema.MinItems = &n
+		case schema.Type == "array" && name == "max":
+			schema.MaxItems = &n
+		case name == "min":
+			schema.Minimum = &n
+		case name == "max":
+			schema.Maximum = &n
+		}
+	}
+	return schema
+}
@@ -0,0 +1,275 @@
+// Package routes - регистрация роутов с описанием в одном месте.
+//
+// Роут объявляет, кто может его вызвать (Scopes), уровень лимита запросов
+// (Tier), бюджет времени ответа (Budget) и документацию (Summary, тела
+// запроса и ответа). Из этого описания Registry собирает цепочку
+// middleware при регистрации в Fiber и спецификацию OpenAPI
+// (GET /openapi.json). Проверка доступа и документация берутся из одного
+// значения Spec, поэтому не расходятся: роут с ScopeAdmin нельзя
+// зарегистрировать без RequireRole и нельзя описать в спецификации
+// как публичный.
+//
+//	users := registry.Group(api, "/api/v1/users", "Пользователи")
+//	users.Put("/:id/role", userHandler.UpdateUserRole, routes.Spec{
+//		Summary: "Назначение роли",
+//		Scopes:  []routes.Scope{routes.ScopeAdmin, routes.ScopeSudo},
+//		Request: models.UpdateUserRoleRequest{},
+//	})
+//
+// Пути, роуты под которыми не бывают публичными (/admin/v1), перечислены в
+// Policy.Protected: роут без нужного scope не регистрируется.
+//
+// Middleware для scope и уровней задаются в Policy (cmd/api). Порядок
+// цепочки фиксирован: Tier.Before (лимиты, которые считают и
+// неудачные попытки входа), проверки Scopes, монитор массовых чтений
+// (BulkRead), Tier.After (лимиты по пользователю и одновременным
+// запросам), обработчик.
+package routes
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Scope - требование доступа к роуту
+type Scope string
+
+// Требования доступа
+const (
+	ScopeUser      Scope = "user"        // Access токен
+	ScopeAdmin     Scope = "admin"       // Access токен с ролью администратора
+	ScopeSudo      Scope = "sudo"        // Sudo токен (повторный ввод пароля)
+	ScopeTwoFactor Scope = "2fa_pending" // two_factor_token первого шага входа
+	ScopeSignedURL Scope = "signed_url"  // Подпись в ссылке (expires, signature)
+	ScopeService   Scope = "service"     // Внутренний сервис: подпись запроса или mTLS
+)
+
+// scopeOrder - порядок проверок в цепочке: подписи и второй шаг входа
+// не зависят от access токена, роль и sudo проверяются после него
+var scopeOrder = []Scope{ScopeService, ScopeSignedURL, ScopeTwoFactor, ScopeUser, ScopeAdmin, ScopeSudo}
+
+// Tier - уровень лимита запросов сверх общих лимитов группы
+type Tier string
+
+// Уровни лимитов
+const (
+	TierDefault    Tier = ""            // Только общие лимиты группы
+	TierAuth       Tier = "auth"        // Вход, 2FA, письма на чужие адреса
+	TierCreateUser Tier = "create_user" // Создание аккаунтов
+	TierExport     Tier = "export"      // Экспорты: по пользователю, IP и одновременным
+	TierDownload   Tier = "download"    // Скачивание экспорта по ссылке: по IP
+	TierImport     Tier = "import"      // Импорт: одновременные запросы
+	TierAvatar     Tier = "avatar"      // Загрузка аватаров: одновременные запросы
+)
+
+// TierHandlers - middleware уровня лимита
+type TierHandlers struct {
+	Before []fiber.Handler // До проверки доступа
+	After  []fiber.Handler // После проверки доступа
+}
+
+// NoBudget - Spec.Budget роута без бюджета времени: потоковые ответы,
+// импорт и скачивание файлов длятся столько, сколько нужно клиенту
+const NoBudget time.Duration = -1
+
+// Policy - middleware для требований доступа и уровней лимитов
+type Policy struct {
+	Scopes   map[Scope][]fiber.Handler
+	Tiers    map[Tier]TierHandlers
+	BulkRead fiber.Handler // Монитор массовых чтений, nil - не подключается
+
+	// Budget оборачивает обработчик роута и передает бюджет времени
+	// Spec.Budget (middleware.RouteBudget), nil - бюджеты не используются
+	Budget func(budget time.Duration, handler fiber.Handler) fiber.Handler
+
+	// Protected - префиксы путей и scope, без которого роут под префиксом
+	// нельзя зарегистрировать: /admin/v1 - ScopeAdmin. Забытое требование
+	// доступа - panic при запуске, а не публичный роут в спецификации
+	Protected map[string]Scope
+}
+
+// Spec - описание роута
+type Spec struct {
+	Summary     string
+	Description string
+	Tags        []string // Дополнительно к тегам группы
+
+	Scopes   []Scope // Пусто - публичный роут
+	Tier     Tier
+	BulkRead bool // Массовое чтение данных пользователей
+
+	// Budget - допустимое время ответа: более медленные запросы пишутся
+	// в лог с разбивкой по этапам. 0 - бюджет по умолчанию, NoBudget - без бюджета
+	Budget time.Duration
+
+	Request  interface{} // Тело запроса: значение типа модели, nil - без тела
+	Response interface{} // Тело успешного ответа, nil - без схемы
+	Status   int         // Статус успешного ответа, 0 - 200
+
+	// Responses - ответы обработчика, которые не следуют из Scopes, Tier,
+	// Request и параметров пути: статус - модель тела, nil - ErrorResponse
+	Responses map[int]interface{}
+}
+
+// Route - зарегистрированный роут
+type Route struct {
+	Method string
+	Path   string // Полный путь в формате Fiber (/api/v1/users/:id)
+	Tags   []string
+	Spec
+}
+
+// Registry хранит зарегистрированные роуты
+type Registry struct {
+	policy Policy
+	routes []Route
+}
+
+// NewRegistry создает реестр роутов
+func NewRegistry(policy Policy) *Registry {
+	return &Registry{policy: policy}
+}
+
+// Routes возвращает роуты в порядке регистрации
+func (r *Registry) Routes() []Route {
+	return r.routes
+}
+
+// Group - группа роутов с общим префиксом, тегами и требованиями доступа
+type Group struct {
+	registry *Registry
+	router   fiber.Router
+	prefix   string
+	tags     []string
+	scopes   []Scope // Требования каждого роута группы (Require)
+}
+
+// Group возвращает группу поверх router
+// prefix - полный путь router (для спецификации), tags - теги OpenAPI
+func (r *Registry) Group(router fiber.Router, prefix string, tags ...string) *Group {
+	return &Group{registry: r, router: router, prefix: strings.TrimSuffix(prefix, "/"), tags: tags}
+}
+
+// Require возвращает группу, каждый роут которой требует scopes
+// вдобавок к своим Spec.Scopes: в цепочке middleware и в спецификации.
+// Так группа целиком закрывается одной строкой, и роут, в Spec которого
+// требования забыли, не становится публичным
+//
+//	adminRoutes := registry.Group(admin, "/admin/v1").Require(routes.ScopeAdmin)
+func (g *Group) Require(scopes ...Scope) *Group {
+	required := *g
+	required.scopes = append(append([]Scope(nil), g.scopes...), scopes...)
+	return &required
+}
+
+// Get регистрирует GET роут
+func (g *Group) Get(path string, handler fiber.Handler, spec Spec) {
+	g.Add(fiber.MethodGet, path, handler, spec)
+}
+
+// Post регистрирует POST роут
+func (g *Group) Post(path string, handler fiber.Handler, spec Spec) {
+	g.Add(fiber.MethodPost, path, handler, spec)
+}
+
+// Put регистрирует PUT роут
+func (g *Group) Put(path string, handler fiber.Handler, spec Spec) {
+	g.Add(fiber.MethodPut, path, handler, spec)
+}
+
+// Delete регистрирует DELETE роут
+func (g *Group) Delete(path string, handler fiber.Handler, spec Spec) {
+	g.Add(fiber.MethodDelete, path, handler, spec)
+}
+
+// Add регистрирует роут с цепочкой middleware из Policy
+// Scope или уровень без middleware в Policy - ошибка программиста,
+// поэтому panic при запуске, а не незащищенный роут
+func (g *Group) Add(method, path string, handler fiber.Handler, spec Spec) {
+	fullPath := g.prefix + path
+	if path == "/" && g.prefix != "" {
+		fullPath = g.prefix
+	}
+	spec.Scopes = mergeScopes(g.scopes, spec.Scopes)
+	if scope, ok := g.registry.protected(fullPath); ok && !slices.Contains(spec.Scopes, scope) {
+		panic(fmt.Sprintf("роут %s %s: под защищенным префиксом нужен scope %q", method, fullPath, scope))
+	}
+
+	chain, err := g.registry.chain(spec)
+	if err != nil {
+		panic(fmt.Sprintf("роут %s %s: %v", method, fullPath, err))
+	}
+	if g.registry.policy.Budget != nil {
+		handler = g.registry.policy.Budget(spec.Budget, handler)
+	}
+	g.router.Add(method, path, append(chain, handler)...)
+
+	g.registry.routes = append(g.registry.routes, Route{
+		Method: method,
+		Path:   fullPath,
+		Tags:   append(append([]string(nil), g.tags...), spec.Tags...),
+		Spec:   spec,
+	})
+}
+
+// protected возвращает scope, обязательный для пути по Policy.Protected
+func (r *Registry) protected(path string) (Scope, bool) {
+	for prefix, scope := range r.policy.Protected {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return scope, true
+		}
+	}
+	return "", false
+}
+
+// mergeScopes объединяет требования группы и роута без повторов
+func mergeScopes(group, route []Scope) []Scope {
+	if len(group) == 0 {
+		return route
+	}
+	merged := append([]Scope(nil), group...)
+	for _, scope := range route {
+		if !slices.Contains(merged, scope) {
+			merged = append(merged, scope)
+		}
+	}
+	return merged
+}
+
+// chain собирает middleware роута в порядке, описанном в документации пакета
+func (r *Registry) chain(spec Spec) ([]fiber.Handler, error) {
+	tier, ok := r.policy.Tiers[spec.Tier]
+	if !ok && spec.Tier != TierDefault {
+		return nil, fmt.Errorf("уровень лимита %q не задан в Policy", spec.Tier)
+	}
+
+	required := make(map[Scope]bool, len(spec.Scopes))
+	for _, scope := range spec.Scopes {
+		if _, ok := r.policy.Scopes[scope]; !ok {
+			return nil, fmt.Errorf("scope %q не задан в Policy", scope)
+		}
+		required[scope] = true
+	}
+	// Роль и sudo проверяются по пользователю из access токена
+	if required[ScopeAdmin] || required[ScopeSudo] {
+		required[ScopeUser] = true
+	}
+
+	chain := append([]fiber.Handler(nil), tier.Before...)
+	for _, scope := range scopeOrder {
+		if required[scope] {
+			chain = append(chain, r.policy.Scopes[scope]...)
+		}
+	}
+	if spec.BulkRead {
+		if r.policy.BulkRead == nil {
+			return nil, fmt.Errorf("монитор массовых чтений не задан в Policy")
+		}
+		chain = append(chain, r.policy.BulkRead)
+	}
+	return append(chain, tier.After...), nil
+}
@@ -0,0 +1,298 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// mark возвращает middleware, которое дописывает name в заголовок X-Chain
+func mark(name string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Append("X-Chain", name)
+		return c.Next()
+	}
+}
+
+// testPolicy - Policy, в которой каждое middleware только отмечает свой вызов
+var testPolicy = Policy{
+	Scopes: map[Scope][]fiber.Handler{
+		ScopeUser:      {mark("user")},
+		ScopeAdmin:     {mark("admin")},
+		ScopeSudo:      {mark("sudo")},
+		ScopeTwoFactor: {mark("2fa")},
+		ScopeSignedURL: {mark("signed")},
+	},
+	Tiers: map[Tier]TierHandlers{
+		TierAuth:   {Before: []fiber.Handler{mark("auth_limit")}},
+		TierExport: {After: []fiber.Handler{mark("export_user"), mark("export_ip")}},
+	},
+	BulkRead: mark("bulk"),
+}
+
+type testRequest struct {
+	Email string   `json:"email" validate:"required,email"`
+	Note  *string  `json:"note,omitempty"`
+	Tags  []string `json:"tags"`
+}
+
+type testResponse struct {
+	ID      int           `json:"id"`
+	Request testRequest   `json:"request"`
+	Parent  *testResponse `json:"parent,omitempty"`
+}
+
+func TestChainOrder(t *testing.T) {
+	app := fiber.New()
+	registry := NewRegistry(testPolicy)
+	api := registry.Group(app.Group("/api"), "/api")
+
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) }
+	api.Post("/login", ok, Spec{Scopes: []Scope{ScopeTwoFactor}, Tier: TierAuth})
+	api.Get("/export", ok, Spec{Scopes: []Scope{ScopeSudo, ScopeAdmin}, Tier: TierExport, BulkRead: true})
+	api.Get("/public", ok, Spec{})
+
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{fiber.MethodPost, "/api/login", "auth_limit, 2fa"},
+		// admin и sudo подразумевают access токен; порядок не зависит от порядка в Spec
+		{fiber.MethodGet, "/api/export", "user, admin, sudo, bulk, export_user, export_ip"},
+		{fiber.MethodGet, "/api/public", ""},
+	}
+	for _, tc := range tests {
+		resp, err := app.Test(httptest.NewRequest(tc.method, tc.path, nil))
+		if err != nil {
+			t.Fatalf("%s %s: %v", tc.method, tc.path, err)
+		}
+		if got := resp.Header.Get("X-Chain"); got != tc.want {
+			t.Errorf("%s %s: цепочка %q, ожидалась %q", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestGroupRequire(t *testing.T) {
+	app := fiber.New()
+	registry := NewRegistry(testPolicy)
+	admin := registry.Group(app.Group("/admin"), "/admin").Require(ScopeAdmin)
+
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) }
+	admin.Get("/stats", ok, Spec{})
+	admin.Delete("/users", ok, Spec{Scopes: []Scope{ScopeAdmin, ScopeSudo}})
+
+	// Требование группы - и в цепочке, и в описании роута
+	wantChain := map[string]string{"/admin/stats": "user, admin", "/admin/users": "user, admin, sudo"}
+	wantScopes := map[string][]Scope{"/admin/stats": {ScopeAdmin}, "/admin/users": {ScopeAdmin, ScopeSudo}}
+	for _, route := range registry.Routes() {
+		resp, err := app.Test(httptest.NewRequest(route.Method, route.Path, nil))
+		if err != nil {
+			t.Fatalf("%s %s: %v", route.Method, route.Path, err)
+		}
+		if got := resp.Header.Get("X-Chain"); got != wantChain[route.Path] {
+			t.Errorf("%s: цепочка %q, ожидалась %q", route.Path, got, wantChain[route.Path])
+		}
+		if !reflect.DeepEqual(route.Scopes, wantScopes[route.Path]) {
+			t.Errorf("%s: Scopes %v, ожидались %v", route.Path, route.Scopes, wantScopes[route.Path])
+		}
+	}
+}
+
+func TestUnknownPolicyPanics(t *testing.T) {
+	registry := NewRegistry(testPolicy)
+	group := registry.Group(fiber.New(), "")
+	ok := func(c *fiber.Ctx) error { return nil }
+
+	for name, spec := range map[string]Spec{
+		"scope":   {Scopes: []Scope{"billing"}},
+		"уровень": {Tier: TierAvatar},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s без middleware в Policy: ожидался panic", name)
+				}
+			}()
+			group.Get("/x", ok, spec)
+		}()
+	}
+}
+
+func TestProtectedPrefixPanics(t *testing.T) {
+	policy := testPolicy
+	policy.Protected = map[string]Scope{"/admin": ScopeAdmin}
+	registry := NewRegistry(policy)
+	app := fiber.New()
+	ok := func(c *fiber.Ctx) error { return nil }
+
+	// Требование группы или роута - регистрируется; /administrators - не под префиксом
+	registry.Group(app.Group("/admin"), "/admin").Require(ScopeAdmin).Get("/stats", ok, Spec{})
+	registry.Group(app.Group("/admin"), "/admin").Get("/users", ok, Spec{Scopes: []Scope{ScopeAdmin, ScopeSudo}})
+	registry.Group(app, "").Get("/administrators", ok, Spec{})
+
+	for name, spec := range map[string]Spec{
+		"без требований":  {},
+		"без роли admin":  {Scopes: []Scope{ScopeUser}},
+		"подпись запроса": {Scopes: []Scope{ScopeSignedURL}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s под /admin: ожидался panic", name)
+				}
+			}()
+			registry.Group(app.Group("/admin"), "/admin").Get("/public", ok, spec)
+		}()
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	registry := NewRegistry(testPolicy)
+	users := registry.Group(fiber.New().Group("/api/v1/users"), "/api/v1/users", "Пользователи")
+	ok := func(c *fiber.Ctx) error { return nil }
+
+	users.Put("/:id/role", ok, Spec{
+		Summary:  "Назначение роли",
+		Scopes:   []Scope{ScopeAdmin, ScopeSudo},
+		Tier:     TierExport,
+		Request:  testRequest{},
+		Response: testResponse{},
+	})
+	users.Get("/:id/avatar", ok, Spec{Summary: "Аватар"})
+
+	doc := registry.OpenAPI(Info{Title: "test", Version: "v1"})
+
+	op, ok2 := doc.Paths["/api/v1/users/{id}/role"]["put"]
+	if !ok2 {
+		t.Fatalf("нет PUT /api/v1/users/{id}/role: %v", doc.Paths)
+	}
+	if op.Summary != "Назначение роли" || !reflect.DeepEqual(op.Tags, []string{"Пользователи"}) {
+		t.Errorf("summary %q, теги %v", op.Summary, op.Tags)
+	}
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "id" || op.Parameters[0].In != "path" {
+		t.Errorf("параметры %+v", op.Parameters)
+	}
+	if !reflect.DeepEqual(op.Security, []map[string][]string{{securityBearer: {}}}) {
+		t.Errorf("security %v", op.Security)
+	}
+	for _, status := range []string{"200", "400", "401", "403", "404", "422", "429"} {
+		if _, ok := op.Responses[status]; !ok {
+			t.Errorf("нет ответа %s: %v", status, op.Responses)
+		}
+	}
+	if op.Tier != TierExport || len(op.Scopes) != 2 {
+		t.Errorf("x-rate-limit-tier %q, x-scopes %v", op.Tier, op.Scopes)
+	}
+
+	// Схемы моделей - в components, в операции - ссылки
+	if ref := op.RequestBody.Content[fiber.MIMEApplicationJSON].Schema.Ref; ref != "#/components/schemas/testRequest" {
+		t.Errorf("тело запроса %q", ref)
+	}
+	req := doc.Components.Schemas["testRequest"]
+	if req == nil || !reflect.DeepEqual(req.Required, []string{"email"}) || req.Properties["tags"].Type != "array" ||
+		!req.Properties["note"].Nullable {
+		t.Errorf("схема testRequest: %+v", req)
+	}
+	resp := doc.Components.Schemas["testResponse"]
+	if resp == nil || resp.Properties["parent"].Ref != "#/components/schemas/testResponse" {
+		t.Errorf("схема testResponse: %+v", resp)
+	}
+
+	// Публичный роут - без security и ответов 401/403
+	public := doc.Paths["/api/v1/users/{id}/avatar"]["get"]
+	if len(public.Security) != 0 || public.Responses["401"].Description != "" {
+		t.Errorf("публичный роут: %+v", public)
+	}
+
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+}
+
+type constrainedRequest struct {
+	Role  string   `json:"role" validate:"required,oneof=user admin"`
+	Name  string   `json:"name" validate:"omitempty,min=2,max=50"`
+	Phone string   `json:"phone" validate:"omitempty,numeric"`
+	Limit int      `json:"limit" validate:"min=1,max=100"`
+	Tags  []string `json:"tags" validate:"max=3,dive,min=1,max=10"`
+}
+
+func TestOpenAPIConstraints(t *testing.T) {
+	registry := NewRegistry(testPolicy)
+	group := registry.Group(fiber.New(), "")
+	ok := func(c *fiber.Ctx) error { return nil }
+	group.Post("/items", ok, Spec{
+		Request:   constrainedRequest{},
+		Responses: map[int]interface{}{fiber.StatusServiceUnavailable: testResponse{}, fiber.StatusConflict: nil},
+	})
+
+	doc := registry.OpenAPI(Info{Title: "test", Version: "v1"})
+	schema := doc.Components.Schemas["constrainedRequest"]
+	if schema == nil {
+		t.Fatalf("нет схемы constrainedRequest: %v", doc.Components.Schemas)
+	}
+	n := func(v *int) int {
+		if v == nil {
+			return -1
+		}
+		return *v
+	}
+
+	// Теги validate переходят в ограничения схемы, dive - в схему элементов
+	props := schema.Properties
+	if !reflect.DeepEqual(props["role"].Enum, []string{"user", "admin"}) {
+		t.Errorf("role: enum %v", props["role"].Enum)
+	}
+	if n(props["name"].MinLength) != 2 || n(props["name"].MaxLength) != 50 {
+		t.Errorf("name: длина %d..%d", n(props["name"].MinLength), n(props["name"].MaxLength))
+	}
+	if props["phone"].Pattern != "^[0-9]+$" {
+		t.Errorf("phone: pattern %q", props["phone"].Pattern)
+	}
+	if n(props["limit"].Minimum) != 1 || n(props["limit"].Maximum) != 100 {
+		t.Errorf("limit: %d..%d", n(props["limit"].Minimum), n(props["limit"].Maximum))
+	}
+	tags := props["tags"]
+	if n(tags.MaxItems) != 3 || n(tags.Items.MinLength) != 1 || n(tags.Items.MaxLength) != 10 {
+		t.Errorf("tags: элементов до %d, длина элемента %d..%d", n(tags.MaxItems), n(tags.Items.MinLength), n(tags.Items.MaxLength))
+	}
+
+	// Spec.Responses: модель - схема тела, nil - ErrorResponse
+	op := doc.Paths["/items"]["post"]
+	if ref := op.Responses["503"].Content[fiber.MIMEApplicationJSON].Schema.Ref; ref != "#/components/schemas/testResponse" {
+		t.Errorf("503: схема %q", ref)
+	}
+	if ref := op.Responses["409"].Content[fiber.MIMEApplicationJSON].Schema.Ref; ref != "#/components/schemas/ErrorResponse" {
+		t.Errorf("409: схема %q", ref)
+	}
+	// Без параметров пути ответа 404 нет
+	if _, ok := op.Responses["404"]; ok {
+		t.Errorf("404 у роута без параметров: %v", op.Responses)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	app := fiber.New()
+	registry := NewRegistry(testPolicy)
+	group := registry.Group(app, "")
+	group.Get("/openapi.json", registry.Handler(Info{Title: "test", Version: "v1"}), Spec{Summary: "Спецификация"})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/openapi.json", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if ct := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(ct, fiber.MIMEApplicationJSON) {
+		t.Errorf("Content-Type %q", ct)
+	}
+	var doc Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("ответ: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" || doc.Paths["/openapi.json"]["get"].Summary != "Спецификация" {
+		t.Errorf("спецификация %+v", doc)
+	}
+}
@@ -0,0 +1,63 @@
+// Package safe изолирует паники: паника в одной части обработки
+// становится ошибкой этой части, а не роняет запрос или процесс.
+//
+// Обработчик запроса защищен middleware.Recover, но побочные действия
+// после успешного изменения (журнал аудита, события для вебхуков,
+// подписчики журнала) и части составного ответа выполняются отдельно:
+// их паника не должна превращать сохраненное изменение в 500 или ронять
+// горутину, в которой нет recover. Такие участки вызываются через Call
+// или защищаются defer Recover.
+//
+// Каждая паника пишется в лог уровнем error со стеком (попадает в
+// пакет диагностики) и в метрику fiber_backend_panics_total{component}.
+package safe
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+)
+
+// PanicError - паника, перехваченная в компоненте
+// Текст содержит значение паники и не предназначен для клиентов
+type PanicError struct {
+	Component string
+	Value     any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("паника в %s: %v", e.Component, e.Value)
+}
+
+// Report пишет панику value в лог со стеком и в метрику
+// Вызывается из отложенной функции, пока стек паники еще доступен
+func Report(ctx context.Context, component string, value any) *PanicError {
+	metrics.Panics.WithLabelValues(component).Inc()
+	slog.ErrorContext(ctx, "💥 Паника перехвачена",
+		"component", component,
+		"panic", fmt.Sprint(value),
+		"stack", string(debug.Stack()),
+	)
+	return &PanicError{Component: component, Value: value}
+}
+
+// Recover перехватывает панику и записывает ее в *errp (errp может быть
+// nil - тогда паника только пишется в лог)
+// Используется только как defer safe.Recover(ctx, component, &err)
+func Recover(ctx context.Context, component string, errp *error) {
+	if value := recover(); value != nil {
+		err := Report(ctx, component, value)
+		if errp != nil {
+			*errp = err
+		}
+	}
+}
+
+// Call выполняет fn; паника fn возвращается ошибкой *PanicError
+func Call(ctx context.Context, component string, fn func() error) (err error) {
+	defer Recover(ctx, component, &err)
+	return fn()
+}
@@ -0,0 +1,36 @@
+package safe
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCallTurnsPanicIntoError(t *testing.T) {
+	errFailed := errors.New("ошибка подписчика")
+	if err := Call(context.Background(), "test", func() error { return errFailed }); err != errFailed {
+		t.Errorf("Call() = %v, ожидалась ошибка fn", err)
+	}
+
+	err := Call(context.Background(), "test", func() error {
+		var subscribers map[string]func()
+		subscribers["user.created"]()
+		return nil
+	})
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Component != "test" {
+		t.Fatalf("Call() = %v, ожидалась *PanicError", err)
+	}
+}
+
+func TestRecoverWithoutError(t *testing.T) {
+	done := false
+	func() {
+		defer Recover(context.Background(), "test", nil)
+		defer func() { done = true }()
+		panic("событие не сериализуется")
+	}()
+	if !done {
+		t.Error("отложенные функции не выполнены")
+	}
+}
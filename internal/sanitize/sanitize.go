@@ -0,0 +1,180 @@
+// Package sanitize очищает свободный текст (имена, тексты объявлений)
+// от HTML разметки перед сохранением.
+//
+// Поля хранятся и отдаются как обычный текст, но клиенты, вставляющие
+// их в страницу как HTML, не должны получать из API исполняемую разметку.
+// Что делать с разметкой, задает политика (SANITIZE_POLICY): вырезать
+// теги, экранировать их или отклонять запрос с ошибкой валидации.
+package sanitize
+
+import (
+	"errors"
+	"html"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+)
+
+// Policy - способ обработки HTML разметки в тексте
+type Policy string
+
+// Политики очистки
+const (
+	// PolicyStrip вырезает теги, комментарии и содержимое script/style,
+	// оставляя текст: "<b>Иван</b>" -> "Иван"
+	PolicyStrip Policy = "strip"
+
+	// PolicyEscape экранирует спецсимволы HTML: "<b>" -> "&lt;b&gt;"
+	// Для клиентов, которые вставляют значения в страницу без экранирования
+	PolicyEscape Policy = "escape"
+
+	// PolicyReject отклоняет текст с разметкой (422 с ошибкой по полю)
+	PolicyReject Policy = "reject"
+)
+
+// ErrMarkup возвращается политикой reject для текста с разметкой
+var ErrMarkup = errors.New("не должно содержать HTML разметку")
+
+// maxPasses ограничивает повторную очистку текста, в котором разметка
+// спрятана за экранированием (&lt;script&gt; или &amp;lt;script&amp;gt;)
+const maxPasses = 4
+
+// skipContent - элементы, содержимое которых вырезается вместе с тегами:
+// это код и служебная разметка, а не текст для пользователя
+var skipContent = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Textarea: true,
+	atom.Title:    true,
+	atom.Xmp:      true,
+	atom.Noembed:  true,
+	atom.Noframes: true,
+}
+
+// Text применяет политику к значению поля
+// Для PolicyReject возвращает ErrMarkup, если в тексте есть разметка
+func (p Policy) Text(s string) (string, error) {
+	switch p {
+	case PolicyEscape:
+		return html.EscapeString(s), nil
+	case PolicyReject:
+		if HasMarkup(s) {
+			return "", ErrMarkup
+		}
+		return s, nil
+	default:
+		return StripTags(s), nil
+	}
+}
+
+// Fields применяет политику к полям запроса; ключ - имя поля в JSON,
+// nil значения пропускаются. Для PolicyReject возвращает *validation.Error
+// со всеми полями, в которых есть разметка
+func (p Policy) Fields(fields map[string]*string) error {
+	var invalid map[string]string
+	for name, value := range fields {
+		if value == nil {
+			continue
+		}
+		cleaned, err := p.Text(*value)
+		if err != nil {
+			if invalid == nil {
+				invalid = make(map[string]string)
+			}
+			invalid[name] = err.Error()
+			continue
+		}
+		*value = cleaned
+	}
+	if invalid != nil {
+		return &validation.Error{Fields: invalid}
+	}
+	return nil
+}
+
+// StripTags вырезает из текста HTML разметку и декодирует сущности
+//
+// Очистка повторяется, пока текст не перестанет меняться: после декодирования
+// &lt;script&gt; сам становится тегом. Если разметка не исчезла за maxPasses
+// проходов, угловые скобки удаляются
+func StripTags(s string) string {
+	for i := 0; i < maxPasses; i++ {
+		stripped := stripOnce(s)
+		if stripped == s {
+			return s
+		}
+		s = stripped
+	}
+	if HasMarkup(s) {
+		s = strings.NewReplacer("<", "", ">", "").Replace(s)
+	}
+	return s
+}
+
+// HasMarkup сообщает, есть ли в тексте HTML разметка, в том числе
+// скрытая за экранированием
+func HasMarkup(s string) bool {
+	for i := 0; i < maxPasses; i++ {
+		if hasTags(s) {
+			return true
+		}
+		unescaped := html.UnescapeString(s)
+		if unescaped == s {
+			return false
+		}
+		s = unescaped
+	}
+	return true
+}
+
+// stripOnce оставляет только текстовые токены, декодируя сущности
+func stripOnce(s string) string {
+	var b strings.Builder
+	z := xhtml.NewTokenizer(strings.NewReader(s))
+	skipDepth := 0
+
+	for {
+		switch z.Next() {
+		case xhtml.ErrorToken:
+			// io.EOF: strings.Reader не возвращает других ошибок
+			return b.String()
+		case xhtml.TextToken:
+			if skipDepth == 0 {
+				b.Write(z.Text())
+			}
+		case xhtml.StartTagToken:
+			name, _ := z.TagName()
+			if skipContent[atom.Lookup(name)] {
+				skipDepth++
+			}
+		case xhtml.EndTagToken:
+			name, _ := z.TagName()
+			if skipContent[atom.Lookup(name)] && skipDepth > 0 {
+				skipDepth--
+			}
+		}
+		// Комментарии, doctype и самозакрывающиеся теги пропускаются
+	}
+}
+
+// hasTags сообщает, находит ли HTML токенизатор в тексте что-то кроме текста
+func hasTags(s string) bool {
+	z := xhtml.NewTokenizer(strings.NewReader(s))
+	for {
+		switch z.Next() {
+		case xhtml.ErrorToken:
+			return false
+		case xhtml.TextToken:
+			continue
+		default:
+			return true
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package sanitize
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+)
+
+// xssPayloads - типичные XSS векторы и ожидаемый результат PolicyStrip
+var xssPayloads = []struct {
+	name  string
+	input string
+	want  string
+}{
+	{"script", `<script>alert(1)</script>`, ""},
+	{"script после текста", `Иван<script>alert(document.cookie)</script>`, "Иван"},
+	{"незакрытый script", `<script>alert(1)`, ""},
+	{"script в верхнем регистре", `<SCRIPT SRC=//evil.example/x.js></SCRIPT>`, ""},
+	{"img onerror", `<img src=x onerror=alert(1)>`, ""},
+	{"svg onload", `<svg/onload=alert(1)>`, ""},
+	{"iframe", `<iframe src="javascript:alert(1)"></iframe>`, ""},
+	{"javascript ссылка", `<a href="javascript:alert(1)">Петр</a>`, "Петр"},
+	{"style", `<style>body{background:url(javascript:alert(1))}</style>Анна`, "Анна"},
+	{"выход из атрибута", `"><script>alert(1)</script>`, `">`},
+	{"комментарий", `<!--<script>alert(1)</script>-->Мария`, "Мария"},
+	{"форматирование", `<b>Иван</b> <i>Петров</i>`, "Иван Петров"},
+	{"экранированный script", `&lt;script&gt;alert(1)&lt;/script&gt;`, ""},
+	{"дважды экранированный script", `&amp;lt;script&amp;gt;alert(1)&amp;lt;/script&amp;gt;`, ""},
+	{"числовые сущности", `&#60;img src=x onerror=alert(1)&#62;`, ""},
+	// Токенизатор читает "<scr<script>" как один тег, остаток - безопасный текст
+	{"вложенный тег", `<scr<script>ipt>alert(1)</script>`, "ipt>alert(1)"},
+}
+
+// plainNames - обычный текст, который не должен меняться
+var plainNames = []string{
+	"Иван",
+	"O'Brien",
+	"Анна-Мария",
+	"Tom & Jerry",
+	"a < b",
+	"5 > 3",
+	"<3",
+}
+
+func TestStripTagsRemovesXSSPayloads(t *testing.T) {
+	for _, tc := range xssPayloads {
+		t.Run(tc.name, func(t *testing.T) {
+			got := StripTags(tc.input)
+			if got != tc.want {
+				t.Errorf("StripTags(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+			if HasMarkup(got) {
+				t.Errorf("после очистки осталась разметка: %q", got)
+			}
+		})
+	}
+}
+
+func TestStripTagsKeepsPlainText(t *testing.T) {
+	for _, name := range plainNames {
+		if got := StripTags(name); got != name {
+			t.Errorf("StripTags(%q) = %q, текст не должен меняться", name, got)
+		}
+		if HasMarkup(name) {
+			t.Errorf("HasMarkup(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestEscapePolicy(t *testing.T) {
+	for _, tc := range xssPayloads {
+		got, err := PolicyEscape.Text(tc.input)
+		if err != nil {
+			t.Fatalf("PolicyEscape.Text(%q): %v", tc.input, err)
+		}
+		if strings.ContainsAny(got, `<>"'`) {
+			t.Errorf("PolicyEscape.Text(%q) = %q, остались неэкранированные символы", tc.input, got)
+		}
+	}
+}
+
+func TestRejectPolicy(t *testing.T) {
+	for _, tc := range xssPayloads {
+		if _, err := PolicyReject.Text(tc.input); !errors.Is(err, ErrMarkup) {
+			t.Errorf("PolicyReject.Text(%q) error = %v, want ErrMarkup", tc.input, err)
+		}
+	}
+	for _, name := range plainNames {
+		got, err := PolicyReject.Text(name)
+		if err != nil || got != name {
+			t.Errorf("PolicyReject.Text(%q) = %q, %v, want %q, nil", name, got, err, name)
+		}
+	}
+}
+
+func TestFieldsReportsRejectedFields(t *testing.T) {
+	first := `<img src=x onerror=alert(1)>`
+	last := "Петров"
+
+	err := PolicyReject.Fields(map[string]*string{
+		"first_name": &first,
+		"last_name":  &last,
+		"missing":    nil,
+	})
+
+	var validationErr *validation.Error
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("error = %v, want *validation.Error", err)
+	}
+	if _, ok := validationErr.Fields["first_name"]; !ok {
+		t.Errorf("нет ошибки для first_name: %v", validationErr.Fields)
+	}
+	if len(validationErr.Fields) != 1 {
+		t.Errorf("ошибки по полям = %v, want только first_name", validationErr.Fields)
+	}
+}
+
+func TestFieldsStripsInPlace(t *testing.T) {
+	first := `<b>Иван</b><script>alert(1)</script>`
+
+	if err := PolicyStrip.Fields(map[string]*string{"first_name": &first}); err != nil {
+		t.Fatalf("Fields: %v", err)
+	}
+	if first != "Иван" {
+		t.Errorf("first_name = %q, want %q", first, "Иван")
+	}
+}
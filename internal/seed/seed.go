@@ -0,0 +1,252 @@
+// Package seed - детерминированный набор тестовых данных для локальной
+// разработки и интеграционных тестов (fiber-backend seed).
+//
+// У каждой записи фиксированный id, public_id, даты и известный пароль,
+// поэтому "пользователь 42" в описании ошибки - один и тот же аккаунт на
+// любой машине разработчика и в тестах. Набор покрывает роли и все
+// состояния аккаунта: администратор, активный пользователь с заполненным
+// профилем, неподтвержденный, заблокированный, деактивированный, удаленный.
+//
+// Повторный запуск возвращает записи набора в исходное состояние и не
+// трогает остальные. Идентификаторы ниже ReservedIDs отданы набору:
+// последовательности сдвигаются за них, чтобы новые регистрации не
+// получили id будущих записей набора.
+//
+// Новое состояние или поле пользователя, которое нужно воспроизводить,
+// добавляется записью в users с новым постоянным id; id существующих
+// записей не меняются - на них ссылаются тесты и описания ошибок.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/emailaddr"
+	"github.com/Soundveyve/fiber-backend/internal/lifecycle"
+	"github.com/Soundveyve/fiber-backend/internal/passhash"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+)
+
+// Password - пароль всех пользователей набора
+const Password = "password123"
+
+// ReservedIDs - id от 1 до ReservedIDs зарезервированы за набором
+const ReservedIDs = 1000
+
+// Epoch - дата регистрации первого пользователя набора; остальные даты
+// отсчитываются от нее, чтобы ответы API не менялись от запуска к запуску
+var Epoch = time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+// Идентификаторы пользователей набора
+const (
+	AdminID       int32 = 1  // Администратор
+	UserID        int32 = 42 // Активный пользователь с заполненным профилем
+	MinimalID     int32 = 43 // Активный пользователь без имени, телефона и страны
+	UnverifiedID  int32 = 50 // Email не подтвержден
+	SuspendedID   int32 = 51 // Заблокирован администратором
+	DeactivatedID int32 = 52 // Деактивирован
+	DeletedID     int32 = 53 // Мягко удален
+)
+
+// User - пользователь набора
+type User struct {
+	ID            int32
+	Username      string
+	Email         string
+	FirstName     string
+	LastName      string
+	Phone         string // E.164
+	Country       string // ISO 3166-1 alpha-2
+	Role          string
+	Status        lifecycle.Status
+	EmailVerified bool
+	ShowName      bool
+}
+
+// users - пользователи набора в порядке id
+var users = []User{
+	{
+		ID: AdminID, Username: "admin", Email: "admin@example.com",
+		FirstName: "Анна", LastName: "Админова",
+		Role: auth.RoleAdmin, Status: lifecycle.StatusActive, EmailVerified: true,
+	},
+	{
+		ID: UserID, Username: "ivan", Email: "ivan@example.com",
+		FirstName: "Иван", LastName: "Петров", Phone: "+79161234567", Country: "RU",
+		Role: auth.RoleUser, Status: lifecycle.StatusActive, EmailVerified: true, ShowName: true,
+	},
+	{
+		ID: MinimalID, Username: "minimal", Email: "minimal@example.com",
+		Role: auth.RoleUser, Status: lifecycle.StatusActive, EmailVerified: true,
+	},
+	{
+		ID: UnverifiedID, Username: "unverified", Email: "unverified@example.com",
+		FirstName: "Олег", LastName: "Новиков",
+		Role: auth.RoleUser, Status: lifecycle.StatusPendingVerification,
+	},
+	{
+		ID: SuspendedID, Username: "suspended", Email: "suspended@example.com",
+		FirstName: "Степан", LastName: "Блохин",
+		Role: auth.RoleUser, Status: lifecycle.StatusSuspended, EmailVerified: true,
+	},
+	{
+		ID: DeactivatedID, Username: "deactivated", Email: "deactivated@example.com",
+		FirstName: "Дарья", LastName: "Тихонова",
+		Role: auth.RoleUser, Status: lifecycle.StatusDeactivated, EmailVerified: true,
+	},
+	{
+		ID: DeletedID, Username: "deleted", Email: "deleted@example.com",
+		FirstName: "Денис", LastName: "Уходов",
+		Role: auth.RoleUser, Status: lifecycle.StatusDeleted, EmailVerified: true,
+	},
+}
+
+// Users возвращает пользователей набора в порядке id
+func Users() []User {
+	return append([]User(nil), users...)
+}
+
+// Get возвращает пользователя набора по id
+func Get(id int32) (User, bool) {
+	for _, u := range users {
+		if u.ID == id {
+			return u, true
+		}
+	}
+	return User{}, false
+}
+
+// PublicID - постоянный public_id пользователя: id в последних цифрах
+// (пользователь 42 - 00000000-0000-4000-8000-000000000042)
+func (u User) PublicID() uuid.UUID {
+	return uuid.MustParse(fmt.Sprintf("00000000-0000-4000-8000-%012d", u.ID))
+}
+
+// CreatedAt - дата регистрации: Epoch плюс id дней
+func (u User) CreatedAt() time.Time {
+	return Epoch.AddDate(0, 0, int(u.ID))
+}
+
+// Record возвращает строку users, которую создает набор
+// passwordHash - хеш Password (у всех пользователей набора одинаковый)
+// Для тестов, которым нужен пользователь набора без БД
+func (u User) Record(passwordHash string) repository.User {
+	created := u.CreatedAt()
+	record := repository.User{
+		ID:             u.ID,
+		Email:          u.Email,
+		CanonicalEmail: pgtype.Text{String: emailaddr.Canonical(u.Email), Valid: true},
+		Username:       u.Username,
+		PasswordHash:   passwordHash,
+		FirstName:      text(u.FirstName),
+		LastName:       text(u.LastName),
+		Phone:          text(u.Phone),
+		Country:        text(u.Country),
+		Role:           u.Role,
+		Status:         string(u.Status),
+		PublicID:       u.PublicID(),
+		ShowName:       u.ShowName,
+		ShowAvatar:     true,
+		CreatedAt:      created,
+		UpdatedAt:      created,
+	}
+	// Подтверждение - через час после регистрации, удаление - через сутки
+	if u.EmailVerified {
+		record.EmailVerifiedAt = pgtype.Timestamp{Time: created.Add(time.Hour), Valid: true}
+	}
+	if u.Status == lifecycle.StatusDeleted {
+		record.DeletedAt = pgtype.Timestamp{Time: created.AddDate(0, 0, 1), Valid: true}
+		record.UpdatedAt = record.DeletedAt.Time
+	}
+	return record
+}
+
+// text - NULL для пустой строки
+func text(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+// userColumns - колонки users, которые заполняет набор
+var userColumns = []string{
+	"id", "public_id", "email", "canonical_email", "username", "password_hash",
+	"first_name", "last_name", "phone", "country", "role", "status",
+	"email_verified_at", "show_name", "show_avatar", "created_at", "updated_at", "deleted_at",
+}
+
+// upsertUserSQL создает пользователя набора или возвращает его в исходное
+// состояние (кроме id, все колонки перезаписываются)
+var upsertUserSQL = func() string {
+	placeholders := make([]string, len(userColumns))
+	var updates []string
+	for i, column := range userColumns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		if column != "id" {
+			updates = append(updates, column+" = EXCLUDED."+column)
+		}
+	}
+	return "INSERT INTO users (" + strings.Join(userColumns, ", ") + ") VALUES (" +
+		strings.Join(placeholders, ", ") + ") ON CONFLICT (id) DO UPDATE SET " +
+		strings.Join(updates, ", ")
+}()
+
+// userArgs - значения userColumns в том же порядке
+func userArgs(r repository.User) []any {
+	return []any{
+		r.ID, r.PublicID, r.Email, r.CanonicalEmail, r.Username, r.PasswordHash,
+		r.FirstName, r.LastName, r.Phone, r.Country, r.Role, r.Status,
+		r.EmailVerifiedAt, r.ShowName, r.ShowAvatar, r.CreatedAt, r.UpdatedAt, r.DeletedAt,
+	}
+}
+
+// Apply записывает набор в базу одной транзакцией и возвращает число
+// пользователей набора
+//
+// Email или username пользователя набора, занятый пользователем не из
+// набора, - ошибка: набор рассчитан на базу разработчика, а не на копию
+// production (см. anonymize)
+func Apply(ctx context.Context, pool *pgxpool.Pool) (int, error) {
+	hash, err := passhash.Hash(Password)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка хеширования пароля: %w", err)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, u := range users {
+		if _, err := tx.Exec(ctx, upsertUserSQL, userArgs(u.Record(string(hash)))...); err != nil {
+			return 0, fmt.Errorf("пользователь %d (%s): %w", u.ID, u.Username, err)
+		}
+	}
+	if err := reserveIDs(ctx, tx, "users"); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return len(users), nil
+}
+
+// reserveIDs сдвигает последовательность id таблицы за ReservedIDs:
+// записи с явным id ее не продвигают
+func reserveIDs(ctx context.Context, tx pgx.Tx, table string) error {
+	_, err := tx.Exec(ctx, fmt.Sprintf(
+		`SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), GREATEST((SELECT MAX(id) FROM %[1]s), $1))`, table),
+		ReservedIDs)
+	if err != nil {
+		return fmt.Errorf("ошибка сдвига последовательности %s: %w", table, err)
+	}
+	return nil
+}
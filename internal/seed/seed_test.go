@@ -0,0 +1,71 @@
+package seed
+
+import (
+	"testing"
+
+	"github.com/Soundveyve/fiber-backend/internal/lifecycle"
+	"github.com/Soundveyve/fiber-backend/internal/migrations"
+	"github.com/Soundveyve/fiber-backend/internal/phone"
+)
+
+func TestUsersAreConsistent(t *testing.T) {
+	emails := make(map[string]bool)
+	usernames := make(map[string]bool)
+	statuses := make(map[lifecycle.Status]bool)
+	var lastID int32
+
+	for _, u := range Users() {
+		if u.ID <= lastID || u.ID > ReservedIDs {
+			t.Errorf("пользователь %d: id не по возрастанию или вне 1..%d", u.ID, ReservedIDs)
+		}
+		lastID = u.ID
+		if emails[u.Email] || usernames[u.Username] {
+			t.Errorf("пользователь %d: email или username повторяется", u.ID)
+		}
+		emails[u.Email], usernames[u.Username] = true, true
+		statuses[u.Status] = true
+
+		if _, err := lifecycle.Parse(string(u.Status)); err != nil {
+			t.Errorf("пользователь %d: %v", u.ID, err)
+		}
+		if u.Phone != "" {
+			if normalized, err := phone.Normalize(u.Phone, u.Country); err != nil || normalized != u.Phone {
+				t.Errorf("пользователь %d: телефон %q не в E.164 (%v)", u.ID, u.Phone, err)
+			}
+		}
+		// Неподтвержденный email - только у pending_verification
+		if u.EmailVerified == (u.Status == lifecycle.StatusPendingVerification) {
+			t.Errorf("пользователь %d: email_verified не соответствует состоянию %s", u.ID, u.Status)
+		}
+
+		record := u.Record("hash")
+		if record.PublicID != u.PublicID() || record.DeletedAt.Valid != (u.Status == lifecycle.StatusDeleted) {
+			t.Errorf("пользователь %d: запись не соответствует фикстуре: %+v", u.ID, record)
+		}
+	}
+
+	// Набор воспроизводит любое состояние аккаунта
+	for _, status := range lifecycle.Statuses {
+		if !statuses[status] {
+			t.Errorf("в наборе нет пользователя в состоянии %s", status)
+		}
+	}
+	if u, ok := Get(UserID); !ok || u.PublicID().String() != "00000000-0000-4000-8000-000000000042" {
+		t.Errorf("Get(%d) = %+v, %v", UserID, u, ok)
+	}
+}
+
+func TestUserColumnsExist(t *testing.T) {
+	schema, err := migrations.Expected(^uint(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(userColumns) != len(userArgs(users[0].Record("hash"))) {
+		t.Fatal("число колонок не совпадает с числом значений")
+	}
+	for _, column := range userColumns {
+		if !schema.Tables["users"][column] {
+			t.Errorf("колонки users.%s нет в миграциях", column)
+		}
+	}
+}
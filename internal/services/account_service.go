@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/jobs"
+	"github.com/Soundveyve/fiber-backend/internal/lifecycle"
+	"github.com/Soundveyve/fiber-backend/internal/mailer"
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/passhash"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+)
+
+// ErrInvalidAccountToken возвращается для невалидной, просроченной
+// или уже использованной ссылки из письма
+var ErrInvalidAccountToken = apperrors.Invalid("INVALID_ACCOUNT_TOKEN", "ссылка недействительна, истекла или уже использована")
+
+// mailSendTimeout ограничивает отправку одного письма
+const mailSendTimeout = 30 * time.Second
+
+// AccountService отвечает за подтверждение email и сброс пароля
+//
+// Ссылки из писем несут подписанный токен (см. auth.TokenManager),
+// а его jti хранится в user_tokens: так ссылку можно использовать
+// только один раз и аннулировать при выдаче новой.
+type AccountService struct {
+	queries     *repository.Queries
+	tx          Transactor
+	tokens      *auth.TokenManager
+	jobs        *jobs.Queue // Письма отправляются заданиями очереди
+	linkBaseURL string      // Адрес фронтенда для ссылок в письмах
+}
+
+// NewAccountService создает сервис подтверждения email и сброса пароля
+func NewAccountService(queries *repository.Queries, db Beginner, tokens *auth.TokenManager, queue *jobs.Queue, linkBaseURL string) *AccountService {
+	return &AccountService{
+		queries:     queries,
+		tx:          NewTransactor(db, queries),
+		tokens:      tokens,
+		jobs:        queue,
+		linkBaseURL: linkBaseURL,
+	}
+}
+
+// SendEmailVerification отправляет письмо со ссылкой подтверждения email
+// Вызывается после регистрации. Для уже подтвержденного email ничего не делает
+func (s *AccountService) SendEmailVerification(ctx context.Context, userID int) error {
+	user, err := s.queries.GetUserByID(ctx, int32(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+	if user.EmailVerifiedAt.Valid {
+		return nil
+	}
+
+	return s.sendEmailVerification(ctx, &user)
+}
+
+// ResendVerification повторно отправляет письмо подтверждения по email
+// Неизвестный, уже подтвержденный или деактивированный email не считается
+// ошибкой: ответ не должен раскрывать, зарегистрирован ли адрес
+func (s *AccountService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.queries.GetUserByEmail(ctx, emailLookup(email))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+	if user.EmailVerifiedAt.Valid || !lifecycle.Status(user.Status).CanSignIn() {
+		return nil
+	}
+
+	return s.sendEmailVerification(ctx, &user)
+}
+
+// VerifyEmail подтверждает email по токену из письма
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) (*models.UserResponse, error) {
+	userID, err := s.consumeToken(ctx, s.queries, token, auth.TokenTypeVerifyEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.queries.MarkUserEmailVerified(ctx, int32(userID))
+	if err != nil {
+		// Пользователь удален после отправки письма
+		if err == pgx.ErrNoRows {
+			return nil, ErrInvalidAccountToken
+		}
+		return nil, fmt.Errorf("ошибка подтверждения email: %w", err)
+	}
+
+	return toUserResponse(&user), nil
+}
+
+// ForgotPassword отправляет письмо со ссылкой сброса пароля
+// Как и ResendVerification, не раскрывает, зарегистрирован ли email
+func (s *AccountService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.queries.GetUserByEmail(ctx, emailLookup(email))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+	if !lifecycle.Status(user.Status).CanSignIn() {
+		return nil
+	}
+
+	token, err := s.issueToken(ctx, user.ID, auth.TokenTypePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	msg, err := mailer.Render(mailer.TemplatePasswordReset, user.Email, mailer.LinkData{
+		Username:  user.Username,
+		Email:     user.Email,
+		Link:      s.linkBaseURL + "/reset-password?token=" + url.QueryEscape(token.Value),
+		ExpiresAt: token.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.deliver(ctx, msg)
+	metrics.PasswordResets.WithLabelValues(metrics.PasswordResetRequested).Inc()
+	return nil
+}
+
+// NotifyEmailRegistered пишет владельцу email о попытке зарегистрировать
+// на его адрес второй аккаунт (см. EmailRegisteredError)
+// Регистрация отвечает на такую попытку как на успешную, поэтому
+// узнать, что аккаунт уже есть, может только владелец ящика
+func (s *AccountService) NotifyEmailRegistered(ctx context.Context, email string) error {
+	user, err := s.queries.GetUserByEmail(ctx, emailLookup(email))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	msg, err := mailer.Render(mailer.TemplateAccountExists, user.Email, mailer.AccountExistsData{
+		Username:  user.Username,
+		Email:     user.Email,
+		LoginLink: s.linkBaseURL + "/login",
+		ResetLink: s.linkBaseURL + "/forgot-password",
+	})
+	if err != nil {
+		return err
+	}
+
+	s.deliver(ctx, msg)
+	return nil
+}
+
+// ResetPassword задает новый пароль по токену из письма
+//
+// В одной транзакции:
+//  1. токен помечается использованным
+//  2. пароль заменяется, остальные ссылки сброса аннулируются
+//  3. отзываются все refresh токены - сессии, открытые со старым паролем,
+//     завершатся не позже чем через AccessTTL
+//  4. email считается подтвержденным: ссылка пришла на этот адрес
+func (s *AccountService) ResetPassword(ctx context.Context, token, password string) error {
+	// Хешируем до транзакции, чтобы не держать ее открытой во время bcrypt
+	passwordHash, err := passhash.Hash(password)
+	if err != nil {
+		return fmt.Errorf("ошибка хеширования пароля: %w", err)
+	}
+
+	err = s.tx.WithTx(ctx, func(q *repository.Queries) error {
+		// 1. Используем токен
+		userID, err := s.consumeToken(ctx, q, token, auth.TokenTypePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		// 2. Меняем пароль
+		if err := q.UpdateUserPassword(ctx, repository.UpdateUserPasswordParams{
+			ID:           int32(userID),
+			PasswordHash: string(passwordHash),
+		}); err != nil {
+			return fmt.Errorf("ошибка обновления пароля: %w", err)
+		}
+		if _, err := q.InvalidateUserTokens(ctx, repository.InvalidateUserTokensParams{
+			UserID:  int32(userID),
+			Purpose: auth.TokenTypePasswordReset,
+		}); err != nil {
+			return fmt.Errorf("ошибка аннулирования ссылок: %w", err)
+		}
+
+		// 3. Завершаем сессии
+		if _, err := q.RevokeUserRefreshTokens(ctx, int32(userID)); err != nil {
+			return fmt.Errorf("ошибка отзыва токенов: %w", err)
+		}
+
+		// 4. Подтверждаем email
+		if _, err := q.MarkUserEmailVerified(ctx, int32(userID)); err != nil {
+			// Пользователь удален после отправки письма
+			if err == pgx.ErrNoRows {
+				return ErrInvalidAccountToken
+			}
+			return fmt.Errorf("ошибка подтверждения email: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.PasswordResets.WithLabelValues(metrics.PasswordResetCompleted).Inc()
+	return nil
+}
+
+// sendEmailVerification выпускает токен подтверждения и отправляет письмо
+func (s *AccountService) sendEmailVerification(ctx context.Context, user *repository.User) error {
+	token, err := s.issueToken(ctx, user.ID, auth.TokenTypeVerifyEmail)
+	if err != nil {
+		return err
+	}
+
+	msg, err := mailer.Render(mailer.TemplateVerifyEmail, user.Email, mailer.LinkData{
+		Username:  user.Username,
+		Email:     user.Email,
+		Link:      s.linkBaseURL + "/verify-email?token=" + url.QueryEscape(token.Value),
+		ExpiresAt: token.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.deliver(ctx, msg)
+	return nil
+}
+
+// issueToken выпускает одноразовый токен и сохраняет его хеш
+// Ранее выданные токены с тем же назначением аннулируются,
+// поэтому действует только ссылка из последнего письма
+func (s *AccountService) issueToken(ctx context.Context, userID int32, purpose string) (auth.Token, error) {
+	var (
+		token auth.Token
+		err   error
+	)
+	switch purpose {
+	case auth.TokenTypeVerifyEmail:
+		token, err = s.tokens.IssueEmailVerification(int(userID))
+	case auth.TokenTypePasswordReset:
+		token, err = s.tokens.IssuePasswordReset(int(userID))
+	case auth.TokenTypeTwoFactorRecovery:
+		token, err = s.tokens.IssueTwoFactorRecovery(int(userID))
+	default:
+		return auth.Token{}, fmt.Errorf("неизвестное назначение токена: %s", purpose)
+	}
+	if err != nil {
+		return auth.Token{}, err
+	}
+
+	if _, err := s.queries.InvalidateUserTokens(ctx, repository.InvalidateUserTokensParams{
+		UserID:  userID,
+		Purpose: purpose,
+	}); err != nil {
+		return auth.Token{}, fmt.Errorf("ошибка аннулирования ссылок: %w", err)
+	}
+
+	_, err = s.queries.CreateUserToken(ctx, repository.CreateUserTokenParams{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: auth.HashTokenID(token.ID),
+		ExpiresAt: token.ExpiresAt,
+	})
+	if err != nil {
+		return auth.Token{}, fmt.Errorf("ошибка сохранения токена: %w", err)
+	}
+
+	return token, nil
+}
+
+// consumeToken проверяет токен и помечает его использованным
+// Возвращает ID пользователя, которому токен был выдан
+func (s *AccountService) consumeToken(ctx context.Context, queries *repository.Queries, value, purpose string) (int, error) {
+	claims, err := s.tokens.Parse(value, purpose)
+	if err != nil {
+		return 0, ErrInvalidAccountToken
+	}
+	userID, _ := claims.UserID()
+
+	stored, err := queries.ConsumeUserToken(ctx, repository.ConsumeUserTokenParams{
+		TokenHash: auth.HashTokenID(claims.ID),
+		Purpose:   purpose,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, ErrInvalidAccountToken
+		}
+		return 0, fmt.Errorf("ошибка проверки токена: %w", err)
+	}
+	if int(stored.UserID) != userID {
+		return 0, ErrInvalidAccountToken
+	}
+
+	return userID, nil
+}
+
+// deliver ставит письмо в очередь заданий (jobs.KindSendEmail)
+//
+// Запрос не ждет SMTP сервер: время ответа не зависит от того,
+// отправлялось ли письмо, и не раскрывает, зарегистрирован ли email.
+// Недоступный SMTP сервер не теряет письмо - задание повторяется с задержкой.
+// Ошибка постановки только логируется - пользователь может запросить письмо повторно
+func (s *AccountService) deliver(ctx context.Context, msg mailer.Message) {
+	if err := s.jobs.Enqueue(ctx, jobs.KindSendEmail, msg); err != nil {
+		slog.ErrorContext(ctx, "❌ Ошибка постановки письма в очередь", "subject", msg.Subject, "error", err)
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/sanitize"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Уровни важности объявлений
+const (
+	AnnouncementSeverityInfo     = "info"
+	AnnouncementSeverityWarning  = "warning"
+	AnnouncementSeverityCritical = "critical"
+)
+
+// AudienceAll - объявление для всех клиентов
+const AudienceAll = "all"
+
+// announcementAudiences - допустимые аудитории объявлений
+var announcementAudiences = map[string]bool{
+	AudienceAll: true,
+	"web":       true,
+	"ios":       true,
+	"android":   true,
+}
+
+// maxAnnouncementTitleLength совпадает с VARCHAR(200) в таблице announcements
+const maxAnnouncementTitleLength = 200
+
+// ErrInvalidAnnouncement возвращается для невалидного объявления
+var ErrInvalidAnnouncement = apperrors.Invalid("INVALID_ANNOUNCEMENT", "невалидное объявление")
+
+// AnnouncementService управляет объявлениями администраторов
+type AnnouncementService struct {
+	queries *repository.Queries
+	text    sanitize.Policy // Очистка заголовка и текста от HTML разметки
+}
+
+// NewAnnouncementService создает сервис объявлений
+func NewAnnouncementService(queries *repository.Queries, textPolicy sanitize.Policy) *AnnouncementService {
+	return &AnnouncementService{
+		queries: queries,
+		text:    textPolicy,
+	}
+}
+
+// CreateAnnouncement публикует объявление
+// Объявление с будущим starts_at станет активным автоматически
+func (s *AnnouncementService) CreateAnnouncement(ctx context.Context, req models.CreateAnnouncementRequest) (*models.AnnouncementResponse, error) {
+	// 1. Очистка от HTML, значения по умолчанию и валидация
+	// Разметка вырезается до проверки длины и пустоты: "<b></b>" - пустой заголовок
+	if err := s.text.Fields(map[string]*string{
+		"title": &req.Title,
+		"body":  &req.Body,
+	}); err != nil {
+		return nil, err
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Severity == "" {
+		req.Severity = AnnouncementSeverityInfo
+	}
+	if req.Audience == "" {
+		req.Audience = AudienceAll
+	}
+
+	switch {
+	case req.Title == "" || req.Body == "":
+		return nil, fmt.Errorf("%w: заголовок и текст обязательны", ErrInvalidAnnouncement)
+	case utf8.RuneCountInString(req.Title) > maxAnnouncementTitleLength:
+		return nil, fmt.Errorf("%w: заголовок длиннее %d символов", ErrInvalidAnnouncement, maxAnnouncementTitleLength)
+	case req.Severity != AnnouncementSeverityInfo && req.Severity != AnnouncementSeverityWarning && req.Severity != AnnouncementSeverityCritical:
+		return nil, fmt.Errorf("%w: неизвестная важность %q", ErrInvalidAnnouncement, req.Severity)
+	case !announcementAudiences[req.Audience]:
+		return nil, fmt.Errorf("%w: неизвестная аудитория %q", ErrInvalidAnnouncement, req.Audience)
+	case req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(req.StartsAt.Time):
+		return nil, fmt.Errorf("%w: ends_at должен быть позже starts_at", ErrInvalidAnnouncement)
+	}
+
+	// 2. Сохраняем
+	params := repository.CreateAnnouncementParams{
+		Title:    req.Title,
+		Body:     req.Body,
+		Severity: req.Severity,
+		Audience: req.Audience,
+	}
+	if req.StartsAt != nil {
+		params.StartsAt = pgtype.Timestamp{Time: req.StartsAt.Time, Valid: true}
+	}
+	if req.EndsAt != nil {
+		params.EndsAt = pgtype.Timestamp{Time: req.EndsAt.Time, Valid: true}
+	}
+
+	announcement, err := s.queries.CreateAnnouncement(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания объявления: %w", err)
+	}
+
+	return toAnnouncementResponse(&announcement), nil
+}
+
+// ListActiveAnnouncements возвращает объявления, активные сейчас для аудитории
+// Пустая аудитория означает клиента без платформы: он получает только объявления для всех
+func (s *AnnouncementService) ListActiveAnnouncements(ctx context.Context, audience string) ([]models.AnnouncementResponse, error) {
+	if audience == "" {
+		audience = AudienceAll
+	}
+	if !announcementAudiences[audience] {
+		return nil, fmt.Errorf("%w: неизвестная аудитория %q", ErrInvalidAnnouncement, audience)
+	}
+
+	announcements, err := s.queries.ListActiveAnnouncements(ctx, audience)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения объявлений: %w", err)
+	}
+
+	// Пустой список, а не null: клиентам проще обрабатывать массив
+	responses := make([]models.AnnouncementResponse, len(announcements))
+	for i := range announcements {
+		responses[i] = *toAnnouncementResponse(&announcements[i])
+	}
+
+	return responses, nil
+}
+
+// toAnnouncementResponse конвертирует модель БД в модель API
+func toAnnouncementResponse(a *repository.Announcement) *models.AnnouncementResponse {
+	resp := &models.AnnouncementResponse{
+		ID:        publicid.ID(a.ID),
+		Title:     a.Title,
+		Body:      a.Body,
+		Severity:  a.Severity,
+		Audience:  a.Audience,
+		StartsAt:  utc.From(a.StartsAt),
+		EndsAt:    utc.FromNull(a.EndsAt),
+		CreatedAt: utc.From(a.CreatedAt),
+	}
+	return resp
+}
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/lifecycle"
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrAccountInactive возвращается при входе в деактивированный аккаунт
+var ErrAccountInactive = apperrors.Forbidden("ACCOUNT_INACTIVE", "аккаунт деактивирован")
+
+// ErrAccountSuspended возвращается при входе в заблокированный аккаунт
+var ErrAccountSuspended = apperrors.Forbidden("ACCOUNT_SUSPENDED", "аккаунт заблокирован")
+
+// signInError возвращает ошибку входа для состояния аккаунта, nil - вход разрешен
+func signInError(status lifecycle.Status) error {
+	switch {
+	case status.CanSignIn():
+		return nil
+	case status == lifecycle.StatusSuspended:
+		return ErrAccountSuspended
+	default:
+		return ErrAccountInactive
+	}
+}
+
+// loginFailed учитывает отказ во входе в метрике logins_total
+// Внутренние ошибки (БД недоступна) отказом пользователю не считаются:
+// учитываются только ошибки apperrors - неверный пароль, код, статус аккаунта
+func loginFailed(method string, err error) {
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		metrics.Logins.WithLabelValues(method, metrics.LoginFailure).Inc()
+	}
+}
+
+// AuthService выдает, обновляет и отзывает токены
+type AuthService struct {
+	queries     *repository.Queries
+	tx          Transactor
+	userService *UserService
+	twoFactor   *TwoFactorService
+	recovery    *TwoFactorRecoveryService
+	tokens      *auth.TokenManager
+}
+
+// NewAuthService создает сервис аутентификации
+func NewAuthService(queries *repository.Queries, db Beginner, userService *UserService, twoFactor *TwoFactorService, recovery *TwoFactorRecoveryService, tokens *auth.TokenManager) *AuthService {
+	return &AuthService{
+		queries:     queries,
+		tx:          NewTransactor(db, queries),
+		userService: userService,
+		twoFactor:   twoFactor,
+		recovery:    recovery,
+		tokens:      tokens,
+	}
+}
+
+// Login проверяет email и пароль и выдает пару токенов
+func (s *AuthService) Login(ctx context.Context, email, password string) (*models.TokenResponse, error) {
+	// 1. Проверяем пароль (ErrInvalidCredentials для любой ошибки входа)
+	user, err := s.userService.VerifyPassword(ctx, email, password)
+	if err != nil {
+		loginFailed(metrics.LoginMethodPassword, err)
+		return nil, err
+	}
+	if err := signInError(lifecycle.Status(user.Status)); err != nil {
+		loginFailed(metrics.LoginMethodPassword, err)
+		return nil, err
+	}
+
+	// 2. С включенной 2FA вместо пары токенов выдаем токен второго шага
+	enabled, err := s.twoFactor.Enabled(ctx, user.InternalID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		pending, err := s.tokens.IssueTwoFactor(user.InternalID)
+		if err != nil {
+			return nil, err
+		}
+		return &models.TokenResponse{
+			TokenType:         "Bearer",
+			ExpiresIn:         int(s.tokens.TwoFactorTTL().Seconds()),
+			TwoFactorRequired: true,
+			TwoFactorToken:    pending.Value,
+		}, nil
+	}
+
+	// 3. Фиксируем вход и выдаем токены
+	if err := recordLogin(ctx, s.queries, user); err != nil {
+		return nil, err
+	}
+	resp, err := s.issuePair(ctx, s.queries, user.InternalID, user.Username, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	metrics.Logins.WithLabelValues(metrics.LoginMethodPassword, metrics.LoginSuccess).Inc()
+	resp.User = user
+	return resp, nil
+}
+
+// recordLogin фиксирует успешный вход (last_login_at, login_count и
+// запись в login_history) и отражает его в ответе без повторного запроса к БД
+// Вызывается только когда вход завершен: после проверки состояния
+// аккаунта и второго фактора
+func recordLogin(ctx context.Context, q *repository.Queries, user *models.UserResponse) error {
+	if err := q.RecordUserLogin(ctx, int32(user.InternalID)); err != nil {
+		return fmt.Errorf("ошибка сохранения статистики входа: %w", err)
+	}
+	if err := createLoginHistory(ctx, q, user.InternalID); err != nil {
+		return err
+	}
+	now := utc.Now()
+	user.LastLoginAt = &now
+	user.LoginCount++
+	return nil
+}
+
+// CompleteTwoFactor завершает вход с 2FA: проверяет код из приложения
+// или код восстановления и выдает пару токенов
+// userID берется из токена two_factor, выданного Login
+func (s *AuthService) CompleteTwoFactor(ctx context.Context, userID int, req models.TwoFactorLoginRequest) (*models.TokenResponse, error) {
+	if req.Code == "" && req.RecoveryCode == "" {
+		return nil, apperrors.Invalid("TWO_FACTOR_CODE_REQUIRED", "укажите code или recovery_code")
+	}
+
+	// 1. Проверяем код (каждый принимается один раз)
+	if err := s.twoFactor.Check(ctx, userID, req); err != nil {
+		loginFailed(metrics.LoginMethodTwoFactor, err)
+		return nil, err
+	}
+
+	// 2. Пользователь мог быть заблокирован после ввода пароля
+	user, err := s.queries.GetUserByID(ctx, int32(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, auth.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+	if err := signInError(lifecycle.Status(user.Status)); err != nil {
+		return nil, err
+	}
+
+	// 3. Второй фактор у пользователя есть: запрос восстановления
+	// доступа без него, если был, создал не владелец
+	s.recovery.CancelOnLogin(ctx, userID)
+
+	// 4. Фиксируем вход и выдаем токены
+	loggedIn := toUserResponse(&user)
+	if err := recordLogin(ctx, s.queries, loggedIn); err != nil {
+		return nil, err
+	}
+	resp, err := s.issuePair(ctx, s.queries, userID, user.Username, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	metrics.Logins.WithLabelValues(metrics.LoginMethodTwoFactor, metrics.LoginSuccess).Inc()
+	resp.User = loggedIn
+	return resp, nil
+}
+
+// CompleteTwoFactorRecovery завершает вход без второго фактора
+// по готовому запросу восстановления (см. TwoFactorRecoveryService):
+// отключает 2FA, завершает прежние сессии и выдает пару токенов
+// userID берется из токена two_factor, то есть пароль проверен заново
+func (s *AuthService) CompleteTwoFactorRecovery(ctx context.Context, userID int) (*models.TokenResponse, error) {
+	// 1. Пользователь мог быть заблокирован после ввода пароля
+	user, err := s.queries.GetUserByID(ctx, int32(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, auth.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+	if err := signInError(lifecycle.Status(user.Status)); err != nil {
+		return nil, err
+	}
+
+	// 2. Отключаем 2FA, фиксируем вход и выдаем токены одной транзакцией
+	var resp *models.TokenResponse
+	loggedIn := toUserResponse(&user)
+	err = s.tx.WithTx(ctx, func(q *repository.Queries) error {
+		if err := s.recovery.complete(ctx, q, userID); err != nil {
+			return err
+		}
+		if err := recordLogin(ctx, q, loggedIn); err != nil {
+			return err
+		}
+		resp, err = s.issuePair(ctx, q, userID, user.Username, user.Role)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.recovery.recordCompleted(ctx, userID)
+	metrics.Logins.WithLabelValues(metrics.LoginMethodRecovery, metrics.LoginSuccess).Inc()
+	resp.User = loggedIn
+	return resp, nil
+}
+
+// Refresh обменивает refresh токен на новую пару (ротация)
+//
+// Старый refresh токен отзывается. Повторное предъявление уже отозванного
+// токена означает, что он утек: в этом случае отзываются все токены
+// пользователя, и войти заново придется и злоумышленнику, и владельцу
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*models.TokenResponse, error) {
+	// 1. Проверяем подпись и срок
+	claims, err := s.tokens.Parse(refreshToken, auth.TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	userID, _ := claims.UserID()
+	hash := auth.HashTokenID(claims.ID)
+
+	var (
+		resp   *models.TokenResponse
+		reused bool
+	)
+	err = s.tx.WithTx(ctx, func(q *repository.Queries) error {
+		// 2. Проверяем, что токен выдан нами и не отозван
+		stored, err := q.GetRefreshTokenForUpdate(ctx, hash)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return auth.ErrInvalidToken
+			}
+			return fmt.Errorf("ошибка получения токена: %w", err)
+		}
+		if stored.RevokedAt.Valid {
+			// Отзыв всех токенов должен зафиксироваться, поэтому
+			// ошибка клиенту возвращается после транзакции
+			reused = true
+			if _, err := q.RevokeUserRefreshTokens(ctx, stored.UserID); err != nil {
+				return fmt.Errorf("ошибка отзыва токенов: %w", err)
+			}
+			return nil
+		}
+		if int(stored.UserID) != userID || time.Now().After(stored.ExpiresAt) {
+			return auth.ErrInvalidToken
+		}
+
+		// 3. Пользователь мог быть удален, деактивирован или заблокирован после входа
+		user, err := q.GetUserByID(ctx, stored.UserID)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return auth.ErrInvalidToken
+			}
+			return fmt.Errorf("ошибка получения пользователя: %w", err)
+		}
+		if err := signInError(lifecycle.Status(user.Status)); err != nil {
+			return err
+		}
+
+		// 4. Ротация: отзываем старый токен и выдаем новую пару
+		if _, err := q.RevokeRefreshToken(ctx, hash); err != nil {
+			return fmt.Errorf("ошибка отзыва токена: %w", err)
+		}
+		resp, err = s.issuePair(ctx, q, int(user.ID), user.Username, user.Role)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, auth.ErrInvalidToken
+	}
+	return resp, nil
+}
+
+// Logout отзывает refresh токен
+// Повторный logout и уже просроченный токен не считаются ошибкой
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	claims, err := s.tokens.Parse(refreshToken, auth.TokenTypeRefresh)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.queries.RevokeRefreshToken(ctx, auth.HashTokenID(claims.ID)); err != nil {
+		return fmt.Errorf("ошибка отзыва токена: %w", err)
+	}
+	return nil
+}
+
+// Sudo выдает sudo токен после повторного ввода пароля
+// Sudo токен не хранится в БД: он живет минуты и привязан к пользователю
+func (s *AuthService) Sudo(ctx context.Context, userID int, password string) (*models.SudoTokenResponse, error) {
+	if err := s.userService.CheckPassword(ctx, userID, password); err != nil {
+		return nil, err
+	}
+
+	sudo, err := s.tokens.IssueSudo(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SudoTokenResponse{
+		SudoToken: sudo.Value,
+		ExpiresIn: int(s.tokens.SudoTTL().Seconds()),
+	}, nil
+}
+
+// PurgeExpiredRefreshTokens удаляет истекшие refresh токены
+// Выполняется заданием jobs.KindPurgeRefreshTokens по расписанию
+func (s *AuthService) PurgeExpiredRefreshTokens(ctx context.Context) error {
+	deleted, err := s.queries.DeleteExpiredRefreshTokens(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления истекших refresh токенов: %w", err)
+	}
+	slog.InfoContext(ctx, "🧹 Истекшие refresh токены удалены", "count", deleted)
+	return nil
+}
+
+// issuePair выпускает access и refresh токены и сохраняет refresh в БД
+func (s *AuthService) issuePair(ctx context.Context, queries *repository.Queries, userID int, username, role string) (*models.TokenResponse, error) {
+	access, err := s.tokens.IssueAccess(userID, username, role)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := s.tokens.IssueRefresh(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = queries.CreateRefreshToken(ctx, repository.CreateRefreshTokenParams{
+		UserID:    int32(userID),
+		TokenHash: auth.HashTokenID(refresh.ID),
+		ExpiresAt: refresh.ExpiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сохранения refresh токена: %w", err)
+	}
+
+	return &models.TokenResponse{
+		AccessToken:  access.Value,
+		RefreshToken: refresh.Value,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.tokens.AccessTTL().Seconds()),
+	}, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/lifecycle"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+)
+
+// loginDB отдает одного пользователя по email и его секрет TOTP
+// и считает изменения (Exec): RecordUserLogin и выдачу токенов
+type loginDB struct {
+	txDB
+	hash        string
+	status      lifecycle.Status
+	totpEnabled bool
+	execs       int
+}
+
+func (d *loginDB) QueryRow(_ context.Context, _ string, _ ...interface{}) pgx.Row {
+	return loginRow{d: d}
+}
+
+func (d *loginDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	d.execs++
+	return pgconn.CommandTag{}, nil
+}
+
+// loginRow различает запросы по числу колонок: users или user_totp
+type loginRow struct{ d *loginDB }
+
+func (r loginRow) Scan(dest ...interface{}) error {
+	switch len(dest) {
+	case 21: // users: password_hash - 4-я колонка, status - 18-я
+		*dest[0].(*int32) = 42
+		*dest[3].(*string) = r.d.hash
+		*dest[17].(*string) = string(r.d.status)
+		return nil
+	case 5: // user_totp: enabled_at - 3-я колонка
+		if !r.d.totpEnabled {
+			return pgx.ErrNoRows
+		}
+		*dest[2].(*pgtype.Timestamp) = pgtype.Timestamp{Time: time.Now(), Valid: true}
+		return nil
+	}
+	return errors.New("неожиданный запрос")
+}
+
+func TestLoginRecordsOnlyCompletedSignIns(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := auth.NewTokenManager(config.AuthConfig{JWTSecret: "test-secret", AccessTTL: time.Minute, TwoFactorTTL: time.Minute})
+
+	tests := []struct {
+		name        string
+		password    string
+		status      lifecycle.Status
+		totpEnabled bool
+		wantErr     error
+	}{
+		{"неверный пароль", "wrong-password", lifecycle.StatusActive, false, ErrInvalidCredentials},
+		{"заблокированный аккаунт", "password123", lifecycle.StatusSuspended, false, ErrAccountSuspended},
+		// Вход завершит второй шаг, статистику фиксирует он
+		{"ожидает второй фактор", "password123", lifecycle.StatusActive, true, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db := &loginDB{hash: string(hash), status: tc.status, totpEnabled: tc.totpEnabled}
+			queries := repository.New(db)
+			users := NewUserService(queries, db, nil, "", nil, nil, nil)
+			twoFactor := NewTwoFactorService(queries, db, nil, "test", nil)
+			s := NewAuthService(queries, db, users, twoFactor, nil, tokens)
+
+			resp, err := s.Login(context.Background(), "ivan@example.com", tc.password)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Login: %v, ожидалось %v", err, tc.wantErr)
+			}
+			if tc.totpEnabled && (resp == nil || !resp.TwoFactorRequired) {
+				t.Errorf("ответ %+v, ожидался второй шаг", resp)
+			}
+			if db.execs != 0 {
+				t.Errorf("изменений в БД: %d, вход не должен учитываться", db.execs)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/audit"
+	"github.com/Soundveyve/fiber-backend/internal/avatar"
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// avatarPrefix - префикс ключей аватаров в хранилище
+const avatarPrefix = "avatars"
+
+// ErrAvatarNotFound возвращается, когда у пользователя нет аватара
+var ErrAvatarNotFound = apperrors.NotFound("AVATAR_NOT_FOUND", "аватар не загружен")
+
+// AvatarService загружает и выдает аватары пользователей
+// Файлы лежат в хранилище (local или s3), в users.avatar_key - ключ текущего
+type AvatarService struct {
+	queries *repository.Queries
+	store   storage.BlobStore
+	cfg     config.AvatarConfig
+	audit   *audit.Logger
+}
+
+// NewAvatarService создает сервис аватаров
+func NewAvatarService(queries *repository.Queries, store storage.BlobStore, cfg config.AvatarConfig, auditLog *audit.Logger) *AvatarService {
+	return &AvatarService{
+		queries: queries,
+		store:   store,
+		cfg:     cfg,
+		audit:   auditLog,
+	}
+}
+
+// MaxSize возвращает максимальный размер загружаемого файла в байтах
+func (s *AvatarService) MaxSize() int64 {
+	return int64(s.cfg.MaxSize)
+}
+
+// UploadAvatar проверяет изображение, приводит его к стандартному размеру
+// и делает аватаром пользователя
+//
+// Каждая загрузка сохраняется под новым ключом: ссылка на прежний аватар,
+// закешированная клиентами, не начинает отдавать другой файл.
+// Ошибки проверки файла - ошибки пакета avatar
+func (s *AvatarService) UploadAvatar(ctx context.Context, userID int, r io.Reader) (*models.UserResponse, error) {
+	// 1. Проверяем и обрабатываем изображение до обращения к хранилищу
+	data, err := avatar.Process(r, s.MaxSize(), s.cfg.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Сохраняем файл
+	key := storage.NewKey(avatarPrefix, "jpg")
+	if err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), avatar.ContentType); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения аватара: %w", err)
+	}
+
+	// 3. Запоминаем ключ; прежний ключ нужен, чтобы удалить старый файл
+	user, prev, err := s.setAvatarKey(ctx, userID, key)
+	if err != nil {
+		// Новый файл никому не нужен
+		s.deleteObject(ctx, key)
+		return nil, err
+	}
+
+	// 4. Прежний файл больше не нужен; если удалить не удалось,
+	// в хранилище остается лишний объект, но загрузка уже прошла
+	if prev.AvatarKey.Valid {
+		s.deleteObject(ctx, prev.AvatarKey.String)
+	}
+
+	slog.InfoContext(ctx, "🖼️  Аватар обновлен", "user_id", userID, "key", key, "size", len(data))
+	updated := toUserResponse(&user)
+	s.audit.Record(ctx, audit.Event{
+		Action:       audit.ActionUserAvatarUpdate,
+		TargetUserID: userID,
+		Before:       toUserResponse(&prev),
+		After:        updated,
+	})
+	return updated, nil
+}
+
+// setAvatarKey записывает ключ аватара и возвращает пользователя
+// после изменения и до него
+func (s *AvatarService) setAvatarKey(ctx context.Context, userID int, key string) (repository.User, repository.User, error) {
+	prev, err := s.queries.GetUserByID(ctx, int32(userID))
+	if err == nil {
+		var user repository.User
+		user, err = s.queries.SetUserAvatar(ctx, repository.SetUserAvatarParams{
+			AvatarKey: pgtype.Text{String: key, Valid: true},
+			ID:        int32(userID),
+		})
+		if err == nil {
+			return user, prev, nil
+		}
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.User{}, repository.User{}, ErrUserNotFound
+	}
+	return repository.User{}, repository.User{}, fmt.Errorf("ошибка обновления аватара: %w", err)
+}
+
+// GetAvatar открывает аватар пользователя на чтение
+// ObjectInfo.Key меняется при каждой загрузке и подходит для ETag.
+// Вызывающий обязан закрыть reader
+func (s *AvatarService) GetAvatar(ctx context.Context, userID int) (io.ReadCloser, storage.ObjectInfo, error) {
+	user, err := s.queries.GetUserByID(ctx, int32(userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ObjectInfo{}, ErrUserNotFound
+		}
+		return nil, storage.ObjectInfo{}, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+	if !user.AvatarKey.Valid {
+		return nil, storage.ObjectInfo{}, ErrAvatarNotFound
+	}
+
+	body, info, err := s.store.Get(ctx, user.AvatarKey.String)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			slog.WarnContext(ctx, "⚠️  Файл аватара отсутствует в хранилище", "user_id", userID, "key", user.AvatarKey.String)
+			return nil, storage.ObjectInfo{}, ErrAvatarNotFound
+		}
+		return nil, storage.ObjectInfo{}, fmt.Errorf("ошибка чтения аватара: %w", err)
+	}
+	return body, info, nil
+}
+
+// deleteObject удаляет файл, ошибка только пишется в лог
+func (s *AvatarService) deleteObject(ctx context.Context, key string) {
+	if err := s.store.Delete(ctx, key); err != nil {
+		slog.WarnContext(ctx, "⚠️  Не удалось удалить файл аватара", "key", key, "error", err)
+	}
+}
+
+// AvatarVersion возвращает версию аватара по ключу объекта:
+// случайное имя файла без расширения
+func AvatarVersion(key string) string {
+	return strings.TrimSuffix(path.Base(key), path.Ext(key))
+}
+
+// avatarURL строит ссылку на аватар для UserResponse
+// Версия в query меняется вместе с файлом и сбрасывает кеш клиентов
+func avatarURL(publicID, key string) string {
+	return "/api/v1/users/" + publicID + "/avatar?v=" + AvatarVersion(key)
+}
+
+// profileAvatarURL строит ссылку на аватар для публичного профиля
+func profileAvatarURL(username, key string) string {
+	return "/api/v1/profiles/" + url.PathEscape(username) + "/avatar?v=" + AvatarVersion(key)
+}
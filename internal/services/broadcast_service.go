@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/mailer"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/settings"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Статусы рассылок
+const (
+	BroadcastStatusPending   = "pending"
+	BroadcastStatusRunning   = "running"
+	BroadcastStatusCompleted = "completed"
+	BroadcastStatusFailed    = "failed"
+	BroadcastStatusCancelled = "cancelled"
+)
+
+// broadcastStaleAfter - через сколько без прогресса рассылка в статусе
+// running считается брошенной и захватывается другим воркером
+// Должно быть больше времени отправки одной пачки
+const broadcastStaleAfter = 15 * time.Minute
+
+// ErrBroadcastNotFound возвращается, когда рассылки нет
+var ErrBroadcastNotFound = apperrors.NotFound("BROADCAST_NOT_FOUND", "рассылка не найдена")
+
+// ErrBroadcastFinished возвращается при отмене завершенной рассылки
+var ErrBroadcastFinished = apperrors.Conflict("BROADCAST_FINISHED", "рассылка уже завершена")
+
+// BroadcastService выполняет массовые рассылки администраторов
+// по сегменту пользователей
+//
+// Рассылка создается в статусе pending, фоновый воркер отправляет письма
+// пачками по возрастанию id пользователя и после каждой пачки сохраняет
+// курсор и счетчики. Если инстанс остановился посреди рассылки, другой
+// воркер продолжит ее с курсора (см. broadcastStaleAfter). Письма пачки,
+// прерванной до сохранения прогресса, могут быть отправлены повторно
+type BroadcastService struct {
+	queries  *repository.Queries
+	mailer   mailer.Mailer
+	settings *settings.Settings // Размер пачки (broadcast.batch_size)
+}
+
+// NewBroadcastService создает сервис рассылок
+func NewBroadcastService(queries *repository.Queries, m mailer.Mailer, runtimeSettings *settings.Settings) *BroadcastService {
+	return &BroadcastService{
+		queries:  queries,
+		mailer:   m,
+		settings: runtimeSettings,
+	}
+}
+
+// CreateBroadcast создает рассылку по сегменту пользователей
+// Сами письма отправляет воркер (RunWorker)
+func (s *BroadcastService) CreateBroadcast(ctx context.Context, req models.CreateBroadcastRequest, createdBy int) (*models.BroadcastResponse, error) {
+	// Перевод строки в теме позволил бы дописать заголовки письма
+	if strings.ContainsAny(req.Subject, "\r\n") {
+		return nil, &validation.Error{Fields: map[string]string{"subject": "не может содержать перевод строки"}}
+	}
+
+	var inactiveSince pgtype.Timestamp
+	if req.Filter.InactiveSince != nil {
+		inactiveSince = pgtype.Timestamp{Time: req.Filter.InactiveSince.Time, Valid: true}
+	}
+
+	// Размер сегмента на момент создания - для отображения прогресса
+	total, err := s.queries.CountBroadcastRecipients(ctx, inactiveSince)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчета получателей: %w", err)
+	}
+
+	broadcast, err := s.queries.CreateBroadcast(ctx, repository.CreateBroadcastParams{
+		Subject:       req.Subject,
+		Body:          req.Body,
+		InactiveSince: inactiveSince,
+		TotalCount:    int32(total),
+		CreatedBy:     pgtype.Int4{Int32: int32(createdBy), Valid: createdBy != 0},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания рассылки: %w", err)
+	}
+
+	return toBroadcastResponse(&broadcast), nil
+}
+
+// GetBroadcast возвращает рассылку с прогрессом
+func (s *BroadcastService) GetBroadcast(ctx context.Context, id int) (*models.BroadcastResponse, error) {
+	broadcast, err := s.queries.GetBroadcast(ctx, int32(id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrBroadcastNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения рассылки: %w", err)
+	}
+
+	return toBroadcastResponse(&broadcast), nil
+}
+
+// CancelBroadcast отменяет незавершенную рассылку
+// Воркер прекращает отправку после текущей пачки
+func (s *BroadcastService) CancelBroadcast(ctx context.Context, id int) (*models.BroadcastResponse, error) {
+	broadcast, err := s.queries.CancelBroadcast(ctx, int32(id))
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			return nil, fmt.Errorf("ошибка отмены рассылки: %w", err)
+		}
+		// Рассылки нет или она уже завершена
+		if _, err := s.GetBroadcast(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrBroadcastFinished
+	}
+
+	return toBroadcastResponse(&broadcast), nil
+}
+
+// RunWorker обрабатывает рассылки, пока не отменен ctx
+// Между проверками очереди ждет interval; если рассылка нашлась,
+// сразу проверяет следующую
+func (s *BroadcastService) RunWorker(ctx context.Context, interval time.Duration) {
+	slog.Info("📣 Воркер рассылок запущен")
+
+	for {
+		processed, err := s.processNext(ctx)
+		// Ошибки из-за остановки воркера не логируем
+		if err != nil && ctx.Err() == nil {
+			slog.Error("❌ Ошибка воркера рассылок", "error", err)
+		}
+		if processed {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("📣 Воркер рассылок остановлен")
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// processNext захватывает рассылку и отправляет ее до конца,
+// до отмены или до остановки воркера
+// Возвращает false, если очередь пуста
+func (s *BroadcastService) processNext(ctx context.Context) (bool, error) {
+	broadcast, err := s.queries.ClaimNextBroadcast(ctx, time.Now().UTC().Add(-broadcastStaleAfter))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("ошибка захвата рассылки: %w", err)
+	}
+
+	if broadcast.LastUserID > 0 {
+		slog.Info("📣 Рассылка продолжена", "broadcast_id", broadcast.ID, "after_user_id", broadcast.LastUserID)
+	}
+
+	for {
+		// Остановка воркера: рассылка остается в running и будет
+		// продолжена с курсора после broadcastStaleAfter
+		if ctx.Err() != nil {
+			return true, nil
+		}
+
+		recipients, err := s.queries.ListBroadcastRecipients(ctx, repository.ListBroadcastRecipientsParams{
+			AfterID:       broadcast.LastUserID,
+			InactiveSince: broadcast.InactiveSince,
+			Limit:         int32(s.settings.Int(settings.BroadcastBatchSize)),
+		})
+		if err != nil {
+			return true, fmt.Errorf("ошибка выборки получателей рассылки %d: %w", broadcast.ID, err)
+		}
+
+		if len(recipients) == 0 {
+			// Статус обновляем даже если ctx отменен, иначе рассылка останется в running
+			if err := s.queries.CompleteBroadcast(context.Background(), broadcast.ID); err != nil {
+				return true, fmt.Errorf("ошибка завершения рассылки %d: %w", broadcast.ID, err)
+			}
+			slog.Info("📣 Рассылка завершена", "broadcast_id", broadcast.ID,
+				"sent", broadcast.SentCount, "failed", broadcast.FailedCount)
+			return true, nil
+		}
+
+		progress, err := s.sendBatch(ctx, &broadcast, recipients)
+		if err != nil {
+			if failErr := s.queries.FailBroadcast(context.Background(), repository.FailBroadcastParams{
+				ID:    broadcast.ID,
+				Error: pgtype.Text{String: err.Error(), Valid: true},
+			}); failErr != nil {
+				return true, fmt.Errorf("ошибка сохранения статуса рассылки %d: %w", broadcast.ID, failErr)
+			}
+			return true, fmt.Errorf("рассылка %d завершилась ошибкой: %w", broadcast.ID, err)
+		}
+
+		// Воркер остановлен до первого письма пачки
+		if progress.LastUserID == 0 {
+			return true, nil
+		}
+
+		// Прогресс сохраняется и при остановке воркера: письма уже отправлены
+		updated, err := s.queries.RecordBroadcastProgress(context.Background(), progress)
+		if err != nil {
+			// Рассылку отменили во время отправки пачки
+			if err == pgx.ErrNoRows {
+				slog.Info("📣 Рассылка отменена", "broadcast_id", broadcast.ID)
+				return true, nil
+			}
+			return true, fmt.Errorf("ошибка сохранения прогресса рассылки %d: %w", broadcast.ID, err)
+		}
+		broadcast = updated
+	}
+}
+
+// sendBatch отправляет письма пачке получателей и возвращает прогресс:
+// курсор на последнего обработанного получателя и счетчики
+//
+// Недоставленное письмо учитывается в Failed и не прерывает рассылку;
+// ошибка возвращается, только если письмо нельзя сформировать.
+// При остановке воркера пачка прерывается, а оставшиеся получатели
+// не считаются недоставленными: они получат письмо после продолжения
+func (s *BroadcastService) sendBatch(ctx context.Context, broadcast *repository.Broadcast, recipients []repository.User) (repository.RecordBroadcastProgressParams, error) {
+	progress := repository.RecordBroadcastProgressParams{ID: broadcast.ID}
+	for i := range recipients {
+		if ctx.Err() != nil {
+			break
+		}
+		user := &recipients[i]
+
+		msg, err := mailer.Render(mailer.TemplateBroadcast, user.Email, mailer.BroadcastData{
+			Username: user.Username,
+			Subject:  broadcast.Subject,
+			Body:     broadcast.Body,
+		})
+		if err != nil {
+			return progress, err
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, mailSendTimeout)
+		err = s.mailer.Send(sendCtx, msg)
+		cancel()
+		// Письмо прервано остановкой воркера - отправим после продолжения
+		if err != nil && ctx.Err() != nil {
+			break
+		}
+
+		progress.LastUserID = user.ID
+		if err != nil {
+			progress.Failed++
+			slog.Warn("⚠️  Письмо рассылки не отправлено", "broadcast_id", broadcast.ID, "user_id", user.ID, "error", err)
+			continue
+		}
+		progress.Sent++
+	}
+	return progress, nil
+}
+
+// toBroadcastResponse конвертирует рассылку из БД в API ответ
+func toBroadcastResponse(broadcast *repository.Broadcast) *models.BroadcastResponse {
+	resp := &models.BroadcastResponse{
+		ID:          publicid.ID(broadcast.ID),
+		Subject:     broadcast.Subject,
+		Status:      broadcast.Status,
+		TotalCount:  int(broadcast.TotalCount),
+		SentCount:   int(broadcast.SentCount),
+		FailedCount: int(broadcast.FailedCount),
+		CreatedAt:   utc.From(broadcast.CreatedAt),
+		StartedAt:   utc.FromNull(broadcast.StartedAt),
+		CompletedAt: utc.FromNull(broadcast.CompletedAt),
+	}
+	if broadcast.InactiveSince.Valid {
+		resp.Filter.InactiveSince = utc.Ptr(broadcast.InactiveSince.Time)
+	}
+	if broadcast.Error.Valid {
+		resp.Error = &broadcast.Error.String
+	}
+
+	// Сегмент мог вырасти после создания, поэтому прогресс ограничен 100%
+	processed := resp.SentCount + resp.FailedCount
+	switch {
+	case broadcast.Status == BroadcastStatusCompleted:
+		resp.Progress = 100
+	case resp.TotalCount > 0:
+		progress := float64(processed) * 100 / float64(resp.TotalCount)
+		resp.Progress = math.Min(100, math.Round(progress*10)/10)
+	}
+
+	return resp
+}
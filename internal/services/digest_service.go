@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/mailer"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DigestService отправляет подписавшимся пользователям сводку активности
+// аккаунта: входы, запросы сброса пароля, привязку провайдеров и изменения
+// профиля за период (по умолчанию неделя)
+//
+// Очередью служит таблица digest_subscriptions: фоновый воркер захватывает
+// подписки, по которым прошел период, так же как воркер экспорта захватывает
+// задания. Несколько инстансов разбирают подписки параллельно
+type DigestService struct {
+	queries     *repository.Queries
+	mailer      mailer.Mailer
+	period      time.Duration
+	settingsURL string // Страница настроек для отписки, указывается в письме
+}
+
+// NewDigestService создает сервис сводки активности
+func NewDigestService(queries *repository.Queries, m mailer.Mailer, period time.Duration, linkBaseURL string) *DigestService {
+	return &DigestService{
+		queries:     queries,
+		mailer:      m,
+		period:      period,
+		settingsURL: linkBaseURL + "/settings/notifications",
+	}
+}
+
+// GetSubscription возвращает состояние подписки пользователя на сводку
+func (s *DigestService) GetSubscription(ctx context.Context, userID int) (*models.DigestSubscriptionResponse, error) {
+	subscription, err := s.queries.GetDigestSubscription(ctx, int32(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return &models.DigestSubscriptionResponse{Enabled: false}, nil
+		}
+		return nil, fmt.Errorf("ошибка получения подписки: %w", err)
+	}
+
+	return toDigestSubscriptionResponse(&subscription), nil
+}
+
+// Subscribe подписывает пользователя на сводку
+// Повторная подписка ничего не меняет
+func (s *DigestService) Subscribe(ctx context.Context, userID int) (*models.DigestSubscriptionResponse, error) {
+	subscription, err := s.queries.CreateDigestSubscription(ctx, int32(userID))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подписки на сводку: %w", err)
+	}
+
+	return toDigestSubscriptionResponse(&subscription), nil
+}
+
+// Unsubscribe отписывает пользователя от сводки
+// Отписка без подписки не считается ошибкой
+func (s *DigestService) Unsubscribe(ctx context.Context, userID int) error {
+	if _, err := s.queries.DeleteDigestSubscription(ctx, int32(userID)); err != nil {
+		return fmt.Errorf("ошибка отписки от сводки: %w", err)
+	}
+	return nil
+}
+
+// RunWorker отправляет сводки, пока не отменен ctx
+// Между проверками ждет interval; если сводка нашлась,
+// сразу проверяет следующую
+func (s *DigestService) RunWorker(ctx context.Context, interval time.Duration) {
+	slog.Info("📬 Воркер сводок активности запущен")
+
+	for {
+		processed, err := s.processNext(ctx)
+		// Ошибки из-за остановки воркера не логируем
+		if err != nil && ctx.Err() == nil {
+			slog.Error("❌ Ошибка воркера сводок активности", "error", err)
+		}
+		if processed {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("📬 Воркер сводок активности остановлен")
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// processNext захватывает одну подписку и отправляет сводку
+// Возвращает false, если отправлять пока нечего
+func (s *DigestService) processNext(ctx context.Context) (bool, error) {
+	claim, err := s.queries.ClaimDueDigest(ctx, time.Now().UTC().Add(-s.period))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("ошибка захвата сводки: %w", err)
+	}
+
+	user, err := s.queries.GetUserByID(ctx, claim.UserID)
+	if err != nil {
+		return true, fmt.Errorf("ошибка получения пользователя %d: %w", claim.UserID, err)
+	}
+
+	data, err := s.collect(ctx, &user, claim.PeriodStart, claim.PeriodEnd)
+	if err != nil {
+		return true, err
+	}
+
+	// Пустую сводку не отправляем: письмо без событий только приучает
+	// пользователя не читать сводки
+	if len(data.Security) == 0 && len(data.Profile) == 0 {
+		return true, nil
+	}
+
+	msg, err := mailer.Render(mailer.TemplateWeeklyDigest, user.Email, data)
+	if err != nil {
+		return true, err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, mailSendTimeout)
+	defer cancel()
+	if err := s.mailer.Send(sendCtx, msg); err != nil {
+		return true, fmt.Errorf("ошибка отправки сводки пользователю %d: %w", user.ID, err)
+	}
+
+	slog.Info("📬 Сводка активности отправлена", "user_id", user.ID,
+		"security_events", len(data.Security), "profile_events", len(data.Profile))
+	return true, nil
+}
+
+// collect собирает события аккаунта за период [start, end)
+func (s *DigestService) collect(ctx context.Context, user *repository.User, start, end time.Time) (mailer.DigestData, error) {
+	data := mailer.DigestData{
+		Username:    user.Username,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		SettingsURL: s.settingsURL,
+	}
+	inPeriod := func(t time.Time) bool {
+		return !t.Before(start) && t.Before(end)
+	}
+
+	// 1. Входы: хранится только последний вход и общее число входов
+	if user.LastLoginAt.Valid && inPeriod(user.LastLoginAt.Time) {
+		data.Security = append(data.Security, mailer.DigestEvent{
+			At:   user.LastLoginAt.Time,
+			Text: fmt.Sprintf("Последний вход в аккаунт (всего входов: %d)", user.LoginCount),
+		})
+	}
+
+	// 2. Ссылки из писем: сброс пароля и подтверждение email
+	tokens, err := s.queries.ListUserTokensSince(ctx, repository.ListUserTokensSinceParams{
+		UserID: user.ID,
+		Since:  start,
+	})
+	if err != nil {
+		return data, fmt.Errorf("ошибка получения ссылок пользователя %d: %w", user.ID, err)
+	}
+	for _, token := range tokens {
+		if !inPeriod(token.CreatedAt) {
+			continue
+		}
+		switch token.Purpose {
+		case auth.TokenTypePasswordReset:
+			data.Security = append(data.Security, mailer.DigestEvent{At: token.CreatedAt, Text: "Запрошен сброс пароля"})
+		case auth.TokenTypeVerifyEmail:
+			data.Profile = append(data.Profile, mailer.DigestEvent{At: token.CreatedAt, Text: "Запрошено подтверждение email"})
+		}
+	}
+
+	// 3. Привязанные внешние учетные записи
+	identities, err := s.queries.ListUserIdentities(ctx, user.ID)
+	if err != nil {
+		return data, fmt.Errorf("ошибка получения привязок пользователя %d: %w", user.ID, err)
+	}
+	for _, identity := range identities {
+		if inPeriod(identity.CreatedAt) {
+			data.Security = append(data.Security, mailer.DigestEvent{
+				At:   identity.CreatedAt,
+				Text: "Привязан вход через " + identity.Provider,
+			})
+		}
+	}
+
+	// 4. Изменения профиля: хранится только момент последнего изменения
+	if user.EmailVerifiedAt.Valid && inPeriod(user.EmailVerifiedAt.Time) {
+		data.Profile = append(data.Profile, mailer.DigestEvent{At: user.EmailVerifiedAt.Time, Text: "Email подтвержден"})
+	}
+	if inPeriod(user.UpdatedAt) && !user.UpdatedAt.Equal(user.CreatedAt) {
+		data.Profile = append(data.Profile, mailer.DigestEvent{At: user.UpdatedAt, Text: "Последнее изменение профиля или пароля"})
+	}
+
+	sortDigestEvents(data.Security)
+	sortDigestEvents(data.Profile)
+	return data, nil
+}
+
+// sortDigestEvents упорядочивает события по времени
+func sortDigestEvents(events []mailer.DigestEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At.Before(events[j].At)
+	})
+}
+
+// toDigestSubscriptionResponse конвертирует подписку из БД в API ответ
+func toDigestSubscriptionResponse(subscription *repository.DigestSubscription) *models.DigestSubscriptionResponse {
+	return &models.DigestSubscriptionResponse{
+		Enabled:    true,
+		LastSentAt: utc.FromNull(subscription.LastSentAt),
+	}
+}
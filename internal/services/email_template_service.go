@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/mailer"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// testSubjectPrefix отличает тестовое письмо от настоящего в почтовом ящике
+const testSubjectPrefix = "[Тест] "
+
+// ErrEmailTemplateNotFound возвращается для неизвестного имени шаблона
+var ErrEmailTemplateNotFound = apperrors.NotFound("EMAIL_TEMPLATE_NOT_FOUND", "шаблон письма не найден")
+
+// EmailTemplateService показывает шаблоны писем администраторам
+//
+// Шаблоны заполняются примером данных (mailer.RenderSample), поэтому
+// после правки шаблона его можно проверить, не запуская регистрацию
+// или сброс пароля настоящего пользователя
+type EmailTemplateService struct {
+	queries *repository.Queries
+	mailer  mailer.Mailer
+}
+
+// NewEmailTemplateService создает сервис предпросмотра шаблонов писем
+func NewEmailTemplateService(queries *repository.Queries, m mailer.Mailer) *EmailTemplateService {
+	return &EmailTemplateService{
+		queries: queries,
+		mailer:  m,
+	}
+}
+
+// Preview заполняет шаблон name примером данных
+func (s *EmailTemplateService) Preview(name string) (*models.EmailTemplatePreviewResponse, error) {
+	msg, err := s.render(name, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.EmailTemplatePreviewResponse{
+		Name:    name,
+		Subject: msg.Subject,
+		Body:    msg.Body,
+	}, nil
+}
+
+// TestSend отправляет шаблон name с примером данных на адрес to,
+// а без адреса - на email администратора adminID
+//
+// Письмо отправляется сразу, а не через очередь заданий: ошибка SMTP
+// сервера возвращается администратору в ответе
+func (s *EmailTemplateService) TestSend(ctx context.Context, name, to string, adminID int) (*models.EmailTemplatePreviewResponse, error) {
+	if to == "" {
+		admin, err := s.queries.GetUserByID(ctx, int32(adminID))
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, ErrUserNotFound
+			}
+			return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
+		}
+		to = admin.Email
+	}
+
+	msg, err := s.render(name, to)
+	if err != nil {
+		return nil, err
+	}
+	msg.Subject = testSubjectPrefix + msg.Subject
+
+	sendCtx, cancel := context.WithTimeout(ctx, mailSendTimeout)
+	defer cancel()
+	if err := s.mailer.Send(sendCtx, msg); err != nil {
+		return nil, fmt.Errorf("ошибка отправки тестового письма %s: %w", name, err)
+	}
+
+	slog.InfoContext(ctx, "✉️ Тестовое письмо отправлено", "template", name, "to", to)
+
+	return &models.EmailTemplatePreviewResponse{
+		Name:    name,
+		To:      to,
+		Subject: msg.Subject,
+		Body:    msg.Body,
+	}, nil
+}
+
+// render заполняет шаблон примером данных
+func (s *EmailTemplateService) render(name, to string) (mailer.Message, error) {
+	if !mailer.HasTemplate(name) {
+		return mailer.Message{}, ErrEmailTemplateNotFound
+	}
+
+	msg, err := mailer.RenderSample(name, to)
+	if err != nil {
+		return mailer.Message{}, fmt.Errorf("ошибка заполнения шаблона: %w", err)
+	}
+	return msg, nil
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/bulk"
+	"github.com/Soundveyve/fiber-backend/internal/jobs"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/operations"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/redact"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/settings"
+	"github.com/Soundveyve/fiber-backend/internal/signedurl"
+	"github.com/Soundveyve/fiber-backend/internal/storage"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Статусы заданий экспорта
+const (
+	ExportStatusPending   = "pending"
+	ExportStatusRunning   = "running"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+// Поддерживаемые форматы экспорта
+const (
+	ExportFormatCSV    = bulk.FormatCSV
+	ExportFormatNDJSON = bulk.FormatNDJSON
+)
+
+// exportBatchSize - сколько пользователей читать из БД за один запрос
+const exportBatchSize = 500
+
+// ErrExportNotFound возвращается, когда задания экспорта нет
+var ErrExportNotFound = apperrors.NotFound("EXPORT_NOT_FOUND", "задание экспорта не найдено")
+
+// ErrInvalidExport возвращается для неподдерживаемого ресурса или формата
+var ErrInvalidExport = apperrors.Invalid("CREATE_EXPORT_ERROR", "невалидное задание экспорта")
+
+// ErrExportNotReady возвращается при попытке скачать незавершенный экспорт
+var ErrExportNotReady = apperrors.Conflict("EXPORT_NOT_READY", "экспорт еще не готов")
+
+// ExportInput - параметры операции export_users (POST /api/v1/exports)
+type ExportInput struct {
+	ExportID int `json:"export_id"`
+}
+
+// exportResult - сохраняемый результат операции export_users
+// Ссылка на скачивание устаревает, поэтому ответ с ней строит
+// presentExport при каждом чтении операции
+type exportResult struct {
+	ExportID int `json:"export_id"`
+}
+
+// ExportService управляет асинхронными экспортами:
+// создает задания, формирует файлы в операции export_users
+// и выдает временные ссылки на готовые файлы
+type ExportService struct {
+	queries    *repository.Queries
+	store      storage.BlobStore
+	signer     *signedurl.Signer   // Подпись ссылок на скачивание
+	settings   *settings.Settings  // Время жизни ссылки на скачивание (export.url_ttl)
+	redact     *redact.Policy      // Скрытие полей в файле (RESPONSE_REDACTION)
+	operations *operations.Manager // Задается RegisterOperations
+}
+
+// NewExportService создает сервис экспорта
+// redactPolicy nil - поля в файле не скрываются
+func NewExportService(queries *repository.Queries, store storage.BlobStore, signer *signedurl.Signer, runtimeSettings *settings.Settings, redactPolicy *redact.Policy) *ExportService {
+	return &ExportService{
+		queries:  queries,
+		store:    store,
+		signer:   signer,
+		settings: runtimeSettings,
+		redact:   redactPolicy,
+	}
+}
+
+// RegisterOperations регистрирует операцию export_users: она формирует
+// файл от имени администратора, создавшего экспорт, а при чтении
+// операции отдает задание со свежей ссылкой на скачивание
+func (s *ExportService) RegisterOperations(m *operations.Manager) {
+	s.operations = m
+	m.Register(operations.KindExportUsers, s.runExport)
+	m.RegisterPresenter(operations.KindExportUsers, s.presentExport)
+}
+
+// CreateExport создает задание на экспорт и запускает операцию
+// export_users, которая сформирует файл
+func (s *ExportService) CreateExport(ctx context.Context, req models.CreateExportRequest) (*models.OperationResponse, error) {
+	if req.Resource != "users" {
+		return nil, fmt.Errorf("%w: неподдерживаемый ресурс %s", ErrInvalidExport, req.Resource)
+	}
+	if req.Format != ExportFormatCSV && req.Format != ExportFormatNDJSON {
+		return nil, fmt.Errorf("%w: неподдерживаемый формат %s", ErrInvalidExport, req.Format)
+	}
+
+	job, err := s.queries.CreateExportJob(ctx, repository.CreateExportJobParams{
+		Resource: req.Resource,
+		Format:   req.Format,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания задания экспорта: %w", err)
+	}
+
+	op, err := s.operations.Start(ctx, operations.KindExportUsers, ExportInput{ExportID: int(job.ID)})
+	if err != nil {
+		// Без операции задание навсегда осталось бы pending
+		s.fail(ctx, job.ID, err)
+		return nil, err
+	}
+	return op, nil
+}
+
+// GetExport возвращает статус задания
+// Для завершенного задания в ответ добавляется подписанная временная ссылка,
+// baseURL - адрес сервиса, от которого строится ссылка
+func (s *ExportService) GetExport(ctx context.Context, id int, baseURL string) (*models.ExportJobResponse, error) {
+	job, err := s.queries.GetExportJob(ctx, int32(id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrExportNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения задания экспорта: %w", err)
+	}
+
+	return s.toExportJobResponse(&job, baseURL), nil
+}
+
+// OpenDownload открывает файл экспорта на чтение
+// Подпись ссылки проверяет middleware.SignedURL до вызова.
+// Вызывающий обязан закрыть reader
+func (s *ExportService) OpenDownload(ctx context.Context, id int) (io.ReadCloser, storage.ObjectInfo, error) {
+	job, err := s.queries.GetExportJob(ctx, int32(id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, storage.ObjectInfo{}, ErrExportNotFound
+		}
+		return nil, storage.ObjectInfo{}, fmt.Errorf("ошибка получения задания экспорта: %w", err)
+	}
+	if job.Status != ExportStatusCompleted || !job.StorageKey.Valid {
+		return nil, storage.ObjectInfo{}, ErrExportNotReady
+	}
+
+	return s.store.Get(ctx, job.StorageKey.String)
+}
+
+// runExport формирует файл задания (Runner операции export_users)
+func (s *ExportService) runExport(ctx context.Context, raw json.RawMessage) (any, error) {
+	var in ExportInput
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("невалидные параметры экспорта: %w", err)
+	}
+
+	// 1. Переводим задание в running
+	job, err := s.queries.StartExportJob(ctx, int32(in.ExportID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrExportNotFound
+		}
+		return nil, fmt.Errorf("ошибка запуска задания экспорта: %w", err)
+	}
+
+	// 2. Формируем файл
+	key, rowCount, err := s.generate(ctx, &job)
+	if err != nil {
+		// Остановка инстанса: файл сформирует следующая попытка операции
+		if ctx.Err() == nil || jobs.LastAttempt(ctx) {
+			s.fail(ctx, job.ID, err)
+		}
+		return nil, err
+	}
+
+	// 3. Сохраняем ключ файла
+	err = s.queries.CompleteExportJob(ctx, repository.CompleteExportJobParams{
+		ID:         job.ID,
+		StorageKey: pgtype.Text{String: key, Valid: true},
+		RowCount:   int32(rowCount),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка завершения задания %d: %w", job.ID, err)
+	}
+
+	slog.InfoContext(ctx, "📦 Экспорт готов", "job_id", job.ID, "rows", rowCount)
+	return exportResult{ExportID: int(job.ID)}, nil
+}
+
+// presentExport отдает задание завершенной операции export_users
+// со ссылкой на скачивание, выписанной в момент чтения
+func (s *ExportService) presentExport(ctx context.Context, raw json.RawMessage, baseURL string) (any, error) {
+	var result exportResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("невалидный результат экспорта: %w", err)
+	}
+	return s.GetExport(ctx, result.ExportID, baseURL)
+}
+
+// fail сохраняет ошибку задания
+// Статус обновляется даже если ctx отменен, иначе задание зависнет
+func (s *ExportService) fail(ctx context.Context, id int32, cause error) {
+	err := s.queries.FailExportJob(context.WithoutCancel(ctx), repository.FailExportJobParams{
+		ID:    id,
+		Error: pgtype.Text{String: cause.Error(), Valid: true},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "❌ Не удалось сохранить ошибку экспорта", "job_id", id, "error", err)
+	}
+}
+
+// generate формирует файл экспорта и сохраняет его в хранилище
+// Файл сначала пишется во временный файл на диске: так размер известен
+// заранее, а память не зависит от количества пользователей
+func (s *ExportService) generate(ctx context.Context, job *repository.ExportJob) (string, int, error) {
+	tmp, err := os.CreateTemp("", "export-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("ошибка создания временного файла: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	rowCount, err := s.writeUsers(ctx, tmp, job.Format)
+	if err != nil {
+		return "", 0, err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", 0, fmt.Errorf("ошибка чтения временного файла: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", 0, fmt.Errorf("ошибка чтения временного файла: %w", err)
+	}
+
+	key := storage.NewKey("exports", job.Format)
+	if err := s.store.Put(ctx, key, tmp, size, bulk.ContentType(job.Format)); err != nil {
+		return "", 0, err
+	}
+
+	return key, rowCount, nil
+}
+
+// writeUsers пишет всех пользователей в w пачками по exportBatchSize
+func (s *ExportService) writeUsers(ctx context.Context, w io.Writer, format string) (int, error) {
+	enc, err := bulk.NewEncoder(w, format)
+	if err != nil {
+		return 0, err
+	}
+	enc.Redact(redactionFor(ctx, s.redact))
+
+	progress := operations.ProgressFromContext(ctx)
+	rowCount := 0
+	for offset := 0; ; offset += exportBatchSize {
+		users, err := s.queries.ListUsers(ctx, repository.ListUsersParams{
+			Limit:  exportBatchSize,
+			Offset: int32(offset),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("ошибка чтения пользователей: %w", err)
+		}
+
+		for i := range users {
+			if err := enc.Encode(toUserResponse(&users[i])); err != nil {
+				return 0, fmt.Errorf("ошибка записи экспорта: %w", err)
+			}
+		}
+		rowCount += len(users)
+		progress.Add(len(users))
+
+		if len(users) < exportBatchSize {
+			break
+		}
+	}
+
+	if err := enc.Flush(); err != nil {
+		return 0, err
+	}
+	return rowCount, nil
+}
+
+// toExportJobResponse конвертирует задание БД в модель API ответа
+func (s *ExportService) toExportJobResponse(job *repository.ExportJob, baseURL string) *models.ExportJobResponse {
+	resp := &models.ExportJobResponse{
+		ID:        publicid.ID(job.ID),
+		Resource:  job.Resource,
+		Format:    job.Format,
+		Status:    job.Status,
+		RowCount:  int(job.RowCount),
+		CreatedAt: utc.From(job.CreatedAt),
+	}
+
+	if job.Error.Valid {
+		resp.Error = &job.Error.String
+	}
+	resp.CompletedAt = utc.FromNull(job.CompletedAt)
+
+	// Ссылку выдаем только для готового файла
+	if job.Status == ExportStatusCompleted && baseURL != "" {
+		expiresAt := time.Now().Add(s.settings.Duration(settings.ExportURLTTL))
+		url := baseURL + s.signer.Sign("/api/v1/exports/"+resp.ID.String()+"/download", expiresAt)
+		resp.DownloadURL = &url
+		resp.DownloadURLExpiresAt = utc.Ptr(expiresAt)
+	}
+
+	return resp
+}
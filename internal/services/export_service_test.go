@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/operations"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/settings"
+	"github.com/Soundveyve/fiber-backend/internal/signedurl"
+)
+
+// exportDB отдает одну операцию export_users и ее задание экспорта,
+// завершенные completedAt; чтение пользователей завершается ошибкой
+type exportDB struct {
+	repository.DBTX
+	completedAt time.Time
+	fails       int // Вызовы FailExportJob (единственный Exec этих тестов)
+}
+
+func (d *exportDB) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return exportRow{d: d}
+}
+
+func (d *exportDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("БД недоступна")
+}
+
+func (d *exportDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	d.fails++
+	return pgconn.CommandTag{}, nil
+}
+
+// exportRow различает запросы по числу колонок: operations или export_jobs
+type exportRow struct{ d *exportDB }
+
+func (r exportRow) Scan(dest ...interface{}) error {
+	switch len(dest) {
+	case 17: // operations
+		*dest[0].(*uuid.UUID) = uuid.New()
+		*dest[1].(*string) = operations.KindExportUsers
+		*dest[3].(*string) = operations.StatusSucceeded
+		*dest[6].(*json.RawMessage) = json.RawMessage(`{"export_id":42}`)
+		*dest[13].(*time.Time) = r.d.completedAt
+		*dest[16].(*pgtype.Timestamp) = pgtype.Timestamp{Time: r.d.completedAt, Valid: true}
+		return nil
+	case 10: // export_jobs
+		*dest[0].(*int32) = 42
+		*dest[2].(*string) = ExportFormatCSV
+		*dest[3].(*string) = ExportStatusCompleted
+		*dest[4].(*pgtype.Text) = pgtype.Text{String: "exports/42.csv", Valid: true}
+		*dest[7].(*time.Time) = r.d.completedAt
+		*dest[9].(*pgtype.Timestamp) = pgtype.Timestamp{Time: r.d.completedAt, Valid: true}
+		return nil
+	}
+	return errors.New("неожиданный запрос")
+}
+
+func newTestExportService(db *exportDB) (*ExportService, *operations.Manager) {
+	cfg := &config.Config{}
+	cfg.Export.URLTTL = 15 * time.Minute
+	queries := repository.New(db)
+	s := NewExportService(queries, nil, signedurl.NewSigner("secret"), settings.New(nil, settings.Definitions(cfg)), nil)
+	m := operations.New(queries, nil)
+	s.RegisterOperations(m)
+	return s, m
+}
+
+func TestExportOperationSignsLinkOnRead(t *testing.T) {
+	// Операция завершилась давно: ссылка, сохраненная тогда, уже истекла бы
+	db := &exportDB{completedAt: time.Now().Add(-2 * time.Hour)}
+	_, m := newTestExportService(db)
+
+	before := time.Now()
+	op, err := m.Get(context.Background(), uuid.NewString(), "https://api.example.com")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	var job models.ExportJobResponse
+	if err := json.Unmarshal(op.Result, &job); err != nil {
+		t.Fatalf("result %s: %v", op.Result, err)
+	}
+	if job.DownloadURL == nil || !strings.HasPrefix(*job.DownloadURL, "https://api.example.com/api/v1/exports/") {
+		t.Fatalf("download_url = %v, ожидалась ссылка на файл", job.DownloadURL)
+	}
+	// Срок ссылки отсчитывается от чтения операции, а не от ее завершения
+	if job.DownloadURLExpiresAt == nil || job.DownloadURLExpiresAt.Before(before.Add(15*time.Minute-time.Second)) {
+		t.Errorf("download_url_expires_at = %v, ожидалось не раньше %v", job.DownloadURLExpiresAt, before.Add(15*time.Minute))
+	}
+	if strings.Contains(string(op.Result), "export_id") {
+		t.Errorf("result = %s, отдан сохраненный результат вместо задания", op.Result)
+	}
+}
+
+func TestRunExportRecordsFailure(t *testing.T) {
+	db := &exportDB{completedAt: time.Now()}
+	s, _ := newTestExportService(db)
+
+	if _, err := s.runExport(context.Background(), json.RawMessage(`{"export_id":42}`)); err == nil {
+		t.Fatal("runExport без доступа к пользователям завершился без ошибки")
+	}
+	// Ошибка сохранена в задании, иначе оно осталось бы running
+	if db.fails != 1 {
+		t.Errorf("FailExportJob вызван %d раз, ожидался 1", db.fails)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/identity"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// identityProviders - провайдеры, которые можно привязать к аккаунту
+var identityProviders = map[string]bool{
+	"google": true,
+	"github": true,
+	"apple":  true,
+}
+
+// ErrIdentityNotFound возвращается, когда привязки к провайдеру нет
+var ErrIdentityNotFound = apperrors.NotFound("IDENTITY_NOT_FOUND", "учетная запись провайдера не привязана")
+
+// ErrIdentityAlreadyLinked возвращается, когда учетная запись провайдера
+// уже привязана к этому или другому пользователю, либо у пользователя
+// уже есть привязка к этому провайдеру
+var ErrIdentityAlreadyLinked = apperrors.Conflict("IDENTITY_ALREADY_LINKED", "учетная запись провайдера уже привязана")
+
+// ErrLastLoginMethod возвращается при попытке отвязать последний способ входа
+var ErrLastLoginMethod = apperrors.Conflict("LAST_LOGIN_METHOD", "нельзя отвязать последний способ входа")
+
+// IdentityService управляет привязкой внешних (OAuth) учетных записей
+type IdentityService struct {
+	queries  *repository.Queries
+	tx       Transactor
+	verifier identity.Verifier
+}
+
+// NewIdentityService создает сервис привязки учетных записей
+func NewIdentityService(queries *repository.Queries, db Beginner, verifier identity.Verifier) *IdentityService {
+	return &IdentityService{
+		queries:  queries,
+		tx:       NewTransactor(db, queries),
+		verifier: verifier,
+	}
+}
+
+// ListIdentities возвращает способы входа пользователя
+func (s *IdentityService) ListIdentities(ctx context.Context, userID int) (*models.ListIdentitiesResponse, error) {
+	user, err := s.queries.GetUserByID(ctx, int32(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	identities, err := s.queries.ListUserIdentities(ctx, int32(userID))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения привязок: %w", err)
+	}
+
+	resp := &models.ListIdentitiesResponse{
+		HasPassword: hasPassword(&user),
+		Identities:  make([]models.IdentityResponse, len(identities)),
+	}
+	for i := range identities {
+		resp.Identities[i] = *toIdentityResponse(&identities[i])
+	}
+	return resp, nil
+}
+
+// LinkIdentity привязывает учетную запись провайдера к пользователю
+// Токен проверяется у провайдера, ID пользователя провайдера берется из проверки
+func (s *IdentityService) LinkIdentity(ctx context.Context, userID int, req models.LinkIdentityRequest) (*models.IdentityResponse, error) {
+	if !identityProviders[req.Provider] {
+		return nil, identity.ErrProviderNotSupported
+	}
+
+	// 1. Проверяем токен у провайдера
+	external, err := s.verifier.Verify(ctx, req.Provider, req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Сохраняем привязку
+	linked, err := s.queries.CreateUserIdentity(ctx, repository.CreateUserIdentityParams{
+		UserID:         int32(userID),
+		Provider:       external.Provider,
+		ProviderUserID: external.ProviderUserID,
+		Email:          pgtype.Text{String: external.Email, Valid: external.Email != ""},
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrIdentityAlreadyLinked
+		}
+		return nil, fmt.Errorf("ошибка привязки учетной записи: %w", err)
+	}
+
+	return toIdentityResponse(&linked), nil
+}
+
+// UnlinkIdentity отвязывает учетную запись провайдера
+//
+// Пользователь без пароля не может отвязать последнюю привязку - иначе
+// он потеряет доступ к аккаунту. Проверка и удаление выполняются в
+// транзакции под блокировкой пользователя, чтобы два параллельных запроса
+// не отвязали две последние привязки одновременно.
+func (s *IdentityService) UnlinkIdentity(ctx context.Context, userID int, provider string) error {
+	return s.tx.WithTx(ctx, func(q *repository.Queries) error {
+		// 1. Блокируем пользователя
+		user, err := q.GetUserForUpdate(ctx, int32(userID))
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("ошибка получения пользователя: %w", err)
+		}
+
+		// 2. Проверяем, что останется другой способ входа
+		count, err := q.CountUserIdentities(ctx, int32(userID))
+		if err != nil {
+			return fmt.Errorf("ошибка подсчета привязок: %w", err)
+		}
+
+		// 3. Удаляем привязку
+		rows, err := q.DeleteUserIdentity(ctx, repository.DeleteUserIdentityParams{
+			UserID:   int32(userID),
+			Provider: provider,
+		})
+		if err != nil {
+			return fmt.Errorf("ошибка отвязки учетной записи: %w", err)
+		}
+		if rows == 0 {
+			return ErrIdentityNotFound
+		}
+		if !hasPassword(&user) && count <= 1 {
+			return ErrLastLoginMethod
+		}
+		return nil
+	})
+}
+
+// hasPassword сообщает, может ли пользователь войти паролем
+// Аккаунты, созданные только через провайдера, хранят пустой хеш
+func hasPassword(user *repository.User) bool {
+	return user.PasswordHash != ""
+}
+
+// toIdentityResponse конвертирует модель БД в модель API
+func toIdentityResponse(i *repository.UserIdentity) *models.IdentityResponse {
+	resp := &models.IdentityResponse{
+		Provider: i.Provider,
+		LinkedAt: utc.From(i.CreatedAt),
+	}
+	if i.Email.Valid {
+		resp.Email = &i.Email.String
+	}
+	return resp
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/archive"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// createLoginHistory записывает завершенный вход с адресом и
+// User-Agent клиента из ctx
+func createLoginHistory(ctx context.Context, q *repository.Queries, userID int) error {
+	params := repository.CreateLoginHistoryParams{UserID: int32(userID)}
+	if client, ok := reqctx.ClientFromContext(ctx); ok {
+		params.Ip = pgtype.Text{String: client.IP, Valid: client.IP != ""}
+		params.UserAgent = pgtype.Text{String: client.UserAgent, Valid: client.UserAgent != ""}
+	}
+	if err := q.CreateLoginHistory(ctx, params); err != nil {
+		return fmt.Errorf("ошибка записи истории входов: %w", err)
+	}
+	return nil
+}
+
+// LoginHistoryArchiveSource - login_history как источник архива журналов
+type LoginHistoryArchiveSource struct {
+	queries *repository.Queries
+}
+
+// NewLoginHistoryArchiveSource создает источник архива для login_history
+func NewLoginHistoryArchiveSource(queries *repository.Queries) *LoginHistoryArchiveSource {
+	return &LoginHistoryArchiveSource{queries: queries}
+}
+
+// Name возвращает имя таблицы истории входов
+func (s *LoginHistoryArchiveSource) Name() string {
+	return "login_history"
+}
+
+// Batch возвращает пачку записей старше before по возрастанию ID
+func (s *LoginHistoryArchiveSource) Batch(ctx context.Context, before time.Time, limit int) (archive.Batch, error) {
+	rows, err := s.queries.ListLoginHistoryForArchive(ctx, repository.ListLoginHistoryForArchiveParams{
+		CreatedBefore: before,
+		Limit:         int32(limit),
+	})
+	if err != nil {
+		return archive.Batch{}, err
+	}
+	return loginHistoryBatch(rows), nil
+}
+
+// Delete удаляет записи пачки из login_history
+func (s *LoginHistoryArchiveSource) Delete(ctx context.Context, q *repository.Queries, batch archive.Batch) (int64, error) {
+	return q.DeleteArchivedLoginHistory(ctx, repository.DeleteArchivedLoginHistoryParams{
+		FirstID:       batch.FirstID,
+		LastID:        batch.LastID,
+		CreatedBefore: batch.Before,
+	})
+}
+
+// loginHistoryBatch собирает пачку архива из строк по возрастанию ID
+func loginHistoryBatch(rows []repository.ListLoginHistoryForArchiveRow) archive.Batch {
+	if len(rows) == 0 {
+		return archive.Batch{}
+	}
+	batch := archive.Batch{
+		Entries: make([]any, 0, len(rows)),
+		FirstID: rows[0].ID,
+		LastID:  rows[len(rows)-1].ID,
+		From:    rows[0].CreatedAt,
+		To:      rows[0].CreatedAt,
+	}
+	for _, row := range rows {
+		// Параллельные входы фиксируются не в порядке ID: период
+		// считается по всем записям
+		if row.CreatedAt.Before(batch.From) {
+			batch.From = row.CreatedAt
+		}
+		if row.CreatedAt.After(batch.To) {
+			batch.To = row.CreatedAt
+		}
+		entry := models.LoginHistoryEntry{
+			ID:        publicid.ID(row.ID),
+			UserID:    row.UserPublicID.String(),
+			CreatedAt: utc.From(row.CreatedAt),
+		}
+		if row.Ip.Valid {
+			ip := row.Ip.String
+			entry.IP = &ip
+		}
+		if row.UserAgent.Valid {
+			userAgent := row.UserAgent.String
+			entry.UserAgent = &userAgent
+		}
+		batch.Entries = append(batch.Entries, entry)
+	}
+	return batch
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+)
+
+// historyDB запоминает аргументы изменений по имени запроса
+type historyDB struct {
+	repository.DBTX
+	calls map[string][]interface{}
+}
+
+func (d *historyDB) Exec(_ context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	d.calls[queryName(sql)] = args
+	return pgconn.CommandTag{}, nil
+}
+
+func TestRecordLoginWritesHistory(t *testing.T) {
+	db := &historyDB{calls: map[string][]interface{}{}}
+	ctx := reqctx.WithClient(context.Background(), reqctx.Client{IP: "203.0.113.7", UserAgent: "curl/8.0"})
+	user := &models.UserResponse{InternalID: 42, LoginCount: 3}
+
+	if err := recordLogin(ctx, repository.New(db), user); err != nil {
+		t.Fatalf("recordLogin: %v", err)
+	}
+
+	args, ok := db.calls["CreateLoginHistory"]
+	if !ok {
+		t.Fatal("вход не записан в login_history")
+	}
+	if args[0] != int32(42) || args[1].(pgtype.Text).String != "203.0.113.7" || args[2].(pgtype.Text).String != "curl/8.0" {
+		t.Errorf("CreateLoginHistory(%v), ожидались пользователь 42 и клиент из контекста", args)
+	}
+	if user.LoginCount != 4 || user.LastLoginAt == nil {
+		t.Errorf("ответ не отражает вход: %d, %v", user.LoginCount, user.LastLoginAt)
+	}
+}
+
+func TestLoginHistoryBatch(t *testing.T) {
+	if batch := loginHistoryBatch(nil); batch.Entries != nil || batch.LastID != 0 {
+		t.Errorf("пустая пачка = %+v", batch)
+	}
+
+	base := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	rows := []repository.ListLoginHistoryForArchiveRow{
+		{ID: 7, UserPublicID: userID, Ip: pgtype.Text{String: "203.0.113.7", Valid: true}, CreatedAt: base.Add(time.Second)},
+		// Параллельный вход зафиксирован раньше записи с меньшим ID
+		{ID: 8, UserPublicID: userID, CreatedAt: base},
+		{ID: 9, UserPublicID: userID, UserAgent: pgtype.Text{String: "curl/8.0", Valid: true}, CreatedAt: base.Add(time.Minute)},
+	}
+
+	batch := loginHistoryBatch(rows)
+	if batch.FirstID != 7 || batch.LastID != 9 {
+		t.Errorf("ID = %d-%d, ожидалось 7-9", batch.FirstID, batch.LastID)
+	}
+	if !batch.From.Equal(base) || !batch.To.Equal(base.Add(time.Minute)) {
+		t.Errorf("период = %v - %v, ожидалось %v - %v", batch.From, batch.To, base, base.Add(time.Minute))
+	}
+
+	// Записи хранятся в JSON представлении: публичный ID пользователя и
+	// created_at, по которому архив фильтрует период
+	data, err := json.Marshal(batch.Entries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["user_id"] != userID.String() || entry["ip"] != "203.0.113.7" || entry["created_at"] == nil {
+		t.Errorf("запись архива = %s", data)
+	}
+	if _, ok := entry["user_agent"]; ok {
+		t.Errorf("запись архива = %s, пустой user_agent не должен попадать в файл", data)
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/origins"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	// ErrCORSOriginNotFound возвращается, когда разрешенного источника нет
+	ErrCORSOriginNotFound = apperrors.NotFound("CORS_ORIGIN_NOT_FOUND", "источник не найден")
+
+	// ErrCORSOriginExists возвращается при повторном разрешении источника
+	ErrCORSOriginExists = apperrors.Conflict("CORS_ORIGIN_EXISTS", "источник уже разрешен")
+
+	// ErrTrustedClientNotFound возвращается, когда клиента нет
+	ErrTrustedClientNotFound = apperrors.NotFound("TRUSTED_CLIENT_NOT_FOUND", "клиент не найден")
+
+	// ErrTrustedClientExists возвращается при повторной регистрации имени клиента
+	ErrTrustedClientExists = apperrors.Conflict("TRUSTED_CLIENT_EXISTS", "клиент с таким именем уже зарегистрирован")
+)
+
+// OriginService управляет источниками CORS и собственными клиентами
+// После каждого изменения перечитывает origins.Registry, чтобы изменение
+// действовало на этом инстансе сразу, а не после следующего обновления
+type OriginService struct {
+	queries  *repository.Queries
+	registry *origins.Registry
+}
+
+// NewOriginService создает сервис источников CORS
+func NewOriginService(queries *repository.Queries, registry *origins.Registry) *OriginService {
+	return &OriginService{
+		queries:  queries,
+		registry: registry,
+	}
+}
+
+// CreateOrigin разрешает источник
+func (s *OriginService) CreateOrigin(ctx context.Context, req models.CreateCORSOriginRequest, createdBy int) (*models.CORSOriginResponse, error) {
+	origin, err := origins.Normalize(req.Origin)
+	if err != nil {
+		return nil, apperrors.Invalid("INVALID_ORIGIN", err.Error())
+	}
+
+	row, err := s.queries.CreateCORSOrigin(ctx, repository.CreateCORSOriginParams{
+		Origin:      origin,
+		Description: pgtype.Text{String: req.Description, Valid: req.Description != ""},
+		CreatedBy:   pgtype.Int4{Int32: int32(createdBy), Valid: createdBy != 0},
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrCORSOriginExists
+		}
+		return nil, fmt.Errorf("ошибка создания источника: %w", err)
+	}
+
+	s.reload(ctx)
+	return toCORSOriginResponse(&row), nil
+}
+
+// ListOrigins возвращает разрешенные источники
+// Источники из CORS_ALLOW_ORIGINS и клиентов в список не входят
+func (s *OriginService) ListOrigins(ctx context.Context) (*models.ListCORSOriginsResponse, error) {
+	rows, err := s.queries.ListCORSOrigins(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения источников: %w", err)
+	}
+
+	resp := &models.ListCORSOriginsResponse{Origins: make([]models.CORSOriginResponse, 0, len(rows))}
+	for i := range rows {
+		resp.Origins = append(resp.Origins, *toCORSOriginResponse(&rows[i]))
+	}
+	return resp, nil
+}
+
+// DeleteOrigin запрещает источник
+func (s *OriginService) DeleteOrigin(ctx context.Context, id int) error {
+	rows, err := s.queries.DeleteCORSOrigin(ctx, int32(id))
+	if err != nil {
+		return fmt.Errorf("ошибка удаления источника: %w", err)
+	}
+	if rows == 0 {
+		return ErrCORSOriginNotFound
+	}
+
+	s.reload(ctx)
+	return nil
+}
+
+// CreateClient регистрирует собственного клиента с его источниками
+func (s *OriginService) CreateClient(ctx context.Context, req models.CreateTrustedClientRequest, createdBy int) (*models.TrustedClientResponse, error) {
+	clientOrigins, err := normalizeOrigins(req.Origins)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.queries.CreateTrustedClient(ctx, repository.CreateTrustedClientParams{
+		Name:        req.Name,
+		Description: pgtype.Text{String: req.Description, Valid: req.Description != ""},
+		Origins:     clientOrigins,
+		CreatedBy:   pgtype.Int4{Int32: int32(createdBy), Valid: createdBy != 0},
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrTrustedClientExists
+		}
+		return nil, fmt.Errorf("ошибка регистрации клиента: %w", err)
+	}
+
+	s.reload(ctx)
+	return toTrustedClientResponse(&client), nil
+}
+
+// ListClients возвращает собственных клиентов
+func (s *OriginService) ListClients(ctx context.Context) (*models.ListTrustedClientsResponse, error) {
+	clients, err := s.queries.ListTrustedClients(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения клиентов: %w", err)
+	}
+
+	resp := &models.ListTrustedClientsResponse{Clients: make([]models.TrustedClientResponse, 0, len(clients))}
+	for i := range clients {
+		resp.Clients = append(resp.Clients, *toTrustedClientResponse(&clients[i]))
+	}
+	return resp, nil
+}
+
+// UpdateClient заменяет описание и источники клиента
+func (s *OriginService) UpdateClient(ctx context.Context, id int, req models.UpdateTrustedClientRequest) (*models.TrustedClientResponse, error) {
+	clientOrigins, err := normalizeOrigins(req.Origins)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.queries.UpdateTrustedClient(ctx, repository.UpdateTrustedClientParams{
+		ID:          int32(id),
+		Description: pgtype.Text{String: req.Description, Valid: req.Description != ""},
+		Origins:     clientOrigins,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrTrustedClientNotFound
+		}
+		return nil, fmt.Errorf("ошибка изменения клиента: %w", err)
+	}
+
+	s.reload(ctx)
+	return toTrustedClientResponse(&client), nil
+}
+
+// DeleteClient удаляет клиента; его источники перестают быть разрешены
+func (s *OriginService) DeleteClient(ctx context.Context, id int) error {
+	rows, err := s.queries.DeleteTrustedClient(ctx, int32(id))
+	if err != nil {
+		return fmt.Errorf("ошибка удаления клиента: %w", err)
+	}
+	if rows == 0 {
+		return ErrTrustedClientNotFound
+	}
+
+	s.reload(ctx)
+	return nil
+}
+
+// reload перечитывает реестр после изменения
+// Изменение уже сохранено, поэтому ошибка только логируется:
+// реестр догонит его при следующем обновлении
+func (s *OriginService) reload(ctx context.Context) {
+	if err := s.registry.Load(ctx); err != nil {
+		slog.ErrorContext(ctx, "❌ Ошибка обновления источников CORS", "error", err)
+	}
+}
+
+// normalizeOrigins нормализует источники клиента и убирает повторы
+func normalizeOrigins(values []string) ([]string, error) {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		origin, err := origins.Normalize(value)
+		if err != nil {
+			return nil, apperrors.Invalid("INVALID_ORIGIN", err.Error())
+		}
+		result = append(result, origin)
+	}
+	return dedupe(result), nil
+}
+
+// toCORSOriginResponse преобразует источник для API
+func toCORSOriginResponse(row *repository.CorsOrigin) *models.CORSOriginResponse {
+	resp := &models.CORSOriginResponse{
+		ID:        publicid.ID(row.ID),
+		Origin:    row.Origin,
+		CreatedAt: utc.From(row.CreatedAt),
+	}
+	if row.Description.Valid {
+		resp.Description = &row.Description.String
+	}
+	return resp
+}
+
+// toTrustedClientResponse преобразует клиента для API
+func toTrustedClientResponse(client *repository.TrustedClient) *models.TrustedClientResponse {
+	resp := &models.TrustedClientResponse{
+		ID:        publicid.ID(client.ID),
+		Name:      client.Name,
+		Origins:   client.Origins,
+		CreatedAt: utc.From(client.CreatedAt),
+		UpdatedAt: utc.From(client.UpdatedAt),
+	}
+	if client.Description.Valid {
+		resp.Description = &client.Description.String
+	}
+	return resp
+}
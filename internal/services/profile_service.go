@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/lifecycle"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrProfileNotFound возвращается, когда публичного профиля нет
+// Заблокированные и выключенные аккаунты отвечают так же, как
+// несуществующие: по ответу нельзя узнать, что аккаунт был
+var ErrProfileNotFound = apperrors.NotFound("PROFILE_NOT_FOUND", "профиль не найден")
+
+// ProfileMovedError возвращается при запросе профиля по прежнему имени
+// пользователя: Username - текущее имя, по которому профиль доступен
+type ProfileMovedError struct {
+	Username string
+}
+
+func (e *ProfileMovedError) Error() string {
+	return "профиль перемещен: " + e.Username
+}
+
+// ProfileService отдает публичные профили пользователей и управляет
+// настройками их приватности
+type ProfileService struct {
+	queries *repository.Queries
+}
+
+// NewProfileService создает сервис публичных профилей
+func NewProfileService(queries *repository.Queries) *ProfileService {
+	return &ProfileService{
+		queries: queries,
+	}
+}
+
+// GetPublicProfile возвращает публичный профиль по username
+// Профиль есть только у аккаунтов, которым разрешен вход.
+// По прежнему имени переименованного пользователя возвращается
+// *ProfileMovedError с текущим именем
+func (s *ProfileService) GetPublicProfile(ctx context.Context, username string) (*models.PublicProfileResponse, error) {
+	user, err := s.queries.GetUserByUsername(ctx, username)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, s.renamedProfile(ctx, username)
+		}
+		return nil, fmt.Errorf("ошибка получения профиля: %w", err)
+	}
+	if !lifecycle.Status(user.Status).CanSignIn() {
+		return nil, ErrProfileNotFound
+	}
+
+	return toPublicProfileResponse(&user), nil
+}
+
+// PublicAvatarOwner возвращает ID пользователя, аватар которого виден
+// в публичном профиле username
+// Нет профиля - ErrProfileNotFound, аватар скрыт или не загружен -
+// ErrAvatarNotFound: по ответу нельзя отличить скрытый аватар от отсутствующего
+func (s *ProfileService) PublicAvatarOwner(ctx context.Context, username string) (int, error) {
+	user, err := s.queries.GetUserByUsername(ctx, username)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, ErrProfileNotFound
+		}
+		return 0, fmt.Errorf("ошибка получения профиля: %w", err)
+	}
+	if !lifecycle.Status(user.Status).CanSignIn() {
+		return 0, ErrProfileNotFound
+	}
+	if !user.ShowAvatar || !user.AvatarKey.Valid {
+		return 0, ErrAvatarNotFound
+	}
+	return int(user.ID), nil
+}
+
+// renamedProfile ищет текущее имя пользователя по прежнему
+// Возвращает *ProfileMovedError или ErrProfileNotFound, если имя
+// никому не принадлежало
+func (s *ProfileService) renamedProfile(ctx context.Context, username string) error {
+	current, err := s.queries.GetRenamedUsername(ctx, username)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrProfileNotFound
+		}
+		return fmt.Errorf("ошибка поиска прежнего имени: %w", err)
+	}
+	return &ProfileMovedError{Username: current}
+}
+
+// GetPrivacy возвращает настройки приватности пользователя
+func (s *ProfileService) GetPrivacy(ctx context.Context, userID int) (*models.PrivacySettingsResponse, error) {
+	user, err := s.queries.GetUserByID(ctx, int32(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	return toPrivacySettingsResponse(&user), nil
+}
+
+// UpdatePrivacy меняет настройки приватности пользователя
+// Поля, отсутствующие в запросе, не меняются
+func (s *ProfileService) UpdatePrivacy(ctx context.Context, userID int, req models.UpdatePrivacySettingsRequest) (*models.PrivacySettingsResponse, error) {
+	params := repository.UpdateUserPrivacyParams{ID: int32(userID)}
+	if req.ShowName != nil {
+		params.ShowName = pgtype.Bool{Bool: *req.ShowName, Valid: true}
+	}
+	if req.ShowAvatar != nil {
+		params.ShowAvatar = pgtype.Bool{Bool: *req.ShowAvatar, Valid: true}
+	}
+
+	user, err := s.queries.UpdateUserPrivacy(ctx, params)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("ошибка изменения настроек приватности: %w", err)
+	}
+
+	return toPrivacySettingsResponse(&user), nil
+}
+
+// toPublicProfileResponse оставляет в профиле только разрешенные пользователем поля
+func toPublicProfileResponse(user *repository.User) *models.PublicProfileResponse {
+	resp := &models.PublicProfileResponse{
+		Username:  user.Username,
+		CreatedAt: utc.From(user.CreatedAt),
+	}
+
+	if user.ShowName {
+		if user.FirstName.Valid {
+			resp.FirstName = &user.FirstName.String
+		}
+		if user.LastName.Valid {
+			resp.LastName = &user.LastName.String
+		}
+	}
+	// Ссылка строится по username: публичный ID в профиль не попадает
+	if user.ShowAvatar && user.AvatarKey.Valid {
+		url := profileAvatarURL(user.Username, user.AvatarKey.String)
+		resp.AvatarURL = &url
+	}
+
+	return resp
+}
+
+// toPrivacySettingsResponse преобразует настройки приватности для API
+func toPrivacySettingsResponse(user *repository.User) *models.PrivacySettingsResponse {
+	return &models.PrivacySettingsResponse{
+		ShowName:   user.ShowName,
+		ShowAvatar: user.ShowAvatar,
+	}
+}
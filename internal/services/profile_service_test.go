@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestPublicProfileHonoursPrivacy(t *testing.T) {
+	user := repository.User{
+		PublicID:  uuid.New(),
+		Username:  "ivan",
+		FirstName: pgtype.Text{String: "Иван", Valid: true},
+		LastName:  pgtype.Text{String: "Петров", Valid: true},
+		AvatarKey: pgtype.Text{String: "avatars/1.png", Valid: true},
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	// По умолчанию имя скрыто, аватар виден
+	user.ShowAvatar = true
+	resp := toPublicProfileResponse(&user)
+	if resp.FirstName != nil || resp.LastName != nil {
+		t.Fatalf("имя не должно отдаваться: %v %v", resp.FirstName, resp.LastName)
+	}
+	if resp.AvatarURL == nil {
+		t.Fatal("аватар должен отдаваться")
+	}
+	// Ссылка ведет на профиль по username и не раскрывает публичный ID
+	if want := "/api/v1/profiles/ivan/avatar?v=1"; *resp.AvatarURL != want {
+		t.Fatalf("avatar_url = %s, want %s", *resp.AvatarURL, want)
+	}
+
+	user.ShowName = true
+	user.ShowAvatar = false
+	resp = toPublicProfileResponse(&user)
+	if resp.FirstName == nil || *resp.FirstName != "Иван" || resp.LastName == nil || *resp.LastName != "Петров" {
+		t.Fatalf("имя должно отдаваться: %v %v", resp.FirstName, resp.LastName)
+	}
+	if resp.AvatarURL != nil {
+		t.Fatalf("аватар не должен отдаваться: %s", *resp.AvatarURL)
+	}
+	if resp.Username != "ivan" {
+		t.Fatalf("username = %q", resp.Username)
+	}
+}
+
+func TestPublicProfileWithoutAvatar(t *testing.T) {
+	user := repository.User{Username: "ivan", ShowAvatar: true}
+	if resp := toPublicProfileResponse(&user); resp.AvatarURL != nil {
+		t.Fatalf("без загруженного аватара ссылки быть не должно: %s", *resp.AvatarURL)
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/redact"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+)
+
+// redactionFor возвращает правила скрытия полей (RESPONSE_REDACTION)
+// для вызывающего из ctx и его внутренний ID
+// Фоновая задача без пользователя получает только правила "*"
+func redactionFor(ctx context.Context, policy *redact.Policy) (redact.Rules, int) {
+	caller, _ := reqctx.UserFromContext(ctx)
+	return policy.For(caller.Role, reqctx.TenantFromContext(ctx)), caller.ID
+}
+
+// RedactUser скрывает поля пользователя по политике RESPONSE_REDACTION
+// для вызывающего из ctx - для ответов не JSON телом (gRPC), которые
+// не проходят через middleware.Redact
+//
+// toUserResponse политику не применяет: ее результат пишется и в журнал
+// аудита и вебхуки, где поля нужны полностью. Собственная запись
+// вызывающего возвращается как есть
+func (s *UserService) RedactUser(ctx context.Context, user *models.UserResponse) (*models.UserResponse, error) {
+	rules, callerID := redactionFor(ctx, s.redact)
+	if len(rules) == 0 || (callerID != 0 && callerID == user.InternalID) {
+		return user, nil
+	}
+
+	redacted, err := redact.Copy(user, rules)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка скрытия полей пользователя: %w", err)
+	}
+	redacted.InternalID = user.InternalID
+	return redacted, nil
+}
+
+// PublicID возвращает публичный ID пользователя по внутреннему:
+// middleware.Redact узнает по нему собственную запись вызывающего
+func (s *UserService) PublicID(ctx context.Context, id int) (string, error) {
+	user, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return user.ID, nil
+}
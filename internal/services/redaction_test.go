@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/redact"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+)
+
+func TestRedactUser(t *testing.T) {
+	policy, err := redact.Parse("*:phone=mask; user:email=mask")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &UserService{redact: policy}
+	phone := "+79123456789"
+	user := &models.UserResponse{InternalID: 7, ID: "pub-7", Email: "ivan@example.com", Phone: &phone}
+
+	caller := func(id int, role string) context.Context {
+		return reqctx.WithUser(context.Background(), reqctx.User{ID: id, Role: role})
+	}
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantEmail string
+		wantPhone string
+	}{
+		{"чужая запись", caller(1, auth.RoleUser), "i***@example.com", "+7********89"},
+		{"своя запись", caller(7, auth.RoleUser), "ivan@example.com", "+79123456789"},
+		{"администратор", caller(1, auth.RoleAdmin), "ivan@example.com", "+7********89"},
+		// Фоновая задача получает только правила "*"
+		{"без вызывающего", context.Background(), "ivan@example.com", "+7********89"},
+	}
+	for _, tc := range tests {
+		got, err := s.RedactUser(tc.ctx, user)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got.Email != tc.wantEmail || got.Phone == nil || *got.Phone != tc.wantPhone {
+			t.Errorf("%s: %s %v, ожидалось %s %s", tc.name, got.Email, got.Phone, tc.wantEmail, tc.wantPhone)
+		}
+		if got.InternalID != user.InternalID {
+			t.Errorf("%s: InternalID = %d, потерян", tc.name, got.InternalID)
+		}
+	}
+	// Исходный ответ не меняется: его же пишут аудит и вебхуки
+	if user.Email != "ivan@example.com" || *user.Phone != phone {
+		t.Errorf("исходная запись изменена: %s %s", user.Email, *user.Phone)
+	}
+}
@@ -0,0 +1,426 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/audit"
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/lifecycle"
+	"github.com/Soundveyve/fiber-backend/internal/mailer"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+)
+
+// Статусы запроса восстановления доступа
+const (
+	TwoFactorRecoveryEmailPending = "email_pending" // Ждет перехода по ссылке из письма
+	TwoFactorRecoveryWaiting      = "waiting"       // Email подтвержден, идет период ожидания
+	TwoFactorRecoveryCompleted    = "completed"     // 2FA отключена, токены выданы
+	TwoFactorRecoveryCancelled    = "cancelled"     // Владелец вошел с кодом 2FA
+	TwoFactorRecoveryRejected     = "rejected"      // Отклонен администратором
+)
+
+// ErrTwoFactorRecoveryNotFound возвращается, если открытого запроса нет
+var ErrTwoFactorRecoveryNotFound = apperrors.NotFound("TWO_FACTOR_RECOVERY_NOT_FOUND", "запрос восстановления не найден")
+
+// ErrTwoFactorNotEnabled возвращается при восстановлении доступа
+// пользователю без 2FA
+var ErrTwoFactorNotEnabled = apperrors.Conflict("TWO_FACTOR_NOT_ENABLED", "двухфакторная аутентификация не включена")
+
+// ErrTwoFactorRecoveryNotReady возвращается при завершении запроса
+// до подтверждения email, окончания ожидания или одобрения
+var ErrTwoFactorRecoveryNotReady = apperrors.Conflict("TWO_FACTOR_RECOVERY_NOT_READY", "восстановление еще нельзя завершить: проверьте статус запроса")
+
+// ErrTwoFactorRecoveryNotPending возвращается при одобрении или отклонении
+// закрытого запроса, а также при повторном или ненужном одобрении
+var ErrTwoFactorRecoveryNotPending = apperrors.Conflict("TWO_FACTOR_RECOVERY_NOT_PENDING", "запрос закрыт, уже одобрен или не требует одобрения")
+
+// TwoFactorRecoveryService восстанавливает доступ пользователю,
+// который потерял и приложение-аутентификатор, и коды восстановления
+//
+// Пароль проверен (запросы идут с токеном two_factor), но этого мало:
+//  1. пользователь подтверждает запрос по ссылке из письма
+//  2. проходит период ожидания (TWO_FACTOR_RECOVERY_DELAY). Если пароль
+//     утек, владелец успеет заметить письмо: вход с кодом 2FA отменяет запрос
+//  3. если включено TWO_FACTOR_RECOVERY_APPROVAL - запрос одобряет администратор
+//
+// После этого AuthService.CompleteTwoFactorRecovery отключает 2FA и выдает
+// токены. Каждый этап записывается в журнал аудита
+type TwoFactorRecoveryService struct {
+	queries  *repository.Queries
+	account  *AccountService // Ссылки из писем и отправка писем
+	delay    time.Duration
+	approval bool
+	audit    *audit.Logger
+}
+
+// NewTwoFactorRecoveryService создает сервис восстановления доступа
+func NewTwoFactorRecoveryService(queries *repository.Queries, account *AccountService, delay time.Duration, approval bool, auditLog *audit.Logger) *TwoFactorRecoveryService {
+	return &TwoFactorRecoveryService{
+		queries:  queries,
+		account:  account,
+		delay:    delay,
+		approval: approval,
+		audit:    auditLog,
+	}
+}
+
+// Start создает запрос восстановления и отправляет письмо со ссылкой
+// подтверждения. Если открытый запрос уже есть, новый не создается:
+// неподтвержденному отправляется новая ссылка, а период ожидания
+// подтвержденного не начинается заново
+func (s *TwoFactorRecoveryService) Start(ctx context.Context, userID int) (*models.TwoFactorRecoveryResponse, error) {
+	// 1. Пользователь мог быть заблокирован после ввода пароля
+	user, err := s.queries.GetUserByID(ctx, int32(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, auth.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+	if err := signInError(lifecycle.Status(user.Status)); err != nil {
+		return nil, err
+	}
+
+	// 2. 2FA могли отключить после ввода пароля: восстанавливать нечего
+	totp, err := s.queries.GetUserTOTP(ctx, user.ID)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("ошибка получения секрета TOTP: %w", err)
+	}
+	if err == pgx.ErrNoRows || !totp.EnabledAt.Valid {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	// 3. Открытый запрос или новый
+	recovery, err := s.queries.GetActiveTwoFactorRecovery(ctx, user.ID)
+	switch {
+	case err == pgx.ErrNoRows:
+		recovery, err = s.queries.CreateTwoFactorRecovery(ctx, repository.CreateTwoFactorRecoveryParams{
+			UserID:           user.ID,
+			ApprovalRequired: s.approval,
+		})
+		if isUniqueViolation(err) {
+			// Параллельный запрос создал его раньше
+			return s.Status(ctx, userID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка создания запроса восстановления: %w", err)
+		}
+		slog.InfoContext(ctx, "🆘 Запрошено восстановление доступа без 2FA", "user_id", userID)
+		s.audit.Record(ctx, audit.Event{
+			Action:       audit.ActionUserTwoFactorRecoveryStart,
+			TargetUserID: userID,
+			After:        map[string]string{"recovery_status": recovery.Status},
+		})
+	case err != nil:
+		return nil, fmt.Errorf("ошибка получения запроса восстановления: %w", err)
+	}
+
+	// 4. Ссылка подтверждения нужна, пока email не подтвержден
+	if recovery.Status == TwoFactorRecoveryEmailPending {
+		if err := s.sendConfirmation(ctx, &user, recovery.ApprovalRequired); err != nil {
+			return nil, err
+		}
+	}
+
+	return toTwoFactorRecoveryResponse(&recovery, time.Now()), nil
+}
+
+// Status возвращает открытый запрос восстановления пользователя
+func (s *TwoFactorRecoveryService) Status(ctx context.Context, userID int) (*models.TwoFactorRecoveryResponse, error) {
+	recovery, err := s.queries.GetActiveTwoFactorRecovery(ctx, int32(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrTwoFactorRecoveryNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения запроса восстановления: %w", err)
+	}
+	return toTwoFactorRecoveryResponse(&recovery, time.Now()), nil
+}
+
+// ConfirmEmail подтверждает запрос по токену из письма
+// и начинает период ожидания
+func (s *TwoFactorRecoveryService) ConfirmEmail(ctx context.Context, token string) (*models.TwoFactorRecoveryResponse, error) {
+	var recovery repository.TwoFactorRecovery
+	err := s.account.tx.WithTx(ctx, func(q *repository.Queries) error {
+		// 1. Используем токен
+		userID, err := s.account.consumeToken(ctx, q, token, auth.TokenTypeTwoFactorRecovery)
+		if err != nil {
+			return err
+		}
+
+		// 2. Начинаем период ожидания
+		recovery, err = q.ConfirmTwoFactorRecoveryEmail(ctx, repository.ConfirmTwoFactorRecoveryEmailParams{
+			AvailableAt: pgtype.Timestamp{Time: time.Now().UTC().Add(s.delay), Valid: true},
+			UserID:      int32(userID),
+		})
+		if err != nil {
+			// Запрос отменен или отклонен после отправки письма
+			if err == pgx.ErrNoRows {
+				return ErrInvalidAccountToken
+			}
+			return fmt.Errorf("ошибка подтверждения запроса восстановления: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "⏳ Восстановление доступа подтверждено по email", "user_id", recovery.UserID, "available_at", recovery.AvailableAt.Time)
+	s.audit.Record(ctx, audit.Event{
+		Action:       audit.ActionUserTwoFactorRecoveryConfirm,
+		TargetUserID: int(recovery.UserID),
+		Before:       map[string]string{"recovery_status": TwoFactorRecoveryEmailPending},
+		After:        map[string]string{"recovery_status": recovery.Status},
+	})
+
+	return toTwoFactorRecoveryResponse(&recovery, time.Now()), nil
+}
+
+// complete закрывает готовый запрос и отключает 2FA в транзакции q
+// Вызывается из AuthService.CompleteTwoFactorRecovery, который в той же
+// транзакции выдает токены. Все сессии пользователя завершаются
+func (s *TwoFactorRecoveryService) complete(ctx context.Context, q *repository.Queries, userID int) error {
+	// 1. Проверяем, что все этапы пройдены
+	recovery, err := q.GetActiveTwoFactorRecovery(ctx, int32(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrTwoFactorRecoveryNotFound
+		}
+		return fmt.Errorf("ошибка получения запроса восстановления: %w", err)
+	}
+	if !twoFactorRecoveryReady(&recovery, time.Now()) {
+		return ErrTwoFactorRecoveryNotReady
+	}
+
+	// 2. Закрываем запрос; параллельное завершение или отмена
+	// не пройдут условие в WHERE
+	if _, err := q.ResolveTwoFactorRecovery(ctx, repository.ResolveTwoFactorRecoveryParams{
+		Status: TwoFactorRecoveryCompleted,
+		ID:     recovery.ID,
+	}); err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrTwoFactorRecoveryNotReady
+		}
+		return fmt.Errorf("ошибка закрытия запроса восстановления: %w", err)
+	}
+
+	// 3. Отключаем 2FA и завершаем сессии
+	if err := q.DeleteUserTOTP(ctx, int32(userID)); err != nil {
+		return fmt.Errorf("ошибка отключения 2FA: %w", err)
+	}
+	if err := q.DeleteRecoveryCodes(ctx, int32(userID)); err != nil {
+		return fmt.Errorf("ошибка удаления кодов восстановления: %w", err)
+	}
+	if _, err := q.RevokeUserRefreshTokens(ctx, int32(userID)); err != nil {
+		return fmt.Errorf("ошибка отзыва токенов: %w", err)
+	}
+	return nil
+}
+
+// recordCompleted записывает завершение восстановления в лог и аудит
+func (s *TwoFactorRecoveryService) recordCompleted(ctx context.Context, userID int) {
+	slog.InfoContext(ctx, "🔓 Доступ восстановлен, 2FA отключена", "user_id", userID)
+	s.audit.Record(ctx, audit.Event{
+		Action:       audit.ActionUserTwoFactorRecoveryComplete,
+		TargetUserID: userID,
+		Before:       map[string]bool{"two_factor_enabled": true},
+		After:        map[string]bool{"two_factor_enabled": false},
+	})
+}
+
+// CancelOnLogin отменяет открытый запрос после входа с кодом 2FA:
+// у пользователя есть второй фактор, а запрос мог создать тот, кто знает пароль
+// Ошибки только логируются - вход уже состоялся
+func (s *TwoFactorRecoveryService) CancelOnLogin(ctx context.Context, userID int) {
+	recovery, err := s.queries.GetActiveTwoFactorRecovery(ctx, int32(userID))
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			slog.ErrorContext(ctx, "❌ Ошибка получения запроса восстановления", "user_id", userID, "error", err)
+		}
+		return
+	}
+
+	if _, err := s.resolve(ctx, &recovery, TwoFactorRecoveryCancelled, userID, audit.ActionUserTwoFactorRecoveryCancel,
+		"вы вошли с кодом двухфакторной аутентификации"); err != nil && err != ErrTwoFactorRecoveryNotPending {
+		slog.ErrorContext(ctx, "❌ Ошибка отмены запроса восстановления", "user_id", userID, "error", err)
+	}
+}
+
+// ListActive возвращает открытые запросы восстановления для администраторов
+func (s *TwoFactorRecoveryService) ListActive(ctx context.Context) ([]models.TwoFactorRecoveryResponse, error) {
+	rows, err := s.queries.ListActiveTwoFactorRecoveries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения запросов восстановления: %w", err)
+	}
+
+	now := time.Now()
+	result := make([]models.TwoFactorRecoveryResponse, len(rows))
+	for i, row := range rows {
+		recovery := repository.TwoFactorRecovery{
+			ID:               row.ID,
+			UserID:           row.UserID,
+			Status:           row.Status,
+			EmailConfirmedAt: row.EmailConfirmedAt,
+			AvailableAt:      row.AvailableAt,
+			ApprovalRequired: row.ApprovalRequired,
+			ApprovedAt:       row.ApprovedAt,
+			ApprovedBy:       row.ApprovedBy,
+			ResolvedAt:       row.ResolvedAt,
+			ResolvedBy:       row.ResolvedBy,
+			CreatedAt:        row.CreatedAt,
+		}
+		result[i] = *toTwoFactorRecoveryResponse(&recovery, now)
+		result[i].UserID = row.PublicID.String()
+		result[i].Email = row.Email
+		result[i].Username = row.Username
+	}
+	return result, nil
+}
+
+// Approve одобряет запрос от имени администратора adminID
+// Период ожидания одобрение не сокращает
+func (s *TwoFactorRecoveryService) Approve(ctx context.Context, id, adminID int) (*models.TwoFactorRecoveryResponse, error) {
+	recovery, err := s.queries.ApproveTwoFactorRecovery(ctx, repository.ApproveTwoFactorRecoveryParams{
+		ApprovedBy: pgtype.Int4{Int32: int32(adminID), Valid: true},
+		ID:         int32(id),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, s.notPending(ctx, id)
+		}
+		return nil, fmt.Errorf("ошибка одобрения запроса восстановления: %w", err)
+	}
+
+	slog.InfoContext(ctx, "✅ Запрос восстановления одобрен", "recovery_id", id, "user_id", recovery.UserID, "admin_id", adminID)
+	s.audit.Record(ctx, audit.Event{
+		Action:       audit.ActionUserTwoFactorRecoveryApprove,
+		TargetUserID: int(recovery.UserID),
+		Before:       map[string]bool{"recovery_approved": false},
+		After:        map[string]bool{"recovery_approved": true},
+	})
+
+	return toTwoFactorRecoveryResponse(&recovery, time.Now()), nil
+}
+
+// Reject отклоняет запрос от имени администратора adminID
+// Пользователь получает письмо, 2FA остается включенной
+func (s *TwoFactorRecoveryService) Reject(ctx context.Context, id, adminID int) (*models.TwoFactorRecoveryResponse, error) {
+	recovery, err := s.queries.GetTwoFactorRecovery(ctx, int32(id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrTwoFactorRecoveryNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения запроса восстановления: %w", err)
+	}
+
+	return s.resolve(ctx, &recovery, TwoFactorRecoveryRejected, adminID, audit.ActionUserTwoFactorRecoveryReject,
+		"запрос отклонен администратором")
+}
+
+// resolve закрывает открытый запрос статусом status, уведомляет
+// пользователя письмом и записывает действие в аудит
+func (s *TwoFactorRecoveryService) resolve(ctx context.Context, recovery *repository.TwoFactorRecovery, status string, by int, action, reason string) (*models.TwoFactorRecoveryResponse, error) {
+	resolved, err := s.queries.ResolveTwoFactorRecovery(ctx, repository.ResolveTwoFactorRecoveryParams{
+		Status:     status,
+		ResolvedBy: pgtype.Int4{Int32: int32(by), Valid: true},
+		ID:         recovery.ID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrTwoFactorRecoveryNotPending
+		}
+		return nil, fmt.Errorf("ошибка закрытия запроса восстановления: %w", err)
+	}
+
+	slog.InfoContext(ctx, "🛑 Запрос восстановления закрыт", "recovery_id", recovery.ID, "user_id", recovery.UserID, "status", status)
+	s.audit.Record(ctx, audit.Event{
+		Action:       action,
+		TargetUserID: int(recovery.UserID),
+		Before:       map[string]string{"recovery_status": recovery.Status},
+		After:        map[string]string{"recovery_status": status},
+	})
+
+	user, err := s.queries.GetUserByID(ctx, recovery.UserID)
+	if err != nil {
+		slog.ErrorContext(ctx, "❌ Ошибка получения пользователя для письма", "user_id", recovery.UserID, "error", err)
+		return toTwoFactorRecoveryResponse(&resolved, time.Now()), nil
+	}
+	msg, err := mailer.Render(mailer.TemplateTwoFactorRecoveryCancelled, user.Email, mailer.TwoFactorRecoveryCancelledData{
+		Username: user.Username,
+		Reason:   reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.account.deliver(ctx, msg)
+
+	return toTwoFactorRecoveryResponse(&resolved, time.Now()), nil
+}
+
+// notPending различает несуществующий запрос и запрос, который нельзя одобрить
+func (s *TwoFactorRecoveryService) notPending(ctx context.Context, id int) error {
+	if _, err := s.queries.GetTwoFactorRecovery(ctx, int32(id)); err == pgx.ErrNoRows {
+		return ErrTwoFactorRecoveryNotFound
+	}
+	return ErrTwoFactorRecoveryNotPending
+}
+
+// sendConfirmation выпускает ссылку подтверждения и отправляет письмо
+func (s *TwoFactorRecoveryService) sendConfirmation(ctx context.Context, user *repository.User, approvalRequired bool) error {
+	token, err := s.account.issueToken(ctx, user.ID, auth.TokenTypeTwoFactorRecovery)
+	if err != nil {
+		return err
+	}
+
+	msg, err := mailer.Render(mailer.TemplateTwoFactorRecovery, user.Email, mailer.TwoFactorRecoveryData{
+		Username:         user.Username,
+		Link:             s.account.linkBaseURL + "/2fa-recovery?token=" + url.QueryEscape(token.Value),
+		ExpiresAt:        token.ExpiresAt,
+		DelayHours:       int(s.delay.Hours()),
+		ApprovalRequired: approvalRequired,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.account.deliver(ctx, msg)
+	return nil
+}
+
+// twoFactorRecoveryReady сообщает, пройдены ли все этапы запроса к моменту now
+func twoFactorRecoveryReady(recovery *repository.TwoFactorRecovery, now time.Time) bool {
+	if recovery.Status != TwoFactorRecoveryWaiting || !recovery.AvailableAt.Valid {
+		return false
+	}
+	if now.Before(recovery.AvailableAt.Time) {
+		return false
+	}
+	return !recovery.ApprovalRequired || recovery.ApprovedAt.Valid
+}
+
+// toTwoFactorRecoveryResponse преобразует запрос восстановления в ответ API
+func toTwoFactorRecoveryResponse(recovery *repository.TwoFactorRecovery, now time.Time) *models.TwoFactorRecoveryResponse {
+	return &models.TwoFactorRecoveryResponse{
+		ID:               publicid.ID(recovery.ID),
+		Status:           recovery.Status,
+		EmailConfirmedAt: utc.FromNull(recovery.EmailConfirmedAt),
+		AvailableAt:      utc.FromNull(recovery.AvailableAt),
+		ApprovalRequired: recovery.ApprovalRequired,
+		ApprovedAt:       utc.FromNull(recovery.ApprovedAt),
+		Ready:            twoFactorRecoveryReady(recovery, now),
+		CreatedAt:        utc.From(recovery.CreatedAt),
+		ResolvedAt:       utc.FromNull(recovery.ResolvedAt),
+	}
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/audit"
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/totp"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrTwoFactorNotConfigured возвращается при включении 2FA без ключа
+// шифрования секретов (TWO_FACTOR_ENCRYPTION_KEY)
+var ErrTwoFactorNotConfigured = apperrors.Forbidden("TWO_FACTOR_NOT_CONFIGURED", "двухфакторная аутентификация не настроена на сервере")
+
+// ErrTwoFactorAlreadyEnabled возвращается при повторном включении 2FA
+var ErrTwoFactorAlreadyEnabled = apperrors.Conflict("TWO_FACTOR_ALREADY_ENABLED", "двухфакторная аутентификация уже включена")
+
+// ErrTwoFactorNotPending возвращается при подтверждении без запроса секрета
+var ErrTwoFactorNotPending = apperrors.Conflict("TWO_FACTOR_NOT_PENDING", "сначала запросите секрет: POST /api/v1/users/me/2fa/enable")
+
+// ErrInvalidTwoFactorCode возвращается при неверном коде подтверждения
+var ErrInvalidTwoFactorCode = apperrors.Invalid("INVALID_TWO_FACTOR_CODE", "неверный код")
+
+// ErrTwoFactorLoginFailed возвращается при неверном, устаревшем или уже
+// использованном коде на втором шаге входа
+var ErrTwoFactorLoginFailed = apperrors.Unauthorized("INVALID_TWO_FACTOR_CODE", "неверный или уже использованный код")
+
+// recoveryCodeCount - сколько кодов восстановления выдается при включении 2FA
+const recoveryCodeCount = 10
+
+// recoveryEncoding - алфавит кодов восстановления без похожих символов
+// (base32: нет 0/1/8, поэтому O/I/B не путаются с цифрами)
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorService включает двухфакторную аутентификацию (TOTP)
+// и проверяет коды на втором шаге входа
+type TwoFactorService struct {
+	queries *repository.Queries
+	tx      Transactor
+	cipher  *totp.Cipher // nil - ключ не задан, включение 2FA недоступно
+	issuer  string
+	audit   *audit.Logger
+}
+
+// NewTwoFactorService создает сервис 2FA
+// cipher может быть nil: тогда включить 2FA нельзя
+func NewTwoFactorService(queries *repository.Queries, db Beginner, cipher *totp.Cipher, issuer string, auditLog *audit.Logger) *TwoFactorService {
+	return &TwoFactorService{
+		queries: queries,
+		tx:      NewTransactor(db, queries),
+		cipher:  cipher,
+		issuer:  issuer,
+		audit:   auditLog,
+	}
+}
+
+// Enable создает секрет TOTP и возвращает его для приложения-аутентификатора
+// 2FA включается только после подтверждения кодом (Verify), до этого
+// вход работает по паролю. Повторный вызов заменяет неподтвержденный секрет
+func (s *TwoFactorService) Enable(ctx context.Context, userID int) (*models.TwoFactorEnrollmentResponse, error) {
+	if s.cipher == nil {
+		return nil, ErrTwoFactorNotConfigured
+	}
+
+	// 1. Имя аккаунта в приложении - email пользователя
+	user, err := s.queries.GetUserByID(ctx, int32(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	// 2. Генерируем и шифруем секрет
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.cipher.Encrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Сохраняем; включенную 2FA запрос не перезаписывает
+	_, err = s.queries.UpsertPendingUserTOTP(ctx, repository.UpsertPendingUserTOTPParams{
+		UserID:          int32(userID),
+		SecretEncrypted: encrypted,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrTwoFactorAlreadyEnabled
+		}
+		return nil, fmt.Errorf("ошибка сохранения секрета TOTP: %w", err)
+	}
+
+	return &models.TwoFactorEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Verify подтверждает включение 2FA первым кодом из приложения
+// и возвращает коды восстановления (показываются один раз)
+func (s *TwoFactorService) Verify(ctx context.Context, userID int, code string) (*models.TwoFactorVerifyResponse, error) {
+	if s.cipher == nil {
+		return nil, ErrTwoFactorNotConfigured
+	}
+
+	// 1. Проверяем код по неподтвержденному секрету
+	stored, err := s.queries.GetUserTOTP(ctx, int32(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrTwoFactorNotPending
+		}
+		return nil, fmt.Errorf("ошибка получения секрета TOTP: %w", err)
+	}
+	if stored.EnabledAt.Valid {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	step, err := s.validate(stored, code)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Генерируем коды восстановления
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Включаем 2FA и сохраняем хеши кодов одной транзакцией
+	err = s.tx.WithTx(ctx, func(q *repository.Queries) error {
+		enabled, err := q.EnableUserTOTP(ctx, repository.EnableUserTOTPParams{
+			Step:   step,
+			UserID: int32(userID),
+		})
+		if err != nil {
+			return fmt.Errorf("ошибка включения 2FA: %w", err)
+		}
+		if enabled == 0 {
+			// Параллельный запрос подтвердил включение раньше
+			return ErrTwoFactorAlreadyEnabled
+		}
+		if err := q.DeleteRecoveryCodes(ctx, int32(userID)); err != nil {
+			return fmt.Errorf("ошибка удаления кодов восстановления: %w", err)
+		}
+		if err := q.CreateRecoveryCodes(ctx, repository.CreateRecoveryCodesParams{
+			UserID:     int32(userID),
+			CodeHashes: hashes,
+		}); err != nil {
+			return fmt.Errorf("ошибка сохранения кодов восстановления: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "🔐 Двухфакторная аутентификация включена", "user_id", userID)
+	s.audit.Record(ctx, audit.Event{
+		Action:       audit.ActionUserTwoFactorEnable,
+		TargetUserID: userID,
+		Before:       map[string]bool{"two_factor_enabled": false},
+		After:        map[string]bool{"two_factor_enabled": true},
+	})
+
+	return &models.TwoFactorVerifyResponse{RecoveryCodes: codes}, nil
+}
+
+// Enabled сообщает, требует ли вход пользователя код 2FA
+func (s *TwoFactorService) Enabled(ctx context.Context, userID int) (bool, error) {
+	stored, err := s.queries.GetUserTOTP(ctx, int32(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("ошибка получения секрета TOTP: %w", err)
+	}
+	return stored.EnabledAt.Valid, nil
+}
+
+// Check проверяет код второго шага входа: код из приложения
+// или код восстановления. Каждый код принимается один раз
+func (s *TwoFactorService) Check(ctx context.Context, userID int, req models.TwoFactorLoginRequest) error {
+	if req.RecoveryCode != "" {
+		used, err := s.queries.UseRecoveryCode(ctx, repository.UseRecoveryCodeParams{
+			UserID:   int32(userID),
+			CodeHash: hashRecoveryCode(req.RecoveryCode),
+		})
+		if err != nil {
+			return fmt.Errorf("ошибка проверки кода восстановления: %w", err)
+		}
+		if used == 0 {
+			return ErrTwoFactorLoginFailed
+		}
+		slog.InfoContext(ctx, "🔑 Вход по коду восстановления", "user_id", userID)
+		return nil
+	}
+
+	if s.cipher == nil {
+		return ErrTwoFactorNotConfigured
+	}
+	stored, err := s.queries.GetUserTOTP(ctx, int32(userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrTwoFactorLoginFailed
+		}
+		return fmt.Errorf("ошибка получения секрета TOTP: %w", err)
+	}
+	if !stored.EnabledAt.Valid {
+		return ErrTwoFactorLoginFailed
+	}
+	step, err := s.validate(stored, req.Code)
+	if err != nil {
+		if err == ErrInvalidTwoFactorCode {
+			return ErrTwoFactorLoginFailed
+		}
+		return err
+	}
+
+	// Шаг фиксируется атомарно: перехваченный код не сработает второй раз
+	used, err := s.queries.UseTOTPStep(ctx, repository.UseTOTPStepParams{
+		Step:   step,
+		UserID: int32(userID),
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения шага TOTP: %w", err)
+	}
+	if used == 0 {
+		return ErrTwoFactorLoginFailed
+	}
+	return nil
+}
+
+// validate расшифровывает секрет и проверяет код, возвращает шаг кода
+func (s *TwoFactorService) validate(stored repository.UserTotp, code string) (int64, error) {
+	secret, err := s.cipher.Decrypt(stored.SecretEncrypted)
+	if err != nil {
+		// Секрет зашифрован другим ключом: TWO_FACTOR_ENCRYPTION_KEY сменили
+		return 0, fmt.Errorf("секрет TOTP пользователя %d: %w", stored.UserID, err)
+	}
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return 0, ErrInvalidTwoFactorCode
+	}
+	return step, nil
+}
+
+// generateRecoveryCodes создает коды восстановления вида xxxxx-xxxxx
+// и их хеши для хранения в БД
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("ошибка генерации кода восстановления: %w", err)
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode возвращает хеш кода восстановления
+// Регистр, дефис и пробелы не важны: код вводится вручную
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return auth.HashTokenID(normalized)
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+)
+
+func TestRecoveryCodesAreHashedAndNormalized(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("кодов %d, хешей %d", len(codes), len(hashes))
+	}
+
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Fatalf("код %q не в формате xxxxx-xxxxx", code)
+		}
+		if hashes[i] == code || seen[hashes[i]] {
+			t.Fatalf("хеш кода %q не уникален или совпадает с кодом", code)
+		}
+		seen[hashes[i]] = true
+
+		// Код вводится вручную: регистр, дефис и пробелы не важны
+		typed := strings.ToUpper(strings.Replace(code, "-", " ", 1))
+		if hashRecoveryCode(typed) != hashes[i] {
+			t.Fatalf("код %q, введенный как %q, не совпал", code, typed)
+		}
+	}
+}
+
+func TestTwoFactorRecoveryReady(t *testing.T) {
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	at := func(t time.Time) pgtype.Timestamp { return pgtype.Timestamp{Time: t, Valid: true} }
+
+	tests := []struct {
+		name     string
+		recovery repository.TwoFactorRecovery
+		want     bool
+	}{
+		{"email не подтвержден", repository.TwoFactorRecovery{Status: TwoFactorRecoveryEmailPending}, false},
+		{"идет ожидание", repository.TwoFactorRecovery{Status: TwoFactorRecoveryWaiting, AvailableAt: at(now.Add(time.Minute))}, false},
+		{"ожидание прошло", repository.TwoFactorRecovery{Status: TwoFactorRecoveryWaiting, AvailableAt: at(now)}, true},
+		{"нет одобрения", repository.TwoFactorRecovery{Status: TwoFactorRecoveryWaiting, AvailableAt: at(now), ApprovalRequired: true}, false},
+		{"одобрен", repository.TwoFactorRecovery{Status: TwoFactorRecoveryWaiting, AvailableAt: at(now), ApprovalRequired: true, ApprovedAt: at(now)}, true},
+		{"одобрен, но ожидание идет", repository.TwoFactorRecovery{Status: TwoFactorRecoveryWaiting, AvailableAt: at(now.Add(time.Hour)), ApprovalRequired: true, ApprovedAt: at(now)}, false},
+		{"отменен", repository.TwoFactorRecovery{Status: TwoFactorRecoveryCancelled, AvailableAt: at(now)}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := twoFactorRecoveryReady(&tc.recovery, now); got != tc.want {
+				t.Errorf("готов = %v, ожидалось %v", got, tc.want)
+			}
+		})
+	}
+}
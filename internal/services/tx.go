@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Transactor выполняет многошаговые операции сервиса в одной транзакции
+// БД (Unit of Work): либо фиксируются все шаги, либо ни один.
+//
+// Сервис хранит Transactor и оборачивает операцию в WithTx. Шаги, которые
+// нужны в нескольких операциях, принимают *repository.Queries параметром
+// (см. AccountService.consumeToken, AuthService.issuePair): вне транзакции
+// им передают s.queries, внутри - q из WithTx, и они становятся частью
+// транзакции вызывающего.
+type Transactor struct {
+	db      Beginner
+	queries *repository.Queries
+}
+
+// Beginner начинает транзакцию; его реализует *pgxpool.Pool
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// NewTransactor создает Transactor поверх пула соединений
+func NewTransactor(db Beginner, queries *repository.Queries) Transactor {
+	return Transactor{db: db, queries: queries}
+}
+
+// WithTx выполняет fn в транзакции; все запросы fn делает через q
+//
+// Транзакция фиксируется, если fn вернула nil, и откатывается, если
+// fn вернула ошибку или запаниковала. Ошибка fn возвращается как есть,
+// поэтому сентинельные ошибки сервиса проверяются через errors.Is.
+// Чтобы откатить изменения без ошибки для клиента (dry run), fn
+// возвращает свою сентинельную ошибку, а вызывающий ее распознает
+func (t Transactor) WithTx(ctx context.Context, fn func(q *repository.Queries) error) error {
+	tx, err := t.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	// После Commit откат ничего не делает. Контекст операции может быть
+	// уже отменен, а откатить транзакцию нужно в любом случае
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	if err := fn(t.queries.WithTx(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
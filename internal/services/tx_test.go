@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// txDB считает зафиксированные и откаченные транзакции
+type txDB struct {
+	repository.DBTX
+	commits   atomic.Int32
+	rollbacks atomic.Int32
+}
+
+func (d *txDB) Begin(context.Context) (pgx.Tx, error) { return &txTx{d: d}, nil }
+
+// txTx - транзакция txDB; методы, кроме Commit и Rollback, тесту не нужны
+// Как и pgx, после завершения транзакции Rollback ничего не делает
+type txTx struct {
+	pgx.Tx
+	d      *txDB
+	closed bool
+}
+
+func (t *txTx) Commit(context.Context) error {
+	t.closed = true
+	t.d.commits.Add(1)
+	return nil
+}
+
+func (t *txTx) Rollback(context.Context) error {
+	if t.closed {
+		return pgx.ErrTxClosed
+	}
+	t.closed = true
+	t.d.rollbacks.Add(1)
+	return nil
+}
+
+// newTestTransactor создает Transactor поверх txDB
+func newTestTransactor(t *testing.T) (Transactor, *txDB) {
+	t.Helper()
+	d := &txDB{}
+	return NewTransactor(d, repository.New(d)), d
+}
+
+func TestWithTxCommitsOnSuccess(t *testing.T) {
+	tx, d := newTestTransactor(t)
+
+	err := tx.WithTx(context.Background(), func(q *repository.Queries) error {
+		if q == nil {
+			t.Error("q = nil")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if d.commits.Load() != 1 || d.rollbacks.Load() != 0 {
+		t.Errorf("commits = %d, rollbacks = %d, want 1, 0", d.commits.Load(), d.rollbacks.Load())
+	}
+}
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	tx, d := newTestTransactor(t)
+
+	err := tx.WithTx(context.Background(), func(*repository.Queries) error {
+		return fmt.Errorf("шаг 2: %w", ErrUserNotFound)
+	})
+
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("error = %v, want ErrUserNotFound", err)
+	}
+	if d.commits.Load() != 0 || d.rollbacks.Load() != 1 {
+		t.Errorf("commits = %d, rollbacks = %d, want 0, 1", d.commits.Load(), d.rollbacks.Load())
+	}
+}
+
+func TestWithTxRollsBackOnPanic(t *testing.T) {
+	tx, d := newTestTransactor(t)
+
+	func() {
+		defer func() { _ = recover() }()
+		_ = tx.WithTx(context.Background(), func(*repository.Queries) error {
+			panic("сбой")
+		})
+	}()
+
+	if d.commits.Load() != 0 || d.rollbacks.Load() != 1 {
+		t.Errorf("commits = %d, rollbacks = %d, want 0, 1", d.commits.Load(), d.rollbacks.Load())
+	}
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/audit"
+	"github.com/Soundveyve/fiber-backend/internal/bulk"
+	"github.com/Soundveyve/fiber-backend/internal/emailaddr"
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/operations"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/sanitize"
+	"github.com/Soundveyve/fiber-backend/internal/tracing"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/Soundveyve/fiber-backend/internal/webhooks"
+)
+
+// bulkImportBatchSize - сколько строк импорта вставляется одним запросом
+const bulkImportBatchSize = 500
+
+// ErrInvalidImportData возвращается, когда источник импорта нельзя прочитать:
+// неизвестный формат, нет заголовка CSV, слишком длинная строка NDJSON
+var ErrInvalidImportData = apperrors.Invalid("INVALID_IMPORT_DATA", "невалидные данные импорта")
+
+// BulkImportUsers создает пользователей из потока r (CSV или NDJSON)
+// пачками по bulkImportBatchSize в одной транзакции
+//
+// Невалидные строки и строки с занятым email или username пропускаются
+// и перечисляются в отчете. Ошибка чтения источника или БД откатывает
+// импорт целиком. Записи аудита и вебхуки отправляются после фиксации
+// транзакции, поэтому откаченный импорт их не оставляет
+func (s *UserService) BulkImportUsers(ctx context.Context, r io.Reader, format string) (*models.ImportUsersResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.BulkImportUsers")
+	defer span.End()
+
+	dec, err := bulk.NewDecoder(r, format)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportData, err)
+	}
+
+	result := &models.ImportUsersResponse{Errors: []models.ImportRowError{}}
+	var created []models.UserResponse
+	// Число строк потока заранее неизвестно: прогресс - прочитанные строки
+	progress := operations.ProgressFromContext(ctx)
+
+	err = s.tx.WithTx(ctx, func(q *repository.Queries) error {
+		batch := make([]bulk.Row, 0, bulkImportBatchSize)
+		flush := func() error {
+			users, rowErrors, err := s.insertImportBatch(ctx, q, batch)
+			if err != nil {
+				return err
+			}
+			created = append(created, users...)
+			result.Errors = append(result.Errors, rowErrors...)
+			batch = batch[:0]
+			return nil
+		}
+
+		for {
+			row, err := dec.Next()
+			if err == io.EOF {
+				break
+			}
+			progress.Add(1)
+			var rowErr *bulk.RowError
+			if errors.As(err, &rowErr) {
+				result.Errors = append(result.Errors, models.ImportRowError{Row: rowErr.Line, Error: rowErr.Err.Error()})
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidImportData, err)
+			}
+
+			if message := s.checkImportRow(&row); message != "" {
+				result.Errors = append(result.Errors, models.ImportRowError{Row: row.Line, Error: message})
+				continue
+			}
+
+			batch = append(batch, row)
+			if len(batch) == bulkImportBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(batch) > 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range created {
+		user := &created[i]
+		s.audit.Record(ctx, audit.Event{Action: audit.ActionUserImport, TargetUserID: user.InternalID, After: user})
+		s.events.Publish(ctx, webhooks.EventUserCreated, user)
+	}
+
+	// Дубликаты выявляются при вставке пачки, позже ошибок разбора
+	sort.SliceStable(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Row < result.Errors[j].Row
+	})
+	result.Imported = len(created)
+	metrics.Signups.WithLabelValues(metrics.SignupSourceImport).Add(float64(result.Imported))
+	result.Failed = len(result.Errors)
+	return result, nil
+}
+
+// checkImportRow проверяет и очищает строку импорта
+// Возвращает причину пропуска строки или пустую строку
+func (s *UserService) checkImportRow(row *bulk.Row) string {
+	if reason := rowViolations(row); reason != "" {
+		return reason
+	}
+
+	// Адрес хранится нормализованным, как при регистрации
+	row.Email = emailaddr.Normalize(row.Email)
+
+	// Принимаем только bcrypt хеши: другой алгоритм не пройдет VerifyPassword
+	if row.PasswordHash != "" {
+		if _, err := bcrypt.Cost([]byte(row.PasswordHash)); err != nil {
+			return "password_hash не является bcrypt хешем"
+		}
+	}
+
+	// Имена очищаются так же, как в API
+	if err := s.text.Fields(map[string]*string{
+		"first_name": &row.FirstName,
+		"last_name":  &row.LastName,
+	}); err != nil {
+		return "имя " + sanitize.ErrMarkup.Error()
+	}
+	return ""
+}
+
+// insertImportBatch вставляет пачку строк одним запросом
+// Строки, которые не вставились из-за занятого email или username,
+// возвращаются ошибками строк
+func (s *UserService) insertImportBatch(ctx context.Context, q *repository.Queries, batch []bulk.Row) ([]models.UserResponse, []models.ImportRowError, error) {
+	params := repository.BulkCreateUsersParams{
+		Emails:          make([]string, len(batch)),
+		CanonicalEmails: make([]string, len(batch)),
+		Usernames:       make([]string, len(batch)),
+		PasswordHashes:  make([]string, len(batch)),
+		FirstNames:      make([]string, len(batch)),
+		LastNames:       make([]string, len(batch)),
+	}
+	for i, row := range batch {
+		// Без хеша пароля сохраняем хеш случайного пароля:
+		// войти такой пользователь сможет только после сброса пароля
+		passwordHash := row.PasswordHash
+		if passwordHash == "" {
+			passwordHash = string(getDummyPasswordHash())
+		}
+
+		params.Emails[i] = row.Email
+		params.CanonicalEmails[i] = emailaddr.Canonical(row.Email)
+		params.Usernames[i] = row.Username
+		params.PasswordHashes[i] = passwordHash
+		params.FirstNames[i] = row.FirstName
+		params.LastNames[i] = row.LastName
+	}
+
+	users, err := q.BulkCreateUsers(ctx, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка создания пользователей: %w", err)
+	}
+
+	// RETURNING не сообщает номер строки: созданные находятся по паре
+	// email и username, повтор той же пары в пачке создан не был
+	inserted := make(map[string]int, len(users))
+	for i := range users {
+		inserted[importKey(users[i].Email, users[i].Username)] = i
+	}
+
+	created := make([]models.UserResponse, 0, len(users))
+	var rowErrors []models.ImportRowError
+	for _, row := range batch {
+		key := importKey(row.Email, row.Username)
+		i, ok := inserted[key]
+		if !ok {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: row.Line, Error: ErrUserAlreadyExists.Error()})
+			continue
+		}
+		delete(inserted, key)
+		created = append(created, *toUserResponse(&users[i]))
+	}
+	return created, rowErrors, nil
+}
+
+// importKey - ключ строки импорта для сопоставления с RETURNING
+func importKey(email, username string) string {
+	return email + "\x00" + username
+}
+
+// ExportUsers пишет в w всех пользователей, подходящих под фильтры
+// и сортировку req (Page и PageSize не используются), в формате format
+//
+// Пользователи читаются из БД пачками по userStreamBatchSize, после каждой
+// пачки запись сбрасывается в w, поэтому память не зависит от числа
+// пользователей. Возвращает количество выгруженных пользователей
+func (s *UserService) ExportUsers(ctx context.Context, req models.ListUsersRequest, w io.Writer, format string) (int, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ExportUsers")
+	defer span.End()
+
+	enc, err := bulk.NewEncoder(w, format)
+	if err != nil {
+		return 0, err
+	}
+	enc.Redact(redactionFor(ctx, s.redact))
+
+	filter := userListFilter(ctx, req)
+	written := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		users, err := s.queries.ListUsers(ctx, repository.ListUsersParams{
+			VisibleUserID: filter.VisibleUserID,
+			Search:        filter.Search,
+			Statuses:      filter.Statuses,
+			CreatedAfter:  filter.CreatedAfter,
+			CreatedBefore: filter.CreatedBefore,
+			InactiveSince: filter.InactiveSince,
+			SortBy:        string(req.SortBy),
+			SortDesc:      req.SortDesc,
+			Limit:         userStreamBatchSize,
+			Offset:        int32(written),
+		})
+		if err != nil {
+			return written, fmt.Errorf("ошибка получения списка пользователей: %w", err)
+		}
+
+		for i := range users {
+			if err := enc.Encode(toUserResponse(&users[i])); err != nil {
+				return written, err
+			}
+		}
+		written += len(users)
+
+		if err := enc.Flush(); err != nil {
+			return written, err
+		}
+		if len(users) < userStreamBatchSize {
+			return written, nil
+		}
+	}
+}
+
+// rowViolations проверяет строку импорта по тегам validate
+// Возвращает ошибки полей одной строкой ("email: ...; username: ...")
+// или пустую строку, если строка валидна
+func rowViolations(row interface{}) string {
+	err := validation.Struct(row)
+	if err == nil {
+		return ""
+	}
+	var validationErr *validation.Error
+	if !errors.As(err, &validationErr) {
+		return err.Error()
+	}
+	fields := make([]string, 0, len(validationErr.Fields))
+	for field, message := range validationErr.Fields {
+		fields = append(fields, field+": "+message)
+	}
+	slices.Sort(fields)
+	return strings.Join(fields, "; ")
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/audit"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/tracing"
+	"github.com/Soundveyve/fiber-backend/internal/webhooks"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidMerge возвращается, когда аккаунт пытаются слить сам с собой
+var ErrInvalidMerge = apperrors.Invalid("INVALID_MERGE", "нельзя слить аккаунт с самим собой")
+
+// errMergeDryRun откатывает транзакцию слияния в режиме dryRun
+var errMergeDryRun = errors.New("пробное слияние")
+
+// MergeUsers сливает дубликат в основной аккаунт
+//
+// В одной транзакции:
+//  1. оба аккаунта блокируются (FOR UPDATE) в порядке возрастания ID,
+//     чтобы встречные слияния не заблокировали друг друга
+//  2. активность и аватар дубликата переносятся в основной аккаунт
+//     (см. MergeUserActivity)
+//  3. связанные записи дубликата переносятся на основной аккаунт
+//     (привязки, прежние имена, история входов, подписка на сводку,
+//     журнал аудита) или
+//     отзываются (сессии, ссылки из писем, второй фактор)
+//  4. дубликат мягко удаляется, его email и username освобождаются
+//
+// При dryRun транзакция откатывается: ответ показывает, каким
+// станет основной аккаунт, но в БД ничего не меняется.
+func (s *UserService) MergeUsers(ctx context.Context, primaryID, duplicateID int, dryRun bool) (*models.MergeUsersResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.MergeUsers")
+	defer span.End()
+
+	if primaryID == duplicateID {
+		return nil, ErrInvalidMerge
+	}
+
+	var (
+		before, duplicate repository.User
+		primary           repository.User
+		reassigned        = map[string]int64{}
+	)
+	err := s.tx.WithTx(ctx, func(q *repository.Queries) error {
+		// 1. Блокируем оба аккаунта
+		lockOrder := []int{primaryID, duplicateID}
+		if duplicateID < primaryID {
+			lockOrder = []int{duplicateID, primaryID}
+		}
+		locked := make(map[int]repository.User, 2)
+		for _, id := range lockOrder {
+			user, err := q.GetUserForUpdate(ctx, int32(id))
+			if err != nil {
+				if err == pgx.ErrNoRows {
+					return fmt.Errorf("%w: %d", ErrUserNotFound, id)
+				}
+				return fmt.Errorf("ошибка получения пользователя: %w", err)
+			}
+			locked[id] = user
+		}
+		before, duplicate = locked[primaryID], locked[duplicateID]
+
+		// 2. Переносим активность
+		var err error
+		primary, err = q.MergeUserActivity(ctx, repository.MergeUserActivityParams{
+			ID:          int32(primaryID),
+			LoginCount:  duplicate.LoginCount,
+			LastLoginAt: duplicate.LastLoginAt,
+			FirstName:   duplicate.FirstName,
+			LastName:    duplicate.LastName,
+			AvatarKey:   duplicate.AvatarKey,
+			CreatedAt:   duplicate.CreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("ошибка переноса активности: %w", err)
+		}
+
+		// 3. Переносим или отзываем связанные записи
+		// Новые таблицы со ссылкой на users добавляют сюда свой шаг
+		// и счетчик затронутых строк
+		if err := reassignMerged(ctx, q, primaryID, duplicateID, reassigned); err != nil {
+			return err
+		}
+
+		// Аватар перешел к основному аккаунту: снимаем его с дубликата,
+		// чтобы файл не удалили вместе с ним
+		if !before.AvatarKey.Valid && duplicate.AvatarKey.Valid {
+			if _, err := q.SetUserAvatar(ctx, repository.SetUserAvatarParams{ID: int32(duplicateID)}); err != nil {
+				return fmt.Errorf("ошибка переноса аватара: %w", err)
+			}
+			reassigned["avatar"] = 1
+		}
+
+		// 4. Удаляем дубликат
+		if _, err := q.SoftDeleteUser(ctx, int32(duplicateID)); err != nil {
+			return fmt.Errorf("ошибка удаления дубликата: %w", err)
+		}
+
+		if dryRun {
+			return errMergeDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errMergeDryRun) {
+		return nil, err
+	}
+
+	merged := toUserResponse(&primary)
+	if !dryRun {
+		// Основной аккаунт изменен, дубликат удален
+		s.forgetUser(primaryID)
+		s.forgetUser(duplicateID)
+		s.audit.Record(ctx, audit.Event{
+			Action:       audit.ActionUserMerge,
+			TargetUserID: primaryID,
+			Before:       toUserResponse(&before),
+			After:        merged,
+		})
+		s.audit.Record(ctx, audit.Event{
+			Action:       audit.ActionUserDelete,
+			TargetUserID: duplicateID,
+			Before:       toUserResponse(&duplicate),
+		})
+		s.events.Publish(ctx, webhooks.EventUserUpdated, merged)
+		s.events.Publish(ctx, webhooks.EventUserDeleted, toUserResponse(&duplicate))
+	}
+
+	return &models.MergeUsersResponse{
+		DryRun:      dryRun,
+		Primary:     *merged,
+		DuplicateID: duplicate.PublicID.String(),
+		Reassigned:  reassigned,
+	}, nil
+}
+
+// reassignMerged переносит записи дубликата на основной аккаунт или
+// отзывает их и записывает в reassigned число затронутых строк по таблицам
+// Вызывается внутри транзакции слияния
+func reassignMerged(ctx context.Context, q *repository.Queries, primaryID, duplicateID int, reassigned map[string]int64) error {
+	primary, duplicate := int32(primaryID), int32(duplicateID)
+
+	identities, err := q.ReassignUserIdentities(ctx, repository.ReassignUserIdentitiesParams{PrimaryID: primary, DuplicateID: duplicate})
+	if err != nil {
+		return fmt.Errorf("ошибка переноса привязок: %w", err)
+	}
+	reassigned["user_identities"] = identities
+
+	// Сессии и ссылки из писем дубликата больше не должны давать вход
+	sessions, err := q.RevokeUserRefreshTokens(ctx, duplicate)
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва сессий: %w", err)
+	}
+	reassigned["refresh_tokens"] = sessions
+
+	tokens, err := q.InvalidateAllUserTokens(ctx, duplicate)
+	if err != nil {
+		return fmt.Errorf("ошибка аннулирования ссылок: %w", err)
+	}
+	reassigned["user_tokens"] = tokens
+
+	twoFactor, err := q.RevokeUserTwoFactor(ctx, duplicate)
+	if err != nil {
+		return fmt.Errorf("ошибка отключения 2FA: %w", err)
+	}
+	reassigned["user_totp"] = twoFactor
+
+	history, err := q.ReassignUsernameHistory(ctx, repository.ReassignUsernameHistoryParams{PrimaryID: primary, DuplicateID: duplicate})
+	if err != nil {
+		return fmt.Errorf("ошибка переноса прежних имен: %w", err)
+	}
+	reassigned["username_history"] = history
+
+	logins, err := q.ReassignLoginHistory(ctx, repository.ReassignLoginHistoryParams{PrimaryID: primary, DuplicateID: duplicate})
+	if err != nil {
+		return fmt.Errorf("ошибка переноса истории входов: %w", err)
+	}
+	reassigned["login_history"] = logins
+
+	digest, err := q.ReassignDigestSubscription(ctx, repository.ReassignDigestSubscriptionParams{PrimaryID: primary, DuplicateID: duplicate})
+	if err != nil {
+		return fmt.Errorf("ошибка переноса подписки на сводку: %w", err)
+	}
+	reassigned["digest_subscriptions"] = digest
+
+	auditLogs, err := q.ReassignAuditLogs(ctx, repository.ReassignAuditLogsParams{PrimaryID: primary, DuplicateID: duplicate})
+	if err != nil {
+		return fmt.Errorf("ошибка переноса журнала аудита: %w", err)
+	}
+	reassigned["audit_logs"] = auditLogs
+	return nil
+}
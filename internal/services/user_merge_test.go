@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+)
+
+// mergeDB выполняет запросы слияния в транзакции txDB: запоминает,
+// какие запросы и с какими аргументами выполнены, и для каждого
+// изменения возвращает заданное число строк
+type mergeDB struct {
+	txDB
+	avatars map[int32]string // Аватары пользователей, нет ключа - без аватара
+	rows    map[string]int64 // Число строк по имени запроса
+	calls   map[string][]interface{}
+}
+
+func (d *mergeDB) Begin(context.Context) (pgx.Tx, error) {
+	return &mergeTx{txTx: txTx{d: &d.txDB}, db: d}, nil
+}
+
+// mergeTx - транзакция mergeDB
+type mergeTx struct {
+	txTx
+	db *mergeDB
+}
+
+// queryName возвращает имя запроса sqlc из комментария "-- name: X :kind"
+func queryName(sql string) string {
+	_, rest, _ := strings.Cut(sql, "-- name: ")
+	name, _, _ := strings.Cut(rest, " ")
+	name, _, _ = strings.Cut(name, "\n")
+	return name
+}
+
+func (t *mergeTx) Exec(_ context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	name := queryName(sql)
+	t.db.calls[name] = args
+	return pgconn.NewCommandTag(fmt.Sprintf("UPDATE %d", t.db.rows[name])), nil
+}
+
+func (t *mergeTx) QueryRow(_ context.Context, sql string, args ...interface{}) pgx.Row {
+	name := queryName(sql)
+	t.db.calls[name] = args
+	switch name {
+	case "GetUserForUpdate":
+		return mergeUserRow{id: args[0].(int32), avatar: t.db.avatars[args[0].(int32)]}
+	case "MergeUserActivity":
+		// Аватар основного аккаунта или, если его нет, дубликата
+		id := args[len(args)-1].(int32)
+		avatar := t.db.avatars[id]
+		if key := args[4].(pgtype.Text); avatar == "" && key.Valid {
+			avatar = key.String
+		}
+		return mergeUserRow{id: id, avatar: avatar}
+	case "SetUserAvatar":
+		return mergeUserRow{id: args[1].(int32)}
+	}
+	return mergeUserRow{err: fmt.Errorf("неожиданный запрос %q", name)}
+}
+
+// mergeUserRow - строка users: ID - 1-я колонка, avatar_key - 17-я
+type mergeUserRow struct {
+	id     int32
+	avatar string
+	err    error
+}
+
+func (r mergeUserRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*int32) = r.id
+	*dest[16].(*pgtype.Text) = pgtype.Text{String: r.avatar, Valid: r.avatar != ""}
+	return nil
+}
+
+func TestMergeUsersReassignsRelatedRecords(t *testing.T) {
+	const primaryID, duplicateID = 1, 2
+	db := &mergeDB{
+		avatars: map[int32]string{duplicateID: "avatars/2.png"},
+		rows: map[string]int64{
+			"ReassignUserIdentities":     1,
+			"RevokeUserRefreshTokens":    3,
+			"InvalidateAllUserTokens":    2,
+			"RevokeUserTwoFactor":        1,
+			"ReassignUsernameHistory":    4,
+			"ReassignLoginHistory":       5,
+			"ReassignDigestSubscription": 1,
+			"ReassignAuditLogs":          7,
+			"SoftDeleteUser":             1,
+		},
+		calls: map[string][]interface{}{},
+	}
+	s := NewUserService(repository.New(db), db, nil, "", nil, nil, nil)
+
+	resp, err := s.MergeUsers(context.Background(), primaryID, duplicateID, false)
+	if err != nil {
+		t.Fatalf("MergeUsers: %v", err)
+	}
+	if db.commits.Load() != 1 || db.rollbacks.Load() != 0 {
+		t.Errorf("commits = %d, rollbacks = %d, ожидалась одна транзакция", db.commits.Load(), db.rollbacks.Load())
+	}
+
+	// Каждая таблица со ссылкой на пользователя учтена в ответе
+	want := map[string]int64{
+		"user_identities":      1,
+		"refresh_tokens":       3,
+		"user_tokens":          2,
+		"user_totp":            1,
+		"username_history":     4,
+		"login_history":        5,
+		"digest_subscriptions": 1,
+		"audit_logs":           7,
+		"avatar":               1,
+	}
+	for table, n := range want {
+		if got, ok := resp.Reassigned[table]; !ok || got != n {
+			t.Errorf("reassigned[%s] = %d (%v), ожидалось %d", table, got, ok, n)
+		}
+	}
+	if len(resp.Reassigned) != len(want) {
+		t.Errorf("reassigned = %v, лишние таблицы", resp.Reassigned)
+	}
+
+	// Отзыв касается только дубликата
+	for _, name := range []string{"RevokeUserRefreshTokens", "InvalidateAllUserTokens", "RevokeUserTwoFactor", "SoftDeleteUser"} {
+		if args := db.calls[name]; len(args) != 1 || args[0] != int32(duplicateID) {
+			t.Errorf("%s(%v), ожидался дубликат %d", name, args, duplicateID)
+		}
+	}
+	// Аватар перешел к основному аккаунту и снят с дубликата
+	if resp.Primary.AvatarURL == nil {
+		t.Error("аватар дубликата не перенесен")
+	}
+	if args := db.calls["SetUserAvatar"]; len(args) != 2 || args[0].(pgtype.Text).Valid || args[1] != int32(duplicateID) {
+		t.Errorf("SetUserAvatar(%v), ожидалось снятие аватара с дубликата", args)
+	}
+}
+
+func TestMergeUsersKeepsPrimaryAvatar(t *testing.T) {
+	db := &mergeDB{
+		avatars: map[int32]string{1: "avatars/1.png", 2: "avatars/2.png"},
+		rows:    map[string]int64{"SoftDeleteUser": 1},
+		calls:   map[string][]interface{}{},
+	}
+	s := NewUserService(repository.New(db), db, nil, "", nil, nil, nil)
+
+	resp, err := s.MergeUsers(context.Background(), 1, 2, true)
+	if err != nil {
+		t.Fatalf("MergeUsers: %v", err)
+	}
+	if _, ok := db.calls["SetUserAvatar"]; ok {
+		t.Error("аватар дубликата снят, хотя основной аккаунт сохранил свой")
+	}
+	if _, ok := resp.Reassigned["avatar"]; ok {
+		t.Errorf("reassigned = %v, аватар не переносился", resp.Reassigned)
+	}
+	// Пробное слияние откатывается
+	if db.commits.Load() != 0 || db.rollbacks.Load() != 1 {
+		t.Errorf("commits = %d, rollbacks = %d, ожидался откат", db.commits.Load(), db.rollbacks.Load())
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Soundveyve/fiber-backend/internal/importer"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/operations"
+)
+
+// BulkImportInput - параметры операции bulk_import_users
+// (POST /api/v1/users/import с Prefer: respond-async)
+type BulkImportInput struct {
+	Format string `json:"format"`
+	Data   string `json:"data"` // Тело запроса: CSV или NDJSON
+}
+
+// ImportInput - параметры операции import_users
+// (POST /admin/v1/users/import с Prefer: respond-async)
+type ImportInput struct {
+	Records []importer.Record       `json:"records"`
+	Errors  []models.ImportRowError `json:"errors"` // Ошибки разбора файла
+}
+
+// MergeInput - параметры операции merge_users
+// (POST /admin/v1/users/:id/merge с Prefer: respond-async)
+type MergeInput struct {
+	PrimaryID   int  `json:"primary_id"`
+	DuplicateID int  `json:"duplicate_id"`
+	DryRun      bool `json:"dry_run"`
+}
+
+// RegisterOperations регистрирует операции пользователей в менеджере:
+// каждая выполняет тот же метод, что и синхронный запрос
+func (s *UserService) RegisterOperations(m *operations.Manager) {
+	m.Register(operations.KindBulkImportUsers, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		var in BulkImportInput
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return nil, fmt.Errorf("невалидные параметры импорта: %w", err)
+		}
+		return s.BulkImportUsers(ctx, strings.NewReader(in.Data), in.Format)
+	})
+
+	m.Register(operations.KindImportUsers, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		var in ImportInput
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return nil, fmt.Errorf("невалидные параметры импорта: %w", err)
+		}
+		return s.ImportUsers(ctx, in.Records, in.Errors), nil
+	})
+
+	m.Register(operations.KindMergeUsers, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		var in MergeInput
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return nil, fmt.Errorf("невалидные параметры слияния: %w", err)
+		}
+		progress := operations.ProgressFromContext(ctx)
+		progress.SetTotal(1)
+		result, err := s.MergeUsers(ctx, in.PrimaryID, in.DuplicateID, in.DryRun)
+		if err != nil {
+			return nil, err
+		}
+		progress.Add(1)
+		return result, nil
+	})
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Soundveyve/fiber-backend/internal/lifecycle"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/query"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/sqlfilter"
+	"github.com/Soundveyve/fiber-backend/internal/tracing"
+)
+
+// Операторы для текстовых полей, дат и дат, которых может не быть
+var (
+	textOps         = []sqlfilter.Op{sqlfilter.Eq, sqlfilter.Ne, sqlfilter.In, sqlfilter.Contains, sqlfilter.Prefix}
+	timeOps         = []sqlfilter.Op{sqlfilter.Lt, sqlfilter.Lte, sqlfilter.Gt, sqlfilter.Gte}
+	nullableTimeOps = []sqlfilter.Op{sqlfilter.Lt, sqlfilter.Lte, sqlfilter.Gt, sqlfilter.Gte, sqlfilter.Null}
+)
+
+// UserSearchSchema - поля расширенного поиска пользователей
+// (GET /admin/v1/users/search). Новое поле добавляется только сюда:
+// все, чего нет в схеме, отклоняется с 400
+var UserSearchSchema = sqlfilter.Schema{
+	Fields: map[string]sqlfilter.Field{
+		"email":    {Column: "email", Type: sqlfilter.Text, Ops: textOps, Sortable: true},
+		"username": {Column: "username", Type: sqlfilter.Text, Ops: textOps, Sortable: true},
+		"status": {
+			Column: "status",
+			Type:   sqlfilter.Text,
+			Ops:    []sqlfilter.Op{sqlfilter.Eq, sqlfilter.Ne, sqlfilter.In},
+			// Удаленные аккаунты в поиск не попадают (deleted_at IS NULL)
+			Values: searchableStatuses(),
+		},
+		"role":    {Column: "role", Type: sqlfilter.Text, Ops: []sqlfilter.Op{sqlfilter.Eq, sqlfilter.Ne, sqlfilter.In}},
+		"country": {Column: "country", Type: sqlfilter.Text, Ops: []sqlfilter.Op{sqlfilter.Eq, sqlfilter.In, sqlfilter.Null}},
+		"phone":   {Column: "phone", Type: sqlfilter.Text, Ops: []sqlfilter.Op{sqlfilter.Eq, sqlfilter.Prefix, sqlfilter.Null}},
+		"created_at": {
+			Column: "created_at", Type: sqlfilter.Time, Ops: timeOps, Sortable: true,
+		},
+		"last_login_at": {
+			Column: "last_login_at", Type: sqlfilter.Time, Ops: nullableTimeOps, Sortable: true,
+		},
+		"email_verified_at": {
+			Column: "email_verified_at", Type: sqlfilter.Time, Ops: nullableTimeOps,
+		},
+		"login_count": {
+			Column: "login_count", Type: sqlfilter.Int,
+			Ops:      []sqlfilter.Op{sqlfilter.Eq, sqlfilter.Lt, sqlfilter.Lte, sqlfilter.Gt, sqlfilter.Gte},
+			Sortable: true,
+		},
+	},
+	DefaultSort: []sqlfilter.Sort{{Field: "created_at", Desc: true}},
+	TieBreak:    "id",
+}
+
+// searchableStatuses - состояния аккаунта, кроме deleted
+func searchableStatuses() []string {
+	var names []string
+	for _, name := range lifecycle.Names() {
+		if name != string(lifecycle.StatusDeleted) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// psql - построитель запросов с плейсхолдерами PostgreSQL ($1, $2, ...)
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+// UserSearchService - расширенный поиск пользователей для панелей поддержки
+//
+// Фильтры произвольные, поэтому запрос собирается sqlfilter, а не sqlc
+// (см. пакет sqlfilter). Остальные операции с пользователями - в UserService
+type UserSearchService struct {
+	db repository.DBTX // Тот же пул, что у sqlc, со счетчиком запросов
+}
+
+// NewUserSearchService создает сервис поиска пользователей
+func NewUserSearchService(db repository.DBTX) *UserSearchService {
+	return &UserSearchService{db: db}
+}
+
+// Search возвращает страницу пользователей по фильтрам и сортировке spec
+// Условия проверены по UserSearchSchema; неизвестное поле - query.ErrInvalidParam
+func (s *UserSearchService) Search(ctx context.Context, spec sqlfilter.Spec, page query.Page) (*models.ListUsersResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserSearchService.Search")
+	defer span.End()
+
+	// 1. Условия общие для списка и подсчета
+	base, err := UserSearchSchema.Where(psql.Select().From("users").Where("deleted_at IS NULL"), spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Страница пользователей
+	list, err := UserSearchSchema.OrderBy(base.Columns("*"), spec)
+	if err != nil {
+		return nil, err
+	}
+	sql, args, err := list.Limit(uint64(page.Size)).Offset(uint64(page.Offset())).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка построения запроса поиска: %w", err)
+	}
+	rows, err := s.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска пользователей: %w", err)
+	}
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByName[repository.User])
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска пользователей: %w", err)
+	}
+
+	// 3. Общее количество для пагинации
+	sql, args, err = base.Columns("COUNT(*)").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка построения запроса поиска: %w", err)
+	}
+	var totalCount int64
+	if err := s.db.QueryRow(ctx, sql, args...).Scan(&totalCount); err != nil {
+		return nil, fmt.Errorf("ошибка подсчета пользователей: %w", err)
+	}
+
+	// 4. Конвертируем в формат ответа
+	resp := newListUsersResponse(models.ListUsersRequest{Page: page.Number, PageSize: page.Size}, int(totalCount))
+	resp.Users = make([]models.UserResponse, len(users))
+	for i := range users {
+		resp.Users[i] = *toUserResponse(&users[i])
+	}
+	return resp, nil
+}
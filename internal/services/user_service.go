@@ -2,102 +2,387 @@ package services
 
 import (
 	"context"
-	"database/sql"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/audit"
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/bulk"
+	"github.com/Soundveyve/fiber-backend/internal/emailaddr"
+	"github.com/Soundveyve/fiber-backend/internal/fanout"
+	"github.com/Soundveyve/fiber-backend/internal/importer"
+	"github.com/Soundveyve/fiber-backend/internal/lifecycle"
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
 	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/operations"
+	"github.com/Soundveyve/fiber-backend/internal/passhash"
+	"github.com/Soundveyve/fiber-backend/internal/phone"
+	"github.com/Soundveyve/fiber-backend/internal/query"
+	"github.com/Soundveyve/fiber-backend/internal/redact"
 	"github.com/Soundveyve/fiber-backend/internal/repository"
-	
-	"golang.org/x/crypto/bcrypt"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/sanitize"
+	"github.com/Soundveyve/fiber-backend/internal/settings"
+	"github.com/Soundveyve/fiber-backend/internal/tracing"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+	"github.com/Soundveyve/fiber-backend/internal/webhooks"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrInvalidCredentials возвращается при неудачной аутентификации
+// Одинаковая ошибка для "нет такого email" и "неверный пароль",
+// чтобы по ответу нельзя было узнать, зарегистрирован ли email
+var ErrInvalidCredentials = apperrors.Unauthorized("INVALID_CREDENTIALS", "неверный email или пароль")
+
+// ErrUserNotFound возвращается, когда пользователя нет или он удален
+var ErrUserNotFound = apperrors.NotFound("USER_NOT_FOUND", "пользователь не найден")
+
+// ErrInvalidUserID возвращается для публичного ID пользователя не в формате UUID
+var ErrInvalidUserID = apperrors.Invalid("INVALID_USER_ID", "невалидный ID пользователя")
+
+// ErrUserAlreadyExists возвращается, когда email или username уже заняты
+// Намеренно не уточняет, какое именно поле совпало
+var ErrUserAlreadyExists = apperrors.Conflict("USER_ALREADY_EXISTS", "пользователь с такими данными уже существует")
+
+// EmailRegisteredError возвращается CreateUser, когда занят email
+//
+// Для вызывающих, которые не различают причину, это ErrUserAlreadyExists
+// (409). Регистрация же отвечает на нее как на успешную, телом User, и
+// пишет владельцу адреса: по ответу нельзя проверить, зарегистрирован
+// ли email, даже подобрав свободное имя пользователя
+type EmailRegisteredError struct {
+	User *models.UserResponse // Ответ того же вида, что у нового аккаунта
+}
+
+func (e *EmailRegisteredError) Error() string { return ErrUserAlreadyExists.Error() }
+
+func (e *EmailRegisteredError) Unwrap() error { return ErrUserAlreadyExists }
+
+// ErrUsernameHeld возвращается, когда имя недавно освободил другой
+// пользователь: ссылки на его профиль по старому имени еще действуют
+var ErrUsernameHeld = apperrors.Conflict("USERNAME_HELD", "имя пользователя недавно освободилось и пока недоступно")
+
+// ErrUnknownRole возвращается при назначении роли, которой нет в справочнике
+var ErrUnknownRole = apperrors.Invalid("UNKNOWN_ROLE", "неизвестная роль")
+
+// Коды ошибок PostgreSQL
+const (
+	pgUniqueViolation     = "23505" // Нарушение UNIQUE ограничения
+	pgForeignKeyViolation = "23503" // Нарушение внешнего ключа
 )
 
+// dummyPasswordHash - bcrypt хеш случайного пароля с той же стоимостью,
+// что и реальные хеши. Сравнение с ним при несуществующем email занимает
+// столько же времени, сколько проверка настоящего пароля, и время ответа
+// не выдает, зарегистрирован ли email
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+// getDummyPasswordHash лениво вычисляет dummyPasswordHash при первом использовании
+func getDummyPasswordHash() []byte {
+	dummyPasswordHashOnce.Do(func() {
+		password := make([]byte, 32)
+		_, _ = rand.Read(password)
+		dummyPasswordHash, _ = passhash.Hash(string(password))
+	})
+	return dummyPasswordHash
+}
+
+// isUniqueViolation проверяет, что ошибка БД - нарушение UNIQUE ограничения
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
+// emailConstraints - ограничения уникальности email пользователей
+var emailConstraints = map[string]bool{
+	"idx_users_email_not_deleted":           true,
+	"idx_users_canonical_email_not_deleted": true,
+	"users_email_key":                       true,
+}
+
+// isEmailConflict проверяет, что ошибка БД - занятый email пользователя
+func isEmailConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && emailConstraints[pgErr.ConstraintName]
+}
+
+// isForeignKeyViolation проверяет, что ошибка БД - нарушение внешнего ключа
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation
+}
+
 // UserService содержит бизнес-логику для работы с пользователями
 // Это промежуточный слой между HTTP handlers и repository (БД)
 type UserService struct {
-	queries *repository.Queries // Сгенерированные sqlc запросы
-	db      *sql.DB             // Прямой доступ к БД для транзакций
+	queries  *repository.Queries // Сгенерированные sqlc запросы
+	tx       Transactor          // Многошаговые операции в транзакции
+	settings *settings.Settings  // Настройки, изменяемые во время работы
+	text     sanitize.Policy     // Очистка имен от HTML разметки
+	audit    *audit.Logger       // Журнал изменений пользователей
+	events   *webhooks.Publisher // События пользователей для подписчиков
+	redact   *redact.Policy      // Скрытие полей в gRPC и выгрузках (RESPONSE_REDACTION)
+
+	// reads объединяет одновременные одинаковые чтения (GetUserByID)
+	// в один запрос к БД, см. coalescedRead
+	reads singleflight.Group
 }
 
 // NewUserService создает новый экземпляр сервиса пользователей
-func NewUserService(queries *repository.Queries, db *sql.DB) *UserService {
+// auditLog и events могут быть nil - тогда изменения не журналируются
+// и события не публикуются; redactPolicy nil - поля не скрываются
+func NewUserService(queries *repository.Queries, db Beginner, runtimeSettings *settings.Settings, textPolicy sanitize.Policy, auditLog *audit.Logger, events *webhooks.Publisher, redactPolicy *redact.Policy) *UserService {
 	return &UserService{
-		queries: queries,
-		db:      db,
+		queries:  queries,
+		tx:       NewTransactor(db, queries),
+		settings: runtimeSettings,
+		text:     textPolicy,
+		audit:    auditLog,
+		events:   events,
+		redact:   redactPolicy,
 	}
 }
 
 // CreateUser создает нового пользователя
 // Хеширует пароль перед сохранением в БД
 func (s *UserService) CreateUser(ctx context.Context, req models.CreateUserRequest) (*models.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
 	// 1. Хешируем пароль с помощью bcrypt
-	// bcrypt автоматически добавляет соль и использует безопасный алгоритм
-	// DefaultCost (10) это хороший баланс между безопасностью и производительностью
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	// bcrypt автоматически добавляет соль; стоимость задается при запуске
+	// (BCRYPT_COST или калибровка под PASSWORD_HASH_TARGET_MS)
+	passwordHash, err := passhash.Hash(req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка хеширования пароля: %w", err)
 	}
 
-	// 2. Создаем пользователя в БД через сгенерированный sqlc метод
-	user, err := s.queries.CreateUser(ctx, repository.CreateUserParams{
-		Email:        req.Email,
-		Username:     req.Username,
-		PasswordHash: string(passwordHash),
-		FirstName:    sql.NullString{String: req.FirstName, Valid: req.FirstName != ""},
-		LastName:     sql.NullString{String: req.LastName, Valid: req.LastName != ""},
-	})
+	// 2. Очищаем имена от HTML, приводим email к нижнему регистру, телефон -
+	// к E.164, страну - к ISO 3166-1 alpha-2
+	req.Email = emailaddr.Normalize(req.Email)
+	if err := s.text.Fields(map[string]*string{
+		"first_name": &req.FirstName,
+		"last_name":  &req.LastName,
+	}); err != nil {
+		return nil, err
+	}
+	country, err := normalizeCountry(req.Country)
+	if err != nil {
+		return nil, err
+	}
+	phoneNumber, err := normalizePhone(req.Phone, country)
 	if err != nil {
-		// Здесь можно добавить проверку на дублирование email/username
+		return nil, err
+	}
+
+	// 3. Имя, недавно освобожденное другим пользователем, не занимаем
+	if err := s.checkUsernameHeld(ctx, s.queries, req.Username, 0); err != nil {
+		return nil, err
+	}
+
+	// 4. Создаем пользователя в БД через сгенерированный sqlc метод
+	params := repository.CreateUserParams{
+		Email:          req.Email,
+		Username:       req.Username,
+		PasswordHash:   string(passwordHash),
+		FirstName:      pgtype.Text{String: req.FirstName, Valid: req.FirstName != ""},
+		LastName:       pgtype.Text{String: req.LastName, Valid: req.LastName != ""},
+		Phone:          pgtype.Text{String: phoneNumber, Valid: phoneNumber != ""},
+		Country:        pgtype.Text{String: country, Valid: country != ""},
+		CanonicalEmail: canonicalEmail(req.Email),
+	}
+	user, err := s.queries.CreateUser(ctx, params)
+	if err != nil {
+		// Дубликат email или username: не передаем клиенту текст ошибки
+		// драйвера с именем индекса. Занятый email регистрация не
+		// раскрывает (EmailRegisteredError), имя видно и по профилям
+		if isEmailConflict(err) {
+			return nil, &EmailRegisteredError{User: unsavedUserResponse(params)}
+		}
+		if isUniqueViolation(err) {
+			return nil, ErrUserAlreadyExists
+		}
 		return nil, fmt.Errorf("ошибка создания пользователя: %w", err)
 	}
 
-	// 3. Конвертируем модель БД в модель ответа API
-	return s.toUserResponse(&user), nil
+	// 5. Подписка на сводку, если она включена по умолчанию
+	// Пользователь уже создан, поэтому ошибка подписки не ошибка запроса
+	if s.settings.Bool(settings.DigestAutoSubscribe) {
+		if _, err := s.queries.CreateDigestSubscription(ctx, user.ID); err != nil {
+			slog.WarnContext(ctx, "⚠️  Не удалось подписать пользователя на сводку", "new_user_id", user.ID, "error", err)
+		}
+	}
+
+	// 6. Конвертируем модель БД в модель ответа API
+	created := toUserResponse(&user)
+	metrics.Signups.WithLabelValues(metrics.SignupSourceAPI).Inc()
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionUserCreate, TargetUserID: int(user.ID), After: created})
+	s.events.Publish(ctx, webhooks.EventUserCreated, created)
+	return created, nil
+}
+
+// ResolveUserID находит внутренний ID пользователя по публичному
+//
+// В маршрутах и ответах API пользователь идентифицируется public_id (UUID),
+// а сервисы и связи между таблицами работают с последовательным id.
+// Мягко удаленный пользователь тоже находится: видимость решает операция
+func (s *UserService) ResolveUserID(ctx context.Context, publicID string) (int, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ResolveUserID")
+	defer span.End()
+
+	parsed, err := uuid.Parse(publicID)
+	if err != nil {
+		return 0, ErrInvalidUserID
+	}
+
+	id, err := s.queries.GetUserIDByPublicID(ctx, parsed)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrUserNotFound
+		}
+		return 0, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+	return int(id), nil
 }
 
 // GetUserByID получает пользователя по ID
+// Одновременные запросы одного пользователя выполняют один SQL запрос
 func (s *UserService) GetUserByID(ctx context.Context, id int) (*models.UserResponse, error) {
-	user, err := s.queries.GetUserByID(ctx, int32(id))
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
+
+	user, err := s.coalescedRead(ctx, "GetUserByID", userReadKey(id), func(ctx context.Context) (*models.UserResponse, error) {
+		user, err := s.queries.GetUserByID(ctx, int32(id))
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, ErrUserNotFound
+			}
+			return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
+		}
+		return toUserResponse(&user), nil
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("пользователь не найден")
+		return nil, err
+	}
+
+	// Каждый вызывающий получает свою копию: общий результат не должен
+	// меняться из-за того, что один из handlers дополнил ответ
+	copied := *user
+	return &copied, nil
+}
+
+// coalescedReadTimeout ограничивает общий запрос объединенного чтения
+// Общий запрос не отменяется вместе с запросом, который его начал
+const coalescedReadTimeout = 10 * time.Second
+
+// coalescedRead выполняет read один раз для всех одновременных вызовов
+// с одинаковым key (singleflight)
+//
+// После сброса кеша или всплеска трафика сотни одинаковых запросов
+// превращаются в один запрос к БД. Общий запрос выполняется с контекстом
+// без отмены: отмена запроса, который его начал, не должна приводить
+// к ошибке у остальных. Каждый вызывающий ждет результат не дольше
+// своего контекста.
+//
+// Объединяются только запросы, которые уже выполняются: результат
+// не кешируется. Изменения пользователя вызывают forgetUser, чтобы
+// чтение после записи не получило результат запроса, начатого до нее
+func (s *UserService) coalescedRead(ctx context.Context, operation, key string, read func(context.Context) (*models.UserResponse, error)) (*models.UserResponse, error) {
+	ch := s.reads.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), coalescedReadTimeout)
+		defer cancel()
+		return read(ctx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-ch:
+		if result.Shared {
+			metrics.CoalescedReads.WithLabelValues(operation).Inc()
 		}
-		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.(*models.UserResponse), nil
 	}
+}
+
+// userReadKey - ключ объединенного чтения пользователя по ID
+func userReadKey(id int) string {
+	return "user:" + strconv.Itoa(id)
+}
 
-	return s.toUserResponse(&user), nil
+// forgetUser отвязывает следующие чтения пользователя от запроса,
+// который уже выполняется: он мог начаться до изменения
+func (s *UserService) forgetUser(id int) {
+	s.reads.Forget(userReadKey(id))
 }
 
 // GetUserByEmail получает пользователя по email
 // Полезно для аутентификации
 func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*models.UserResponse, error) {
-	user, err := s.queries.GetUserByEmail(ctx, email)
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByEmail")
+	defer span.End()
+
+	user, err := s.queries.GetUserByEmail(ctx, emailLookup(email))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("пользователь не найден")
+		if err == pgx.ErrNoRows {
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
 	}
 
-	return s.toUserResponse(&user), nil
+	return toUserResponse(&user), nil
 }
 
 // ListUsers возвращает список пользователей с пагинацией
 func (s *UserService) ListUsers(ctx context.Context, req models.ListUsersRequest) (*models.ListUsersResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListUsers")
+	defer span.End()
+
 	// 1. Рассчитываем offset для SQL запроса
 	// Например: страница 2, размер 10 -> offset = (2-1) * 10 = 10
 	offset := (req.Page - 1) * req.PageSize
 
+	// Опциональные поиск и фильтры, одинаковые для списка и подсчета
+	filter := userListFilter(ctx, req)
+
 	// 2. Получаем пользователей из БД
 	users, err := s.queries.ListUsers(ctx, repository.ListUsersParams{
-		Limit:  int32(req.PageSize),
-		Offset: int32(offset),
+		VisibleUserID: filter.VisibleUserID,
+		Search:        filter.Search,
+		Statuses:      filter.Statuses,
+		CreatedAfter:  filter.CreatedAfter,
+		CreatedBefore: filter.CreatedBefore,
+		InactiveSince: filter.InactiveSince,
+		SortBy:        string(req.SortBy),
+		SortDesc:      req.SortDesc,
+		Limit:         int32(req.PageSize),
+		Offset:        int32(offset),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения списка пользователей: %w", err)
 	}
 
 	// 3. Получаем общее количество пользователей для пагинации
-	totalCount, err := s.queries.CountUsers(ctx)
+	totalCount, err := s.queries.CountUsers(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка подсчета пользователей: %w", err)
 	}
@@ -105,119 +390,870 @@ func (s *UserService) ListUsers(ctx context.Context, req models.ListUsersRequest
 	// 4. Конвертируем в формат ответа
 	userResponses := make([]models.UserResponse, len(users))
 	for i, user := range users {
-		userResponses[i] = *s.toUserResponse(&user)
+		userResponses[i] = *toUserResponse(&user)
+	}
+
+	// 5. Рассчитываем общее количество страниц и навигацию
+	resp := newListUsersResponse(req, int(totalCount))
+	resp.Users = userResponses
+
+	// 6. Временные метки границ страницы
+	if len(users) > 0 {
+		resp.FirstItemAt = utc.Ptr(users[0].CreatedAt)
+		resp.LastItemAt = utc.Ptr(users[len(users)-1].CreatedAt)
+	}
+
+	return resp, nil
+}
+
+// userCursor - позиция в списке пользователей для keyset пагинации
+// Упаковывается в непрозрачный токен next_cursor через query.EncodeCursor
+type userCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        int32     `json:"i"`
+}
+
+// ListUsersAfter возвращает страницу списка пользователей после курсора
+// Фильтры берутся из req, сортировка всегда created_at DESC, id DESC.
+// Поврежденный курсор - ошибка query.ErrInvalidParam
+func (s *UserService) ListUsersAfter(ctx context.Context, req models.ListUsersRequest, page query.CursorPage) (*models.ListUsersCursorResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListUsersAfter")
+	defer span.End()
+
+	// 1. Позиция предыдущей страницы, пустой курсор - первая страница
+	filter := userListFilter(ctx, req)
+	params := repository.ListUsersAfterParams{
+		VisibleUserID: filter.VisibleUserID,
+		Search:        filter.Search,
+		Statuses:      filter.Statuses,
+		CreatedAfter:  filter.CreatedAfter,
+		CreatedBefore: filter.CreatedBefore,
+		InactiveSince: filter.InactiveSince,
+		// Одна запись сверх страницы показывает, есть ли следующая
+		Limit: int32(page.Limit + 1),
+	}
+	if page.Cursor != "" {
+		var cursor userCursor
+		if err := query.DecodeCursor(page.Cursor, &cursor); err != nil {
+			return nil, err
+		}
+		if cursor.ID <= 0 || cursor.CreatedAt.IsZero() {
+			return nil, fmt.Errorf("%w: cursor", query.ErrInvalidParam)
+		}
+		params.CursorCreatedAt = pgtype.Timestamp{Time: cursor.CreatedAt, Valid: true}
+		params.CursorID = pgtype.Int4{Int32: cursor.ID, Valid: true}
 	}
 
-	// 5. Рассчитываем общее количество страниц
-	totalPages := int(totalCount) / req.PageSize
-	if int(totalCount)%req.PageSize != 0 {
-		totalPages++
+	// 2. Получаем пользователей из БД
+	users, err := s.queries.ListUsersAfter(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка пользователей: %w", err)
 	}
 
+	resp := &models.ListUsersCursorResponse{Limit: page.Limit}
+	if len(users) > page.Limit {
+		users = users[:page.Limit]
+		resp.HasNext = true
+	}
+
+	// 3. Курсор следующей страницы - позиция последней записи
+	if resp.HasNext {
+		last := users[len(users)-1]
+		next, err := query.EncodeCursor(userCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		if err != nil {
+			return nil, err
+		}
+		resp.NextCursor = &next
+	}
+
+	// 4. Конвертируем в формат ответа
+	resp.Users = make([]models.UserResponse, len(users))
+	for i, user := range users {
+		resp.Users[i] = *toUserResponse(&user)
+	}
+
+	return resp, nil
+}
+
+// userListFilter переводит поиск и фильтры списка в параметры запросов
+// Незаданный фильтр передается как NULL и в SQL не применяется
+//
+// Права вызывающего (userVisibility) тоже попадают в параметры: список,
+// подсчет и выгрузка видят одни и те же строки
+func userListFilter(ctx context.Context, req models.ListUsersRequest) repository.CountUsersParams {
+	filter := repository.CountUsersParams{VisibleUserID: userVisibility(ctx)}
+	if req.Search != "" {
+		filter.Search = pgtype.Text{String: escapeLike(req.Search), Valid: true}
+	}
+	if len(req.Statuses) > 0 {
+		filter.Statuses = make([]string, len(req.Statuses))
+		for i, status := range req.Statuses {
+			filter.Statuses[i] = string(status)
+		}
+	}
+	if req.CreatedAfter != nil {
+		filter.CreatedAfter = pgtype.Timestamp{Time: *req.CreatedAfter, Valid: true}
+	}
+	if req.CreatedBefore != nil {
+		filter.CreatedBefore = pgtype.Timestamp{Time: *req.CreatedBefore, Valid: true}
+	}
+	if req.InactiveSince != nil {
+		filter.InactiveSince = pgtype.Timestamp{Time: *req.InactiveSince, Valid: true}
+	}
+	return filter
+}
+
+// userVisibility возвращает ограничение списков пользователей по правам
+// вызывающего из ctx: администратор видит всех, остальные - только себя.
+// Вызов без пользователя (фоновые задания) не ограничивается: права
+// проверяются при постановке задания
+//
+// Ограничение применяется в SQL, а не фильтром загруженной страницы:
+// иначе страница была бы короче page_size, а total_count считал бы
+// недоступные строки. Организации и команды RBAC добавятся сюда же
+// параметрами запросов ListUsers и CountUsers
+func userVisibility(ctx context.Context) pgtype.Int4 {
+	caller, ok := reqctx.UserFromContext(ctx)
+	if !ok || caller.Role == auth.RoleAdmin {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: int32(caller.ID), Valid: true}
+}
+
+// likeEscaper экранирует спецсимволы шаблона LIKE
+// Без этого поиск "100%" или "a_b" совпадал бы с лишними строками
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike превращает строку поиска в буквальную подстроку для ILIKE
+// Экранирующий символ - обратный слеш, по умолчанию в PostgreSQL
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// newListUsersResponse заполняет метаданные пагинации ответа списка
+func newListUsersResponse(req models.ListUsersRequest, totalCount int) *models.ListUsersResponse {
+	meta := query.NewPageMeta(query.Page{Number: req.Page, Size: req.PageSize}, totalCount)
+
 	return &models.ListUsersResponse{
-		Users:      userResponses,
-		TotalCount: int(totalCount),
+		TotalCount: meta.TotalCount,
 		Page:       req.Page,
 		PageSize:   req.PageSize,
-		TotalPages: totalPages,
-	}, nil
+		TotalPages: meta.TotalPages,
+		HasNext:    meta.HasNext,
+		HasPrev:    meta.HasPrev,
+	}
 }
 
 // UpdateUser обновляет данные пользователя
 func (s *UserService) UpdateUser(ctx context.Context, id int, req models.UpdateUserRequest) (*models.UserResponse, error) {
-	// Конвертируем указатели в sql.Null* типы
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	// Конвертируем указатели в pgtype.* типы
 	// Это позволяет различать "не передано" (nil) и "установить пусто" ("")
 	params := repository.UpdateUserParams{
 		ID: int32(id),
 	}
 
+	// Имена очищаются от HTML до сохранения
+	if err := s.text.Fields(map[string]*string{
+		"first_name": req.FirstName,
+		"last_name":  req.LastName,
+	}); err != nil {
+		return nil, err
+	}
+
 	if req.Email != nil {
-		params.Email = sql.NullString{String: *req.Email, Valid: true}
+		email := emailaddr.Normalize(*req.Email)
+		params.Email = pgtype.Text{String: email, Valid: true}
+		params.CanonicalEmail = canonicalEmail(email)
 	}
 	if req.Username != nil {
-		params.Username = sql.NullString{String: *req.Username, Valid: true}
+		params.Username = pgtype.Text{String: *req.Username, Valid: true}
 	}
 	if req.FirstName != nil {
-		params.FirstName = sql.NullString{String: *req.FirstName, Valid: true}
+		params.FirstName = pgtype.Text{String: *req.FirstName, Valid: true}
 	}
 	if req.LastName != nil {
-		params.LastName = sql.NullString{String: *req.LastName, Valid: true}
+		params.LastName = pgtype.Text{String: *req.LastName, Valid: true}
+	}
+	if err := s.normalizeContactUpdate(ctx, id, req, &params); err != nil {
+		return nil, err
+	}
+
+	before := s.auditSnapshot(ctx, id)
+
+	// Смена email и аннулирование ссылок на старый адрес, смена имени
+	// и запись прежнего имени - одна операция
+	var user repository.User
+	err := s.tx.WithTx(ctx, func(q *repository.Queries) error {
+		var prevUsername string
+		if req.Username != nil {
+			if err := s.checkUsernameHeld(ctx, q, *req.Username, id); err != nil {
+				return err
+			}
+			prev, err := q.GetUserByID(ctx, int32(id))
+			if err != nil {
+				if err == pgx.ErrNoRows {
+					return ErrUserNotFound
+				}
+				return fmt.Errorf("ошибка получения пользователя: %w", err)
+			}
+			prevUsername = prev.Username
+		}
+
+		var err error
+		user, err = q.UpdateUser(ctx, params)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrUserNotFound
+			}
+			if isUniqueViolation(err) {
+				return ErrUserAlreadyExists
+			}
+			return fmt.Errorf("ошибка обновления пользователя: %w", err)
+		}
+
+		// Прежнее имя запоминается: профиль по нему ведет на новое
+		if prevUsername != "" && prevUsername != user.Username {
+			if err := q.CreateUsernameHistory(ctx, repository.CreateUsernameHistoryParams{
+				UserID:   user.ID,
+				Username: prevUsername,
+			}); err != nil {
+				return fmt.Errorf("ошибка сохранения прежнего имени: %w", err)
+			}
+		}
+
+		// При смене email подтверждение сброшено запросом UpdateUser,
+		// а ссылки из писем на старый адрес не должны подтвердить новый
+		if req.Email != nil && !user.EmailVerifiedAt.Valid {
+			if _, err := q.InvalidateUserTokens(ctx, repository.InvalidateUserTokensParams{
+				UserID:  user.ID,
+				Purpose: auth.TokenTypeVerifyEmail,
+			}); err != nil {
+				return fmt.Errorf("ошибка аннулирования ссылок: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	if req.IsActive != nil {
-		params.IsActive = sql.NullBool{Bool: *req.IsActive, Valid: true}
+
+	updated := toUserResponse(&user)
+	s.forgetUser(id)
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionUserUpdate, TargetUserID: id, Before: before, After: updated})
+	s.events.Publish(ctx, webhooks.EventUserUpdated, updated)
+	return updated, nil
+}
+
+// checkUsernameHeld возвращает ErrUsernameHeld, если имя в течение
+// настройки users.username_hold принадлежало другому пользователю
+// userID - пользователь, который занимает имя (0 - новый пользователь)
+func (s *UserService) checkUsernameHeld(ctx context.Context, queries *repository.Queries, username string, userID int) error {
+	hold := s.settings.Duration(settings.UsernameHold)
+	if hold <= 0 {
+		return nil
 	}
 
-	user, err := s.queries.UpdateUser(ctx, params)
+	held, err := queries.IsUsernameHeld(ctx, repository.IsUsernameHeldParams{
+		Username:  username,
+		UserID:    int32(userID),
+		HeldSince: time.Now().UTC().Add(-hold),
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("пользователь не найден")
+		return fmt.Errorf("ошибка проверки прежних имен: %w", err)
+	}
+	if held {
+		return ErrUsernameHeld
+	}
+	return nil
+}
+
+// normalizeContactUpdate нормализует телефон и страну из запроса обновления
+// Пустая строка очищает поле (см. запрос UpdateUser). Национальный номер
+// без "+" разбирается по стране из запроса, а если ее нет - по сохраненной
+func (s *UserService) normalizeContactUpdate(ctx context.Context, id int, req models.UpdateUserRequest, params *repository.UpdateUserParams) error {
+	var country string
+	if req.Country != nil {
+		normalized, err := normalizeCountry(*req.Country)
+		if err != nil {
+			return err
+		}
+		country = normalized
+		params.Country = pgtype.Text{String: country, Valid: true}
+	}
+
+	if req.Phone == nil {
+		return nil
+	}
+	if req.Country == nil && needsCountry(*req.Phone) {
+		user, err := s.queries.GetUserByID(ctx, int32(id))
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("ошибка получения пользователя: %w", err)
 		}
-		return nil, fmt.Errorf("ошибка обновления пользователя: %w", err)
+		country = user.Country.String
+	}
+
+	phoneNumber, err := normalizePhone(*req.Phone, country)
+	if err != nil {
+		return err
+	}
+	params.Phone = pgtype.Text{String: phoneNumber, Valid: true}
+	return nil
+}
+
+// emailLookup - параметры поиска пользователя по введенному email
+// Адрес сравнивается по каноническому виду, см. emailaddr
+func emailLookup(email string) repository.GetUserByEmailParams {
+	return repository.GetUserByEmailParams{
+		CanonicalEmail: canonicalEmail(email),
+		Email:          emailaddr.Normalize(email),
 	}
+}
 
-	return s.toUserResponse(&user), nil
+// canonicalEmail - канонический email для записи в users.canonical_email
+func canonicalEmail(email string) pgtype.Text {
+	return pgtype.Text{String: emailaddr.Canonical(email), Valid: true}
+}
+
+// normalizeCountry приводит код страны к верхнему регистру
+// Пустая строка остается пустой
+func normalizeCountry(country string) (string, error) {
+	if country == "" {
+		return "", nil
+	}
+	code, err := phone.NormalizeCountry(country)
+	if err != nil {
+		return "", &validation.Error{Fields: map[string]string{"country": err.Error()}}
+	}
+	return code, nil
 }
 
-// DeleteUser удаляет пользователя (физически)
+// normalizePhone приводит номер к E.164 по стране country
+// Пустая строка остается пустой. Ошибки возвращаются как *validation.Error,
+// поэтому клиент получает 422 с описанием поля phone
+func normalizePhone(number, country string) (string, error) {
+	if number == "" {
+		return "", nil
+	}
+	normalized, err := phone.Normalize(number, country)
+	if err != nil {
+		return "", &validation.Error{Fields: map[string]string{"phone": err.Error()}}
+	}
+	return normalized, nil
+}
+
+// needsCountry проверяет, что номер указан без международного кода
+func needsCountry(number string) bool {
+	return number != "" && !strings.HasPrefix(strings.TrimSpace(number), "+")
+}
+
+// DeleteUser мягко удаляет пользователя
+// Запись остается в БД, но пропадает из всех выборок,
+// а email и username можно использовать для новой регистрации
 func (s *UserService) DeleteUser(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	before := s.auditSnapshot(ctx, id)
+	rows, err := s.queries.SoftDeleteUser(ctx, int32(id))
+	if err != nil {
+		return fmt.Errorf("ошибка удаления пользователя: %w", err)
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	s.forgetUser(id)
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionUserDelete, TargetUserID: id, Before: before})
+	s.events.Publish(ctx, webhooks.EventUserDeleted, before)
+	return nil
+}
+
+// HardDeleteUser удаляет пользователя физически, включая мягко удаленных
+// Необратимо - предназначено для административной очистки данных
+func (s *UserService) HardDeleteUser(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "UserService.HardDeleteUser")
+	defer span.End()
+
+	// Мягко удаленный пользователь тоже попадает в журнал со своими данными
+	var before *models.UserResponse
+	if s.audit != nil || s.events != nil {
+		if user, err := s.queries.GetUserByIDWithDeleted(ctx, int32(id)); err == nil {
+			before = toUserResponse(&user)
+		}
+	}
+
 	err := s.queries.DeleteUser(ctx, int32(id))
 	if err != nil {
 		return fmt.Errorf("ошибка удаления пользователя: %w", err)
 	}
+	s.forgetUser(id)
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionUserHardDelete, TargetUserID: id, Before: before})
+	// О мягком удалении подписчики уже получили user.deleted
+	if before != nil && before.Status != string(lifecycle.StatusDeleted) {
+		s.events.Publish(ctx, webhooks.EventUserDeleted, before)
+	}
 	return nil
 }
 
-// DeactivateUser деактивирует пользователя (soft delete)
-// Предпочтительный способ в production
-func (s *UserService) DeactivateUser(ctx context.Context, id int) error {
-	err := s.queries.DeactivateUser(ctx, int32(id))
+// ChangeStatus переводит аккаунт в состояние to
+// Переход проверяется по lifecycle: недопустимый - ошибка, обернутая
+// в lifecycle.ErrInvalidTransition. Состояние читается с блокировкой
+// строки, поэтому параллельная смена не проскочит между проверкой и записью.
+// Переход в deleted - мягкое удаление, как DeleteUser
+func (s *UserService) ChangeStatus(ctx context.Context, id int, to lifecycle.Status) (*models.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ChangeStatus")
+	defer span.End()
+
+	var before, after repository.User
+	err := s.tx.WithTx(ctx, func(q *repository.Queries) error {
+		var err error
+		before, err = q.GetUserForUpdate(ctx, int32(id))
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("ошибка получения пользователя: %w", err)
+		}
+
+		if err := lifecycle.Status(before.Status).Transition(to); err != nil {
+			return err
+		}
+
+		// Удаление освобождает email и username (см. SoftDeleteUser)
+		if to == lifecycle.StatusDeleted {
+			if _, err := q.SoftDeleteUser(ctx, int32(id)); err != nil {
+				return fmt.Errorf("ошибка удаления пользователя: %w", err)
+			}
+			after = before
+			after.Status = string(lifecycle.StatusDeleted)
+			return nil
+		}
+
+		after, err = q.SetUserStatus(ctx, repository.SetUserStatusParams{
+			Status: string(to),
+			ID:     int32(id),
+		})
+		if err != nil {
+			return fmt.Errorf("ошибка смены состояния аккаунта: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("ошибка деактивации пользователя: %w", err)
+		return nil, err
+	}
+
+	updated := toUserResponse(&after)
+	s.forgetUser(id)
+	if to == lifecycle.StatusDeactivated {
+		metrics.Deactivations.WithLabelValues(deactivationInitiator(ctx, id)).Inc()
+	}
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionUserStatusUpdate, TargetUserID: id, Before: toUserResponse(&before), After: updated})
+	s.events.Publish(ctx, statusEvent(to), updated)
+	return updated, nil
+}
+
+// deactivationInitiator - метка инициатора деактивации для метрик
+// Без пользователя в контексте аккаунт деактивирует фоновое задание
+func deactivationInitiator(ctx context.Context, id int) string {
+	actor, ok := reqctx.UserFromContext(ctx)
+	switch {
+	case !ok:
+		return metrics.DeactivationSystem
+	case actor.ID == id:
+		return metrics.DeactivationSelf
+	default:
+		return metrics.DeactivationAdmin
 	}
+}
+
+// statusEvent - событие подписчикам о переходе аккаунта в состояние to
+func statusEvent(to lifecycle.Status) string {
+	switch to {
+	case lifecycle.StatusDeactivated:
+		return webhooks.EventUserDeactivated
+	case lifecycle.StatusDeleted:
+		return webhooks.EventUserDeleted
+	default:
+		return webhooks.EventUserUpdated
+	}
+}
+
+// inactiveUsersBatch - сколько аккаунтов деактивируется за один запрос к БД
+const inactiveUsersBatch = 100
+
+// DeactivateInactiveUsers деактивирует аккаунты без входа дольше inactiveFor
+// Выполняется ночным заданием jobs.KindDeactivateInactiveUsers. Каждый
+// аккаунт проходит через ChangeStatus: переход проверяется lifecycle,
+// а в журнал аудита попадает запись без автора (система)
+func (s *UserService) DeactivateInactiveUsers(ctx context.Context, inactiveFor time.Duration) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeactivateInactiveUsers")
+	defer span.End()
+
+	cutoff := time.Now().Add(-inactiveFor)
+	deactivated := 0
+	for {
+		ids, err := s.queries.ListInactiveUserIDs(ctx, repository.ListInactiveUserIDsParams{
+			InactiveSince: cutoff,
+			Limit:         inactiveUsersBatch,
+		})
+		if err != nil {
+			return fmt.Errorf("ошибка поиска неактивных пользователей: %w", err)
+		}
+
+		for _, id := range ids {
+			_, err := s.ChangeStatus(ctx, int(id), lifecycle.StatusDeactivated)
+			// Состояние успели изменить между выборкой и блокировкой строки
+			if errors.Is(err, lifecycle.ErrInvalidTransition) || errors.Is(err, ErrUserNotFound) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("ошибка деактивации пользователя %d: %w", id, err)
+			}
+			deactivated++
+		}
+
+		// Деактивированные выпадают из выборки, поэтому курсор не нужен
+		if len(ids) < inactiveUsersBatch {
+			break
+		}
+	}
+
+	slog.InfoContext(ctx, "💤 Неактивные пользователи деактивированы", "count", deactivated, "inactive_since", cutoff)
 	return nil
 }
 
+// UpdateUserRole назначает пользователю роль
+// Новая роль попадает в access токен пользователя при следующем входе
+// или обновлении токенов
+func (s *UserService) UpdateUserRole(ctx context.Context, id int, role string) (*models.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUserRole")
+	defer span.End()
+
+	before := s.auditSnapshot(ctx, id)
+	user, err := s.queries.UpdateUserRole(ctx, repository.UpdateUserRoleParams{
+		ID:   int32(id),
+		Role: role,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		if isForeignKeyViolation(err) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownRole, role)
+		}
+		return nil, fmt.Errorf("ошибка назначения роли: %w", err)
+	}
+
+	updated := toUserResponse(&user)
+	s.forgetUser(id)
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionUserRoleUpdate, TargetUserID: id, Before: before, After: updated})
+	s.events.Publish(ctx, webhooks.EventUserUpdated, updated)
+	return updated, nil
+}
+
+// auditSnapshot возвращает состояние пользователя до изменения для журнала
+// и события user.deleted. Без журнала и подписчиков или при ошибке чтения
+// возвращает nil: журнал не должен мешать самой операции, которая сама
+// сообщит, что пользователя нет
+func (s *UserService) auditSnapshot(ctx context.Context, id int) *models.UserResponse {
+	if s.audit == nil && s.events == nil {
+		return nil
+	}
+	user, err := s.queries.GetUserByID(ctx, int32(id))
+	if err != nil {
+		return nil
+	}
+	return toUserResponse(&user)
+}
+
 // VerifyPassword проверяет пароль пользователя
-// Используется при аутентификации
+// Используется при аутентификации. Верный пароль - еще не вход: статистику
+// входов фиксирует AuthService, когда выдает токены
 func (s *UserService) VerifyPassword(ctx context.Context, email, password string) (*models.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.VerifyPassword")
+	defer span.End()
+
 	// Получаем пользователя с хешем пароля
-	user, err := s.queries.GetUserByEmail(ctx, email)
+	user, err := s.queries.GetUserByEmail(ctx, emailLookup(email))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("неверный email или пароль")
+		if err == pgx.ErrNoRows {
+			// Выполняем bcrypt сравнение с фиктивным хешем, чтобы ответ
+			// для несуществующего email не был заметно быстрее
+			_ = passhash.Compare(getDummyPasswordHash(), password)
+			return nil, ErrInvalidCredentials
 		}
 		return nil, fmt.Errorf("ошибка проверки пароля: %w", err)
 	}
 
 	// Сравниваем хеш с введенным паролем
-	// bcrypt.CompareHashAndPassword безопасно сравнивает пароли
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	// Сравнение идет за постоянное время
+	err = passhash.Compare([]byte(user.PasswordHash), password)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return toUserResponse(&user), nil
+}
+
+// CheckPassword повторно проверяет пароль уже аутентифицированного пользователя
+// (подтверждение разрушительных действий). В отличие от VerifyPassword
+// не считается входом и не меняет статистику входов
+func (s *UserService) CheckPassword(ctx context.Context, id int, password string) error {
+	ctx, span := tracing.Start(ctx, "UserService.CheckPassword")
+	defer span.End()
+
+	user, err := s.queries.GetUserByID(ctx, int32(id))
 	if err != nil {
-		return nil, fmt.Errorf("неверный email или пароль")
+		if err == pgx.ErrNoRows {
+			_ = passhash.Compare(getDummyPasswordHash(), password)
+			return ErrInvalidCredentials
+		}
+		return fmt.Errorf("ошибка проверки пароля: %w", err)
+	}
+
+	if err := passhash.Compare([]byte(user.PasswordHash), password); err != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// statsPartTimeout ограничивает каждый источник статистики пользователя
+const statsPartTimeout = 2 * time.Second
+
+// GetUserStats собирает статистику активности пользователя
+// Источники: счетчики в таблице users, журнал аудита, привязки и 2FA;
+// новые источники (сессии, использование API) добавляются частью fanout
+func (s *UserService) GetUserStats(ctx context.Context, id int) (*models.UserStatsResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserStats")
+	defer span.End()
+
+	// Источники читаются параллельно: без пользователя сводки нет,
+	// остальные части при сбое или таймауте отдаются пустыми
+	var (
+		user             repository.User
+		auditEvents      int64
+		linkedIdentities int64
+		twoFactorEnabled bool
+	)
+	statuses, err := fanout.Run(ctx, statsPartTimeout,
+		fanout.Part{Name: "user", Required: true, Run: func(ctx context.Context) error {
+			var err error
+			user, err = s.queries.GetUserByID(ctx, int32(id))
+			if err == pgx.ErrNoRows {
+				return ErrUserNotFound
+			}
+			return err
+		}},
+		fanout.Part{Name: "audit", Run: func(ctx context.Context) error {
+			var err error
+			auditEvents, err = s.queries.CountAuditLogs(ctx, repository.CountAuditLogsParams{
+				TargetUserID: pgtype.Int4{Int32: int32(id), Valid: true},
+			})
+			return err
+		}},
+		fanout.Part{Name: "identities", Run: func(ctx context.Context) error {
+			var err error
+			linkedIdentities, err = s.queries.CountUserIdentities(ctx, int32(id))
+			return err
+		}},
+		fanout.Part{Name: "two_factor", Run: func(ctx context.Context) error {
+			totp, err := s.queries.GetUserTOTP(ctx, int32(id))
+			if err == pgx.ErrNoRows {
+				return nil
+			}
+			twoFactorEnabled = totp.EnabledAt.Valid
+			return err
+		}},
+	)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка получения статистики пользователя: %w", err)
+	}
+
+	stats := &models.UserStatsResponse{
+		UserID:         user.PublicID.String(),
+		LoginCount:     int(user.LoginCount),
+		LastLoginAt:    utc.FromNull(user.LastLoginAt),
+		LastActivityAt: utc.From(user.UpdatedAt),
+		AccountAgeDays: int(time.Since(user.CreatedAt).Hours() / 24),
+		CreatedAt:      utc.From(user.CreatedAt),
+		Parts:          fanout.Report(statuses),
+	}
+
+	// Последняя активность - более поздний из входа и изменения профиля
+	if user.LastLoginAt.Valid && user.LastLoginAt.Time.After(user.UpdatedAt) {
+		stats.LastActivityAt = utc.From(user.LastLoginAt.Time)
+	}
+
+	// Необязательные части - только выполненные, причина сбоя в логе
+	for _, part := range statuses {
+		if !part.OK() {
+			slog.WarnContext(ctx, "⚠️ Часть статистики пользователя недоступна",
+				"part", part.Name, "status", part.Status, "user_id", id, "error", part.Err)
+			continue
+		}
+		switch part.Name {
+		case "audit":
+			stats.AuditEvents = &auditEvents
+		case "identities":
+			stats.LinkedIdentities = &linkedIdentities
+		case "two_factor":
+			stats.TwoFactorEnabled = &twoFactorEnabled
+		}
+	}
+
+	return stats, nil
+}
+
+// ImportUsers создает пользователей из записей внешней системы
+// Каждая запись импортируется независимо: ошибка одной (например дубликат)
+// не отменяет остальные и попадает в отчет вместе с ошибками разбора
+func (s *UserService) ImportUsers(ctx context.Context, records []importer.Record, parseErrors []models.ImportRowError) *models.ImportUsersResponse {
+	ctx, span := tracing.Start(ctx, "UserService.ImportUsers")
+	defer span.End()
+
+	result := &models.ImportUsersResponse{
+		Errors: append([]models.ImportRowError{}, parseErrors...),
+	}
+
+	progress := operations.ProgressFromContext(ctx)
+	progress.SetTotal(len(records))
+	for _, record := range records {
+		progress.Add(1)
+		// Email и username проверяются по тем же правилам, что при регистрации:
+		// невалидный адрес из внешней системы не попадает в таблицу
+		if reason := rowViolations(bulk.Row{Email: record.Email, Username: record.Username}); reason != "" {
+			result.Errors = append(result.Errors, models.ImportRowError{Row: record.Row, Error: reason})
+			continue
+		}
+
+		// Имена из внешней системы очищаются так же, как в API
+		if err := s.text.Fields(map[string]*string{
+			"first_name": &record.FirstName,
+			"last_name":  &record.LastName,
+		}); err != nil {
+			result.Errors = append(result.Errors, models.ImportRowError{Row: record.Row, Error: "имя " + sanitize.ErrMarkup.Error()})
+			continue
+		}
+
+		// Без хеша пароля сохраняем хеш случайного пароля:
+		// войти такой пользователь сможет только после сброса пароля
+		passwordHash := record.PasswordHash
+		if passwordHash == "" {
+			passwordHash = string(getDummyPasswordHash())
+		}
+
+		// Сохраняем исходную дату регистрации, если она известна
+		createdAt := time.Now().UTC()
+		if record.CreatedAt != nil {
+			createdAt = *record.CreatedAt
+		}
+
+		email := emailaddr.Normalize(record.Email)
+		user, err := s.queries.ImportUser(ctx, repository.ImportUserParams{
+			Email:          email,
+			Username:       record.Username,
+			PasswordHash:   passwordHash,
+			FirstName:      pgtype.Text{String: record.FirstName, Valid: record.FirstName != ""},
+			LastName:       pgtype.Text{String: record.LastName, Valid: record.LastName != ""},
+			CreatedAt:      createdAt,
+			CanonicalEmail: canonicalEmail(email),
+		})
+		if err != nil {
+			message := "ошибка создания пользователя"
+			if isUniqueViolation(err) {
+				message = ErrUserAlreadyExists.Error()
+			}
+			result.Errors = append(result.Errors, models.ImportRowError{Row: record.Row, Error: message})
+			continue
+		}
+
+		imported := toUserResponse(&user)
+		s.audit.Record(ctx, audit.Event{Action: audit.ActionUserImport, TargetUserID: int(user.ID), After: imported})
+		s.events.Publish(ctx, webhooks.EventUserCreated, imported)
+		result.Imported++
 	}
 
-	return s.toUserResponse(&user), nil
+	metrics.Signups.WithLabelValues(metrics.SignupSourceImport).Add(float64(result.Imported))
+	result.Failed = len(result.Errors)
+	return result
 }
 
 // toUserResponse конвертирует модель БД в модель API ответа
 // Убирает sensitive данные (пароль) и преобразует типы
-func (s *UserService) toUserResponse(user *repository.User) *models.UserResponse {
+func toUserResponse(user *repository.User) *models.UserResponse {
 	resp := &models.UserResponse{
-		ID:        int(user.ID),
-		Email:     user.Email,
-		Username:  user.Username,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:         user.PublicID.String(),
+		InternalID: int(user.ID),
+		Email:      user.Email,
+		Username:   user.Username,
+		Status:     user.Status,
+		IsActive:   lifecycle.Status(user.Status).CanSignIn(),
+		Role:       user.Role,
+		CreatedAt:  utc.From(user.CreatedAt),
+		UpdatedAt:  utc.From(user.UpdatedAt),
+		LoginCount: int(user.LoginCount),
+
+		LastLoginAt:     utc.FromNull(user.LastLoginAt),
+		EmailVerifiedAt: utc.FromNull(user.EmailVerifiedAt),
 	}
 
-	// Преобразуем sql.NullString в *string
+	// Преобразуем pgtype.Text в *string
 	if user.FirstName.Valid {
 		resp.FirstName = &user.FirstName.String
 	}
 	if user.LastName.Valid {
 		resp.LastName = &user.LastName.String
 	}
+	if user.Phone.Valid {
+		resp.Phone = &user.Phone.String
+	}
+	if user.Country.Valid {
+		resp.Country = &user.Country.String
+	}
+	if user.AvatarKey.Valid {
+		url := avatarURL(user.PublicID.String(), user.AvatarKey.String)
+		resp.AvatarURL = &url
+	}
 
 	return resp
 }
+
+// unsavedUserResponse - ответ для пользователя, который не был создан:
+// новый аккаунт со случайным ID, как его вернул бы INSERT
+func unsavedUserResponse(params repository.CreateUserParams) *models.UserResponse {
+	now := time.Now().UTC()
+	return toUserResponse(&repository.User{
+		PublicID:       uuid.New(),
+		Email:          params.Email,
+		Username:       params.Username,
+		FirstName:      params.FirstName,
+		LastName:       params.LastName,
+		Phone:          params.Phone,
+		Country:        params.Country,
+		Status:         string(lifecycle.StatusPendingVerification),
+		Role:           auth.RoleUser,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		CanonicalEmail: params.CanonicalEmail,
+	})
+}
+
+// WarmUp прогревает сервис перед приемом трафика:
+// вычисляет dummyPasswordHash (первый bcrypt занимает заметное время)
+// и выполняет горячий запрос подсчета пользователей
+func (s *UserService) WarmUp(ctx context.Context) error {
+	getDummyPasswordHash()
+
+	if _, err := s.queries.CountUsers(ctx, repository.CountUsersParams{}); err != nil {
+		return fmt.Errorf("ошибка прогрева запросов пользователей: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/auth"
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/importer"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/query"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+	"github.com/Soundveyve/fiber-backend/internal/settings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestCoalescedReadSharesQuery(t *testing.T) {
+	s := &UserService{}
+	var queries atomic.Int32
+	release := make(chan struct{})
+	read := func(ctx context.Context) (*models.UserResponse, error) {
+		queries.Add(1)
+		<-release
+		return &models.UserResponse{Username: "ivan"}, nil
+	}
+
+	const callers = 50
+	var wg sync.WaitGroup
+	var started atomic.Int32
+	results := make([]*models.UserResponse, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			started.Add(1)
+			results[i], _ = s.coalescedRead(context.Background(), "test", userReadKey(1), read)
+		}(i)
+	}
+
+	// Ждем, пока все вызовы начнутся и присоединятся к запросу
+	for started.Load() < callers || queries.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := queries.Load(); n != 1 {
+		t.Errorf("выполнено %d запросов, ожидался 1", n)
+	}
+	for i, user := range results {
+		if user == nil || user.Username != "ivan" {
+			t.Fatalf("вызов %d получил %+v", i, user)
+		}
+	}
+}
+
+func TestCoalescedReadCancelledCallerDoesNotFailOthers(t *testing.T) {
+	s := &UserService{}
+	release := make(chan struct{})
+	read := func(ctx context.Context) (*models.UserResponse, error) {
+		select {
+		case <-release:
+			return &models.UserResponse{Username: "ivan"}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	// Первый вызов начинает запрос и отменяется
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, err := s.coalescedRead(leaderCtx, "test", userReadKey(1), read)
+		leaderDone <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	followerDone := make(chan *models.UserResponse, 1)
+	go func() {
+		user, _ := s.coalescedRead(context.Background(), "test", userReadKey(1), read)
+		followerDone <- user
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	if err := <-leaderDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("отмененный вызов вернул %v", err)
+	}
+	close(release)
+	if user := <-followerDone; user == nil || user.Username != "ivan" {
+		t.Errorf("второй вызов получил %+v, отмена первого не должна его прерывать", user)
+	}
+}
+
+func TestForgetUserStartsNewQuery(t *testing.T) {
+	s := &UserService{}
+	var queries atomic.Int32
+	release := make(chan struct{})
+	read := func(ctx context.Context) (*models.UserResponse, error) {
+		queries.Add(1)
+		<-release
+		return &models.UserResponse{}, nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = s.coalescedRead(context.Background(), "test", userReadKey(1), read)
+	}()
+	for queries.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Запись между чтениями: следующее чтение не должно получить старый результат
+	s.forgetUser(1)
+	go func() {
+		defer wg.Done()
+		_, _ = s.coalescedRead(context.Background(), "test", userReadKey(1), read)
+	}()
+	for queries.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+}
+
+func TestUserListFilterTrimsToCallerVisibility(t *testing.T) {
+	req := models.ListUsersRequest{Search: "ivan"}
+
+	// Администратор и фоновые задания видят всех
+	admin := reqctx.WithUser(context.Background(), reqctx.User{ID: 1, Role: auth.RoleAdmin})
+	for _, ctx := range []context.Context{admin, context.Background()} {
+		if filter := userListFilter(ctx, req); filter.VisibleUserID.Valid {
+			t.Errorf("VisibleUserID = %+v, ожидалось без ограничения", filter.VisibleUserID)
+		}
+	}
+
+	// Остальные - только себя, вместе с остальными фильтрами
+	user := reqctx.WithUser(context.Background(), reqctx.User{ID: 42, Role: auth.RoleUser})
+	filter := userListFilter(user, req)
+	if !filter.VisibleUserID.Valid || filter.VisibleUserID.Int32 != 42 {
+		t.Errorf("VisibleUserID = %+v, ожидался 42", filter.VisibleUserID)
+	}
+	if filter.Search.String != "ivan" {
+		t.Errorf("Search = %+v, фильтр потерян", filter.Search)
+	}
+}
+
+func TestListUserChangesRejectsBadTokens(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.SyncRetention = 30 * 24 * time.Hour
+	s := &UserService{settings: settings.New(nil, settings.Definitions(cfg))}
+
+	// Токен не читается - ошибка клиента, а не сервера
+	if _, err := s.ListUserChanges(context.Background(), "not-a-token", 10); !errors.Is(err, ErrInvalidSyncToken) {
+		t.Errorf("поврежденный токен: %v, ожидался ErrInvalidSyncToken", err)
+	}
+
+	// Удаления старше хранения могли быть очищены - нужна полная загрузка
+	expired, err := query.EncodeCursor(userSyncCursor{Horizon: time.Now().Add(-31 * 24 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ListUserChanges(context.Background(), expired, 10); !errors.Is(err, ErrSyncTokenExpired) {
+		t.Errorf("устаревший токен: %v, ожидался ErrSyncTokenExpired", err)
+	}
+}
+
+// importDB запоминает email каждой вставки; сама вставка завершается
+// ошибкой, чтобы тесту не требовались аудит и вебхуки
+type importDB struct {
+	repository.DBTX
+	inserted []interface{}
+}
+
+func (d *importDB) QueryRow(_ context.Context, _ string, args ...interface{}) pgx.Row {
+	d.inserted = append(d.inserted, args[0])
+	return importRow{}
+}
+
+type importRow struct{}
+
+func (importRow) Scan(...interface{}) error { return errors.New("БД недоступна") }
+
+func TestImportUsersRejectsInvalidEmails(t *testing.T) {
+	db := &importDB{}
+	s := &UserService{queries: repository.New(db)}
+
+	result := s.ImportUsers(context.Background(), []importer.Record{
+		{Row: 1, Email: "not-an-email", Username: "ivan"},
+		{Row: 2, Email: "Petr@Example.com", Username: "petr"},
+		{Row: 3, Email: "anna@example.com", Username: "an"},
+	}, nil)
+
+	// Невалидные строки не доходят до БД, валидная - вставляется
+	if len(db.inserted) != 1 || db.inserted[0] != "petr@example.com" {
+		t.Errorf("вставлены %v, ожидался только petr@example.com", db.inserted)
+	}
+	want := map[int]string{
+		1: "email: должен быть валидным email",
+		2: "ошибка создания пользователя",
+		3: "username: минимум 3 символов",
+	}
+	if len(result.Errors) != len(want) || result.Failed != len(want) || result.Imported != 0 {
+		t.Fatalf("результат %+v, ожидалось %d ошибок", result, len(want))
+	}
+	for _, rowErr := range result.Errors {
+		if rowErr.Error != want[rowErr.Row] {
+			t.Errorf("строка %d: %q, ожидалось %q", rowErr.Row, rowErr.Error, want[rowErr.Row])
+		}
+	}
+}
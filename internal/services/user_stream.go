@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+)
+
+// userStreamBatchSize - сколько строк читается из БД за один запрос
+// при потоковой выдаче. В памяти одновременно находится не больше одной пачки
+const userStreamBatchSize = 500
+
+// UserStream - потоковая выдача большой страницы списка пользователей
+//
+// Ответ имеет тот же формат, что и models.ListUsersResponse, но массив users
+// пишется по одной записи, а строки читаются из БД пачками. Поэтому память
+// не растет с размером страницы.
+type UserStream struct {
+	queries *repository.Queries
+	req     models.ListUsersRequest
+	filter  repository.CountUsersParams
+	meta    *models.ListUsersResponse
+}
+
+// NewUserStream подготавливает потоковую выдачу страницы
+// Подсчет выполняется сразу, чтобы ошибку БД можно было вернуть
+// обычным ответом до начала записи тела
+func (s *UserService) NewUserStream(ctx context.Context, req models.ListUsersRequest) (*UserStream, error) {
+	filter := userListFilter(ctx, req)
+
+	totalCount, err := s.queries.CountUsers(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчета пользователей: %w", err)
+	}
+
+	return &UserStream{
+		queries: s.queries,
+		req:     req,
+		filter:  filter,
+		meta:    newListUsersResponse(req, int(totalCount)),
+	}, nil
+}
+
+// WriteTo пишет JSON ответ в w
+//
+// После каждой пачки буфер сбрасывается в соединение. Flush блокируется,
+// пока медленный клиент не заберет данные, и следующая пачка не читается
+// из БД раньше времени - так работает backpressure.
+// Ошибка в середине потока означает оборванный (невалидный) JSON:
+// статус ответа к этому моменту уже отправлен.
+func (st *UserStream) WriteTo(ctx context.Context, w *bufio.Writer) error {
+	// 1. Метаданные пагинации известны заранее и пишутся до массива
+	meta := st.meta
+	if _, err := fmt.Fprintf(w, `{"total_count":%d,"page":%d,"page_size":%d,"total_pages":%d,"has_next":%t,"has_prev":%t,"users":[`,
+		meta.TotalCount, meta.Page, meta.PageSize, meta.TotalPages, meta.HasNext, meta.HasPrev); err != nil {
+		return err
+	}
+
+	// 2. Пользователи страницы пачками
+	var firstItemAt, lastItemAt *time.Time
+	written := 0
+	offset := (st.req.Page - 1) * st.req.PageSize
+
+	for written < st.req.PageSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		limit := min(userStreamBatchSize, st.req.PageSize-written)
+		users, err := st.queries.ListUsers(ctx, repository.ListUsersParams{
+			VisibleUserID: st.filter.VisibleUserID,
+			Search:        st.filter.Search,
+			Statuses:      st.filter.Statuses,
+			CreatedAfter:  st.filter.CreatedAfter,
+			CreatedBefore: st.filter.CreatedBefore,
+			InactiveSince: st.filter.InactiveSince,
+			SortBy:        string(st.req.SortBy),
+			SortDesc:      st.req.SortDesc,
+			Limit:         int32(limit),
+			Offset:        int32(offset + written),
+		})
+		if err != nil {
+			return fmt.Errorf("ошибка получения списка пользователей: %w", err)
+		}
+
+		for i := range users {
+			data, err := json.Marshal(toUserResponse(&users[i]))
+			if err != nil {
+				return err
+			}
+			if written+i > 0 {
+				if err := w.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+
+		if len(users) > 0 {
+			if firstItemAt == nil {
+				firstItemAt = &users[0].CreatedAt
+			}
+			lastItemAt = &users[len(users)-1].CreatedAt
+		}
+		written += len(users)
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if len(users) < limit {
+			break
+		}
+	}
+
+	// 3. Границы страницы известны только после выдачи всех строк
+	if _, err := w.WriteString("]"); err != nil {
+		return err
+	}
+	if firstItemAt != nil {
+		first, _ := json.Marshal(utc.From(*firstItemAt))
+		last, _ := json.Marshal(utc.From(*lastItemAt))
+		if _, err := fmt.Fprintf(w, `,"first_item_at":%s,"last_item_at":%s`, first, last); err != nil {
+			return err
+		}
+	}
+	if _, err := w.WriteString("}"); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
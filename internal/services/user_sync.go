@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/query"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/settings"
+	"github.com/Soundveyve/fiber-backend/internal/tracing"
+)
+
+// syncSettle - сколько последних секунд изменений не отдается клиентам,
+// совпадает с интервалом в ListUserChanges и ListUserTombstones
+const syncSettle = 5 * time.Second
+
+var (
+	// ErrInvalidSyncToken - since не разбирается как токен синхронизации
+	// Токен не подписан, поэтому подлинность не проверяется: клиент может
+	// собрать позицию сам, но права проверяются запросом, а не токеном
+	ErrInvalidSyncToken = apperrors.Invalid("INVALID_SYNC_TOKEN", "невалидный токен синхронизации, загрузите список заново")
+	// ErrSyncTokenExpired - записи об удалениях с момента токена уже очищены
+	ErrSyncTokenExpired = apperrors.New(apperrors.ErrGone, "SYNC_TOKEN_EXPIRED", "токен синхронизации устарел, загрузите список заново")
+)
+
+// userSyncCursor - позиция клиента в синхронизации пользователей
+// Позиции в users и user_tombstones независимы: каждая таблица читается
+// своим индексом с места, где клиент остановился
+type userSyncCursor struct {
+	UpdatedAt   time.Time `json:"u"`
+	UserID      int32     `json:"i"`
+	DeletedAt   time.Time `json:"d"`
+	TombstoneID int64     `json:"t"`
+
+	// Horizon - до какого момента клиент получил все физические удаления
+	// Старше users.sync_retention - записи могли быть очищены, токен устарел
+	Horizon time.Time `json:"h"`
+}
+
+// ListUserChanges возвращает изменения пользователей после токена since:
+// созданных, измененных и удаленных (мягко - по deleted_at, физически -
+// по user_tombstones). Пустой since - первая синхронизация с начала.
+//
+// Изменения идут по возрастанию updated_at, поэтому клиент, прервавший
+// синхронизацию, продолжает с последнего полученного токена. Права
+// вызывающего - как у списка: не администратор получает только свою
+// запись и не получает физические удаления чужих записей
+func (s *UserService) ListUserChanges(ctx context.Context, since string, limit int) (*models.UserChangesResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListUserChanges")
+	defer span.End()
+
+	// 1. Позиция прошлой синхронизации
+	var cursor userSyncCursor
+	if since != "" {
+		if err := query.DecodeCursor(since, &cursor); err != nil {
+			return nil, ErrInvalidSyncToken
+		}
+		if cursor.Horizon.Before(time.Now().Add(-s.settings.Duration(settings.SyncRetention))) {
+			return nil, ErrSyncTokenExpired
+		}
+	}
+
+	// 2. Изменения пользователей и физические удаления после позиции
+	// Одна запись сверх limit показывает, есть ли еще изменения
+	visible := userVisibility(ctx)
+	params := repository.ListUserChangesParams{
+		VisibleUserID: visible,
+		Limit:         int32(limit + 1),
+	}
+	if since != "" {
+		params.CursorUpdatedAt = pgtype.Timestamp{Time: cursor.UpdatedAt, Valid: true}
+		params.CursorID = pgtype.Int4{Int32: cursor.UserID, Valid: true}
+	}
+	users, err := s.queries.ListUserChanges(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения изменений пользователей: %w", err)
+	}
+
+	var tombstones []repository.UserTombstone
+	if !visible.Valid {
+		tombstoneParams := repository.ListUserTombstonesParams{Limit: int32(limit + 1)}
+		if since != "" {
+			tombstoneParams.CursorDeletedAt = pgtype.Timestamp{Time: cursor.DeletedAt, Valid: true}
+			tombstoneParams.CursorID = pgtype.Int8{Int64: cursor.TombstoneID, Valid: true}
+		}
+		tombstones, err = s.queries.ListUserTombstones(ctx, tombstoneParams)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения удаленных пользователей: %w", err)
+		}
+	}
+
+	resp := &models.UserChangesResponse{
+		Created: []models.UserResponse{},
+		Updated: []models.UserResponse{},
+		Deleted: []string{},
+	}
+	if len(users) > limit {
+		users = users[:limit]
+		resp.HasMore = true
+	}
+	next := cursor
+	next.Horizon = time.Now().Add(-syncSettle)
+	if len(tombstones) > limit {
+		tombstones = tombstones[:limit]
+		resp.HasMore = true
+		// Удаления после этой страницы клиент еще не видел
+		next.Horizon = tombstones[len(tombstones)-1].DeletedAt
+	}
+
+	// 3. Раскладываем по видам изменений; созданный после прошлой
+	// синхронизации - новый для клиента, даже если его успели изменить
+	for _, user := range users {
+		switch {
+		case user.DeletedAt.Valid:
+			resp.Deleted = append(resp.Deleted, user.PublicID.String())
+		case since == "" || user.CreatedAt.After(cursor.UpdatedAt):
+			resp.Created = append(resp.Created, *toUserResponse(&user))
+		default:
+			resp.Updated = append(resp.Updated, *toUserResponse(&user))
+		}
+	}
+	for _, tombstone := range tombstones {
+		resp.Deleted = append(resp.Deleted, tombstone.PublicID.String())
+	}
+
+	// 4. Токен следующей синхронизации - позиция последних полученных записей
+	if len(users) > 0 {
+		last := users[len(users)-1]
+		next.UpdatedAt, next.UserID = last.UpdatedAt, last.ID
+	}
+	if len(tombstones) > 0 {
+		last := tombstones[len(tombstones)-1]
+		next.DeletedAt, next.TombstoneID = last.DeletedAt, last.ID
+	}
+	resp.NextCursor, err = query.EncodeCursor(next)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// PurgeUserTombstones удаляет записи о физически удаленных пользователях
+// старше users.sync_retention (задание purge_user_tombstones)
+func (s *UserService) PurgeUserTombstones(ctx context.Context) error {
+	before := time.Now().Add(-s.settings.Duration(settings.SyncRetention))
+	deleted, err := s.queries.PurgeUserTombstones(ctx, before)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления записей об удаленных пользователях: %w", err)
+	}
+	slog.InfoContext(ctx, "🧹 Старые записи об удаленных пользователях удалены", "count", deleted)
+	return nil
+}
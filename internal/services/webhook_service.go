@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/publicid"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// webhookDeliveriesLimit - сколько последних доставок показывает журнал подписки
+const webhookDeliveriesLimit = 100
+
+// ErrWebhookNotFound возвращается, когда подписки нет
+var ErrWebhookNotFound = apperrors.NotFound("WEBHOOK_NOT_FOUND", "подписка не найдена")
+
+// WebhookService управляет подписками внешних систем на события пользователей
+// Сами события публикует и доставляет webhooks.Publisher
+type WebhookService struct {
+	queries *repository.Queries
+}
+
+// NewWebhookService создает сервис подписок на события
+func NewWebhookService(queries *repository.Queries) *WebhookService {
+	return &WebhookService{
+		queries: queries,
+	}
+}
+
+// CreateWebhook регистрирует подписку и генерирует ключ подписи
+// Ключ возвращается только в ответе на создание
+func (s *WebhookService) CreateWebhook(ctx context.Context, req models.CreateWebhookRequest, createdBy int) (*models.WebhookResponse, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("ошибка генерации ключа подписи: %w", err)
+	}
+
+	webhook, err := s.queries.CreateWebhook(ctx, repository.CreateWebhookParams{
+		Url:       req.URL,
+		Events:    dedupe(req.Events),
+		Secret:    hex.EncodeToString(secret),
+		CreatedBy: pgtype.Int4{Int32: int32(createdBy), Valid: createdBy != 0},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания подписки: %w", err)
+	}
+
+	resp := toWebhookResponse(&webhook)
+	resp.Secret = webhook.Secret
+	return resp, nil
+}
+
+// ListWebhooks возвращает все подписки без ключей подписи
+func (s *WebhookService) ListWebhooks(ctx context.Context) (*models.ListWebhooksResponse, error) {
+	webhooks, err := s.queries.ListWebhooks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения подписок: %w", err)
+	}
+
+	resp := &models.ListWebhooksResponse{Webhooks: make([]models.WebhookResponse, 0, len(webhooks))}
+	for i := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, *toWebhookResponse(&webhooks[i]))
+	}
+	return resp, nil
+}
+
+// DeleteWebhook удаляет подписку вместе с журналом доставки
+// Недоставленные события подписчику больше не отправляются
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	rows, err := s.queries.DeleteWebhook(ctx, int32(id))
+	if err != nil {
+		return fmt.Errorf("ошибка удаления подписки: %w", err)
+	}
+	if rows == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// ListDeliveries возвращает последние доставки событий подписки
+func (s *WebhookService) ListDeliveries(ctx context.Context, id int) (*models.ListWebhookDeliveriesResponse, error) {
+	if _, err := s.queries.GetWebhook(ctx, int32(id)); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения подписки: %w", err)
+	}
+
+	deliveries, err := s.queries.ListWebhookDeliveries(ctx, repository.ListWebhookDeliveriesParams{
+		WebhookID: int32(id),
+		Limit:     webhookDeliveriesLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения журнала доставки: %w", err)
+	}
+
+	resp := &models.ListWebhookDeliveriesResponse{Deliveries: make([]models.WebhookDeliveryResponse, 0, len(deliveries))}
+	for _, d := range deliveries {
+		delivery := models.WebhookDeliveryResponse{
+			ID:          publicid.ID(d.ID),
+			Event:       d.Event,
+			Status:      d.Status,
+			Attempts:    int(d.Attempts),
+			CreatedAt:   utc.From(d.CreatedAt),
+			DeliveredAt: utc.FromNull(d.DeliveredAt),
+		}
+		if d.ResponseStatus.Valid {
+			status := int(d.ResponseStatus.Int32)
+			delivery.ResponseStatus = &status
+		}
+		if d.LastError.Valid {
+			delivery.LastError = &d.LastError.String
+		}
+		resp.Deliveries = append(resp.Deliveries, delivery)
+	}
+	return resp, nil
+}
+
+// toWebhookResponse конвертирует подписку из БД в ответ API без ключа подписи
+func toWebhookResponse(webhook *repository.Webhook) *models.WebhookResponse {
+	return &models.WebhookResponse{
+		ID:        publicid.ID(webhook.ID),
+		URL:       webhook.Url,
+		Events:    webhook.Events,
+		CreatedAt: utc.From(webhook.CreatedAt),
+	}
+}
+
+// dedupe убирает повторы, сохраняя порядок
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,154 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+)
+
+// Ключи настроек
+const (
+	HealthCacheTTL      = "health.cache_ttl"      // Время жизни кеша /health/lb
+	ExportURLTTL        = "export.url_ttl"        // Время жизни ссылки на скачивание экспорта
+	BroadcastBatchSize  = "broadcast.batch_size"  // Получателей в пачке рассылки
+	DigestAutoSubscribe = "digest.auto_subscribe" // Подписывать новых пользователей на сводку
+	UsernameHold        = "users.username_hold"   // Резерв прежнего имени после переименования
+	SyncRetention       = "users.sync_retention"  // Хранение записей об удалении для синхронизации
+)
+
+// Типы значений настроек
+const (
+	TypeInt      = "int"
+	TypeBool     = "bool"
+	TypeDuration = "duration"
+)
+
+// Definitions описывает настройки, которые можно переопределить
+// Значения по умолчанию берутся из конфигурации (переменных окружения)
+func Definitions(cfg *config.Config) []Definition {
+	return []Definition{
+		DurationSetting(HealthCacheTTL, "Время жизни кеша проверки зависимостей для /health/lb",
+			cfg.App.HealthCacheTTL, 0, time.Minute),
+		DurationSetting(ExportURLTTL, "Время жизни ссылки на скачивание готового экспорта",
+			cfg.Export.URLTTL, time.Minute, 24*time.Hour),
+		IntSetting(BroadcastBatchSize, "Получателей в пачке рассылки; прогресс сохраняется после каждой пачки",
+			cfg.Broadcast.BatchSize, 1, 1000),
+		BoolSetting(DigestAutoSubscribe, "Подписывать создаваемых пользователей на еженедельную сводку",
+			cfg.Digest.AutoSubscribe),
+		DurationSetting(UsernameHold, "Сколько прежнее имя пользователя недоступно другим после переименования",
+			cfg.App.UsernameHold, 0, 365*24*time.Hour),
+		DurationSetting(SyncRetention, "Сколько хранятся записи об удаленных пользователях для синхронизации клиентов",
+			cfg.App.SyncRetention, 24*time.Hour, 365*24*time.Hour),
+	}
+}
+
+// Definition описывает настройку: ключ, тип, значение по умолчанию
+// и правила разбора значения
+type Definition struct {
+	Key         string
+	Type        string
+	Description string
+	Default     interface{}
+
+	parse  func(string) (interface{}, error)          // Значение из БД
+	decode func(json.RawMessage) (interface{}, error) // Значение из admin API с проверкой диапазона
+	format func(interface{}) string                   // Значение для БД
+	public func(interface{}) interface{}              // Значение для JSON ответа
+}
+
+// JSONValue переводит значение настройки в представление для JSON ответа
+func (d Definition) JSONValue(value interface{}) interface{} {
+	return d.public(value)
+}
+
+// IntSetting описывает целочисленную настройку в диапазоне [min, max]
+func IntSetting(key, description string, def, min, max int) Definition {
+	return Definition{
+		Key:         key,
+		Type:        TypeInt,
+		Description: description,
+		Default:     def,
+		parse: func(s string) (interface{}, error) {
+			return strconv.Atoi(s)
+		},
+		decode: func(raw json.RawMessage) (interface{}, error) {
+			var value int
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, fmt.Errorf("должно быть целым числом")
+			}
+			if value < min || value > max {
+				return nil, fmt.Errorf("должно быть от %d до %d", min, max)
+			}
+			return value, nil
+		},
+		format: func(v interface{}) string {
+			return strconv.Itoa(v.(int))
+		},
+		public: func(v interface{}) interface{} {
+			return v
+		},
+	}
+}
+
+// BoolSetting описывает логическую настройку (включение функций)
+func BoolSetting(key, description string, def bool) Definition {
+	return Definition{
+		Key:         key,
+		Type:        TypeBool,
+		Description: description,
+		Default:     def,
+		parse: func(s string) (interface{}, error) {
+			return strconv.ParseBool(s)
+		},
+		decode: func(raw json.RawMessage) (interface{}, error) {
+			var value bool
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, fmt.Errorf("должно быть true или false")
+			}
+			return value, nil
+		},
+		format: func(v interface{}) string {
+			return strconv.FormatBool(v.(bool))
+		},
+		public: func(v interface{}) interface{} {
+			return v
+		},
+	}
+}
+
+// DurationSetting описывает настройку-длительность в диапазоне [min, max]
+// В admin API значение передается строкой Go формата: "30s", "15m", "1h30m"
+func DurationSetting(key, description string, def, min, max time.Duration) Definition {
+	return Definition{
+		Key:         key,
+		Type:        TypeDuration,
+		Description: description,
+		Default:     def,
+		parse: func(s string) (interface{}, error) {
+			return time.ParseDuration(s)
+		},
+		decode: func(raw json.RawMessage) (interface{}, error) {
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, fmt.Errorf("должно быть строкой длительности, например \"30s\"")
+			}
+			value, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("должно быть строкой длительности, например \"30s\"")
+			}
+			if value < min || value > max {
+				return nil, fmt.Errorf("должно быть от %s до %s", min, max)
+			}
+			return value, nil
+		},
+		format: func(v interface{}) string {
+			return v.(time.Duration).String()
+		},
+		public: func(v interface{}) interface{} {
+			return v.(time.Duration).String()
+		},
+	}
+}
@@ -0,0 +1,235 @@
+// Package settings - настройки приложения, которые можно менять во время работы.
+//
+// Значение по умолчанию каждой настройки приходит из переменных окружения,
+// строка в таблице settings его переопределяет. Ключи и их типы описаны
+// в коде (Definitions), поэтому в БД нельзя записать неизвестный ключ или
+// значение не того типа. Переопределения загружаются при запуске и
+// перечитываются каждые SETTINGS_REFRESH_INTERVAL, так что изменение
+// через admin API доходит до всех инстансов без перезапуска.
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/validation"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrUnknownSetting возвращается для ключа, которого нет в Definitions
+var ErrUnknownSetting = apperrors.NotFound("SETTING_NOT_FOUND", "неизвестная настройка")
+
+// Override - переопределение настройки из БД
+type Override struct {
+	UpdatedBy string // Публичный ID администратора, пустой - неизвестен
+	UpdatedAt time.Time
+}
+
+// Entry - настройка с текущим значением для admin API
+type Entry struct {
+	Definition
+	Value    interface{} // Действующее значение
+	Override *Override   // nil - действует значение по умолчанию
+}
+
+// snapshot - действующие значения, заменяется целиком при перезагрузке
+type snapshot struct {
+	values    map[string]interface{}
+	overrides map[string]*Override
+}
+
+// Settings хранит действующие значения настроек
+// Чтение не обращается к БД и безопасно из любых горутин
+type Settings struct {
+	queries *repository.Queries
+	defs    []Definition
+	byKey   map[string]Definition
+
+	current atomic.Pointer[snapshot]
+}
+
+// New создает настройки со значениями по умолчанию
+// Переопределения из БД подгружает Load
+func New(queries *repository.Queries, defs []Definition) *Settings {
+	s := &Settings{
+		queries: queries,
+		defs:    defs,
+		byKey:   make(map[string]Definition, len(defs)),
+	}
+	for _, def := range defs {
+		s.byKey[def.Key] = def
+	}
+	s.current.Store(s.build(nil))
+	return s
+}
+
+// Load перечитывает переопределения из БД
+// Неизвестные ключи и невалидные значения пропускаются с предупреждением:
+// настройка, удаленная из кода, или поврежденная строка не должны
+// мешать запуску
+func (s *Settings) Load(ctx context.Context) error {
+	rows, err := s.queries.ListSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки настроек: %w", err)
+	}
+	s.current.Store(s.build(rows))
+	return nil
+}
+
+// build собирает снимок из значений по умолчанию и переопределений
+func (s *Settings) build(rows []repository.ListSettingsRow) *snapshot {
+	snap := &snapshot{
+		values:    make(map[string]interface{}, len(s.defs)),
+		overrides: make(map[string]*Override, len(rows)),
+	}
+	for _, def := range s.defs {
+		snap.values[def.Key] = def.Default
+	}
+
+	for _, row := range rows {
+		def, ok := s.byKey[row.Key]
+		if !ok {
+			slog.Warn("⚠️  Неизвестная настройка в БД пропущена", "key", row.Key)
+			continue
+		}
+		value, err := def.parse(row.Value)
+		if err != nil {
+			slog.Warn("⚠️  Невалидное значение настройки в БД пропущено", "key", row.Key, "error", err)
+			continue
+		}
+
+		snap.values[row.Key] = value
+		override := &Override{UpdatedAt: row.UpdatedAt}
+		if row.UpdatedByPublicID.Valid {
+			override.UpdatedBy = row.UpdatedByPublicID.UUID.String()
+		}
+		snap.overrides[row.Key] = override
+	}
+
+	return snap
+}
+
+// RunRefresher перечитывает переопределения каждые interval, пока не отменен ctx
+// При ошибке продолжают действовать прежние значения
+func (s *Settings) RunRefresher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Load(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("❌ Ошибка обновления настроек", "error", err)
+			}
+		}
+	}
+}
+
+// Int возвращает значение целочисленной настройки
+func (s *Settings) Int(key string) int {
+	value, _ := s.current.Load().values[key].(int)
+	return value
+}
+
+// Duration возвращает значение настройки-длительности
+func (s *Settings) Duration(key string) time.Duration {
+	value, _ := s.current.Load().values[key].(time.Duration)
+	return value
+}
+
+// Bool возвращает значение логической настройки
+func (s *Settings) Bool(key string) bool {
+	value, _ := s.current.Load().values[key].(bool)
+	return value
+}
+
+// List возвращает все настройки в порядке Definitions
+func (s *Settings) List() []Entry {
+	snap := s.current.Load()
+	entries := make([]Entry, 0, len(s.defs))
+	for _, def := range s.defs {
+		entries = append(entries, s.entry(snap, def))
+	}
+	return entries
+}
+
+// Get возвращает настройку по ключу
+func (s *Settings) Get(key string) (Entry, error) {
+	def, ok := s.byKey[key]
+	if !ok {
+		return Entry{}, ErrUnknownSetting
+	}
+	return s.entry(s.current.Load(), def), nil
+}
+
+// entry собирает настройку для admin API
+func (s *Settings) entry(snap *snapshot, def Definition) Entry {
+	return Entry{
+		Definition: def,
+		Value:      snap.values[def.Key],
+		Override:   snap.overrides[def.Key],
+	}
+}
+
+// Set переопределяет настройку
+// Значение передается JSON в представлении типа (число, true/false, "30s")
+// и проверяется на допустимый диапазон. Этот инстанс применяет его сразу,
+// остальные - при следующем обновлении
+func (s *Settings) Set(ctx context.Context, key string, raw json.RawMessage, updatedBy int) (Entry, error) {
+	def, ok := s.byKey[key]
+	if !ok {
+		return Entry{}, ErrUnknownSetting
+	}
+
+	// null разбирается в нулевое значение без ошибки
+	if len(raw) == 0 || string(raw) == "null" {
+		return Entry{}, &validation.Error{Fields: map[string]string{"value": "обязательное поле"}}
+	}
+
+	value, err := def.decode(raw)
+	if err != nil {
+		return Entry{}, &validation.Error{Fields: map[string]string{"value": err.Error()}}
+	}
+
+	if _, err := s.queries.UpsertSetting(ctx, repository.UpsertSettingParams{
+		Key:       key,
+		Value:     def.format(value),
+		UpdatedBy: pgtype.Int4{Int32: int32(updatedBy), Valid: updatedBy != 0},
+	}); err != nil {
+		return Entry{}, fmt.Errorf("ошибка сохранения настройки: %w", err)
+	}
+
+	if err := s.Load(ctx); err != nil {
+		return Entry{}, err
+	}
+	slog.InfoContext(ctx, "⚙️  Настройка изменена", "key", key, "value", def.format(value))
+
+	return s.Get(key)
+}
+
+// Reset удаляет переопределение, возвращая значение по умолчанию
+func (s *Settings) Reset(ctx context.Context, key string) (Entry, error) {
+	if _, ok := s.byKey[key]; !ok {
+		return Entry{}, ErrUnknownSetting
+	}
+
+	if _, err := s.queries.DeleteSetting(ctx, key); err != nil {
+		return Entry{}, fmt.Errorf("ошибка сброса настройки: %w", err)
+	}
+
+	if err := s.Load(ctx); err != nil {
+		return Entry{}, err
+	}
+	slog.InfoContext(ctx, "⚙️  Настройка сброшена к значению по умолчанию", "key", key)
+
+	return s.Get(key)
+}
@@ -0,0 +1,176 @@
+// Package shutdown останавливает подсистемы приложения по фазам, у каждой
+// подсистемы - свой срок (SHUTDOWN_*_TIMEOUT).
+//
+// Фазы выполняются по порядку, подсистемы одной фазы - параллельно:
+// сначала серверы перестают принимать запросы, затем останавливаются
+// воркеры, затем дописывается то, что они накопили (аудит, спаны).
+// Подсистема, не уложившаяся в срок, не задерживает следующие фазы:
+// она считается незавершенной, а остановка идет дальше. По итогам
+// Report пишет в лог, что завершилось чисто, а что - нет.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Subsystem - часть приложения, которая останавливается при завершении
+type Subsystem struct {
+	Name    string
+	Timeout time.Duration
+
+	// Stop останавливает подсистему и возвращается, когда она остановлена
+	// или истек ctx (срок подсистемы)
+	Stop func(ctx context.Context) error
+}
+
+// Result - итог остановки подсистемы
+type Result struct {
+	Phase    string
+	Name     string
+	Duration time.Duration
+	TimedOut bool  // Не остановилась за Timeout
+	Err      error // Ошибка остановки, в том числе по истечении срока
+}
+
+// Clean сообщает, остановилась ли подсистема вовремя и без ошибок
+func (r Result) Clean() bool {
+	return r.Err == nil && !r.TimedOut
+}
+
+// phase - подсистемы, которые останавливаются одновременно
+type phase struct {
+	name       string
+	subsystems []Subsystem
+}
+
+// Plan - порядок остановки подсистем
+type Plan struct {
+	phases []phase
+}
+
+// Phase добавляет фазу; фазы выполняются в порядке добавления
+// Подсистема с Stop == nil пропускается (например, выключенный gRPC)
+func (p *Plan) Phase(name string, subsystems ...Subsystem) {
+	enabled := make([]Subsystem, 0, len(subsystems))
+	for _, s := range subsystems {
+		if s.Stop != nil {
+			enabled = append(enabled, s)
+		}
+	}
+	p.phases = append(p.phases, phase{name: name, subsystems: enabled})
+}
+
+// Run останавливает подсистемы и возвращает итог по каждой в порядке плана
+func (p *Plan) Run(ctx context.Context) []Result {
+	var results []Result
+	for _, ph := range p.phases {
+		phaseResults := make([]Result, len(ph.subsystems))
+		var wg sync.WaitGroup
+		for i, s := range ph.subsystems {
+			wg.Add(1)
+			go func(i int, s Subsystem) {
+				defer wg.Done()
+				phaseResults[i] = stop(ctx, s)
+				phaseResults[i].Phase = ph.name
+			}(i, s)
+		}
+		wg.Wait()
+		results = append(results, phaseResults...)
+	}
+	return results
+}
+
+// stop останавливает одну подсистему в пределах ее срока
+// Если Stop не вернулся вовремя, он продолжает работать в фоне, а
+// остановка переходит к следующим подсистемам
+func stop(parent context.Context, s Subsystem) Result {
+	ctx, cancel := context.WithTimeout(parent, s.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Stop(ctx)
+	}()
+
+	result := Result{Name: s.Name}
+	select {
+	case result.Err = <-done:
+		result.TimedOut = errors.Is(result.Err, context.DeadlineExceeded)
+	case <-ctx.Done():
+		result.Err = ctx.Err()
+		result.TimedOut = true
+	}
+	result.Duration = time.Since(started)
+	return result
+}
+
+// Report пишет итог остановки в лог и возвращает true, если все
+// подсистемы остановились чисто
+func Report(results []Result) bool {
+	var failed []string
+	for _, r := range results {
+		attrs := []any{"phase", r.Phase, "subsystem", r.Name, "duration", r.Duration.Round(time.Millisecond)}
+		switch {
+		case r.TimedOut:
+			slog.Error("❌ Подсистема не остановилась вовремя", attrs...)
+		case r.Err != nil:
+			slog.Error("❌ Ошибка остановки подсистемы", append(attrs, "error", r.Err)...)
+		default:
+			slog.Info("✅ Подсистема остановлена", attrs...)
+		}
+		if !r.Clean() {
+			failed = append(failed, r.Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		slog.Warn("⚠️  Приложение завершено, не все подсистемы остановились чисто", "failed", failed)
+		return false
+	}
+	slog.Info("✅ Приложение успешно завершено")
+	return true
+}
+
+// Workers - фоновые горутины подсистемы, работающие до остановки
+type Workers struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWorkers создает группу фоновых горутин
+func NewWorkers() *Workers {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Workers{ctx: ctx, cancel: cancel}
+}
+
+// Go запускает run в отдельной горутине; ctx отменяется при остановке
+func (w *Workers) Go(run func(ctx context.Context)) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		run(w.ctx)
+	}()
+}
+
+// Stop отменяет контекст горутин и ждет их завершения, но не дольше ctx
+// Используется как Subsystem.Stop
+func (w *Workers) Stop(ctx context.Context) error {
+	w.cancel()
+	stopped := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
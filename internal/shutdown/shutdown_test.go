@@ -0,0 +1,87 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPlanRunsPhasesInOrderWithOwnDeadlines(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) {
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+	}
+
+	hung := make(chan struct{})
+	defer close(hung)
+
+	var plan Plan
+	plan.Phase("servers",
+		Subsystem{Name: "http", Timeout: time.Second, Stop: func(context.Context) error {
+			record("http")
+			return nil
+		}},
+		// Зависшая подсистема ждет только свой срок
+		Subsystem{Name: "grpc", Timeout: 20 * time.Millisecond, Stop: func(context.Context) error {
+			<-hung
+			return nil
+		}},
+		Subsystem{Name: "disabled"},
+	)
+	plan.Phase("workers",
+		Subsystem{Name: "jobs", Timeout: time.Second, Stop: func(context.Context) error {
+			record("jobs")
+			return errors.New("задание прервано")
+		}},
+	)
+
+	started := time.Now()
+	results := plan.Run(context.Background())
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Errorf("остановка заняла %s: зависшая подсистема задержала следующие фазы", elapsed)
+	}
+
+	if len(order) != 2 || order[0] != "http" || order[1] != "jobs" {
+		t.Errorf("порядок остановки %v, ожидался [http jobs]", order)
+	}
+	if len(results) != 3 {
+		t.Fatalf("итогов %d, ожидалось 3 (подсистема без Stop пропускается)", len(results))
+	}
+	if r := results[0]; !r.Clean() || r.Phase != "servers" {
+		t.Errorf("http: %+v, ожидалась чистая остановка", r)
+	}
+	if r := results[1]; !r.TimedOut || r.Name != "grpc" {
+		t.Errorf("grpc: %+v, ожидалось превышение срока", r)
+	}
+	if r := results[2]; r.Clean() || r.TimedOut || r.Phase != "workers" {
+		t.Errorf("jobs: %+v, ожидалась ошибка остановки", r)
+	}
+	if Report(results) {
+		t.Error("Report сообщает о чистой остановке")
+	}
+}
+
+func TestWorkersStopWaitsForGoroutines(t *testing.T) {
+	workers := NewWorkers()
+	stopped := make(chan struct{})
+	workers.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := workers.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("Stop вернулся до завершения горутины")
+	}
+}
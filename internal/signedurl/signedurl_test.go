@@ -0,0 +1,57 @@
+package signedurl
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// parse разбирает подписанную ссылку на путь, expires и signature
+func parse(t *testing.T, link string) (string, string, string) {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("url.Parse(%s): %v", link, err)
+	}
+	query := u.Query()
+	return u.Path, query.Get(ParamExpires), query.Get(ParamSignature)
+}
+
+func TestVerify(t *testing.T) {
+	signer := NewSigner("secret")
+	const path = "/api/v1/exports/42/download"
+	expiresAt := time.Now().Add(time.Hour)
+	_, expires, signature := parse(t, signer.Sign(path, expiresAt))
+	later := strconv.FormatInt(expiresAt.Add(24*time.Hour).Unix(), 10)
+
+	// Просроченная ссылка подписана честно, но время истекло
+	_, pastExpires, pastSignature := parse(t, signer.Sign(path, time.Now().Add(-time.Second)))
+
+	tests := []struct {
+		name      string
+		signer    *Signer
+		path      string
+		expires   string
+		signature string
+		valid     bool
+	}{
+		{"верная ссылка", signer, path, expires, signature, true},
+		{"подмененный путь", signer, "/api/v1/exports/43/download", expires, signature, false},
+		{"продленный срок", signer, path, later, signature, false},
+		{"истекшая ссылка", signer, path, pastExpires, pastSignature, false},
+		{"другой ключ", NewSigner("other"), path, expires, signature, false},
+		{"без подписи", signer, path, expires, "", false},
+		{"нечисловой срок", signer, path, "tomorrow", signature, false},
+	}
+	for _, tc := range tests {
+		err := tc.signer.Verify(tc.path, tc.expires, tc.signature)
+		if tc.valid && err != nil {
+			t.Errorf("%s: %v, ожидалась действительная ссылка", tc.name, err)
+		}
+		if !tc.valid && !errors.Is(err, ErrInvalidLink) {
+			t.Errorf("%s: %v, ожидалась ErrInvalidLink", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+// Package sqlfilter строит SQL для административных списков с
+// произвольными фильтрами и сортировкой.
+//
+// Обычные списки описаны запросами sqlc: каждый фильтр - отдельный
+// sqlc.narg, сортировка - CASE (см. ListUsers). Для панелей поддержки,
+// где фильтровать нужно по любому сочетанию полей и операторов, такой
+// запрос неподдерживаем, поэтому условия собираются построителем
+// squirrel.
+//
+// Безопасность держится на белом списке (Schema): клиент выбирает
+// только имя поля, оператор и значение. Имена колонок берутся из схемы,
+// а не из запроса, операторы - из фиксированного набора, значения
+// приводятся к типу поля и передаются параметрами ($1, $2, ...).
+// Строка клиента никогда не попадает в текст SQL.
+//
+// Формат параметров запроса:
+//
+//	filter[email][contains]=example.com
+//	filter[status][in]=active,suspended
+//	filter[last_login_at][null]=true
+//	filter[role]=admin                     (оператор eq)
+//	sort=-last_login_at,email
+package sqlfilter
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/Soundveyve/fiber-backend/internal/query"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+)
+
+// Лимиты запроса: сколько условий и значений in принимается
+const (
+	MaxConditions = 20  // Условий filter[...] в одном запросе
+	MaxInValues   = 100 // Значений в одном in
+	MaxSortFields = 3   // Полей в sort
+)
+
+// Op - оператор сравнения
+type Op string
+
+// Операторы фильтров
+const (
+	Eq       Op = "eq"
+	Ne       Op = "ne"
+	Lt       Op = "lt"
+	Lte      Op = "lte"
+	Gt       Op = "gt"
+	Gte      Op = "gte"
+	In       Op = "in"       // Любое из значений через запятую
+	Contains Op = "contains" // Подстрока без учета регистра
+	Prefix   Op = "prefix"   // Начало строки без учета регистра
+	Null     Op = "null"     // true - значения нет, false - есть
+)
+
+// Type - тип значения поля
+type Type int
+
+// Типы полей
+const (
+	Text Type = iota
+	Int
+	Time // Дата YYYY-MM-DD или время в формате utc.Parse
+	Bool
+)
+
+// Field - поле, по которому разрешено фильтровать или сортировать
+type Field struct {
+	Column   string   // Выражение SQL: задается только в коде, не клиентом
+	Type     Type     // Тип значения
+	Ops      []Op     // Разрешенные операторы, пусто - фильтр запрещен
+	Values   []string // Допустимые значения (перечисление), пусто - любые
+	Sortable bool     // Разрешена сортировка
+}
+
+// Schema - белый список полей одного списка
+type Schema struct {
+	Fields map[string]Field
+
+	// DefaultSort - сортировка, если sort не передан
+	DefaultSort []Sort
+
+	// TieBreak - колонка, замыкающая порядок (первичный ключ):
+	// без нее при равных значениях страницы пересекались бы
+	TieBreak string
+}
+
+// Condition - условие фильтра после проверки по схеме
+type Condition struct {
+	Field string
+	Op    Op
+	Value interface{} // Значение типа поля, для In - []interface{}
+}
+
+// Sort - поле сортировки
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Spec - разобранные фильтры и сортировка
+type Spec struct {
+	Conditions []Condition
+	Sort       []Sort
+}
+
+// Parse разбирает filter[...] и sort по схеме
+// Неизвестное поле, оператор или значение неверного типа - ошибка
+// query.ErrInvalidParam. Остальные параметры (page, page_size) пропускаются
+func Parse(schema Schema, values url.Values) (Spec, error) {
+	var spec Spec
+
+	// 1. Фильтры в порядке ключей: одинаковый запрос дает одинаковый SQL
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name, op, err := parseKey(key)
+		if err != nil {
+			return Spec{}, err
+		}
+		for _, raw := range values[key] {
+			cond, err := schema.condition(name, op, raw)
+			if err != nil {
+				return Spec{}, err
+			}
+			spec.Conditions = append(spec.Conditions, cond)
+		}
+	}
+	if len(spec.Conditions) > MaxConditions {
+		return Spec{}, fmt.Errorf("%w: не больше %d условий filter", query.ErrInvalidParam, MaxConditions)
+	}
+
+	// 2. Сортировка
+	sorts, err := schema.parseSort(values.Get("sort"))
+	if err != nil {
+		return Spec{}, err
+	}
+	spec.Sort = sorts
+	return spec, nil
+}
+
+// parseKey разбирает filter[field] и filter[field][op]
+func parseKey(key string) (string, Op, error) {
+	rest := strings.TrimPrefix(key, "filter[")
+	name, rest, ok := strings.Cut(rest, "]")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("%w: %q, ожидается filter[поле][оператор]", query.ErrInvalidParam, key)
+	}
+	if rest == "" {
+		return name, Eq, nil
+	}
+
+	op, ok := strings.CutPrefix(rest, "[")
+	if !ok || !strings.HasSuffix(op, "]") || len(op) < 2 {
+		return "", "", fmt.Errorf("%w: %q, ожидается filter[поле][оператор]", query.ErrInvalidParam, key)
+	}
+	return name, Op(strings.TrimSuffix(op, "]")), nil
+}
+
+// condition проверяет поле и оператор по схеме и приводит значение к типу поля
+func (s Schema) condition(name string, op Op, raw string) (Condition, error) {
+	field, ok := s.Fields[name]
+	if !ok || len(field.Ops) == 0 {
+		return Condition{}, fmt.Errorf("%w: фильтр по %q не поддерживается", query.ErrInvalidParam, name)
+	}
+	if !slices.Contains(field.Ops, op) {
+		return Condition{}, fmt.Errorf("%w: оператор %q для %q не поддерживается", query.ErrInvalidParam, op, name)
+	}
+
+	cond := Condition{Field: name, Op: op}
+	switch op {
+	case Null:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return Condition{}, fmt.Errorf("%w: filter[%s][null] ожидает true или false", query.ErrInvalidParam, name)
+		}
+		cond.Value = value
+	case In:
+		parts := strings.Split(raw, ",")
+		if len(parts) > MaxInValues {
+			return Condition{}, fmt.Errorf("%w: filter[%s][in] - не больше %d значений", query.ErrInvalidParam, name, MaxInValues)
+		}
+		list := make([]interface{}, len(parts))
+		for i, part := range parts {
+			value, err := field.parse(strings.TrimSpace(part))
+			if err != nil {
+				return Condition{}, fmt.Errorf("%w: filter[%s]: %v", query.ErrInvalidParam, name, err)
+			}
+			list[i] = value
+		}
+		cond.Value = list
+	case Contains, Prefix:
+		if field.Type != Text {
+			return Condition{}, fmt.Errorf("%w: оператор %q применим только к тексту", query.ErrInvalidParam, op)
+		}
+		cond.Value = raw
+	default:
+		value, err := field.parse(raw)
+		if err != nil {
+			return Condition{}, fmt.Errorf("%w: filter[%s]: %v", query.ErrInvalidParam, name, err)
+		}
+		cond.Value = value
+	}
+	return cond, nil
+}
+
+// parse приводит строку к типу поля
+func (f Field) parse(raw string) (interface{}, error) {
+	if len(f.Values) > 0 && !slices.Contains(f.Values, raw) {
+		return nil, fmt.Errorf("допустимые значения: %s", strings.Join(f.Values, ", "))
+	}
+
+	switch f.Type {
+	case Int:
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("ожидается целое число")
+		}
+		return value, nil
+	case Time:
+		value, err := utc.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("ожидается дата или время")
+		}
+		return value, nil
+	case Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("ожидается true или false")
+		}
+		return value, nil
+	default:
+		return raw, nil
+	}
+}
+
+// parseSort разбирает sort: поля через запятую, "-" - по убыванию
+func (s Schema) parseSort(raw string) ([]Sort, error) {
+	if raw == "" {
+		return s.DefaultSort, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	if len(parts) > MaxSortFields {
+		return nil, fmt.Errorf("%w: сортировка не больше чем по %d полям", query.ErrInvalidParam, MaxSortFields)
+	}
+	sorts := make([]Sort, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		name, desc := strings.CutPrefix(part, "-")
+		if field, ok := s.Fields[name]; !ok || !field.Sortable {
+			return nil, fmt.Errorf("%w: сортировка по %q не поддерживается", query.ErrInvalidParam, part)
+		}
+		sorts = append(sorts, Sort{Field: name, Desc: desc})
+	}
+	return sorts, nil
+}
+
+// Where добавляет условия spec к запросу
+// Поля spec уже проверены Parse, но схема сверяется повторно:
+// Spec, собранный в коде вручную, тоже не подставит чужую колонку
+func (s Schema) Where(b sq.SelectBuilder, spec Spec) (sq.SelectBuilder, error) {
+	for _, cond := range spec.Conditions {
+		field, ok := s.Fields[cond.Field]
+		if !ok || !slices.Contains(field.Ops, cond.Op) {
+			return b, fmt.Errorf("%w: фильтр %s %s не поддерживается", query.ErrInvalidParam, cond.Field, cond.Op)
+		}
+		pred, err := predicate(field.Column, cond)
+		if err != nil {
+			return b, err
+		}
+		b = b.Where(pred)
+	}
+	return b, nil
+}
+
+// OrderBy добавляет сортировку spec и замыкающую колонку TieBreak
+func (s Schema) OrderBy(b sq.SelectBuilder, spec Spec) (sq.SelectBuilder, error) {
+	for _, srt := range spec.Sort {
+		field, ok := s.Fields[srt.Field]
+		if !ok || !field.Sortable {
+			return b, fmt.Errorf("%w: сортировка по %q не поддерживается", query.ErrInvalidParam, srt.Field)
+		}
+		// NULL (например, ни разу не входил) - в начале по возрастанию
+		// и в конце по убыванию, как в ListUsers
+		if srt.Desc {
+			b = b.OrderBy(field.Column + " DESC NULLS LAST")
+		} else {
+			b = b.OrderBy(field.Column + " ASC NULLS FIRST")
+		}
+	}
+	if s.TieBreak != "" {
+		b = b.OrderBy(s.TieBreak + " DESC")
+	}
+	return b, nil
+}
+
+// predicate возвращает условие squirrel для колонки
+// Значение всегда уходит параметром запроса
+func predicate(column string, cond Condition) (sq.Sqlizer, error) {
+	switch cond.Op {
+	case Eq:
+		return sq.Eq{column: cond.Value}, nil
+	case Ne:
+		return sq.NotEq{column: cond.Value}, nil
+	case Lt:
+		return sq.Lt{column: cond.Value}, nil
+	case Lte:
+		return sq.LtOrEq{column: cond.Value}, nil
+	case Gt:
+		return sq.Gt{column: cond.Value}, nil
+	case Gte:
+		return sq.GtOrEq{column: cond.Value}, nil
+	case In:
+		// squirrel разворачивает срез в IN ($1, $2, ...)
+		return sq.Eq{column: cond.Value}, nil
+	case Contains:
+		return sq.ILike{column: "%" + escapeLike(fmt.Sprint(cond.Value)) + "%"}, nil
+	case Prefix:
+		return sq.ILike{column: escapeLike(fmt.Sprint(cond.Value)) + "%"}, nil
+	case Null:
+		if isNull, _ := cond.Value.(bool); isNull {
+			return sq.Eq{column: nil}, nil
+		}
+		return sq.NotEq{column: nil}, nil
+	default:
+		return nil, fmt.Errorf("%w: оператор %q не поддерживается", query.ErrInvalidParam, cond.Op)
+	}
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы "%" и "_" клиента
+// искались как обычные символы (экранирующий символ по умолчанию - "\")
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
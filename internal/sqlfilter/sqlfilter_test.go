@@ -0,0 +1,162 @@
+package sqlfilter
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/Soundveyve/fiber-backend/internal/query"
+)
+
+// testSchema - схема, похожая на поиск пользователей
+var testSchema = Schema{
+	Fields: map[string]Field{
+		"email":         {Column: "email", Type: Text, Ops: []Op{Eq, In, Contains, Prefix}, Sortable: true},
+		"status":        {Column: "status", Type: Text, Ops: []Op{Eq, In}, Values: []string{"active", "suspended"}},
+		"login_count":   {Column: "login_count", Type: Int, Ops: []Op{Gte, Lt}, Sortable: true},
+		"last_login_at": {Column: "last_login_at", Type: Time, Ops: []Op{Gte, Null}, Sortable: true},
+		"internal_note": {Column: "note"}, // Поле без операторов: фильтр запрещен
+	},
+	DefaultSort: []Sort{{Field: "login_count", Desc: true}},
+	TieBreak:    "id",
+}
+
+// mustParseQuery разбирает query string
+func mustParseQuery(t *testing.T, rawQuery string) url.Values {
+	t.Helper()
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		t.Fatalf("ParseQuery(%q): %v", rawQuery, err)
+	}
+	return values
+}
+
+// build разбирает параметры и возвращает SQL и его параметры
+func build(t *testing.T, values url.Values) (string, []interface{}, error) {
+	t.Helper()
+	spec, err := Parse(testSchema, values)
+	if err != nil {
+		return "", nil, err
+	}
+
+	b, err := testSchema.Where(sq.Select("*").From("users").PlaceholderFormat(sq.Dollar), spec)
+	if err != nil {
+		return "", nil, err
+	}
+	b, err = testSchema.OrderBy(b, spec)
+	if err != nil {
+		return "", nil, err
+	}
+	sql, args, err := b.ToSql()
+	if err != nil {
+		t.Fatalf("ToSql: %v", err)
+	}
+	return sql, args, nil
+}
+
+func TestBuild(t *testing.T) {
+	sql, args, err := build(t, mustParseQuery(t, "filter[email][contains]=example&filter[status][in]=active,suspended"+
+		"&filter[last_login_at][null]=false&filter[login_count][gte]=3&sort=-last_login_at,email"))
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	want := "SELECT * FROM users WHERE email ILIKE $1 AND last_login_at IS NOT NULL AND login_count >= $2" +
+		" AND status IN ($3,$4) ORDER BY last_login_at DESC NULLS LAST, email ASC NULLS FIRST, id DESC"
+	if sql != want {
+		t.Errorf("SQL:\n%s\nожидался:\n%s", sql, want)
+	}
+	wantArgs := []interface{}{"%example%", int64(3), "active", "suspended"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("параметры %#v, ожидались %#v", args, wantArgs)
+	}
+
+	// Без sort - сортировка по умолчанию
+	sql, _, err = build(t, url.Values{})
+	if err != nil || !strings.HasSuffix(sql, "ORDER BY login_count DESC NULLS LAST, id DESC") {
+		t.Errorf("сортировка по умолчанию: %q, %v", sql, err)
+	}
+}
+
+func TestInjectionRejected(t *testing.T) {
+	// Строка клиента в имени поля, операторе или сортировке - ошибка,
+	// а не фрагмент SQL
+	tests := [][2]string{
+		{"filter[email;DROP TABLE users--]", "x"},
+		{"filter[email) OR 1=1--]", "x"},
+		{"filter[email][eq) OR (1=1]", "x"},
+		{"filter[email][contains][extra]", "x"},
+		{"filter[]", "x"},
+		{"filter[internal_note]", "x"},
+		{"filter[status]", "deleted"},
+		{"filter[status][in]", "active,deleted' OR '1'='1"},
+		{"filter[login_count][gte]", "1 OR 1=1"},
+		{"filter[last_login_at][gte]", "2024-01-01'; DROP TABLE users--"},
+		{"filter[last_login_at][null]", "1=1"},
+		{"filter[login_count][contains]", "1"},
+		{"sort", "email;DROP TABLE users"},
+		{"sort", "email DESC, (SELECT 1)"},
+		{"sort", "-internal_note"},
+		{"sort", "status"},
+	}
+	for _, tc := range tests {
+		sql, _, err := build(t, url.Values{tc[0]: {tc[1]}})
+		if !errors.Is(err, query.ErrInvalidParam) {
+			t.Errorf("%s=%s: ошибка %v, SQL %q; ожидалась query.ErrInvalidParam", tc[0], tc[1], err, sql)
+		}
+	}
+}
+
+func TestValuesAreParameters(t *testing.T) {
+	// Значения с SQL и спецсимволами LIKE уходят параметрами как есть
+	payload := "x' OR '1'='1"
+	sql, args, err := build(t, url.Values{
+		"filter[email]":         {payload},
+		"filter[email][prefix]": {`50%_off\`},
+	})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if strings.Contains(sql, "'") || strings.Contains(sql, "%") {
+		t.Errorf("значение попало в SQL: %s", sql)
+	}
+	wantArgs := []interface{}{payload, `50\%\_off\\%`}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("параметры %#v, ожидались %#v", args, wantArgs)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	values := url.Values{}
+	for i := 0; i <= MaxConditions; i++ {
+		values.Add("filter[email]", "a")
+	}
+	if _, err := Parse(testSchema, values); !errors.Is(err, query.ErrInvalidParam) {
+		t.Errorf("больше MaxConditions условий: %v", err)
+	}
+
+	in := strings.TrimSuffix(strings.Repeat("active,", MaxInValues+1), ",")
+	if _, err := Parse(testSchema, url.Values{"filter[status][in]": {in}}); !errors.Is(err, query.ErrInvalidParam) {
+		t.Errorf("больше MaxInValues значений: %v", err)
+	}
+
+	if _, err := Parse(testSchema, url.Values{"sort": {"email,login_count,last_login_at,email"}}); !errors.Is(err, query.ErrInvalidParam) {
+		t.Errorf("больше MaxSortFields полей: %v", err)
+	}
+}
+
+func TestManualSpecChecked(t *testing.T) {
+	// Spec, собранный в коде, тоже сверяется со схемой
+	spec := Spec{Conditions: []Condition{{Field: "email; DROP TABLE users", Op: Eq, Value: "x"}}}
+	if _, err := testSchema.Where(sq.Select("*").From("users"), spec); !errors.Is(err, query.ErrInvalidParam) {
+		t.Errorf("Where с чужим полем: %v", err)
+	}
+	spec = Spec{Sort: []Sort{{Field: "status"}}}
+	if _, err := testSchema.OrderBy(sq.Select("*").From("users"), spec); !errors.Is(err, query.ErrInvalidParam) {
+		t.Errorf("OrderBy по полю без Sortable: %v", err)
+	}
+}
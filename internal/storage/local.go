@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+)
+
+// LocalStore хранит объекты в директории на локальном диске
+// Подходит для разработки и одноинстансных установок
+type LocalStore struct {
+	root string // Корневая директория хранилища
+}
+
+// NewLocalStore создает хранилище в директории root, создавая ее при необходимости
+func NewLocalStore(root string) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("ошибка создания директории хранилища: %w", err)
+	}
+	return &LocalStore{root: root}, nil
+}
+
+// Put сохраняет объект на диск
+// Запись идет во временный файл с последующим переименованием,
+// чтобы читатели никогда не видели частично записанный файл
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ошибка создания директории: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("ошибка создания временного файла: %w", err)
+	}
+	// После успешного Rename удалять уже нечего, ошибку игнорируем
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("ошибка записи файла: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("ошибка записи файла: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("ошибка сохранения файла: %w", err)
+	}
+	return nil
+}
+
+// Get открывает объект на чтение
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	path, _ := s.path(key)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, ObjectInfo{}, mapFSError(err)
+	}
+	return f, info, nil
+}
+
+// Stat возвращает информацию об объекте
+// MIME тип определяется по расширению - локальный диск не хранит метаданные
+func (s *LocalStore) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return ObjectInfo{}, mapFSError(err)
+	}
+	if fi.IsDir() {
+		return ObjectInfo{}, ErrNotFound
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return ObjectInfo{
+		Key:         key,
+		Size:        fi.Size(),
+		ContentType: contentType,
+		ModifiedAt:  fi.ModTime(),
+	}, nil
+}
+
+// Delete удаляет объект с диска
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("ошибка удаления файла: %w", err)
+	}
+	return nil
+}
+
+// path возвращает путь к файлу объекта на диске
+func (s *LocalStore) path(key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+// mapFSError превращает "файл не найден" в ErrNotFound
+func mapFSError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return fmt.Errorf("ошибка чтения файла: %w", err)
+}
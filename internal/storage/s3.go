@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+)
+
+// S3Store хранит объекты в S3-совместимом хранилище (AWS S3, MinIO, Yandex Object Storage)
+type S3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store создает клиент S3 и проверяет существование бакета
+func NewS3Store(cfg config.StorageConfig) (*S3Store, error) {
+	client, err := minio.New(cfg.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		Secure: cfg.S3UseSSL,
+		Region: cfg.S3Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания S3 клиента: %w", err)
+	}
+
+	exists, err := client.BucketExists(context.Background(), cfg.S3Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения к S3: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("S3 бакет %s не существует", cfg.S3Bucket)
+	}
+
+	return &S3Store{
+		client: client,
+		bucket: cfg.S3Bucket,
+	}, nil
+}
+
+// Put загружает объект в бакет
+// При size = -1 клиент использует multipart загрузку
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки объекта в S3: %w", err)
+	}
+	return nil
+}
+
+// Get открывает объект на чтение
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	// Stat перед GetObject: minio возвращает ошибку отсутствия объекта
+	// только при первом чтении, а нам нужен ErrNotFound сразу
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	obj, err := s.client.GetObject(ctx, s.bucket, info.Key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectInfo{}, mapS3Error(err)
+	}
+	return obj, info, nil
+}
+
+// Stat возвращает метаданные объекта
+func (s *S3Store) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	stat, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, mapS3Error(err)
+	}
+
+	return ObjectInfo{
+		Key:         key,
+		Size:        stat.Size,
+		ContentType: stat.ContentType,
+		ModifiedAt:  stat.LastModified,
+	}, nil
+}
+
+// Delete удаляет объект из бакета
+// S3 не возвращает ошибку при удалении несуществующего объекта
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("ошибка удаления объекта из S3: %w", err)
+	}
+	return nil
+}
+
+// mapS3Error превращает ответ NoSuchKey в ErrNotFound
+func mapS3Error(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return fmt.Errorf("ошибка S3: %w", err)
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+)
+
+// ErrNotFound возвращается, когда объекта с таким ключом нет в хранилище
+var ErrNotFound = errors.New("объект не найден")
+
+// ErrInvalidKey возвращается для ключей, выходящих за пределы хранилища
+var ErrInvalidKey = errors.New("невалидный ключ объекта")
+
+// ObjectInfo описывает сохраненный объект
+type ObjectInfo struct {
+	Key         string    // Ключ объекта (путь внутри хранилища)
+	Size        int64     // Размер в байтах
+	ContentType string    // MIME тип содержимого
+	ModifiedAt  time.Time // Время последнего изменения
+}
+
+// BlobStore - абстракция хранилища файлов (вложения, аватары, экспорты)
+// Модули работают только с этим интерфейсом, а конкретный бэкенд
+// (локальный диск или S3) выбирается конфигурацией через New
+type BlobStore interface {
+	// Put сохраняет объект, перезаписывая существующий с тем же ключом
+	// size может быть -1, если размер заранее неизвестен
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get открывает объект на чтение; вызывающий обязан закрыть reader
+	Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+
+	// Stat возвращает информацию об объекте без чтения содержимого
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+
+	// Delete удаляет объект; удаление несуществующего объекта не ошибка
+	Delete(ctx context.Context, key string) error
+}
+
+// New создает хранилище по конфигурации
+// Поддерживаемые драйверы: local (локальный диск) и s3 (S3-совместимое хранилище)
+func New(cfg config.StorageConfig) (BlobStore, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocalStore(cfg.LocalDir)
+	case "s3":
+		return NewS3Store(cfg)
+	default:
+		return nil, fmt.Errorf("неподдерживаемый драйвер хранилища: %s", cfg.Driver)
+	}
+}
+
+// NewKey генерирует уникальный ключ объекта вида prefix/<random>.ext
+// Случайное имя исключает коллизии и подбор ключей чужих файлов
+func NewKey(prefix, ext string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return path.Join(prefix, hex.EncodeToString(b)+ext)
+}
+
+// cleanKey нормализует ключ и запрещает выход за пределы хранилища (../)
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != strings.TrimPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCleanKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string // Пусто - ErrInvalidKey
+	}{
+		{"avatars/ab12.png", "avatars/ab12.png"},
+		{"exports/2024/01/users.csv", "exports/2024/01/users.csv"},
+		// Абсолютный ключ остается внутри хранилища
+		{"/avatars/ab12.png", "avatars/ab12.png"},
+		{"/etc/passwd", "etc/passwd"},
+		{"../secret", ""},
+		{"../../etc/passwd", ""},
+		{"avatars/../../secret", ""},
+		{"/../secret", ""},
+		{"avatars/./ab12.png", ""},
+		{"avatars//ab12.png", ""},
+		{"avatars/", ""},
+		{".", ""},
+		{"..", ""},
+		{"", ""},
+		{"/", ""},
+	}
+	for _, tc := range tests {
+		got, err := cleanKey(tc.key)
+		if tc.want == "" {
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("cleanKey(%q) = %q, %v; ожидался ErrInvalidKey", tc.key, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("cleanKey(%q) = %q, %v; ожидался %q", tc.key, got, err, tc.want)
+		}
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	root := filepath.Join(t.TempDir(), "store")
+	store, err := NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Запись, чтение, удаление
+	key := NewKey("avatars", "png")
+	if err := store.Put(ctx, key, strings.NewReader("image"), 5, "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	r, info, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "image" || info.Size != 5 || info.ContentType != "image/png" || info.Key != key {
+		t.Errorf("Get = %q, %+v", data, info)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Stat(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat после Delete: %v, ожидался ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("повторный Delete: %v", err)
+	}
+
+	// Директория - не объект
+	if _, err := store.Stat(ctx, "avatars"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat директории: %v, ожидался ErrNotFound", err)
+	}
+
+	// Ключи с выходом из хранилища отклоняются всеми методами
+	outside := filepath.Join(filepath.Dir(root), "outside.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"../outside.txt", "avatars/../../outside.txt"} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q): %v, ожидался ErrInvalidKey", key, err)
+		}
+		if _, _, err := store.Get(ctx, key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Get(%q): %v, ожидался ErrInvalidKey", key, err)
+		}
+		if err := store.Delete(ctx, key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Delete(%q): %v, ожидался ErrInvalidKey", key, err)
+		}
+	}
+	if data, err := os.ReadFile(outside); err != nil || string(data) != "secret" {
+		t.Errorf("файл вне хранилища: %q, %v", data, err)
+	}
+
+	// Абсолютный ключ пишется внутрь корня
+	if err := store.Put(ctx, "/etc/passwd", strings.NewReader("x"), 1, "text/plain"); err != nil {
+		t.Fatalf("Put абсолютного ключа: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "etc", "passwd")); err != nil {
+		t.Errorf("абсолютный ключ не внутри хранилища: %v", err)
+	}
+}
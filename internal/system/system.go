@@ -0,0 +1,197 @@
+// Package system собирает сводку о состоянии процесса для админки
+// (GET /admin/v1/system).
+//
+// Новых источников данных пакет не заводит: он читает то, что уже
+// ведут подсистемы, - runtime, статистику пула pgxpool, длину очередей в
+// памяти и метрики Prometheus (ошибки HTTP, кеши, отброшенные записи
+// аудита). Счетчики накоплены с момента запуска процесса, скорость
+// за период по-прежнему считается в Prometheus.
+package system
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/metrics"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// topRoutesLimit - сколько роутов с ошибками 5xx попадает в сводку
+const topRoutesLimit = 10
+
+// Queue - очередь в памяти, глубину которой показывает сводка
+// Реализуется, например, audit.Logger
+type Queue interface {
+	Len() int
+	Cap() int
+}
+
+// Inspector собирает сводку о состоянии процесса
+type Inspector struct {
+	startedAt time.Time
+	db        *pgxpool.Pool // nil - пул в сводку не попадает
+	gatherer  prometheus.Gatherer
+	queues    map[string]Queue
+}
+
+// NewInspector создает сборщик сводки
+// startedAt - время запуска процесса, gatherer - реестр метрик приложения
+// (prometheus.DefaultGatherer), queues - очереди по именам для ответа
+func NewInspector(startedAt time.Time, db *pgxpool.Pool, gatherer prometheus.Gatherer, queues map[string]Queue) *Inspector {
+	return &Inspector{
+		startedAt: startedAt,
+		db:        db,
+		gatherer:  gatherer,
+		queues:    queues,
+	}
+}
+
+// Snapshot возвращает текущее состояние процесса
+// ReadMemStats ненадолго останавливает мир, поэтому эндпоинт не для
+// частого опроса: для графиков есть /metrics
+func (i *Inspector) Snapshot() (*models.SystemResponse, error) {
+	resp := &models.SystemResponse{
+		StartedAt:     utc.From(i.startedAt),
+		UptimeSeconds: int64(time.Since(i.startedAt).Seconds()),
+		Goroutines:    runtime.NumGoroutine(),
+		Memory:        memory(),
+		Queues:        make(map[string]models.SystemQueue, len(i.queues)),
+	}
+
+	if i.db != nil {
+		stats := i.db.Stat()
+		resp.DBPool = &models.SystemDBPool{
+			MaxOpen:   int(stats.MaxConns()),
+			Open:      int(stats.TotalConns()),
+			InUse:     int(stats.AcquiredConns()),
+			Idle:      int(stats.IdleConns()),
+			WaitCount: stats.EmptyAcquireCount(),
+			WaitMs:    float64(stats.AcquireDuration().Microseconds()) / 1000,
+		}
+	}
+
+	for name, queue := range i.queues {
+		resp.Queues[name] = models.SystemQueue{Depth: queue.Len(), Capacity: queue.Cap()}
+	}
+
+	var err error
+	if resp.Caches, err = i.caches(); err != nil {
+		return nil, err
+	}
+	if resp.Errors, err = i.errors(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// memory читает память процесса из runtime
+func memory() models.SystemMemory {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	mem := models.SystemMemory{
+		HeapAllocBytes: stats.HeapAlloc,
+		HeapInuseBytes: stats.HeapInuse,
+		SysBytes:       stats.Sys,
+		NumGC:          stats.NumGC,
+		GCPauseTotalMs: float64(stats.PauseTotalNs) / float64(time.Millisecond),
+	}
+	if stats.LastGC > 0 {
+		mem.LastGCAt = utc.Ptr(time.Unix(0, int64(stats.LastGC)))
+	}
+	return mem
+}
+
+// caches считает попадания в кеши по метрике cache_lookups_total
+func (i *Inspector) caches() (map[string]models.SystemCache, error) {
+	samples, err := metrics.Read(i.gatherer, "cache_lookups_total")
+	if err != nil {
+		return nil, err
+	}
+
+	caches := make(map[string]models.SystemCache)
+	for _, sample := range samples {
+		cache := caches[sample.Labels["cache"]]
+		switch sample.Labels["result"] {
+		case "hit":
+			cache.Hits += int64(sample.Value)
+		case "miss":
+			cache.Misses += int64(sample.Value)
+		}
+		caches[sample.Labels["cache"]] = cache
+	}
+	for name, cache := range caches {
+		if total := cache.Hits + cache.Misses; total > 0 {
+			cache.HitRate = float64(cache.Hits) / float64(total)
+		}
+		caches[name] = cache
+	}
+	return caches, nil
+}
+
+// errors собирает счетчики ошибок из метрик HTTP, лимитов и аудита
+func (i *Inspector) errors() (models.SystemErrors, error) {
+	result := models.SystemErrors{TopServerErrorRoutes: []models.SystemRouteErrors{}}
+
+	requests, err := metrics.Read(i.gatherer, "http_requests_total")
+	if err != nil {
+		return result, err
+	}
+	for _, sample := range requests {
+		status, err := strconv.Atoi(sample.Labels["status"])
+		if err != nil {
+			return result, fmt.Errorf("неожиданный статус в http_requests_total: %q", sample.Labels["status"])
+		}
+		count := int64(sample.Value)
+		switch {
+		case status >= 500:
+			result.ServerErrors += count
+			result.TopServerErrorRoutes = addRoute(result.TopServerErrorRoutes, sample.Labels["method"], sample.Labels["route"], count)
+		case status >= 400:
+			result.ClientErrors += count
+		}
+	}
+	sort.Slice(result.TopServerErrorRoutes, func(a, b int) bool {
+		return result.TopServerErrorRoutes[a].Count > result.TopServerErrorRoutes[b].Count
+	})
+	if len(result.TopServerErrorRoutes) > topRoutesLimit {
+		result.TopServerErrorRoutes = result.TopServerErrorRoutes[:topRoutesLimit]
+	}
+
+	totals := []struct {
+		name string
+		dst  *int64
+	}{
+		{"http_requests_aborted_total", &result.RequestsAborted},
+		{"rate_limit_rejected_total", &result.RateLimitRejected},
+		{"audit_dropped_total", &result.AuditDropped},
+	}
+	for _, t := range totals {
+		samples, err := metrics.Read(i.gatherer, t.name)
+		if err != nil {
+			return result, err
+		}
+		for _, sample := range samples {
+			*t.dst += int64(sample.Value)
+		}
+	}
+	return result, nil
+}
+
+// addRoute прибавляет ответы 5xx к роуту: у роута несколько рядов,
+// по одному на каждый статус (500, 503, ...)
+func addRoute(routes []models.SystemRouteErrors, method, route string, count int64) []models.SystemRouteErrors {
+	for i := range routes {
+		if routes[i].Method == method && routes[i].Route == route {
+			routes[i].Count += count
+			return routes
+		}
+	}
+	return append(routes, models.SystemRouteErrors{Method: method, Route: route, Count: count})
+}
@@ -0,0 +1,65 @@
+package system
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// fakeQueue - очередь с заданной глубиной
+type fakeQueue struct{ depth, capacity int }
+
+func (q fakeQueue) Len() int { return q.depth }
+func (q fakeQueue) Cap() int { return q.capacity }
+
+func TestSnapshot(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fiber_backend_http_requests_total",
+	}, []string{"method", "route", "status"})
+	lookups := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fiber_backend_cache_lookups_total",
+	}, []string{"cache", "result"})
+	registry.MustRegister(requests, lookups)
+
+	requests.WithLabelValues("GET", "/api/v1/users", "200").Add(10)
+	requests.WithLabelValues("GET", "/api/v1/users", "500").Add(2)
+	requests.WithLabelValues("GET", "/api/v1/users", "503").Add(1)
+	requests.WithLabelValues("POST", "/api/v1/auth/login", "500").Add(1)
+	requests.WithLabelValues("POST", "/api/v1/auth/login", "401").Add(4)
+	lookups.WithLabelValues("health_lb", "hit").Add(3)
+	lookups.WithLabelValues("health_lb", "miss").Add(1)
+
+	inspector := NewInspector(time.Now().Add(-time.Minute), nil, registry, map[string]Queue{
+		"audit": fakeQueue{depth: 5, capacity: 100},
+	})
+	snapshot, err := inspector.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	if snapshot.UptimeSeconds < 60 {
+		t.Errorf("uptime_seconds = %d, ожидалось не меньше 60", snapshot.UptimeSeconds)
+	}
+	if snapshot.DBPool != nil {
+		t.Error("db_pool без пула должен отсутствовать")
+	}
+	if q := snapshot.Queues["audit"]; q.Depth != 5 || q.Capacity != 100 {
+		t.Errorf("queues.audit = %+v", q)
+	}
+	if c := snapshot.Caches["health_lb"]; c.Hits != 3 || c.Misses != 1 || c.HitRate != 0.75 {
+		t.Errorf("caches.health_lb = %+v", c)
+	}
+
+	errs := snapshot.Errors
+	if errs.ServerErrors != 4 || errs.ClientErrors != 4 {
+		t.Errorf("server_errors = %d, client_errors = %d, ожидалось 4 и 4", errs.ServerErrors, errs.ClientErrors)
+	}
+	if len(errs.TopServerErrorRoutes) != 2 {
+		t.Fatalf("top_server_error_routes = %+v", errs.TopServerErrorRoutes)
+	}
+	if top := errs.TopServerErrorRoutes[0]; top.Route != "/api/v1/users" || top.Count != 3 {
+		t.Errorf("первый роут = %+v, ожидался /api/v1/users с 3 ошибками", top)
+	}
+}
@@ -0,0 +1,117 @@
+// Package tiers - уровни лимитов вызывающего: anonymous, free, pro, internal.
+//
+// Уровень определяется один раз на запрос (middleware.ResolveTier) по
+// пользователю из access токена: без токена - anonymous, с токеном - уровень
+// роли из Policy.Roles, для остальных ролей - free. Лимит запросов и
+// ограничения списков берут значения своего уровня из Policy.Limits, поэтому
+// пороги уровня задаются в одном месте, а не отдельно в каждом лимитере.
+//
+// Тарифных планов у пользователей пока нет: pro назначается ролям через
+// конфигурацию (APP_LIMIT_TIER_ROLES).
+package tiers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Tier - уровень лимитов вызывающего
+type Tier string
+
+// Уровни лимитов
+const (
+	Anonymous Tier = "anonymous" // Запрос без access токена
+	Free      Tier = "free"      // Пользователь без особого уровня
+	Pro       Tier = "pro"       // Расширенные лимиты
+	Internal  Tier = "internal"  // Администраторы и внутренние инструменты
+)
+
+// All - уровни в порядке возрастания лимитов
+var All = []Tier{Anonymous, Free, Pro, Internal}
+
+// Parse проверяет имя уровня
+func Parse(name string) (Tier, error) {
+	for _, t := range All {
+		if string(t) == name {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("неизвестный уровень лимитов %q", name)
+}
+
+// Limits - пороги одного уровня
+type Limits struct {
+	// Лимит запросов к /api/v1 в окне APP_RATE_LIMIT_WINDOW: сверх
+	// RateLimitSoft - предупреждение, сверх RateLimit - 429, 0 - без лимита.
+	// RateLimitBurst - запросов в секунду, 0 - без ограничения
+	RateLimit      int
+	RateLimitSoft  int
+	RateLimitBurst int
+
+	// Ограничения списков (см. query.Quota): MaxPageSize = 0 - без
+	// ограничения уровня, ListFilters разрешает фильтры и сортировку
+	MaxPageSize int
+	ListFilters bool
+}
+
+// Policy - пороги уровней и уровни ролей
+type Policy struct {
+	Limits map[Tier]Limits
+	Roles  map[string]Tier // Роль - уровень; роли без записи получают Free
+}
+
+// Resolve возвращает уровень вызывающего с ролью role
+// authenticated = false - запрос без access токена
+func (p Policy) Resolve(role string, authenticated bool) Tier {
+	if !authenticated {
+		return Anonymous
+	}
+	if t, ok := p.Roles[role]; ok {
+		return t
+	}
+	return Free
+}
+
+// ParseRoles разбирает уровни ролей в формате "admin=internal,partner=pro"
+// Аноним не роль, поэтому anonymous назначить нельзя
+func ParseRoles(value string) (map[string]Tier, error) {
+	roles := make(map[string]Tier)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		role, name, ok := strings.Cut(pair, "=")
+		role, name = strings.TrimSpace(role), strings.TrimSpace(name)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("ожидается роль=уровень: %q", pair)
+		}
+		t, err := Parse(name)
+		if err != nil {
+			return nil, err
+		}
+		if t == Anonymous {
+			return nil, fmt.Errorf("уровень anonymous нельзя назначить роли %s", role)
+		}
+		roles[role] = t
+	}
+	return roles, nil
+}
+
+// tierKey - ключ уровня запроса в Locals
+type tierKey struct{}
+
+// Set сохраняет уровень вызывающего
+// Вызывается middleware.ResolveTier
+func Set(c *fiber.Ctx, t Tier) {
+	c.Locals(tierKey{}, t)
+}
+
+// Of возвращает уровень вызывающего
+// Пустой уровень - ResolveTier не подключен, лимиты уровней не действуют
+func Of(c *fiber.Ctx) Tier {
+	t, _ := c.Locals(tierKey{}).(Tier)
+	return t
+}
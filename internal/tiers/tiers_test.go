@@ -0,0 +1,40 @@
+package tiers
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	policy := Policy{Roles: map[string]Tier{"admin": Internal, "partner": Pro}}
+
+	tests := []struct {
+		role          string
+		authenticated bool
+		want          Tier
+	}{
+		{"", false, Anonymous},
+		{"admin", false, Anonymous}, // Роль без токена не учитывается
+		{"user", true, Free},
+		{"partner", true, Pro},
+		{"admin", true, Internal},
+	}
+	for _, tt := range tests {
+		if got := policy.Resolve(tt.role, tt.authenticated); got != tt.want {
+			t.Errorf("Resolve(%q, %v) = %s, ожидалось %s", tt.role, tt.authenticated, got, tt.want)
+		}
+	}
+}
+
+func TestParseRoles(t *testing.T) {
+	roles, err := ParseRoles(" admin = internal, partner=pro ,")
+	if err != nil {
+		t.Fatalf("ParseRoles: %v", err)
+	}
+	if len(roles) != 2 || roles["admin"] != Internal || roles["partner"] != Pro {
+		t.Errorf("ParseRoles() = %v", roles)
+	}
+
+	for _, value := range []string{"admin", "=pro", "admin=gold", "guest=anonymous"} {
+		if _, err := ParseRoles(value); err == nil {
+			t.Errorf("ParseRoles(%q): ожидалась ошибка", value)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package totp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrDecrypt возвращается, если секрет зашифрован другим ключом или поврежден
+var ErrDecrypt = errors.New("не удалось расшифровать секрет TOTP")
+
+// Cipher шифрует секреты TOTP для хранения в БД (AES-256-GCM)
+// Утечка таблицы без ключа из конфигурации не дает сгенерировать коды
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher создает Cipher по ключу в hex (32 байта)
+func NewCipher(hexKey string) (*Cipher, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("ключ шифрования TOTP должен быть 32 байтами в hex")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания шифра: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания шифра: %w", err)
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt шифрует секрет, результат - nonce и шифротекст
+func (c *Cipher) Encrypt(secret string) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("ошибка генерации nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, []byte(secret), nil), nil
+}
+
+// Decrypt расшифровывает результат Encrypt
+func (c *Cipher) Decrypt(data []byte) (string, error) {
+	size := c.aead.NonceSize()
+	if len(data) < size {
+		return "", ErrDecrypt
+	}
+	plain, err := c.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,91 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret - ключ из тестовых векторов RFC 6238 (приложение B, SHA1)
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestValidateRFCVectors(t *testing.T) {
+	// В RFC коды 8-значные, 6-значный код - их последние 6 цифр
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tc := range cases {
+		step, ok := Validate(rfcSecret, tc.code, time.Unix(tc.unix, 0))
+		if !ok {
+			t.Errorf("код %s на %d не принят", tc.code, tc.unix)
+			continue
+		}
+		if want := tc.unix / 30; step != want {
+			t.Errorf("шаг = %d, ожидался %d", step, want)
+		}
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	// Соседний шаг принимается, дальше - нет
+	if _, ok := Validate(rfcSecret, "081804", now.Add(Period)); !ok {
+		t.Error("код предыдущего шага должен приниматься")
+	}
+	if _, ok := Validate(rfcSecret, "081804", now.Add(3*Period)); ok {
+		t.Error("устаревший код принят")
+	}
+	if _, ok := Validate(rfcSecret, "000000", now); ok {
+		t.Error("неверный код принят")
+	}
+	if _, ok := Validate(rfcSecret, "81804", now); ok {
+		t.Error("короткий код принят")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("fiber-backend", "ivan", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/fiber-backend:ivan?") {
+		t.Fatalf("uri = %s", uri)
+	}
+	for _, part := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=fiber-backend", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("в %s нет %s", uri, part)
+		}
+	}
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	c, err := NewCipher(strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.Encrypt("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "JBSWY3DPEHPK3PXP") {
+		t.Fatal("секрет хранится открытым")
+	}
+	secret, err := c.Decrypt(data)
+	if err != nil || secret != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("Decrypt = %q, %v", secret, err)
+	}
+
+	other, _ := NewCipher(strings.Repeat("cd", 32))
+	if _, err := other.Decrypt(data); err != ErrDecrypt {
+		t.Fatalf("чужой ключ: err = %v", err)
+	}
+	if _, err := NewCipher("short"); err == nil {
+		t.Fatal("короткий ключ принят")
+	}
+}
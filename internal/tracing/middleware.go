@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Soundveyve/fiber-backend/internal/reqctx"
+)
+
+// Middleware создает серверный спан на каждый HTTP запрос
+//
+// Подключается после RequestID: спан получает request.id, а запись лога
+// запроса (RequestLogger) - trace_id. Контекст со спаном кладется в
+// c.UserContext(), поэтому спаны сервисов и SQL становятся дочерними.
+// Ошибка handler'а переводится в ответ через ErrorHandler приложения,
+// чтобы в спан попал итоговый статус; 5xx помечают спан ошибкой
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{&c.Request().Header})
+
+		// Строки fasthttp живут до конца запроса, а спан отправляется позже
+		ctx, span := tracer.Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(utils.CopyString(c.Path())),
+				semconv.ClientAddress(c.IP()),
+				semconv.UserAgentOriginal(utils.CopyString(c.Get(fiber.HeaderUserAgent))),
+				attribute.String("request.id", utils.CopyString(reqctx.RequestID(c))),
+			),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		if err := c.Next(); err != nil {
+			span.RecordError(err)
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		// Шаблон роута известен только после роутинга
+		route := c.Route().Path
+		status := c.Response().StatusCode()
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(
+			semconv.HTTPRoute(route),
+			semconv.HTTPResponseStatusCode(status),
+		)
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return nil
+	}
+}
+
+// headerCarrier - заголовки запроса fasthttp для propagation.TextMapCarrier
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+// Get возвращает значение заголовка
+func (h headerCarrier) Get(key string) string {
+	return string(h.header.Peek(key))
+}
+
+// Set задает значение заголовка
+func (h headerCarrier) Set(key, value string) {
+	h.header.Set(key, value)
+}
+
+// Keys возвращает имена всех заголовков
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, h.header.Len())
+	h.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	app := fiber.New()
+	app.Use(Middleware())
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		_, span := Start(c.UserContext(), "UserService.GetUserByID")
+		defer span.End()
+		return fiber.ErrServiceUnavailable
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/users/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("завершено %d спанов, ожидалось 2", len(spans))
+	}
+	child, server := spans[0], spans[1]
+
+	if server.Name() != "GET /users/:id" {
+		t.Errorf("имя спана %q", server.Name())
+	}
+	if got := server.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace_id = %s, ожидалась трасса из traceparent", got)
+	}
+	if server.Status().Code != codes.Error {
+		t.Errorf("статус спана %v, 503 должен помечать спан ошибкой", server.Status().Code)
+	}
+	var status int64
+	for _, attr := range server.Attributes() {
+		if attr.Key == semconv.HTTPResponseStatusCodeKey {
+			status = attr.Value.AsInt64()
+		}
+	}
+	if status != fiber.StatusServiceUnavailable {
+		t.Errorf("http.response.status_code = %d", status)
+	}
+	if child.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("спан сервиса должен быть дочерним для спана запроса")
+	}
+}
@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sqlSpanKey - ключ контекста для спана SQL запроса
+type sqlSpanKey struct{}
+
+// SQLTracer создает спаны SQL запросов пула pgx (pgx.QueryTracer)
+//
+// Спаны создаются только внутри трассы: фоновые воркеры опрашивают
+// БД каждые несколько секунд и без родительского спана засыпали бы
+// коллектор одиночными трассами. Спан запроса создается провайдером
+// родительского спана
+type SQLTracer struct{}
+
+// TraceQueryStart открывает спан запроса
+func (SQLTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.SpanContext().IsValid() {
+		return ctx
+	}
+
+	operation := sqlOperation(data.SQL)
+	ctx, span := parent.TracerProvider().Tracer(instrumentationName).Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(data.SQL),
+		),
+	)
+	return context.WithValue(ctx, sqlSpanKey{}, span)
+}
+
+// TraceQueryEnd завершает спан запроса, открытый TraceQueryStart
+func (SQLTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span, ok := ctx.Value(sqlSpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.End()
+}
+
+// sqlOperation возвращает первое слово запроса (SELECT, INSERT, ...)
+// для имени спана
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "SQL"
+	}
+	return strings.ToUpper(fields[0])
+}
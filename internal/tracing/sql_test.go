@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSQLTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	var tracer SQLTracer
+	query := pgx.TraceQueryStartData{SQL: "select * from users where id = $1"}
+
+	// Вне трассы спан не создается
+	ctx := tracer.TraceQueryStart(context.Background(), nil, query)
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	if n := len(recorder.Ended()); n != 0 {
+		t.Fatalf("вне трассы завершено %d спанов, ожидалось 0", n)
+	}
+
+	parentCtx, parent := provider.Tracer("test").Start(context.Background(), "UserService.GetUserByID")
+	ctx = tracer.TraceQueryStart(parentCtx, nil, query)
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("connection reset")})
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("завершено %d спанов, ожидалось 2", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "SELECT" {
+		t.Errorf("имя спана %q, ожидалось SELECT", span.Name())
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("спан запроса не дочерний для спана сервиса")
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("статус спана %v, ошибка запроса должна помечать спан", span.Status().Code)
+	}
+}
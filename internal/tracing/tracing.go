@@ -0,0 +1,93 @@
+// Package tracing - распределенная трассировка на OpenTelemetry.
+//
+// Каждый HTTP запрос получает серверный спан (Middleware), методы
+// сервисов - дочерние спаны (Start), SQL запросы - спаны pgx
+// (SQLTracer). Входящий заголовок traceparent продолжает трассу
+// вызывающего сервиса. Спаны отправляются по OTLP/HTTP на
+// TRACING_OTLP_ENDPOINT; без него трассировка выключена и Start
+// возвращает пустые спаны без накладных расходов.
+//
+// trace_id и span_id попадают в записи лога с контекстом запроса
+// (см. пакет logging), поэтому по trace_id из коллектора находятся
+// строки лога, и наоборот.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+)
+
+// instrumentationName - имя библиотеки инструментирования в спанах
+const instrumentationName = "github.com/Soundveyve/fiber-backend"
+
+// tracer создает спаны приложения через глобальный провайдер:
+// до Setup (и без него) это no-op провайдер OpenTelemetry
+var tracer = otel.Tracer(instrumentationName)
+
+// Setup настраивает отправку спанов в OTLP коллектор и возвращает функцию
+// остановки, которая дописывает буфер спанов при завершении процесса
+//
+// Пустой cfg.OTLPEndpoint оставляет трассировку выключенной. Заголовки
+// traceparent и baggage передаются дальше в любом случае. Непустой region
+// попадает в ресурс как cloud.region
+func Setup(ctx context.Context, cfg config.TracingConfig, serviceName, env, region string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if cfg.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	// Экспортер не подключается к коллектору при создании: недоступный
+	// коллектор не мешает запуску, спаны просто не доставляются
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания OTLP экспортера: %w", err)
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(serviceName),
+		semconv.DeploymentEnvironment(env),
+	}
+	if region != "" {
+		attrs = append(attrs, semconv.CloudRegion(region))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, attrs...))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка описания ресурса трассировки: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Решение о сэмплировании принимает первый сервис в цепочке,
+		// TRACING_SAMPLE_RATIO действует на трассы, которые начались здесь
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start создает дочерний спан операции (UserService.CreateUser)
+// Спан нужно завершить: defer span.End()
+func Start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name)
+}
@@ -0,0 +1,103 @@
+// Package utc - время на границе API.
+//
+// Все моменты времени в ответах API приводятся к UTC и пишутся в RFC3339
+// ("2024-05-01T12:30:00Z"), а во входящих данных принимаются в нескольких
+// распространенных форматах (см. Parse). Время без указания зоны считается
+// UTC. Так БД, сервер и клиенты в разных часовых поясах видят одни и те же
+// значения, а результат не зависит от TZ сервера.
+package utc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrInvalidTime возвращается для строки, не подходящей ни под один формат
+var ErrInvalidTime = errors.New("невалидный формат времени")
+
+// layouts - принимаемые форматы входящего времени, по порядку проверки
+// Форматы без зоны интерпретируются как UTC
+var layouts = []string{
+	time.RFC3339Nano, // 2024-05-01T12:30:00Z, 2024-05-01T15:30:00.5+03:00
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	time.DateOnly, // 2024-05-01 - начало дня в UTC
+}
+
+// Time - момент времени для моделей API
+// В JSON всегда пишется в UTC в формате RFC3339
+type Time struct {
+	time.Time
+}
+
+// Now возвращает текущее время в UTC
+func Now() Time {
+	return Time{time.Now().UTC()}
+}
+
+// From приводит t к UTC
+func From(t time.Time) Time {
+	return Time{t.UTC()}
+}
+
+// Ptr возвращает указатель на t в UTC (для необязательных полей ответа)
+func Ptr(t time.Time) *Time {
+	v := From(t)
+	return &v
+}
+
+// FromNull возвращает nil для NULL из БД, иначе время в UTC
+func FromNull(t pgtype.Timestamp) *Time {
+	if !t.Valid {
+		return nil
+	}
+	return Ptr(t.Time)
+}
+
+// Parse разбирает время в любом из принимаемых форматов
+// и возвращает его в UTC. Кроме строковых форматов (см. layouts)
+// принимается Unix время в секундах
+func Parse(value string) (time.Time, error) {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidTime, value)
+}
+
+// MarshalJSON пишет время в UTC в формате RFC3339
+func (t Time) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + t.UTC().Format(time.RFC3339) + `"`), nil
+}
+
+// UnmarshalJSON принимает строку в любом формате Parse, Unix время
+// числом или null (нулевое время)
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	value := string(data)
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+
+	parsed, err := Parse(value)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
@@ -0,0 +1,126 @@
+// Package validation проверяет разобранные тела запросов по тегам validate
+// (github.com/go-playground/validator) и формирует ошибки по полям.
+//
+// Handlers вызывают Struct сразу после BodyParser и возвращают ошибку
+// как есть: ErrorHandler приложения отвечает на *Error статусом 422
+// с ошибками по полям в ErrorResponse.Details.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/Soundveyve/fiber-backend/internal/apperrors"
+	"github.com/Soundveyve/fiber-backend/internal/origins"
+	"github.com/Soundveyve/fiber-backend/internal/phone"
+)
+
+// Error - ошибки валидации по полям
+// Ключ - имя поля из тега json (как его видит клиент), значение - описание
+type Error struct {
+	Fields map[string]string
+}
+
+// Error реализует интерфейс error
+func (e *Error) Error() string {
+	return apperrors.ErrValidation.Error()
+}
+
+// Is относит ошибку к категории apperrors.ErrValidation
+func (e *Error) Is(target error) bool {
+	return target == apperrors.ErrValidation
+}
+
+// validate - общий экземпляр валидатора, кеширует разбор структур
+var validate = newValidator()
+
+// newValidator создает валидатор, который называет поля по тегу json
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	// country - код страны ISO 3166-1 alpha-2 в любом регистре
+	// Пустая строка допустима: в запросах обновления она очищает поле
+	_ = v.RegisterValidation("country", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		if value == "" {
+			return true
+		}
+		_, err := phone.NormalizeCountry(value)
+		return err == nil
+	})
+
+	// origin - источник CORS: https://app.example.com или https://*.example.com
+	_ = v.RegisterValidation("origin", func(fl validator.FieldLevel) bool {
+		_, err := origins.Normalize(fl.Field().String())
+		return err == nil
+	})
+	return v
+}
+
+// Struct проверяет структуру по тегам validate
+// Возвращает *Error с ошибками по полям или nil
+func Struct(s interface{}) error {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		// Передана не структура - ошибка программиста, а не клиента
+		panic(fmt.Sprintf("validation: %v", err))
+	}
+
+	result := &Error{Fields: make(map[string]string, len(fieldErrors))}
+	for _, fe := range fieldErrors {
+		result.Fields[fe.Field()] = message(fe)
+	}
+	return result
+}
+
+// message формирует описание ошибки поля для клиента
+func message(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+
+	switch fe.Tag() {
+	case "required":
+		return "обязательное поле"
+	case "email":
+		return "должен быть валидным email"
+	case "min":
+		if isString {
+			return fmt.Sprintf("минимум %s символов", fe.Param())
+		}
+		return fmt.Sprintf("должно быть не меньше %s", fe.Param())
+	case "max":
+		if isString {
+			return fmt.Sprintf("максимум %s символов", fe.Param())
+		}
+		return fmt.Sprintf("должно быть не больше %s", fe.Param())
+	case "origin":
+		return origins.ErrInvalidOrigin.Error()
+	case "country":
+		return "должен быть кодом страны ISO 3166-1 alpha-2 (например, RU)"
+	case "uuid":
+		return "должен быть UUID"
+	case "http_url":
+		return "должен быть URL с http:// или https://"
+	case "oneof":
+		return fmt.Sprintf("должно быть одним из: %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	}
+	return "невалидное значение"
+}
@@ -0,0 +1,447 @@
+// Package wal - ограниченный журнал записей на диске для событий, которые
+// временно некуда сохранить (например, журнал аудита при недоступной БД).
+//
+// Записи дописываются в файлы-сегменты каталога и читаются в порядке
+// записи (Replay). Позиция прочитанного хранится в файле cursor, поэтому
+// после перезапуска процесса повторяются только неподтвержденные записи.
+// Полностью прочитанные сегменты удаляются.
+//
+// Журнал не синхронизируется с диском после каждой записи: события в нем
+// некритичные, а fsync на каждый запрос дороже потери последних записей
+// при падении ОС. Поврежденный хвост сегмента (процесс упал посреди
+// записи) пропускается при чтении.
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrFull возвращается Append, когда журнал занял maxBytes
+var ErrFull = errors.New("журнал на диске заполнен")
+
+const (
+	// headerSize - длина (4 байта) и CRC32 (4 байта) перед данными записи
+	headerSize = 8
+
+	// maxRecordSize - наибольший размер одной записи
+	// Больший заголовок при чтении - признак повреждения
+	maxRecordSize = 1 << 20
+
+	// segmentSuffix - расширение файлов-сегментов
+	segmentSuffix = ".wal"
+
+	// cursorFile - файл с позицией прочитанного: "<сегмент> <смещение>"
+	cursorFile = "cursor"
+)
+
+// position - место в журнале: номер сегмента и смещение в нем
+type position struct {
+	segment uint64
+	offset  int64
+}
+
+// Log - журнал записей в каталоге
+// Append и Replay можно вызывать одновременно из разных горутин
+type Log struct {
+	dir         string
+	maxBytes    int64
+	segmentSize int64
+
+	mu       sync.Mutex
+	segments []uint64         // Номера сегментов по возрастанию, последний - активный
+	sizes    map[uint64]int64 // Размер сегментов в байтах
+	total    int64            // Сумма sizes
+	active   *os.File         // Открыт на запись, nil - новый сегмент создается при Append
+	cursor   position         // Все записи до cursor прочитаны и подтверждены
+
+	replayMu sync.Mutex // Replay выполняется по одному
+}
+
+// Open открывает журнал в каталоге dir, создавая его при необходимости
+// maxBytes ограничивает суммарный размер сегментов
+//
+// Запись продолжается в новом сегменте: хвост последнего сегмента мог
+// остаться недописанным после падения процесса
+func Open(dir string, maxBytes int64) (*Log, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("размер журнала должен быть положительным: %d", maxBytes)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("ошибка создания каталога журнала: %w", err)
+	}
+
+	l := &Log{
+		dir:      dir,
+		maxBytes: maxBytes,
+		// Сегмент - восьмая часть журнала: место освобождается
+		// по мере чтения, не дожидаясь опустошения всего журнала
+		segmentSize: max(maxBytes/8, headerSize+1),
+		sizes:       make(map[uint64]int64),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения каталога журнала: %w", err)
+	}
+	for _, entry := range entries {
+		id, ok := segmentID(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения сегмента журнала: %w", err)
+		}
+		l.segments = append(l.segments, id)
+		l.sizes[id] = info.Size()
+		l.total += info.Size()
+	}
+	slices.Sort(l.segments)
+
+	if err := l.loadCursor(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Append дописывает запись в журнал
+// ErrFull - журнал заполнен, запись не сохранена
+func (l *Log) Append(data []byte) error {
+	if len(data) > maxRecordSize {
+		return fmt.Errorf("запись длиннее %d байт", maxRecordSize)
+	}
+	size := int64(headerSize + len(data))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.total+size > l.maxBytes {
+		return ErrFull
+	}
+
+	// Активный сегмент заполнен или еще не создан - начинаем новый
+	if l.active == nil || l.sizes[l.activeID()]+size > l.segmentSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	record := make([]byte, size)
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(data))
+	copy(record[headerSize:], data)
+
+	id := l.activeID()
+	if n, err := l.active.Write(record); err != nil {
+		// Недописанная запись обрезается, чтобы не испортить следующие
+		if n > 0 {
+			_ = l.active.Truncate(l.sizes[id])
+		}
+		return fmt.Errorf("ошибка записи в журнал: %w", err)
+	}
+	l.sizes[id] += size
+	l.total += size
+	return nil
+}
+
+// Replay передает fn неподтвержденные записи по порядку, не больше limit
+//
+// Запись подтверждается, когда fn вернул nil. Первая ошибка fn останавливает
+// чтение и возвращается: эта и следующие записи будут переданы снова
+// при следующем Replay. Возвращает число подтвержденных записей
+func (l *Log) Replay(limit int, fn func(data []byte) error) (int, error) {
+	l.replayMu.Lock()
+	defer l.replayMu.Unlock()
+
+	replayed := 0
+	for replayed < limit {
+		records, next, err := l.read(limit - replayed)
+		if err != nil {
+			return replayed, err
+		}
+
+		for i, record := range records {
+			if err := fn(record.data); err != nil {
+				// Подтверждаем записи до той, на которой fn ошибся
+				if commitErr := l.commit(records[i].pos); commitErr != nil {
+					return replayed, commitErr
+				}
+				return replayed, err
+			}
+			replayed++
+		}
+		if err := l.commit(next); err != nil {
+			return replayed, err
+		}
+		// Пустое чтение - журнал прочитан или пропущен поврежденный хвост
+		if len(records) == 0 && !l.Pending() {
+			return replayed, nil
+		}
+	}
+	return replayed, nil
+}
+
+// Size возвращает занятое журналом место в байтах
+// Прочитанные записи еще не удаленного сегмента тоже учитываются
+func (l *Log) Size() int64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.total
+}
+
+// Pending сообщает, есть ли непрочитанные записи
+func (l *Log) Pending() bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pendingLocked()
+}
+
+// Close синхронизирует и закрывает активный сегмент
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closeActive()
+}
+
+// record - прочитанная запись и ее начало в журнале
+type record struct {
+	pos  position
+	data []byte
+}
+
+// read читает до limit записей от курсора в пределах одного сегмента
+// next - позиция после последней прочитанной записи
+func (l *Log) read(limit int) ([]record, position, error) {
+	l.mu.Lock()
+	pos := l.cursor
+	if !l.pendingLocked() {
+		l.mu.Unlock()
+		return nil, pos, nil
+	}
+	// Курсор в конце сегмента или указывает на удаленный либо еще
+	// не созданный сегмент - переходим к следующему существующему
+	for {
+		size, ok := l.sizes[pos.segment]
+		if ok && pos.offset < size {
+			break
+		}
+		pos = position{segment: l.nextSegment(pos.segment)}
+	}
+	end := l.sizes[pos.segment]
+	last := pos.segment == l.activeID() && l.active != nil
+	l.mu.Unlock()
+
+	file, err := os.Open(l.segmentPath(pos.segment))
+	if err != nil {
+		return nil, pos, fmt.Errorf("ошибка открытия сегмента журнала: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Seek(pos.offset, io.SeekStart); err != nil {
+		return nil, pos, fmt.Errorf("ошибка чтения сегмента журнала: %w", err)
+	}
+
+	// Активный сегмент читается только до записанного под блокировкой размера
+	reader := bufio.NewReader(io.LimitReader(file, end-pos.offset))
+	var records []record
+	header := make([]byte, headerSize)
+	for len(records) < limit && pos.offset < end {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return l.corrupted(records, pos, last, err)
+		}
+		length := binary.BigEndian.Uint32(header[0:4])
+		if length > maxRecordSize {
+			return l.corrupted(records, pos, last, fmt.Errorf("длина записи %d", length))
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return l.corrupted(records, pos, last, err)
+		}
+		if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:8]) {
+			return l.corrupted(records, pos, last, errors.New("контрольная сумма не совпадает"))
+		}
+
+		records = append(records, record{pos: pos, data: data})
+		pos.offset += int64(headerSize) + int64(length)
+	}
+	return records, pos, nil
+}
+
+// corrupted обрабатывает поврежденную запись на позиции pos
+// Остаток закрытого сегмента пропускается: дописать его уже некому
+func (l *Log) corrupted(records []record, pos position, active bool, cause error) ([]record, position, error) {
+	if active {
+		return records, pos, fmt.Errorf("поврежден активный сегмент журнала: %w", cause)
+	}
+	slog.Warn("⚠️ Поврежденный хвост сегмента журнала пропущен",
+		"dir", l.dir, "segment", pos.segment, "offset", pos.offset, "error", cause)
+
+	l.mu.Lock()
+	next := position{segment: l.nextSegment(pos.segment)}
+	l.mu.Unlock()
+	return records, next, nil
+}
+
+// commit подтверждает записи до pos: сохраняет курсор и удаляет
+// прочитанные сегменты
+func (l *Log) commit(pos position) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if pos == l.cursor {
+		return nil
+	}
+	l.cursor = pos
+
+	// Все прочитано - активный сегмент закрывается, чтобы его тоже удалить
+	if !l.pendingLocked() && l.active != nil {
+		if err := l.closeActive(); err != nil {
+			return err
+		}
+	}
+
+	for len(l.segments) > 0 {
+		id := l.segments[0]
+		if id > pos.segment || id == pos.segment && l.sizes[id] > pos.offset {
+			break
+		}
+		if id == l.activeID() && l.active != nil {
+			break
+		}
+		if err := os.Remove(l.segmentPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("ошибка удаления сегмента журнала: %w", err)
+		}
+		l.total -= l.sizes[id]
+		delete(l.sizes, id)
+		l.segments = l.segments[1:]
+	}
+
+	return l.saveCursor()
+}
+
+// pendingLocked - есть ли записи после курсора; вызывается под mu
+func (l *Log) pendingLocked() bool {
+	for _, id := range l.segments {
+		if id > l.cursor.segment || id == l.cursor.segment && l.sizes[id] > l.cursor.offset {
+			return true
+		}
+	}
+	return false
+}
+
+// nextSegment возвращает первый сегмент после id
+// Если его нет - номер следующего сегмента, который создаст Append
+func (l *Log) nextSegment(id uint64) uint64 {
+	for _, segment := range l.segments {
+		if segment > id {
+			return segment
+		}
+	}
+	return id + 1
+}
+
+// activeID - номер последнего сегмента, 0 - сегментов нет
+func (l *Log) activeID() uint64 {
+	if len(l.segments) == 0 {
+		return 0
+	}
+	return l.segments[len(l.segments)-1]
+}
+
+// rotate закрывает активный сегмент и создает следующий
+func (l *Log) rotate() error {
+	if err := l.closeActive(); err != nil {
+		return err
+	}
+
+	id := max(l.activeID(), l.cursor.segment) + 1
+	file, err := os.OpenFile(l.segmentPath(id), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("ошибка создания сегмента журнала: %w", err)
+	}
+	l.active = file
+	l.segments = append(l.segments, id)
+	l.sizes[id] = 0
+	return nil
+}
+
+// closeActive синхронизирует и закрывает активный сегмент
+func (l *Log) closeActive() error {
+	if l.active == nil {
+		return nil
+	}
+	file := l.active
+	l.active = nil
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("ошибка синхронизации сегмента журнала: %w", err)
+	}
+	return file.Close()
+}
+
+// loadCursor читает позицию прочитанного; нет файла - журнал читается с начала
+func (l *Log) loadCursor() error {
+	data, err := os.ReadFile(filepath.Join(l.dir, cursorFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка чтения курсора журнала: %w", err)
+	}
+
+	segment, offset, ok := strings.Cut(strings.TrimSpace(string(data)), " ")
+	id, idErr := strconv.ParseUint(segment, 10, 64)
+	off, offErr := strconv.ParseInt(offset, 10, 64)
+	if !ok || idErr != nil || offErr != nil || off < 0 {
+		// Поврежденный курсор: повтор записей лучше их потери
+		slog.Warn("⚠️ Поврежденный курсор журнала, чтение с начала", "dir", l.dir)
+		return nil
+	}
+	l.cursor = position{segment: id, offset: off}
+	return nil
+}
+
+// saveCursor атомарно сохраняет курсор через временный файл
+func (l *Log) saveCursor() error {
+	path := filepath.Join(l.dir, cursorFile)
+	data := fmt.Sprintf("%d %d\n", l.cursor.segment, l.cursor.offset)
+	if err := os.WriteFile(path+".tmp", []byte(data), 0o640); err != nil {
+		return fmt.Errorf("ошибка записи курсора журнала: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("ошибка записи курсора журнала: %w", err)
+	}
+	return nil
+}
+
+// segmentPath - путь к файлу сегмента
+func (l *Log) segmentPath(id uint64) string {
+	return filepath.Join(l.dir, fmt.Sprintf("%016d%s", id, segmentSuffix))
+}
+
+// segmentID разбирает номер сегмента из имени файла
+func segmentID(name string) (uint64, bool) {
+	digits, ok := strings.CutSuffix(name, segmentSuffix)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(digits, 10, 64)
+	return id, err == nil && id > 0
+}
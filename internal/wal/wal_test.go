@@ -0,0 +1,154 @@
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// replayAll читает все записи журнала
+func replayAll(t *testing.T, l *Log) []string {
+	t.Helper()
+	var got []string
+	if _, err := l.Replay(1000, func(data []byte) error {
+		got = append(got, string(data))
+		return nil
+	}); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	return got
+}
+
+func TestReplayOrderAndSegments(t *testing.T) {
+	// Сегмент на 64 байта: записи расходятся по нескольким файлам
+	l, err := Open(t.TempDir(), 512)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := l.Append([]byte(fmt.Sprintf("event-%d", i))); err != nil {
+			t.Fatalf("Append %d: %v", i, err)
+		}
+	}
+	if !l.Pending() {
+		t.Fatal("Pending() = false после Append")
+	}
+
+	got := replayAll(t, l)
+	if len(got) != 10 || got[0] != "event-0" || got[9] != "event-9" {
+		t.Errorf("записи %v", got)
+	}
+
+	// Прочитанные сегменты удалены, повторное чтение пустое
+	if l.Pending() || l.Size() != 0 {
+		t.Errorf("после чтения Pending() = %v, Size() = %d", l.Pending(), l.Size())
+	}
+	if got := replayAll(t, l); len(got) != 0 {
+		t.Errorf("повторное чтение %v", got)
+	}
+}
+
+func TestReplayStopsOnError(t *testing.T) {
+	l, err := Open(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+
+	for _, event := range []string{"a", "b", "c"} {
+		if err := l.Append([]byte(event)); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	// Получатель недоступен на второй записи: первая подтверждена,
+	// вторая и третья будут переданы снова
+	down := errors.New("недоступен")
+	n, err := l.Replay(10, func(data []byte) error {
+		if string(data) == "b" {
+			return down
+		}
+		return nil
+	})
+	if n != 1 || !errors.Is(err, down) {
+		t.Fatalf("Replay = %d, %v", n, err)
+	}
+
+	if got := replayAll(t, l); len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Errorf("после восстановления %v, ожидалось [b c]", got)
+	}
+}
+
+func TestFull(t *testing.T) {
+	l, err := Open(t.TempDir(), 3*(headerSize+5))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := l.Append([]byte("12345")); err != nil {
+			t.Fatalf("Append %d: %v", i, err)
+		}
+	}
+	if err := l.Append([]byte("12345")); !errors.Is(err, ErrFull) {
+		t.Fatalf("Append в заполненный журнал = %v, ожидалась ErrFull", err)
+	}
+
+	// Чтение освобождает место
+	replayAll(t, l)
+	if err := l.Append([]byte("12345")); err != nil {
+		t.Errorf("Append после чтения: %v", err)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for _, event := range []string{"a", "b", "c"} {
+		if err := l.Append([]byte(event)); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if _, err := l.Replay(1, func([]byte) error { return nil }); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Процесс упал посреди записи: в хвосте сегмента неполная запись
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	if len(segments) != 1 {
+		t.Fatalf("сегменты %v", segments)
+	}
+	file, err := os.OpenFile(segments[0], os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	file.Write([]byte{0, 0, 0, 9, 1, 2})
+	file.Close()
+
+	// После перезапуска читаются только неподтвержденные записи,
+	// поврежденный хвост пропускается, новые записи идут следом
+	l, err = Open(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+	if err := l.Append([]byte("d")); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	got := replayAll(t, l)
+	if len(got) != 3 || got[0] != "b" || got[1] != "c" || got[2] != "d" {
+		t.Errorf("после перезапуска %v, ожидалось [b c d]", got)
+	}
+}
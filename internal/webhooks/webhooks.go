@@ -0,0 +1,249 @@
+// Package webhooks - события пользователей для внешних систем.
+//
+// Администратор регистрирует подписку: URL и список событий. Сервис
+// пользователей после изменения вызывает Publish, и для каждой подписки
+// на событие в webhook_deliveries появляется доставка, а в очереди
+// заданий - задание jobs.KindDeliverWebhook. Задание отправляет POST
+// подписчику (Deliver); если подписчик не ответил 2xx, очередь повторяет
+// попытку с экспоненциальной задержкой (jobs.Backoff), после последней
+// попытки доставка получает статус failed.
+//
+// Тело запроса подписывается HMAC-SHA256 ключом подписки (Sign), чтобы
+// подписчик мог проверить, что событие отправил этот сервис. Доставка
+// "хотя бы один раз": при повторе подписчик получает событие с тем же id.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Soundveyve/fiber-backend/internal/jobs"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+	"github.com/Soundveyve/fiber-backend/internal/safe"
+	"github.com/Soundveyve/fiber-backend/internal/utc"
+)
+
+// События пользователей
+const (
+	EventUserCreated     = "user.created"
+	EventUserUpdated     = "user.updated"
+	EventUserDeleted     = "user.deleted"
+	EventUserDeactivated = "user.deactivated"
+)
+
+// Events - все события, на которые можно подписаться
+var Events = []string{
+	EventUserCreated,
+	EventUserUpdated,
+	EventUserDeleted,
+	EventUserDeactivated,
+}
+
+// Статусы доставки
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Заголовки запроса к подписчику
+const (
+	HeaderEvent     = "X-Webhook-Event"     // user.created, ...
+	HeaderDelivery  = "X-Webhook-Delivery"  // ID доставки из журнала
+	HeaderSignature = "X-Webhook-Signature" // t=<unix>,v1=<hex HMAC-SHA256>
+)
+
+// deliveryTimeout ограничивает ожидание ответа подписчика
+const deliveryTimeout = 10 * time.Second
+
+// maxErrorBody - сколько байт ответа подписчика попадает в last_error
+const maxErrorBody = 512
+
+// Event - тело запроса к подписчику
+type Event struct {
+	ID         string               `json:"id"` // Одинаковый для всех подписчиков и повторов
+	Type       string               `json:"type"`
+	OccurredAt utc.Time             `json:"occurred_at"`
+	Data       *models.UserResponse `json:"data"` // Пользователь после изменения, для user.deleted - до удаления
+}
+
+// deliveryJob - параметры задания jobs.KindDeliverWebhook
+type deliveryJob struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// Publisher публикует события пользователей и доставляет их подписчикам
+type Publisher struct {
+	queries *repository.Queries
+	queue   *jobs.Queue
+	client  *http.Client
+}
+
+// New создает издателя событий
+// Deliver нужно зарегистрировать в очереди для jobs.KindDeliverWebhook
+func New(queries *repository.Queries, queue *jobs.Queue) *Publisher {
+	return &Publisher{
+		queries: queries,
+		queue:   queue,
+		client: &http.Client{
+			Timeout: deliveryTimeout,
+			// Редирект не выполняется: подписка указывает точный адрес,
+			// а ответ 3xx считается неудачной попыткой
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Publish ставит доставку события event всем подписчикам
+//
+// Вызывается после успешного изменения: ошибка публикации не отменяет
+// изменение и только логируется. Отмена запроса не прерывает публикацию,
+// иначе изменение сохранилось бы без события. Publisher может быть nil
+func (p *Publisher) Publish(ctx context.Context, event string, user *models.UserResponse) {
+	if p == nil || user == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	defer safe.Recover(ctx, "webhooks", nil)
+
+	hooks, err := p.queries.ListWebhooksForEvent(ctx, event)
+	if err != nil {
+		slog.ErrorContext(ctx, "❌ Ошибка получения подписок на событие", "event", event, "error", err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(Event{
+		ID:         uuid.NewString(),
+		Type:       event,
+		OccurredAt: utc.Now(),
+		Data:       user,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "❌ Ошибка сериализации события", "event", event, "error", err)
+		return
+	}
+
+	for _, hook := range hooks {
+		delivery, err := p.queries.CreateWebhookDelivery(ctx, repository.CreateWebhookDeliveryParams{
+			WebhookID: hook.ID,
+			Event:     event,
+			Payload:   body,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "❌ Ошибка сохранения доставки события", "event", event, "webhook_id", hook.ID, "error", err)
+			continue
+		}
+		if err := p.queue.Enqueue(ctx, jobs.KindDeliverWebhook, deliveryJob{DeliveryID: delivery.ID}); err != nil {
+			slog.ErrorContext(ctx, "❌ Ошибка постановки доставки события", "event", event, "delivery_id", delivery.ID, "error", err)
+		}
+	}
+}
+
+// Deliver - обработчик заданий jobs.KindDeliverWebhook
+// Отправляет событие подписчику и сохраняет результат попытки
+func (p *Publisher) Deliver(ctx context.Context, payload json.RawMessage) error {
+	var job deliveryJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return jobs.Permanent(fmt.Errorf("невалидное задание доставки: %w", err))
+	}
+
+	delivery, err := p.queries.GetWebhookDelivery(ctx, job.DeliveryID)
+	if err != nil {
+		// Подписку удалили вместе с журналом доставки - отправлять некому
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("ошибка получения доставки %d: %w", job.DeliveryID, err)
+	}
+	if delivery.Status != StatusPending {
+		return nil
+	}
+
+	status, sendErr := p.send(ctx, &delivery)
+	responseStatus := pgtype.Int4{Int32: int32(status), Valid: status != 0}
+	if sendErr == nil {
+		if err := p.queries.MarkWebhookDelivered(ctx, repository.MarkWebhookDeliveredParams{
+			ID:             delivery.ID,
+			ResponseStatus: responseStatus,
+		}); err != nil {
+			return jobs.Permanent(fmt.Errorf("событие доставлено, но статус не сохранен: %w", err))
+		}
+		return nil
+	}
+
+	next := StatusPending
+	if jobs.LastAttempt(ctx) {
+		next = StatusFailed
+	}
+	if err := p.queries.RecordWebhookAttempt(ctx, repository.RecordWebhookAttemptParams{
+		ID:             delivery.ID,
+		Status:         next,
+		ResponseStatus: responseStatus,
+		LastError:      pgtype.Text{String: sendErr.Error(), Valid: true},
+	}); err != nil {
+		slog.ErrorContext(ctx, "❌ Ошибка сохранения попытки доставки", "delivery_id", delivery.ID, "error", err)
+	}
+	return sendErr
+}
+
+// send отправляет событие подписчику
+// Возвращает HTTP статус ответа (0, если ответа не было)
+func (p *Publisher) send(ctx context.Context, delivery *repository.GetWebhookDeliveryRow) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("невалидный адрес подписки: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "fiber-backend-webhooks")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, time.Now(), delivery.Payload))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка отправки события: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("подписчик ответил %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	// Тело дочитывается, чтобы соединение вернулось в пул
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// Sign возвращает значение заголовка X-Webhook-Signature:
+// t=<unix время>,v1=<hex HMAC-SHA256(secret, "<unix время>.<тело>")>
+//
+// Время входит в подпись, чтобы подписчик мог отклонять старые запросы
+// (повтор перехваченного запроса)
+func Sign(secret string, at time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,109 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/repository"
+)
+
+// verify проверяет подпись так, как это делает подписчик
+func verify(secret, header string, body []byte) bool {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	return hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac.Sum(nil))))
+}
+
+func TestSign(t *testing.T) {
+	at := time.Unix(1767225600, 0)
+	body := []byte(`{"type":"user.created"}`)
+
+	header := Sign("secret", at, body)
+	if !strings.HasPrefix(header, "t=1767225600,v1=") {
+		t.Errorf("заголовок %q", header)
+	}
+	if !verify("secret", header, body) {
+		t.Error("подпись не проходит проверку подписчика")
+	}
+	if verify("other", header, body) || verify("secret", header, []byte(`{}`)) {
+		t.Error("подпись должна зависеть от ключа и тела")
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got *http.Request
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	p := New(nil, nil)
+	delivery := &repository.GetWebhookDeliveryRow{
+		ID:      42,
+		Event:   EventUserUpdated,
+		Payload: []byte(`{"id":"e1","type":"user.updated"}`),
+		Url:     server.URL,
+		Secret:  "secret",
+	}
+
+	status, err := p.send(context.Background(), delivery)
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("send = %d, %v", status, err)
+	}
+	if got.Method != http.MethodPost || got.Header.Get(HeaderEvent) != EventUserUpdated || got.Header.Get(HeaderDelivery) != "42" {
+		t.Errorf("запрос %s, заголовки %v", got.Method, got.Header)
+	}
+	if string(gotBody) != string(delivery.Payload) {
+		t.Errorf("тело %s", gotBody)
+	}
+	if !verify("secret", got.Header.Get(HeaderSignature), gotBody) {
+		t.Error("подпись запроса не проходит проверку")
+	}
+}
+
+func TestSendFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/moved" {
+			http.Redirect(w, r, "/ok", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("maintenance"))
+	}))
+	defer server.Close()
+
+	p := New(nil, nil)
+	for path, want := range map[string]int{
+		"/down":  http.StatusServiceUnavailable,
+		"/moved": http.StatusFound, // редирект не выполняется
+	} {
+		status, err := p.send(context.Background(), &repository.GetWebhookDeliveryRow{
+			Payload: []byte(`{}`),
+			Url:     server.URL + path,
+		})
+		if err == nil || status != want {
+			t.Errorf("%s: send = %d, %v; ожидалась ошибка со статусом %d", path, status, err, want)
+		}
+	}
+}
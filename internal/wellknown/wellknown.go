@@ -0,0 +1,118 @@
+// Package wellknown - документы /.well-known/ (RFC 8615).
+//
+// Документы собираются из конфигурации при запуске, а ссылки в них -
+// из констант путей ниже, которые cmd/api сверяет с зарегистрированными
+// роутами (см. Endpoints). Так документы не расходятся с настоящими
+// эндпоинтами API:
+//
+//   - security.txt (RFC 9116) - контакты для сообщений об уязвимостях
+//   - change-password - перенаправление на страницу смены пароля,
+//     по нему менеджеры паролей открывают нужную страницу
+//   - jwks.json - публичные ключи проверки токенов
+//   - openid-configuration - описание аутентификации (OIDC Discovery)
+package wellknown
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+	"github.com/Soundveyve/fiber-backend/internal/models"
+)
+
+// Пути документов
+const (
+	SecurityTxtPath         = "/.well-known/security.txt"
+	ChangePasswordPath      = "/.well-known/change-password"
+	JWKSPath                = "/.well-known/jwks.json"
+	OpenIDConfigurationPath = "/.well-known/openid-configuration"
+)
+
+// Эндпоинты API, на которые ссылается openid-configuration
+const (
+	TokenPath      = "/api/v1/auth/login"
+	RevocationPath = "/api/v1/auth/logout"
+)
+
+// Documents отдает документы /.well-known/, собранные из конфигурации
+type Documents struct {
+	cfg       config.WellKnownConfig
+	contacts  []string
+	discovery models.OpenIDConfiguration
+}
+
+// New собирает документы из конфигурации
+func New(cfg *config.Config) *Documents {
+	base := strings.TrimSuffix(cfg.WellKnown.BaseURL, "/")
+
+	d := &Documents{
+		cfg: cfg.WellKnown,
+		discovery: models.OpenIDConfiguration{
+			Issuer:                            cfg.Auth.Issuer,
+			JWKSURI:                           base + JWKSPath,
+			TokenEndpoint:                     base + TokenPath,
+			RevocationEndpoint:                base + RevocationPath,
+			GrantTypesSupported:               []string{"password", "refresh_token"},
+			ResponseTypesSupported:            []string{"token"},
+			SubjectTypesSupported:             []string{"public"},
+			TokenEndpointAuthMethodsSupported: []string{"none"},
+			IDTokenSigningAlgValuesSupported:  []string{"HS256"},
+		},
+	}
+	for _, contact := range strings.Split(cfg.WellKnown.SecurityContacts, ",") {
+		if contact = strings.TrimSpace(contact); contact != "" {
+			d.contacts = append(d.contacts, contact)
+		}
+	}
+	return d
+}
+
+// Endpoints возвращает пути API, на которые ссылаются документы
+// cmd/api проверяет при запуске, что все они зарегистрированы
+func (d *Documents) Endpoints() []string {
+	return []string{TokenPath, RevocationPath}
+}
+
+// SecurityTxt возвращает security.txt на момент now
+// ok = false, если контакты не заданы (SECURITY_CONTACTS): без поля
+// Contact файл невалиден, и его лучше не отдавать
+func (d *Documents) SecurityTxt(now time.Time) (text string, ok bool) {
+	if len(d.contacts) == 0 {
+		return "", false
+	}
+
+	var b strings.Builder
+	for _, contact := range d.contacts {
+		fmt.Fprintf(&b, "Contact: %s\n", contact)
+	}
+	// Expires считается от момента запроса, поэтому файл не устаревает,
+	// пока сервис работает; время округляется до часа для кеширования
+	expires := now.UTC().Add(d.cfg.SecurityExpires).Truncate(time.Hour)
+	fmt.Fprintf(&b, "Expires: %s\n", expires.Format(time.RFC3339))
+	if d.cfg.SecurityPolicy != "" {
+		fmt.Fprintf(&b, "Policy: %s\n", d.cfg.SecurityPolicy)
+	}
+	if d.cfg.SecurityLanguage != "" {
+		fmt.Fprintf(&b, "Preferred-Languages: %s\n", d.cfg.SecurityLanguage)
+	}
+	fmt.Fprintf(&b, "Canonical: %s%s\n", strings.TrimSuffix(d.cfg.BaseURL, "/"), SecurityTxtPath)
+
+	return b.String(), true
+}
+
+// ChangePasswordURL возвращает адрес страницы смены пароля
+func (d *Documents) ChangePasswordURL() string {
+	return d.cfg.ChangePasswordURL
+}
+
+// JWKS возвращает публичные ключи проверки токенов
+// HS256 ключ секретный, поэтому список пуст (см. models.JWKSResponse)
+func (d *Documents) JWKS() models.JWKSResponse {
+	return models.JWKSResponse{Keys: []models.JWK{}}
+}
+
+// OpenIDConfiguration возвращает описание аутентификации
+func (d *Documents) OpenIDConfiguration() models.OpenIDConfiguration {
+	return d.discovery
+}
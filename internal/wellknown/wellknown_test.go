@@ -0,0 +1,61 @@
+package wellknown
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Soundveyve/fiber-backend/internal/config"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Auth: config.AuthConfig{Issuer: "fiber-backend"},
+		WellKnown: config.WellKnownConfig{
+			BaseURL:          "https://api.example.com/",
+			SecurityContacts: "mailto:security@example.com, https://example.com/security",
+			SecurityPolicy:   "https://example.com/security/policy",
+			SecurityLanguage: "ru, en",
+			SecurityExpires:  180 * 24 * time.Hour,
+		},
+	}
+}
+
+func TestSecurityTxt(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	text, ok := New(testConfig()).SecurityTxt(now)
+	if !ok {
+		t.Fatal("security.txt не отдается при заданных контактах")
+	}
+
+	want := "Contact: mailto:security@example.com\n" +
+		"Contact: https://example.com/security\n" +
+		"Expires: 2024-10-28T12:00:00Z\n" +
+		"Policy: https://example.com/security/policy\n" +
+		"Preferred-Languages: ru, en\n" +
+		"Canonical: https://api.example.com/.well-known/security.txt\n"
+	if text != want {
+		t.Errorf("security.txt:\n%s\nожидался:\n%s", text, want)
+	}
+}
+
+func TestSecurityTxtWithoutContacts(t *testing.T) {
+	cfg := testConfig()
+	cfg.WellKnown.SecurityContacts = " , "
+	if _, ok := New(cfg).SecurityTxt(time.Now()); ok {
+		t.Error("security.txt без контактов не должен отдаваться")
+	}
+}
+
+func TestOpenIDConfiguration(t *testing.T) {
+	doc := New(testConfig()).OpenIDConfiguration()
+	if doc.Issuer != "fiber-backend" {
+		t.Errorf("issuer = %q, ожидался iss токенов", doc.Issuer)
+	}
+	if doc.JWKSURI != "https://api.example.com"+JWKSPath {
+		t.Errorf("jwks_uri = %q", doc.JWKSURI)
+	}
+	if !strings.HasSuffix(doc.TokenEndpoint, TokenPath) {
+		t.Errorf("token_endpoint = %q", doc.TokenEndpoint)
+	}
+}
@@ -0,0 +1,123 @@
+// Package svcauth подписывает запросы внутренних сервисов к
+// эндпоинтам /internal/v1 (и /admin/v1 при SERVICE_SIGNING_KEY).
+//
+// Пакет вне internal/, чтобы его могли импортировать другие сервисы.
+// Сервис получает имя и ключ HMAC (INTERNAL_SERVICE_KEYS на стороне
+// fiber-backend) и отправляет запросы через клиент с подписью:
+//
+//	client := svcauth.NewClient("billing", os.Getenv("USERS_SERVICE_KEY"), nil)
+//	resp, err := client.Get("https://users.internal/internal/v1/users/" + id)
+//
+// Каждый запрос получает свежие timestamp и nonce, поэтому повтор
+// (в том числе ретрай http.Client) подписывается заново. При mTLS
+// (сертификат проверяет ingress) клиенту передается tls.Config с
+// сертификатом сервиса, подпись тогда можно не настраивать.
+package svcauth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Заголовки подписанного запроса
+const (
+	HeaderService   = "X-Service"   // Имя сервиса: по нему выбирается ключ
+	HeaderTimestamp = "X-Timestamp" // Unix время подписи в секундах
+	HeaderNonce     = "X-Nonce"     // Одноразовое случайное значение
+	HeaderSignature = "X-Signature" // hex(HMAC-SHA256), см. Sign
+)
+
+// Sign вычисляет подпись запроса
+//
+// Подписывается строка:
+//
+//	METHOD\nURI\nTIMESTAMP\nNONCE\nhex(sha256(BODY))
+//
+// где URI - путь вместе с query string, как он придет на сервер
+func Sign(key []byte, method, uri, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(method + "\n" + uri + "\n" + timestamp + "\n" + nonce + "\n"))
+	mac.Write([]byte(hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest добавляет к запросу заголовки подписи от имени service
+// Тело читается целиком и подставляется обратно, поэтому запрос
+// можно отправлять после подписи как обычно
+func SignRequest(req *http.Request, service string, key []byte) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return fmt.Errorf("svcauth: ошибка чтения тела запроса: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("svcauth: ошибка генерации nonce: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonceHex := hex.EncodeToString(nonce)
+
+	req.Header.Set(HeaderService, service)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNonce, nonceHex)
+	req.Header.Set(HeaderSignature, Sign(key, req.Method, req.URL.RequestURI(), timestamp, nonceHex, body))
+	return nil
+}
+
+// Transport подписывает каждый запрос перед отправкой через Base
+type Transport struct {
+	Service string
+	Key     []byte
+	Base    http.RoundTripper // nil - http.DefaultTransport
+}
+
+// RoundTrip реализует http.RoundTripper
+// Исходный запрос не меняется: подписывается копия
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	signed := req.Clone(req.Context())
+	if err := SignRequest(signed, t.Service, t.Key); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(signed)
+}
+
+// NewClient создает http.Client, который подписывает запросы от имени service
+// tlsConfig - клиентский сертификат для mTLS, nil - настройки по умолчанию
+func NewClient(service, key string, tlsConfig *tls.Config) *http.Client {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		base.TLSClientConfig = tlsConfig
+	}
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &Transport{Service: service, Key: []byte(key), Base: base},
+	}
+}
@@ -0,0 +1,48 @@
+package svcauth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientSignsRequests(t *testing.T) {
+	key := "billing-key-0123456789abcdef0123456789"
+	nonces := map[string]bool{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(HeaderService) != "billing" {
+			t.Errorf("%s = %q", HeaderService, r.Header.Get(HeaderService))
+		}
+		expected := Sign([]byte(key), r.Method, r.URL.RequestURI(),
+			r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderNonce), body)
+		if r.Header.Get(HeaderSignature) != expected {
+			t.Errorf("подпись %q не совпала с ожидаемой %q", r.Header.Get(HeaderSignature), expected)
+		}
+		nonces[r.Header.Get(HeaderNonce)] = true
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	client := NewClient("billing", key, nil)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post(server.URL+"/internal/v1/users?x=1", "application/json", strings.NewReader(`{"id":1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		// Тело читается для подписи и отправляется без изменений
+		if string(body) != `{"id":1}` {
+			t.Errorf("сервер получил тело %q", body)
+		}
+	}
+
+	// Каждый запрос подписывается со своим nonce
+	if len(nonces) != 2 {
+		t.Errorf("nonce повторился: %v", nonces)
+	}
+}
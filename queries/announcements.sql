@@ -0,0 +1,27 @@
+-- name: CreateAnnouncement :one
+-- Создание объявления
+-- starts_at = NULL означает "показывать сразу"
+INSERT INTO announcements (
+    title,
+    body,
+    severity,
+    audience,
+    starts_at,
+    ends_at
+) VALUES (
+    sqlc.arg('title'),
+    sqlc.arg('body'),
+    sqlc.arg('severity'),
+    sqlc.arg('audience'),
+    COALESCE(sqlc.narg('starts_at')::timestamp, CURRENT_TIMESTAMP),
+    sqlc.narg('ends_at')
+) RETURNING *;
+
+-- name: ListActiveAnnouncements :many
+-- Активные на текущий момент объявления для аудитории
+-- Объявления для всех (all) показываются любой платформе
+SELECT * FROM announcements
+WHERE starts_at <= CURRENT_TIMESTAMP
+  AND (ends_at IS NULL OR ends_at > CURRENT_TIMESTAMP)
+  AND (audience = 'all' OR audience = sqlc.arg('audience'))
+ORDER BY starts_at DESC, id DESC;
@@ -0,0 +1,47 @@
+-- name: CreateArchive :one
+-- Файл архива, сохраненный в хранилище
+-- Вызывается в одной транзакции с удалением перенесенных записей
+INSERT INTO archives (
+    source,
+    range_start,
+    range_end,
+    first_id,
+    last_id,
+    entry_count,
+    size_bytes,
+    storage_key
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetArchive :one
+SELECT * FROM archives
+WHERE id = $1;
+
+-- name: ListArchives :many
+-- Архивы, период которых пересекается с [created_after, created_before),
+-- от старых к новым
+SELECT * FROM archives
+WHERE (sqlc.narg('source')::text IS NULL OR source = sqlc.narg('source'))
+  AND (sqlc.narg('created_after')::timestamp IS NULL OR range_end >= sqlc.narg('created_after'))
+  AND (sqlc.narg('created_before')::timestamp IS NULL OR range_start < sqlc.narg('created_before'))
+ORDER BY range_start, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountArchives :one
+-- Количество архивов с теми же фильтрами, что у ListArchives
+SELECT COUNT(*) FROM archives
+WHERE (sqlc.narg('source')::text IS NULL OR source = sqlc.narg('source'))
+  AND (sqlc.narg('created_after')::timestamp IS NULL OR range_end >= sqlc.narg('created_after'))
+  AND (sqlc.narg('created_before')::timestamp IS NULL OR range_start < sqlc.narg('created_before'));
+
+-- name: ListExpiredArchives :many
+-- Архивы источника, все записи которых старше срока хранения архива
+SELECT * FROM archives
+WHERE source = $1 AND range_end < $2
+ORDER BY id;
+
+-- name: DeleteArchive :exec
+-- Удаление строки после удаления файла из хранилища
+DELETE FROM archives
+WHERE id = $1;
@@ -0,0 +1,76 @@
+-- name: CreateAuditLog :exec
+-- Запись в журнал аудита
+-- Вызывается фоновым писателем (internal/audit), не из обработки запроса
+INSERT INTO audit_logs (
+    actor_id,
+    actor_role,
+    action,
+    target_user_id,
+    changes,
+    request_id,
+    ip,
+    user_agent,
+    created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+);
+
+-- name: ListAuditLogs :many
+-- Страница журнала от новых записей к старым
+-- Пользователи возвращаются публичными ID для API; у физически
+-- удаленных public_id будет NULL
+SELECT
+    audit_logs.*,
+    actor.public_id AS actor_public_id,
+    target.public_id AS target_public_id
+FROM audit_logs
+LEFT JOIN users actor ON actor.id = audit_logs.actor_id
+LEFT JOIN users target ON target.id = audit_logs.target_user_id
+WHERE (sqlc.narg('actor_id')::int IS NULL OR audit_logs.actor_id = sqlc.narg('actor_id'))
+  AND (sqlc.narg('target_user_id')::int IS NULL OR audit_logs.target_user_id = sqlc.narg('target_user_id'))
+  AND (sqlc.narg('action')::text IS NULL OR audit_logs.action = sqlc.narg('action'))
+  AND (sqlc.narg('created_after')::timestamp IS NULL OR audit_logs.created_at >= sqlc.narg('created_after'))
+  AND (sqlc.narg('created_before')::timestamp IS NULL OR audit_logs.created_at < sqlc.narg('created_before'))
+ORDER BY audit_logs.created_at DESC, audit_logs.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountAuditLogs :one
+-- Количество записей журнала с теми же фильтрами, что у ListAuditLogs
+SELECT COUNT(*) FROM audit_logs
+WHERE (sqlc.narg('actor_id')::int IS NULL OR actor_id = sqlc.narg('actor_id'))
+  AND (sqlc.narg('target_user_id')::int IS NULL OR target_user_id = sqlc.narg('target_user_id'))
+  AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
+  AND (sqlc.narg('created_after')::timestamp IS NULL OR created_at >= sqlc.narg('created_after'))
+  AND (sqlc.narg('created_before')::timestamp IS NULL OR created_at < sqlc.narg('created_before'));
+
+-- name: ListAuditLogsForArchive :many
+-- Пачка записей старше created_before для архива (задание archive_records)
+-- По возрастанию id: следующая пачка начинается после удаленной
+SELECT
+    audit_logs.*,
+    actor.public_id AS actor_public_id,
+    target.public_id AS target_public_id
+FROM audit_logs
+LEFT JOIN users actor ON actor.id = audit_logs.actor_id
+LEFT JOIN users target ON target.id = audit_logs.target_user_id
+WHERE audit_logs.created_at < sqlc.arg('created_before')
+ORDER BY audit_logs.id
+LIMIT sqlc.arg('limit');
+
+-- name: DeleteArchivedAuditLogs :execrows
+-- Удаление записей, перенесенных в архив: той же пачки, что вернул
+-- ListAuditLogsForArchive с тем же created_before
+DELETE FROM audit_logs
+WHERE id BETWEEN sqlc.arg('first_id') AND sqlc.arg('last_id')
+  AND created_at < sqlc.arg('created_before');
+
+-- name: ReassignAuditLogs :execrows
+-- Перенос ссылок журнала с дубликата на основной аккаунт при слиянии:
+-- история основного аккаунта включает действия над дубликатом и его
+-- собственные. Содержимое записей не меняется, само слияние фиксирует
+-- запись user.merge
+UPDATE audit_logs
+SET
+    actor_id = CASE WHEN actor_id = sqlc.arg('duplicate_id') THEN sqlc.arg('primary_id') ELSE actor_id END,
+    target_user_id = CASE WHEN target_user_id = sqlc.arg('duplicate_id') THEN sqlc.arg('primary_id') ELSE target_user_id END
+WHERE actor_id = sqlc.arg('duplicate_id') OR target_user_id = sqlc.arg('duplicate_id');
@@ -0,0 +1,45 @@
+-- name: CreateBackfill :exec
+-- Запись прогресса при первом запуске заполнения
+INSERT INTO backfills (name) VALUES ($1)
+ON CONFLICT (name) DO NOTHING;
+
+-- name: LockBackfill :one
+-- Прогресс заполнения с блокировкой строки до конца транзакции пачки:
+-- второй запуск того же заполнения ждет и продолжает после первого,
+-- а не обрабатывает те же строки
+SELECT * FROM backfills
+WHERE name = $1
+FOR UPDATE;
+
+-- name: SaveBackfillProgress :exec
+-- Позиция и счетчики после пачки, в транзакции пачки
+UPDATE backfills
+SET
+    last_id = sqlc.arg(last_id),
+    scanned = scanned + sqlc.arg(scanned),
+    updated = updated + sqlc.arg(updated),
+    updated_at = CURRENT_TIMESTAMP
+WHERE name = sqlc.arg(name);
+
+-- name: CompleteBackfill :exec
+-- Заполнение дошло до конца таблицы
+UPDATE backfills
+SET completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE name = $1;
+
+-- name: ResetBackfill :exec
+-- Начать заполнение заново (backfill restart)
+UPDATE backfills
+SET
+    last_id = 0,
+    scanned = 0,
+    updated = 0,
+    started_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP,
+    completed_at = NULL
+WHERE name = $1;
+
+-- name: ListBackfills :many
+-- Прогресс всех запускавшихся заполнений
+SELECT * FROM backfills
+ORDER BY name;
@@ -0,0 +1,79 @@
+-- name: CreateBroadcast :one
+-- Создание рассылки
+-- Рассылка создается в статусе pending и ждет воркера
+INSERT INTO broadcasts (
+    subject,
+    body,
+    inactive_since,
+    total_count,
+    created_by
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetBroadcast :one
+-- Получение рассылки по ID
+SELECT * FROM broadcasts
+WHERE id = $1 LIMIT 1;
+
+-- name: ClaimNextBroadcast :one
+-- Захват следующей рассылки воркером
+-- Кроме ожидающих захватываются рассылки в статусе running, по которым
+-- давно не было прогресса (heartbeat_at < stale_before): их инстанс
+-- остановился посреди рассылки. Обработка продолжается с last_user_id.
+-- FOR UPDATE SKIP LOCKED позволяет нескольким инстансам разбирать
+-- очередь параллельно, не захватывая одну рассылку дважды
+UPDATE broadcasts
+SET
+    status = 'running',
+    started_at = COALESCE(started_at, CURRENT_TIMESTAMP),
+    heartbeat_at = CURRENT_TIMESTAMP
+WHERE id = (
+    SELECT id FROM broadcasts
+    WHERE status = 'pending'
+       OR (status = 'running' AND heartbeat_at < sqlc.arg(stale_before)::timestamp)
+    ORDER BY created_at
+    FOR UPDATE SKIP LOCKED
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: RecordBroadcastProgress :one
+-- Сохранение прогресса после пачки: курсор, счетчики и heartbeat
+-- Отмененная рассылка не обновляется: воркер получит sql.ErrNoRows
+-- и прекратит отправку
+UPDATE broadcasts
+SET
+    last_user_id = $2,
+    sent_count = sent_count + sqlc.arg(sent)::int,
+    failed_count = failed_count + sqlc.arg(failed)::int,
+    heartbeat_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'running'
+RETURNING *;
+
+-- name: CompleteBroadcast :exec
+-- Успешное завершение рассылки
+UPDATE broadcasts
+SET
+    status = 'completed',
+    completed_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'running';
+
+-- name: FailBroadcast :exec
+-- Завершение рассылки с ошибкой
+UPDATE broadcasts
+SET
+    status = 'failed',
+    error = $2,
+    completed_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'running';
+
+-- name: CancelBroadcast :one
+-- Отмена рассылки, которая еще не завершена
+-- Уже отправленные письма не отзываются
+UPDATE broadcasts
+SET
+    status = 'cancelled',
+    completed_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status IN ('pending', 'running')
+RETURNING *;
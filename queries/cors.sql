@@ -0,0 +1,47 @@
+-- name: CreateCORSOrigin :one
+-- Разрешение источника; повторный origin - нарушение UNIQUE
+INSERT INTO cors_origins (
+    origin,
+    description,
+    created_by
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: ListCORSOrigins :many
+-- Все разрешенные источники для админки
+SELECT * FROM cors_origins
+ORDER BY origin;
+
+-- name: DeleteCORSOrigin :execrows
+DELETE FROM cors_origins
+WHERE id = $1;
+
+-- name: CreateTrustedClient :one
+-- Регистрация собственного клиента; повторное имя - нарушение UNIQUE
+INSERT INTO trusted_clients (
+    name,
+    description,
+    origins,
+    created_by
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListTrustedClients :many
+-- Все клиенты для админки и кеша источников
+SELECT * FROM trusted_clients
+ORDER BY name;
+
+-- name: UpdateTrustedClient :one
+-- Замена описания и списка источников клиента
+UPDATE trusted_clients
+SET description = $2,
+    origins = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteTrustedClient :execrows
+DELETE FROM trusted_clients
+WHERE id = $1;
@@ -0,0 +1,56 @@
+-- name: GetDigestSubscription :one
+-- Подписка пользователя на сводку
+SELECT * FROM digest_subscriptions
+WHERE user_id = $1 LIMIT 1;
+
+-- name: CreateDigestSubscription :one
+-- Подписка на сводку
+-- Повторная подписка не сбрасывает момент последней отправки
+INSERT INTO digest_subscriptions (
+    user_id
+) VALUES (
+    $1
+)
+ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+RETURNING *;
+
+-- name: DeleteDigestSubscription :execrows
+-- Отписка от сводки
+DELETE FROM digest_subscriptions
+WHERE user_id = $1;
+
+-- name: ClaimDueDigest :one
+-- Захват следующей подписки, по которой пора отправить сводку
+-- Сводка положена, если с последней отправки прошел период (due_before -
+-- текущее время минус период). Первая сводка охватывает последний период.
+-- last_sent_at обновляется при захвате: при ошибке отправки сводка
+-- за период пропускается, а не отправляется повторно.
+-- FOR UPDATE SKIP LOCKED позволяет нескольким инстансам разбирать
+-- подписки параллельно, не отправляя одну сводку дважды
+WITH due AS (
+    SELECT s.user_id, COALESCE(s.last_sent_at, sqlc.arg(due_before)::timestamp) AS period_start
+    FROM digest_subscriptions s
+    JOIN users u ON u.id = s.user_id
+    WHERE (s.last_sent_at IS NULL OR s.last_sent_at <= sqlc.arg(due_before)::timestamp)
+      AND u.status IN ('pending_verification', 'active')
+      AND u.deleted_at IS NULL
+    ORDER BY s.last_sent_at NULLS FIRST
+    FOR UPDATE OF s SKIP LOCKED
+    LIMIT 1
+)
+UPDATE digest_subscriptions d
+SET last_sent_at = CURRENT_TIMESTAMP
+FROM due
+WHERE d.user_id = due.user_id
+RETURNING d.user_id, due.period_start::timestamp AS period_start, d.last_sent_at::timestamp AS period_end;
+
+-- name: ReassignDigestSubscription :execrows
+-- Перенос подписки дубликата на основной аккаунт при слиянии
+-- Если основной аккаунт уже подписан, его подписка остается как есть
+UPDATE digest_subscriptions
+SET user_id = sqlc.arg('primary_id')
+WHERE user_id = sqlc.arg('duplicate_id')
+  AND NOT EXISTS (
+      SELECT 1 FROM digest_subscriptions
+      WHERE user_id = sqlc.arg('primary_id')
+  );
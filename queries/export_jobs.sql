@@ -0,0 +1,44 @@
+-- name: CreateExportJob :one
+-- Создание задания на экспорт
+-- Задание создается в статусе pending, файл формирует операция export_users
+INSERT INTO export_jobs (
+    resource,
+    format
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: GetExportJob :one
+-- Получение задания по ID
+SELECT * FROM export_jobs
+WHERE id = $1 LIMIT 1;
+
+-- name: StartExportJob :one
+-- Начало формирования файла операцией export_users
+-- Задание, прерванное остановкой инстанса (running), повторная попытка
+-- операции начинает заново; завершенное не начинается повторно
+UPDATE export_jobs
+SET
+    status = 'running',
+    started_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status IN ('pending', 'running')
+RETURNING *;
+
+-- name: CompleteExportJob :exec
+-- Успешное завершение задания
+UPDATE export_jobs
+SET
+    status = 'completed',
+    storage_key = $2,
+    row_count = $3,
+    completed_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: FailExportJob :exec
+-- Завершение задания с ошибкой
+UPDATE export_jobs
+SET
+    status = 'failed',
+    error = $2,
+    completed_at = CURRENT_TIMESTAMP
+WHERE id = $1;
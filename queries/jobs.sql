@@ -0,0 +1,100 @@
+-- name: EnqueueJob :one
+-- Постановка задания в очередь
+-- run_at в будущем откладывает выполнение
+INSERT INTO jobs (
+    kind,
+    payload,
+    max_attempts,
+    run_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: EnqueueUniqueJob :execrows
+-- Постановка задания по расписанию
+-- Все инстансы ставят задание с одним ключом периода, в очередь попадает
+-- только первое: 0 обновленных строк - задание на этот период уже есть
+INSERT INTO jobs (
+    kind,
+    payload,
+    max_attempts,
+    unique_key
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (unique_key) WHERE unique_key IS NOT NULL DO NOTHING;
+
+-- name: ClaimNextJob :one
+-- Захват следующего готового задания воркером
+-- FOR UPDATE SKIP LOCKED позволяет нескольким воркерам и инстансам
+-- разбирать очередь параллельно, не захватывая одно задание дважды
+UPDATE jobs
+SET
+    status = 'running',
+    attempts = attempts + 1,
+    locked_at = CURRENT_TIMESTAMP
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE status = 'pending' AND run_at <= CURRENT_TIMESTAMP
+    ORDER BY run_at
+    FOR UPDATE SKIP LOCKED
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: CompleteJob :exec
+-- Успешное выполнение задания
+UPDATE jobs
+SET
+    status = 'completed',
+    locked_at = NULL,
+    completed_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: RetryJob :exec
+-- Возврат задания в очередь после неудачной попытки
+-- Следующая попытка не раньше run_at (экспоненциальная задержка)
+UPDATE jobs
+SET
+    status = 'pending',
+    run_at = $2,
+    last_error = $3,
+    locked_at = NULL
+WHERE id = $1;
+
+-- name: FailJob :exec
+-- Задание исчерпало попытки или ошибка не исправится повтором
+UPDATE jobs
+SET
+    status = 'failed',
+    last_error = $2,
+    locked_at = NULL,
+    completed_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: RequeueStaleJobs :execrows
+-- Возврат в очередь заданий, захваченных до locked_before
+-- Инстанс упал или был убит, не дописав статус; попытка уже засчитана
+UPDATE jobs
+SET
+    status = 'pending',
+    locked_at = NULL
+WHERE status = 'running' AND locked_at < $1;
+
+-- name: DeleteFinishedJobs :execrows
+-- Удаление завершенных заданий старше completed_before
+DELETE FROM jobs
+WHERE status IN ('completed', 'failed') AND completed_at < $1;
+
+-- name: JobQueueStats :many
+-- Глубина очереди по типам и статусам для админки
+-- Завершенные успешно не считаются: их удаляет очистка очереди
+SELECT
+    kind,
+    status,
+    COUNT(*)::integer AS count,
+    MIN(run_at)::timestamp AS oldest_run_at
+FROM jobs
+WHERE status IN ('pending', 'running', 'failed')
+GROUP BY kind, status
+ORDER BY kind, status;
@@ -0,0 +1,34 @@
+-- name: CreateLoginHistory :exec
+-- Запись о завершенном входе
+INSERT INTO login_history (
+    user_id,
+    ip,
+    user_agent
+) VALUES (
+    $1, $2, $3
+);
+
+-- name: ListLoginHistoryForArchive :many
+-- Пачка записей старше created_before для архива (задание archive_records)
+-- По возрастанию id: следующая пачка начинается после удаленной
+SELECT
+    login_history.*,
+    users.public_id AS user_public_id
+FROM login_history
+JOIN users ON users.id = login_history.user_id
+WHERE login_history.created_at < sqlc.arg('created_before')
+ORDER BY login_history.id
+LIMIT sqlc.arg('limit');
+
+-- name: DeleteArchivedLoginHistory :execrows
+-- Удаление записей, перенесенных в архив: той же пачки, что вернул
+-- ListLoginHistoryForArchive с тем же created_before
+DELETE FROM login_history
+WHERE id BETWEEN sqlc.arg('first_id') AND sqlc.arg('last_id')
+  AND created_at < sqlc.arg('created_before');
+
+-- name: ReassignLoginHistory :execrows
+-- Перенос истории входов дубликата на основной аккаунт при слиянии
+UPDATE login_history
+SET user_id = sqlc.arg('primary_id')
+WHERE user_id = sqlc.arg('duplicate_id');
@@ -0,0 +1,38 @@
+-- name: AddNotificationEvent :exec
+-- Добавление события в пачку пользователя
+-- Первое событие создает пачку с отправкой через окно типа,
+-- следующие только увеличивают счетчик
+INSERT INTO notification_batches (
+    user_id, event_type, first_at, last_at, send_after
+) VALUES (
+    sqlc.arg(user_id), sqlc.arg(event_type), sqlc.arg(occurred_at), sqlc.arg(occurred_at), sqlc.arg(send_after)
+)
+ON CONFLICT (user_id, event_type) DO UPDATE SET
+    count = notification_batches.count + 1,
+    first_at = LEAST(notification_batches.first_at, EXCLUDED.first_at),
+    last_at = GREATEST(notification_batches.last_at, EXCLUDED.last_at);
+
+-- name: ClaimDueNotificationBatch :one
+-- Захват следующей пачки, которую пора отправить, вместе с получателем
+-- Пачка удаляется при захвате: при ошибке отправки уведомление
+-- пропускается, а не отправляется повторно. Пачки удаленных
+-- и заблокированных пользователей удаляются без отправки.
+-- FOR UPDATE SKIP LOCKED позволяет нескольким инстансам разбирать
+-- пачки параллельно
+WITH due AS (
+    SELECT b.user_id, b.event_type
+    FROM notification_batches b
+    WHERE b.send_after <= sqlc.arg(now)::timestamp
+    ORDER BY b.send_after
+    FOR UPDATE SKIP LOCKED
+    LIMIT 1
+)
+DELETE FROM notification_batches n
+USING due, users u
+WHERE n.user_id = due.user_id
+  AND n.event_type = due.event_type
+  AND u.id = n.user_id
+RETURNING
+    n.user_id, n.event_type, n.count, n.first_at, n.last_at,
+    u.email, u.username,
+    (u.status IN ('pending_verification', 'active') AND u.deleted_at IS NULL)::boolean AS deliverable;
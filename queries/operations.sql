@@ -0,0 +1,58 @@
+-- name: CreateOperation :one
+-- Новая операция в статусе pending (Prefer: respond-async)
+INSERT INTO operations (
+    kind,
+    input,
+    actor_id,
+    actor_role,
+    request_id
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetOperation :one
+SELECT * FROM operations
+WHERE id = $1;
+
+-- name: StartOperation :one
+-- Начало выполнения воркером. Операция, прерванная падением инстанса,
+-- запускается заново (status = running) с нулевым прогрессом: импорт и
+-- слияние транзакционны или пропускают уже созданные записи.
+-- Завершенная операция не возвращается (pgx.ErrNoRows)
+UPDATE operations
+SET
+    status = 'running',
+    progress_done = 0,
+    started_at = COALESCE(started_at, CURRENT_TIMESTAMP),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status IN ('pending', 'running')
+RETURNING *;
+
+-- name: UpdateOperationProgress :exec
+UPDATE operations
+SET
+    progress_done = sqlc.arg(progress_done),
+    progress_total = sqlc.narg(progress_total),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = 'running';
+
+-- name: CompleteOperation :exec
+-- Итог операции; параметры больше не нужны и удаляются
+UPDATE operations
+SET
+    status = sqlc.arg(status),
+    progress_done = sqlc.arg(progress_done),
+    progress_total = sqlc.narg(progress_total),
+    result = sqlc.narg(result),
+    error = sqlc.narg(error),
+    error_code = sqlc.narg(error_code),
+    error_details = sqlc.narg(error_details),
+    input = NULL,
+    updated_at = CURRENT_TIMESTAMP,
+    completed_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: PurgeOperations :execrows
+-- Удаление завершенных операций старше срока хранения
+DELETE FROM operations
+WHERE completed_at < $1;
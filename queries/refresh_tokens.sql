@@ -0,0 +1,37 @@
+-- name: CreateRefreshToken :one
+-- Сохранение выданного refresh токена
+INSERT INTO refresh_tokens (
+    user_id,
+    token_hash,
+    expires_at
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetRefreshTokenForUpdate :one
+-- Получение токена по хешу с блокировкой
+-- Блокировка не дает двум параллельным refresh ротировать один токен дважды
+SELECT * FROM refresh_tokens
+WHERE token_hash = $1
+FOR UPDATE;
+
+-- name: RevokeRefreshToken :execrows
+-- Отзыв токена (logout, ротация)
+UPDATE refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE token_hash = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :execrows
+-- Отзыв всех действующих токенов пользователя
+-- Используется при повторном использовании уже отозванного токена
+-- (признак кражи) и при смене пароля
+UPDATE refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: DeleteExpiredRefreshTokens :execrows
+-- Удаление истекших токенов (задание purge_refresh_tokens)
+-- Отозванные, но еще не истекшие токены остаются: по ним распознается
+-- повторное использование украденного токена
+DELETE FROM refresh_tokens
+WHERE expires_at < CURRENT_TIMESTAMP;
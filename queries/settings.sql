@@ -0,0 +1,25 @@
+-- name: ListSettings :many
+-- Все переопределенные настройки
+-- Администратор, изменивший настройку, возвращается публичным ID для API
+SELECT settings.*, users.public_id AS updated_by_public_id
+FROM settings
+LEFT JOIN users ON users.id = settings.updated_by
+ORDER BY settings.key;
+
+-- name: UpsertSetting :one
+-- Переопределение настройки
+INSERT INTO settings (
+    key, value, updated_by
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (key) DO UPDATE SET
+    value = EXCLUDED.value,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteSetting :execrows
+-- Сброс настройки к значению по умолчанию
+DELETE FROM settings
+WHERE key = $1;
@@ -0,0 +1,141 @@
+-- name: GetUserTOTP :one
+-- Секрет TOTP пользователя (включенный или ожидающий подтверждения)
+SELECT * FROM user_totp
+WHERE user_id = $1 LIMIT 1;
+
+-- name: UpsertPendingUserTOTP :one
+-- Новый секрет для включения 2FA
+-- Незавершенное включение начинается заново; включенную 2FA
+-- условие в WHERE не перезаписывает (строка не возвращается)
+INSERT INTO user_totp (
+    user_id,
+    secret_encrypted
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (user_id) DO UPDATE
+SET secret_encrypted = EXCLUDED.secret_encrypted,
+    last_used_step = 0,
+    created_at = CURRENT_TIMESTAMP
+WHERE user_totp.enabled_at IS NULL
+RETURNING *;
+
+-- name: EnableUserTOTP :execrows
+-- Подтверждение включения 2FA первым кодом
+-- Шаг кода запоминается, чтобы его нельзя было сразу использовать для входа
+UPDATE user_totp
+SET enabled_at = CURRENT_TIMESTAMP,
+    last_used_step = sqlc.arg(step)
+WHERE user_id = sqlc.arg(user_id) AND enabled_at IS NULL;
+
+-- name: UseTOTPStep :execrows
+-- Фиксация использованного шага TOTP
+-- Условие в WHERE не дает принять один код дважды, в том числе
+-- в двух параллельных запросах
+UPDATE user_totp
+SET last_used_step = sqlc.arg(step)
+WHERE user_id = sqlc.arg(user_id)
+  AND enabled_at IS NOT NULL
+  AND last_used_step < sqlc.arg(step);
+
+-- name: DeleteRecoveryCodes :exec
+-- Удаление кодов восстановления перед выдачей нового набора
+DELETE FROM user_recovery_codes
+WHERE user_id = $1;
+
+-- name: CreateRecoveryCodes :exec
+-- Сохранение набора кодов восстановления (хеши)
+INSERT INTO user_recovery_codes (user_id, code_hash)
+SELECT sqlc.arg(user_id), unnest(sqlc.arg(code_hashes)::text[]);
+
+-- name: UseRecoveryCode :execrows
+-- Использование кода восстановления, каждый срабатывает один раз
+UPDATE user_recovery_codes
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CreateTwoFactorRecovery :one
+-- Новый запрос восстановления доступа
+-- Второй открытый запрос пользователя нарушит idx_two_factor_recoveries_active
+INSERT INTO two_factor_recoveries (
+    user_id,
+    approval_required
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: GetActiveTwoFactorRecovery :one
+-- Открытый запрос восстановления пользователя
+SELECT * FROM two_factor_recoveries
+WHERE user_id = $1 AND status IN ('email_pending', 'waiting')
+LIMIT 1;
+
+-- name: GetTwoFactorRecovery :one
+-- Запрос восстановления по ID
+SELECT * FROM two_factor_recoveries
+WHERE id = $1 LIMIT 1;
+
+-- name: ConfirmTwoFactorRecoveryEmail :one
+-- Подтверждение email: начинается период ожидания
+UPDATE two_factor_recoveries
+SET status = 'waiting',
+    email_confirmed_at = CURRENT_TIMESTAMP,
+    available_at = sqlc.arg(available_at)
+WHERE user_id = sqlc.arg(user_id) AND status = 'email_pending'
+RETURNING *;
+
+-- name: ApproveTwoFactorRecovery :one
+-- Одобрение администратором; повторное одобрение не меняет запрос
+UPDATE two_factor_recoveries
+SET approved_at = CURRENT_TIMESTAMP,
+    approved_by = sqlc.arg(approved_by)
+WHERE id = sqlc.arg(id)
+  AND status IN ('email_pending', 'waiting')
+  AND approval_required
+  AND approved_at IS NULL
+RETURNING *;
+
+-- name: ResolveTwoFactorRecovery :one
+-- Закрытие открытого запроса: completed, cancelled или rejected
+-- Условие в WHERE не дает закрыть запрос дважды
+UPDATE two_factor_recoveries
+SET status = sqlc.arg(status),
+    resolved_at = CURRENT_TIMESTAMP,
+    resolved_by = sqlc.narg(resolved_by)
+WHERE id = sqlc.arg(id) AND status IN ('email_pending', 'waiting')
+RETURNING *;
+
+-- name: ListActiveTwoFactorRecoveries :many
+-- Открытые запросы восстановления для администраторов, старые первыми
+SELECT
+    two_factor_recoveries.*,
+    users.public_id,
+    users.email,
+    users.username
+FROM two_factor_recoveries
+JOIN users ON users.id = two_factor_recoveries.user_id
+WHERE two_factor_recoveries.status IN ('email_pending', 'waiting')
+ORDER BY two_factor_recoveries.created_at, two_factor_recoveries.id;
+
+-- name: DeleteUserTOTP :exec
+-- Отключение 2FA: секрет и коды восстановления удаляются
+DELETE FROM user_totp
+WHERE user_id = $1;
+
+-- name: RevokeUserTwoFactor :execrows
+-- Отключение 2FA дубликата при слиянии: секрет и коды восстановления
+-- удаляются, открытые запросы восстановления отменяются
+-- Второй фактор на основной аккаунт не переносится: вход в него
+-- остается таким, каким его настроил владелец
+-- Возвращает число удаленных секретов (0 или 1)
+WITH codes AS (
+    DELETE FROM user_recovery_codes
+    WHERE user_id = $1
+), recoveries AS (
+    UPDATE two_factor_recoveries
+    SET status = 'cancelled',
+        resolved_at = CURRENT_TIMESTAMP
+    WHERE user_id = $1 AND status IN ('email_pending', 'waiting')
+)
+DELETE FROM user_totp
+WHERE user_id = $1;
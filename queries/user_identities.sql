@@ -0,0 +1,40 @@
+-- name: ListUserIdentities :many
+-- Привязанные внешние учетные записи пользователя
+SELECT * FROM user_identities
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: CreateUserIdentity :one
+-- Привязка внешней учетной записи
+-- Нарушение UNIQUE означает, что аккаунт провайдера уже привязан
+-- (к этому или другому пользователю)
+INSERT INTO user_identities (
+    user_id,
+    provider,
+    provider_user_id,
+    email
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: DeleteUserIdentity :execrows
+-- Отвязка внешней учетной записи
+DELETE FROM user_identities
+WHERE user_id = $1 AND provider = $2;
+
+-- name: CountUserIdentities :one
+-- Количество привязанных учетных записей (способов входа кроме пароля)
+SELECT COUNT(*) FROM user_identities
+WHERE user_id = $1;
+
+-- name: ReassignUserIdentities :execrows
+-- Перенос привязок дубликата на основной аккаунт при слиянии
+-- Привязки к провайдерам, которые уже есть у основного аккаунта, остаются
+-- у дубликата (ограничение user_identities_user_provider_key)
+UPDATE user_identities
+SET user_id = sqlc.arg('primary_id')
+WHERE user_id = sqlc.arg('duplicate_id')
+  AND provider NOT IN (
+      SELECT provider FROM user_identities
+      WHERE user_id = sqlc.arg('primary_id')
+  );
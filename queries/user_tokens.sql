@@ -0,0 +1,45 @@
+-- name: CreateUserToken :one
+-- Сохранение выданного одноразового токена
+INSERT INTO user_tokens (
+    user_id,
+    purpose,
+    token_hash,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ConsumeUserToken :one
+-- Использование токена: помечает его использованным и возвращает
+-- Условие в WHERE делает использование атомарным - из двух параллельных
+-- запросов с одним токеном строку получит только один
+UPDATE user_tokens
+SET used_at = CURRENT_TIMESTAMP
+WHERE token_hash = $1
+  AND purpose = $2
+  AND used_at IS NULL
+  AND expires_at > CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: InvalidateUserTokens :execrows
+-- Аннулирование неиспользованных токенов пользователя с этим назначением
+-- Вызывается при выдаче нового токена и после сброса пароля,
+-- чтобы действовала только последняя ссылка из писем
+UPDATE user_tokens
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL;
+
+-- name: ListUserTokensSince :many
+-- Ссылки подтверждения email и сброса пароля, выданные с момента since
+-- (для сводки активности). По used_at не выбираем: он ставится и при
+-- аннулировании ссылки, а не только при ее использовании
+SELECT * FROM user_tokens
+WHERE user_id = $1 AND created_at >= sqlc.arg(since)
+ORDER BY created_at;
+
+-- name: InvalidateAllUserTokens :execrows
+-- Аннулирование всех неиспользованных токенов пользователя
+-- Вызывается при слиянии: ссылки из писем дубликату больше не действуют
+UPDATE user_tokens
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND used_at IS NULL;
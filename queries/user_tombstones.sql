@@ -0,0 +1,15 @@
+-- name: ListUserTombstones :many
+-- Физически удаленные пользователи после позиции (deleted_at, id) для
+-- синхронизации клиентов. Последние секунды не отдаются - как в ListUserChanges
+SELECT * FROM user_tombstones
+WHERE (sqlc.narg('cursor_deleted_at')::timestamp IS NULL
+       OR (deleted_at, id) > (sqlc.narg('cursor_deleted_at')::timestamp, sqlc.narg('cursor_id')::bigint))
+  AND deleted_at < LOCALTIMESTAMP - INTERVAL '5 seconds'
+ORDER BY deleted_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: PurgeUserTombstones :execrows
+-- Удаление записей старше deleted_before (задание purge_user_tombstones)
+-- Клиент, не синхронизировавшийся дольше, загружает список заново
+DELETE FROM user_tombstones
+WHERE deleted_at < $1;
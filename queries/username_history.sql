@@ -0,0 +1,37 @@
+-- name: CreateUsernameHistory :exec
+-- Запись прежнего имени при переименовании
+INSERT INTO username_history (
+    user_id,
+    username
+) VALUES (
+    $1, $2
+);
+
+-- name: IsUsernameHeld :one
+-- Занято ли имя, недавно освобожденное другим пользователем
+-- Владелец может вернуть себе прежнее имя в любой момент
+SELECT EXISTS (
+    SELECT 1 FROM username_history
+    WHERE username = sqlc.arg(username)
+      AND user_id <> sqlc.arg(user_id)
+      AND changed_at > sqlc.arg(held_since)
+);
+
+-- name: GetRenamedUsername :one
+-- Текущее имя пользователя, который последним носил прежнее имя
+-- Удаленные и заблокированные аккаунты не находятся
+SELECT u.username FROM username_history h
+JOIN users u ON u.id = h.user_id
+WHERE h.username = $1
+  AND u.deleted_at IS NULL
+  AND u.status IN ('pending_verification', 'active')
+ORDER BY h.changed_at DESC
+LIMIT 1;
+
+-- name: ReassignUsernameHistory :execrows
+-- Перенос прежних имен дубликата на основной аккаунт при слиянии
+-- Профиль по прежнему имени дубликата находит основной аккаунт,
+-- и другой пользователь не займет имя раньше срока
+UPDATE username_history
+SET user_id = sqlc.arg('primary_id')
+WHERE user_id = sqlc.arg('duplicate_id');
@@ -2,41 +2,142 @@
 -- Создание нового пользователя
 -- :one означает что запрос вернет одну строку
 -- RETURNING * возвращает все поля созданной записи
+-- phone и country передаются уже нормализованными (E.164, ISO 3166-1 alpha-2),
+-- email - нормализованным вместе с каноническим (пакет emailaddr)
+-- Новый аккаунт ждет подтверждения email (см. MarkUserEmailVerified)
 INSERT INTO users (
     email,
     username,
     password_hash,
     first_name,
-    last_name
+    last_name,
+    phone,
+    country,
+    canonical_email,
+    status
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8, 'pending_verification'
 ) RETURNING *;
 
 -- name: GetUserByID :one
 -- Получение пользователя по ID
 -- sqlc автоматически создаст функцию с параметром типа int
+-- Мягко удаленные пользователи (deleted_at IS NOT NULL) во всех запросах ниже не видны
+SELECT * FROM users
+WHERE id = $1 AND deleted_at IS NULL LIMIT 1;
+
+-- name: GetUserByIDWithDeleted :one
+-- Получение пользователя по ID вместе с мягко удаленными
+-- Снимок для журнала аудита перед физическим удалением
 SELECT * FROM users
 WHERE id = $1 LIMIT 1;
 
+-- name: GetUserIDByPublicID :one
+-- Внутренний ID по публичному (из маршрутов API)
+-- Мягко удаленные тоже находятся: их видимость решают запросы по id,
+-- а физическое удаление должно работать и для них
+SELECT id FROM users
+WHERE public_id = $1 LIMIT 1;
+
 -- name: GetUserByEmail :one
 -- Получение пользователя по email
 -- Используется для аутентификации
+-- Ищет по каноническому адресу: ivan.petrov+shop@gmail.com находит
+-- ivanpetrov@gmail.com, если точки и метки отбрасываются. Строки, которые
+-- еще не заполнил backfill canonical-emails, ищутся по email, как раньше
 SELECT * FROM users
-WHERE email = $1 LIMIT 1;
+WHERE (canonical_email = sqlc.arg('canonical_email')
+       OR (canonical_email IS NULL AND email = sqlc.arg('email')))
+  AND deleted_at IS NULL
+LIMIT 1;
 
 -- name: GetUserByUsername :one
 -- Получение пользователя по username
 SELECT * FROM users
-WHERE username = $1 LIMIT 1;
+WHERE username = $1 AND deleted_at IS NULL LIMIT 1;
 
 -- name: ListUsers :many
--- Получение списка пользователей с пагинацией
+-- Получение списка пользователей с пагинацией, поиском, фильтрами и сортировкой
 -- :many означает что запрос вернет массив записей
--- $1 - limit (количество записей)
--- $2 - offset (смещение для пагинации)
+-- limit - количество записей, offset - смещение для пагинации
+-- Все фильтры опциональны: sqlc.narg делает параметр nullable, и NULL отключает фильтр
+-- search - подстрока email, username, имени или фамилии без учета регистра
+-- (спецсимволы LIKE экранирует сервис)
+-- statuses - состояния аккаунта (любое из списка)
+-- inactive_since - пользователи, не входившие с указанной даты
+-- (включая тех, кто не входил ни разу)
+-- visible_user_id - права вызывающего: только его собственная строка
+-- (NULL - все строки). Ограничение прав - условие запроса, а не фильтр
+-- страницы в памяти, поэтому страницы и total_count с ним согласованы
+-- sort_by и sort_desc выбирают сортировку через CASE: имя колонки не подставляется
+-- в текст запроса, неизвестное значение дает порядок по умолчанию (created_at DESC)
+SELECT * FROM users
+WHERE deleted_at IS NULL
+  AND (sqlc.narg('visible_user_id')::integer IS NULL OR id = sqlc.narg('visible_user_id')::integer)
+  AND (sqlc.narg('search')::text IS NULL
+       OR email ILIKE '%' || sqlc.narg('search')::text || '%'
+       OR username ILIKE '%' || sqlc.narg('search')::text || '%'
+       OR first_name ILIKE '%' || sqlc.narg('search')::text || '%'
+       OR last_name ILIKE '%' || sqlc.narg('search')::text || '%')
+  AND (sqlc.narg('statuses')::text[] IS NULL OR status = ANY(sqlc.narg('statuses')::text[]))
+  AND (sqlc.narg('created_after')::timestamp IS NULL OR created_at >= sqlc.narg('created_after')::timestamp)
+  AND (sqlc.narg('created_before')::timestamp IS NULL OR created_at < sqlc.narg('created_before')::timestamp)
+  AND (sqlc.narg('inactive_since')::timestamp IS NULL
+       OR last_login_at IS NULL
+       OR last_login_at < sqlc.narg('inactive_since')::timestamp)
+ORDER BY
+    CASE WHEN sqlc.arg('sort_by')::text = 'email' AND NOT sqlc.arg('sort_desc')::boolean THEN email END ASC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'email' AND sqlc.arg('sort_desc')::boolean THEN email END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'username' AND NOT sqlc.arg('sort_desc')::boolean THEN username END ASC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'username' AND sqlc.arg('sort_desc')::boolean THEN username END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'last_login_at' AND NOT sqlc.arg('sort_desc')::boolean THEN last_login_at END ASC NULLS FIRST,
+    CASE WHEN sqlc.arg('sort_by')::text = 'last_login_at' AND sqlc.arg('sort_desc')::boolean THEN last_login_at END DESC NULLS LAST,
+    CASE WHEN sqlc.arg('sort_by')::text = 'created_at' AND NOT sqlc.arg('sort_desc')::boolean THEN created_at END ASC,
+    -- Последние ключи - порядок по умолчанию и однозначный порядок
+    -- при равных значениях, иначе страницы пересекались бы
+    created_at DESC,
+    id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListUsersAfter :many
+-- Страница списка пользователей после курсора (keyset пагинация)
+-- В отличие от ListUsers не использует OFFSET: позиция задается парой
+-- (created_at, id) последней записи предыдущей страницы, и запрос читает
+-- индекс idx_users_keyset сразу с нее. Без курсора (NULL) - первая страница.
+-- Фильтры совпадают с ListUsers, порядок только created_at DESC, id DESC
+SELECT * FROM users
+WHERE deleted_at IS NULL
+  AND (sqlc.narg('visible_user_id')::integer IS NULL OR id = sqlc.narg('visible_user_id')::integer)
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+       OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::integer))
+  AND (sqlc.narg('search')::text IS NULL
+       OR email ILIKE '%' || sqlc.narg('search')::text || '%'
+       OR username ILIKE '%' || sqlc.narg('search')::text || '%'
+       OR first_name ILIKE '%' || sqlc.narg('search')::text || '%'
+       OR last_name ILIKE '%' || sqlc.narg('search')::text || '%')
+  AND (sqlc.narg('statuses')::text[] IS NULL OR status = ANY(sqlc.narg('statuses')::text[]))
+  AND (sqlc.narg('created_after')::timestamp IS NULL OR created_at >= sqlc.narg('created_after')::timestamp)
+  AND (sqlc.narg('created_before')::timestamp IS NULL OR created_at < sqlc.narg('created_before')::timestamp)
+  AND (sqlc.narg('inactive_since')::timestamp IS NULL
+       OR last_login_at IS NULL
+       OR last_login_at < sqlc.narg('inactive_since')::timestamp)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListUserChanges :many
+-- Пользователи, измененные после позиции (updated_at, id), для синхронизации
+-- клиентов (GET /api/v1/users/changes). Мягко удаленные тоже попадают в
+-- выборку: по deleted_at клиент удаляет их у себя. Без курсора (NULL) - с начала.
+-- Последние секунды не отдаются: updated_at - время начала транзакции, и
+-- транзакция, зафиксированная позже, записала бы изменение за позицию,
+-- которую клиент уже прошел. Права вызывающего - как у ListUsers
 SELECT * FROM users
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2;
+WHERE (sqlc.narg('cursor_updated_at')::timestamp IS NULL
+       OR (updated_at, id) > (sqlc.narg('cursor_updated_at')::timestamp, sqlc.narg('cursor_id')::integer))
+  AND updated_at < LOCALTIMESTAMP - INTERVAL '5 seconds'
+  AND (sqlc.narg('visible_user_id')::integer IS NULL OR id = sqlc.narg('visible_user_id')::integer)
+ORDER BY updated_at, id
+LIMIT sqlc.arg('limit');
 
 -- name: UpdateUser :one
 -- Обновление данных пользователя
@@ -45,12 +146,18 @@ LIMIT $1 OFFSET $2;
 UPDATE users
 SET
     email = COALESCE($2, email),
+    canonical_email = COALESCE(sqlc.narg('canonical_email'), canonical_email),
     username = COALESCE($3, username),
     first_name = COALESCE($4, first_name),
     last_name = COALESCE($5, last_name),
-    is_active = COALESCE($6, is_active),
+    -- Пустая строка очищает телефон и страну, NULL оставляет как есть
+    phone = CASE WHEN sqlc.narg('phone')::text IS NULL THEN phone ELSE NULLIF(sqlc.narg('phone')::text, '') END,
+    country = CASE WHEN sqlc.narg('country')::text IS NULL THEN country ELSE NULLIF(sqlc.narg('country')::text, '') END,
+    -- Новый email нужно подтвердить заново: при смене email
+    -- CASE без ELSE сбрасывает отметку о подтверждении в NULL
+    email_verified_at = CASE WHEN COALESCE($2, email) = email THEN email_verified_at END,
     updated_at = CURRENT_TIMESTAMP
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 RETURNING *;
 
 -- name: UpdateUserPassword :exec
@@ -60,29 +167,269 @@ UPDATE users
 SET
     password_hash = $2,
     updated_at = CURRENT_TIMESTAMP
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: RecordUserLogin :exec
+-- Фиксация успешного входа пользователя
+-- updated_at не трогаем - вход не является изменением профиля
+UPDATE users
+SET
+    last_login_at = CURRENT_TIMESTAMP,
+    login_count = login_count + 1
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: DeleteUser :exec
 -- Удаление пользователя (физическое удаление)
--- В production часто используют soft delete (is_active = false)
-DELETE FROM users
-WHERE id = $1;
+-- Удаляет и мягко удаленных пользователей - используется для окончательной очистки
+-- Тем же запросом оставляет запись в user_tombstones: без нее клиенты
+-- синхронизации (ListUserChanges) не узнали бы об удалении
+WITH deleted AS (
+    DELETE FROM users
+    WHERE id = $1
+    RETURNING public_id
+)
+INSERT INTO user_tombstones (public_id)
+SELECT public_id FROM deleted;
 
--- name: DeactivateUser :exec
--- Деактивация пользователя (soft delete)
--- Предпочтительный способ "удаления" в production
+-- name: SoftDeleteUser :execrows
+-- Мягкое удаление пользователя
+-- Строка остается в таблице, а email и username освобождаются для новых
+-- регистраций благодаря частичным уникальным индексам (WHERE deleted_at IS NULL)
+-- :execrows возвращает число обновленных строк, чтобы отличить "не найден"
 UPDATE users
 SET
-    is_active = false,
+    status = 'deleted',
+    deleted_at = CURRENT_TIMESTAMP,
     updated_at = CURRENT_TIMESTAMP
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: SetUserStatus :one
+-- Смена состояния аккаунта (suspended, deactivated, active)
+-- Допустимость перехода проверяет сервис (internal/lifecycle) по строке,
+-- заблокированной GetUserForUpdate. Удаление - SoftDeleteUser
+UPDATE users
+SET
+    status = sqlc.arg('status'),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg('id') AND deleted_at IS NULL
+RETURNING *;
 
 -- name: CountUsers :one
 -- Подсчет общего количества пользователей
--- Полезно для пагинации
-SELECT COUNT(*) FROM users;
+-- Полезно для пагинации, фильтры должны совпадать с ListUsers
+SELECT COUNT(*) FROM users
+WHERE deleted_at IS NULL
+  AND (sqlc.narg('visible_user_id')::integer IS NULL OR id = sqlc.narg('visible_user_id')::integer)
+  AND (sqlc.narg('search')::text IS NULL
+       OR email ILIKE '%' || sqlc.narg('search')::text || '%'
+       OR username ILIKE '%' || sqlc.narg('search')::text || '%'
+       OR first_name ILIKE '%' || sqlc.narg('search')::text || '%'
+       OR last_name ILIKE '%' || sqlc.narg('search')::text || '%')
+  AND (sqlc.narg('statuses')::text[] IS NULL OR status = ANY(sqlc.narg('statuses')::text[]))
+  AND (sqlc.narg('created_after')::timestamp IS NULL OR created_at >= sqlc.narg('created_after')::timestamp)
+  AND (sqlc.narg('created_before')::timestamp IS NULL OR created_at < sqlc.narg('created_before')::timestamp)
+  AND (sqlc.narg('inactive_since')::timestamp IS NULL
+       OR last_login_at IS NULL
+       OR last_login_at < sqlc.narg('inactive_since')::timestamp);
 
 -- name: CountActiveUsers :one
--- Подсчет активных пользователей
+-- Подсчет пользователей, которые могут входить (lifecycle.Status.CanSignIn)
+SELECT COUNT(*) FROM users
+WHERE status IN ('pending_verification', 'active') AND deleted_at IS NULL;
+
+-- name: ImportUser :one
+-- Создание пользователя из внешней системы
+-- В отличие от CreateUser сохраняет исходную дату создания
+-- и уже готовый bcrypt хеш пароля
+-- Состояние - active по умолчанию: email подтверждала внешняя система
+INSERT INTO users (
+    email,
+    username,
+    password_hash,
+    first_name,
+    last_name,
+    created_at,
+    updated_at,
+    canonical_email
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $6, $7
+) RETURNING *;
+
+-- name: BulkCreateUsers :many
+-- Пакетное создание пользователей одним запросом (POST /api/v1/users/import)
+-- Массивы одной длины: i-е элементы - поля i-го пользователя,
+-- пустые first_name и last_name сохраняются как NULL.
+-- Строки с занятым email, каноническим email или username (в том числе
+-- повтор внутри пачки) пропускаются: RETURNING возвращает только созданных
+-- пользователей. Состояние - active по умолчанию, как у ImportUser
+INSERT INTO users (
+    email,
+    canonical_email,
+    username,
+    password_hash,
+    first_name,
+    last_name
+)
+SELECT
+    batch.email,
+    batch.canonical_email,
+    batch.username,
+    batch.password_hash,
+    NULLIF(batch.first_name, ''),
+    NULLIF(batch.last_name, '')
+FROM unnest(
+    sqlc.arg('emails')::text[],
+    sqlc.arg('canonical_emails')::text[],
+    sqlc.arg('usernames')::text[],
+    sqlc.arg('password_hashes')::text[],
+    sqlc.arg('first_names')::text[],
+    sqlc.arg('last_names')::text[]
+) AS batch(email, canonical_email, username, password_hash, first_name, last_name)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetUserForUpdate :one
+-- Получение пользователя с блокировкой строки до конца транзакции
+-- Используется операциями, которые меняют несколько пользователей сразу (слияние)
+SELECT * FROM users
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: MergeUserActivity :one
+-- Перенос активности дубликата в основной аккаунт при слиянии
+-- Счетчики складываются, даты берутся крайние, пустые поля профиля
+-- и аватар заполняются значениями дубликата. GREATEST/LEAST в PostgreSQL
+-- игнорируют NULL
+UPDATE users
+SET
+    login_count = login_count + sqlc.arg('login_count'),
+    last_login_at = GREATEST(last_login_at, sqlc.narg('last_login_at')),
+    first_name = COALESCE(first_name, sqlc.narg('first_name')),
+    last_name = COALESCE(last_name, sqlc.narg('last_name')),
+    avatar_key = COALESCE(avatar_key, sqlc.narg('avatar_key')),
+    created_at = LEAST(created_at, sqlc.arg('created_at')),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg('id') AND deleted_at IS NULL
+RETURNING *;
+
+-- name: UpdateUserRole :one
+-- Назначение роли пользователю
+-- Несуществующая роль отклоняется внешним ключом на roles(name)
+UPDATE users
+SET
+    role = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: MarkUserEmailVerified :one
+-- Отметка о подтверждении email
+-- Повторное подтверждение не меняет исходный момент. Подтверждение
+-- переводит аккаунт из pending_verification в active, другие состояния
+-- (например suspended) не меняются
+UPDATE users
+SET
+    email_verified_at = COALESCE(email_verified_at, CURRENT_TIMESTAMP),
+    status = CASE WHEN status = 'pending_verification' THEN 'active' ELSE status END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: SetUserAvatar :one
+-- Замена аватара: ключ нового файла в хранилище, NULL - удалить аватар
+-- Старый файл удаляет сервис после успешного обновления
+UPDATE users
+SET
+    avatar_key = sqlc.narg('avatar_key'),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg('id') AND deleted_at IS NULL
+RETURNING *;
+
+-- name: UpdateUserPrivacy :one
+-- Настройки приватности публичного профиля, NULL - оставить как есть
+UPDATE users
+SET
+    show_name = COALESCE(sqlc.narg('show_name'), show_name),
+    show_avatar = COALESCE(sqlc.narg('show_avatar'), show_avatar),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg('id') AND deleted_at IS NULL
+RETURNING *;
+
+-- name: CountBroadcastRecipients :one
+-- Размер сегмента рассылки: пользователи, которые могут входить, с фильтрами ListUsers
 SELECT COUNT(*) FROM users
-WHERE is_active = true;
+WHERE deleted_at IS NULL
+  AND status IN ('pending_verification', 'active')
+  AND (sqlc.narg('inactive_since')::timestamp IS NULL
+       OR last_login_at IS NULL
+       OR last_login_at < sqlc.narg('inactive_since')::timestamp);
+
+-- name: ListBroadcastRecipients :many
+-- Следующая пачка получателей рассылки после курсора after_id
+-- Фильтры совпадают с ListUsers, заблокированные и деактивированные пропускаются.
+-- Порядок по id, а не по created_at: курсор должен быть однозначным
+SELECT * FROM users
+WHERE deleted_at IS NULL
+  AND status IN ('pending_verification', 'active')
+  AND id > sqlc.arg('after_id')
+  AND (sqlc.narg('inactive_since')::timestamp IS NULL
+       OR last_login_at IS NULL
+       OR last_login_at < sqlc.narg('inactive_since')::timestamp)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: ListInactiveUserIDs :many
+-- Пользователи, которые не входили с inactive_since (задание deactivate_inactive_users)
+-- Никогда не входившие считаются по дате регистрации. Администраторы
+-- не деактивируются автоматически: без них некому вернуть доступ
+SELECT id FROM users
+WHERE deleted_at IS NULL
+  AND status IN ('pending_verification', 'active')
+  AND role <> 'admin'
+  AND COALESCE(last_login_at, created_at) < sqlc.arg('inactive_since')
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: ListUserNamesAfter :many
+-- Имена следующей пачки пользователей после курсора after_id (заполнение
+-- sanitize-user-names). Удаленные тоже: имена хранятся и у них
+SELECT id, first_name, last_name FROM users
+WHERE id > sqlc.arg('after_id')
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateUserNames :exec
+-- Очищенные имя и фамилия (заполнение sanitize-user-names)
+UPDATE users
+SET
+    first_name = $2,
+    last_name = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: ListUserEmailsAfter :many
+-- Адреса следующей пачки пользователей после курсора after_id (заполнение
+-- canonical-emails). Удаленные тоже: при восстановлении адрес должен быть
+-- каноническим
+SELECT id, email, canonical_email, deleted_at FROM users
+WHERE id > sqlc.arg('after_id')
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateUserEmailCanonical :execrows
+-- Нормализованный и канонический email (заполнение canonical-emails)
+-- Строка не меняется (0 строк), если адрес уже занят другим неудаленным
+-- пользователем: такие дубликаты разбираются вручную. Проверка в том же
+-- запросе, потому что ошибка уникальности прервала бы транзакцию пачки
+UPDATE users
+SET
+    email = sqlc.arg('email'),
+    canonical_email = sqlc.arg('canonical_email'),
+    updated_at = CASE WHEN email = sqlc.arg('email') THEN updated_at ELSE CURRENT_TIMESTAMP END
+WHERE users.id = sqlc.arg('id')
+  AND (users.deleted_at IS NOT NULL OR NOT EXISTS (
+      SELECT 1 FROM users other
+      WHERE other.id <> users.id
+        AND other.deleted_at IS NULL
+        AND (other.email = sqlc.arg('email') OR other.canonical_email = sqlc.arg('canonical_email'))
+  ));
@@ -0,0 +1,81 @@
+-- name: CreateWebhook :one
+-- Регистрация подписки на события пользователей
+INSERT INTO webhooks (
+    url,
+    events,
+    secret,
+    created_by
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListWebhooks :many
+-- Все подписки для админки
+SELECT * FROM webhooks
+ORDER BY id;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = $1;
+
+-- name: DeleteWebhook :execrows
+-- Удаление подписки вместе с журналом доставки (ON DELETE CASCADE)
+DELETE FROM webhooks
+WHERE id = $1;
+
+-- name: ListWebhooksForEvent :many
+-- Подписки, которые получают событие
+SELECT * FROM webhooks
+WHERE sqlc.arg(event)::text = ANY(events)
+ORDER BY id;
+
+-- name: CreateWebhookDelivery :one
+-- Событие для подписчика в статусе pending
+-- Отправляет его задание очереди jobs (webhook_delivery)
+INSERT INTO webhook_deliveries (
+    webhook_id,
+    event,
+    payload
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetWebhookDelivery :one
+-- Событие вместе с адресом и ключом подписки для отправки
+SELECT
+    d.id,
+    d.event,
+    d.payload,
+    d.status,
+    w.url,
+    w.secret
+FROM webhook_deliveries d
+JOIN webhooks w ON w.id = d.webhook_id
+WHERE d.id = $1;
+
+-- name: MarkWebhookDelivered :exec
+-- Подписчик ответил 2xx
+UPDATE webhook_deliveries
+SET status = 'delivered',
+    attempts = attempts + 1,
+    response_status = $2,
+    last_error = NULL,
+    delivered_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: RecordWebhookAttempt :exec
+-- Неудачная попытка доставки
+-- status остается pending до последней попытки, затем становится failed
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = attempts + 1,
+    response_status = $3,
+    last_error = $4
+WHERE id = $1;
+
+-- name: ListWebhookDeliveries :many
+-- Журнал доставки подписки от новых событий к старым
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2;